@@ -1,7 +1,8 @@
 package main
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -9,25 +10,46 @@ import (
 
 	"github.com/casualdoto/go-currency-tracker/microservices/api-gateway/internal/config"
 	"github.com/casualdoto/go-currency-tracker/microservices/api-gateway/internal/gateway"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/logging"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/tracing"
 )
 
 func main() {
 	cfg := config.Load()
 
+	logging.Setup("api-gateway")
+	shutdownTracing, err := tracing.Setup(context.Background(), "api-gateway")
+	if err != nil {
+		slog.Error("tracing setup failed", "error", err)
+		os.Exit(1)
+	}
+	defer shutdownTracing(context.Background())
+
 	gw := gateway.New(cfg)
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if cfg.KafkaBrokers != "" {
+		go func() {
+			if err := gw.ConsumeRates(ctx); err != nil {
+				slog.Error("rate stream consumer stopped", "error", err)
+			}
+		}()
+	}
+
 	addr := ":" + cfg.ServerPort
-	log.Printf("API Gateway listening on %s", addr)
+	slog.Info("http listening", "addr", addr)
 
 	srv := &http.Server{Addr: addr, Handler: gw.Routes()}
 	go func() {
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("server error: %v", err)
+			slog.Error("http server failed", "error", err)
+			os.Exit(1)
 		}
 	}()
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	log.Println("API Gateway: shutting down")
+	slog.Info("shutting down")
 }
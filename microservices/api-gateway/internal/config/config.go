@@ -6,6 +6,17 @@ type Config struct {
 	HistoryServiceURL      string
 	NotificationServiceURL string
 	ServerPort             string
+	// KafkaBrokers feeds the live rate stream; empty disables the consumer.
+	KafkaBrokers string
+	// HistoryGRPCAddr and NotificationGRPCAddr (host:port) make the gateway
+	// answer the /v1 rate and subscription routes over the internal gRPC API
+	// instead of proxying them; empty keeps the HTTP proxy.
+	HistoryGRPCAddr      string
+	NotificationGRPCAddr string
+	// StatusServices lists further services shown by /status as
+	// comma-separated name=base URL pairs, e.g.
+	// data-collector=http://data-collector:9081; each must serve /readyz.
+	StatusServices string
 }
 
 func Load() *Config {
@@ -13,6 +24,10 @@ func Load() *Config {
 		HistoryServiceURL:      getEnv("HISTORY_SERVICE_URL", "http://localhost:8084"),
 		NotificationServiceURL: getEnv("NOTIFICATION_SERVICE_URL", "http://localhost:8085"),
 		ServerPort:             getEnv("SERVER_PORT", "8080"),
+		KafkaBrokers:           getEnv("KAFKA_BROKERS", "localhost:9092"),
+		HistoryGRPCAddr:        os.Getenv("HISTORY_GRPC_ADDR"),
+		NotificationGRPCAddr:   os.Getenv("NOTIFICATION_GRPC_ADDR"),
+		StatusServices:         os.Getenv("STATUS_SERVICES"),
 	}
 }
 
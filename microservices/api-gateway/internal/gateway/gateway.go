@@ -1,56 +1,205 @@
 package gateway
 
 import (
+	"context"
+	"encoding/json"
 	"io"
-	"log"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/casualdoto/go-currency-tracker/microservices/api-gateway/internal/config"
+	"github.com/casualdoto/go-currency-tracker/microservices/api-gateway/internal/gql"
+	"github.com/casualdoto/go-currency-tracker/microservices/api-gateway/internal/openapi"
+	"github.com/casualdoto/go-currency-tracker/microservices/api-gateway/internal/stream"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/health"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/logging"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/metrics"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/pkg/client"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/rpcv1"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/tracing"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"google.golang.org/grpc"
 )
 
-// Gateway holds service URLs and the HTTP client.
+// upstreamTimeout bounds one upstream call. History-service crypto range can
+// chain two Binance calls plus ClickHouse; 30s caused frequent gateway timeouts.
+const upstreamTimeout = 120 * time.Second
+
+var logger = logging.For("gateway")
+
+// fatal logs a configuration error the gateway cannot start with and exits.
+func fatal(msg string, args ...any) {
+	logger.Error(msg, args...)
+	os.Exit(1)
+}
+
+// Gateway holds service URLs, the HTTP client, the API client used for
+// GraphQL and gRPC-backed routes, the live rate stream and the GraphQL
+// handler.
 type Gateway struct {
 	cfg        *config.Config
 	httpClient *http.Client
+	stream     *stream.Hub
+	graphql    *gql.Handler
+	// api reaches the backends over gRPC where a connection is configured
+	api *client.Client
+	// historyRPC and subscriptionsRPC report whether api uses gRPC for the
+	// rate and the subscription calls
+	historyRPC       bool
+	subscriptionsRPC bool
+	// ready backs /readyz and status the aggregated /status
+	ready  *health.Checker
+	status *health.Checker
 }
 
 func New(cfg *config.Config) *Gateway {
-	// History-service crypto range can chain two Binance calls plus ClickHouse; 30s caused frequent gateway timeouts.
+	history, err := dialOptional(cfg.HistoryGRPCAddr)
+	if err != nil {
+		fatal("invalid history-service gRPC address", "error", err)
+	}
+	subscriptions, err := dialOptional(cfg.NotificationGRPCAddr)
+	if err != nil {
+		fatal("invalid notification-service gRPC address", "error", err)
+	}
+	hc := &http.Client{Timeout: upstreamTimeout, Transport: tracing.Transport(logging.Transport(nil))}
+	return newGateway(cfg, hc, history, subscriptions)
+}
+
+// dialOptional connects to addr, or returns nil when addr is empty.
+func dialOptional(addr string) (grpc.ClientConnInterface, error) {
+	if addr == "" {
+		return nil, nil
+	}
+	return rpcv1.Dial(addr)
+}
+
+// newGateway builds a gateway; nil connections keep the matching routes on
+// the HTTP proxy.
+func newGateway(cfg *config.Config, hc *http.Client, history, subscriptions grpc.ClientConnInterface) *Gateway {
+	api := client.New(cfg.HistoryServiceURL,
+		client.WithNotificationsURL(cfg.NotificationServiceURL),
+		client.WithHTTPClient(hc),
+		client.WithGRPC(history, subscriptions),
+		client.WithCallTimeout(upstreamTimeout))
 	return &Gateway{
-		cfg:        cfg,
-		httpClient: &http.Client{Timeout: 120 * time.Second},
+		cfg:              cfg,
+		httpClient:       hc,
+		stream:           stream.NewHub(stream.DefaultHistorySize),
+		graphql:          newGraphQL(api),
+		api:              api,
+		historyRPC:       history != nil,
+		subscriptionsRPC: subscriptions != nil,
+		ready:            readiness(cfg, hc),
+		status:           upstreamStatus(cfg, hc),
+	}
+}
+
+// newGraphQL returns the GraphQL handler; its resolvers reach history-service
+// and notification-service directly through c.
+func newGraphQL(c *client.Client) *gql.Handler {
+	h, err := gql.NewHandler(c)
+	if err != nil {
+		fatal("invalid GraphQL schema", "error", err)
 	}
+	return h
+}
+
+// ConsumeRates feeds the live rate stream from Kafka until ctx is done.
+func (g *Gateway) ConsumeRates(ctx context.Context) error {
+	return stream.Consume(ctx, g.cfg.KafkaBrokers, g.stream)
 }
 
 // Routes builds and returns the chi router.
 func (g *Gateway) Routes() http.Handler {
+	spec, err := openapi.Load()
+	if err != nil {
+		fatal("invalid OpenAPI document", "error", err)
+	}
+
 	r := chi.NewRouter()
-	r.Use(middleware.Logger)
+	// The request ID of every call is generated here unless the client sent one
+	r.Use(tracing.Middleware)
+	r.Use(logging.Middleware)
+	r.Use(metrics.Middleware)
 	r.Use(middleware.Recoverer)
 	r.Use(corsMiddleware)
+	r.Use(validateRequests(spec))
+	// Innermost: decimals as JSON numbers for ?decimals=number
+	r.Use(money.Middleware)
 
-	// Health check
+	// Health checks; /status shows the readiness of every upstream service
 	r.Get("/ping", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("pong"))
 	})
+	r.Get("/healthz", health.Live)
+	r.Get("/readyz", g.ready.Ready)
+	r.Get("/status", g.status.Ready)
+
+	// Prometheus metrics of the gateway itself
+	r.Method(http.MethodGet, "/metrics", metrics.Handler())
+
+	// API documentation; every route below must be described in the document
+	r.Get("/api/openapi", openAPIHandler)
+	r.Get("/api/docs", swaggerUIHandler)
+	r.Get("/api", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/api/docs", http.StatusFound)
+	})
 
 	// History routes — public (read-only data)
 	r.Mount("/history", g.reverseProxy(g.cfg.HistoryServiceURL, "/history"))
 
-	// Current rates via History Service
-	r.Get("/rates/cbr", g.proxyTo(g.cfg.HistoryServiceURL+"/history/cbr"))
-	r.Get("/rates/cbr/range", g.proxyTo(g.cfg.HistoryServiceURL+"/history/cbr/range"))
-	r.Get("/rates/crypto/symbols", g.proxyTo(g.cfg.HistoryServiceURL+"/history/crypto/symbols"))
-	r.Get("/rates/crypto/history", g.proxyTo(g.cfg.HistoryServiceURL+"/history/crypto"))
-	r.Get("/rates/crypto/history/range", g.proxyTo(g.cfg.HistoryServiceURL+"/history/crypto/range"))
+	// Live rate updates, served by the gateway itself
+	r.Get("/v1/stream", g.stream.ServeSSE)
+	r.Get("/v1/stream/ws", g.stream.ServeWS)
+
+	// GraphQL, resolved by the gateway against the backends
+	r.Get("/graphql", g.graphql.ServeHTTP)
+	r.Post("/graphql", g.graphql.ServeHTTP)
+
+	// Versioned API: served by history-service under the same paths. With a
+	// gRPC connection the gateway answers the rate routes itself.
+	if g.historyRPC {
+		r.Get("/v1/rates/cbr", g.v1CBRRates)
+		r.Get("/v1/rates/cbr/range", g.v1CBRRange)
+		r.Get("/v1/rates/crypto/symbols", g.v1CryptoSymbols)
+		r.Get("/v1/rates/crypto/range", g.v1CryptoRange)
+		r.Get("/v1/convert", g.v1Convert)
+	}
+	r.Mount("/v1", g.v1Proxy(g.cfg.HistoryServiceURL))
+
+	// Current rates via History Service. These return storage structs and are
+	// kept as deprecated aliases of their /v1 successors.
+	r.Get("/rates/cbr", deprecated("/v1/rates/cbr", g.proxyTo(g.cfg.HistoryServiceURL+"/history/cbr")))
+	r.Get("/rates/cbr/range", deprecated("/v1/rates/cbr/range", g.proxyTo(g.cfg.HistoryServiceURL+"/history/cbr/range")))
+	r.Get("/rates/crypto/symbols", deprecated("/v1/rates/crypto/symbols", g.proxyTo(g.cfg.HistoryServiceURL+"/history/crypto/symbols")))
+	r.Get("/rates/crypto/history", deprecated("/v1/rates/crypto/range", g.proxyTo(g.cfg.HistoryServiceURL+"/history/crypto")))
+	r.Get("/rates/crypto/history/range", deprecated("/v1/rates/crypto/range", g.proxyTo(g.cfg.HistoryServiceURL+"/history/crypto/range")))
+	r.Get("/rates/crypto/indicators", deprecated("/v1/rates/crypto/indicators", g.proxyTo(g.cfg.HistoryServiceURL+"/history/crypto/indicators")))
+	r.Get("/rates/convert", deprecated("/v1/convert", g.proxyTo(g.cfg.HistoryServiceURL+"/history/convert")))
+	r.Get("/rates/analytics", deprecated("/v1/analytics", g.proxyTo(g.cfg.HistoryServiceURL+"/history/analytics")))
+	r.Get("/rates/analytics/correlation", deprecated("/v1/analytics/correlation", g.proxyTo(g.cfg.HistoryServiceURL+"/history/analytics/correlation")))
+	r.Get("/rates/cbr/revisions", deprecated("/v1/rates/cbr/revisions", g.proxyTo(g.cfg.HistoryServiceURL+"/history/cbr/revisions")))
+	r.Get("/rates/cbr/nominal-changes", deprecated("/v1/rates/cbr/nominal-changes", g.proxyTo(g.cfg.HistoryServiceURL+"/history/cbr/nominal-changes")))
+
+	// Bulk exports stream for as long as the upstream keeps sending rows
+	r.Get("/rates/cbr/export", g.streamTo(g.cfg.HistoryServiceURL+"/history/cbr/export"))
+	r.Get("/rates/crypto/export", g.streamTo(g.cfg.HistoryServiceURL+"/history/crypto/export"))
 
-	// Notification / subscription routes
+	// Notification / subscription routes; subscriptions over gRPC when connected
+	if g.subscriptionsRPC {
+		for _, kind := range []client.SubscriptionKind{client.CBRSubscriptions, client.CryptoSubscriptions, client.MetalSubscriptions, client.IndicatorSubscriptions, client.AlertSubscriptions} {
+			path := "/notifications/subscriptions/" + string(kind)
+			r.Get(path, g.listSubscriptions(kind))
+			r.Post(path, g.updateSubscription(kind, g.api.Subscribe))
+			r.Delete(path, g.updateSubscription(kind, g.api.Unsubscribe))
+		}
+	}
 	r.Mount("/notifications", g.reverseProxy(g.cfg.NotificationServiceURL, "/notifications"))
 
 	return r
@@ -60,11 +209,12 @@ func (g *Gateway) Routes() http.Handler {
 func (g *Gateway) reverseProxy(targetBase, stripPrefix string) http.Handler {
 	target, err := url.Parse(targetBase)
 	if err != nil {
-		log.Fatalf("invalid upstream URL %q: %v", targetBase, err)
+		fatal("invalid upstream URL", "url", targetBase, "error", err)
 	}
 	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.Transport = tracing.Transport(nil)
 	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
-		log.Printf("proxy error for %s: %v", r.URL, err)
+		logger.WarnContext(r.Context(), "proxy failed", "upstream", target.Host, "error", err)
 		http.Error(w, "upstream unavailable", http.StatusBadGateway)
 	}
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -77,10 +227,39 @@ func (g *Gateway) reverseProxy(targetBase, stripPrefix string) http.Handler {
 	})
 }
 
+// v1Proxy forwards /v1 requests with their path unchanged. Upstream failures
+// are reported in the v1 error format.
+func (g *Gateway) v1Proxy(targetBase string) http.Handler {
+	target, err := url.Parse(targetBase)
+	if err != nil {
+		fatal("invalid upstream URL", "url", targetBase, "error", err)
+	}
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.Transport = tracing.Transport(nil)
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		logger.WarnContext(r.Context(), "proxy failed", "upstream", target.Host, "error", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(`{"error":{"code":"unavailable","message":"upstream unavailable"}}` + "\n"))
+	}
+	return proxy
+}
+
+// deprecated marks a legacy route with the Deprecation header and a Link to
+// its /v1 successor.
+func deprecated(successor string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+successor+`>; rel="successor-version"`)
+		next(w, r)
+	}
+}
+
 // proxyTo forwards the request to the given full URL, preserving query params.
+// The upstream call joins the trace of the request.
 func (g *Gateway) proxyTo(targetURL string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		req, err := http.NewRequest(r.Method, targetURL+"?"+r.URL.RawQuery, r.Body)
+		req, err := http.NewRequestWithContext(r.Context(), r.Method, targetURL+"?"+r.URL.RawQuery, r.Body)
 		if err != nil {
 			http.Error(w, "failed to build upstream request", http.StatusInternalServerError)
 			return
@@ -89,7 +268,7 @@ func (g *Gateway) proxyTo(targetURL string) http.HandlerFunc {
 
 		resp, err := g.httpClient.Do(req)
 		if err != nil {
-			log.Printf("proxyTo %s error: %v", targetURL, err)
+			logger.WarnContext(r.Context(), "proxy failed", "upstream", targetURL, "error", err)
 			http.Error(w, "upstream unavailable", http.StatusBadGateway)
 			return
 		}
@@ -105,11 +284,77 @@ func (g *Gateway) proxyTo(targetURL string) http.HandlerFunc {
 	}
 }
 
+// streamTo forwards the request to the given full URL like proxyTo, but copies
+// the response as it arrives and without the client timeout, so long exports
+// are neither buffered nor cut off. A client disconnect cancels the upstream.
+func (g *Gateway) streamTo(targetURL string) http.HandlerFunc {
+	target, err := url.Parse(targetURL)
+	if err != nil {
+		fatal("invalid upstream URL", "url", targetURL, "error", err)
+	}
+	proxy := &httputil.ReverseProxy{
+		Director: func(r *http.Request) {
+			r.URL.Scheme = target.Scheme
+			r.URL.Host = target.Host
+			r.URL.Path = target.Path
+			r.Host = target.Host
+		},
+		Transport:     tracing.Transport(nil),
+		FlushInterval: -1,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			logger.WarnContext(r.Context(), "proxy failed", "upstream", targetURL, "error", err)
+			http.Error(w, "upstream unavailable", http.StatusBadGateway)
+		},
+	}
+	return proxy.ServeHTTP
+}
+
+// validateRequests rejects requests whose query parameters or JSON body do not
+// match their operation in spec with a 400 in the /v1 error format, before
+// anything is proxied. Paths the document does not describe are left to the
+// router.
+func validateRequests(spec *openapi.Spec) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			op, params := spec.Find(r.Method, r.URL.Path)
+			if op == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if problems := op.Validate(r, params); len(problems) > 0 {
+				writeBadRequest(w, problems)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// validationError is the /v1 error body plus every failed check.
+type validationError struct {
+	Error struct {
+		Code    string   `json:"code"`
+		Message string   `json:"message"`
+		Details []string `json:"details"`
+	} `json:"error"`
+}
+
+func writeBadRequest(w http.ResponseWriter, problems []string) {
+	var body validationError
+	body.Error.Code = "bad_request"
+	body.Error.Message = strings.Join(problems, "; ")
+	body.Error.Details = problems
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(body)
+}
+
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET,POST,PUT,DELETE,OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type,Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type,Authorization,X-Request-ID")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID")
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusNoContent)
 			return
@@ -1,6 +1,8 @@
 package gateway
 
 import (
+	"bufio"
+	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -8,18 +10,24 @@ import (
 	"testing"
 
 	"github.com/casualdoto/go-currency-tracker/microservices/api-gateway/internal/config"
+	"github.com/casualdoto/go-currency-tracker/microservices/api-gateway/internal/openapi"
+	"github.com/casualdoto/go-currency-tracker/microservices/api-gateway/internal/stream"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/health"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/logging"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/tracing"
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
 // ─── helpers ──────────────────────────────────────────────────────────────────
 
 func newTestGateway(historySrvURL, notifSrvURL string) *Gateway {
-	return &Gateway{
-		cfg: &config.Config{
-			HistoryServiceURL:      historySrvURL,
-			NotificationServiceURL: notifSrvURL,
-		},
-		httpClient: &http.Client{},
+	cfg := &config.Config{
+		HistoryServiceURL:      historySrvURL,
+		NotificationServiceURL: notifSrvURL,
 	}
+	return newGateway(cfg, &http.Client{}, nil, nil)
 }
 
 func doRequest(t *testing.T, handler http.Handler, method, path string) *httptest.ResponseRecorder {
@@ -103,6 +111,108 @@ func TestPing(t *testing.T) {
 	}
 }
 
+func TestMetrics_recordsRoutes(t *testing.T) {
+	routes := newTestGateway("http://localhost:8084", "http://localhost:8085").Routes()
+	doRequest(t, routes, http.MethodGet, "/ping")
+
+	rr := doRequest(t, routes, http.MethodGet, "/metrics")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	want := `currency_tracker_http_request_duration_seconds_count{method="GET",route="/ping",status="200"}`
+	if !strings.Contains(rr.Body.String(), want) {
+		t.Errorf("expected %s in the metrics", want)
+	}
+}
+
+// ─── /readyz and /status ──────────────────────────────────────────────────────
+
+func TestStatus_nestsUpstreamReports(t *testing.T) {
+	history := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/healthz":
+			health.Live(w, r)
+		case "/readyz":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"status":"ok","components":{"postgres":{"status":"ok","duration_ms":0.5}}}`))
+		}
+	}))
+	defer history.Close()
+	notifications := httptest.NewServer(http.NotFoundHandler())
+	notifications.Close()
+
+	routes := newTestGateway(history.URL, notifications.URL).Routes()
+
+	rr := doRequest(t, routes, http.MethodGet, "/readyz")
+	var ready health.Report
+	json.Unmarshal(rr.Body.Bytes(), &ready)
+	if rr.Code != http.StatusOK || ready.Status != health.StatusDegraded {
+		t.Errorf("readyz: expected 200 degraded with notification-service down, got %d %s", rr.Code, rr.Body)
+	}
+
+	rr = doRequest(t, routes, http.MethodGet, "/status")
+	var status health.Report
+	if err := json.Unmarshal(rr.Body.Bytes(), &status); err != nil {
+		t.Fatalf("status: invalid report %q", rr.Body)
+	}
+	if rr.Code != http.StatusOK || status.Status != health.StatusDegraded {
+		t.Errorf("status: expected 200 degraded, got %d %s", rr.Code, status.Status)
+	}
+	if c := status.Components["history-service"]; c.Components["postgres"].Status != health.StatusOK {
+		t.Errorf("status: expected history-service's own components, got %+v", c)
+	}
+	if c := status.Components["notification-service"]; c.Status != health.StatusDown {
+		t.Errorf("status: expected notification-service down, got %+v", c)
+	}
+
+	// history-service is required
+	history.Close()
+	if rr = doRequest(t, routes, http.MethodGet, "/status"); rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("status: expected 503 without history-service, got %d", rr.Code)
+	}
+}
+
+func TestTracing_continuesTraceUpstream(t *testing.T) {
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider())
+	defer otel.SetTracerProvider(prev)
+
+	var traceparent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer upstream.Close()
+	cfg := &config.Config{HistoryServiceURL: upstream.URL, NotificationServiceURL: upstream.URL}
+	gw := newGateway(cfg, &http.Client{Transport: tracing.Transport(nil)}, nil, nil).Routes()
+
+	const incoming = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req := httptest.NewRequest(http.MethodGet, "/rates/convert?from=USD&to=EUR&amount=1", nil)
+	req.Header.Set("traceparent", incoming)
+	gw.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Same trace, but the parent is now the gateway's own span
+	if !strings.HasPrefix(traceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-") || traceparent == incoming {
+		t.Errorf("expected the upstream call to continue the trace, got traceparent %q", traceparent)
+	}
+}
+
+func TestRequestID_generatedAndForwardedUpstream(t *testing.T) {
+	var upstreamID string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamID = r.Header.Get(logging.HeaderRequestID)
+	}))
+	defer upstream.Close()
+	gw := newTestGateway(upstream.URL, upstream.URL).Routes()
+
+	rr := httptest.NewRecorder()
+	gw.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/rates/convert?from=USD&to=EUR&amount=1", nil))
+
+	id := rr.Header().Get(logging.HeaderRequestID)
+	if id == "" || upstreamID != id {
+		t.Errorf("expected the generated request ID %q to reach the upstream, got %q", id, upstreamID)
+	}
+}
+
 // ─── proxyTo ──────────────────────────────────────────────────────────────────
 
 func TestProxyTo_forwardsResponse(t *testing.T) {
@@ -261,3 +371,355 @@ func TestRoutes_proxiesHistoryPath(t *testing.T) {
 		t.Errorf("expected 200, got %d", rr.Code)
 	}
 }
+
+func TestRoutes_proxiesConvert(t *testing.T) {
+	var receivedPath, receivedQuery string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.Path
+		receivedQuery = r.URL.RawQuery
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	gw := newTestGateway(upstream.URL, upstream.URL)
+	rr := doRequest(t, gw.Routes(), http.MethodGet, "/rates/convert?from=EUR&to=CNY&amount=250")
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rr.Code)
+	}
+	if receivedPath != "/history/convert" {
+		t.Errorf("expected /history/convert, got %q", receivedPath)
+	}
+	if receivedQuery != "from=EUR&to=CNY&amount=250" {
+		t.Errorf("query not forwarded: %q", receivedQuery)
+	}
+}
+
+func TestRoutes_proxiesAnalytics(t *testing.T) {
+	var receivedPaths []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedPaths = append(receivedPaths, r.URL.Path+"?"+r.URL.RawQuery)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	gw := newTestGateway(upstream.URL, upstream.URL)
+	for path, want := range map[string]string{
+		"/rates/analytics?code=USD&from=2024-01-01&to=2024-03-31":                  "/history/analytics?code=USD&from=2024-01-01&to=2024-03-31",
+		"/rates/analytics/correlation?codes=USD,BTC&from=2024-01-01&to=2024-03-31": "/history/analytics/correlation?codes=USD,BTC&from=2024-01-01&to=2024-03-31",
+	} {
+		receivedPaths = nil
+		rr := doRequest(t, gw.Routes(), http.MethodGet, path)
+		if rr.Code != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d", path, rr.Code)
+		}
+		if len(receivedPaths) != 1 || receivedPaths[0] != want {
+			t.Errorf("%s: expected upstream %s, got %v", path, want, receivedPaths)
+		}
+	}
+}
+
+func TestRoutes_proxiesCryptoIndicators(t *testing.T) {
+	var receivedPath, receivedQuery string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.Path
+		receivedQuery = r.URL.RawQuery
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	gw := newTestGateway(upstream.URL, upstream.URL)
+	rr := doRequest(t, gw.Routes(), http.MethodGet, "/rates/crypto/indicators?symbol=BTCUSDT&interval=1h&indicators=rsi14,ema20")
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rr.Code)
+	}
+	if receivedPath != "/history/crypto/indicators" {
+		t.Errorf("expected /history/crypto/indicators, got %q", receivedPath)
+	}
+	if receivedQuery != "symbol=BTCUSDT&interval=1h&indicators=rsi14,ema20" {
+		t.Errorf("query not forwarded: %q", receivedQuery)
+	}
+}
+
+func TestRoutes_streamsExports(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="export.csv"`)
+		w.Write([]byte(r.URL.Path + "?" + r.URL.RawQuery + "\n"))
+	}))
+	defer upstream.Close()
+
+	gw := newTestGateway(upstream.URL, upstream.URL)
+	for path, want := range map[string]string{
+		"/rates/cbr/export?from=2015-01-01&to=2024-12-31&format=csv":    "/history/cbr/export?from=2015-01-01&to=2024-12-31&format=csv\n",
+		"/rates/crypto/export?symbol=BTC&from=2024-01-01&to=2024-12-31": "/history/crypto/export?symbol=BTC&from=2024-01-01&to=2024-12-31\n",
+	} {
+		rr := doRequest(t, gw.Routes(), http.MethodGet, path)
+		if rr.Code != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d", path, rr.Code)
+		}
+		if rr.Body.String() != want {
+			t.Errorf("%s: expected body %q, got %q", path, want, rr.Body.String())
+		}
+		if rr.Header().Get("Content-Disposition") != `attachment; filename="export.csv"` {
+			t.Errorf("%s: download headers not forwarded: %v", path, rr.Header())
+		}
+	}
+}
+
+func TestRoutes_streamExportUpstreamDown(t *testing.T) {
+	gw := newTestGateway("http://127.0.0.1:1", "http://127.0.0.1:1")
+	rr := doRequest(t, gw.Routes(), http.MethodGet, "/rates/cbr/export?from=2024-01-01&to=2024-01-31")
+	if rr.Code != http.StatusBadGateway {
+		t.Errorf("expected 502, got %d", rr.Code)
+	}
+}
+
+func TestRoutes_liveStream(t *testing.T) {
+	gw := newTestGateway("http://127.0.0.1:1", "http://127.0.0.1:1")
+	srv := httptest.NewServer(gw.Routes())
+	defer srv.Close()
+
+	// The stream is served by the gateway even when the upstreams are down
+	resp, err := http.Get(srv.URL + "/v1/stream?types=cbr&symbols=USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	gw.stream.Publish(stream.TypeCBR, "USD", map[string]float64{"value": 90})
+	lines := bufio.NewScanner(resp.Body)
+	for lines.Scan() {
+		if lines.Text() == `data: {"value":90}` {
+			return
+		}
+	}
+	t.Fatalf("event not received: %v", lines.Err())
+}
+
+func TestRoutes_liveStreamRejectsInvalidType(t *testing.T) {
+	gw := newTestGateway("http://127.0.0.1:1", "http://127.0.0.1:1")
+	for _, path := range []string{"/v1/stream?types=fiat", "/v1/stream/ws?last_event_id=-1"} {
+		if rr := doRequest(t, gw.Routes(), http.MethodGet, path); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", path, rr.Code)
+		}
+	}
+}
+
+func TestRoutes_graphql(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/rates/crypto/symbols" {
+			t.Errorf("unexpected upstream path %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"data":["BTC","ETH"]}`))
+	}))
+	defer upstream.Close()
+	gw := newTestGateway(upstream.URL, upstream.URL)
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":"{ cryptoSymbols }"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	gw.Routes().ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || strings.TrimSpace(rr.Body.String()) != `{"data":{"cryptoSymbols":["BTC","ETH"]}}` {
+		t.Errorf("unexpected response %d %s", rr.Code, rr.Body)
+	}
+
+	// The body is validated against the OpenAPI document first
+	req = httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"variables":{}}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr = httptest.NewRecorder()
+	gw.Routes().ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "query") {
+		t.Errorf("expected 400 for a body without query, got %d %s", rr.Code, rr.Body)
+	}
+}
+
+func TestRoutes_v1PassesPathThrough(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":"` + r.URL.Path + "?" + r.URL.RawQuery + `"}`))
+	}))
+	defer upstream.Close()
+
+	gw := newTestGateway(upstream.URL, upstream.URL)
+	rr := doRequest(t, gw.Routes(), http.MethodGet, "/v1/rates/cbr/range?code=USD&from=2024-01-01&to=2024-01-31")
+	want := `{"data":"/v1/rates/cbr/range?code=USD&from=2024-01-01&to=2024-01-31"}`
+	if rr.Code != http.StatusOK || rr.Body.String() != want {
+		t.Errorf("expected 200 %s, got %d %s", want, rr.Code, rr.Body.String())
+	}
+	if rr.Header().Get("Deprecation") != "" {
+		t.Error("v1 routes must not be marked deprecated")
+	}
+}
+
+func TestRoutes_v1UpstreamDown(t *testing.T) {
+	gw := newTestGateway("http://127.0.0.1:1", "http://127.0.0.1:1")
+	rr := doRequest(t, gw.Routes(), http.MethodGet, "/v1/rates/cbr")
+	if rr.Code != http.StatusBadGateway {
+		t.Fatalf("expected 502, got %d", rr.Code)
+	}
+	want := `{"error":{"code":"unavailable","message":"upstream unavailable"}}` + "\n"
+	if rr.Body.String() != want {
+		t.Errorf("expected %s, got %s", want, rr.Body.String())
+	}
+}
+
+func TestRoutes_legacyRoutesDeprecated(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[]"))
+	}))
+	defer upstream.Close()
+
+	gw := newTestGateway(upstream.URL, upstream.URL)
+	for path, successor := range map[string]string{
+		"/rates/cbr?date=2024-01-15":                                               "/v1/rates/cbr",
+		"/rates/crypto/history?symbol=BTCUSDT":                                     "/v1/rates/crypto/range",
+		"/rates/crypto/history/range?symbol=BTCUSDT&from=2024-01-01&to=2024-01-31": "/v1/rates/crypto/range",
+		"/rates/convert?from=USD&to=EUR":                                           "/v1/convert",
+		"/rates/cbr/revisions?code=USD&date=2024-01-13":                            "/v1/rates/cbr/revisions",
+		"/rates/cbr/nominal-changes?from=2015-01-01&to=2024-12-31":                 "/v1/rates/cbr/nominal-changes",
+	} {
+		rr := doRequest(t, gw.Routes(), http.MethodGet, path)
+		if rr.Code != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d", path, rr.Code)
+		}
+		if rr.Header().Get("Deprecation") != "true" {
+			t.Errorf("%s: missing Deprecation header", path)
+		}
+		if want := "<" + successor + `>; rel="successor-version"`; rr.Header().Get("Link") != want {
+			t.Errorf("%s: expected Link %s, got %s", path, want, rr.Header().Get("Link"))
+		}
+	}
+}
+
+// ─── OpenAPI document and validation ─────────────────────────────────────────
+
+// Every route registered on the router must be described in the OpenAPI
+// document, and every described operation must be routed. Mounted subtrees
+// (/v1, /history, /notifications) need at least one path under their prefix.
+func TestRoutes_matchOpenAPIDocument(t *testing.T) {
+	spec, err := openapi.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The gRPC-backed routes replace proxied ones and must be documented too
+	gateways := map[string]*Gateway{
+		"proxy": newTestGateway("http://127.0.0.1:1", "http://127.0.0.1:1"),
+		"gRPC":  newGRPCTestGateway(t, &fakeSubscriptions{}),
+	}
+	for name, gw := range gateways {
+		router := gw.Routes().(chi.Routes)
+
+		err = chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+			if prefix, ok := strings.CutSuffix(route, "*"); ok {
+				for path := range spec.Paths {
+					if strings.HasPrefix(path, prefix) {
+						return nil
+					}
+				}
+				t.Errorf("%s: mounted %s has no path in the OpenAPI document", name, route)
+				return nil
+			}
+			if spec.Paths[route][strings.ToLower(method)] == nil {
+				t.Errorf("%s: %s %s is not described in the OpenAPI document", name, method, route)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for path, methods := range spec.Paths {
+			concrete := strings.NewReplacer("{path}", "x").Replace(path)
+			for method := range methods {
+				if !router.Match(chi.NewRouteContext(), strings.ToUpper(method), concrete) {
+					t.Errorf("%s: %s %s is described but not routed", name, strings.ToUpper(method), path)
+				}
+			}
+		}
+	}
+}
+
+func TestRoutes_rejectInvalidRequests(t *testing.T) {
+	called := false
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer upstream.Close()
+
+	gw := newTestGateway(upstream.URL, upstream.URL)
+	tests := []struct {
+		method, path, body, want string
+	}{
+		{http.MethodGet, "/v1/rates/cbr/range?code=USD&from=2024-01-01", "", `query parameter "to" is required`},
+		{http.MethodGet, "/rates/cbr?date=15.01.2024", "", `query parameter "date" must be a date in YYYY-MM-DD format`},
+		{http.MethodGet, "/rates/crypto/indicators?symbol=BTC&indicators=rsi14&interval=2h", "", `query parameter "interval" must be one of [1m 5m 15m 30m 1h 4h 1d]`},
+		{http.MethodGet, "/rates/cbr/export?from=2024-01-01&to=2024-01-31&format=parquet", "", `query parameter "format" must be one of [csv ndjson jsonl]`},
+		{http.MethodGet, "/notifications/subscriptions/cbr?telegram_id=abc", "", `query parameter "telegram_id" must be an integer`},
+		{http.MethodPost, "/notifications/subscriptions/crypto", `{"value":"BTCUSDT"}`, `body field "telegram_id" is required`},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		rr := httptest.NewRecorder()
+		gw.Routes().ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s %s: expected 400, got %d", tc.method, tc.path, rr.Code)
+			continue
+		}
+		var body validationError
+		if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+			t.Fatalf("%s: decode: %v", tc.path, err)
+		}
+		if body.Error.Code != "bad_request" || body.Error.Message != tc.want || len(body.Error.Details) != 1 {
+			t.Errorf("%s %s: unexpected error %+v", tc.method, tc.path, body.Error)
+		}
+	}
+	if called {
+		t.Error("invalid requests must not reach the upstream")
+	}
+}
+
+func TestRoutes_validRequestIsProxied(t *testing.T) {
+	var got string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got = r.URL.Path + " " + string(b)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer upstream.Close()
+
+	gw := newTestGateway(upstream.URL, upstream.URL)
+	req := httptest.NewRequest(http.MethodPost, "/notifications/subscriptions/cbr", strings.NewReader(`{"telegram_id":1,"value":"USD"}`))
+	rr := httptest.NewRecorder()
+	gw.Routes().ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", rr.Code)
+	}
+	if want := `/subscriptions/cbr {"telegram_id":1,"value":"USD"}`; got != want {
+		t.Errorf("expected upstream to receive %q, got %q", want, got)
+	}
+}
+
+func TestRoutes_servesOpenAPIDocument(t *testing.T) {
+	gw := newTestGateway("http://127.0.0.1:1", "http://127.0.0.1:1")
+
+	rr := doRequest(t, gw.Routes(), http.MethodGet, "/api/openapi")
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("expected JSON document, got %d %s", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil || doc["openapi"] == nil {
+		t.Errorf("expected an OpenAPI document, got %v", err)
+	}
+
+	rr = doRequest(t, gw.Routes(), http.MethodGet, "/api/docs")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `url: "/api/openapi"`) {
+		t.Errorf("expected Swagger UI page, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,197 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/apiv1"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/pkg/client"
+)
+
+// The handlers below translate JSON requests into calls of the internal
+// gRPC API and answer with exactly what the proxied HTTP routes would: the
+// /v1 envelope and error format for rates, and notification-service's own
+// format for subscriptions. Query parameters and bodies have already been
+// checked against the OpenAPI document; the services validate the rest.
+
+// queryDate parses an optional YYYY-MM-DD query parameter.
+func queryDate(r *http.Request, name string) (time.Time, error) {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return t, fmt.Errorf("invalid %s date", name)
+	}
+	return t, nil
+}
+
+// queryAsOf parses the optional as_of query parameter: an RFC 3339 time, or
+// a YYYY-MM-DD date meaning the end of that day in UTC.
+func queryAsOf(r *http.Request) (time.Time, error) {
+	s := r.URL.Query().Get("as_of")
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	d, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return d, errors.New("invalid as_of, use an RFC 3339 time or YYYY-MM-DD")
+	}
+	return d.AddDate(0, 0, 1).Add(-time.Microsecond), nil
+}
+
+// queryRange parses the from and to query parameters.
+func queryRange(r *http.Request) (from, to time.Time, err error) {
+	if from, err = queryDate(r, "from"); err != nil {
+		return
+	}
+	to, err = queryDate(r, "to")
+	return
+}
+
+// writeV1Result writes data in the /v1 envelope, or err in the /v1 error
+// format with the status the service reported.
+func writeV1Result(w http.ResponseWriter, data any, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		status, body := v1Error(err)
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+		return
+	}
+	json.NewEncoder(w).Encode(money.ForResponse(w, apiv1.Response[any]{Data: data}))
+}
+
+func v1Error(err error) (int, apiv1.ErrorResponse) {
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode, apiv1.ErrorResponse{Error: apiv1.Error{Code: apiErr.Code, Message: apiErr.Message}}
+	}
+	return http.StatusBadRequest, apiv1.NewError(http.StatusBadRequest, err.Error())
+}
+
+// GET /v1/rates/cbr
+func (g *Gateway) v1CBRRates(w http.ResponseWriter, r *http.Request) {
+	date, err := queryDate(r, "date")
+	if err != nil {
+		writeV1Result(w, nil, err)
+		return
+	}
+	asOf, err := queryAsOf(r)
+	if err != nil {
+		writeV1Result(w, nil, err)
+		return
+	}
+	rates, err := g.api.CBRRatesAsOf(r.Context(), date, asOf)
+	writeV1Result(w, rates, err)
+}
+
+// GET /v1/rates/cbr/range
+func (g *Gateway) v1CBRRange(w http.ResponseWriter, r *http.Request) {
+	from, to, err := queryRange(r)
+	if err != nil {
+		writeV1Result(w, nil, err)
+		return
+	}
+	asOf, err := queryAsOf(r)
+	if err != nil {
+		writeV1Result(w, nil, err)
+		return
+	}
+	rates, err := g.api.CBRRangeAsOf(r.Context(), r.URL.Query().Get("code"), from, to, asOf)
+	writeV1Result(w, rates, err)
+}
+
+// GET /v1/rates/crypto/symbols
+func (g *Gateway) v1CryptoSymbols(w http.ResponseWriter, r *http.Request) {
+	symbols, err := g.api.CryptoSymbols(r.Context())
+	writeV1Result(w, symbols, err)
+}
+
+// GET /v1/rates/crypto/range
+func (g *Gateway) v1CryptoRange(w http.ResponseWriter, r *http.Request) {
+	from, to, err := queryRange(r)
+	if err != nil {
+		writeV1Result(w, nil, err)
+		return
+	}
+	rates, err := g.api.CryptoRange(r.Context(), r.URL.Query().Get("symbol"), from, to)
+	writeV1Result(w, rates, err)
+}
+
+// GET /v1/convert
+func (g *Gateway) v1Convert(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	req := client.ConvertRequest{From: q.Get("from"), To: q.Get("to")}
+	var err error
+	if s := q.Get("amount"); s != "" {
+		if req.Amount, err = money.Parse(s); err != nil {
+			writeV1Result(w, nil, errors.New("invalid amount"))
+			return
+		}
+	}
+	if req.Date, err = queryDate(r, "date"); err != nil {
+		writeV1Result(w, nil, err)
+		return
+	}
+	res, err := g.api.Convert(r.Context(), req)
+	writeV1Result(w, res, err)
+}
+
+// writeSubscriptionError writes err in notification-service's
+// {"error": "message"} format.
+func writeSubscriptionError(w http.ResponseWriter, err error) {
+	status, body := v1Error(err)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": body.Error.Message})
+}
+
+// GET /notifications/subscriptions/{kind}?telegram_id=
+func (g *Gateway) listSubscriptions(kind client.SubscriptionKind) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tid, err := strconv.ParseInt(r.URL.Query().Get("telegram_id"), 10, 64)
+		if err != nil {
+			writeSubscriptionError(w, errors.New("invalid telegram_id"))
+			return
+		}
+		values, err := g.api.Subscriptions(r.Context(), kind, tid)
+		if err != nil {
+			writeSubscriptionError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(values)
+	}
+}
+
+// POST and DELETE /notifications/subscriptions/{kind}
+func (g *Gateway) updateSubscription(kind client.SubscriptionKind,
+	call func(ctx context.Context, kind client.SubscriptionKind, telegramID int64, value string) error,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			TelegramID int64  `json:"telegram_id"`
+			Value      string `json:"value"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeSubscriptionError(w, errors.New("invalid body"))
+			return
+		}
+		if err := call(r.Context(), kind, req.TelegramID, req.Value); err != nil {
+			writeSubscriptionError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
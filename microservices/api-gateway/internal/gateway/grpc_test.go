@@ -0,0 +1,152 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/api-gateway/internal/config"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/apiv1"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/rpcv1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// ─── gRPC fakes ───────────────────────────────────────────────────────────────
+
+type fakeHistory struct {
+	rpcv1.UnimplementedHistoryServiceServer
+}
+
+func (fakeHistory) GetCBRRange(_ context.Context, req *rpcv1.GetCBRRangeRequest) (*rpcv1.CurrencyRates, error) {
+	if req.Code == "XXX" {
+		return nil, rpcv1.Error(http.StatusNotFound, "no rates for XXX")
+	}
+	rate := apiv1.CurrencyRate{Date: req.From, Code: req.Code, Nominal: 1, Value: money.MustParse("90.5")}
+	if req.AsOf != "" {
+		// Echo the point in time so tests can check what was sent
+		rate.Name, rate.CarriedFrom = "as of "+req.AsOf, "2024-01-05"
+	}
+	return rpcv1.NewCurrencyRates([]apiv1.CurrencyRate{rate}), nil
+}
+
+func (fakeHistory) Convert(_ context.Context, req *rpcv1.ConvertRequest) (*rpcv1.Conversion, error) {
+	return rpcv1.NewConversion(apiv1.Conversion{From: req.From, To: req.To, Amount: req.AmountValue(), Result: req.AmountValue().Mul(money.NewFromInt(2)), Rate: money.NewFromInt(2), Date: req.Date}), nil
+}
+
+type fakeSubscriptions struct {
+	rpcv1.UnimplementedSubscriptionServiceServer
+	got []*rpcv1.SubscriptionRequest
+}
+
+func (f *fakeSubscriptions) Subscribe(_ context.Context, req *rpcv1.SubscriptionRequest) (*rpcv1.SubscribeResponse, error) {
+	f.got = append(f.got, req)
+	return &rpcv1.SubscribeResponse{}, nil
+}
+
+func (f *fakeSubscriptions) ListSubscriptions(context.Context, *rpcv1.ListSubscriptionsRequest) (*rpcv1.Subscriptions, error) {
+	return &rpcv1.Subscriptions{}, nil
+}
+
+// newGRPCTestGateway returns a gateway whose rate and subscription routes go
+// to the fakes over gRPC; the HTTP upstreams are unreachable.
+func newGRPCTestGateway(t *testing.T, subs *fakeSubscriptions) *Gateway {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	rpcv1.RegisterHistoryServiceServer(srv, fakeHistory{})
+	rpcv1.RegisterSubscriptionServiceServer(srv, subs)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	cfg := &config.Config{HistoryServiceURL: "http://127.0.0.1:1", NotificationServiceURL: "http://127.0.0.1:1"}
+	return newGateway(cfg, &http.Client{}, conn, conn)
+}
+
+// ─── gRPC-backed routes ───────────────────────────────────────────────────────
+
+func TestRoutes_v1RatesOverGRPC(t *testing.T) {
+	gw := newGRPCTestGateway(t, &fakeSubscriptions{}).Routes()
+
+	rr := doRequest(t, gw, http.MethodGet, "/v1/rates/cbr/range?code=USD&from=2024-01-09&to=2024-01-10")
+	want := `{"data":[{"date":"2024-01-09","code":"USD","name":"","nominal":1,"value":"90.5","previous":"0","unit_value":"90.5"}]}`
+	if rr.Code != http.StatusOK || strings.TrimSpace(rr.Body.String()) != want {
+		t.Errorf("expected 200 %s, got %d %s", want, rr.Code, rr.Body)
+	}
+
+	rr = doRequest(t, gw, http.MethodGet, "/v1/rates/cbr/range?code=USD&from=2024-01-09&to=2024-01-10&decimals=number")
+	want = `{"data":[{"date":"2024-01-09","code":"USD","name":"","nominal":1,"value":90.5,"previous":0,"unit_value":90.5}]}`
+	if rr.Code != http.StatusOK || strings.TrimSpace(rr.Body.String()) != want {
+		t.Errorf("expected 200 %s with decimals=number, got %d %s", want, rr.Code, rr.Body)
+	}
+
+	rr = doRequest(t, gw, http.MethodGet, "/v1/rates/cbr/range?code=USD&from=2024-01-09&to=2024-01-10&as_of=2024-01-16")
+	want = `{"data":[{"date":"2024-01-09","code":"USD","name":"as of 2024-01-16T23:59:59.999999Z","nominal":1,"value":"90.5","previous":"0","unit_value":"90.5","carried_from":"2024-01-05"}]}`
+	if rr.Code != http.StatusOK || strings.TrimSpace(rr.Body.String()) != want {
+		t.Errorf("expected 200 %s, got %d %s", want, rr.Code, rr.Body)
+	}
+
+	rr = doRequest(t, gw, http.MethodGet, "/v1/convert?from=EUR&to=USD&amount=2.5&date=2025-03-10")
+	var conv apiv1.Response[apiv1.Conversion]
+	json.NewDecoder(rr.Body).Decode(&conv)
+	if rr.Code != http.StatusOK || conv.Data.Result.String() != "5" || conv.Data.Date != "2025-03-10" {
+		t.Errorf("unexpected conversion %d %+v", rr.Code, conv.Data)
+	}
+}
+
+func TestRoutes_v1ErrorsOverGRPC(t *testing.T) {
+	gw := newGRPCTestGateway(t, &fakeSubscriptions{}).Routes()
+
+	rr := doRequest(t, gw, http.MethodGet, "/v1/rates/cbr/range?code=XXX&from=2024-01-09&to=2024-01-10")
+	var body apiv1.ErrorResponse
+	json.NewDecoder(rr.Body).Decode(&body)
+	if rr.Code != http.StatusNotFound || body.Error.Code != apiv1.CodeNotFound || body.Error.Message != "no rates for XXX" {
+		t.Errorf("expected a v1 not_found, got %d %+v", rr.Code, body)
+	}
+
+	// Routes the fake does not implement fail like any other service error
+	rr = doRequest(t, gw, http.MethodGet, "/v1/rates/crypto/symbols")
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500 for an unimplemented method, got %d", rr.Code)
+	}
+}
+
+func TestRoutes_subscriptionsOverGRPC(t *testing.T) {
+	subs := &fakeSubscriptions{}
+	gw := newGRPCTestGateway(t, subs).Routes()
+
+	req := httptest.NewRequest(http.MethodPost, "/notifications/subscriptions/crypto", strings.NewReader(`{"telegram_id":42,"value":"BTC"}`))
+	rr := httptest.NewRecorder()
+	gw.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d %s", rr.Code, rr.Body)
+	}
+	if len(subs.got) != 1 || subs.got[0].Kind != rpcv1.SubscriptionKind_SUBSCRIPTION_KIND_CRYPTO || subs.got[0].TelegramId != 42 || subs.got[0].Value != "BTC" {
+		t.Errorf("unexpected gRPC requests %v", subs.got)
+	}
+
+	rr = doRequest(t, gw, http.MethodGet, "/notifications/subscriptions/cbr?telegram_id=42")
+	if rr.Code != http.StatusOK || strings.TrimSpace(rr.Body.String()) != "[]" {
+		t.Errorf("expected an empty list, got %d %s", rr.Code, rr.Body)
+	}
+
+	// Routes without a gRPC counterpart are still proxied
+	rr = doRequest(t, gw, http.MethodGet, "/notifications/ping")
+	if rr.Code != http.StatusBadGateway {
+		t.Errorf("expected the proxy to report the unreachable upstream, got %d", rr.Code)
+	}
+}
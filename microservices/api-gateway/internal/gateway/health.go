@@ -0,0 +1,53 @@
+package gateway
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/api-gateway/internal/config"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/health"
+)
+
+// readiness checks what the gateway itself talks to. Every check is
+// optional: while an upstream is down its routes answer 502, and the other
+// routes keep working.
+func readiness(cfg *config.Config, hc *http.Client) *health.Checker {
+	c := health.New().
+		AddOptional("history-service", health.HTTP(hc, cfg.HistoryServiceURL+"/healthz")).
+		AddOptional("notification-service", health.HTTP(hc, cfg.NotificationServiceURL+"/healthz"))
+	if cfg.KafkaBrokers != "" {
+		c.AddOptional("kafka", health.Kafka(cfg.KafkaBrokers))
+	}
+	return c
+}
+
+// upstreamStatus collects the /readyz reports behind /status: the proxied
+// services and those listed in cfg.StatusServices. Without history-service
+// nothing but the subscriptions works, so it is the one required service.
+func upstreamStatus(cfg *config.Config, hc *http.Client) *health.Checker {
+	c := health.New().
+		AddService("history-service", hc, cfg.HistoryServiceURL+"/readyz", false).
+		AddService("notification-service", hc, cfg.NotificationServiceURL+"/readyz", true)
+	for name, base := range parseStatusServices(cfg.StatusServices) {
+		c.AddService(name, hc, base+"/readyz", true)
+	}
+	return c
+}
+
+// parseStatusServices parses the comma-separated name=URL pairs of
+// STATUS_SERVICES.
+func parseStatusServices(s string) map[string]string {
+	services := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		name, base, ok := strings.Cut(pair, "=")
+		if u, err := url.Parse(base); !ok || name == "" || err != nil || u.Host == "" {
+			fatal("invalid STATUS_SERVICES entry, want name=http://host:port", "entry", pair)
+		}
+		services[name] = strings.TrimSuffix(base, "/")
+	}
+	return services
+}
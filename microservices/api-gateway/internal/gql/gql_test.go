@@ -0,0 +1,263 @@
+package gql
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/apiv1"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/pkg/client"
+)
+
+// backend fakes history-service and notification-service and counts the
+// calls per path and query.
+type backend struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (b *backend) count(r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.calls[r.URL.Path+"?"+r.URL.RawQuery]++
+}
+
+func (b *backend) callsTo(path string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := 0
+	for k, v := range b.calls {
+		if strings.HasPrefix(k, path+"?") {
+			n += v
+		}
+	}
+	return n
+}
+
+func writeData(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(apiv1.Response[any]{Data: v})
+}
+
+func newTestHandler(t *testing.T) (*Handler, *backend) {
+	t.Helper()
+	b := &backend{calls: map[string]int{}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b.count(r)
+		q := r.URL.Query()
+		switch r.URL.Path {
+		case "/v1/rates/cbr":
+			writeData(w, []apiv1.CurrencyRate{
+				{Date: "2024-01-15", Code: "EUR", Nominal: 1, Value: money.MustParse("97")},
+				{Date: "2024-01-15", Code: "JPY", Nominal: 100, Value: money.MustParse("61")},
+				{Date: "2024-01-15", Code: "USD", Nominal: 1, Value: money.MustParse("89")},
+			})
+		case "/v1/rates/cbr/range":
+			code := q.Get("code")
+			if code == "XXX" {
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(apiv1.NewError(http.StatusNotFound, "no rates for XXX"))
+				return
+			}
+			writeData(w, []apiv1.CurrencyRate{
+				{Date: "2024-01-10", Code: code, Nominal: 1, Value: money.MustParse("100")},
+				{Date: "2024-01-11", Code: code, Nominal: 1, Value: money.MustParse("110")},
+			})
+		case "/v1/rates/crypto/range":
+			writeData(w, []apiv1.CryptoRate{
+				{Time: time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), Symbol: q.Get("symbol"), Close: money.MustParse("4000000")},
+			})
+		case "/v1/rates/crypto/symbols":
+			writeData(w, []string{"BTC", "ETH"})
+		case "/v1/convert":
+			writeData(w, apiv1.Conversion{From: q.Get("from"), To: q.Get("to"), Amount: money.MustParse("2"), Result: money.MustParse("2.2"), Rate: money.MustParse("1.1")})
+		case "/subscriptions/cbr":
+			json.NewEncoder(w).Encode([]string{"USD"})
+		case "/subscriptions/crypto":
+			json.NewEncoder(w).Encode([]string{"BTC", "ETH"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	h, err := NewHandler(client.New(srv.URL, client.WithNotificationsURL(srv.URL), client.WithRetries(0, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h, b
+}
+
+type response struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func post(t *testing.T, h http.Handler, body string) response {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var resp response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func query(q string) string {
+	b, _ := json.Marshal(Request{Query: q})
+	return string(b)
+}
+
+func TestQuery_dashboardInOneRoundTrip(t *testing.T) {
+	h, b := newTestHandler(t)
+	resp := post(t, h, query(`{
+		series(codes: ["usd", "EUR"], from: "2024-01-10", to: "2024-01-11") {
+			code
+			rates { date value }
+			stats { count last changePct }
+		}
+		candles(symbols: ["BTC"], from: "2024-01-10", to: "2024-01-11") { symbol candles { close } }
+		latest: rates(codes: ["USD", "JPY", "GBP"]) { code unitValue }
+		cryptoSymbols
+		subscriptions(telegramId: "42") { telegramId currencies crypto }
+	}`))
+	if len(resp.Errors) > 0 {
+		t.Fatalf("unexpected errors %+v", resp.Errors)
+	}
+
+	var series []struct {
+		Code  string
+		Rates []struct{ Date string }
+		Stats struct {
+			Count     int
+			Last      float64
+			ChangePct float64
+		}
+	}
+	json.Unmarshal(resp.Data["series"], &series)
+	if len(series) != 2 || series[0].Code != "USD" || len(series[0].Rates) != 2 {
+		t.Fatalf("unexpected series %s", resp.Data["series"])
+	}
+	if s := series[1].Stats; s.Count != 2 || s.Last != 110 || s.ChangePct < 9.99 || s.ChangePct > 10.01 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+
+	if got := string(resp.Data["latest"]); got != `[{"code":"USD","unitValue":89},{"code":"JPY","unitValue":0.61}]` {
+		t.Errorf("unexpected latest rates %s", got)
+	}
+	if got := string(resp.Data["subscriptions"]); got != `{"crypto":["BTC","ETH"],"currencies":["USD"],"telegramId":"42"}` {
+		t.Errorf("unexpected subscriptions %s", got)
+	}
+
+	// rates and stats of a series share one backend call
+	for path, want := range map[string]int{
+		"/v1/rates/cbr/range":      2,
+		"/v1/rates/crypto/range":   1,
+		"/v1/rates/cbr":            1,
+		"/v1/rates/crypto/symbols": 1,
+		"/subscriptions/cbr":       1,
+		"/subscriptions/crypto":    1,
+	} {
+		if got := b.callsTo(path); got != want {
+			t.Errorf("%s: expected %d calls, got %d", path, want, got)
+		}
+	}
+}
+
+func TestQuery_deduplicatesAliases(t *testing.T) {
+	h, b := newTestHandler(t)
+	resp := post(t, h, query(`{
+		a: convert(from: "EUR", to: "USD", amount: 2) { result }
+		b: convert(from: "eur", to: "usd", amount: 2) { rate }
+		c: convert(from: "EUR", to: "CNY", amount: 2, date: "2024-01-15") { result }
+		d: rates(date: "2024-01-15") { code }
+		e: rates(date: "2024-01-15", codes: ["EUR"]) { value }
+	}`))
+	if len(resp.Errors) > 0 {
+		t.Fatalf("unexpected errors %+v", resp.Errors)
+	}
+	if b.callsTo("/v1/convert") != 2 || b.callsTo("/v1/rates/cbr") != 1 {
+		t.Errorf("expected 2 conversions and 1 rates call, got %v", b.calls)
+	}
+	if got := string(resp.Data["c"]); got != `{"result":2.2}` {
+		t.Errorf("unexpected conversion %s", got)
+	}
+}
+
+func TestQuery_backendErrorsKeepPartialData(t *testing.T) {
+	h, _ := newTestHandler(t)
+	resp := post(t, h, query(`{
+		ok: series(codes: ["USD"], from: "2024-01-10", to: "2024-01-11") { code rates { value } }
+		missing: series(codes: ["XXX"], from: "2024-01-10", to: "2024-01-11") { code rates { value } }
+	}`))
+	if len(resp.Errors) != 1 {
+		t.Fatalf("expected one error, got %+v", resp.Errors)
+	}
+	e := resp.Errors[0]
+	if e.Message != "no rates for XXX" || e.Extensions["code"] != apiv1.CodeNotFound || e.Extensions["status"] != float64(404) {
+		t.Errorf("unexpected error %+v", e)
+	}
+	if !strings.Contains(string(resp.Data["ok"]), `"value":100`) {
+		t.Errorf("expected the USD series, got %s", resp.Data["ok"])
+	}
+}
+
+func TestQuery_invalidArguments(t *testing.T) {
+	h, b := newTestHandler(t)
+	for q, want := range map[string]string{
+		`{ series(codes: ["USD"], from: "10.01.2024", to: "2024-01-11") { code } }`:      "from must be a date in YYYY-MM-DD format",
+		`{ candles(symbols: ["BTC"], from: "2024-02-01", to: "2024-01-01") { symbol } }`: "to must not be before from",
+		`{ convert(from: "EUR", to: "USD", amount: -1) { result } }`:                     "amount must be positive",
+		`{ subscriptions(telegramId: "abc") { crypto } }`:                                "telegramId must be a positive integer",
+		`{ rates { unknownField } }`:                                                     `Cannot query field "unknownField" on type "CurrencyRate".`,
+	} {
+		resp := post(t, h, query(q))
+		if len(resp.Errors) != 1 || resp.Errors[0].Message != want {
+			t.Errorf("%s: expected %q, got %+v", q, want, resp.Errors)
+		}
+	}
+	if len(b.calls) != 0 {
+		t.Errorf("invalid queries must not reach the backends, got %v", b.calls)
+	}
+}
+
+func TestServeHTTP_getWithVariables(t *testing.T) {
+	h, _ := newTestHandler(t)
+	v := url.Values{}
+	v.Set("query", `query Latest($codes: [String!]) { rates(codes: $codes) { code } }`)
+	v.Set("variables", `{"codes": ["EUR"]}`)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/graphql?"+v.Encode(), nil))
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != `{"data":{"rates":[{"code":"EUR"}]}}` {
+		t.Errorf("unexpected response %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestServeHTTP_malformedRequests(t *testing.T) {
+	h, _ := newTestHandler(t)
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader("not json")),
+		httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"variables": {}}`)),
+		httptest.NewRequest(http.MethodGet, "/graphql?query=%7Brates%7Bcode%7D%7D&variables=%5B", nil),
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		var body apiv1.ErrorResponse
+		if rec.Code != http.StatusBadRequest || json.Unmarshal(rec.Body.Bytes(), &body) != nil || body.Error.Code != apiv1.CodeBadRequest {
+			t.Errorf("%s %s: expected a v1 bad_request, got %d %s", req.Method, req.URL, rec.Code, rec.Body)
+		}
+	}
+}
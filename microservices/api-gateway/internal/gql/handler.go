@@ -0,0 +1,148 @@
+package gql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/apiv1"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/pkg/client"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+)
+
+// maxBodyBytes bounds the size of a GraphQL request body.
+const maxBodyBytes = 1 << 20
+
+type contextKey struct{}
+
+func fromContext(ctx context.Context) *loaders {
+	return ctx.Value(contextKey{}).(*loaders)
+}
+
+// Request is a GraphQL request as sent by POST bodies and GET query strings.
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Handler executes GraphQL requests against the backends of c.
+type Handler struct {
+	schema graphql.Schema
+	client *client.Client
+}
+
+// NewHandler returns a handler whose resolvers use c.
+func NewHandler(c *client.Client) (*Handler, error) {
+	schema, err := NewSchema()
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{schema: schema, client: c}, nil
+}
+
+// ServeHTTP accepts POST requests with a JSON body and GET requests with
+// query, operationName and variables (JSON) parameters. Execution errors
+// are reported in the errors list of a 200 response, as GraphQL clients
+// expect; malformed requests get 400 in the /v1 error format.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req, err := parseRequest(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, apiv1.NewError(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), contextKey{}, newLoaders(h.client))
+	result := graphql.Do(graphql.Params{
+		Schema:         h.schema,
+		RequestString:  req.Query,
+		OperationName:  req.OperationName,
+		VariableValues: req.Variables,
+		Context:        ctx,
+	})
+	addExtensions(result.Errors)
+	writeJSON(w, http.StatusOK, result)
+}
+
+func parseRequest(r *http.Request) (Request, error) {
+	var req Request
+	if r.Method == http.MethodGet {
+		q := r.URL.Query()
+		req.Query = q.Get("query")
+		req.OperationName = q.Get("operationName")
+		if v := q.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				return req, errors.New("variables must be a JSON object")
+			}
+		}
+	} else if err := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxBodyBytes)).Decode(&req); err != nil {
+		return req, errors.New("body must be a JSON object with a query")
+	}
+	if req.Query == "" {
+		return req, errors.New("query is required")
+	}
+	return req, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// apiError carries a backend error into the GraphQL errors list with the
+// /v1 error code and HTTP status as extensions.
+type apiError struct {
+	*client.APIError
+}
+
+func (e apiError) Error() string { return e.Message }
+
+func (e apiError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.Code, "status": e.StatusCode}
+}
+
+// backendError converts err for the GraphQL response.
+func backendError(err error) error {
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) {
+		return apiError{apiErr}
+	}
+	if err != nil {
+		return apiError{&client.APIError{
+			StatusCode: http.StatusBadGateway,
+			Code:       apiv1.CodeUnavailable,
+			Message:    "backend unavailable: " + err.Error(),
+		}}
+	}
+	return nil
+}
+
+// addExtensions sets the extensions of backend errors. The executor keeps
+// them for errors returned by resolvers but drops them for errors returned
+// by thunks, so they are recovered from the original errors.
+func addExtensions(errs []gqlerrors.FormattedError) {
+	for i := range errs {
+		if errs[i].Extensions != nil {
+			continue
+		}
+		for err := error(errs[i]); err != nil; err = originalError(err) {
+			if e, ok := err.(apiError); ok {
+				errs[i].Extensions = e.Extensions()
+				break
+			}
+		}
+	}
+}
+
+func originalError(err error) error {
+	switch e := err.(type) {
+	case gqlerrors.FormattedError:
+		return e.OriginalError()
+	case *gqlerrors.Error:
+		return e.OriginalError
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package gql
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/pkg/client"
+	"github.com/graph-gophers/dataloader"
+)
+
+const (
+	// batchWait is how long a loader collects keys before fetching them.
+	batchWait = 2 * time.Millisecond
+	// maxConcurrentFetches bounds the backend calls of one batch.
+	maxConcurrentFetches = 8
+)
+
+// rangeKey identifies a series: a currency code or crypto symbol and a date range.
+type rangeKey struct {
+	symbol   string
+	from, to time.Time
+}
+
+func (k rangeKey) String() string {
+	return k.symbol + "|" + k.from.Format(dateLayout) + "|" + k.to.Format(dateLayout)
+}
+func (k rangeKey) Raw() interface{} { return k }
+
+// convertKey identifies a conversion.
+type convertKey client.ConvertRequest
+
+func (k convertKey) String() string {
+	return k.From + "|" + k.To + "|" + k.Amount.String() + "|" + k.Date.Format(dateLayout)
+}
+func (k convertKey) Raw() interface{} { return k }
+
+// subscriptionsKey identifies the subscriptions of one kind of a Telegram user.
+type subscriptionsKey struct {
+	kind       client.SubscriptionKind
+	telegramID int64
+}
+
+func (k subscriptionsKey) String() string {
+	return string(k.kind) + "|" + strconv.FormatInt(k.telegramID, 10)
+}
+func (k subscriptionsKey) Raw() interface{} { return k }
+
+// dateKey is a CBR publication date; the zero date means the latest one.
+type dateKey time.Time
+
+func (k dateKey) String() string   { return time.Time(k).Format(dateLayout) }
+func (k dateKey) Raw() interface{} { return k }
+
+// loaders batch and deduplicate the backend calls of one GraphQL request.
+// Resolvers register keys and return thunks; the executor resolves a whole
+// level of the query before calling them, so every rate, series or
+// subscription requested on that level is fetched in a single batch and each
+// distinct key is fetched once.
+type loaders struct {
+	ratesByDate   *dataloader.Loader
+	cbrSeries     *dataloader.Loader
+	cryptoSeries  *dataloader.Loader
+	cryptoSymbols *dataloader.Loader
+	conversions   *dataloader.Loader
+	subscriptions *dataloader.Loader
+}
+
+func newLoaders(c *client.Client) *loaders {
+	return &loaders{
+		ratesByDate: newLoader(func(ctx context.Context, k dateKey) (interface{}, error) {
+			return c.CBRRates(ctx, time.Time(k))
+		}),
+		cbrSeries: newLoader(func(ctx context.Context, k rangeKey) (interface{}, error) {
+			return c.CBRRange(ctx, k.symbol, k.from, k.to)
+		}),
+		cryptoSeries: newLoader(func(ctx context.Context, k rangeKey) (interface{}, error) {
+			return c.CryptoRange(ctx, k.symbol, k.from, k.to)
+		}),
+		cryptoSymbols: newLoader(func(ctx context.Context, _ dataloader.StringKey) (interface{}, error) {
+			return c.CryptoSymbols(ctx)
+		}),
+		conversions: newLoader(func(ctx context.Context, k convertKey) (interface{}, error) {
+			return c.Convert(ctx, client.ConvertRequest(k))
+		}),
+		subscriptions: newLoader(func(ctx context.Context, k subscriptionsKey) (interface{}, error) {
+			return c.Subscriptions(ctx, k.kind, k.telegramID)
+		}),
+	}
+}
+
+// newLoader returns a loader that fetches the keys of a batch concurrently.
+// The backends have no multi-key endpoints, so batching saves latency rather
+// than calls; the per-request cache removes duplicate keys.
+func newLoader[K dataloader.Key](fetch func(context.Context, K) (interface{}, error)) *dataloader.Loader {
+	batch := func(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+		results := make([]*dataloader.Result, len(keys))
+		sem := make(chan struct{}, maxConcurrentFetches)
+		var wg sync.WaitGroup
+		for i, key := range keys {
+			wg.Add(1)
+			go func(i int, key K) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				data, err := fetch(ctx, key)
+				results[i] = &dataloader.Result{Data: data, Error: backendError(err)}
+			}(i, key.(K))
+		}
+		wg.Wait()
+		return results
+	}
+	return dataloader.NewBatchedLoader(batch, dataloader.WithWait(batchWait))
+}
+
+// load registers key with l and returns a thunk for a resolver.
+func load(ctx context.Context, l *dataloader.Loader, key dataloader.Key) func() (interface{}, error) {
+	thunk := l.Load(ctx, key)
+	return func() (interface{}, error) { return thunk() }
+}
@@ -0,0 +1,403 @@
+// Package gql serves the GraphQL API of the gateway: CBR rates and series,
+// crypto candles, conversions and subscriptions in one round trip. Resolvers
+// call history-service and notification-service through the shared client
+// and batch their calls per request with dataloaders.
+package gql
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/analytics"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/apiv1"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/pkg/client"
+	"github.com/graph-gophers/dataloader"
+	"github.com/graphql-go/graphql"
+)
+
+const dateLayout = "2006-01-02"
+
+// series is a requested CBR or crypto series; its points are loaded lazily
+// so that the series of one query are fetched in a single batch.
+type series struct {
+	symbol   string
+	from, to time.Time
+}
+
+func (s series) key() rangeKey { return rangeKey{s.symbol, s.from, s.to} }
+
+// subscriptions is the parent of the subscription lists of a Telegram user.
+type subscriptions struct {
+	telegramID int64
+}
+
+// field returns a field resolved by fn from a parent of type P.
+func field[P any](typ graphql.Output, description string, fn func(P) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type:        typ,
+		Description: description,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return fn(p.Source.(P)), nil
+		},
+	}
+}
+
+var nonNullString = graphql.NewNonNull(graphql.String)
+var nonNullFloat = graphql.NewNonNull(graphql.Float)
+var nonNullInt = graphql.NewNonNull(graphql.Int)
+var stringList = graphql.NewNonNull(graphql.NewList(nonNullString))
+
+var currencyRateType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "CurrencyRate",
+	Description: "Official CBR rate of a currency on a date.",
+	Fields: graphql.Fields{
+		"date":     field(nonNullString, "CBR date (YYYY-MM-DD).", func(r apiv1.CurrencyRate) interface{} { return r.Date }),
+		"code":     field(nonNullString, "ISO currency code.", func(r apiv1.CurrencyRate) interface{} { return r.Code }),
+		"name":     field(nonNullString, "Currency name.", func(r apiv1.CurrencyRate) interface{} { return r.Name }),
+		"nominal":  field(nonNullInt, "Number of units the value is quoted for.", func(r apiv1.CurrencyRate) interface{} { return r.Nominal }),
+		"value":    field(nonNullFloat, "Price of nominal units in RUB.", func(r apiv1.CurrencyRate) interface{} { return r.Value.Float64() }),
+		"previous": field(nonNullFloat, "Price on the previous CBR date.", func(r apiv1.CurrencyRate) interface{} { return r.Previous.Float64() }),
+		"unitValue": field(nonNullFloat, "Price of one unit in RUB; comparable across a change of nominal.", func(r apiv1.CurrencyRate) interface{} {
+			return unitValue(r)
+		}),
+		"previousNominal": field(graphql.Int, "Nominal before, on the first date with a new nominal.", func(r apiv1.CurrencyRate) interface{} {
+			if r.PreviousNominal == 0 {
+				return nil
+			}
+			return r.PreviousNominal
+		}),
+		"carriedFrom": field(graphql.String, "Earlier CBR date the rate was carried over from, when nothing was published for date.", func(r apiv1.CurrencyRate) interface{} {
+			if r.CarriedFrom == "" {
+				return nil
+			}
+			return r.CarriedFrom
+		}),
+	},
+})
+
+var cryptoCandleType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "CryptoCandle",
+	Description: "Candle of a cryptocurrency priced in RUB.",
+	Fields: graphql.Fields{
+		"time":   field(graphql.NewNonNull(graphql.DateTime), "Candle open time in UTC.", func(r apiv1.CryptoRate) interface{} { return r.Time }),
+		"symbol": field(nonNullString, "Base asset (BTC).", func(r apiv1.CryptoRate) interface{} { return r.Symbol }),
+		"open":   field(nonNullFloat, "", func(r apiv1.CryptoRate) interface{} { return r.Open.Float64() }),
+		"high":   field(nonNullFloat, "", func(r apiv1.CryptoRate) interface{} { return r.High.Float64() }),
+		"low":    field(nonNullFloat, "", func(r apiv1.CryptoRate) interface{} { return r.Low.Float64() }),
+		"close":  field(nonNullFloat, "", func(r apiv1.CryptoRate) interface{} { return r.Close.Float64() }),
+		"volume": field(nonNullFloat, "Volume in the base asset.", func(r apiv1.CryptoRate) interface{} { return r.Volume.Float64() }),
+	},
+})
+
+var statsType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "Stats",
+	Description: "Statistics of a series, computed like /v1/analytics.",
+	Fields: graphql.Fields{
+		"count":                     field(nonNullInt, "", func(s analytics.Summary) interface{} { return s.Count }),
+		"first":                     field(nonNullFloat, "", func(s analytics.Summary) interface{} { return s.First }),
+		"last":                      field(nonNullFloat, "", func(s analytics.Summary) interface{} { return s.Last }),
+		"mean":                      field(nonNullFloat, "", func(s analytics.Summary) interface{} { return s.Mean }),
+		"min":                       field(nonNullFloat, "", func(s analytics.Summary) interface{} { return s.Min }),
+		"max":                       field(nonNullFloat, "", func(s analytics.Summary) interface{} { return s.Max }),
+		"stddev":                    field(nonNullFloat, "Population standard deviation.", func(s analytics.Summary) interface{} { return s.StdDev }),
+		"coefficientOfVariationPct": field(nonNullFloat, "", func(s analytics.Summary) interface{} { return s.CoefficientOfVariation }),
+		"volatilityPct":             field(nonNullFloat, "Standard deviation of daily log returns.", func(s analytics.Summary) interface{} { return s.Volatility }),
+		"maxDrawdownPct":            field(nonNullFloat, "", func(s analytics.Summary) interface{} { return s.MaxDrawdown }),
+		"changePct":                 field(nonNullFloat, "", func(s analytics.Summary) interface{} { return s.ChangePct }),
+	},
+})
+
+var rateSeriesType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "RateSeries",
+	Description: "CBR rates of one currency over a date range.",
+	Fields: graphql.Fields{
+		"code": field(nonNullString, "", func(s series) interface{} { return s.symbol }),
+		"rates": {
+			Type:        graphql.NewList(graphql.NewNonNull(currencyRateType)),
+			Description: "Rates oldest first; null when they could not be loaded.",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return load(p.Context, fromContext(p.Context).cbrSeries, p.Source.(series).key()), nil
+			},
+		},
+		"stats": {
+			Type:        statsType,
+			Description: "Statistics of the per-unit values.",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				thunk := fromContext(p.Context).cbrSeries.Load(p.Context, p.Source.(series).key())
+				return func() (interface{}, error) {
+					data, err := thunk()
+					if err != nil {
+						return nil, err
+					}
+					var points []analytics.Point
+					for _, r := range data.([]apiv1.CurrencyRate) {
+						t, _ := time.Parse(dateLayout, r.Date)
+						points = append(points, analytics.Point{Time: t, Value: unitValue(r)})
+					}
+					return analytics.Summarize(points), nil
+				}, nil
+			},
+		},
+	},
+})
+
+var candleSeriesType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "CandleSeries",
+	Description: "Candles of one cryptocurrency over a date range.",
+	Fields: graphql.Fields{
+		"symbol": field(nonNullString, "", func(s series) interface{} { return s.symbol }),
+		"candles": {
+			Type:        graphql.NewList(graphql.NewNonNull(cryptoCandleType)),
+			Description: "Candles oldest first; null when they could not be loaded.",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return load(p.Context, fromContext(p.Context).cryptoSeries, p.Source.(series).key()), nil
+			},
+		},
+		"stats": {
+			Type:        statsType,
+			Description: "Statistics of the close prices.",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				thunk := fromContext(p.Context).cryptoSeries.Load(p.Context, p.Source.(series).key())
+				return func() (interface{}, error) {
+					data, err := thunk()
+					if err != nil {
+						return nil, err
+					}
+					var points []analytics.Point
+					for _, r := range data.([]apiv1.CryptoRate) {
+						points = append(points, analytics.Point{Time: r.Time, Value: r.Close.Float64()})
+					}
+					return analytics.Summarize(points), nil
+				}, nil
+			},
+		},
+	},
+})
+
+var conversionType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "Conversion",
+	Description: "Conversion of an amount via RUB cross rates.",
+	Fields: graphql.Fields{
+		"from":         field(nonNullString, "", func(c apiv1.Conversion) interface{} { return c.From }),
+		"to":           field(nonNullString, "", func(c apiv1.Conversion) interface{} { return c.To }),
+		"amount":       field(nonNullFloat, "", func(c apiv1.Conversion) interface{} { return c.Amount.Float64() }),
+		"result":       field(nonNullFloat, "", func(c apiv1.Conversion) interface{} { return c.Result.Float64() }),
+		"rate":         field(nonNullFloat, "Units of to per unit of from.", func(c apiv1.Conversion) interface{} { return c.Rate.Float64() }),
+		"date":         field(nonNullString, "Requested date.", func(c apiv1.Conversion) interface{} { return c.Date }),
+		"fromRateDate": field(nonNullString, "Date of the rate used for from.", func(c apiv1.Conversion) interface{} { return c.FromRateDate }),
+		"toRateDate":   field(nonNullString, "Date of the rate used for to.", func(c apiv1.Conversion) interface{} { return c.ToRateDate }),
+	},
+})
+
+// subscriptionList resolves one kind of subscription of the parent user.
+func subscriptionList(kind client.SubscriptionKind, description string) *graphql.Field {
+	return &graphql.Field{
+		Type:        graphql.NewList(nonNullString),
+		Description: description,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			key := subscriptionsKey{kind, p.Source.(subscriptions).telegramID}
+			return load(p.Context, fromContext(p.Context).subscriptions, key), nil
+		},
+	}
+}
+
+var subscriptionsType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "Subscriptions",
+	Description: "Rate subscriptions of a Telegram user.",
+	Fields: graphql.Fields{
+		"telegramId": field(graphql.NewNonNull(graphql.ID), "", func(s subscriptions) interface{} {
+			return strconv.FormatInt(s.telegramID, 10)
+		}),
+		"currencies": subscriptionList(client.CBRSubscriptions, "Subscribed currency codes."),
+		"crypto":     subscriptionList(client.CryptoSubscriptions, "Subscribed crypto symbols."),
+		"metals":     subscriptionList(client.MetalSubscriptions, "Subscribed precious metals (XAU)."),
+		"indicators": subscriptionList(client.IndicatorSubscriptions, "Subscribed CBR indicators (KEY_RATE)."),
+		"alerts":     subscriptionList(client.AlertSubscriptions, "Subscribed indicator alerts (BTC 1h rsi14>70)."),
+	},
+})
+
+func rangeArgs(symbols, description string) graphql.FieldConfigArgument {
+	return graphql.FieldConfigArgument{
+		symbols: {Type: stringList, Description: description},
+		"from":  {Type: nonNullString, Description: "First date (YYYY-MM-DD), inclusive."},
+		"to":    {Type: nonNullString, Description: "Last date (YYYY-MM-DD), inclusive."},
+	}
+}
+
+// Top-level fields are nullable, so a failing field leaves the others intact.
+var queryType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Query",
+	Fields: graphql.Fields{
+		"rates": {
+			Type:        graphql.NewList(graphql.NewNonNull(currencyRateType)),
+			Description: "CBR rates on a date, all currencies or the given codes in their order.",
+			Args: graphql.FieldConfigArgument{
+				"date":  {Type: graphql.String, Description: "CBR date (YYYY-MM-DD); the latest if omitted."},
+				"codes": {Type: graphql.NewList(nonNullString), Description: "Currency codes; all if omitted."},
+			},
+			Resolve: resolveRates,
+		},
+		"series": {
+			Type:        graphql.NewList(graphql.NewNonNull(rateSeriesType)),
+			Description: "CBR rate series of several currencies. Ranges are limited to 365 days.",
+			Args:        rangeArgs("codes", "Currency codes (USD)."),
+			Resolve:     resolveSeries("codes"),
+		},
+		"cryptoSymbols": {
+			Type:        graphql.NewList(nonNullString),
+			Description: "Available cryptocurrencies as base assets (BTC).",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return load(p.Context, fromContext(p.Context).cryptoSymbols, dataloader.StringKey("symbols")), nil
+			},
+		},
+		"candles": {
+			Type:        graphql.NewList(graphql.NewNonNull(candleSeriesType)),
+			Description: "Candle series of several cryptocurrencies. Ranges are limited to 365 days.",
+			Args:        rangeArgs("symbols", "Base assets (BTC)."),
+			Resolve:     resolveSeries("symbols"),
+		},
+		"convert": {
+			Type:        conversionType,
+			Description: "Converts an amount between currencies and cryptocurrencies; use aliases for several conversions.",
+			Args: graphql.FieldConfigArgument{
+				"from":   {Type: nonNullString, Description: "Currency code or crypto symbol."},
+				"to":     {Type: nonNullString, Description: "Currency code or crypto symbol."},
+				"amount": {Type: graphql.Float, DefaultValue: 1.0},
+				"date":   {Type: graphql.String, Description: "Date (YYYY-MM-DD); today if omitted."},
+			},
+			Resolve: resolveConvert,
+		},
+		"subscriptions": {
+			Type:        subscriptionsType,
+			Description: "Rate subscriptions of the Telegram user.",
+			Args: graphql.FieldConfigArgument{
+				"telegramId": {Type: graphql.NewNonNull(graphql.ID)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id, err := strconv.ParseInt(fmt.Sprint(p.Args["telegramId"]), 10, 64)
+				if err != nil || id <= 0 {
+					return nil, errors.New("telegramId must be a positive integer")
+				}
+				return subscriptions{telegramID: id}, nil
+			},
+		},
+	},
+})
+
+// NewSchema builds the GraphQL schema.
+func NewSchema() (graphql.Schema, error) {
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+}
+
+func resolveRates(p graphql.ResolveParams) (interface{}, error) {
+	date, err := optionalDate(p.Args, "date")
+	if err != nil {
+		return nil, err
+	}
+	codes := upperStrings(p.Args["codes"])
+	thunk := fromContext(p.Context).ratesByDate.Load(p.Context, dateKey(date))
+	return func() (interface{}, error) {
+		data, err := thunk()
+		if err != nil {
+			return nil, err
+		}
+		rates := data.([]apiv1.CurrencyRate)
+		if codes == nil {
+			return rates, nil
+		}
+		byCode := make(map[string]apiv1.CurrencyRate, len(rates))
+		for _, r := range rates {
+			byCode[r.Code] = r
+		}
+		out := make([]apiv1.CurrencyRate, 0, len(codes))
+		for _, c := range codes {
+			if r, ok := byCode[c]; ok {
+				out = append(out, r)
+			}
+		}
+		return out, nil
+	}, nil
+}
+
+// resolveSeries returns one series per symbol of the arg list; the points
+// are loaded by the series fields.
+func resolveSeries(arg string) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		from, err := requiredDate(p.Args, "from")
+		if err != nil {
+			return nil, err
+		}
+		to, err := requiredDate(p.Args, "to")
+		if err != nil {
+			return nil, err
+		}
+		if to.Before(from) {
+			return nil, errors.New("to must not be before from")
+		}
+		var out []series
+		for _, s := range upperStrings(p.Args[arg]) {
+			out = append(out, series{symbol: s, from: from, to: to})
+		}
+		return out, nil
+	}
+}
+
+func resolveConvert(p graphql.ResolveParams) (interface{}, error) {
+	date, err := optionalDate(p.Args, "date")
+	if err != nil {
+		return nil, err
+	}
+	amount, _ := p.Args["amount"].(float64)
+	if amount <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+	key := convertKey{
+		From:   strings.ToUpper(strings.TrimSpace(p.Args["from"].(string))),
+		To:     strings.ToUpper(strings.TrimSpace(p.Args["to"].(string))),
+		Amount: money.NewFromFloat(amount),
+		Date:   date,
+	}
+	return load(p.Context, fromContext(p.Context).conversions, key), nil
+}
+
+func requiredDate(args map[string]interface{}, name string) (time.Time, error) {
+	t, err := time.Parse(dateLayout, args[name].(string))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be a date in YYYY-MM-DD format", name)
+	}
+	return t, nil
+}
+
+func optionalDate(args map[string]interface{}, name string) (time.Time, error) {
+	if s, _ := args[name].(string); s != "" {
+		return requiredDate(args, name)
+	}
+	return time.Time{}, nil
+}
+
+// upperStrings returns a list argument as trimmed upper-case strings, or nil
+// when it is absent.
+func upperStrings(v interface{}) []string {
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil
+	}
+	out := make([]string, 0, len(list))
+	for _, s := range list {
+		out = append(out, strings.ToUpper(strings.TrimSpace(s.(string))))
+	}
+	return out
+}
+
+// unitValue is the price of one unit of r, derived from Value for a history
+// service without unit values. GraphQL serves prices as Float.
+func unitValue(r apiv1.CurrencyRate) float64 {
+	if r.UnitValue.IsPositive() {
+		return r.UnitValue.Float64()
+	}
+	return money.PerUnit(r.Value, r.Nominal).Float64()
+}
@@ -0,0 +1,179 @@
+// Package openapi holds the gateway's OpenAPI document and validates incoming
+// requests against it. Only the parts of OpenAPI the document uses are
+// supported: query and path parameters with primitive schemas, and JSON
+// object request bodies.
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+//go:embed openapi.json
+var document []byte
+
+// Document returns the raw OpenAPI document served at /api/openapi.
+func Document() []byte { return document }
+
+// Spec is the parsed subset of an OpenAPI document needed for validation.
+type Spec struct {
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components struct {
+		Schemas map[string]*Schema `json:"schemas"`
+	} `json:"components"`
+
+	templates []pathTemplate
+}
+
+// Operation is a single method on a path.
+type Operation struct {
+	OperationID string       `json:"operationId"`
+	Parameters  []Parameter  `json:"parameters"`
+	RequestBody *RequestBody `json:"requestBody"`
+}
+
+// Parameter is a query or path parameter.
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody describes an operation's body; only application/json is validated.
+type RequestBody struct {
+	Required bool `json:"required"`
+	Content  map[string]struct {
+		Schema *Schema `json:"schema"`
+	} `json:"content"`
+}
+
+// Schema is the subset of JSON Schema used by the document.
+type Schema struct {
+	Ref        string             `json:"$ref"`
+	Type       string             `json:"type"`
+	Format     string             `json:"format"`
+	Enum       []any              `json:"enum"`
+	Pattern    string             `json:"pattern"`
+	MinLength  *int               `json:"minLength"`
+	Minimum    *float64           `json:"minimum"`
+	Maximum    *float64           `json:"maximum"`
+	Required   []string           `json:"required"`
+	Properties map[string]*Schema `json:"properties"`
+
+	pattern *regexp.Regexp
+}
+
+type pathTemplate struct {
+	path     string
+	segments []string
+}
+
+// Load parses the embedded document.
+func Load() (*Spec, error) {
+	return Parse(document)
+}
+
+// Parse parses an OpenAPI document, resolves local schema references and
+// compiles patterns.
+func Parse(data []byte) (*Spec, error) {
+	var s Spec
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+	for path, methods := range s.Paths {
+		for method, op := range methods {
+			for i := range op.Parameters {
+				p := &op.Parameters[i]
+				if p.In != "query" && p.In != "path" {
+					return nil, fmt.Errorf("openapi: %s %s: parameters in %s are not supported", method, path, p.In)
+				}
+				if err := s.prepare(&p.Schema); err != nil {
+					return nil, fmt.Errorf("openapi: %s %s: %s: %w", method, path, p.Name, err)
+				}
+			}
+			if op.RequestBody != nil {
+				for ct, c := range op.RequestBody.Content {
+					if err := s.prepare(&c.Schema); err != nil {
+						return nil, fmt.Errorf("openapi: %s %s: body: %w", method, path, err)
+					}
+					op.RequestBody.Content[ct] = c
+				}
+			}
+		}
+		s.templates = append(s.templates, pathTemplate{path: path, segments: strings.Split(strings.Trim(path, "/"), "/")})
+	}
+	return &s, nil
+}
+
+// prepare replaces a reference with its target and compiles patterns, recursively.
+func (s *Spec) prepare(schema **Schema) error {
+	if *schema == nil {
+		return nil
+	}
+	if ref := (*schema).Ref; ref != "" {
+		name, ok := strings.CutPrefix(ref, "#/components/schemas/")
+		target := s.Components.Schemas[name]
+		if !ok || target == nil {
+			return fmt.Errorf("unresolved reference %q", ref)
+		}
+		*schema = target
+	}
+	sc := *schema
+	if sc.Pattern != "" && sc.pattern == nil {
+		re, err := regexp.Compile(sc.Pattern)
+		if err != nil {
+			return err
+		}
+		sc.pattern = re
+	}
+	for name := range sc.Properties {
+		prop := sc.Properties[name]
+		if err := s.prepare(&prop); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		sc.Properties[name] = prop
+	}
+	return nil
+}
+
+// Find returns the operation for method and a request path together with the
+// path parameters, or nil if the document does not describe it. Literal
+// segments take precedence over parameters.
+func (s *Spec) Find(method, path string) (*Operation, map[string]string) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	var (
+		best       *Operation
+		bestParams map[string]string
+		bestScore  = -1
+	)
+	for _, t := range s.templates {
+		if len(t.segments) != len(segments) {
+			continue
+		}
+		op := s.Paths[t.path][strings.ToLower(method)]
+		if op == nil {
+			continue
+		}
+		params := map[string]string{}
+		score := 0
+		for i, seg := range t.segments {
+			if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+				params[seg[1:len(seg)-1]] = segments[i]
+				continue
+			}
+			if seg != segments[i] {
+				score = -1
+				break
+			}
+			score++
+		}
+		if score > bestScore {
+			best, bestParams, bestScore = op, params, score
+		}
+	}
+	return best, bestParams
+}
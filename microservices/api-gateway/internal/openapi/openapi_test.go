@@ -0,0 +1,131 @@
+package openapi
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testDoc = `{
+  "paths": {
+    "/rates/range": {"get": {"parameters": [
+      {"name": "code", "in": "query", "required": true, "schema": {"type": "string", "minLength": 1}},
+      {"name": "from", "in": "query", "required": true, "schema": {"type": "string", "format": "date"}},
+      {"name": "amount", "in": "query", "schema": {"type": "number", "minimum": 0}},
+      {"name": "interval", "in": "query", "schema": {"type": "string", "enum": ["1h", "1d"]}},
+      {"name": "quote", "in": "query", "schema": {"type": "string", "pattern": "^[A-Za-z]{3}$"}}
+    ]}},
+    "/items/{id}": {"get": {"parameters": [
+      {"name": "id", "in": "path", "required": true, "schema": {"type": "integer"}}
+    ]}},
+    "/items/latest": {"get": {}},
+    "/subscriptions": {"post": {"requestBody": {"required": true, "content": {
+      "application/json": {"schema": {"$ref": "#/components/schemas/Sub"}}
+    }}}}
+  },
+  "components": {"schemas": {"Sub": {"type": "object", "required": ["telegram_id", "value"], "properties": {
+    "telegram_id": {"type": "integer"},
+    "value": {"type": "string", "minLength": 1}
+  }}}}
+}`
+
+func mustParse(t *testing.T) *Spec {
+	t.Helper()
+	s, err := Parse([]byte(testDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestLoad_embeddedDocument(t *testing.T) {
+	s, err := Load()
+	if err != nil {
+		t.Fatalf("embedded document: %v", err)
+	}
+	if op, _ := s.Find("GET", "/v1/rates/cbr/range"); op == nil {
+		t.Error("expected /v1/rates/cbr/range to be described")
+	}
+}
+
+func TestParse_unresolvedReference(t *testing.T) {
+	doc := `{"paths": {"/x": {"post": {"requestBody": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Missing"}}}}}}}}`
+	if _, err := Parse([]byte(doc)); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestFind(t *testing.T) {
+	s := mustParse(t)
+
+	if op, params := s.Find("GET", "/items/42"); op == nil || params["id"] != "42" {
+		t.Errorf("expected /items/{id} with id=42, got %v %v", op, params)
+	}
+	if op, params := s.Find("GET", "/items/latest"); op == nil || len(params) != 0 {
+		t.Errorf("literal segment should win over a parameter, got %v", params)
+	}
+	if op, _ := s.Find("POST", "/rates/range"); op != nil {
+		t.Error("POST /rates/range is not described")
+	}
+	if op, _ := s.Find("GET", "/rates/range/extra"); op != nil {
+		t.Error("longer path must not match")
+	}
+}
+
+func TestValidate_query(t *testing.T) {
+	s := mustParse(t)
+	tests := map[string]string{
+		"/rates/range?code=USD&from=2024-01-01":                           "",
+		"/rates/range?from=2024-01-01":                                    `query parameter "code" is required`,
+		"/rates/range?code=USD&from=01.01.2024":                           `query parameter "from" must be a date in YYYY-MM-DD format`,
+		"/rates/range?code=USD&from=2024-01-01&amount=-1":                 `query parameter "amount" must be at least 0`,
+		"/rates/range?code=USD&from=2024-01-01&amount=abc":                `query parameter "amount" must be a number`,
+		"/rates/range?code=USD&from=2024-01-01&interval=2h":               `query parameter "interval" must be one of [1h 1d]`,
+		"/rates/range?code=USD&from=2024-01-01&quote=EURO":                `query parameter "quote" must match ^[A-Za-z]{3}$`,
+		"/rates/range?code=USD&from=2024-01-01&interval=1d&quote=eur&x=1": "",
+		"/items/abc": `path parameter "id" must be an integer`,
+	}
+	for url, want := range tests {
+		req := httptest.NewRequest("GET", url, nil)
+		op, params := s.Find("GET", req.URL.Path)
+		got := strings.Join(op.Validate(req, params), "; ")
+		if got != want {
+			t.Errorf("%s: expected %q, got %q", url, want, got)
+		}
+	}
+}
+
+func TestValidate_body(t *testing.T) {
+	s := mustParse(t)
+	op, _ := s.Find("POST", "/subscriptions")
+	tests := map[string]string{
+		`{"telegram_id": 1, "value": "USD"}`:   "",
+		``:                                     "request body is required",
+		`{"telegram_id": 1`:                    "request body is not valid JSON",
+		`[]`:                                   "body must be an object",
+		`{"value": ""}`:                        `body field "telegram_id" is required; body field "value" must be at least 1 characters long`,
+		`{"telegram_id": "1", "value": "USD"}`: `body field "telegram_id" must be an integer`,
+		`{"telegram_id": 1.5, "value": "USD"}`: `body field "telegram_id" must be an integer`,
+	}
+	for body, want := range tests {
+		req := httptest.NewRequest("POST", "/subscriptions", strings.NewReader(body))
+		got := strings.Join(op.Validate(req, nil), "; ")
+		if got != want {
+			t.Errorf("%s: expected %q, got %q", body, want, got)
+		}
+		if rest, _ := io.ReadAll(req.Body); string(rest) != body {
+			t.Errorf("%s: body was not restored, got %q", body, rest)
+		}
+	}
+}
+
+func TestValidate_bodyContentType(t *testing.T) {
+	s := mustParse(t)
+	op, _ := s.Find("POST", "/subscriptions")
+	req := httptest.NewRequest("POST", "/subscriptions", strings.NewReader(`telegram_id=1`))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if got := op.Validate(req, nil); len(got) != 1 || got[0] != "request body must be application/json" {
+		t.Errorf("unexpected problems %v", got)
+	}
+}
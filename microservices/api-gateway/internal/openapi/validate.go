@@ -0,0 +1,206 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// maxBodyBytes bounds the request bodies read for validation.
+const maxBodyBytes = 1 << 20
+
+// Validate checks the query, path parameters and JSON body of r against op
+// and returns one message per failed check. The body is restored so it can
+// still be proxied.
+func (op *Operation) Validate(r *http.Request, pathParams map[string]string) []string {
+	var problems []string
+	query := r.URL.Query()
+	for _, p := range op.Parameters {
+		var (
+			value   string
+			present bool
+		)
+		if p.In == "path" {
+			value, present = pathParams[p.Name]
+		} else if vs, ok := query[p.Name]; ok && len(vs) > 0 {
+			value, present = vs[0], vs[0] != ""
+		}
+		where := p.In + " parameter " + strconv.Quote(p.Name)
+		if !present {
+			if p.Required {
+				problems = append(problems, where+" is required")
+			}
+			continue
+		}
+		if p.Schema != nil {
+			if msg := p.Schema.checkString(value); msg != "" {
+				problems = append(problems, where+" "+msg)
+			}
+		}
+	}
+	if op.RequestBody != nil {
+		problems = append(problems, op.validateBody(r)...)
+	}
+	return problems
+}
+
+func (op *Operation) validateBody(r *http.Request) []string {
+	content, ok := op.RequestBody.Content["application/json"]
+	if !ok || content.Schema == nil {
+		return nil
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes+1))
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return []string{"request body could not be read"}
+	}
+	if len(body) > maxBodyBytes {
+		return []string{fmt.Sprintf("request body exceeds %d bytes", maxBodyBytes)}
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		if op.RequestBody.Required {
+			return []string{"request body is required"}
+		}
+		return nil
+	}
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		if mt, _, _ := mime.ParseMediaType(ct); mt != "application/json" {
+			return []string{"request body must be application/json"}
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return []string{"request body is not valid JSON"}
+	}
+	return content.Schema.checkValue("body", v)
+}
+
+// checkString validates a parameter value given as text.
+func (s *Schema) checkString(value string) string {
+	switch s.Type {
+	case "integer":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return "must be an integer"
+		}
+		return s.checkNumber(float64(n))
+	case "number":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "must be a number"
+		}
+		return s.checkNumber(n)
+	case "boolean":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return "must be true or false"
+		}
+		return ""
+	}
+	return s.checkText(value)
+}
+
+func (s *Schema) checkText(value string) string {
+	if s.MinLength != nil && len(value) < *s.MinLength {
+		return fmt.Sprintf("must be at least %d characters long", *s.MinLength)
+	}
+	switch s.Format {
+	case "date":
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return "must be a date in YYYY-MM-DD format"
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return "must be an RFC 3339 timestamp"
+		}
+	}
+	if len(s.Enum) > 0 {
+		allowed := make([]string, 0, len(s.Enum))
+		for _, e := range s.Enum {
+			if fmt.Sprint(e) == value {
+				return ""
+			}
+			allowed = append(allowed, fmt.Sprint(e))
+		}
+		return "must be one of " + fmt.Sprint(allowed)
+	}
+	if s.pattern != nil && !s.pattern.MatchString(value) {
+		return "must match " + s.Pattern
+	}
+	return ""
+}
+
+func (s *Schema) checkNumber(n float64) string {
+	if s.Minimum != nil && n < *s.Minimum {
+		return fmt.Sprintf("must be at least %v", *s.Minimum)
+	}
+	if s.Maximum != nil && n > *s.Maximum {
+		return fmt.Sprintf("must be at most %v", *s.Maximum)
+	}
+	return ""
+}
+
+// checkValue validates a decoded JSON value; where names it in messages.
+func (s *Schema) checkValue(where string, v any) []string {
+	switch s.Type {
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return []string{where + " must be an object"}
+		}
+		var problems []string
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				problems = append(problems, fmt.Sprintf("%s field %q is required", where, name))
+			}
+		}
+		names := make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if fv, ok := obj[name]; ok {
+				problems = append(problems, s.Properties[name].checkValue(fmt.Sprintf("%s field %q", where, name), fv)...)
+			}
+		}
+		return problems
+	case "array":
+		if _, ok := v.([]any); !ok {
+			return []string{where + " must be an array"}
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return []string{where + " must be a string"}
+		}
+		if msg := s.checkText(str); msg != "" {
+			return []string{where + " " + msg}
+		}
+	case "integer", "number":
+		num, ok := v.(json.Number)
+		if !ok && s.Type == "integer" {
+			return []string{where + " must be an integer"}
+		}
+		if !ok {
+			return []string{where + " must be a number"}
+		}
+		if msg := s.checkString(num.String()); msg != "" {
+			return []string{where + " " + msg}
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return []string{where + " must be true or false"}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/apiv1"
+	"github.com/gorilla/websocket"
+)
+
+// writeWait bounds a single WebSocket write.
+const writeWait = 10 * time.Second
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	// The API is public and served with open CORS; the stream is read-only
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// request holds the parsed stream parameters shared by SSE and WebSocket.
+type request struct {
+	filter Filter
+	lastID uint64
+	resume bool
+}
+
+// parseRequest reads ?types=cbr,crypto, ?symbols=USD,BTC and the resume
+// position from the Last-Event-ID header or ?last_event_id=.
+func parseRequest(r *http.Request) (request, error) {
+	var req request
+	q := r.URL.Query()
+	req.filter.Types = csvSet(q.Get("types"), strings.ToLower)
+	for t := range req.filter.Types {
+		if t != TypeCBR && t != TypeCrypto {
+			return req, fmt.Errorf("invalid type %q, use cbr or crypto", t)
+		}
+	}
+	req.filter.Symbols = csvSet(q.Get("symbols"), strings.ToUpper)
+
+	last := r.Header.Get("Last-Event-ID")
+	if last == "" {
+		last = q.Get("last_event_id")
+	}
+	if last != "" {
+		id, err := strconv.ParseUint(last, 10, 64)
+		if err != nil {
+			return req, fmt.Errorf("invalid last event ID %q", last)
+		}
+		req.lastID, req.resume = id, true
+	}
+	return req, nil
+}
+
+func csvSet(s string, norm func(string) string) map[string]bool {
+	set := make(map[string]bool)
+	for _, v := range strings.Split(s, ",") {
+		if v = norm(strings.TrimSpace(v)); v != "" {
+			set[v] = true
+		}
+	}
+	return set
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(apiv1.NewError(status, msg))
+}
+
+// ServeSSE streams events as Server-Sent Events: the event ID as id, the type
+// as event and the DTO as data. A comment line is sent as a heartbeat.
+func (h *Hub) ServeSSE(w http.ResponseWriter, r *http.Request) {
+	req, err := parseRequest(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	backlog, sub := h.Subscribe(req.filter, req.lastID, req.resume)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 5000\n\n")
+	for _, e := range backlog {
+		writeSSE(w, e)
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			writeSSE(w, e)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		}
+		flusher.Flush()
+	}
+}
+
+func writeSSE(w http.ResponseWriter, e Event) {
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, e.Data)
+}
+
+// ServeWS streams events over a WebSocket as JSON text messages of the form
+// {"id", "type", "symbol", "data"}. Heartbeats are ping frames; a client that
+// stops answering them is disconnected. Messages from the client are ignored.
+func (h *Hub) ServeWS(w http.ResponseWriter, r *http.Request) {
+	req, err := parseRequest(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // the upgrader has answered with an HTTP error
+	}
+	defer conn.Close()
+
+	backlog, sub := h.Subscribe(req.filter, req.lastID, req.resume)
+	defer sub.Close()
+
+	// The read loop processes pongs and notices when the client goes away
+	closed := make(chan struct{})
+	conn.SetReadLimit(512)
+	conn.SetReadDeadline(time.Now().Add(2 * h.heartbeat))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * h.heartbeat))
+	})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for _, e := range backlog {
+		if writeWS(conn, e) != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case e, ok := <-sub.Events():
+			if !ok {
+				msg := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "client too slow, reconnect with last_event_id")
+				conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(writeWait))
+				return
+			}
+			if writeWS(conn, e) != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)) != nil {
+				return
+			}
+		}
+	}
+}
+
+func writeWS(conn *websocket.Conn, e Event) error {
+	conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return conn.WriteJSON(e)
+}
@@ -0,0 +1,171 @@
+// Package stream fans live rate updates out to SSE and WebSocket clients.
+// Every update is an Event with an increasing ID; the hub keeps the most
+// recent events so a reconnecting client can resume after the last ID it saw.
+package stream
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types.
+const (
+	TypeCBR    = "cbr"
+	TypeCrypto = "crypto"
+)
+
+// Defaults used by NewHub.
+const (
+	DefaultHistorySize = 1024
+	DefaultHeartbeat   = 15 * time.Second
+)
+
+// subscriberBuffer is how many events a client may fall behind before it is
+// disconnected; it can reconnect and resume from the last event it received.
+const subscriberBuffer = 256
+
+// Event is one rate update. Symbol is a currency code (USD) or a crypto base
+// asset (BTC); Data is the JSON of the apiv1 DTO of the update.
+type Event struct {
+	ID     uint64          `json:"id"`
+	Type   string          `json:"type"`
+	Symbol string          `json:"symbol"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// Filter selects the events a client receives. Empty sets match everything.
+type Filter struct {
+	Types   map[string]bool
+	Symbols map[string]bool
+}
+
+// Match reports whether e passes the filter.
+func (f Filter) Match(e Event) bool {
+	if len(f.Types) > 0 && !f.Types[e.Type] {
+		return false
+	}
+	return len(f.Symbols) == 0 || f.Symbols[e.Symbol]
+}
+
+// Hub distributes published events to subscribers. It is safe for
+// concurrent use.
+type Hub struct {
+	heartbeat time.Duration
+
+	mu      sync.Mutex
+	lastID  uint64
+	history []Event // ring buffer of the latest events
+	next    int     // position of the next write in history
+	full    bool
+	subs    map[*Subscription]struct{}
+}
+
+// NewHub returns a hub that remembers the last historySize events. Event IDs
+// start at the current time in microseconds, so they keep increasing across
+// restarts and a client resuming with an ID from a previous run gets no
+// duplicates.
+func NewHub(historySize int) *Hub {
+	if historySize <= 0 {
+		historySize = DefaultHistorySize
+	}
+	return &Hub{
+		heartbeat: DefaultHeartbeat,
+		lastID:    uint64(time.Now().UnixMicro()),
+		history:   make([]Event, historySize),
+		subs:      make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish sends data, encoded as JSON, to every matching subscriber.
+func (h *Hub) Publish(typ, symbol string, data any) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastID++
+	e := Event{ID: h.lastID, Type: typ, Symbol: strings.ToUpper(symbol), Data: raw}
+	h.history[h.next] = e
+	h.next = (h.next + 1) % len(h.history)
+	if h.next == 0 {
+		h.full = true
+	}
+
+	for s := range h.subs {
+		if !s.filter.Match(e) {
+			continue
+		}
+		select {
+		case s.events <- e:
+		default:
+			// Too slow: drop the client rather than block the publisher
+			h.remove(s)
+		}
+	}
+	return nil
+}
+
+// Subscription receives the events of one client.
+type Subscription struct {
+	hub    *Hub
+	filter Filter
+	events chan Event
+}
+
+// Events returns the channel of live events. It is closed when the client
+// falls too far behind or Close is called.
+func (s *Subscription) Events() <-chan Event { return s.events }
+
+// Close unsubscribes; it may be called more than once.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s)
+}
+
+// remove unregisters s; h.mu must be held.
+func (h *Hub) remove(s *Subscription) {
+	if _, ok := h.subs[s]; ok {
+		delete(h.subs, s)
+		close(s.events)
+	}
+}
+
+// Subscribe registers a client. When resume is set, the returned backlog
+// holds the remembered events after lastID that match f, oldest first;
+// live events follow on the subscription without gaps or duplicates.
+func (h *Hub) Subscribe(f Filter, lastID uint64, resume bool) ([]Event, *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var backlog []Event
+	if resume {
+		for _, e := range h.ordered() {
+			if e.ID > lastID && f.Match(e) {
+				backlog = append(backlog, e)
+			}
+		}
+	}
+	s := &Subscription{hub: h, filter: f, events: make(chan Event, subscriberBuffer)}
+	h.subs[s] = struct{}{}
+	return backlog, s
+}
+
+// ordered returns the remembered events oldest first; h.mu must be held.
+func (h *Hub) ordered() []Event {
+	if !h.full {
+		return h.history[:h.next]
+	}
+	return append(append([]Event(nil), h.history[h.next:]...), h.history[:h.next]...)
+}
+
+// Subscribers returns the number of connected clients.
+func (h *Hub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
@@ -0,0 +1,195 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/apiv1"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/events"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/metrics"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/tracing"
+	"github.com/segmentio/kafka-go"
+)
+
+// consumerName labels the stream's Kafka metrics and traces. The stream
+// reads without a consumer group: every gateway instance sees every update,
+// no offsets are committed and no group is left behind by an instance that
+// goes away.
+const consumerName = "api-gateway-stream"
+
+// partitionRetry spaces the attempts to look up the topic's partitions
+// while Kafka is not reachable yet.
+const partitionRetry = 5 * time.Second
+
+// Consume reads normalized rates from every partition of the topic and
+// publishes them to h until ctx is done. Each partition is read from its
+// newest message on; clients only ever see updates published while they
+// are connected or remembered by the hub.
+func Consume(ctx context.Context, brokers string, h *Hub) error {
+	addrs := strings.Split(brokers, ",")
+	var partitions []int
+	for {
+		var err error
+		if partitions, err = topicPartitions(ctx, addrs); err == nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(partitionRetry):
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errs := make(chan error, len(partitions))
+	for _, p := range partitions {
+		go func() { errs <- consumePartition(ctx, addrs, p, h) }()
+	}
+	var first error
+	for range partitions {
+		if err := <-errs; err != nil && first == nil {
+			first = err
+			cancel()
+		}
+	}
+	return first
+}
+
+// topicPartitions returns the partitions of the normalized-rates topic from
+// the first broker that answers.
+func topicPartitions(ctx context.Context, brokers []string) ([]int, error) {
+	var errs []error
+	for _, b := range brokers {
+		conn, err := (&kafka.Dialer{}).DialContext(ctx, "tcp", strings.TrimSpace(b))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		parts, err := conn.ReadPartitions(events.TopicNormalizedRates)
+		conn.Close()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if len(parts) == 0 {
+			errs = append(errs, fmt.Errorf("topic %s has no partitions", events.TopicNormalizedRates))
+			continue
+		}
+		ids := make([]int, len(parts))
+		for i, p := range parts {
+			ids[i] = p.ID
+		}
+		return ids, nil
+	}
+	return nil, errors.Join(errs...)
+}
+
+// consumePartition publishes the messages of one partition to h, starting
+// at the newest, until ctx is done.
+func consumePartition(ctx context.Context, brokers []string, partition int, h *Hub) error {
+	r := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   brokers,
+		Topic:     events.TopicNormalizedRates,
+		Partition: partition,
+		MinBytes:  1,
+		MaxBytes:  10e6,
+	})
+	defer r.Close()
+	// StartOffset only applies to consumer groups
+	if err := r.SetOffset(kafka.LastOffset); err != nil {
+		return err
+	}
+
+	for {
+		msg, err := r.ReadMessage(ctx)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return err
+		}
+		metrics.KafkaConsumed(events.TopicNormalizedRates, consumerName, r.Stats().Lag)
+		// Delivering the events to the clients ends the trace of the collector run
+		_, span := tracing.StartConsumer(ctx, consumerName, msg)
+		err = PublishMessage(h, msg.Value)
+		tracing.End(span, err)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+type baseEvent struct {
+	Source string          `json:"source"`
+	Rates  json.RawMessage `json:"rates"`
+}
+
+// PublishMessage publishes every rate of a normalized-rates message as an
+// apiv1 DTO: CBR rates as CurrencyRate, crypto rates as RUB CryptoRate
+// candles with the base asset as symbol. Malformed messages are skipped.
+func PublishMessage(h *Hub, data []byte) error {
+	var evt baseEvent
+	if json.Unmarshal(data, &evt) != nil {
+		return nil
+	}
+
+	switch evt.Source {
+	case string(events.SourceCBR):
+		var rates []events.NormalizedCBRRate
+		if json.Unmarshal(evt.Rates, &rates) != nil {
+			return nil
+		}
+		for _, r := range rates {
+			dto := apiv1.CurrencyRate{
+				Date:      r.Date.Format("2006-01-02"),
+				Code:      r.CurrencyCode,
+				Name:      r.CurrencyName,
+				Nominal:   r.Nominal,
+				Value:     r.ValueRUB,
+				Previous:  r.PreviousRUB,
+				UnitValue: r.UnitValueRUB,
+			}
+			if !dto.UnitValue.IsPositive() {
+				// An event from a normalizer without unit values
+				dto.UnitValue = money.PerUnit(r.ValueRUB, r.Nominal)
+			}
+			if err := h.Publish(TypeCBR, dto.Code, dto); err != nil {
+				return err
+			}
+		}
+
+	case string(events.SourceBinance):
+		var rates []events.NormalizedCryptoRate
+		if json.Unmarshal(evt.Rates, &rates) != nil {
+			return nil
+		}
+		for _, r := range rates {
+			if !r.PriceRUB.IsPositive() || !r.Close.IsPositive() {
+				continue
+			}
+			// OHLC arrive in USDT; scale them by the RUB close like history-service
+			rub := func(price money.Decimal) money.Decimal {
+				return price.Mul(r.PriceRUB).Div(r.Close, money.PriceScale)
+			}
+			dto := apiv1.CryptoRate{
+				Time:   r.Timestamp.UTC(),
+				Symbol: strings.TrimSuffix(r.Symbol, "USDT"),
+				Open:   rub(r.Open),
+				High:   rub(r.High),
+				Low:    rub(r.Low),
+				Close:  r.PriceRUB,
+				Volume: r.Volume,
+			}
+			if err := h.Publish(TypeCrypto, dto.Symbol, dto); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
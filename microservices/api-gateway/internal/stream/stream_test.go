@@ -0,0 +1,253 @@
+package stream
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/apiv1"
+	"github.com/gorilla/websocket"
+)
+
+func publish(t *testing.T, h *Hub, typ, symbol string) {
+	t.Helper()
+	if err := h.Publish(typ, symbol, map[string]string{"symbol": symbol}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func symbolsOf(events []Event) []string {
+	out := make([]string, 0, len(events))
+	for _, e := range events {
+		out = append(out, e.Symbol)
+	}
+	return out
+}
+
+func TestHub_filterAndLiveEvents(t *testing.T) {
+	h := NewHub(8)
+	_, sub := h.Subscribe(Filter{Types: map[string]bool{TypeCrypto: true}, Symbols: map[string]bool{"BTC": true}}, 0, false)
+	defer sub.Close()
+
+	publish(t, h, TypeCBR, "BTC")
+	publish(t, h, TypeCrypto, "ETH")
+	publish(t, h, TypeCrypto, "btc")
+
+	select {
+	case e := <-sub.Events():
+		if e.Type != TypeCrypto || e.Symbol != "BTC" {
+			t.Errorf("unexpected event %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+	}
+	select {
+	case e := <-sub.Events():
+		t.Errorf("unexpected extra event %+v", e)
+	default:
+	}
+}
+
+func TestHub_resumeReplaysAfterLastID(t *testing.T) {
+	h := NewHub(3)
+	for _, s := range []string{"USD", "EUR", "GBP", "CNY", "JPY"} {
+		publish(t, h, TypeCBR, s)
+	}
+
+	all, sub := h.Subscribe(Filter{}, 0, true)
+	sub.Close()
+	if got := strings.Join(symbolsOf(all), ","); got != "GBP,CNY,JPY" {
+		t.Fatalf("expected the last 3 events oldest first, got %s", got)
+	}
+
+	after, sub := h.Subscribe(Filter{}, all[1].ID, true)
+	sub.Close()
+	if len(after) != 1 || after[0].Symbol != "JPY" {
+		t.Errorf("expected only JPY after %d, got %v", all[1].ID, symbolsOf(after))
+	}
+
+	none, sub := h.Subscribe(Filter{}, 0, false)
+	sub.Close()
+	if len(none) != 0 {
+		t.Errorf("expected no backlog without resume, got %v", symbolsOf(none))
+	}
+}
+
+func TestHub_idsIncreaseAcrossHubs(t *testing.T) {
+	first := NewHub(1)
+	publish(t, first, TypeCBR, "USD")
+	backlog, sub := first.Subscribe(Filter{}, 0, true)
+	sub.Close()
+
+	time.Sleep(time.Millisecond)
+	second := NewHub(1)
+	publish(t, second, TypeCBR, "USD")
+	again, sub := second.Subscribe(Filter{}, backlog[0].ID, true)
+	sub.Close()
+	if len(again) != 1 {
+		t.Errorf("expected the new hub's event after the old ID, got %v", again)
+	}
+}
+
+func TestHub_dropsSlowSubscriber(t *testing.T) {
+	h := NewHub(8)
+	_, sub := h.Subscribe(Filter{}, 0, false)
+	for i := 0; i <= subscriberBuffer; i++ {
+		publish(t, h, TypeCBR, "USD")
+	}
+	if h.Subscribers() != 0 {
+		t.Fatal("expected the slow subscriber to be removed")
+	}
+	n := 0
+	for range sub.Events() {
+		n++
+	}
+	if n != subscriberBuffer {
+		t.Errorf("expected %d buffered events before close, got %d", subscriberBuffer, n)
+	}
+	sub.Close() // closing again is harmless
+}
+
+func TestServeSSE(t *testing.T) {
+	h := NewHub(8)
+	h.heartbeat = 20 * time.Millisecond
+	publish(t, h, TypeCBR, "USD")
+	publish(t, h, TypeCBR, "EUR")
+	srv := httptest.NewServer(http.HandlerFunc(h.ServeSSE))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"?symbols=eur,BTC", nil)
+	req.Header.Set("Last-Event-ID", "0")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected text/event-stream, got %q", ct)
+	}
+
+	lines := bufio.NewScanner(resp.Body)
+	next := func() string {
+		if !lines.Scan() {
+			t.Fatalf("stream ended: %v", lines.Err())
+		}
+		return lines.Text()
+	}
+	// retry hint, then the replayed EUR event
+	for next() != "" {
+	}
+	id := next()
+	if !strings.HasPrefix(id, "id: ") || next() != "event: cbr" || next() != `data: {"symbol":"EUR"}` {
+		t.Fatalf("unexpected replayed event starting with %q", id)
+	}
+	next()
+
+	publish(t, h, TypeCrypto, "BTC")
+	if line := next(); !strings.HasPrefix(line, "id: ") {
+		t.Fatalf("expected a live event, got %q", line)
+	}
+	if line := next(); line != "event: crypto" {
+		t.Fatalf("expected a crypto event, got %q", line)
+	}
+	next()
+	next()
+	if line := next(); line != ": heartbeat" {
+		t.Errorf("expected a heartbeat, got %q", line)
+	}
+}
+
+func TestServeSSE_invalidParameters(t *testing.T) {
+	h := NewHub(8)
+	for _, query := range []string{"types=fiat", "last_event_id=abc"} {
+		rec := httptest.NewRecorder()
+		h.ServeSSE(rec, httptest.NewRequest(http.MethodGet, "/v1/stream?"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, rec.Code)
+		}
+		var body apiv1.ErrorResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Error.Code != apiv1.CodeBadRequest {
+			t.Errorf("%s: expected a v1 error, got %s", query, rec.Body)
+		}
+	}
+	if h.Subscribers() != 0 {
+		t.Error("rejected requests must not subscribe")
+	}
+}
+
+func TestServeWS(t *testing.T) {
+	h := NewHub(8)
+	publish(t, h, TypeCBR, "USD")
+	publish(t, h, TypeCrypto, "BTC")
+	srv := httptest.NewServer(http.HandlerFunc(h.ServeWS))
+	defer srv.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "?types=crypto&last_event_id=0"
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	var e Event
+	if err := conn.ReadJSON(&e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Type != TypeCrypto || e.Symbol != "BTC" {
+		t.Fatalf("expected the replayed BTC event, got %+v", e)
+	}
+
+	publish(t, h, TypeCBR, "EUR")
+	publish(t, h, TypeCrypto, "ETH")
+	if err := conn.ReadJSON(&e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Symbol != "ETH" || string(e.Data) != `{"symbol":"ETH"}` {
+		t.Errorf("expected the live ETH event, got %+v", e)
+	}
+
+	conn.Close()
+	for deadline := time.Now().Add(time.Second); h.Subscribers() != 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("subscription not released after the client left")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestPublishMessage(t *testing.T) {
+	h := NewHub(8)
+	cbr := `{"source":"cbr","rates":[{"date":"2024-01-15T00:00:00Z","currency_code":"USD","currency_name":"US Dollar","nominal":1,"value_rub":90,"previous_rub":89}]}`
+	crypto := `{"source":"binance","rates":[{"symbol":"BTCUSDT","timestamp":"2024-01-15T10:00:00Z","open":40000,"high":42000,"low":39000,"close":41000,"volume":5,"price_rub":3690000}]}`
+	for _, msg := range []string{cbr, crypto, "not json", `{"source":"cbr","rates":{}}`} {
+		if err := PublishMessage(h, []byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	events, sub := h.Subscribe(Filter{}, 0, true)
+	sub.Close()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+
+	var rate apiv1.CurrencyRate
+	json.Unmarshal(events[0].Data, &rate)
+	if events[0].Type != TypeCBR || rate.Code != "USD" || rate.Date != "2024-01-15" || rate.Value.String() != "90" {
+		t.Errorf("unexpected CBR event %+v: %+v", events[0], rate)
+	}
+
+	var candle apiv1.CryptoRate
+	json.Unmarshal(events[1].Data, &candle)
+	if events[1].Symbol != "BTC" || candle.Symbol != "BTC" || candle.Close.String() != "3690000" || candle.High.String() != "3780000" {
+		t.Errorf("unexpected crypto event %+v: %+v", events[1], candle)
+	}
+	if events[1].ID <= events[0].ID {
+		t.Error("expected increasing IDs")
+	}
+}
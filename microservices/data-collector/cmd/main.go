@@ -1,7 +1,8 @@
 package main
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"os"
 	"os/signal"
 	"strconv"
@@ -10,6 +11,9 @@ import (
 
 	"github.com/casualdoto/go-currency-tracker/microservices/data-collector/internal/collector"
 	"github.com/casualdoto/go-currency-tracker/microservices/data-collector/internal/producer"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/health"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/logging"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/tracing"
 )
 
 func main() {
@@ -17,40 +21,97 @@ func main() {
 	cbrURL := getEnv("CBR_BASE_URL", "https://www.cbr-xml-daily.ru")
 	cbrInterval := getDurationEnv("COLLECT_INTERVAL_CBR", 86400) // daily
 	cryptoInterval := getDurationEnv("COLLECT_INTERVAL_CRYPTO", 60) // every minute
+	metalsURL := getEnv("CBR_METALS_URL", "https://www.cbr.ru")
+	metalsInterval := getDurationEnv("COLLECT_INTERVAL_METALS", 86400) // daily
+	metalsDays := getIntEnv("METALS_LOOKBACK_DAYS", 7)
+	indicatorsURL := getEnv("CBR_INDICATORS_URL", "https://www.cbr.ru")
+	indicatorsInterval := getDurationEnv("COLLECT_INTERVAL_INDICATORS", 86400) // daily
+	indicatorsDays := getIntEnv("INDICATORS_LOOKBACK_DAYS", 7)
+	// METRICS_PORT is the name the port had before it served health checks
+	httpPort := getEnv("HTTP_PORT", getEnv("METRICS_PORT", "9081"))
+
+	logging.Setup("data-collector")
+	shutdownTracing, err := tracing.Setup(context.Background(), "data-collector")
+	if err != nil {
+		slog.Error("tracing setup failed", "error", err)
+		os.Exit(1)
+	}
+	defer shutdownTracing(context.Background())
 
 	p := producer.New(brokers)
 	defer p.Close()
 
+	// Health checks and Prometheus metrics; the collector only needs Kafka
+	checker := health.New().Add("kafka", health.Kafka(brokers))
+	go func() {
+		slog.Info("http listening", "addr", ":"+httpPort)
+		if err := health.ListenAndServe(":"+httpPort, checker); err != nil {
+			slog.Error("http server failed", "error", err)
+		}
+	}()
+
 	cbrCollector := collector.NewCBR(cbrURL, p)
 	cryptoCollector := collector.NewCrypto(p)
+	metalsCollector := collector.NewMetals(metalsURL, metalsDays, p)
+	indicatorsCollector := collector.NewIndicators(indicatorsURL, indicatorsDays, p)
 
 	// Run CBR collector
 	go func() {
-		log.Println("Data Collector: starting CBR polling every", cbrInterval)
+		slog.Info("polling started", "source", "cbr", "interval", cbrInterval.String())
 		// Run immediately, then on schedule
 		if err := cbrCollector.Collect(); err != nil {
-			log.Printf("CBR collect error: %v", err)
+			slog.Error("collect failed", "source", "cbr", "error", err)
 		}
 		t := time.NewTicker(cbrInterval)
 		defer t.Stop()
 		for range t.C {
 			if err := cbrCollector.Collect(); err != nil {
-				log.Printf("CBR collect error: %v", err)
+				slog.Error("collect failed", "source", "cbr", "error", err)
 			}
 		}
 	}()
 
 	// Run Crypto collector
 	go func() {
-		log.Println("Data Collector: starting Crypto polling every", cryptoInterval)
+		slog.Info("polling started", "source", "binance", "interval", cryptoInterval.String())
 		if err := cryptoCollector.Collect(); err != nil {
-			log.Printf("Crypto collect error: %v", err)
+			slog.Error("collect failed", "source", "binance", "error", err)
 		}
 		t := time.NewTicker(cryptoInterval)
 		defer t.Stop()
 		for range t.C {
 			if err := cryptoCollector.Collect(); err != nil {
-				log.Printf("Crypto collect error: %v", err)
+				slog.Error("collect failed", "source", "binance", "error", err)
+			}
+		}
+	}()
+
+	// Run CBR metals collector
+	go func() {
+		slog.Info("polling started", "source", "cbr_metals", "interval", metalsInterval.String())
+		if err := metalsCollector.Collect(); err != nil {
+			slog.Error("collect failed", "source", "cbr_metals", "error", err)
+		}
+		t := time.NewTicker(metalsInterval)
+		defer t.Stop()
+		for range t.C {
+			if err := metalsCollector.Collect(); err != nil {
+				slog.Error("collect failed", "source", "cbr_metals", "error", err)
+			}
+		}
+	}()
+
+	// Run CBR key rate and RUONIA collector
+	go func() {
+		slog.Info("polling started", "source", "cbr_indicators", "interval", indicatorsInterval.String())
+		if err := indicatorsCollector.Collect(); err != nil {
+			slog.Error("collect failed", "source", "cbr_indicators", "error", err)
+		}
+		t := time.NewTicker(indicatorsInterval)
+		defer t.Stop()
+		for range t.C {
+			if err := indicatorsCollector.Collect(); err != nil {
+				slog.Error("collect failed", "source", "cbr_indicators", "error", err)
 			}
 		}
 	}()
@@ -58,7 +119,7 @@ func main() {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	log.Println("Data Collector: shutting down")
+	slog.Info("shutting down")
 }
 
 func getEnv(key, def string) string {
@@ -76,3 +137,12 @@ func getDurationEnv(key string, defaultSeconds int) time.Duration {
 	}
 	return time.Duration(defaultSeconds) * time.Second
 }
+
+func getIntEnv(key string, def int) int {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+	}
+	return def
+}
@@ -4,14 +4,20 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"time"
 
 	"github.com/casualdoto/go-currency-tracker/microservices/data-collector/internal/producer"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/calendar"
 	"github.com/casualdoto/go-currency-tracker/microservices/shared/events"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/logging"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/metrics"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/tracing"
 )
 
+var logger = logging.For("collector")
+
 // CBRCollector polls the CBR API and publishes raw CBR rates to Kafka.
 type CBRCollector struct {
 	baseURL string
@@ -23,7 +29,7 @@ func NewCBR(baseURL string, prod *producer.Producer) *CBRCollector {
 	return &CBRCollector{
 		baseURL: baseURL,
 		prod:    prod,
-		client:  &http.Client{Timeout: 15 * time.Second},
+		client:  &http.Client{Timeout: 15 * time.Second, Transport: metrics.Transport(metrics.SourceCBR, tracing.Transport(nil))},
 	}
 }
 
@@ -33,13 +39,15 @@ type cbrResponse struct {
 }
 
 type cbrValute struct {
-	ID       string  `json:"ID"`
-	NumCode  string  `json:"NumCode"`
-	CharCode string  `json:"CharCode"`
-	Nominal  int     `json:"Nominal"`
-	Name     string  `json:"Name"`
-	Value    float64 `json:"Value"`
-	Previous float64 `json:"Previous"`
+	ID       string        `json:"ID"`
+	NumCode  string        `json:"NumCode"`
+	CharCode string        `json:"CharCode"`
+	Nominal  int           `json:"Nominal"`
+	Name     string        `json:"Name"`
+	Value    money.Decimal `json:"Value"`
+	Previous money.Decimal `json:"Previous"`
+	// VunitRate, the price of one unit, is only in newer sheets.
+	VunitRate *money.Decimal `json:"VunitRate"`
 }
 
 // parseCBRResponse converts a decoded CBR API response into a slice of RawCBRRate.
@@ -55,15 +63,32 @@ func parseCBRResponse(data cbrResponse, collectedAt time.Time) []events.RawCBRRa
 			Name:        v.Name,
 			Value:       v.Value,
 			Previous:    v.Previous,
+			VunitRate:   v.VunitRate,
 			CollectedAt: collectedAt,
 		})
 	}
 	return rates
 }
 
+// Collect fetches the daily sheet and publishes it. Every run is the root of
+// a trace and gets a request ID of its own, which the published message
+// carries on to its consumers.
 func (c *CBRCollector) Collect() error {
+	ctx := logging.WithRequestID(context.Background(), logging.NewRequestID())
+	ctx, span := tracing.Start(ctx, "cbr collect")
+	err := c.collect(ctx)
+	tracing.End(span, err)
+	return err
+}
+
+func (c *CBRCollector) collect(ctx context.Context) error {
+	start := time.Now()
 	url := fmt.Sprintf("%s/daily_json.js", c.baseURL)
-	resp, err := c.client.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("cbr fetch: %w", err)
+	}
+	resp, err := c.client.Do(req)
 	if err != nil {
 		return fmt.Errorf("cbr fetch: %w", err)
 	}
@@ -80,15 +105,25 @@ func (c *CBRCollector) Collect() error {
 
 	now := time.Now()
 	rates := parseCBRResponse(data, now)
+	for i := range rates {
+		rates[i].SourceURL = url
+	}
 
 	event := events.RawCBRRatesEvent{Source: events.SourceCBR, Rates: rates}
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	if err := c.prod.Publish(ctx, events.TopicRawRates, event); err != nil {
 		return fmt.Errorf("cbr publish: %w", err)
 	}
 
-	log.Printf("CBRCollector: published %d rates for date %s", len(rates), data.Date)
+	// The normalizer quarantines a sheet with an unrecognised date, so it is
+	// still published.
+	day := data.Date
+	if d, err := calendar.ParseSheetDate(data.Date); err == nil {
+		day = d.Format(time.DateOnly)
+	}
+	logger.InfoContext(ctx, "published rates", "source", events.SourceCBR, "count", len(rates),
+		"date", day, "duration_ms", logging.Millis(time.Since(start)))
 	return nil
 }
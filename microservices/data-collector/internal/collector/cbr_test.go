@@ -10,6 +10,7 @@ import (
 
 	"github.com/casualdoto/go-currency-tracker/microservices/data-collector/internal/producer"
 	"github.com/casualdoto/go-currency-tracker/microservices/shared/events"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
 )
 
 // ─── helpers ──────────────────────────────────────────────────────────────────
@@ -22,14 +23,14 @@ func stubCBRServer(t *testing.T, resp cbrResponse) *httptest.Server {
 	}))
 }
 
-func sampleValute(charCode string, value, previous float64) cbrValute {
+func sampleValute(charCode string, value, previous string) cbrValute {
 	return cbrValute{
 		CharCode: charCode,
 		NumCode:  "840",
 		Nominal:  1,
 		Name:     charCode + " test",
-		Value:    value,
-		Previous: previous,
+		Value:    money.MustParse(value),
+		Previous: money.MustParse(previous),
 	}
 }
 
@@ -39,8 +40,8 @@ func TestParseCBRResponse_basic(t *testing.T) {
 	data := cbrResponse{
 		Date: "2026/04/15 11:30:00",
 		Valute: map[string]cbrValute{
-			"USD": sampleValute("USD", 90.5, 89.0),
-			"EUR": sampleValute("EUR", 98.2, 97.0),
+			"USD": sampleValute("USD", "90.5", "89.0"),
+			"EUR": sampleValute("EUR", "98.2", "97.0"),
 		},
 	}
 	now := time.Now()
@@ -59,11 +60,11 @@ func TestParseCBRResponse_basic(t *testing.T) {
 	if !ok {
 		t.Fatal("USD not found in result")
 	}
-	if usd.Value != 90.5 {
-		t.Errorf("USD Value: expected 90.5, got %f", usd.Value)
+	if usd.Value.String() != "90.5" {
+		t.Errorf("USD Value: expected 90.5, got %s", usd.Value)
 	}
-	if usd.Previous != 89.0 {
-		t.Errorf("USD Previous: expected 89.0, got %f", usd.Previous)
+	if usd.Previous.String() != "89" {
+		t.Errorf("USD Previous: expected 89, got %s", usd.Previous)
 	}
 	if usd.Nominal != 1 {
 		t.Errorf("USD Nominal: expected 1, got %d", usd.Nominal)
@@ -73,6 +74,30 @@ func TestParseCBRResponse_basic(t *testing.T) {
 	}
 }
 
+func TestCBRResponse_decodesExactValues(t *testing.T) {
+	var data cbrResponse
+	body := `{"Date":"2026-04-15T11:30:00+03:00","Valute":{"USD":{"CharCode":"USD","Nominal":1,"Value":81.0123,"Previous":80.1}}}`
+	if err := json.Unmarshal([]byte(body), &data); err != nil {
+		t.Fatal(err)
+	}
+	usd := data.Valute["USD"]
+	if usd.Value.String() != "81.0123" || usd.Previous.String() != "80.1" {
+		t.Errorf("expected 81.0123 and 80.1 as published, got %s and %s", usd.Value, usd.Previous)
+	}
+}
+
+func TestParseCBRResponse_passesVunitRate(t *testing.T) {
+	var data cbrResponse
+	body := `{"Date":"2026-04-15T11:30:00+03:00","Valute":{"VND":{"CharCode":"VND","Nominal":10000,"Value":31.4567,"Previous":31.5,"VunitRate":0.00314567}}}`
+	if err := json.Unmarshal([]byte(body), &data); err != nil {
+		t.Fatal(err)
+	}
+	rates := parseCBRResponse(data, time.Now())
+	if len(rates) != 1 || rates[0].VunitRate.String() != "0.00314567" {
+		t.Errorf("expected VunitRate 0.00314567, got %+v", rates)
+	}
+}
+
 func TestParseCBRResponse_emptyValute(t *testing.T) {
 	data := cbrResponse{
 		Date:   "2026/04/15 11:30:00",
@@ -92,7 +117,7 @@ func TestParseCBRResponse_preservesDate(t *testing.T) {
 	wantDate := "2026/04/15 11:30:00"
 	data := cbrResponse{
 		Date:   wantDate,
-		Valute: map[string]cbrValute{"USD": sampleValute("USD", 90, 89)},
+		Valute: map[string]cbrValute{"USD": sampleValute("USD", "90", "89")},
 	}
 	rates := parseCBRResponse(data, time.Now())
 
@@ -106,11 +131,11 @@ func TestParseCBRResponse_preservesDate(t *testing.T) {
 
 func TestParseCBRResponse_multipleRates(t *testing.T) {
 	valutes := map[string]cbrValute{
-		"USD": sampleValute("USD", 90, 89),
-		"EUR": sampleValute("EUR", 98, 97),
-		"GBP": sampleValute("GBP", 114, 113),
-		"CNY": sampleValute("CNY", 12, 11),
-		"JPY": sampleValute("JPY", 0.6, 0.59),
+		"USD": sampleValute("USD", "90", "89"),
+		"EUR": sampleValute("EUR", "98", "97"),
+		"GBP": sampleValute("GBP", "114", "113"),
+		"CNY": sampleValute("CNY", "12", "11"),
+		"JPY": sampleValute("JPY", "0.6", "0.59"),
 	}
 	data := cbrResponse{Date: "2026/04/15 11:30:00", Valute: valutes}
 	rates := parseCBRResponse(data, time.Now())
@@ -182,8 +207,8 @@ func TestCBRCollector_Collect_parsesAndReachesPublish(t *testing.T) {
 	srv := stubCBRServer(t, cbrResponse{
 		Date: "2026/04/15 11:30:00",
 		Valute: map[string]cbrValute{
-			"USD": sampleValute("USD", 90.5, 89.0),
-			"EUR": sampleValute("EUR", 98.2, 97.0),
+			"USD": sampleValute("USD", "90.5", "89.0"),
+			"EUR": sampleValute("EUR", "98.2", "97.0"),
 		},
 	})
 	defer srv.Close()
@@ -3,16 +3,19 @@ package collector
 import (
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
-	"log"
 	"net"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/adshao/go-binance/v2"
 	"github.com/casualdoto/go-currency-tracker/microservices/data-collector/internal/producer"
 	"github.com/casualdoto/go-currency-tracker/microservices/shared/events"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/logging"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/metrics"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/tracing"
 )
 
 var trackedSymbols = []string{
@@ -29,12 +32,12 @@ type CryptoCollector struct {
 func NewCrypto(prod *producer.Producer) *CryptoCollector {
 	httpClient := &http.Client{
 		Timeout: 30 * time.Second,
-		Transport: &http.Transport{
+		Transport: metrics.Transport(metrics.SourceBinance, tracing.Transport(&http.Transport{
 			DialContext:           (&net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
 			TLSHandshakeTimeout:   10 * time.Second,
 			ResponseHeaderTimeout: 10 * time.Second,
 			TLSClientConfig:       &tls.Config{InsecureSkipVerify: false},
-		},
+		})),
 	}
 	bc := binance.NewClient("", "")
 	bc.HTTPClient = httpClient
@@ -42,50 +45,110 @@ func NewCrypto(prod *producer.Producer) *CryptoCollector {
 	return &CryptoCollector{prod: prod, client: bc}
 }
 
+// Collect fetches the tickers and publishes them. Every run is the root of a
+// trace and gets a request ID of its own, which the published message
+// carries on to its consumers.
 func (c *CryptoCollector) Collect() error {
+	ctx := logging.WithRequestID(context.Background(), logging.NewRequestID())
+	ctx, span := tracing.Start(ctx, "binance collect")
+	err := c.collect(ctx)
+	tracing.End(span, err)
+	return err
+}
+
+func (c *CryptoCollector) collect(ctx context.Context) error {
 	now := time.Now()
 	rates := make([]events.RawCryptoRate, 0, len(trackedSymbols))
+	var unparseable []events.QuarantinedRate
 
 	for _, symbol := range trackedSymbols {
-		ticker, err := c.client.NewListPriceChangeStatsService().Symbol(symbol).Do(context.Background())
+		ticker, err := c.client.NewListPriceChangeStatsService().Symbol(symbol).Do(ctx)
 		if err != nil {
-			log.Printf("CryptoCollector: failed to get ticker for %s: %v", symbol, err)
+			logger.WarnContext(ctx, "ticker fetch failed", "symbol", symbol, "error", err)
 			continue
 		}
 		if len(ticker) == 0 {
 			continue
 		}
-		t := ticker[0]
-		open, _ := strconv.ParseFloat(t.OpenPrice, 64)
-		high, _ := strconv.ParseFloat(t.HighPrice, 64)
-		low, _ := strconv.ParseFloat(t.LowPrice, 64)
-		closeP, _ := strconv.ParseFloat(t.LastPrice, 64)
-		vol, _ := strconv.ParseFloat(t.Volume, 64)
-
-		rates = append(rates, events.RawCryptoRate{
-			Symbol:      symbol,
-			Timestamp:   now,
-			Open:        open,
-			High:        high,
-			Low:         low,
-			Close:       closeP,
-			Volume:      vol,
-			CollectedAt: now,
-		})
+		rate, err := parseTicker(ticker[0])
+		if err != nil {
+			// A ticker that does not parse is quarantined as Binance sent
+			// it rather than published with zero prices
+			logger.WarnContext(ctx, "ticker parse failed", "symbol", symbol, "error", err)
+			unparseable = append(unparseable, quarantineTicker(ticker[0], err, now))
+			continue
+		}
+		rate.Symbol, rate.Timestamp, rate.CollectedAt = symbol, now, now
+		rates = append(rates, rate)
 	}
+	c.quarantine(ctx, unparseable)
 
 	if len(rates) == 0 {
 		return fmt.Errorf("no crypto rates collected")
 	}
 
 	event := events.RawCryptoRatesEvent{Source: events.SourceBinance, Rates: rates}
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	if err := c.prod.Publish(ctx, events.TopicRawRates, event); err != nil {
 		return fmt.Errorf("crypto publish: %w", err)
 	}
 
-	log.Printf("CryptoCollector: published %d rates", len(rates))
+	logger.InfoContext(ctx, "published rates", "source", events.SourceBinance, "count", len(rates),
+		"duration_ms", logging.Millis(time.Since(now)))
 	return nil
 }
+
+// parseTicker parses the prices and the volume of a 24hr ticker.
+func parseTicker(t *binance.PriceChangeStats) (events.RawCryptoRate, error) {
+	var r events.RawCryptoRate
+	for _, f := range []struct {
+		name  string
+		value string
+		dst   *money.Decimal
+	}{
+		{"open", t.OpenPrice, &r.Open},
+		{"high", t.HighPrice, &r.High},
+		{"low", t.LowPrice, &r.Low},
+		{"last", t.LastPrice, &r.Close},
+		{"volume", t.Volume, &r.Volume},
+	} {
+		v, err := money.Parse(f.value)
+		if err != nil {
+			return events.RawCryptoRate{}, fmt.Errorf("%s: %w", f.name, err)
+		}
+		*f.dst = v
+	}
+	return r, nil
+}
+
+// quarantineTicker records a ticker that failed to parse with its payload.
+func quarantineTicker(t *binance.PriceChangeStats, err error, at time.Time) events.QuarantinedRate {
+	raw, _ := json.Marshal(t)
+	return events.QuarantinedRate{
+		Source:        events.SourceBinance,
+		Rule:          events.RuleUnparseable,
+		Reason:        err.Error(),
+		Rate:          raw,
+		QuarantinedAt: at.UTC(),
+	}
+}
+
+// quarantine publishes the unparseable tickers of a run to the quarantine
+// topic and counts them like the rejections of the normalizer. A failure
+// is logged; the parsed rates are published regardless.
+func (c *CryptoCollector) quarantine(ctx context.Context, rates []events.QuarantinedRate) {
+	if len(rates) == 0 {
+		return
+	}
+	for range rates {
+		metrics.RateRejected(string(events.SourceBinance), events.RuleUnparseable)
+	}
+	event := events.QuarantinedRatesEvent{Source: events.SourceBinance, Rates: rates}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := c.prod.Publish(ctx, events.TopicQuarantinedRates, event); err != nil {
+		logger.ErrorContext(ctx, "quarantine publish failed", "source", events.SourceBinance, "count", len(rates), "error", err)
+	}
+}
@@ -0,0 +1,43 @@
+package collector
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/events"
+)
+
+func TestParseTicker_exactValues(t *testing.T) {
+	r, err := parseTicker(&binance.PriceChangeStats{
+		OpenPrice: "41000.10", HighPrice: "42000", LowPrice: "40000.5", LastPrice: "41500.01", Volume: "1234.5678",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Open.String() != "41000.1" || r.Close.String() != "41500.01" || r.Volume.String() != "1234.5678" {
+		t.Errorf("unexpected rate %+v", r)
+	}
+}
+
+func TestParseTicker_unparseableQuarantined(t *testing.T) {
+	ticker := &binance.PriceChangeStats{Symbol: "BTCUSDT", OpenPrice: "41000", HighPrice: "42000", LowPrice: "40000", LastPrice: "n/a", Volume: "1"}
+	_, err := parseTicker(ticker)
+	if err == nil {
+		t.Fatal("expected an error for a non-numeric last price")
+	}
+
+	at := time.Date(2026, 3, 28, 9, 0, 0, 0, time.UTC)
+	q := quarantineTicker(ticker, err, at)
+	if q.Source != events.SourceBinance || q.Rule != events.RuleUnparseable || !q.QuarantinedAt.Equal(at) {
+		t.Errorf("unexpected quarantined rate %+v", q)
+	}
+	var raw binance.PriceChangeStats
+	if err := json.Unmarshal(q.Rate, &raw); err != nil || raw.Symbol != "BTCUSDT" || raw.LastPrice != "n/a" {
+		t.Errorf("expected the ticker as Binance sent it, got %s (%v)", q.Rate, err)
+	}
+	if q.Reason != err.Error() {
+		t.Errorf("expected the parse error %q as the reason, got %q", err, q.Reason)
+	}
+}
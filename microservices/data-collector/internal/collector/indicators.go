@@ -0,0 +1,202 @@
+package collector
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/data-collector/internal/producer"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/calendar"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/events"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/logging"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/metrics"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/tracing"
+)
+
+// indicatorFeed is a DailyInfo web service method serving one indicator:
+// every record element holds the date a value took effect and the value.
+type indicatorFeed struct {
+	indicator string
+	method    string
+	record    string
+	date      string
+	value     string
+}
+
+// indicatorFeeds are the DailyInfo methods the collector polls.
+var indicatorFeeds = []indicatorFeed{
+	{indicator: events.IndicatorKeyRate, method: "KeyRateXML", record: "KR", date: "DT", value: "Rate"},
+	{indicator: events.IndicatorRUONIA, method: "RuoniaXML", record: "ro", date: "D0", value: "ruo"},
+}
+
+// IndicatorsCollector polls the CBR key rate and RUONIA from the DailyInfo
+// web service and publishes them to Kafka. Like MetalsCollector, every run
+// asks for the last days days and storage upserts the repeats.
+type IndicatorsCollector struct {
+	baseURL string
+	days    int
+	prod    *producer.Producer
+	client  *http.Client
+}
+
+func NewIndicators(baseURL string, days int, prod *producer.Producer) *IndicatorsCollector {
+	if days < 1 {
+		days = 1
+	}
+	return &IndicatorsCollector{
+		baseURL: baseURL,
+		days:    days,
+		prod:    prod,
+		client:  &http.Client{Timeout: 15 * time.Second, Transport: metrics.Transport(metrics.SourceCBRIndicators, tracing.Transport(nil))},
+	}
+}
+
+// indicatorRecord is a record of a DailyInfo answer with its fields by name.
+type indicatorRecord struct {
+	Fields []struct {
+		XMLName xml.Name
+		Value   string `xml:",chardata"`
+	} `xml:",any"`
+}
+
+func (r indicatorRecord) field(name string) string {
+	for _, f := range r.Fields {
+		if f.XMLName.Local == name {
+			return strings.TrimSpace(f.Value)
+		}
+	}
+	return ""
+}
+
+// decodeIndicatorRecords returns the feed's record elements of a DailyInfo
+// answer, wherever the SOAP dataset wrapping puts them.
+func decodeIndicatorRecords(r io.Reader, feed indicatorFeed) ([]indicatorRecord, error) {
+	dec := xml.NewDecoder(r)
+	var records []indicatorRecord
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != feed.record {
+			continue
+		}
+		var rec indicatorRecord
+		if err := dec.DecodeElement(&rec, &start); err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	if records == nil {
+		records = []indicatorRecord{}
+	}
+	return records, nil
+}
+
+// parseIndicatorRecords converts decoded records into RawIndicatorRate
+// values of the feed's indicator.
+// Pure function — no I/O, directly testable.
+func parseIndicatorRecords(records []indicatorRecord, feed indicatorFeed, collectedAt time.Time) ([]events.RawIndicatorRate, error) {
+	rates := make([]events.RawIndicatorRate, 0, len(records))
+	for _, rec := range records {
+		date := rec.field(feed.date)
+		if date == "" {
+			return nil, fmt.Errorf("%s record without %s", feed.indicator, feed.date)
+		}
+		value, err := money.Parse(rec.field(feed.value))
+		if err != nil {
+			return nil, fmt.Errorf("%s on %s: %q: %w", feed.indicator, date, rec.field(feed.value), err)
+		}
+		rates = append(rates, events.RawIndicatorRate{
+			Date:        date,
+			Indicator:   feed.indicator,
+			Value:       value,
+			CollectedAt: collectedAt,
+		})
+	}
+	return rates, nil
+}
+
+// Collect fetches the values of the last days of every indicator and
+// publishes them as one batch. Like the CBR collector, every run is the
+// root of a trace with its own request ID.
+func (c *IndicatorsCollector) Collect() error {
+	ctx := logging.WithRequestID(context.Background(), logging.NewRequestID())
+	ctx, span := tracing.Start(ctx, "cbr indicators collect")
+	err := c.collect(ctx)
+	tracing.End(span, err)
+	return err
+}
+
+func (c *IndicatorsCollector) collect(ctx context.Context) error {
+	start := time.Now()
+	to := calendar.Today()
+	from := to.AddDate(0, 0, 1-c.days)
+
+	var rates []events.RawIndicatorRate
+	for _, feed := range indicatorFeeds {
+		got, err := c.fetch(ctx, feed, from, to)
+		if err != nil {
+			return err
+		}
+		rates = append(rates, got...)
+	}
+	if len(rates) == 0 {
+		// Nothing published in the window (a long holiday); not an error.
+		logger.InfoContext(ctx, "no indicator values", "from", from.Format(time.DateOnly), "to", to.Format(time.DateOnly))
+		return nil
+	}
+
+	event := events.RawIndicatorRatesEvent{Source: events.SourceCBRIndicators, Rates: rates}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if err := c.prod.Publish(ctx, events.TopicRawRates, event); err != nil {
+		return fmt.Errorf("indicators publish: %w", err)
+	}
+
+	logger.InfoContext(ctx, "published rates", "source", events.SourceCBRIndicators, "count", len(rates),
+		"from", from.Format(time.DateOnly), "to", to.Format(time.DateOnly), "duration_ms", logging.Millis(time.Since(start)))
+	return nil
+}
+
+// fetch asks the feed's DailyInfo method for the values from from to to.
+func (c *IndicatorsCollector) fetch(ctx context.Context, feed indicatorFeed, from, to time.Time) ([]events.RawIndicatorRate, error) {
+	url := fmt.Sprintf("%s/DailyInfoWebServ/DailyInfo.asmx/%s?fromDate=%s&ToDate=%s",
+		c.baseURL, feed.method, from.Format(time.DateOnly), to.Format(time.DateOnly))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("indicators fetch %s: %w", feed.indicator, err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("indicators fetch %s: %w", feed.indicator, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("indicators status %d for %s", resp.StatusCode, feed.indicator)
+	}
+
+	records, err := decodeIndicatorRecords(resp.Body, feed)
+	if err != nil {
+		return nil, fmt.Errorf("indicators decode %s: %w", feed.indicator, err)
+	}
+	rates, err := parseIndicatorRecords(records, feed, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("indicators decode %s: %w", feed.indicator, err)
+	}
+	for i := range rates {
+		rates[i].SourceURL = url
+	}
+	return rates, nil
+}
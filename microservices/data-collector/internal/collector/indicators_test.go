@@ -0,0 +1,148 @@
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/data-collector/internal/producer"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/calendar"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/events"
+)
+
+// keyRateXML is a KeyRateXML answer as the CBR sends it.
+const keyRateXML = `<?xml version="1.0" encoding="utf-8"?>
+<KeyRate xmlns="">
+  <KR><DT>2026-04-13T00:00:00+03:00</DT><Rate>16.00</Rate></KR>
+  <KR><DT>2026-04-14T00:00:00+03:00</DT><Rate>15.50</Rate></KR>
+</KeyRate>`
+
+// ruoniaXML is a RuoniaXML answer as the CBR sends it.
+const ruoniaXML = `<?xml version="1.0" encoding="utf-8"?>
+<Ruonia xmlns="">
+  <ro><D0>2026-04-13T00:00:00+03:00</D0><ruo>15.8400</ruo><vol>412.50</vol><DateUpdate>2026-04-14T14:05:00+03:00</DateUpdate></ro>
+</Ruonia>`
+
+// stubIndicatorsServer serves the DailyInfo methods in bodies and records
+// the query of the last request.
+func stubIndicatorsServer(t *testing.T, bodies map[string]string, query *string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := bodies[strings.TrimPrefix(r.URL.Path, "/DailyInfoWebServ/DailyInfo.asmx/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if query != nil {
+			*query = r.URL.RawQuery
+		}
+		w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		w.Write([]byte(body))
+	}))
+}
+
+// ─── decodeIndicatorRecords / parseIndicatorRecords ───────────────────────────
+
+func TestParseIndicatorRecords_keyRate(t *testing.T) {
+	feed := indicatorFeeds[0]
+	records, err := decodeIndicatorRecords(strings.NewReader(keyRateXML), feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rates, err := parseIndicatorRecords(records, feed, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rates) != 2 {
+		t.Fatalf("expected 2 values, got %d", len(rates))
+	}
+	r := rates[1]
+	if r.Indicator != events.IndicatorKeyRate || r.Date != "2026-04-14T00:00:00+03:00" || r.Value.String() != "15.5" {
+		t.Errorf("unexpected value %+v", r)
+	}
+	if r.CollectedAt.IsZero() {
+		t.Error("CollectedAt should not be zero")
+	}
+}
+
+func TestParseIndicatorRecords_ruoniaIgnoresVolume(t *testing.T) {
+	feed := indicatorFeeds[1]
+	records, err := decodeIndicatorRecords(strings.NewReader(ruoniaXML), feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rates, err := parseIndicatorRecords(records, feed, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rates) != 1 || rates[0].Indicator != events.IndicatorRUONIA || rates[0].Value.String() != "15.84" {
+		t.Errorf("unexpected values %+v", rates)
+	}
+}
+
+func TestParseIndicatorRecords_badValue(t *testing.T) {
+	feed := indicatorFeeds[0]
+	records, err := decodeIndicatorRecords(strings.NewReader(`<KeyRate><KR><DT>2026-04-13T00:00:00+03:00</DT><Rate>n/a</Rate></KR></KeyRate>`), feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseIndicatorRecords(records, feed, time.Now()); err == nil {
+		t.Error("expected an error for an unparseable value")
+	}
+}
+
+// ─── IndicatorsCollector.Collect ──────────────────────────────────────────────
+
+func TestIndicatorsCollector_Collect_requestsWindow(t *testing.T) {
+	var query string
+	srv := stubIndicatorsServer(t, map[string]string{"KeyRateXML": keyRateXML, "RuoniaXML": ruoniaXML}, &query)
+	defer srv.Close()
+
+	c := NewIndicators(srv.URL, 7, producer.New("localhost:1"))
+	err := c.Collect()
+	if err == nil || !strings.Contains(err.Error(), "indicators publish") {
+		t.Fatalf("expected 'indicators publish' (parse succeeded, kafka failed), got: %v", err)
+	}
+
+	today := calendar.Today()
+	want := "fromDate=" + today.AddDate(0, 0, -6).Format(time.DateOnly) + "&ToDate=" + today.Format(time.DateOnly)
+	if query != want {
+		t.Errorf("expected query %q, got %q", want, query)
+	}
+}
+
+func TestIndicatorsCollector_Collect_emptyWindow(t *testing.T) {
+	srv := stubIndicatorsServer(t, map[string]string{"KeyRateXML": `<KeyRate xmlns=""/>`, "RuoniaXML": `<Ruonia xmlns=""/>`}, nil)
+	defer srv.Close()
+
+	// Nothing to publish, so the unreachable broker is never asked.
+	c := NewIndicators(srv.URL, 1, producer.New("localhost:1"))
+	if err := c.Collect(); err != nil {
+		t.Errorf("expected no error for a window without values, got: %v", err)
+	}
+}
+
+func TestIndicatorsCollector_Collect_non200(t *testing.T) {
+	// Only the key rate is served; RUONIA answers 404.
+	srv := stubIndicatorsServer(t, map[string]string{"KeyRateXML": keyRateXML}, nil)
+	defer srv.Close()
+
+	c := NewIndicators(srv.URL, 7, producer.New("localhost:1"))
+	err := c.Collect()
+	if err == nil || !strings.Contains(err.Error(), "indicators status 404 for RUONIA") {
+		t.Errorf("expected error to contain 'indicators status 404 for RUONIA', got: %v", err)
+	}
+}
+
+func TestIndicatorsCollector_Collect_invalidXML(t *testing.T) {
+	srv := stubIndicatorsServer(t, map[string]string{"KeyRateXML": "<KeyRate><KR>", "RuoniaXML": ruoniaXML}, nil)
+	defer srv.Close()
+
+	c := NewIndicators(srv.URL, 7, producer.New("localhost:1"))
+	err := c.Collect()
+	if err == nil || !strings.Contains(err.Error(), "indicators decode KEY_RATE") {
+		t.Errorf("expected error to contain 'indicators decode KEY_RATE', got: %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package collector
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/data-collector/internal/producer"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/calendar"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/events"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/logging"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/metrics"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/tracing"
+)
+
+// metalsDateLayout is the form of the date_req1 and date_req2 parameters.
+const metalsDateLayout = "02/01/2006"
+
+// MetalsCollector polls the CBR's precious metals prices and publishes them
+// to Kafka. Every run asks for the last days days, so prices missed on a
+// failed run or set on a holiday still arrive; storage upserts the repeats.
+type MetalsCollector struct {
+	baseURL string
+	days    int
+	prod    *producer.Producer
+	client  *http.Client
+}
+
+func NewMetals(baseURL string, days int, prod *producer.Producer) *MetalsCollector {
+	if days < 1 {
+		days = 1
+	}
+	return &MetalsCollector{
+		baseURL: baseURL,
+		days:    days,
+		prod:    prod,
+		client:  &http.Client{Timeout: 15 * time.Second, Transport: metrics.Transport(metrics.SourceCBRMetals, tracing.Transport(nil))},
+	}
+}
+
+type metalsResponse struct {
+	XMLName xml.Name      `xml:"Metall"`
+	Records []metalRecord `xml:"Record"`
+}
+
+type metalRecord struct {
+	Date string `xml:"Date,attr"`
+	Code int    `xml:"Code,attr"`
+	Buy  string `xml:"Buy"`
+	Sell string `xml:"Sell"`
+}
+
+// decodeMetalsResponse decodes an xml_metall.asp document. It is declared
+// windows-1251 but holds nothing outside ASCII, so it is read as is.
+func decodeMetalsResponse(r io.Reader) (metalsResponse, error) {
+	dec := xml.NewDecoder(r)
+	dec.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	var data metalsResponse
+	err := dec.Decode(&data)
+	return data, err
+}
+
+// parseMetalsResponse converts a decoded metals document into a slice of
+// RawMetalPrice. Prices are written with a decimal comma.
+// Pure function — no I/O, directly testable.
+func parseMetalsResponse(data metalsResponse, collectedAt time.Time) ([]events.RawMetalPrice, error) {
+	prices := make([]events.RawMetalPrice, 0, len(data.Records))
+	for _, rec := range data.Records {
+		buy, err := parseCommaDecimal(rec.Buy)
+		if err != nil {
+			return nil, fmt.Errorf("metal %d on %s: buy %q: %w", rec.Code, rec.Date, rec.Buy, err)
+		}
+		sell, err := parseCommaDecimal(rec.Sell)
+		if err != nil {
+			return nil, fmt.Errorf("metal %d on %s: sell %q: %w", rec.Code, rec.Date, rec.Sell, err)
+		}
+		prices = append(prices, events.RawMetalPrice{
+			Date:        rec.Date,
+			Code:        rec.Code,
+			Buy:         buy,
+			Sell:        sell,
+			CollectedAt: collectedAt,
+		})
+	}
+	return prices, nil
+}
+
+func parseCommaDecimal(s string) (money.Decimal, error) {
+	return money.Parse(strings.ReplaceAll(strings.TrimSpace(s), ",", "."))
+}
+
+// Collect fetches the prices of the last days and publishes them. Like the
+// CBR collector, every run is the root of a trace with its own request ID.
+func (c *MetalsCollector) Collect() error {
+	ctx := logging.WithRequestID(context.Background(), logging.NewRequestID())
+	ctx, span := tracing.Start(ctx, "cbr metals collect")
+	err := c.collect(ctx)
+	tracing.End(span, err)
+	return err
+}
+
+func (c *MetalsCollector) collect(ctx context.Context) error {
+	start := time.Now()
+	to := calendar.Today()
+	from := to.AddDate(0, 0, 1-c.days)
+	url := fmt.Sprintf("%s/scripts/xml_metall.asp?date_req1=%s&date_req2=%s",
+		c.baseURL, from.Format(metalsDateLayout), to.Format(metalsDateLayout))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("metals fetch: %w", err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("metals fetch: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("metals status %d", resp.StatusCode)
+	}
+
+	data, err := decodeMetalsResponse(resp.Body)
+	if err != nil {
+		return fmt.Errorf("metals decode: %w", err)
+	}
+	prices, err := parseMetalsResponse(data, time.Now())
+	if err != nil {
+		return fmt.Errorf("metals decode: %w", err)
+	}
+	if len(prices) == 0 {
+		// Nothing set in the window (a long holiday); not an error.
+		logger.InfoContext(ctx, "no metal prices", "from", from.Format(time.DateOnly), "to", to.Format(time.DateOnly))
+		return nil
+	}
+	for i := range prices {
+		prices[i].SourceURL = url
+	}
+
+	event := events.RawMetalPricesEvent{Source: events.SourceCBRMetals, Rates: prices}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if err := c.prod.Publish(ctx, events.TopicRawRates, event); err != nil {
+		return fmt.Errorf("metals publish: %w", err)
+	}
+
+	logger.InfoContext(ctx, "published rates", "source", events.SourceCBRMetals, "count", len(prices),
+		"from", from.Format(time.DateOnly), "to", to.Format(time.DateOnly), "duration_ms", logging.Millis(time.Since(start)))
+	return nil
+}
@@ -0,0 +1,143 @@
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/data-collector/internal/producer"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/calendar"
+)
+
+// metalsXML is an xml_metall.asp answer as the CBR sends it.
+const metalsXML = `<?xml version="1.0" encoding="windows-1251"?>
+<Metall FromDate="20260414" ToDate="20260415" name="Precious metals quotations">
+<Record Date="14.04.2026" Code="1"><Buy>8512,37</Buy><Sell>8512,37</Sell></Record>
+<Record Date="14.04.2026" Code="2"><Buy>96,84</Buy><Sell>96,84</Sell></Record>
+<Record Date="15.04.2026" Code="1"><Buy>8540,1</Buy><Sell>8540,1</Sell></Record>
+<Record Date="15.04.2026" Code="4"><Buy>2801,05</Buy><Sell>2801,05</Sell></Record>
+</Metall>`
+
+// stubMetalsServer serves body at /scripts/xml_metall.asp and records the
+// query it was asked.
+func stubMetalsServer(t *testing.T, body string, query *string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/scripts/xml_metall.asp" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if query != nil {
+			*query = r.URL.RawQuery
+		}
+		w.Header().Set("Content-Type", "application/xml; charset=windows-1251")
+		w.Write([]byte(body))
+	}))
+}
+
+// ─── decodeMetalsResponse / parseMetalsResponse ───────────────────────────────
+
+func TestParseMetalsResponse_decimalComma(t *testing.T) {
+	data, err := decodeMetalsResponse(strings.NewReader(metalsXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prices, err := parseMetalsResponse(data, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prices) != 4 {
+		t.Fatalf("expected 4 prices, got %d", len(prices))
+	}
+	gold := prices[0]
+	if gold.Date != "14.04.2026" || gold.Code != 1 {
+		t.Errorf("expected gold on 14.04.2026, got code %d on %s", gold.Code, gold.Date)
+	}
+	if gold.Buy.String() != "8512.37" || gold.Sell.String() != "8512.37" {
+		t.Errorf("expected 8512.37 as published, got %s and %s", gold.Buy, gold.Sell)
+	}
+	if prices[3].Code != 4 || prices[3].Buy.String() != "2801.05" {
+		t.Errorf("expected palladium at 2801.05, got %+v", prices[3])
+	}
+	if gold.CollectedAt.IsZero() {
+		t.Error("CollectedAt should not be zero")
+	}
+}
+
+func TestParseMetalsResponse_badPrice(t *testing.T) {
+	data := metalsResponse{Records: []metalRecord{{Date: "14.04.2026", Code: 1, Buy: "n/a", Sell: "1,5"}}}
+	if _, err := parseMetalsResponse(data, time.Now()); err == nil {
+		t.Error("expected an error for an unparseable price")
+	}
+}
+
+func TestParseMetalsResponse_empty(t *testing.T) {
+	data, err := decodeMetalsResponse(strings.NewReader(`<?xml version="1.0" encoding="windows-1251"?><Metall FromDate="20260411" ToDate="20260412" name="Precious metals quotations"></Metall>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prices, err := parseMetalsResponse(data, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prices == nil || len(prices) != 0 {
+		t.Errorf("expected an empty non-nil slice, got %v", prices)
+	}
+}
+
+// ─── MetalsCollector.Collect ──────────────────────────────────────────────────
+
+func TestMetalsCollector_Collect_requestsWindow(t *testing.T) {
+	var query string
+	srv := stubMetalsServer(t, metalsXML, &query)
+	defer srv.Close()
+
+	c := NewMetals(srv.URL, 7, producer.New("localhost:1"))
+	err := c.Collect()
+	if err == nil || !strings.Contains(err.Error(), "metals publish") {
+		t.Fatalf("expected 'metals publish' (parse succeeded, kafka failed), got: %v", err)
+	}
+
+	today := calendar.Today()
+	want := "date_req1=" + today.AddDate(0, 0, -6).Format(metalsDateLayout) + "&date_req2=" + today.Format(metalsDateLayout)
+	if query != want {
+		t.Errorf("expected query %q, got %q", want, query)
+	}
+}
+
+func TestMetalsCollector_Collect_emptyWindow(t *testing.T) {
+	srv := stubMetalsServer(t, `<?xml version="1.0" encoding="windows-1251"?><Metall name="Precious metals quotations"></Metall>`, nil)
+	defer srv.Close()
+
+	// Nothing to publish, so the unreachable broker is never asked.
+	c := NewMetals(srv.URL, 1, producer.New("localhost:1"))
+	if err := c.Collect(); err != nil {
+		t.Errorf("expected no error for a window without prices, got: %v", err)
+	}
+}
+
+func TestMetalsCollector_Collect_non200(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	c := NewMetals(srv.URL, 7, producer.New("localhost:1"))
+	err := c.Collect()
+	if err == nil || !strings.Contains(err.Error(), "metals status 502") {
+		t.Errorf("expected error to contain 'metals status 502', got: %v", err)
+	}
+}
+
+func TestMetalsCollector_Collect_invalidXML(t *testing.T) {
+	srv := stubMetalsServer(t, "<html>maintenance</html>", nil)
+	defer srv.Close()
+
+	c := NewMetals(srv.URL, 7, producer.New("localhost:1"))
+	err := c.Collect()
+	if err == nil || !strings.Contains(err.Error(), "metals decode") {
+		t.Errorf("expected error to contain 'metals decode', got: %v", err)
+	}
+}
@@ -3,13 +3,17 @@ package producer
 import (
 	"context"
 	"encoding/json"
-	"log"
 	"strings"
 	"time"
 
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/logging"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/metrics"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/tracing"
 	"github.com/segmentio/kafka-go"
 )
 
+var logger = logging.For("producer")
+
 // Producer wraps a kafka writer.
 type Producer struct {
 	writer *kafka.Writer
@@ -27,7 +31,8 @@ func New(brokers string) *Producer {
 	return &Producer{writer: w}
 }
 
-// Publish encodes v as JSON and sends it to the given topic.
+// Publish encodes v as JSON and sends it to the given topic. The message
+// headers carry the trace context and request ID of ctx.
 func (p *Producer) Publish(ctx context.Context, topic string, v any) error {
 	data, err := json.Marshal(v)
 	if err != nil {
@@ -37,8 +42,13 @@ func (p *Producer) Publish(ctx context.Context, topic string, v any) error {
 		Topic: topic,
 		Value: data,
 	}
-	if err := p.writer.WriteMessages(ctx, msg); err != nil {
-		log.Printf("producer: failed to write to %s: %v", topic, err)
+	logging.InjectKafka(ctx, &msg)
+	ctx, span := tracing.StartProducer(ctx, topic, &msg)
+	err = p.writer.WriteMessages(ctx, msg)
+	tracing.End(span, err)
+	metrics.KafkaProduced(topic, err)
+	if err != nil {
+		logger.ErrorContext(ctx, "kafka write failed", "topic", topic, "error", err)
 		return err
 	}
 	return nil
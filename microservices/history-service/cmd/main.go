@@ -1,7 +1,9 @@
 package main
 
 import (
-	"log"
+	"context"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -9,10 +11,17 @@ import (
 
 	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/cbrbackfill"
 	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/config"
+	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/coverage"
 	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/cryptobackfill"
 	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/handler"
 	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/storage"
 	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/subscriber"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/health"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/logging"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/metrics"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/rpcv1"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/tracing"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
@@ -20,6 +29,14 @@ import (
 func main() {
 	cfg := config.Load()
 
+	logging.Setup("history-service")
+	shutdownTracing, err := tracing.Setup(context.Background(), "history-service")
+	if err != nil {
+		slog.Error("tracing setup failed", "error", err)
+		os.Exit(1)
+	}
+	defer shutdownTracing(context.Background())
+
 	// Connect to PostgreSQL (CBR rates)
 	pg, err := storage.NewPostgresDB(storage.Config{
 		Host:     cfg.DBHost,
@@ -30,12 +47,14 @@ func main() {
 		SSLMode:  cfg.DBSSLMode,
 	})
 	if err != nil {
-		log.Fatalf("failed to connect to postgres: %v", err)
+		slog.Error("failed to connect to postgres", "error", err)
+		os.Exit(1)
 	}
 	defer pg.Close()
 
 	if err := pg.InitSchema(); err != nil {
-		log.Fatalf("failed to init postgres schema: %v", err)
+		slog.Error("failed to init postgres schema", "error", err)
+		os.Exit(1)
 	}
 
 	// Connect to ClickHouse (crypto rates)
@@ -47,57 +66,148 @@ func main() {
 		Password: cfg.CHPassword,
 	})
 	if err != nil {
-		log.Fatalf("failed to connect to clickhouse: %v", err)
+		slog.Error("failed to connect to clickhouse", "error", err)
+		os.Exit(1)
 	}
 	defer ch.Close()
 
 	if err := ch.InitSchema(); err != nil {
-		log.Fatalf("failed to init clickhouse schema: %v", err)
+		slog.Error("failed to init clickhouse schema", "error", err)
+		os.Exit(1)
+	}
+
+	// The age of the newest stored rates is reported from startup on
+	if d, err := pg.LatestCurrencyRateDate(); err == nil {
+		metrics.RateStored(metrics.SourceCBR, d)
+	}
+	if t, err := ch.LatestCryptoRateTime(); err == nil {
+		metrics.RateStored(metrics.SourceBinance, t)
+	}
+	if d, err := pg.LatestMetalPriceDate(); err == nil {
+		metrics.RateStored(metrics.SourceCBRMetals, d)
 	}
 
 	// Start Kafka subscriber in background
 	sub := subscriber.New(cfg.KafkaBrokers, pg, ch)
 	go func() {
-		log.Println("History Service: starting Kafka subscriber")
+		slog.Info("kafka subscriber starting")
 		if err := sub.Run(); err != nil {
-			log.Printf("subscriber error: %v", err)
+			slog.Error("subscriber stopped", "error", err)
 		}
 	}()
 
 	// Setup HTTP router (optional CBR archive client when CBR_BASE_URL is set)
 	cbrClient := cbrbackfill.New(cfg.CBRBaseURL)
 	cryptoBackfill := cryptobackfill.New(cfg.BinanceAPIBase, cbrClient)
-	h := handler.New(pg, ch, cbrClient, cryptoBackfill)
+	// Gap reconciliation over the stored history, repaired through the same clients
+	rec := coverage.NewReconciler(pg, ch, cbrClient, cryptoBackfill, cfg.ReconcileDays)
+	if cfg.ReconcileInterval > 0 {
+		slog.Info("reconciliation scheduled", "interval", cfg.ReconcileInterval.String(), "days", cfg.ReconcileDays)
+		rec.Start(context.Background(), cfg.ReconcileInterval)
+	}
+	// Key rate and RUONIA history from the CBR DailyInfo web service
+	indicatorsClient := cbrbackfill.NewIndicators(cfg.CBRIndicatorsURL)
+	h := handler.New(pg, ch, cbrClient, indicatorsClient, cryptoBackfill, rec)
 	r := chi.NewRouter()
-	r.Use(middleware.Logger)
+	r.Use(tracing.Middleware)
+	r.Use(logging.Middleware)
+	r.Use(metrics.Middleware)
 	r.Use(middleware.Recoverer)
+	// Innermost: decimals as JSON numbers for ?decimals=number
+	r.Use(money.Middleware)
 
 	// CBR history endpoints
 	r.Get("/history/cbr", h.GetCBRHistory)
 	r.Get("/history/cbr/range", h.GetCBRHistoryRange)
+	r.Get("/history/cbr/revisions", h.GetCBRRevisions)
+	r.Get("/history/cbr/nominal-changes", h.GetCBRNominalChanges)
+	r.Get("/history/cbr/export", h.ExportCBR)
 
 	// Crypto history endpoints (backed by ClickHouse)
 	r.Get("/history/crypto", h.GetCryptoHistory)
 	r.Get("/history/crypto/range", h.GetCryptoHistoryRange)
 	r.Get("/history/crypto/symbols", h.GetCryptoSymbols)
+	r.Get("/history/crypto/indicators", h.GetCryptoIndicators)
+	r.Get("/history/crypto/export", h.ExportCrypto)
+
+	// Conversion via CBR cross rates and stored crypto RUB prices
+	r.Get("/history/convert", h.Convert)
+
+	// Statistics and return correlations over stored series
+	r.Get("/history/analytics", h.GetAnalytics)
+	r.Get("/history/analytics/correlation", h.GetCorrelation)
 
-	// Health
+	// Coverage report and gap reconciliation; not proxied by the gateway
+	r.Get("/admin/coverage", h.GetCoverage)
+	r.Get("/admin/reconcile", h.GetReconcile)
+	r.Post("/admin/reconcile", h.PostReconcile)
+
+	// Versioned contract (shared/apiv1); the gateway forwards /v1 unchanged.
+	// The /history routes above stay as deprecated aliases.
+	r.Route("/v1", func(r chi.Router) {
+		r.NotFound(handler.V1NotFound)
+		r.MethodNotAllowed(handler.V1MethodNotAllowed)
+		r.Get("/rates/cbr", h.V1CBRRates)
+		r.Get("/rates/cbr/range", h.V1CBRRange)
+		r.Get("/rates/cbr/revisions", h.V1CBRRevisions)
+		r.Get("/rates/cbr/nominal-changes", h.V1CBRNominalChanges)
+		r.Get("/rates/metals", h.V1MetalPrices)
+		r.Get("/rates/metals/range", h.V1MetalRange)
+		r.Get("/rates/indicators", h.V1IndicatorRates)
+		r.Get("/rates/indicators/range", h.V1IndicatorRange)
+		r.Get("/rates/crypto/symbols", h.V1CryptoSymbols)
+		r.Get("/rates/crypto/range", h.V1CryptoRange)
+		r.Get("/rates/crypto/indicators", h.V1CryptoIndicators)
+		r.Get("/convert", h.V1Convert)
+		r.Get("/analytics", h.V1Analytics)
+		r.Get("/analytics/correlation", h.V1Correlation)
+	})
+
+	// Health: /ping and /healthz only show the process is up; /readyz checks
+	// the databases. Stored rates are still served while Kafka is down.
+	checker := health.New().
+		Add("postgres", pg.Ping).
+		Add("clickhouse", ch.Ping).
+		AddOptional("kafka", health.Kafka(cfg.KafkaBrokers))
 	r.Get("/ping", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("pong"))
 	})
+	r.Get("/healthz", health.Live)
+	r.Get("/readyz", checker.Ready)
+
+	// Prometheus metrics
+	r.Method(http.MethodGet, "/metrics", metrics.Handler())
 
 	addr := ":" + cfg.ServerPort
-	log.Printf("History Service listening on %s", addr)
+	slog.Info("http listening", "addr", addr)
 
 	srv := &http.Server{Addr: addr, Handler: r}
 	go func() {
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("server error: %v", err)
+			slog.Error("http server failed", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	// Internal gRPC API (rpcv1.HistoryService) for the gateway and the bot
+	lis, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+	if err != nil {
+		slog.Error("failed to listen for gRPC", "error", err)
+		os.Exit(1)
+	}
+	grpcSrv := rpcv1.NewServer()
+	rpcv1.RegisterHistoryServiceServer(grpcSrv, handler.NewGRPCServer(h))
+	slog.Info("grpc listening", "addr", ":"+cfg.GRPCPort)
+	go func() {
+		if err := grpcSrv.Serve(lis); err != nil {
+			slog.Error("grpc server failed", "error", err)
+			os.Exit(1)
 		}
 	}()
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	log.Println("History Service: shutting down")
+	slog.Info("shutting down")
+	grpcSrv.GracefulStop()
 }
@@ -1,14 +1,20 @@
 // Package cbrbackfill fetches historical CBR daily_json from cbr-xml-daily.ru archive
-// when PostgreSQL has no rows yet (same URL layout as monolith/internal/currency/cbr).
+// when PostgreSQL has no rows yet (same URL layout as monolith/internal/currency/cbr),
+// and the key rate and RUONIA history from the CBR DailyInfo web service.
 package cbrbackfill
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/storage"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/calendar"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/metrics"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/tracing"
 )
 
 // maxArchiveLookbackDays is how far back we walk when cbr-xml-daily has no file (weekends/holidays).
@@ -26,7 +32,7 @@ func New(baseURL string) *Client {
 	}
 	return &Client{
 		baseURL: baseURL,
-		http:    &http.Client{Timeout: 20 * time.Second},
+		http:    &http.Client{Timeout: 20 * time.Second, Transport: metrics.Transport(metrics.SourceCBR, tracing.Transport(nil))},
 	}
 }
 
@@ -36,23 +42,29 @@ type dailyResponse struct {
 }
 
 type unit struct {
-	CharCode string  `json:"CharCode"`
-	NumCode  string  `json:"NumCode"`
-	Nominal  int     `json:"Nominal"`
-	Name     string  `json:"Name"`
-	Value    float64 `json:"Value"`
-	Previous float64 `json:"Previous"`
+	CharCode string        `json:"CharCode"`
+	NumCode  string        `json:"NumCode"`
+	Nominal  int           `json:"Nominal"`
+	Name     string        `json:"Name"`
+	Value    money.Decimal `json:"Value"`
+	Previous money.Decimal `json:"Previous"`
+	// VunitRate, the price of one unit, is only in newer sheets.
+	VunitRate money.Decimal `json:"VunitRate"`
 }
 
-// FetchDay downloads archive JSON for the given calendar day (local date parts used for URL path).
-func (c *Client) FetchDay(day time.Time) ([]storage.CurrencyRate, error) {
+// FetchDay downloads archive JSON for the given calendar day.
+func (c *Client) FetchDay(ctx context.Context, day time.Time) ([]storage.CurrencyRate, error) {
 	if c == nil {
 		return nil, fmt.Errorf("cbr backfill client is nil")
 	}
-	path := day.Format("2006/01/02")
+	path := calendar.Date(day).Format("2006/01/02")
 	url := fmt.Sprintf("%s/archive/%s/daily_json.js", c.baseURL, path)
 
-	resp, err := c.http.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cbr get %s: %w", url, err)
+	}
+	resp, err := c.http.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("cbr get %s: %w", url, err)
 	}
@@ -75,6 +87,7 @@ func (c *Client) FetchDay(day time.Time) ([]storage.CurrencyRate, error) {
 		return nil, fmt.Errorf("cbr date field: %w", err)
 	}
 
+	fetchedAt := time.Now()
 	out := make([]storage.CurrencyRate, 0, len(data.Valute))
 	for _, v := range data.Valute {
 		if v.CharCode == "" {
@@ -87,6 +100,9 @@ func (c *Client) FetchDay(day time.Time) ([]storage.CurrencyRate, error) {
 			Nominal:      v.Nominal,
 			Value:        v.Value,
 			Previous:     v.Previous,
+			UnitValue:    v.VunitRate,
+			Source:       url,
+			FetchedAt:    fetchedAt,
 		})
 	}
 	if len(out) == 0 {
@@ -98,15 +114,16 @@ func (c *Client) FetchDay(day time.Time) ([]storage.CurrencyRate, error) {
 // FetchDayWithFallback tries archive for the given UTC calendar day, then earlier days up to
 // maxArchiveLookbackDays until a file exists (same approach as carrying last known CBR over non-trading days).
 // Returns rates from the archive file, and sourceDay — the calendar day of the file actually used.
-func (c *Client) FetchDayWithFallback(day time.Time) (rates []storage.CurrencyRate, sourceDay time.Time, err error) {
+// Callers storing the rates under day mark them with CarryOver.
+func (c *Client) FetchDayWithFallback(ctx context.Context, day time.Time) (rates []storage.CurrencyRate, sourceDay time.Time, err error) {
 	if c == nil {
 		return nil, time.Time{}, fmt.Errorf("cbr backfill client is nil")
 	}
-	target := calendarDateUTC(day)
+	target := calendar.Date(day)
 	var lastErr error
 	for i := 0; i <= maxArchiveLookbackDays; i++ {
 		cand := target.AddDate(0, 0, -i)
-		rates, err := c.FetchDay(cand)
+		rates, err := c.FetchDay(ctx, cand)
 		if err == nil {
 			return rates, cand, nil
 		}
@@ -115,27 +132,21 @@ func (c *Client) FetchDayWithFallback(day time.Time) (rates []storage.CurrencyRa
 	return nil, time.Time{}, lastErr
 }
 
-func calendarDateUTC(t time.Time) time.Time {
-	y, m, d := t.UTC().Date()
-	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+// CarryOver dates rates fetched for sourceDay to day and records sourceDay as
+// their CarriedFrom, so the carried-over value can be told from a publication.
+func CarryOver(rates []storage.CurrencyRate, day, sourceDay time.Time) {
+	from := calendar.Date(sourceDay)
+	for i := range rates {
+		rates[i].Date = day
+		rates[i].CarriedFrom = &from
+	}
 }
 
+// parseCBRRootDate returns the Moscow day a sheet is dated by its Date field.
 func parseCBRRootDate(s string) (time.Time, error) {
-	layouts := []string{
-		"2006/01/02 15:04:05",
-		"2006-01-02T15:04:05-07:00",
-		time.RFC3339,
-		"02.01.2006",
-		"2006-01-02",
-	}
-	var lastErr error
-	for _, layout := range layouts {
-		t, err := time.Parse(layout, s)
-		if err == nil {
-			y, m, d := t.Date()
-			return time.Date(y, m, d, 0, 0, 0, 0, time.UTC), nil
-		}
-		lastErr = err
+	d, err := calendar.ParseSheetDate(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse CBR date %q: %w", s, err)
 	}
-	return time.Time{}, fmt.Errorf("parse CBR date %q: %v", s, lastErr)
+	return d, nil
 }
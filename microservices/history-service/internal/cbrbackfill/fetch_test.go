@@ -1,11 +1,14 @@
 package cbrbackfill
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/calendar"
 )
 
 func TestParseCBRRootDate(t *testing.T) {
@@ -37,22 +40,30 @@ func TestFetchDayWithFallback_usesPreviousArchiveDay(t *testing.T) {
 
 	c := &Client{baseURL: srv.URL, http: srv.Client()}
 	target := time.Date(2026, 3, 29, 0, 0, 0, 0, time.UTC)
-	rates, src, err := c.FetchDayWithFallback(target)
+	rates, src, err := c.FetchDayWithFallback(context.Background(), target)
 	if err != nil {
 		t.Fatal(err)
 	}
 	wantSrc := time.Date(2026, 3, 28, 0, 0, 0, 0, time.UTC)
-	if !calendarDateUTC(src).Equal(wantSrc) {
+	if !calendar.Date(src).Equal(wantSrc) {
 		t.Fatalf("source day: got %v want %v", src, wantSrc)
 	}
-	var usd float64
+	var usd string
 	for _, r := range rates {
 		if r.CurrencyCode == "USD" {
-			usd = r.Value / float64(r.Nominal)
+			usd = r.Value.String()
 			break
 		}
 	}
-	if usd != 95.5 {
+	if usd != "95.5" {
 		t.Fatalf("USD rate: got %v", usd)
 	}
+	if want := srv.URL + "/archive/2026/03/28/daily_json.js"; rates[0].Source != want || rates[0].FetchedAt.IsZero() {
+		t.Fatalf("provenance: got %q at %v, want %q", rates[0].Source, rates[0].FetchedAt, want)
+	}
+
+	CarryOver(rates, target, src)
+	if !rates[0].Date.Equal(target) || rates[0].CarriedFrom == nil || !rates[0].CarriedFrom.Equal(wantSrc) {
+		t.Fatalf("carry over: got date %v from %v", rates[0].Date, rates[0].CarriedFrom)
+	}
 }
@@ -0,0 +1,143 @@
+package cbrbackfill
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/storage"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/calendar"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/events"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/metrics"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/tracing"
+)
+
+// IndicatorLookbackDays is how far before a day the values of an indicator
+// are asked for, so that one published on an earlier business day is found
+// (same reach as the archive fallback).
+const IndicatorLookbackDays = maxArchiveLookbackDays
+
+// indicatorFeed is the DailyInfo method serving an indicator (same layout
+// as the data-collector feeds).
+type indicatorFeed struct {
+	name   string
+	method string
+	record string
+	date   string
+	value  string
+}
+
+var indicatorFeeds = map[string]indicatorFeed{
+	events.IndicatorKeyRate: {name: "Key rate", method: "KeyRateXML", record: "KR", date: "DT", value: "Rate"},
+	events.IndicatorRUONIA:  {name: "RUONIA", method: "RuoniaXML", record: "ro", date: "D0", value: "ruo"},
+}
+
+// IsIndicator reports whether code is a CBR indicator this package fetches.
+func IsIndicator(code string) bool {
+	_, ok := indicatorFeeds[code]
+	return ok
+}
+
+// IndicatorsClient downloads the history of CBR indicators from the
+// DailyInfo web service, which serves any date range in one request.
+type IndicatorsClient struct {
+	baseURL string
+	http    *http.Client
+}
+
+func NewIndicators(baseURL string) *IndicatorsClient {
+	if baseURL == "" {
+		return nil
+	}
+	return &IndicatorsClient{
+		baseURL: baseURL,
+		http:    &http.Client{Timeout: 30 * time.Second, Transport: metrics.Transport(metrics.SourceCBRIndicators, tracing.Transport(nil))},
+	}
+}
+
+type indicatorRecord struct {
+	Fields []struct {
+		XMLName xml.Name
+		Value   string `xml:",chardata"`
+	} `xml:",any"`
+}
+
+func (r indicatorRecord) field(name string) string {
+	for _, f := range r.Fields {
+		if f.XMLName.Local == name {
+			return strings.TrimSpace(f.Value)
+		}
+	}
+	return ""
+}
+
+// FetchIndicator downloads the values indicator took from from to to.
+// Rows repeating the value before them are dropped on save.
+func (c *IndicatorsClient) FetchIndicator(ctx context.Context, indicator string, from, to time.Time) ([]storage.IndicatorRate, error) {
+	if c == nil {
+		return nil, fmt.Errorf("cbr indicators client is nil")
+	}
+	feed, ok := indicatorFeeds[indicator]
+	if !ok {
+		return nil, fmt.Errorf("unknown cbr indicator %q", indicator)
+	}
+	url := fmt.Sprintf("%s/DailyInfoWebServ/DailyInfo.asmx/%s?fromDate=%s&ToDate=%s",
+		c.baseURL, feed.method, calendar.Date(from).Format("2006-01-02"), calendar.Date(to).Format("2006-01-02"))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cbr get %s: %w", url, err)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cbr get %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cbr status %d for %s", resp.StatusCode, indicator)
+	}
+
+	fetchedAt := time.Now()
+	var out []storage.IndicatorRate
+	dec := xml.NewDecoder(resp.Body)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cbr decode: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != feed.record {
+			continue
+		}
+		var rec indicatorRecord
+		if err := dec.DecodeElement(&rec, &start); err != nil {
+			return nil, fmt.Errorf("cbr decode: %w", err)
+		}
+		date, err := calendar.ParseSheetDate(rec.field(feed.date))
+		if err != nil {
+			return nil, fmt.Errorf("cbr %s date: %w", indicator, err)
+		}
+		value, err := money.Parse(rec.field(feed.value))
+		if err != nil {
+			return nil, fmt.Errorf("cbr %s value on %s: %w", indicator, date.Format("2006-01-02"), err)
+		}
+		out = append(out, storage.IndicatorRate{
+			Indicator:     indicator,
+			EffectiveDate: date,
+			Name:          feed.name,
+			Value:         value,
+			Source:        url,
+			FetchedAt:     fetchedAt,
+		})
+	}
+	return out, nil
+}
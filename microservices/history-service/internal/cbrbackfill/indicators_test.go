@@ -0,0 +1,49 @@
+package cbrbackfill
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/events"
+)
+
+func TestFetchIndicator_keyRate(t *testing.T) {
+	const keyRateXML = `<?xml version="1.0" encoding="utf-8"?>
+<KeyRate xmlns=""><KR><DT>2026-03-20T00:00:00+03:00</DT><Rate>16.00</Rate></KR><KR><DT>2026-03-23T00:00:00+03:00</DT><Rate>15.50</Rate></KR></KeyRate>`
+	var path, query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, query = r.URL.Path, r.URL.RawQuery
+		w.Write([]byte(keyRateXML))
+	}))
+	defer srv.Close()
+
+	c := &IndicatorsClient{baseURL: srv.URL, http: srv.Client()}
+	from := time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC)
+	rates, err := c.FetchIndicator(context.Background(), events.IndicatorKeyRate, from, from.AddDate(0, 0, 5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/DailyInfoWebServ/DailyInfo.asmx/KeyRateXML" || query != "fromDate=2026-03-20&ToDate=2026-03-25" {
+		t.Fatalf("unexpected request %s?%s", path, query)
+	}
+	if len(rates) != 2 {
+		t.Fatalf("expected 2 values, got %d", len(rates))
+	}
+	r := rates[1]
+	if want := time.Date(2026, 3, 23, 0, 0, 0, 0, time.UTC); !r.EffectiveDate.Equal(want) || r.Value.String() != "15.5" || r.Name != "Key rate" {
+		t.Fatalf("unexpected value %+v", r)
+	}
+	if r.Source == "" || r.FetchedAt.IsZero() {
+		t.Fatalf("provenance: got %q at %v", r.Source, r.FetchedAt)
+	}
+}
+
+func TestFetchIndicator_unknown(t *testing.T) {
+	c := &IndicatorsClient{baseURL: "http://127.0.0.1:1", http: http.DefaultClient}
+	if _, err := c.FetchIndicator(context.Background(), "MIACR", time.Now(), time.Now()); err == nil {
+		t.Fatal("expected an error for an unknown indicator")
+	}
+}
@@ -2,7 +2,9 @@ package config
 
 import (
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Config struct {
@@ -23,11 +25,21 @@ type Config struct {
 
 	KafkaBrokers string
 	ServerPort   string
+	// GRPCPort serves rpcv1.HistoryService to the gateway and the bot.
+	GRPCPort string
 
 	// CBRBaseURL is used to pull missing archive daily_json into PostgreSQL (same host as data-collector).
 	CBRBaseURL string
+	// CBRIndicatorsURL is the www.cbr.ru root whose DailyInfo web service
+	// backfills the key rate and RUONIA (empty disables it).
+	CBRIndicatorsURL string
 	// BinanceAPIBase is the REST root for klines backfill (empty = https://api.binance.com).
 	BinanceAPIBase string
+
+	// ReconcileInterval is how often the gaps of the last ReconcileDays days
+	// are repaired; 0 disables the schedule (POST /admin/reconcile still works).
+	ReconcileInterval time.Duration
+	ReconcileDays     int
 }
 
 func Load() *Config {
@@ -45,10 +57,15 @@ func Load() *Config {
 		CHUser:     getEnv("CH_USER", "default"),
 		CHPassword: getEnv("CH_PASSWORD", ""),
 
-		KafkaBrokers:   getEnv("KAFKA_BROKERS", "localhost:9092"),
-		ServerPort:     getEnv("SERVER_PORT", "8084"),
-		CBRBaseURL:     getEnvAllowEmpty("CBR_BASE_URL", "https://www.cbr-xml-daily.ru"),
-		BinanceAPIBase: strings.TrimSpace(os.Getenv("BINANCE_API_BASE")),
+		KafkaBrokers:     getEnv("KAFKA_BROKERS", "localhost:9092"),
+		ServerPort:       getEnv("SERVER_PORT", "8084"),
+		GRPCPort:         getEnv("GRPC_PORT", "9084"),
+		CBRBaseURL:       getEnvAllowEmpty("CBR_BASE_URL", "https://www.cbr-xml-daily.ru"),
+		CBRIndicatorsURL: getEnvAllowEmpty("CBR_INDICATORS_URL", "https://www.cbr.ru"),
+		BinanceAPIBase:   strings.TrimSpace(os.Getenv("BINANCE_API_BASE")),
+
+		ReconcileInterval: getDuration("RECONCILE_INTERVAL", 6*time.Hour),
+		ReconcileDays:     getInt("RECONCILE_DAYS", 30),
 	}
 }
 
@@ -59,6 +76,24 @@ func getEnv(key, def string) string {
 	return def
 }
 
+// getDuration parses a duration such as 6h; unset or invalid values give def.
+func getDuration(key string, def time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || d < 0 {
+		return def
+	}
+	return d
+}
+
+// getInt parses a positive integer; unset or invalid values give def.
+func getInt(key string, def int) int {
+	n, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || n <= 0 {
+		return def
+	}
+	return n
+}
+
 // getEnvAllowEmpty returns def if the variable is unset; if set to empty string, returns "" (disables CBR backfill).
 func getEnvAllowEmpty(key, def string) string {
 	v, ok := os.LookupEnv(key)
@@ -0,0 +1,105 @@
+package coverage
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/calendar"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse(time.DateOnly, s)
+	return t
+}
+
+func TestSheetDays_expectsSaturdayNotMonday(t *testing.T) {
+	// Fri 2024-01-12 .. Tue 2024-01-16: Friday's sheet is dated Saturday
+	days := calendar.SheetDays(date("2024-01-12"), date("2024-01-16"))
+	want := []time.Time{date("2024-01-12"), date("2024-01-13"), date("2024-01-16")}
+	if !reflect.DeepEqual(days, want) {
+		t.Errorf("got %v, want %v", days, want)
+	}
+	if days := calendar.SheetDays(date("2024-01-14"), date("2024-01-15")); len(days) != 0 {
+		t.Errorf("no sheet is dated Sunday or Monday, got %v", days)
+	}
+}
+
+func TestSlots_alignedToInterval(t *testing.T) {
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	slots := Slots(from, from.Add(time.Hour), 15*time.Minute)
+	if len(slots) != 4 || !slots[0].Equal(from) || !slots[3].Equal(from.Add(45*time.Minute)) {
+		t.Errorf("unexpected slots %v", slots)
+	}
+	if n := len(Slots(from, from.AddDate(0, 0, 7), 24*time.Hour)); n != 7 {
+		t.Errorf("expected 7 daily slots, got %d", n)
+	}
+}
+
+func TestCompare_groupsConsecutiveMissing(t *testing.T) {
+	// Jan 13 (Sat) and Jan 16 (Tue) are consecutive sheet days, so a gap
+	// over them spans Sunday and Monday
+	expected := calendar.SheetDays(date("2024-01-10"), date("2024-01-18"))
+	stored := []time.Time{date("2024-01-10"), date("2024-01-11"), date("2024-01-12"), date("2024-01-18")}
+
+	r := compare(expected, stored, time.DateOnly)
+	if r.Expected != 7 || r.Present != 4 || r.Missing != 3 {
+		t.Fatalf("expected 7/4/3, got %d/%d/%d", r.Expected, r.Present, r.Missing)
+	}
+	want := []Gap{
+		{From: "2024-01-13", To: "2024-01-17", Count: 3},
+	}
+	if !reflect.DeepEqual(r.Gaps, want) {
+		t.Errorf("got gaps %+v, want %+v", r.Gaps, want)
+	}
+	if len(r.spans) != 1 || !r.spans[0].to.Equal(date("2024-01-17")) {
+		t.Errorf("spans should mirror the gaps, got %v", r.spans)
+	}
+	if r.Coverage != 4.0/7 {
+		t.Errorf("coverage = %v", r.Coverage)
+	}
+}
+
+func TestCompare_fullAndEmpty(t *testing.T) {
+	expected := calendar.SheetDays(date("2024-01-15"), date("2024-01-17"))
+	if r := compare(expected, expected, time.DateOnly); r.Missing != 0 || r.Coverage != 1 || r.Gaps == nil {
+		t.Errorf("full coverage: got %+v", r)
+	}
+	if r := compare(nil, nil, time.DateOnly); r.Coverage != 1 || r.Expected != 0 {
+		t.Errorf("nothing expected counts as covered: got %+v", r)
+	}
+}
+
+func TestTooLong_rejectedBeforeQuerying(t *testing.T) {
+	// nil stores: the range check must come first
+	_, err := Crypto(context.Background(), nil, "BTCUSDT", "1m", date("2020-01-01"), date("2024-01-01"))
+	if !errors.Is(err, ErrTooLong) {
+		t.Errorf("expected ErrTooLong for crypto, got %v", err)
+	}
+	_, err = CBR(context.Background(), nil, "USD", date("1700-01-01"), date("2024-01-01"))
+	if !errors.Is(err, ErrTooLong) {
+		t.Errorf("expected ErrTooLong for CBR, got %v", err)
+	}
+}
+
+func TestFillSheetDays_stopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var filled []time.Time
+	fill := func(_ context.Context, d time.Time) error {
+		filled = append(filled, d)
+		if len(filled) == 2 {
+			cancel()
+		}
+		return nil
+	}
+	// Tue 2024-01-09 .. Sat 2024-01-13: five sheet days
+	errs := fillSheetDays(ctx, []span{{date("2024-01-09"), date("2024-01-13")}}, fill)
+	if len(filled) != 2 {
+		t.Errorf("expected the run to stop after 2 days, filled %v", filled)
+	}
+	if len(errs) != 1 || errs[0] != context.Canceled.Error() {
+		t.Errorf("expected the cancellation reported, got %v", errs)
+	}
+}
@@ -0,0 +1,239 @@
+package coverage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/cbrbackfill"
+	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/cryptobackfill"
+	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/storage"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/calendar"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/logging"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/metrics"
+)
+
+var logger = logging.For("coverage")
+
+// ErrRunning is returned by Run while another run is in progress.
+var ErrRunning = errors.New("reconciliation already running")
+
+// archivePause spaces the CBR archive requests of a run, like the
+// on-demand backfill does.
+const archivePause = 120 * time.Millisecond
+
+// Reconciler checks the stored history of the last days and repairs the
+// gaps it finds: missing CBR sheet days from the CBR archive and missing
+// days of every stored crypto symbol from Binance daily klines. Today is
+// left out, as its rates may not be collected yet.
+type Reconciler struct {
+	pg     *storage.PostgresDB
+	ch     *storage.ClickHouseDB
+	cbr    *cbrbackfill.Client
+	crypto *cryptobackfill.Client
+	days   int
+
+	running sync.Mutex
+	mu      sync.Mutex
+	last    *Run
+}
+
+// Run is what one reconciliation found and fixed.
+type Run struct {
+	StartedAt  time.Time `json:"started_at"`
+	DurationMs float64   `json:"duration_ms"`
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	Series     []Repair  `json:"series"`
+}
+
+// Repair is the outcome for one series. Missing counts the days missing
+// before the repair, Remaining the gaps still open after it.
+type Repair struct {
+	Source    string   `json:"source"`
+	Code      string   `json:"code,omitempty"`
+	Missing   int      `json:"missing"`
+	Repaired  int      `json:"repaired"`
+	Remaining []Gap    `json:"remaining"`
+	Errors    []string `json:"errors,omitempty"`
+}
+
+// NewReconciler returns a Reconciler over the last days days. cbr and
+// crypto may be nil; the gaps they would fill are then only reported.
+func NewReconciler(pg *storage.PostgresDB, ch *storage.ClickHouseDB, cbr *cbrbackfill.Client, crypto *cryptobackfill.Client, days int) *Reconciler {
+	return &Reconciler{pg: pg, ch: ch, cbr: cbr, crypto: crypto, days: days}
+}
+
+// Start runs a reconciliation every interval until ctx is done, the first
+// one right away. Every run gets a request ID of its own.
+func (r *Reconciler) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			runCtx := logging.WithRequestID(ctx, logging.NewRequestID())
+			if _, err := r.Run(runCtx); err != nil {
+				logger.WarnContext(runCtx, "reconciliation skipped", "error", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Last returns the latest finished run, or nil before the first one.
+func (r *Reconciler) Last() *Run {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.last
+}
+
+// Run reconciles the window now and returns what it did.
+func (r *Reconciler) Run(ctx context.Context) (*Run, error) {
+	if !r.running.TryLock() {
+		return nil, ErrRunning
+	}
+	defer r.running.Unlock()
+
+	start := time.Now()
+	to := calendar.Day(start).AddDate(0, 0, -1)
+	from := to.AddDate(0, 0, 1-r.days)
+	run := &Run{StartedAt: start.UTC(), From: from.Format(time.DateOnly), To: to.Format(time.DateOnly)}
+
+	run.Series = append(run.Series, r.repairCBR(ctx, from, to))
+	symbols, err := r.ch.GetAvailableCryptoSymbols()
+	if err != nil {
+		run.Series = append(run.Series, Repair{Source: SourceCrypto, Remaining: []Gap{}, Errors: []string{err.Error()}})
+	}
+	for _, symbol := range symbols {
+		if ctx.Err() != nil {
+			break
+		}
+		run.Series = append(run.Series, r.repairCrypto(ctx, symbol, from, to))
+	}
+
+	var missing, repaired int
+	for _, s := range run.Series {
+		missing += s.Missing
+		repaired += s.Repaired
+		if s.Missing > 0 || len(s.Errors) > 0 {
+			logger.InfoContext(ctx, "gaps reconciled", "source", s.Source, "code", s.Code,
+				"missing", s.Missing, "repaired", s.Repaired, "remaining", s.Missing-s.Repaired, "errors", len(s.Errors))
+		}
+	}
+	run.DurationMs = logging.Millis(time.Since(start))
+	logger.InfoContext(ctx, "reconciliation finished", "from", run.From, "to", run.To,
+		"series", len(run.Series), "missing", missing, "repaired", repaired, "duration_ms", run.DurationMs)
+
+	r.mu.Lock()
+	r.last = run
+	r.mu.Unlock()
+	return run, nil
+}
+
+// repairCBR fills the sheet days without any CBR rate with the archive
+// sheet dated that day. Days the archive has no sheet of their own for
+// (holidays) are left missing rather than filled with an earlier sheet.
+func (r *Reconciler) repairCBR(ctx context.Context, from, to time.Time) Repair {
+	rep := Repair{Source: SourceCBR, Remaining: []Gap{}}
+	before, err := CBR(ctx, r.pg, "", from, to)
+	if err != nil {
+		rep.Errors = append(rep.Errors, err.Error())
+		return rep
+	}
+	rep.Missing, rep.Remaining = before.Missing, before.Gaps
+	if before.Missing == 0 {
+		return rep
+	}
+	if r.cbr == nil {
+		rep.Errors = append(rep.Errors, "CBR archive backfill is disabled")
+		return rep
+	}
+	rep.Errors = append(rep.Errors, fillSheetDays(ctx, before.spans, r.fillCBRDay)...)
+	if ctx.Err() != nil {
+		return rep
+	}
+	return recheck(rep, func() (Report, error) { return CBR(ctx, r.pg, "", from, to) })
+}
+
+// fillSheetDays calls fill for every sheet day of spans, archivePause
+// apart, and returns the errors it got. It stops when ctx is done.
+func fillSheetDays(ctx context.Context, spans []span, fill func(context.Context, time.Time) error) []string {
+	var errs []string
+	for _, s := range spans {
+		for _, d := range calendar.SheetDays(s.from, s.to) {
+			if err := fill(ctx, d); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", d.Format(time.DateOnly), err))
+			}
+			select {
+			case <-ctx.Done():
+				return append(errs, ctx.Err().Error())
+			case <-time.After(archivePause):
+			}
+		}
+	}
+	return errs
+}
+
+func (r *Reconciler) fillCBRDay(ctx context.Context, d time.Time) error {
+	rates, srcDay, err := r.cbr.FetchDayWithFallback(ctx, d)
+	if err != nil {
+		metrics.Backfill(metrics.SourceCBR, err)
+		return err
+	}
+	if !calendar.Date(srcDay).Equal(d) {
+		metrics.Backfill(metrics.SourceCBR, nil)
+		return nil
+	}
+	err = r.pg.SaveCurrencyRates(rates)
+	metrics.Backfill(metrics.SourceCBR, err)
+	return err
+}
+
+// repairCrypto fills the days without any row of symbol with Binance daily
+// klines, one request per gap.
+func (r *Reconciler) repairCrypto(ctx context.Context, symbol string, from, to time.Time) Repair {
+	rep := Repair{Source: SourceCrypto, Code: symbol, Remaining: []Gap{}}
+	before, err := Crypto(ctx, r.ch, symbol, "1d", from, to)
+	if err != nil {
+		rep.Errors = append(rep.Errors, err.Error())
+		return rep
+	}
+	rep.Missing, rep.Remaining = before.Missing, before.Gaps
+	if before.Missing == 0 {
+		return rep
+	}
+	if r.crypto == nil {
+		rep.Errors = append(rep.Errors, "Binance backfill is disabled")
+		return rep
+	}
+	for _, s := range before.spans {
+		rows, err := r.crypto.FetchDailyRUBRates(ctx, symbol, s.from, s.to)
+		if err == nil && len(rows) > 0 {
+			err = r.ch.SaveCryptoRates(rows)
+		}
+		metrics.Backfill(metrics.SourceBinance, err)
+		if err != nil {
+			rep.Errors = append(rep.Errors, fmt.Sprintf("%s..%s: %v", s.from.Format(time.DateOnly), s.to.Format(time.DateOnly), err))
+		}
+	}
+	return recheck(rep, func() (Report, error) { return Crypto(ctx, r.ch, symbol, "1d", from, to) })
+}
+
+// recheck measures the series again after a repair. Days the sources have
+// no data for stay in Remaining.
+func recheck(rep Repair, measure func() (Report, error)) Repair {
+	after, err := measure()
+	if err != nil {
+		rep.Errors = append(rep.Errors, err.Error())
+		return rep
+	}
+	rep.Repaired = rep.Missing - after.Missing
+	rep.Remaining = after.Gaps
+	return rep
+}
@@ -3,6 +3,7 @@
 package cryptobackfill
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -15,6 +16,9 @@ import (
 
 	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/cbrbackfill"
 	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/storage"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/metrics"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/tracing"
 )
 
 const (
@@ -39,13 +43,13 @@ func New(binanceBase string, cbr *cbrbackfill.Client) *Client {
 	}
 	return &Client{
 		binanceBase: binanceBase,
-		http:        &http.Client{Timeout: 25 * time.Second},
+		http:        &http.Client{Timeout: 25 * time.Second, Transport: metrics.Transport(metrics.SourceBinance, tracing.Transport(nil))},
 		cbr:         cbr,
 	}
 }
 
 // FetchDailyRUBRates returns one row per UTC calendar day (Binance 1d open time) for symbol (e.g. BTCUSDT).
-func (c *Client) FetchDailyRUBRates(symbol string, from, to time.Time) ([]storage.CryptoRate, error) {
+func (c *Client) FetchDailyRUBRates(ctx context.Context, symbol string, from, to time.Time) ([]storage.CryptoRate, error) {
 	if c == nil {
 		return nil, fmt.Errorf("cryptobackfill client is nil")
 	}
@@ -61,11 +65,11 @@ func (c *Client) FetchDailyRUBRates(symbol string, from, to time.Time) ([]storag
 	wg.Add(2)
 	go func() {
 		defer wg.Done()
-		cryptoKlines, errCrypto = c.fetchAllDailyKlines(symbol, from, to)
+		cryptoKlines, errCrypto = c.fetchAllDailyKlines(ctx, symbol, from, to)
 	}()
 	go func() {
 		defer wg.Done()
-		usdtRub, errUSDT = c.fetchAllDailyKlines(usdtRubSymbol, from, to)
+		usdtRub, errUSDT = c.fetchAllDailyKlines(ctx, usdtRubSymbol, from, to)
 	}()
 	wg.Wait()
 	if errCrypto != nil {
@@ -75,14 +79,14 @@ func (c *Client) FetchDailyRUBRates(symbol string, from, to time.Time) ([]storag
 		usdtRub = nil
 	}
 
-	rubCloseByOpenMs := make(map[int64]float64, len(usdtRub))
+	rubCloseByOpenMs := make(map[int64]money.Decimal, len(usdtRub))
 	for _, k := range usdtRub {
 		rubCloseByOpenMs[k.openTimeMs] = k.close
 	}
 
 	needCBR := make(map[string]time.Time)
 	for _, k := range cryptoKlines {
-		if r, ok := rubCloseByOpenMs[k.openTimeMs]; ok && r > 0 {
+		if r, ok := rubCloseByOpenMs[k.openTimeMs]; ok && r.IsPositive() {
 			continue
 		}
 		day := utcDate(time.UnixMilli(k.openTimeMs).UTC())
@@ -92,33 +96,33 @@ func (c *Client) FetchDailyRUBRates(symbol string, from, to time.Time) ([]storag
 	for _, d := range needCBR {
 		cbrDays = append(cbrDays, d)
 	}
-	cbrCache := c.prefetchCBRUSD(cbrDays)
+	cbrCache := c.prefetchCBRUSD(ctx, cbrDays)
 
 	out := make([]storage.CryptoRate, 0, len(cryptoKlines))
 	for _, k := range cryptoKlines {
 		rub, ok := rubCloseByOpenMs[k.openTimeMs]
-		if !ok || rub <= 0 {
+		if !ok || !rub.IsPositive() {
 			key := utcDate(time.UnixMilli(k.openTimeMs).UTC()).Format("2006-01-02")
 			r, hit := cbrCache[key]
-			if !hit || r <= 0 {
+			if !hit || !r.IsPositive() {
 				continue
 			}
 			rub = r
 			ok = true
 		}
-		if !ok || rub <= 0 {
+		if !ok || !rub.IsPositive() {
 			continue
 		}
 		ts := time.UnixMilli(k.openTimeMs).UTC()
 		out = append(out, storage.CryptoRate{
 			Timestamp: ts,
 			Symbol:    symbol,
-			Open:      k.open * rub,
-			High:      k.high * rub,
-			Low:       k.low * rub,
-			Close:     k.close * rub,
+			Open:      toRUB(k.open, rub),
+			High:      toRUB(k.high, rub),
+			Low:       toRUB(k.low, rub),
+			Close:     toRUB(k.close, rub),
 			Volume:    k.volume,
-			PriceRUB:  k.close * rub,
+			PriceRUB:  toRUB(k.close, rub),
 		})
 	}
 	return out, nil
@@ -126,7 +130,7 @@ func (c *Client) FetchDailyRUBRates(symbol string, from, to time.Time) ([]storag
 
 // FetchIntervalRUBRates loads klines at the given Binance interval (e.g. 15m, 1h), converts to RUB
 // via USDTRUB (same interval) or CBR USD when USDTRUB is missing — mirrors monolith behaviour.
-func (c *Client) FetchIntervalRUBRates(symbol, interval string, from, to time.Time) ([]storage.CryptoRate, error) {
+func (c *Client) FetchIntervalRUBRates(ctx context.Context, symbol, interval string, from, to time.Time) ([]storage.CryptoRate, error) {
 	if c == nil {
 		return nil, fmt.Errorf("cryptobackfill client is nil")
 	}
@@ -144,11 +148,11 @@ func (c *Client) FetchIntervalRUBRates(symbol, interval string, from, to time.Ti
 	wg.Add(2)
 	go func() {
 		defer wg.Done()
-		cryptoKlines, errCrypto = c.fetchAllKlinesPaginated(symbol, interval, startMs, endMs)
+		cryptoKlines, errCrypto = c.fetchAllKlinesPaginated(ctx, symbol, interval, startMs, endMs)
 	}()
 	go func() {
 		defer wg.Done()
-		usdtKlines, errUSDT = c.fetchAllKlinesPaginated(usdtRubSymbol, interval, startMs, endMs)
+		usdtKlines, errUSDT = c.fetchAllKlinesPaginated(ctx, usdtRubSymbol, interval, startMs, endMs)
 	}()
 	wg.Wait()
 	if errCrypto != nil {
@@ -163,7 +167,7 @@ func (c *Client) FetchIntervalRUBRates(symbol, interval string, from, to time.Ti
 
 	var out []storage.CryptoRate
 	if len(usdtKlines) == 0 {
-		out = c.mergeKlinesCBRFallback(cryptoKlines, symbol)
+		out = c.mergeKlinesCBRFallback(ctx, cryptoKlines, symbol)
 		if len(out) == 0 {
 			return nil, fmt.Errorf("no USDT/RUB klines and CBR fallback produced no rows")
 		}
@@ -203,13 +207,13 @@ func (c *Client) mergeKlinesToRUB(crypto, usdt []klineOHLCV, symbol string) []st
 			u = best
 		}
 		ts := time.UnixMilli(k.openTimeMs).UTC()
-		closeRub := k.close * u.close
+		closeRub := toRUB(k.close, u.close)
 		out = append(out, storage.CryptoRate{
 			Timestamp: ts,
 			Symbol:    symbol,
-			Open:      k.open * u.open,
-			High:      k.high * u.high,
-			Low:       k.low * u.low,
+			Open:      toRUB(k.open, u.open),
+			High:      toRUB(k.high, u.high),
+			Low:       toRUB(k.low, u.low),
 			Close:     closeRub,
 			Volume:    k.volume,
 			PriceRUB:  closeRub,
@@ -218,7 +222,7 @@ func (c *Client) mergeKlinesToRUB(crypto, usdt []klineOHLCV, symbol string) []st
 	return out
 }
 
-func (c *Client) mergeKlinesCBRFallback(crypto []klineOHLCV, symbol string) []storage.CryptoRate {
+func (c *Client) mergeKlinesCBRFallback(ctx context.Context, crypto []klineOHLCV, symbol string) []storage.CryptoRate {
 	if c.cbr == nil {
 		return nil
 	}
@@ -227,22 +231,22 @@ func (c *Client) mergeKlinesCBRFallback(crypto []klineOHLCV, symbol string) []st
 		keys[i] = k.openTimeMs
 	}
 	days := uniqueCalendarDaysFromOpenMs(keys)
-	cbrCache := c.prefetchCBRUSD(days)
+	cbrCache := c.prefetchCBRUSD(ctx, days)
 	out := make([]storage.CryptoRate, 0, len(crypto))
 	for _, k := range crypto {
 		key := utcDate(time.UnixMilli(k.openTimeMs).UTC()).Format("2006-01-02")
 		rub, hit := cbrCache[key]
-		if !hit || rub <= 0 {
+		if !hit || !rub.IsPositive() {
 			continue
 		}
 		ts := time.UnixMilli(k.openTimeMs).UTC()
-		closeRub := k.close * rub
+		closeRub := toRUB(k.close, rub)
 		out = append(out, storage.CryptoRate{
 			Timestamp: ts,
 			Symbol:    symbol,
-			Open:      k.open * rub,
-			High:      k.high * rub,
-			Low:       k.low * rub,
+			Open:      toRUB(k.open, rub),
+			High:      toRUB(k.high, rub),
+			Low:       toRUB(k.low, rub),
 			Close:     closeRub,
 			Volume:    k.volume,
 			PriceRUB:  closeRub,
@@ -258,11 +262,11 @@ func absMilli(n int64) int64 {
 	return n
 }
 
-func (c *Client) fetchAllKlinesPaginated(symbol, interval string, startMs, endMs int64) ([]klineOHLCV, error) {
+func (c *Client) fetchAllKlinesPaginated(ctx context.Context, symbol, interval string, startMs, endMs int64) ([]klineOHLCV, error) {
 	var all []klineOHLCV
 	cur := startMs
 	for cur < endMs {
-		chunk, err := c.fetchKlinesPage(symbol, interval, cur, endMs)
+		chunk, err := c.fetchKlinesPage(ctx, symbol, interval, cur, endMs)
 		if err != nil {
 			return nil, err
 		}
@@ -284,20 +288,20 @@ func (c *Client) fetchAllKlinesPaginated(symbol, interval string, startMs, endMs
 	return all, nil
 }
 
-func (c *Client) usdRubFromCBR(day time.Time) (float64, error) {
+func (c *Client) usdRubFromCBR(ctx context.Context, day time.Time) (money.Decimal, error) {
 	if c.cbr == nil {
-		return 0, fmt.Errorf("cbr client disabled")
+		return money.Zero, fmt.Errorf("cbr client disabled")
 	}
-	rates, _, err := c.cbr.FetchDayWithFallback(day)
+	rates, _, err := c.cbr.FetchDayWithFallback(ctx, day)
 	if err != nil {
-		return 0, err
+		return money.Zero, err
 	}
 	for _, r := range rates {
 		if r.CurrencyCode == "USD" && r.Nominal > 0 {
-			return r.Value / float64(r.Nominal), nil
+			return r.Value.Div(money.NewFromInt(int64(r.Nominal)), money.PriceScale), nil
 		}
 	}
-	return 0, fmt.Errorf("no USD in CBR for %s", day.Format("2006-01-02"))
+	return money.Zero, fmt.Errorf("no USD in CBR for %s", day.Format("2006-01-02"))
 }
 
 func utcDate(t time.Time) time.Time {
@@ -308,14 +312,14 @@ func utcDate(t time.Time) time.Time {
 
 type klineOHLCV struct {
 	openTimeMs int64
-	open       float64
-	high       float64
-	low        float64
-	close      float64
-	volume     float64
+	open       money.Decimal
+	high       money.Decimal
+	low        money.Decimal
+	close      money.Decimal
+	volume     money.Decimal
 }
 
-func (c *Client) fetchAllDailyKlines(symbol string, from, to time.Time) ([]klineOHLCV, error) {
+func (c *Client) fetchAllDailyKlines(ctx context.Context, symbol string, from, to time.Time) ([]klineOHLCV, error) {
 	var all []klineOHLCV
 	cur := from
 	endInclusive := to
@@ -326,7 +330,7 @@ func (c *Client) fetchAllDailyKlines(symbol string, from, to time.Time) ([]kline
 		}
 		startMs := cur.UnixMilli()
 		endMs := chunkEnd.AddDate(0, 0, 1).UnixMilli()
-		part, err := c.fetchKlinesPage(symbol, interval1d, startMs, endMs)
+		part, err := c.fetchKlinesPage(ctx, symbol, interval1d, startMs, endMs)
 		if err != nil {
 			return nil, err
 		}
@@ -337,7 +341,7 @@ func (c *Client) fetchAllDailyKlines(symbol string, from, to time.Time) ([]kline
 	return all, nil
 }
 
-func (c *Client) fetchKlinesPage(symbol, interval string, startMs, endMs int64) ([]klineOHLCV, error) {
+func (c *Client) fetchKlinesPage(ctx context.Context, symbol, interval string, startMs, endMs int64) ([]klineOHLCV, error) {
 	q := url.Values{}
 	q.Set("symbol", symbol)
 	q.Set("interval", interval)
@@ -346,7 +350,11 @@ func (c *Client) fetchKlinesPage(symbol, interval string, startMs, endMs int64)
 	q.Set("limit", "1000")
 	reqURL := fmt.Sprintf("%s/api/v3/klines?%s", c.binanceBase, q.Encode())
 
-	resp, err := c.http.Get(reqURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("binance get: %w", err)
+	}
+	resp, err := c.http.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("binance get: %w", err)
 	}
@@ -381,11 +389,11 @@ func parseKlineRow(row []interface{}) (klineOHLCV, bool) {
 		return klineOHLCV{}, false
 	}
 	openTimeMs := int64(otf)
-	open, _ := parseFloatField(row[1])
-	high, _ := parseFloatField(row[2])
-	low, _ := parseFloatField(row[3])
-	close, _ := parseFloatField(row[4])
-	vol, _ := parseFloatField(row[5])
+	open, _ := parseDecimalField(row[1])
+	high, _ := parseDecimalField(row[2])
+	low, _ := parseDecimalField(row[3])
+	close, _ := parseDecimalField(row[4])
+	vol, _ := parseDecimalField(row[5])
 	return klineOHLCV{
 		openTimeMs: openTimeMs,
 		open:       open,
@@ -396,18 +404,25 @@ func parseKlineRow(row []interface{}) (klineOHLCV, bool) {
 	}, true
 }
 
-func parseFloatField(v interface{}) (float64, bool) {
+// parseDecimalField parses a kline price or volume, which Binance sends as
+// a decimal string.
+func parseDecimalField(v interface{}) (money.Decimal, bool) {
 	switch x := v.(type) {
 	case string:
-		f, err := strconv.ParseFloat(x, 64)
-		return f, err == nil
+		d, err := money.Parse(x)
+		return d, err == nil
 	case float64:
-		return x, true
+		return money.NewFromFloat(x), true
 	default:
-		return 0, false
+		return money.Zero, false
 	}
 }
 
+// toRUB converts a USD(T) price to RUB at rate, to money.PriceScale places.
+func toRUB(price, rate money.Decimal) money.Decimal {
+	return price.Mul(rate).Round(money.PriceScale)
+}
+
 func truncate(b []byte, n int) string {
 	if len(b) <= n {
 		return string(b)
@@ -18,7 +18,7 @@ func TestParseKlineRow(t *testing.T) {
 	if k.openTimeMs != 1499040000000 {
 		t.Fatalf("openTimeMs: got %d", k.openTimeMs)
 	}
-	if k.close < 0.015 || k.close > 0.016 {
+	if k.close.String() != "0.015771" || k.volume.String() != "148976.11427815" {
 		t.Fatalf("close: got %v", k.close)
 	}
 }
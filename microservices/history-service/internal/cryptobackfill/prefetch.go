@@ -1,16 +1,19 @@
 package cryptobackfill
 
 import (
+	"context"
 	"sync"
 	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
 )
 
 const cbrPrefetchWorkers = 8
 
 // prefetchCBRUSD loads USD/RUB for distinct calendar days in parallel (bounded),
 // so we do not chain one 20s HTTP call after another when USDTRUB is missing.
-func (c *Client) prefetchCBRUSD(days []time.Time) map[string]float64 {
-	out := make(map[string]float64)
+func (c *Client) prefetchCBRUSD(ctx context.Context, days []time.Time) map[string]money.Decimal {
+	out := make(map[string]money.Decimal)
 	if c == nil || c.cbr == nil || len(days) == 0 {
 		return out
 	}
@@ -25,7 +28,7 @@ func (c *Client) prefetchCBRUSD(days []time.Time) map[string]float64 {
 		go func(day time.Time, k string) {
 			defer wg.Done()
 			defer func() { <-sem }()
-			rub, err := c.usdRubFromCBR(day)
+			rub, err := c.usdRubFromCBR(ctx, day)
 			if err != nil {
 				return
 			}
@@ -0,0 +1,218 @@
+// Package export writes rate rows as CSV or newline-delimited JSON while they
+// are read from the database, flushing periodically so clients receive the
+// data as it is produced.
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+)
+
+// Format is an export encoding.
+type Format string
+
+const (
+	CSV    Format = "csv"
+	NDJSON Format = "ndjson"
+)
+
+// flushEvery is the number of rows buffered between flushes.
+const flushEvery = 500
+
+// ParseFormat accepts csv (default), ndjson or jsonl.
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "", "csv":
+		return CSV, nil
+	case "ndjson", "jsonl":
+		return NDJSON, nil
+	case "parquet":
+		return "", fmt.Errorf("parquet export is not supported, use csv or ndjson")
+	}
+	return "", fmt.Errorf("unsupported format %q, use csv or ndjson", s)
+}
+
+// ContentType is the MIME type of the format.
+func (f Format) ContentType() string {
+	if f == NDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Ext is the file extension used in Content-Disposition.
+func (f Format) Ext() string {
+	if f == NDJSON {
+		return "ndjson"
+	}
+	return "csv"
+}
+
+// Writer encodes rows with a fixed column list. CSV starts with a header
+// line; NDJSON writes one object per line with keys in column order.
+type Writer struct {
+	format  Format
+	columns []string
+	buf     *bufio.Writer
+	csv     *csv.Writer
+	flush   func()
+	numbers bool
+	pending int
+	rows    int
+}
+
+// NewWriter returns a writer for w. flush, if not nil, is called after the
+// buffered data has been written to w (e.g. http.Flusher.Flush).
+func NewWriter(w io.Writer, format Format, columns []string, flush func()) *Writer {
+	ew := &Writer{format: format, columns: columns, buf: bufio.NewWriter(w), flush: flush}
+	if format == CSV {
+		ew.csv = csv.NewWriter(ew.buf)
+	}
+	return ew
+}
+
+// NumberDecimals makes NDJSON rows write decimals as JSON numbers instead of
+// strings, for clients that asked for them (?decimals=number).
+func (w *Writer) NumberDecimals() { w.numbers = true }
+
+// Write encodes one row; values must match the column list. Supported value
+// types are string, int, int64, float64, money.Decimal and time.Time.
+func (w *Writer) Write(values ...any) error {
+	if len(values) != len(w.columns) {
+		return fmt.Errorf("export: %d values for %d columns", len(values), len(w.columns))
+	}
+	if w.rows == 0 && w.format == CSV {
+		if err := w.csv.Write(w.columns); err != nil {
+			return err
+		}
+	}
+	w.rows++
+
+	var err error
+	if w.format == CSV {
+		err = w.writeCSV(values)
+	} else {
+		err = w.writeNDJSON(values)
+	}
+	if err != nil {
+		return err
+	}
+
+	if w.pending++; w.pending >= flushEvery {
+		return w.Flush()
+	}
+	return nil
+}
+
+// Rows is the number of rows written so far.
+func (w *Writer) Rows() int { return w.rows }
+
+// Flush writes buffered rows to the underlying writer.
+func (w *Writer) Flush() error {
+	w.pending = 0
+	if w.csv != nil {
+		if w.rows == 0 {
+			// An empty export still gets its header.
+			if err := w.csv.Write(w.columns); err != nil {
+				return err
+			}
+		}
+		w.csv.Flush()
+		if err := w.csv.Error(); err != nil {
+			return err
+		}
+	}
+	if err := w.buf.Flush(); err != nil {
+		return err
+	}
+	if w.flush != nil {
+		w.flush()
+	}
+	return nil
+}
+
+// Abort ends an export that failed after rows were written with a record
+// that cannot be mistaken for a row: {"error": msg} as the last NDJSON line,
+// or a CSV record with "error" in the first column and msg in the second,
+// padded to the column count. Clients that find it know the export is
+// incomplete; without it a truncated file would look whole.
+func (w *Writer) Abort(msg string) error {
+	if w.csv != nil {
+		record := make([]string, max(len(w.columns), 2))
+		record[0], record[1] = "error", msg
+		if err := w.csv.Write(record); err != nil {
+			return err
+		}
+	} else {
+		b, err := json.Marshal(map[string]string{"error": msg})
+		if err != nil {
+			return err
+		}
+		w.buf.Write(b)
+		w.buf.WriteByte('\n')
+	}
+	return w.Flush()
+}
+
+func (w *Writer) writeCSV(values []any) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = formatValue(v)
+	}
+	return w.csv.Write(record)
+}
+
+func (w *Writer) writeNDJSON(values []any) error {
+	w.buf.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			w.buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(w.columns[i])
+		w.buf.Write(key)
+		w.buf.WriteByte(':')
+		switch x := v.(type) {
+		case time.Time:
+			v = formatValue(x)
+		case money.Decimal:
+			if w.numbers {
+				v = money.Numbers(x)
+			}
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		w.buf.Write(b)
+	}
+	w.buf.WriteByte('}')
+	return w.buf.WriteByte('\n')
+}
+
+// formatValue renders timestamps as RFC 3339 in UTC, floats in their
+// shortest exact form and decimals as stored.
+func formatValue(v any) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case int:
+		return strconv.Itoa(x)
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case money.Decimal:
+		return x.String()
+	case time.Time:
+		return x.UTC().Format(time.RFC3339)
+	}
+	return fmt.Sprint(v)
+}
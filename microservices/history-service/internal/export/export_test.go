@@ -0,0 +1,121 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFormat(t *testing.T) {
+	for in, want := range map[string]Format{"": CSV, "CSV": CSV, "ndjson": NDJSON, "jsonl": NDJSON} {
+		if got, err := ParseFormat(in); err != nil || got != want {
+			t.Errorf("%q: expected %s, got %s (%v)", in, want, got, err)
+		}
+	}
+	for _, bad := range []string{"parquet", "xlsx"} {
+		if _, err := ParseFormat(bad); err == nil {
+			t.Errorf("%q: expected error", bad)
+		}
+	}
+}
+
+func TestWriter_CSV(t *testing.T) {
+	var buf bytes.Buffer
+	flushes := 0
+	w := NewWriter(&buf, CSV, []string{"date", "code", "nominal", "value"}, func() { flushes++ })
+
+	if err := w.Write("2024-01-09", "USD", 1, 89.6883); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write("2024-01-09", "JPY, Japan", 100, 61.5); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("rows should stay buffered until Flush, got %q", buf.String())
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "date,code,nominal,value\n2024-01-09,USD,1,89.6883\n2024-01-09,\"JPY, Japan\",100,61.5\n"
+	if buf.String() != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, buf.String())
+	}
+	if flushes != 1 || w.Rows() != 2 {
+		t.Errorf("expected 1 flush and 2 rows, got %d and %d", flushes, w.Rows())
+	}
+}
+
+func TestWriter_CSVEmptyHasHeader(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, CSV, []string{"a", "b"}, nil)
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "a,b\n" {
+		t.Errorf("expected header only, got %q", buf.String())
+	}
+}
+
+func TestWriter_NDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, NDJSON, []string{"timestamp", "symbol", "close"}, nil)
+	ts := time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("MSK", 3*3600))
+	if err := w.Write(ts, "BTCUSDT", 62000.5); err != nil {
+		t.Fatal(err)
+	}
+	w.Flush()
+
+	want := `{"timestamp":"2024-03-01T09:00:00Z","symbol":"BTCUSDT","close":62000.5}` + "\n"
+	if buf.String() != want {
+		t.Errorf("expected %s, got %s", want, buf.String())
+	}
+}
+
+func TestWriter_flushesPeriodically(t *testing.T) {
+	var buf bytes.Buffer
+	flushes := 0
+	w := NewWriter(&buf, NDJSON, []string{"n"}, func() { flushes++ })
+	for i := 0; i < flushEvery*2+1; i++ {
+		if err := w.Write(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if flushes != 2 {
+		t.Errorf("expected 2 automatic flushes, got %d", flushes)
+	}
+	if got := strings.Count(buf.String(), "\n"); got != flushEvery*2 {
+		t.Errorf("expected %d flushed lines, got %d", flushEvery*2, got)
+	}
+}
+
+func TestWriter_columnMismatch(t *testing.T) {
+	w := NewWriter(&bytes.Buffer{}, CSV, []string{"a", "b"}, nil)
+	if err := w.Write("only one"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestWriter_abortEndsWithErrorRecord(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, CSV, []string{"date", "code", "value"}, nil)
+	w.Write("2024-01-09", "USD", 89.6883)
+	if err := w.Abort("database error"); err != nil {
+		t.Fatal(err)
+	}
+	want := "date,code,value\n2024-01-09,USD,89.6883\nerror,database error,\n"
+	if buf.String() != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, buf.String())
+	}
+
+	buf.Reset()
+	w = NewWriter(&buf, NDJSON, []string{"n"}, nil)
+	w.Write(1)
+	if err := w.Abort("database error"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\"n\":1}\n{\"error\":\"database error\"}\n"; buf.String() != want {
+		t.Errorf("expected %q, got %q", want, buf.String())
+	}
+}
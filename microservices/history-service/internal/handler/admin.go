@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/coverage"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/calendar"
+)
+
+// The /admin routes report and repair gaps in the stored history. They are
+// served on the history-service port only; the gateway does not proxy them.
+
+// GET /admin/coverage?source=cbr&code=USD&from=2024-01-01&to=2024-01-31
+// GET /admin/coverage?source=crypto&code=BTCUSDT&from=2024-01-01&to=2024-01-07&interval=1h
+func (h *Handler) GetCoverage(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	source := q.Get("source")
+	code := strings.ToUpper(strings.TrimSpace(q.Get("code")))
+	interval := q.Get("interval")
+	if interval == "" {
+		interval = "1d"
+	}
+
+	from, err := calendar.ParseDate(q.Get("from"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid from date")
+		return
+	}
+	to, err := calendar.ParseDate(q.Get("to"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid to date")
+		return
+	}
+	if to.Before(from) {
+		writeError(w, http.StatusBadRequest, "to is before from")
+		return
+	}
+
+	var report coverage.Report
+	switch source {
+	case coverage.SourceCBR:
+		if interval != "1d" {
+			writeError(w, http.StatusBadRequest, "CBR rates are daily, interval must be 1d")
+			return
+		}
+		report, err = coverage.CBR(r.Context(), h.pg, code, from, to)
+	case coverage.SourceCrypto:
+		if code == "" {
+			writeError(w, http.StatusBadRequest, "code (the symbol) is required for crypto")
+			return
+		}
+		if _, ok := coverage.Intervals[interval]; !ok {
+			writeError(w, http.StatusBadRequest, "interval must be one of 1m, 5m, 15m, 30m, 1h, 4h, 1d")
+			return
+		}
+		report, err = coverage.Crypto(r.Context(), h.ch, code, interval, from, to)
+	default:
+		writeError(w, http.StatusBadRequest, "source must be cbr or crypto")
+		return
+	}
+	if errors.Is(err, coverage.ErrTooLong) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		logger.ErrorContext(r.Context(), "coverage query failed", "source", source, "code", code, "error", err)
+		writeError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// GET /admin/reconcile returns the latest reconciliation run
+func (h *Handler) GetReconcile(w http.ResponseWriter, r *http.Request) {
+	run := h.rec.Last()
+	if run == nil {
+		writeError(w, http.StatusNotFound, "no reconciliation has run yet")
+		return
+	}
+	writeJSON(w, http.StatusOK, run)
+}
+
+// POST /admin/reconcile runs a reconciliation now and returns it
+func (h *Handler) PostReconcile(w http.ResponseWriter, r *http.Request) {
+	run, err := h.rec.Run(r.Context())
+	if errors.Is(err, coverage.ErrRunning) {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, run)
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/coverage"
+)
+
+func TestGetCoverage_validationErrors(t *testing.T) {
+	h := &Handler{}
+	for _, path := range []string{
+		"/admin/coverage?code=USD&from=2024-01-01&to=2024-01-31",
+		"/admin/coverage?source=moex&from=2024-01-01&to=2024-01-31",
+		"/admin/coverage?source=cbr&from=2024-01-01",
+		"/admin/coverage?source=cbr&from=2024-02-01&to=2024-01-31",
+		"/admin/coverage?source=cbr&from=2024-01-01&to=2024-01-31&interval=1h",
+		"/admin/coverage?source=crypto&from=2024-01-01&to=2024-01-31",
+		"/admin/coverage?source=crypto&code=BTCUSDT&from=2024-01-01&to=2024-01-31&interval=2h",
+		// Too many candles is refused before any query
+		"/admin/coverage?source=crypto&code=BTCUSDT&from=2020-01-01&to=2024-01-31&interval=1m",
+	} {
+		if rr := get(t, h.GetCoverage, path); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d %s", path, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestGetReconcile_beforeFirstRun(t *testing.T) {
+	h := &Handler{rec: coverage.NewReconciler(nil, nil, nil, nil, 30)}
+	rr := httptest.NewRecorder()
+	h.GetReconcile(rr, httptest.NewRequest(http.MethodGet, "/admin/reconcile", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 before the first run, got %d", rr.Code)
+	}
+}
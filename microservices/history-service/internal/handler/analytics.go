@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/analytics"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/apiv1"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/calendar"
+)
+
+const maxCorrelationCodes = 20
+
+// AnalyticsResult is the response of GET /history/analytics.
+type AnalyticsResult = apiv1.AnalyticsResult
+
+// parseRange reads the mandatory from/to (YYYY-MM-DD) parameters.
+func parseRange(r *http.Request) (from, to time.Time, err error) {
+	return parseRangeValues(r.URL.Query().Get("from"), r.URL.Query().Get("to"))
+}
+
+// parseRangeValues validates a from/to pair of YYYY-MM-DD dates.
+func parseRangeValues(fromStr, toStr string) (from, to time.Time, err error) {
+	from, err = calendar.ParseDate(fromStr)
+	if err != nil {
+		return from, to, fmt.Errorf("invalid from date")
+	}
+	to, err = calendar.ParseDate(toStr)
+	if err != nil {
+		return from, to, fmt.Errorf("invalid to date")
+	}
+	if to.Before(from) {
+		return from, to, fmt.Errorf("to must not be before from")
+	}
+	return from, to, nil
+}
+
+// parseCodes splits a comma-separated list, upper-casing and de-duplicating.
+func parseCodes(s string) []string {
+	var codes []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ",") {
+		c := strings.ToUpper(strings.TrimSpace(part))
+		if c != "" && !seen[c] {
+			seen[c] = true
+			codes = append(codes, c)
+		}
+	}
+	return codes
+}
+
+// GET /history/analytics?code=USD&from=2024-01-01&to=2024-03-31[&source=crypto]
+//
+// CBR values are per single unit (Value/Nominal) in RUB; crypto values are
+// PriceRUB. code may be BTC or BTCUSDT for crypto.
+func (h *Handler) GetAnalytics(w http.ResponseWriter, r *http.Request) {
+	p, err := parseAnalyticsParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	res, err := h.analyze(r.Context(), p)
+	if err != nil {
+		writeFailure(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+type analyticsParams struct {
+	code, source string
+	from, to     time.Time
+}
+
+// parseAnalyticsParams validates ?code=&from=&to=[&source=]; source
+// defaults to cbr.
+func parseAnalyticsParams(r *http.Request) (analyticsParams, error) {
+	p := analyticsParams{
+		code:   strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("code"))),
+		source: r.URL.Query().Get("source"),
+	}
+	if p.code == "" {
+		return p, fmt.Errorf("code is required")
+	}
+	if p.source == "" {
+		p.source = "cbr"
+	}
+	if p.source != "cbr" && p.source != "crypto" {
+		return p, fmt.Errorf("source must be cbr or crypto")
+	}
+	var err error
+	p.from, p.to, err = parseRange(r)
+	return p, err
+}
+
+func (h *Handler) analyze(ctx context.Context, p analyticsParams) (AnalyticsResult, error) {
+	var points []analytics.Point
+	var err error
+	code := p.code
+	if p.source == "crypto" {
+		code = cryptoSymbol(code)
+		points, err = h.cryptoSeries(ctx, code, p.from, p.to)
+	} else {
+		points, err = h.cbrSeries(ctx, code, p.from, p.to)
+	}
+	if err != nil {
+		return AnalyticsResult{}, errDatabase
+	}
+	if len(points) == 0 {
+		return AnalyticsResult{}, notFound("no data for " + code + " in range")
+	}
+	return AnalyticsResult{Code: code, Source: p.source, Summary: analytics.Summarize(points)}, nil
+}
+
+// GET /history/analytics/correlation?codes=USD,EUR,BTC&from=2024-01-01&to=2024-03-31
+//
+// Codes listed by /history/crypto/symbols (with or without the USDT suffix)
+// are read from ClickHouse, everything else from the CBR tables.
+func (h *Handler) GetCorrelation(w http.ResponseWriter, r *http.Request) {
+	codes, from, to, err := parseCorrelationParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	res, err := h.correlate(r.Context(), codes, from, to)
+	if err != nil {
+		writeFailure(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// parseCorrelationParams validates ?codes=&from=&to=.
+func parseCorrelationParams(r *http.Request) (codes []string, from, to time.Time, err error) {
+	codes = parseCodes(r.URL.Query().Get("codes"))
+	if len(codes) < 2 {
+		return nil, from, to, fmt.Errorf("codes must list at least two currencies or symbols")
+	}
+	if len(codes) > maxCorrelationCodes {
+		return nil, from, to, fmt.Errorf("at most %d codes are allowed", maxCorrelationCodes)
+	}
+	from, to, err = parseRange(r)
+	return codes, from, to, err
+}
+
+func (h *Handler) correlate(ctx context.Context, codes []string, from, to time.Time) (analytics.CorrelationMatrix, error) {
+	symbols, err := h.ch.GetAvailableCryptoSymbols()
+	if err != nil {
+		return analytics.CorrelationMatrix{}, errDatabase
+	}
+	isCrypto := make(map[string]bool, len(symbols))
+	for _, s := range symbols {
+		isCrypto[s] = true
+	}
+
+	series := make(map[string][]analytics.Point, len(codes))
+	for _, code := range codes {
+		var points []analytics.Point
+		if sym := cryptoSymbol(code); isCrypto[sym] {
+			points, err = h.cryptoSeries(ctx, sym, from, to)
+		} else {
+			points, err = h.cbrSeries(ctx, code, from, to)
+		}
+		if err != nil {
+			return analytics.CorrelationMatrix{}, errDatabase
+		}
+		if len(points) == 0 {
+			return analytics.CorrelationMatrix{}, notFound("no data for " + code + " in range")
+		}
+		series[code] = points
+	}
+	return analytics.Correlate(codes, series), nil
+}
+
+// cryptoSymbol maps BTC to the stored BTCUSDT pair.
+func cryptoSymbol(code string) string {
+	if strings.HasSuffix(code, "USDT") {
+		return code
+	}
+	return code + "USDT"
+}
+
+// cbrSeries loads per-unit RUB values for code, backfilling missing days
+// from the CBR archive like GetCBRHistoryRange.
+func (h *Handler) cbrSeries(ctx context.Context, code string, from, to time.Time) ([]analytics.Point, error) {
+	h.backfillCBRMissingDays(ctx, code, from, to)
+	rates, err := h.pg.GetCurrencyRatesByDateRange(code, from, to)
+	if err != nil {
+		return nil, err
+	}
+	points := make([]analytics.Point, 0, len(rates))
+	for _, r := range rates {
+		points = append(points, analytics.Point{Time: r.Date, Value: r.PerUnit().Float64()})
+	}
+	return points, nil
+}
+
+// cryptoSeries loads PriceRUB for symbol, backfilling daily klines like
+// GetCryptoHistoryRange.
+func (h *Handler) cryptoSeries(ctx context.Context, symbol string, from, to time.Time) ([]analytics.Point, error) {
+	rates, err := h.ch.GetCryptoRatesByDateRange(symbol, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if h.backfillCryptoRange(ctx, symbol, from, to, rates) {
+		if rates, err = h.ch.GetCryptoRatesByDateRange(symbol, from, to); err != nil {
+			return nil, err
+		}
+	}
+	points := make([]analytics.Point, 0, len(rates))
+	for _, r := range rates {
+		if r.PriceRUB.IsPositive() {
+			points = append(points, analytics.Point{Time: r.Timestamp, Value: r.PriceRUB.Float64()})
+		}
+	}
+	return points, nil
+}
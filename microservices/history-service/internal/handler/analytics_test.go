@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseRange(t *testing.T) {
+	from, to, err := parseRange(httptest.NewRequest("GET", "/history/analytics?from=2024-01-01&to=2024-03-31", nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if from.Format("2006-01-02") != "2024-01-01" || to.Format("2006-01-02") != "2024-03-31" {
+		t.Errorf("unexpected range %s..%s", from, to)
+	}
+
+	for _, q := range []string{
+		"?to=2024-03-31",
+		"?from=2024-01-01",
+		"?from=01.01.2024&to=2024-03-31",
+		"?from=2024-03-31&to=2024-01-01",
+	} {
+		if _, _, err := parseRange(httptest.NewRequest("GET", "/history/analytics"+q, nil)); err == nil {
+			t.Errorf("%s: expected error", q)
+		}
+	}
+}
+
+func TestParseCodes(t *testing.T) {
+	got := parseCodes(" usd,EUR,,btc,USD ")
+	want := []string{"USD", "EUR", "BTC"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestCryptoSymbol(t *testing.T) {
+	if got := cryptoSymbol("BTC"); got != "BTCUSDT" {
+		t.Errorf("expected BTCUSDT, got %s", got)
+	}
+	if got := cryptoSymbol("ETHUSDT"); got != "ETHUSDT" {
+		t.Errorf("expected ETHUSDT, got %s", got)
+	}
+}
@@ -1,86 +1,122 @@
 package handler
 
 import (
-	"log"
+	"context"
 	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/cbrbackfill"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/calendar"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/logging"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/metrics"
 )
 
 const maxCBRAutoBackfillSpanDays = 400
 
-func calendarDateUTC(t time.Time) time.Time {
-	y, m, d := t.UTC().Date()
-	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
-}
-
 // backfillCBRMissingDays loads archive daily_json for each calendar day in [from,to]
 // where the requested currency code is missing from PostgreSQL.
 // Returns true if at least one day was fetched and stored.
-func (h *Handler) backfillCBRMissingDays(code string, from, to time.Time) bool {
+func (h *Handler) backfillCBRMissingDays(ctx context.Context, code string, from, to time.Time) bool {
 	if h.cbr == nil {
 		return false
 	}
-	start := calendarDateUTC(from)
-	end := calendarDateUTC(to)
+	began := time.Now()
+	start := calendar.Date(from)
+	end := calendar.Date(to)
 	if end.Before(start) {
 		return false
 	}
 	span := int(end.Sub(start).Hours()/24) + 1
 	if span > maxCBRAutoBackfillSpanDays {
-		log.Printf("cbr backfill: range too long (%d days), skipping auto-fetch", span)
+		logger.InfoContext(ctx, "cbr backfill skipped, range too long", "currency", code, "days", span)
 		return false
 	}
 	any := false
 	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
 		has, err := h.pg.HasCBRRateOnDay(code, d)
 		if err != nil {
-			log.Printf("cbr backfill: has check %s %s: %v", code, d.Format("2006-01-02"), err)
+			logger.WarnContext(ctx, "cbr backfill check failed", "currency", code, "date", d.Format("2006-01-02"), "error", err)
 			continue
 		}
 		if has {
 			continue
 		}
-		rates, srcDay, err := h.cbr.FetchDayWithFallback(d)
+		rates, srcDay, err := h.cbr.FetchDayWithFallback(ctx, d)
 		if err != nil {
-			log.Printf("cbr backfill: fetch %s: %v", d.Format("2006-01-02"), err)
+			metrics.Backfill(metrics.SourceCBR, err)
+			logger.WarnContext(ctx, "cbr backfill fetch failed", "currency", code, "date", d.Format("2006-01-02"), "error", err)
 			continue
 		}
-		if !calendarDateUTC(srcDay).Equal(d) {
-			log.Printf("cbr backfill: using archive %s for missing day %s", srcDay.Format("2006-01-02"), d.Format("2006-01-02"))
-			for i := range rates {
-				rates[i].Date = d
-			}
+		if !calendar.Date(srcDay).Equal(d) {
+			logger.DebugContext(ctx, "cbr backfill using earlier sheet", "date", d.Format("2006-01-02"), "sheet", srcDay.Format("2006-01-02"))
+			cbrbackfill.CarryOver(rates, d, srcDay)
 		}
-		if err := h.pg.SaveCurrencyRates(rates); err != nil {
-			log.Printf("cbr backfill: save %s: %v", d.Format("2006-01-02"), err)
+		err = h.pg.SaveCurrencyRates(rates)
+		metrics.Backfill(metrics.SourceCBR, err)
+		if err != nil {
+			logger.ErrorContext(ctx, "cbr backfill save failed", "date", d.Format("2006-01-02"), "error", err)
 			continue
 		}
 		any = true
-		log.Printf("cbr backfill: stored %d CBR rows for %s", len(rates), d.Format("2006-01-02"))
+		logger.InfoContext(ctx, "cbr backfill stored", "currency", code, "date", d.Format("2006-01-02"),
+			"count", len(rates), "duration_ms", logging.Millis(time.Since(began)))
 		time.Sleep(120 * time.Millisecond)
 	}
 	return any
 }
 
 // backfillCBRDayIfEmpty loads the full daily sheet when the DB has no rows for that date.
-func (h *Handler) backfillCBRDayIfEmpty(day time.Time) {
+func (h *Handler) backfillCBRDayIfEmpty(ctx context.Context, day time.Time) {
 	if h.cbr == nil {
 		return
 	}
-	d := calendarDateUTC(day)
-	rates, srcDay, err := h.cbr.FetchDayWithFallback(d)
+	d := calendar.Date(day)
+	began := time.Now()
+	rates, srcDay, err := h.cbr.FetchDayWithFallback(ctx, d)
 	if err != nil {
-		log.Printf("cbr backfill: single-day fetch %s: %v", d.Format("2006-01-02"), err)
+		metrics.Backfill(metrics.SourceCBR, err)
+		logger.WarnContext(ctx, "cbr backfill fetch failed", "date", d.Format("2006-01-02"), "error", err)
 		return
 	}
-	if !calendarDateUTC(srcDay).Equal(d) {
-		log.Printf("cbr backfill: single-day using archive %s for %s", srcDay.Format("2006-01-02"), d.Format("2006-01-02"))
-		for i := range rates {
-			rates[i].Date = d
-		}
+	if !calendar.Date(srcDay).Equal(d) {
+		logger.DebugContext(ctx, "cbr backfill using earlier sheet", "date", d.Format("2006-01-02"), "sheet", srcDay.Format("2006-01-02"))
+		cbrbackfill.CarryOver(rates, d, srcDay)
 	}
-	if err := h.pg.SaveCurrencyRates(rates); err != nil {
-		log.Printf("cbr backfill: single-day save %s: %v", d.Format("2006-01-02"), err)
+	err = h.pg.SaveCurrencyRates(rates)
+	metrics.Backfill(metrics.SourceCBR, err)
+	if err != nil {
+		logger.ErrorContext(ctx, "cbr backfill save failed", "date", d.Format("2006-01-02"), "error", err)
 		return
 	}
-	log.Printf("cbr backfill: stored %d CBR rows for %s (list-by-date)", len(rates), d.Format("2006-01-02"))
+	logger.InfoContext(ctx, "cbr backfill stored", "date", d.Format("2006-01-02"),
+		"count", len(rates), "duration_ms", logging.Millis(time.Since(began)))
+}
+
+// backfillIndicator loads the values indicator took in [from, to] from the
+// DailyInfo web service, reaching back cbrbackfill.IndicatorLookbackDays
+// before from for the value in effect on it. Returns true if anything was
+// stored.
+func (h *Handler) backfillIndicator(ctx context.Context, indicator string, from, to time.Time) bool {
+	if h.cbrInd == nil {
+		return false
+	}
+	began := time.Now()
+	start := calendar.Date(from).AddDate(0, 0, -cbrbackfill.IndicatorLookbackDays)
+	rates, err := h.cbrInd.FetchIndicator(ctx, indicator, start, to)
+	if err != nil {
+		metrics.Backfill(metrics.SourceCBRIndicators, err)
+		logger.WarnContext(ctx, "cbr indicator backfill fetch failed", "indicator", indicator, "from", start.Format("2006-01-02"), "error", err)
+		return false
+	}
+	if len(rates) == 0 {
+		return false
+	}
+	err = h.pg.SaveIndicatorRates(rates)
+	metrics.Backfill(metrics.SourceCBRIndicators, err)
+	if err != nil {
+		logger.ErrorContext(ctx, "cbr indicator backfill save failed", "indicator", indicator, "error", err)
+		return false
+	}
+	logger.InfoContext(ctx, "cbr indicator backfill stored", "indicator", indicator, "from", start.Format("2006-01-02"),
+		"to", calendar.Date(to).Format("2006-01-02"), "count", len(rates), "duration_ms", logging.Millis(time.Since(began)))
+	return true
 }
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/cbrbackfill"
+	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/storage"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/apiv1"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/events"
+)
+
+// cbrIndicators are the CBR indicators served, in the order they are listed.
+var cbrIndicators = []string{events.IndicatorKeyRate, events.IndicatorRUONIA}
+
+// GET /v1/rates/indicators[?date=2024-01-15]
+//
+// The CBR key rate and RUONIA in effect on date. An indicator with nothing
+// stored on or before date is backfilled from the DailyInfo web service.
+func (h *Handler) V1IndicatorRates(w http.ResponseWriter, r *http.Request) {
+	date, err := parseDate(r)
+	if err != nil {
+		writeV1Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	rates, err := h.pg.GetIndicatorRatesOn(r.Context(), date)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "indicator rates query failed", "date", date.Format("2006-01-02"), "error", err)
+		writeV1Failure(w, errDatabase)
+		return
+	}
+	if len(rates) < len(cbrIndicators) {
+		filled := false
+		for _, ind := range cbrIndicators {
+			if !hasIndicator(rates, ind) {
+				filled = h.backfillIndicator(r.Context(), ind, date, date) || filled
+			}
+		}
+		if filled {
+			if rates, err = h.pg.GetIndicatorRatesOn(r.Context(), date); err != nil {
+				writeV1Failure(w, errDatabase)
+				return
+			}
+		}
+	}
+	writeV1(w, v1IndicatorRates(rates))
+}
+
+// GET /v1/rates/indicators/range?indicator=KEY_RATE&from=2024-01-01&to=2024-12-31
+//
+// The values in effect in the range, one per change: the first is the one
+// in effect on from. History missing before from is backfilled.
+func (h *Handler) V1IndicatorRange(w http.ResponseWriter, r *http.Request) {
+	indicator := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("indicator")))
+	if !cbrbackfill.IsIndicator(indicator) {
+		writeV1Error(w, http.StatusBadRequest, "indicator must be one of "+strings.Join(cbrIndicators, ", "))
+		return
+	}
+	from, to, err := parseRange(r)
+	if err != nil {
+		writeV1Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	rates, err := h.pg.GetIndicatorRatesByDateRange(r.Context(), indicator, from, to)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "indicator rates query failed", "indicator", indicator, "error", err)
+		writeV1Failure(w, errDatabase)
+		return
+	}
+	if len(rates) == 0 || rates[0].EffectiveDate.After(from) {
+		if h.backfillIndicator(r.Context(), indicator, from, to) {
+			if rates, err = h.pg.GetIndicatorRatesByDateRange(r.Context(), indicator, from, to); err != nil {
+				writeV1Failure(w, errDatabase)
+				return
+			}
+		}
+	}
+	writeV1(w, v1IndicatorRates(rates))
+}
+
+func hasIndicator(rates []storage.IndicatorRate, indicator string) bool {
+	for _, r := range rates {
+		if r.Indicator == indicator {
+			return true
+		}
+	}
+	return false
+}
+
+func v1IndicatorRates(rates []storage.IndicatorRate) []apiv1.IndicatorRate {
+	out := make([]apiv1.IndicatorRate, 0, len(rates))
+	for _, r := range rates {
+		out = append(out, apiv1.IndicatorRate{
+			EffectiveDate: r.EffectiveDate.Format("2006-01-02"),
+			Indicator:     r.Indicator,
+			Name:          r.Name,
+			Value:         r.Value,
+		})
+	}
+	return out
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/storage"
+)
+
+func TestV1IndicatorRates_json(t *testing.T) {
+	day := time.Date(2026, 3, 23, 0, 0, 0, 0, time.UTC)
+	rates := v1IndicatorRates([]storage.IndicatorRate{
+		{Indicator: "KEY_RATE", EffectiveDate: day, Name: "Key rate", Value: dec("15.5")},
+	})
+	b, _ := json.Marshal(rates)
+	want := `[{"effective_date":"2026-03-23","indicator":"KEY_RATE","name":"Key rate","value":"15.5"}]`
+	if string(b) != want {
+		t.Errorf("expected %s, got %s", want, b)
+	}
+	if b, _ := json.Marshal(v1IndicatorRates(nil)); string(b) != "[]" {
+		t.Errorf("expected an empty list, got %s", b)
+	}
+}
+
+func TestV1IndicatorRange_validationErrors(t *testing.T) {
+	h := &Handler{}
+	for _, path := range []string{
+		"/v1/rates/indicators/range?from=2024-01-01&to=2024-01-31",
+		"/v1/rates/indicators/range?indicator=MIACR&from=2024-01-01&to=2024-01-31",
+		"/v1/rates/indicators/range?indicator=KEY_RATE",
+		"/v1/rates/indicators/range?indicator=key_rate&from=2024-01-31&to=2024-01-01",
+	} {
+		if rr := get(t, h.V1IndicatorRange, path); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d %s", path, rr.Code, rr.Body.String())
+		}
+	}
+	if rr := get(t, h.V1IndicatorRates, "/v1/rates/indicators?date=23.03.2026"); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a bad date, got %d", rr.Code)
+	}
+}
+
+func TestHasIndicator(t *testing.T) {
+	rates := []storage.IndicatorRate{{Indicator: "RUONIA"}}
+	if !hasIndicator(rates, "RUONIA") || hasIndicator(rates, "KEY_RATE") {
+		t.Error("expected RUONIA only")
+	}
+}
@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/calendar"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+)
+
+// ConversionResult is the response of GET /history/convert. Rate has
+// money.PriceScale places and Result is rounded to the minor unit of To.
+type ConversionResult struct {
+	From         string
+	To           string
+	Amount       money.Decimal
+	Result       money.Decimal
+	Rate         money.Decimal // units of To per one unit of From
+	Date         string
+	FromRateDate string // differs from Date when a rate was carried over
+	ToRateDate   string
+}
+
+// assetQuote is the RUB value of one unit of a currency or crypto asset.
+type assetQuote struct {
+	rubPerUnit money.Decimal
+	rateDate   time.Time
+	crypto     bool
+}
+
+type convertParams struct {
+	from, to string
+	amount   money.Decimal
+	day      time.Time
+}
+
+// parseConvertParams validates ?from=&to=&amount=&date=. amount defaults to 1
+// and date to today in Moscow.
+func parseConvertParams(r *http.Request) (convertParams, error) {
+	q := r.URL.Query()
+	p := convertParams{
+		from:   strings.ToUpper(strings.TrimSpace(q.Get("from"))),
+		to:     strings.ToUpper(strings.TrimSpace(q.Get("to"))),
+		amount: money.NewFromInt(1),
+		day:    calendar.Today(),
+	}
+	if p.from == "" || p.to == "" {
+		return p, fmt.Errorf("from and to are required")
+	}
+	if s := q.Get("amount"); s != "" {
+		v, err := money.Parse(s)
+		if err != nil || v.IsNegative() {
+			return p, fmt.Errorf("invalid amount")
+		}
+		p.amount = v
+	}
+	if s := q.Get("date"); s != "" {
+		d, err := calendar.ParseDate(s)
+		if err != nil {
+			return p, fmt.Errorf("invalid date format, use YYYY-MM-DD")
+		}
+		p.day = d
+	}
+	return p, nil
+}
+
+// convertAmount returns amount of from in units of to, rounded to the minor
+// unit of to. It divides last, so the result does not inherit the rounding
+// of the cross rate.
+func convertAmount(amount money.Decimal, code string, from, to assetQuote) money.Decimal {
+	return amount.Mul(from.rubPerUnit).Div(to.rubPerUnit, money.Scale(code, to.crypto))
+}
+
+// GET /history/convert?from=EUR&to=CNY&amount=250&date=2025-03-10
+func (h *Handler) Convert(w http.ResponseWriter, r *http.Request) {
+	p, err := parseConvertParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	res, err := h.convert(r.Context(), p)
+	if err != nil {
+		writeFailure(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (h *Handler) convert(ctx context.Context, p convertParams) (ConversionResult, error) {
+	fromQ, err := h.quoteOn(ctx, p.from, p.day)
+	if err != nil {
+		return ConversionResult{}, notFound(err.Error())
+	}
+	toQ, err := h.quoteOn(ctx, p.to, p.day)
+	if err != nil {
+		return ConversionResult{}, notFound(err.Error())
+	}
+
+	return ConversionResult{
+		From:         p.from,
+		To:           p.to,
+		Amount:       p.amount,
+		Result:       convertAmount(p.amount, p.to, fromQ, toQ),
+		Rate:         fromQ.rubPerUnit.Div(toQ.rubPerUnit, money.PriceScale),
+		Date:         p.day.Format("2006-01-02"),
+		FromRateDate: fromQ.rateDate.Format("2006-01-02"),
+		ToRateDate:   toQ.rateDate.Format("2006-01-02"),
+	}, nil
+}
+
+// quoteOn resolves code on day: CBR rates from PostgreSQL (archive backfill
+// if the window is empty), then crypto RUB prices from ClickHouse. Rates are
+// carried over for up to quoteLookbackDays, like FetchDayWithFallback.
+func (h *Handler) quoteOn(ctx context.Context, code string, day time.Time) (assetQuote, error) {
+	day = calendar.Date(day)
+	if code == quoteRUB {
+		return assetQuote{rubPerUnit: money.NewFromInt(1), rateDate: day}, nil
+	}
+
+	if q, ok := h.fiatQuoteOn(code, day); ok {
+		return q, nil
+	}
+	if rows, err := h.pg.GetCurrencyRatesByDate(day); err == nil && len(rows) == 0 && h.cbr != nil {
+		h.backfillCBRDayIfEmpty(ctx, day)
+		if q, ok := h.fiatQuoteOn(code, day); ok {
+			return q, nil
+		}
+	}
+
+	symbol := code
+	if !strings.HasSuffix(symbol, "USDT") {
+		symbol += "USDT"
+	}
+	rates, err := h.ch.GetCryptoRatesByDateRange(symbol, day.AddDate(0, 0, -quoteLookbackDays), day)
+	if err == nil {
+		for i := len(rates) - 1; i >= 0; i-- {
+			if rates[i].PriceRUB.IsPositive() {
+				return assetQuote{rubPerUnit: rates[i].PriceRUB, rateDate: calendar.Date(rates[i].Timestamp.UTC()), crypto: true}, nil
+			}
+		}
+	}
+	return assetQuote{}, fmt.Errorf("no rate for %s on or before %s", code, day.Format("2006-01-02"))
+}
+
+func (h *Handler) fiatQuoteOn(code string, day time.Time) (assetQuote, bool) {
+	rows, err := h.pg.GetCurrencyRatesByDateRange(code, day.AddDate(0, 0, -quoteLookbackDays), day)
+	if err != nil || len(rows) == 0 {
+		return assetQuote{}, false
+	}
+	// Rows are ordered by date descending.
+	return assetQuote{rubPerUnit: rows[0].PerUnit(), rateDate: calendar.Date(rows[0].Date)}, true
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+)
+
+func TestParseConvertParams(t *testing.T) {
+	p, err := parseConvertParams(httptest.NewRequest("GET", "/history/convert?from=eur&to=CNY&amount=250&date=2025-03-10", nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.from != "EUR" || p.to != "CNY" || p.amount.String() != "250" || p.day.Format("2006-01-02") != "2025-03-10" {
+		t.Errorf("unexpected params: %+v", p)
+	}
+
+	p, err = parseConvertParams(httptest.NewRequest("GET", "/history/convert?from=USD&to=RUB", nil))
+	if err != nil || p.amount.String() != "1" {
+		t.Errorf("amount should default to 1, got %v (err %v)", p.amount, err)
+	}
+
+	for _, q := range []string{
+		"?to=CNY",
+		"?from=EUR",
+		"?from=EUR&to=CNY&amount=abc",
+		"?from=EUR&to=CNY&amount=-1",
+		"?from=EUR&to=CNY&date=10.03.2025",
+	} {
+		if _, err := parseConvertParams(httptest.NewRequest("GET", "/history/convert"+q, nil)); err == nil {
+			t.Errorf("%s: expected error", q)
+		}
+	}
+}
+
+func TestConvertAmount(t *testing.T) {
+	eur := assetQuote{rubPerUnit: dec("100")}
+	cny := assetQuote{rubPerUnit: money.PerUnit(dec("125"), 10)}
+	jpy := assetQuote{rubPerUnit: money.PerUnit(dec("60.1234"), 100)}
+	kwd := assetQuote{rubPerUnit: dec("295.3333")}
+	btc := assetQuote{rubPerUnit: dec("5600000.12345678"), crypto: true}
+	for _, tc := range []struct {
+		amount string
+		code   string
+		to     assetQuote
+		want   string
+	}{
+		{"250", "CNY", cny, "2000"},
+		{"0.1", "CNY", cny, "0.8"},
+		{"1", "JPY", jpy, "166"},   // 166.32...: no minor unit
+		{"1", "KWD", kwd, "0.339"}, // 0.33860...: three places
+		{"1000", "BTC", btc, "0.01785714"},
+	} {
+		if got := convertAmount(dec(tc.amount), tc.code, eur, tc.to); got.String() != tc.want {
+			t.Errorf("%s EUR in %s: expected %s, got %s", tc.amount, tc.code, tc.want, got)
+		}
+	}
+	if got := convertAmount(dec("1"), "CNY", eur, assetQuote{}); !got.IsZero() {
+		t.Errorf("expected 0 for missing quote, got %s", got)
+	}
+}
@@ -1,10 +1,12 @@
 package handler
 
 import (
-	"log"
+	"context"
 	"time"
 
 	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/storage"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/logging"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/metrics"
 )
 
 const maxCryptoAutoBackfillSpanDays = 400
@@ -35,7 +37,7 @@ func distinctUTCDayCount(rates []storage.CryptoRate) int {
 
 // backfillCryptoRange loads daily Binance klines into ClickHouse when the stored series
 // does not cover all calendar days in [from, to] (same idea as CBR archive fill).
-func (h *Handler) backfillCryptoRange(symbol string, from, to time.Time, rates []storage.CryptoRate) bool {
+func (h *Handler) backfillCryptoRange(ctx context.Context, symbol string, from, to time.Time, rates []storage.CryptoRate) bool {
 	if h.crypto == nil {
 		return false
 	}
@@ -44,7 +46,7 @@ func (h *Handler) backfillCryptoRange(symbol string, from, to time.Time, rates [
 		return false
 	}
 	if span > maxCryptoAutoBackfillSpanDays {
-		log.Printf("crypto backfill: range too long (%d days), skipping auto-fetch", span)
+		logger.InfoContext(ctx, "crypto backfill skipped, range too long", "symbol", symbol, "days", span)
 		return false
 	}
 	have := distinctUTCDayCount(rates)
@@ -52,18 +54,23 @@ func (h *Handler) backfillCryptoRange(symbol string, from, to time.Time, rates [
 		return false
 	}
 
-	rows, err := h.crypto.FetchDailyRUBRates(symbol, from, to)
+	began := time.Now()
+	rows, err := h.crypto.FetchDailyRUBRates(ctx, symbol, from, to)
 	if err != nil {
-		log.Printf("crypto backfill: fetch failed: %v", err)
+		metrics.Backfill(metrics.SourceBinance, err)
+		logger.WarnContext(ctx, "crypto backfill fetch failed", "symbol", symbol, "error", err)
 		return false
 	}
 	if len(rows) == 0 {
 		return false
 	}
-	if err := h.ch.SaveCryptoRates(rows); err != nil {
-		log.Printf("crypto backfill: save clickhouse: %v", err)
+	err = h.ch.SaveCryptoRates(rows)
+	metrics.Backfill(metrics.SourceBinance, err)
+	if err != nil {
+		logger.ErrorContext(ctx, "crypto backfill save failed", "symbol", symbol, "error", err)
 		return false
 	}
-	log.Printf("crypto backfill: stored %d daily rows for %s", len(rows), symbol)
+	logger.InfoContext(ctx, "crypto backfill stored", "symbol", symbol,
+		"count", len(rows), "duration_ms", logging.Millis(time.Since(began)))
 	return true
 }
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/export"
+	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/storage"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+)
+
+var (
+	cbrExportColumns    = []string{"date", "currency_code", "currency_name", "nominal", "value", "previous", "unit_value"}
+	cryptoExportColumns = []string{"timestamp", "symbol", "open", "high", "low", "close", "volume", "price_rub"}
+)
+
+// GET /history/cbr/export?from=2015-01-01&to=2024-12-31[&code=USD][&format=csv|ndjson]
+//
+// Streams stored CBR rates (all currencies without code) for any range; unlike
+// /history/cbr/range there is no length cap and no archive backfill.
+func (h *Handler) ExportCBR(w http.ResponseWriter, r *http.Request) {
+	format, err := export.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	from, to, err := parseRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	code := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("code")))
+
+	ew := startExport(w, format, cbrExportColumns, exportFilename("cbr", code, from.Format("2006-01-02"), to.Format("2006-01-02"), format))
+	err = h.pg.StreamCurrencyRates(r.Context(), code, from, to, func(rate storage.CurrencyRate) error {
+		return ew.Write(rate.Date.Format("2006-01-02"), rate.CurrencyCode, rate.CurrencyName, rate.Nominal, rate.Value, rate.Previous, rate.PerUnit())
+	})
+	finishExport(r.Context(), w, ew, err)
+}
+
+// GET /history/crypto/export?from=2024-01-01&to=2024-12-31[&symbol=BTCUSDT][&format=csv|ndjson]
+//
+// Streams stored crypto rows (all symbols without symbol) for any range.
+// OHLC are as stored: USDT for collector rows, RUB for backfilled daily rows;
+// price_rub is always RUB.
+func (h *Handler) ExportCrypto(w http.ResponseWriter, r *http.Request) {
+	format, err := export.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	from, to, err := parseRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	symbol := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("symbol")))
+	if symbol != "" {
+		symbol = cryptoSymbol(symbol)
+	}
+
+	ew := startExport(w, format, cryptoExportColumns, exportFilename("crypto", symbol, from.Format("2006-01-02"), to.Format("2006-01-02"), format))
+	err = h.ch.StreamCryptoRates(r.Context(), symbol, from, to.AddDate(0, 0, 1), func(rate storage.CryptoRate) error {
+		return ew.Write(rate.Timestamp, rate.Symbol, rate.Open, rate.High, rate.Low, rate.Close, rate.Volume, rate.PriceRUB)
+	})
+	finishExport(r.Context(), w, ew, err)
+}
+
+// startExport sets download headers and returns a writer that flushes the
+// response every few hundred rows.
+func startExport(w http.ResponseWriter, format export.Format, columns []string, filename string) *export.Writer {
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	flush := func() {}
+	if f, ok := w.(http.Flusher); ok {
+		flush = f.Flush
+	}
+	ew := export.NewWriter(w, format, columns, flush)
+	if money.NumbersRequested(w) {
+		ew.NumberDecimals()
+	}
+	return ew
+}
+
+// finishExport flushes the tail of the export. A failure before the first row
+// still becomes a JSON error; after it the status is sent, so the stream
+// ends with an error record instead (see export.Writer.Abort).
+func finishExport(ctx context.Context, w http.ResponseWriter, ew *export.Writer, err error) {
+	if err != nil {
+		if ew.Rows() == 0 {
+			w.Header().Del("Content-Disposition")
+			writeError(w, http.StatusInternalServerError, "database error")
+			return
+		}
+		logger.ErrorContext(ctx, "export aborted", "rows", ew.Rows(), "error", err)
+		if err := ew.Abort("database error"); err != nil {
+			logger.WarnContext(ctx, "export error record not written", "error", err)
+		}
+		return
+	}
+	if err := ew.Flush(); err != nil {
+		logger.WarnContext(ctx, "export flush failed", "rows", ew.Rows(), "error", err)
+	}
+}
+
+func exportFilename(kind, code, from, to string, format export.Format) string {
+	name := kind
+	if code != "" {
+		name += "_" + code
+	}
+	return fmt.Sprintf("%s_%s_%s.%s", name, from, to, format.Ext())
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/export"
+)
+
+func TestExportFilename(t *testing.T) {
+	if got := exportFilename("cbr", "USD", "2015-01-01", "2024-12-31", export.CSV); got != "cbr_USD_2015-01-01_2024-12-31.csv" {
+		t.Errorf("unexpected filename %s", got)
+	}
+	if got := exportFilename("crypto", "", "2024-01-01", "2024-01-31", export.NDJSON); got != "crypto_2024-01-01_2024-01-31.ndjson" {
+		t.Errorf("unexpected filename %s", got)
+	}
+}
+
+func TestExport_validation(t *testing.T) {
+	h := &Handler{}
+	for _, tc := range []struct {
+		name string
+		url  string
+	}{
+		{"cbr parquet", "/history/cbr/export?from=2015-01-01&to=2024-12-31&format=parquet"},
+		{"cbr missing range", "/history/cbr/export?code=USD"},
+		{"crypto reversed range", "/history/crypto/export?from=2024-12-31&to=2024-01-01"},
+	} {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", tc.url, nil)
+		if strings.HasPrefix(tc.url, "/history/cbr") {
+			h.ExportCBR(rr, req)
+		} else {
+			h.ExportCrypto(rr, req)
+		}
+		if rr.Code != 400 {
+			t.Errorf("%s: expected 400, got %d", tc.name, rr.Code)
+		}
+		if rr.Header().Get("Content-Disposition") != "" {
+			t.Errorf("%s: validation errors must not look like a download", tc.name)
+		}
+	}
+}
+
+func TestFinishExport_errorBeforeFirstRow(t *testing.T) {
+	rr := httptest.NewRecorder()
+	ew := startExport(rr, export.CSV, cbrExportColumns, "cbr.csv")
+	finishExport(context.Background(), rr, ew, errors.New("connection reset"))
+	if rr.Code != 500 || rr.Header().Get("Content-Disposition") != "" {
+		t.Errorf("expected a plain 500, got %d with %v", rr.Code, rr.Header())
+	}
+}
+
+func TestFinishExport_streamsRows(t *testing.T) {
+	rr := httptest.NewRecorder()
+	ew := startExport(rr, export.CSV, []string{"a"}, "x.csv")
+	ew.Write("1")
+	finishExport(context.Background(), rr, ew, nil)
+	if rr.Code != 200 || rr.Body.String() != "a\n1\n" || !rr.Flushed {
+		t.Errorf("unexpected response %d %q flushed=%v", rr.Code, rr.Body.String(), rr.Flushed)
+	}
+	if rr.Header().Get("Content-Disposition") != `attachment; filename="x.csv"` {
+		t.Errorf("unexpected disposition %q", rr.Header().Get("Content-Disposition"))
+	}
+}
+
+func TestFinishExport_errorAfterRowsIsMarked(t *testing.T) {
+	rr := httptest.NewRecorder()
+	ew := startExport(rr, export.NDJSON, []string{"a"}, "x.ndjson")
+	ew.Write("1")
+	ew.Flush()
+	finishExport(context.Background(), rr, ew, errors.New("connection reset"))
+	if want := "{\"a\":\"1\"}\n{\"error\":\"database error\"}\n"; rr.Code != 200 || rr.Body.String() != want {
+		t.Errorf("expected the rows and an error line, got %d %q", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/apiv1"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/calendar"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/rpcv1"
+)
+
+// GRPCServer serves rpcv1.HistoryService on top of the same loaders and
+// DTOs as the /v1 routes, with the same validation messages.
+type GRPCServer struct {
+	rpcv1.UnimplementedHistoryServiceServer
+	h *Handler
+}
+
+func NewGRPCServer(h *Handler) *GRPCServer {
+	return &GRPCServer{h: h}
+}
+
+// grpcError converts a loader error to a status error.
+func grpcError(err error) error {
+	status, msg := errorStatus(err)
+	return rpcv1.Error(status, msg)
+}
+
+func invalidArgument(err error) error {
+	return rpcv1.Error(http.StatusBadRequest, err.Error())
+}
+
+func (s *GRPCServer) GetCBRRates(ctx context.Context, req *rpcv1.GetCBRRatesRequest) (*rpcv1.CurrencyRates, error) {
+	date, err := parseDateValue(req.GetDate())
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+	asOf, err := parseAsOfValue(req.GetAsOf())
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+	rates, err := s.h.cbrRatesOn(ctx, date, asOf)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return rpcv1.NewCurrencyRates(v1CurrencyRates(rates)), nil
+}
+
+func (s *GRPCServer) GetCBRRange(ctx context.Context, req *rpcv1.GetCBRRangeRequest) (*rpcv1.CurrencyRates, error) {
+	code := strings.ToUpper(strings.TrimSpace(req.GetCode()))
+	if code == "" {
+		return nil, invalidArgument(errors.New("code is required"))
+	}
+	from, to, err := parseRangeValues(req.GetFrom(), req.GetTo())
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+	asOf, err := parseAsOfValue(req.GetAsOf())
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+	rates, err := s.h.cbrRange(ctx, code, from, to, asOf)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return rpcv1.NewCurrencyRates(v1CurrencyRates(rates)), nil
+}
+
+func (s *GRPCServer) ListCryptoSymbols(context.Context, *rpcv1.ListCryptoSymbolsRequest) (*rpcv1.CryptoSymbols, error) {
+	symbols, err := s.h.ch.GetAvailableCryptoSymbols()
+	if err != nil {
+		return nil, grpcError(errDatabase)
+	}
+	out := &rpcv1.CryptoSymbols{Symbols: make([]string, 0, len(symbols))}
+	for _, sym := range symbols {
+		out.Symbols = append(out.Symbols, baseSymbol(sym))
+	}
+	return out, nil
+}
+
+func (s *GRPCServer) GetCryptoRange(ctx context.Context, req *rpcv1.GetCryptoRangeRequest) (*rpcv1.CryptoRates, error) {
+	symbol := strings.ToUpper(strings.TrimSpace(req.GetSymbol()))
+	if symbol == "" {
+		return nil, invalidArgument(errors.New("symbol is required"))
+	}
+	from, to, err := parseRangeValues(req.GetFrom(), req.GetTo())
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+	symbol = cryptoSymbol(symbol)
+	rates, err := s.h.cryptoRange(ctx, symbol, from, to)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return rpcv1.NewCryptoRates(v1CryptoRates(symbol, rates)), nil
+}
+
+// Convert mirrors parseConvertParams, except that a zero amount means 1
+// because proto3 cannot tell it from an unset one.
+func (s *GRPCServer) Convert(ctx context.Context, req *rpcv1.ConvertRequest) (*rpcv1.Conversion, error) {
+	p := convertParams{
+		from:   strings.ToUpper(strings.TrimSpace(req.GetFrom())),
+		to:     strings.ToUpper(strings.TrimSpace(req.GetTo())),
+		amount: req.AmountValue(),
+		day:    calendar.Today(),
+	}
+	if p.from == "" || p.to == "" {
+		return nil, invalidArgument(errors.New("from and to are required"))
+	}
+	if p.amount.IsNegative() {
+		return nil, invalidArgument(errors.New("invalid amount"))
+	}
+	if p.amount.IsZero() {
+		p.amount = money.NewFromInt(1)
+	}
+	if req.GetDate() != "" {
+		d, err := calendar.ParseDate(req.GetDate())
+		if err != nil {
+			return nil, invalidArgument(errors.New("invalid date format, use YYYY-MM-DD"))
+		}
+		p.day = d
+	}
+	res, err := s.h.convert(ctx, p)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return rpcv1.NewConversion(apiv1.Conversion(res)), nil
+}
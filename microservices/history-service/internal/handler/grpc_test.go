@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/rpcv1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCServer_rejectsInvalidArguments(t *testing.T) {
+	// Validation runs before any loader, so no database is needed
+	s := NewGRPCServer(&Handler{})
+	ctx := context.Background()
+
+	for name, call := range map[string]func() error{
+		"invalid date format, use YYYY-MM-DD": func() error {
+			_, err := s.GetCBRRates(ctx, &rpcv1.GetCBRRatesRequest{Date: "15.01.2024"})
+			return err
+		},
+		"code is required": func() error {
+			_, err := s.GetCBRRange(ctx, &rpcv1.GetCBRRangeRequest{From: "2024-01-01", To: "2024-01-31"})
+			return err
+		},
+		"to must not be before from": func() error {
+			_, err := s.GetCryptoRange(ctx, &rpcv1.GetCryptoRangeRequest{Symbol: "BTC", From: "2024-02-01", To: "2024-01-01"})
+			return err
+		},
+		"invalid amount": func() error {
+			_, err := s.Convert(ctx, &rpcv1.ConvertRequest{From: "EUR", To: "USD", Amount: -5})
+			return err
+		},
+		"from and to are required": func() error {
+			_, err := s.Convert(ctx, &rpcv1.ConvertRequest{From: "EUR"})
+			return err
+		},
+	} {
+		st, _ := status.FromError(call())
+		if st.Code() != codes.InvalidArgument || st.Message() != name {
+			t.Errorf("expected InvalidArgument %q, got %s %q", name, st.Code(), st.Message())
+		}
+	}
+}
+
+func TestGRPCError_keepsLoaderStatus(t *testing.T) {
+	if got := status.Code(grpcError(notFound("no rates for XXX"))); got != codes.NotFound {
+		t.Errorf("expected NotFound, got %s", got)
+	}
+	st, _ := status.FromError(grpcError(errDatabase))
+	if st.Code() != codes.Internal || st.Message() != "database error" {
+		t.Errorf("expected Internal database error, got %s %q", st.Code(), st.Message())
+	}
+}
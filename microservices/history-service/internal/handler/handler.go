@@ -1,70 +1,155 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/cbrbackfill"
+	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/coverage"
 	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/cryptobackfill"
 	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/storage"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/calendar"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/logging"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
 )
 
+var logger = logging.For("handler")
+
 type Handler struct {
 	pg     *storage.PostgresDB
 	ch     *storage.ClickHouseDB
 	cbr    *cbrbackfill.Client
+	cbrInd *cbrbackfill.IndicatorsClient
 	crypto *cryptobackfill.Client
+	rec    *coverage.Reconciler
 }
 
-func New(pg *storage.PostgresDB, ch *storage.ClickHouseDB, cbr *cbrbackfill.Client, crypto *cryptobackfill.Client) *Handler {
-	return &Handler{pg: pg, ch: ch, cbr: cbr, crypto: crypto}
+func New(pg *storage.PostgresDB, ch *storage.ClickHouseDB, cbr *cbrbackfill.Client, cbrInd *cbrbackfill.IndicatorsClient, crypto *cryptobackfill.Client, rec *coverage.Reconciler) *Handler {
+	return &Handler{pg: pg, ch: ch, cbr: cbr, cbrInd: cbrInd, crypto: crypto, rec: rec}
 }
 
+// writeJSON writes v with its decimals as strings, or as numbers when the
+// request asked for them (money.Middleware).
 func writeJSON(w http.ResponseWriter, status int, v any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(v)
+	json.NewEncoder(w).Encode(money.ForResponse(w, v))
 }
 
 func writeError(w http.ResponseWriter, status int, msg string) {
 	writeJSON(w, status, map[string]string{"error": msg})
 }
 
-// GET /history/cbr?date=2024-01-15
-func (h *Handler) GetCBRHistory(w http.ResponseWriter, r *http.Request) {
-	dateStr := r.URL.Query().Get("date")
-	var date time.Time
+// statusError is an error reported to clients with its own HTTP status.
+// Loaders shared by the legacy and /v1 handlers return it so both can map
+// failures the same way.
+type statusError struct {
+	status int
+	msg    string
+}
+
+func (e *statusError) Error() string { return e.msg }
+
+var errDatabase = &statusError{http.StatusInternalServerError, "database error"}
+
+func notFound(msg string) error { return &statusError{http.StatusNotFound, msg} }
+
+// errorStatus returns the status and client message for err.
+func errorStatus(err error) (int, string) {
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.status, se.msg
+	}
+	return http.StatusInternalServerError, err.Error()
+}
+
+// writeFailure writes err in the legacy error format.
+func writeFailure(w http.ResponseWriter, err error) {
+	status, msg := errorStatus(err)
+	writeError(w, status, msg)
+}
+
+// parseDate reads the optional ?date= parameter, defaulting to today.
+func parseDate(r *http.Request) (time.Time, error) {
+	return parseDateValue(r.URL.Query().Get("date"))
+}
+
+// parseDateValue parses an optional YYYY-MM-DD date, defaulting to the
+// current Moscow day.
+func parseDateValue(dateStr string) (time.Time, error) {
 	if dateStr == "" {
-		date = time.Now().Truncate(24 * time.Hour)
-	} else {
-		var err error
-		date, err = time.Parse("2006-01-02", dateStr)
-		if err != nil {
-			writeError(w, http.StatusBadRequest, "invalid date format, use YYYY-MM-DD")
-			return
-		}
+		return calendar.Today(), nil
 	}
+	date, err := calendar.ParseDate(dateStr)
+	if err != nil {
+		return date, errors.New("invalid date format, use YYYY-MM-DD")
+	}
+	return date, nil
+}
 
-	rates, err := h.pg.GetCurrencyRatesByDate(date)
+// parseAsOf reads the optional ?as_of= parameter, see parseAsOfValue.
+func parseAsOf(r *http.Request) (time.Time, error) {
+	return parseAsOfValue(r.URL.Query().Get("as_of"))
+}
+
+// parseAsOfValue parses an optional point in time for reading stored CBR
+// rates as they were then: an RFC 3339 time, or a YYYY-MM-DD date meaning
+// the end of that day in UTC. The zero time means the current rates.
+func parseAsOfValue(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	if d, err := time.Parse("2006-01-02", s); err == nil {
+		// PostgreSQL keeps microseconds, so this is the last instant of the day
+		return d.AddDate(0, 0, 1).Add(-time.Microsecond), nil
+	}
+	return time.Time{}, errors.New("invalid as_of, use an RFC 3339 time or YYYY-MM-DD")
+}
+
+// GET /history/cbr?date=2024-01-15[&quote=USD][&as_of=2024-01-16T12:00:00Z]
+func (h *Handler) GetCBRHistory(w http.ResponseWriter, r *http.Request) {
+	quote, err := parseQuote(r)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "database error")
+		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	if len(rates) == 0 {
-		h.backfillCBRDayIfEmpty(date)
-		rates, err = h.pg.GetCurrencyRatesByDate(date)
-		if err != nil {
-			writeError(w, http.StatusInternalServerError, "database error")
-			return
-		}
+	date, err := parseDate(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
 	}
-	writeJSON(w, http.StatusOK, rates)
+	asOf, err := parseAsOf(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rates, err := h.cbrRatesOn(r.Context(), date, asOf)
+	if err != nil {
+		writeFailure(w, err)
+		return
+	}
+	h.writeCBRRates(w, r, rates, quote, date, date, asOf)
 }
 
-// GET /history/cbr/range?code=USD&from=2024-01-01&to=2024-01-31
+// GET /history/cbr/range?code=USD&from=2024-01-01&to=2024-01-31[&quote=EUR][&as_of=2024-02-01]
 func (h *Handler) GetCBRHistoryRange(w http.ResponseWriter, r *http.Request) {
+	quote, err := parseQuote(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	asOf, err := parseAsOf(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	code := r.URL.Query().Get("code")
 	fromStr := r.URL.Query().Get("from")
 	toStr := r.URL.Query().Get("to")
@@ -74,35 +159,79 @@ func (h *Handler) GetCBRHistoryRange(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	from, err := time.Parse("2006-01-02", fromStr)
+	from, err := calendar.ParseDate(fromStr)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, "invalid from date")
 		return
 	}
-	to, err := time.Parse("2006-01-02", toStr)
+	to, err := calendar.ParseDate(toStr)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, "invalid to date")
 		return
 	}
 
-	rates, err := h.pg.GetCurrencyRatesByDateRange(code, from, to)
+	rates, err := h.cbrRange(r.Context(), code, from, to, asOf)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "database error")
+		writeFailure(w, err)
 		return
 	}
+	h.writeCBRRates(w, r, rates, quote, from, to, asOf)
+}
 
-	if h.backfillCBRMissingDays(code, from, to) {
-		rates, err = h.pg.GetCurrencyRatesByDateRange(code, from, to)
+// cbrRatesOn returns all rates stored for date, backfilling an empty day
+// from the CBR archive. With a non-zero asOf it returns the rates as they
+// were stored then and backfills nothing.
+func (h *Handler) cbrRatesOn(ctx context.Context, date, asOf time.Time) ([]storage.CurrencyRate, error) {
+	if !asOf.IsZero() {
+		rates, err := h.pg.GetCurrencyRatesAsOf(ctx, "", date, date, asOf)
 		if err != nil {
-			writeError(w, http.StatusInternalServerError, "database error")
-			return
+			return nil, errDatabase
 		}
+		return rates, nil
 	}
-	writeJSON(w, http.StatusOK, rates)
+	rates, err := h.pg.GetCurrencyRatesByDate(date)
+	if err != nil {
+		return nil, errDatabase
+	}
+	if len(rates) == 0 {
+		h.backfillCBRDayIfEmpty(ctx, date)
+		if rates, err = h.pg.GetCurrencyRatesByDate(date); err != nil {
+			return nil, errDatabase
+		}
+	}
+	return rates, nil
+}
+
+// cbrRange returns the rates of code in [from, to], newest first, after
+// backfilling missing days from the CBR archive. With a non-zero asOf it
+// returns the rates as they were stored then and backfills nothing.
+func (h *Handler) cbrRange(ctx context.Context, code string, from, to, asOf time.Time) ([]storage.CurrencyRate, error) {
+	if !asOf.IsZero() {
+		rates, err := h.pg.GetCurrencyRatesAsOf(ctx, code, from, to, asOf)
+		if err != nil {
+			return nil, errDatabase
+		}
+		return rates, nil
+	}
+	rates, err := h.pg.GetCurrencyRatesByDateRange(code, from, to)
+	if err != nil {
+		return nil, errDatabase
+	}
+	if h.backfillCBRMissingDays(ctx, code, from, to) {
+		if rates, err = h.pg.GetCurrencyRatesByDateRange(code, from, to); err != nil {
+			return nil, errDatabase
+		}
+	}
+	return rates, nil
 }
 
-// GET /history/crypto?symbol=BTCUSDT&limit=100
+// GET /history/crypto?symbol=BTCUSDT&limit=100[&quote=USD]
 func (h *Handler) GetCryptoHistory(w http.ResponseWriter, r *http.Request) {
+	quote, err := parseQuote(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	symbol := r.URL.Query().Get("symbol")
 	if symbol == "" {
 		writeError(w, http.StatusBadRequest, "symbol is required")
@@ -113,11 +242,17 @@ func (h *Handler) GetCryptoHistory(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusInternalServerError, "database error")
 		return
 	}
-	writeJSON(w, http.StatusOK, rates)
+	from, to := cryptoSpan(rates)
+	h.writeCryptoRates(w, r, rates, quote, from, to)
 }
 
-// GET /history/crypto/range?symbol=BTCUSDT&from=2024-01-01&to=2024-01-31
+// GET /history/crypto/range?symbol=BTCUSDT&from=2024-01-01&to=2024-01-31[&quote=USD]
 func (h *Handler) GetCryptoHistoryRange(w http.ResponseWriter, r *http.Request) {
+	quote, err := parseQuote(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	symbol := r.URL.Query().Get("symbol")
 	fromStr := r.URL.Query().Get("from")
 	toStr := r.URL.Query().Get("to")
@@ -127,53 +262,59 @@ func (h *Handler) GetCryptoHistoryRange(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	from, err := time.Parse("2006-01-02", fromStr)
+	from, err := calendar.ParseDate(fromStr)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, "invalid from date")
 		return
 	}
-	to, err := time.Parse("2006-01-02", toStr)
+	to, err := calendar.ParseDate(toStr)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, "invalid to date")
 		return
 	}
 
-	// Short ranges: same resolution as monolith (15m for ≤7 days, etc.) from Binance + USDTRUB/CBR.
-	// Avoids mixing 1d backfill + live USDT Close rows (UI would treat Close as RUB and fake "zero" dips).
+	rates, err := h.cryptoRange(r.Context(), symbol, from, to)
+	if err != nil {
+		writeFailure(w, err)
+		return
+	}
+	h.writeCryptoRates(w, r, rates, quote, from, to)
+}
+
+// cryptoRange returns rows of symbol for [from, to]. Short ranges come from
+// Binance at the same resolution as the monolith (15m for ≤7 days, etc.) and
+// are cached asynchronously; longer ones from ClickHouse, backfilled with
+// daily klines. Mixing 1d backfill with live USDT Close rows would make the
+// UI treat Close as RUB and show fake "zero" dips.
+func (h *Handler) cryptoRange(ctx context.Context, symbol string, from, to time.Time) ([]storage.CryptoRate, error) {
 	span := inclusiveCalendarDaysUTC(from, to)
 	interval := cryptobackfill.IntervalForCalendarSpan(span)
 	if interval != "1d" && h.crypto != nil {
-		live, err := h.crypto.FetchIntervalRUBRates(symbol, interval, from, to)
+		live, err := h.crypto.FetchIntervalRUBRates(ctx, symbol, interval, from, to)
 		if err == nil && len(live) > 0 {
 			rows := append([]storage.CryptoRate(nil), live...)
 			go func() {
 				if err := h.ch.SaveCryptoRates(rows); err != nil {
-					log.Printf("crypto: async cache to clickhouse: %v", err)
+					logger.WarnContext(ctx, "caching klines failed", "symbol", symbol, "interval", interval, "error", err)
 				}
 			}()
-			writeJSON(w, http.StatusOK, live)
-			return
+			return live, nil
 		}
 		if err != nil {
-			log.Printf("crypto: binance interval %s failed, using DB: %v", interval, err)
+			logger.WarnContext(ctx, "binance klines failed, using stored rates", "symbol", symbol, "interval", interval, "error", err)
 		}
 	}
 
 	rates, err := h.ch.GetCryptoRatesByDateRange(symbol, from, to)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "database error")
-		return
+		return nil, errDatabase
 	}
-
-	if h.backfillCryptoRange(symbol, from, to, rates) {
-		rates, err = h.ch.GetCryptoRatesByDateRange(symbol, from, to)
-		if err != nil {
-			writeError(w, http.StatusInternalServerError, "database error")
-			return
+	if h.backfillCryptoRange(ctx, symbol, from, to, rates) {
+		if rates, err = h.ch.GetCryptoRatesByDateRange(symbol, from, to); err != nil {
+			return nil, errDatabase
 		}
 	}
-
-	writeJSON(w, http.StatusOK, rates)
+	return rates, nil
 }
 
 // GET /history/crypto/symbols
@@ -185,3 +326,30 @@ func (h *Handler) GetCryptoSymbols(w http.ResponseWriter, r *http.Request) {
 	}
 	writeJSON(w, http.StatusOK, symbols)
 }
+
+// writeCBRRates writes rates, converted to quote when one was requested.
+// The quote currency is read as of asOf too.
+func (h *Handler) writeCBRRates(w http.ResponseWriter, r *http.Request, rates []storage.CurrencyRate, quote string, from, to, asOf time.Time) {
+	if quote != "" && len(rates) > 0 {
+		qs, err := h.loadQuoteSeries(r.Context(), quote, from, to, asOf)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "database error")
+			return
+		}
+		applyCBRQuote(rates, quote, qs)
+	}
+	writeJSON(w, http.StatusOK, rates)
+}
+
+// writeCryptoRates writes rates, converted to quote when one was requested.
+func (h *Handler) writeCryptoRates(w http.ResponseWriter, r *http.Request, rates []storage.CryptoRate, quote string, from, to time.Time) {
+	if quote != "" && len(rates) > 0 {
+		qs, err := h.loadQuoteSeries(r.Context(), quote, from, to, time.Time{})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "database error")
+			return
+		}
+		applyCryptoQuote(rates, quote, qs)
+	}
+	writeJSON(w, http.StatusOK, rates)
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/storage"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
 )
 
 // ─── stubs ────────────────────────────────────────────────────────────────────
@@ -93,6 +94,8 @@ func (h *testableHandler) GetCryptoSymbols(w http.ResponseWriter, r *http.Reques
 
 // ─── helpers ──────────────────────────────────────────────────────────────────
 
+func dec(s string) money.Decimal { return money.MustParse(s) }
+
 func get(t *testing.T, fn http.HandlerFunc, path string) *httptest.ResponseRecorder {
 	t.Helper()
 	req := httptest.NewRequest(http.MethodGet, path, nil)
@@ -106,8 +109,8 @@ func get(t *testing.T, fn http.HandlerFunc, path string) *httptest.ResponseRecor
 func TestGetCBRHistory_returnRates(t *testing.T) {
 	h := &testableHandler{
 		pg: &stubPG{rates: []storage.CurrencyRate{
-			{CurrencyCode: "USD", Value: 90.5},
-			{CurrencyCode: "EUR", Value: 98.2},
+			{CurrencyCode: "USD", Value: dec("90.5")},
+			{CurrencyCode: "EUR", Value: dec("98.2")},
 		}},
 	}
 
@@ -122,6 +125,25 @@ func TestGetCBRHistory_returnRates(t *testing.T) {
 	}
 }
 
+func TestGetCBRHistory_decimals(t *testing.T) {
+	h := &testableHandler{pg: &stubPG{rates: []storage.CurrencyRate{{CurrencyCode: "USD", Value: dec("90.5")}}}}
+	fn := money.Middleware(http.HandlerFunc(h.GetCBRHistory)).ServeHTTP
+
+	var rates []map[string]any
+	json.NewDecoder(get(t, fn, "/history/cbr?date=2024-01-15").Body).Decode(&rates)
+	if len(rates) != 1 || rates[0]["Value"] != "90.5" {
+		t.Errorf("expected Value as the string 90.5, got %+v", rates)
+	}
+	rates = nil
+	json.NewDecoder(get(t, fn, "/history/cbr?date=2024-01-15&decimals=number").Body).Decode(&rates)
+	if len(rates) != 1 || rates[0]["Value"] != 90.5 {
+		t.Errorf("expected Value as the number 90.5 with decimals=number, got %+v", rates)
+	}
+	if _, ok := rates[0]["QuoteValue"]; ok {
+		t.Errorf("expected no QuoteValue without a quote, got %+v", rates[0])
+	}
+}
+
 func TestGetCBRHistory_invalidDate(t *testing.T) {
 	h := &testableHandler{pg: &stubPG{}}
 	rr := get(t, h.GetCBRHistory, "/history/cbr?date=not-a-date")
@@ -151,7 +173,7 @@ func TestGetCryptoHistory_missingSymbol(t *testing.T) {
 func TestGetCryptoHistory_returnRates(t *testing.T) {
 	h := &testableHandler{
 		ch: &stubCH{rates: []storage.CryptoRate{
-			{Symbol: "BTCUSDT", Close: 41000, PriceRUB: 3690000},
+			{Symbol: "BTCUSDT", Close: dec("41000"), PriceRUB: dec("3690000")},
 		}},
 	}
 	rr := get(t, h.GetCryptoHistory, "/history/crypto?symbol=BTCUSDT")
@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/storage"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/apiv1"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/indicators"
+)
+
+const (
+	defaultIndicatorBars = 100
+	maxIndicatorBars     = 5000
+)
+
+// IndicatorsResult is the response of GET /history/crypto/indicators.
+type IndicatorsResult = apiv1.IndicatorsResult
+
+// ConditionResult reports whether a ?when= condition holds on Latest.
+type ConditionResult = apiv1.ConditionResult
+
+type indicatorParams struct {
+	symbol     string
+	interval   string
+	step       time.Duration
+	specs      []indicators.Spec
+	conditions []indicators.Condition
+	from, to   time.Time
+}
+
+// parseIndicatorParams validates ?symbol=&interval=&indicators=&from=&to=&when=.
+// interval defaults to 1h; without from/to the last defaultIndicatorBars bars
+// up to now are returned.
+func parseIndicatorParams(r *http.Request, now time.Time) (indicatorParams, error) {
+	q := r.URL.Query()
+	p := indicatorParams{
+		symbol:   strings.ToUpper(strings.TrimSpace(q.Get("symbol"))),
+		interval: q.Get("interval"),
+	}
+	if p.symbol == "" {
+		return p, fmt.Errorf("symbol is required")
+	}
+	p.symbol = cryptoSymbol(p.symbol)
+	if p.interval == "" {
+		p.interval = "1h"
+	}
+	var err error
+	if p.step, err = indicators.ParseInterval(p.interval); err != nil {
+		return p, err
+	}
+	if p.specs, err = indicators.ParseSpecs(q.Get("indicators")); err != nil {
+		return p, err
+	}
+	if s := q.Get("when"); s != "" {
+		for _, part := range strings.Split(s, ",") {
+			c, err := indicators.ParseCondition(part)
+			if err != nil {
+				return p, err
+			}
+			p.conditions = append(p.conditions, c)
+		}
+	}
+
+	fromStr, toStr := q.Get("from"), q.Get("to")
+	switch {
+	case fromStr == "" && toStr == "":
+		p.to = now.UTC()
+		p.from = p.to.Add(-defaultIndicatorBars * p.step)
+	case fromStr == "" || toStr == "":
+		return p, fmt.Errorf("from and to must be given together")
+	default:
+		if p.from, p.to, err = parseRange(r); err != nil {
+			return p, err
+		}
+		// to is inclusive: cover the whole day.
+		p.to = p.to.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	if bars := p.to.Sub(p.from) / p.step; bars > maxIndicatorBars {
+		return p, fmt.Errorf("range too long for interval %s: %d bars (max %d)", p.interval, bars, maxIndicatorBars)
+	}
+	return p, nil
+}
+
+// GET /history/crypto/indicators?symbol=BTCUSDT&interval=1h&indicators=rsi14,ema20,macd,bb20[&from=&to=][&when=rsi14>70]
+//
+// Stored rows are converted to RUB candles and resampled to interval. Enough
+// bars before from are loaded to seed every indicator; if storage cannot
+// cover the window, Binance klines are fetched like GetCryptoHistoryRange does.
+func (h *Handler) GetCryptoIndicators(w http.ResponseWriter, r *http.Request) {
+	p, err := parseIndicatorParams(r, time.Now())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	res, err := h.indicators(r.Context(), p)
+	if err != nil {
+		writeFailure(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (h *Handler) indicators(ctx context.Context, p indicatorParams) (IndicatorsResult, error) {
+	warmup := indicators.MaxWarmup(p.specs)
+	loadFrom := p.from.Add(-time.Duration(warmup) * p.step)
+
+	rates, err := h.ch.GetCryptoRatesByDateRange(p.symbol, loadFrom, p.to)
+	if err != nil {
+		return IndicatorsResult{}, errDatabase
+	}
+	candles := indicators.Resample(rubCandles(rates), p.step)
+
+	if len(candles) <= warmup && h.crypto != nil {
+		live, err := h.crypto.FetchIntervalRUBRates(ctx, p.symbol, p.interval, loadFrom, p.to)
+		if err != nil {
+			logger.WarnContext(ctx, "binance klines failed", "symbol", p.symbol, "interval", p.interval, "error", err)
+		} else if len(live) > 0 {
+			rows := append([]storage.CryptoRate(nil), live...)
+			go func() {
+				if err := h.ch.SaveCryptoRates(rows); err != nil {
+					logger.WarnContext(ctx, "caching klines failed", "symbol", p.symbol, "interval", p.interval, "error", err)
+				}
+			}()
+			candles = indicators.Resample(rubCandles(inRange(live, loadFrom, p.to)), p.step)
+		}
+	}
+	if len(candles) == 0 {
+		return IndicatorsResult{}, notFound("no data for " + p.symbol + " in range")
+	}
+	return buildIndicatorsResult(p, candles), nil
+}
+
+// buildIndicatorsResult computes the requested indicators over candles and
+// trims the warmup bars before p.from.
+func buildIndicatorsResult(p indicatorParams, candles []indicators.Candle) IndicatorsResult {
+	start := p.from.UTC().Truncate(p.step)
+	series := indicators.Compute(candles, p.specs)
+	indicators.Trim(series, start)
+
+	shown := candles
+	for len(shown) > 0 && shown[0].Time.Before(start) {
+		shown = shown[1:]
+	}
+
+	latest := indicators.Latest(series)
+	latest["close"] = candles[len(candles)-1].Close
+
+	res := IndicatorsResult{
+		Symbol:     p.symbol,
+		Interval:   p.interval,
+		Candles:    shown,
+		Indicators: series,
+		Latest:     latest,
+	}
+	for _, c := range p.conditions {
+		res.Conditions = append(res.Conditions, ConditionResult{Condition: c.String(), Met: c.Met(latest)})
+	}
+	return res
+}
+
+// rubCandles converts stored rows to RUB candles. Collector rows carry USDT
+// OHLC plus PriceRUB, backfilled rows are already in RUB (Close == PriceRUB);
+// rows without a RUB price are skipped. Indicators are computed in floats.
+func rubCandles(rates []storage.CryptoRate) []indicators.Candle {
+	out := make([]indicators.Candle, 0, len(rates))
+	for _, r := range rates {
+		if !r.PriceRUB.IsPositive() || !r.Close.IsPositive() {
+			continue
+		}
+		k := r.PriceRUB.Float64() / r.Close.Float64()
+		out = append(out, indicators.Candle{
+			Time:   r.Timestamp,
+			Open:   r.Open.Float64() * k,
+			High:   r.High.Float64() * k,
+			Low:    r.Low.Float64() * k,
+			Close:  r.PriceRUB.Float64(),
+			Volume: r.Volume.Float64(),
+		})
+	}
+	return out
+}
+
+// inRange keeps rows with from <= Timestamp <= to; Binance returns whole days.
+func inRange(rates []storage.CryptoRate, from, to time.Time) []storage.CryptoRate {
+	var out []storage.CryptoRate
+	for _, r := range rates {
+		if !r.Timestamp.Before(from) && !r.Timestamp.After(to) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
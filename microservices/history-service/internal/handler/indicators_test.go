@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/storage"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/indicators"
+)
+
+var indicatorsNow = time.Date(2025, 3, 10, 12, 30, 0, 0, time.UTC)
+
+func TestParseIndicatorParams_defaults(t *testing.T) {
+	p, err := parseIndicatorParams(httptest.NewRequest("GET", "/history/crypto/indicators?symbol=btc&indicators=rsi14,ema20", nil), indicatorsNow)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.symbol != "BTCUSDT" || p.interval != "1h" || p.step != time.Hour || len(p.specs) != 2 {
+		t.Errorf("unexpected params: %+v", p)
+	}
+	if !p.to.Equal(indicatorsNow) || !p.from.Equal(indicatorsNow.Add(-100*time.Hour)) {
+		t.Errorf("expected last 100 bars, got %s..%s", p.from, p.to)
+	}
+}
+
+func TestParseIndicatorParams_rangeAndConditions(t *testing.T) {
+	p, err := parseIndicatorParams(httptest.NewRequest("GET",
+		"/history/crypto/indicators?symbol=ETHUSDT&interval=1d&indicators=macd&from=2025-01-01&to=2025-01-31&when=rsi14>70,macd_hist<0", nil), indicatorsNow)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.to.Format("2006-01-02") != "2025-01-31" || p.to.Hour() != 23 {
+		t.Errorf("to should cover the whole day, got %s", p.to)
+	}
+	if len(p.conditions) != 2 || p.conditions[1].String() != "macd_hist<0" {
+		t.Errorf("unexpected conditions %+v", p.conditions)
+	}
+
+	for _, q := range []string{
+		"?indicators=rsi14",
+		"?symbol=BTC",
+		"?symbol=BTC&indicators=vwap",
+		"?symbol=BTC&indicators=rsi14&interval=2h",
+		"?symbol=BTC&indicators=rsi14&from=2025-01-01",
+		"?symbol=BTC&indicators=rsi14&when=rsi14",
+		"?symbol=BTC&indicators=rsi14&interval=1m&from=2024-01-01&to=2025-01-01",
+	} {
+		if _, err := parseIndicatorParams(httptest.NewRequest("GET", "/history/crypto/indicators"+q, nil), indicatorsNow); err == nil {
+			t.Errorf("%s: expected error", q)
+		}
+	}
+}
+
+func TestRubCandles(t *testing.T) {
+	ts := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	got := rubCandles([]storage.CryptoRate{
+		// Collector row: USDT OHLC, RUB close via PriceRUB.
+		{Timestamp: ts, Open: dec("100"), High: dec("110"), Low: dec("90"), Close: dec("100"), Volume: dec("5"), PriceRUB: dec("9000")},
+		// Backfilled row: already RUB.
+		{Timestamp: ts.Add(time.Hour), Open: dec("8800"), High: dec("9100"), Low: dec("8700"), Close: dec("9050"), PriceRUB: dec("9050")},
+		// No RUB price: skipped.
+		{Timestamp: ts.Add(2 * time.Hour), Close: dec("101")},
+	})
+	if len(got) != 2 {
+		t.Fatalf("expected 2 candles, got %+v", got)
+	}
+	if got[0].Open != 9000 || got[0].High != 9900 || got[0].Low != 8100 || got[0].Close != 9000 || got[0].Volume != 5 {
+		t.Errorf("unexpected converted candle %+v", got[0])
+	}
+	if got[1].Close != 9050 || got[1].High != 9100 {
+		t.Errorf("RUB row should be unchanged, got %+v", got[1])
+	}
+}
+
+func TestBuildIndicatorsResult_trimsWarmup(t *testing.T) {
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	var candles []indicators.Candle
+	for i := 0; i < 30; i++ {
+		candles = append(candles, indicators.Candle{Time: start.Add(time.Duration(i) * time.Hour), Close: float64(100 + i)})
+	}
+	specs, _ := indicators.ParseSpecs("rsi14,sma5")
+	cond, _ := indicators.ParseCondition("rsi14>70")
+	p := indicatorParams{
+		symbol: "BTCUSDT", interval: "1h", step: time.Hour, specs: specs,
+		conditions: []indicators.Condition{cond},
+		from:       start.Add(20*time.Hour + 15*time.Minute),
+		to:         start.Add(29 * time.Hour),
+	}
+
+	res := buildIndicatorsResult(p, candles)
+	if len(res.Candles) != 10 || !res.Candles[0].Time.Equal(start.Add(20*time.Hour)) {
+		t.Errorf("expected 10 candles from the bar containing from, got %d", len(res.Candles))
+	}
+	if len(res.Indicators["rsi14"]) != 10 || len(res.Indicators["sma5"]) != 10 {
+		t.Errorf("indicator series should match the shown candles: rsi14=%d sma5=%d",
+			len(res.Indicators["rsi14"]), len(res.Indicators["sma5"]))
+	}
+	if res.Latest["close"] != 129 || res.Latest["rsi14"] != 100 {
+		t.Errorf("unexpected latest %+v", res.Latest)
+	}
+	if len(res.Conditions) != 1 || !res.Conditions[0].Met || res.Conditions[0].Condition != "rsi14>70" {
+		t.Errorf("unexpected conditions %+v", res.Conditions)
+	}
+}
@@ -35,8 +35,8 @@ func newIntegrationServer(t *testing.T, pg *stubPG, ch *stubCH) *httptest.Server
 // request → chi router → testableHandler → stub DB → JSON response.
 func TestIntegration_GetCBRHistory_fullCycle(t *testing.T) {
 	pg := &stubPG{rates: []storage.CurrencyRate{
-		{CurrencyCode: "USD", Value: 90.5},
-		{CurrencyCode: "EUR", Value: 98.2},
+		{CurrencyCode: "USD", Value: dec("90.5")},
+		{CurrencyCode: "EUR", Value: dec("98.2")},
 	}}
 	srv := newIntegrationServer(t, pg, &stubCH{})
 
@@ -66,7 +66,7 @@ func TestIntegration_GetCBRHistory_fullCycle(t *testing.T) {
 // the date parameter returns a 200 (uses today's date internally).
 func TestIntegration_GetCBRHistory_noDate_defaultsToday(t *testing.T) {
 	pg := &stubPG{rates: []storage.CurrencyRate{
-		{CurrencyCode: "CNY", Value: 12.5},
+		{CurrencyCode: "CNY", Value: dec("12.5")},
 	}}
 	srv := newIntegrationServer(t, pg, &stubCH{})
 
@@ -117,8 +117,8 @@ func TestIntegration_GetCryptoHistory_missingSymbol_returns400(t *testing.T) {
 // a valid symbol parameter returns the crypto rates.
 func TestIntegration_GetCryptoHistory_withSymbol_returnsRates(t *testing.T) {
 	ch := &stubCH{rates: []storage.CryptoRate{
-		{Symbol: "BTCUSDT", Close: 41000, PriceRUB: 3690000},
-		{Symbol: "BTCUSDT", Close: 42000, PriceRUB: 3780000},
+		{Symbol: "BTCUSDT", Close: dec("41000"), PriceRUB: dec("3690000")},
+		{Symbol: "BTCUSDT", Close: dec("42000"), PriceRUB: dec("3780000")},
 	}}
 	srv := newIntegrationServer(t, &stubPG{}, ch)
 
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/storage"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/apiv1"
+)
+
+// metals are the ISO 4217 codes of the metals the CBR prices.
+var metals = map[string]bool{"XAU": true, "XAG": true, "XPT": true, "XPD": true}
+
+// parseMetal reads the optional ?metal= parameter; empty means every metal.
+func parseMetal(r *http.Request) (string, bool) {
+	metal := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("metal")))
+	return metal, metal == "" || metals[metal]
+}
+
+// GET /v1/rates/metals[?date=2024-01-15]
+//
+// The CBR's discount prices of gold, silver, platinum and palladium in
+// effect on date: those of the latest date on or before it with prices.
+func (h *Handler) V1MetalPrices(w http.ResponseWriter, r *http.Request) {
+	date, err := parseDate(r)
+	if err != nil {
+		writeV1Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	prices, err := h.pg.GetMetalPricesOn(r.Context(), date)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "metal prices query failed", "date", date.Format("2006-01-02"), "error", err)
+		writeV1Failure(w, errDatabase)
+		return
+	}
+	writeV1(w, v1MetalPrices(prices))
+}
+
+// GET /v1/rates/metals/range?from=2024-01-01&to=2024-01-31[&metal=XAU]
+func (h *Handler) V1MetalRange(w http.ResponseWriter, r *http.Request) {
+	metal, ok := parseMetal(r)
+	if !ok {
+		writeV1Error(w, http.StatusBadRequest, "metal must be one of XAU, XAG, XPT, XPD")
+		return
+	}
+	from, to, err := parseRange(r)
+	if err != nil {
+		writeV1Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	prices, err := h.pg.GetMetalPricesByDateRange(r.Context(), metal, from, to)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "metal prices query failed", "metal", metal, "error", err)
+		writeV1Failure(w, errDatabase)
+		return
+	}
+	writeV1(w, v1MetalPrices(prices))
+}
+
+func v1MetalPrices(prices []storage.MetalPrice) []apiv1.MetalPrice {
+	out := make([]apiv1.MetalPrice, 0, len(prices))
+	for _, m := range prices {
+		out = append(out, apiv1.MetalPrice{
+			Date:  m.Date.Format("2006-01-02"),
+			Metal: m.Metal,
+			Name:  m.Name,
+			Buy:   m.Buy,
+			Sell:  m.Sell,
+		})
+	}
+	return out
+}
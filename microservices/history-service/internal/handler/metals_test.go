@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/storage"
+)
+
+func TestV1MetalPrices_json(t *testing.T) {
+	day := time.Date(2026, 4, 15, 0, 0, 0, 0, time.UTC)
+	prices := v1MetalPrices([]storage.MetalPrice{
+		{Date: day, Metal: "XAU", Name: "Gold", Buy: dec("8540.1"), Sell: dec("8540.1")},
+	})
+	b, _ := json.Marshal(prices)
+	want := `[{"date":"2026-04-15","metal":"XAU","name":"Gold","buy":"8540.1","sell":"8540.1"}]`
+	if string(b) != want {
+		t.Errorf("expected %s, got %s", want, b)
+	}
+	if b, _ := json.Marshal(v1MetalPrices(nil)); string(b) != "[]" {
+		t.Errorf("expected an empty list, got %s", b)
+	}
+}
+
+func TestV1MetalRange_validationErrors(t *testing.T) {
+	h := &Handler{}
+	for _, path := range []string{
+		"/v1/rates/metals/range?metal=XAU",
+		"/v1/rates/metals/range?from=2024-01-31&to=2024-01-01",
+		"/v1/rates/metals/range?metal=XYZ&from=2024-01-01&to=2024-01-31",
+	} {
+		if rr := get(t, h.V1MetalRange, path); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d %s", path, rr.Code, rr.Body.String())
+		}
+	}
+	if rr := get(t, h.V1MetalPrices, "/v1/rates/metals?date=15.04.2026"); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a bad date, got %d", rr.Code)
+	}
+}
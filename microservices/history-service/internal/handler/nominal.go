@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/storage"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/apiv1"
+)
+
+// GET /history/cbr/nominal-changes?from=2015-01-01&to=2024-12-31[&code=KZT]
+//
+// The dates on which the CBR changed the number of units a currency is
+// quoted for, oldest first. Value jumps on these dates; UnitValue does not.
+func (h *Handler) GetCBRNominalChanges(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	changes, err := h.nominalChanges(r.Context(), r.URL.Query().Get("code"), from, to)
+	if err != nil {
+		writeFailure(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, changes)
+}
+
+// GET /v1/rates/cbr/nominal-changes?from=2015-01-01&to=2024-12-31[&code=KZT]
+func (h *Handler) V1CBRNominalChanges(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseRange(r)
+	if err != nil {
+		writeV1Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	changes, err := h.nominalChanges(r.Context(), r.URL.Query().Get("code"), from, to)
+	if err != nil {
+		writeV1Failure(w, err)
+		return
+	}
+	writeV1(w, v1NominalChanges(changes))
+}
+
+// nominalChanges loads the nominal changes between from and to, of code or
+// of every currency when code is empty.
+func (h *Handler) nominalChanges(ctx context.Context, code string, from, to time.Time) ([]storage.NominalChange, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	changes, err := h.pg.GetNominalChanges(ctx, code, from, to)
+	if err != nil {
+		logger.ErrorContext(ctx, "nominal changes query failed", "currency", code, "error", err)
+		return nil, errDatabase
+	}
+	if changes == nil {
+		changes = []storage.NominalChange{}
+	}
+	return changes, nil
+}
+
+func v1NominalChanges(changes []storage.NominalChange) []apiv1.NominalChange {
+	out := make([]apiv1.NominalChange, 0, len(changes))
+	for _, c := range changes {
+		out = append(out, apiv1.NominalChange{
+			Date:            c.Date.Format("2006-01-02"),
+			Code:            c.CurrencyCode,
+			PreviousNominal: c.PreviousNominal,
+			Nominal:         c.Nominal,
+		})
+	}
+	return out
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/storage"
+)
+
+func TestGetCBRNominalChanges_validationErrors(t *testing.T) {
+	h := &Handler{}
+	for _, path := range []string{
+		"/history/cbr/nominal-changes?code=KZT",
+		"/history/cbr/nominal-changes?from=2024-01-31&to=2024-01-01",
+	} {
+		if rr := get(t, h.GetCBRNominalChanges, path); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d %s", path, rr.Code, rr.Body.String())
+		}
+		if rr := get(t, h.V1CBRNominalChanges, "/v1/rates"+path[len("/history"):]); rr.Code != http.StatusBadRequest ||
+			!strings.Contains(rr.Body.String(), `"code":"bad_request"`) {
+			t.Errorf("v1 %s: expected a v1 400, got %d %s", path, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestV1NominalChanges_json(t *testing.T) {
+	changes := v1NominalChanges([]storage.NominalChange{
+		{Date: time.Date(2015, 6, 2, 0, 0, 0, 0, time.UTC), CurrencyCode: "KZT", PreviousNominal: 100, Nominal: 1000},
+	})
+	b, _ := json.Marshal(changes)
+	want := `[{"date":"2015-06-02","code":"KZT","previous_nominal":100,"nominal":1000}]`
+	if string(b) != want {
+		t.Errorf("expected %s, got %s", want, b)
+	}
+	if b, _ := json.Marshal(v1NominalChanges(nil)); string(b) != "[]" {
+		t.Errorf("expected an empty list, got %s", b)
+	}
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/storage"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/calendar"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+)
+
+const (
+	quoteRUB = "RUB"
+	// quoteLookbackDays bounds how far a quote-currency rate is carried over
+	// (weekends and holidays), matching cbrbackfill.FetchDayWithFallback.
+	quoteLookbackDays = 14
+)
+
+// parseQuote reads the optional ?quote= parameter. An empty result means
+// RUB, the native currency of stored rates, which needs no conversion.
+func parseQuote(r *http.Request) (string, error) {
+	q := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("quote")))
+	if q == "" || q == quoteRUB {
+		return "", nil
+	}
+	if len(q) != 3 {
+		return "", fmt.Errorf("invalid quote currency %q, use a 3-letter code such as USD", q)
+	}
+	for _, c := range q {
+		if c < 'A' || c > 'Z' {
+			return "", fmt.Errorf("invalid quote currency %q, use a 3-letter code such as USD", q)
+		}
+	}
+	return q, nil
+}
+
+// quoteSeries holds the CBR rows of the quote currency sorted by date, used
+// to derive cross rates for rows stored without a precomputed quote.
+type quoteSeries []storage.CurrencyRate
+
+func newQuoteSeries(rows []storage.CurrencyRate) quoteSeries {
+	s := append(quoteSeries(nil), rows...)
+	sort.Slice(s, func(i, j int) bool { return s[i].Date.Before(s[j].Date) })
+	return s
+}
+
+// on returns the latest quote row dated on or before day, carried over for
+// at most quoteLookbackDays.
+func (s quoteSeries) on(day time.Time) (storage.CurrencyRate, bool) {
+	d := calendar.Date(day)
+	i := sort.Search(len(s), func(i int) bool { return calendar.Date(s[i].Date).After(d) })
+	if i == 0 {
+		return storage.CurrencyRate{}, false
+	}
+	row := s[i-1]
+	if d.Sub(calendar.Date(row.Date)) > quoteLookbackDays*24*time.Hour || !row.Value.IsPositive() {
+		return storage.CurrencyRate{}, false
+	}
+	return row, true
+}
+
+// applyCBRQuote fills Quote, QuoteValue and QuotePrevious. Stored quotes
+// win; otherwise the cross rate is computed via RUB from the quote series,
+// to money.PriceScale places.
+func applyCBRQuote(rates []storage.CurrencyRate, quote string, qs quoteSeries) {
+	for i := range rates {
+		r := &rates[i]
+		r.Quote = quote
+		if r.CurrencyCode == quote {
+			nominal := money.NewFromInt(int64(r.Nominal))
+			r.QuoteValue, r.QuotePrevious = &nominal, &nominal
+			continue
+		}
+		q, ok := qs.on(r.Date)
+		if v, stored := r.Quotes[quote]; stored {
+			r.QuoteValue = &v
+		} else if ok {
+			v := r.Value.Div(q.PerUnit(), money.PriceScale)
+			r.QuoteValue = &v
+		}
+		if ok && q.Previous.IsPositive() {
+			v := r.Previous.Div(money.PerUnit(q.Previous, q.Nominal), money.PriceScale)
+			r.QuotePrevious = &v
+		}
+	}
+}
+
+// applyCryptoQuote fills Quote and QuotePrice, preferring stored quotes and
+// falling back to PriceRUB divided by the quote currency's CBR rate.
+func applyCryptoQuote(rates []storage.CryptoRate, quote string, qs quoteSeries) {
+	for i := range rates {
+		r := &rates[i]
+		r.Quote = quote
+		if v, ok := r.Quotes[quote]; ok {
+			r.QuotePrice = &v
+			continue
+		}
+		if q, ok := qs.on(r.Timestamp); ok {
+			v := r.PriceRUB.Div(q.PerUnit(), money.PriceScale)
+			r.QuotePrice = &v
+		}
+	}
+}
+
+// loadQuoteSeries reads the quote currency's CBR rows for [from, to],
+// widened by the carry-over window so the first days can be converted too,
+// as stored at asOf unless it is zero.
+func (h *Handler) loadQuoteSeries(ctx context.Context, quote string, from, to, asOf time.Time) (quoteSeries, error) {
+	start := from.AddDate(0, 0, -quoteLookbackDays)
+	var rows []storage.CurrencyRate
+	var err error
+	if asOf.IsZero() {
+		rows, err = h.pg.GetCurrencyRatesByDateRange(quote, start, to)
+	} else {
+		rows, err = h.pg.GetCurrencyRatesAsOf(ctx, quote, start, to, asOf)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newQuoteSeries(rows), nil
+}
+
+// cryptoSpan returns the calendar range covered by rates.
+func cryptoSpan(rates []storage.CryptoRate) (from, to time.Time) {
+	for i, r := range rates {
+		if i == 0 || r.Timestamp.Before(from) {
+			from = r.Timestamp
+		}
+		if i == 0 || r.Timestamp.After(to) {
+			to = r.Timestamp
+		}
+	}
+	return calendar.Date(from), calendar.Date(to)
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/storage"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+)
+
+func day(s string) time.Time {
+	d, _ := time.Parse("2006-01-02", s)
+	return d
+}
+
+func TestParseQuote(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{"?quote=rub", "", false},
+		{"?quote=usd", "USD", false},
+		{"?quote=EUR", "EUR", false},
+		{"?quote=EURO", "", true},
+		{"?quote=U1D", "", true},
+	}
+	for _, tc := range tests {
+		got, err := parseQuote(httptest.NewRequest("GET", "/history/cbr"+tc.query, nil))
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%q: unexpected error state: %v", tc.query, err)
+		}
+		if got != tc.want {
+			t.Errorf("%q: expected %q, got %q", tc.query, tc.want, got)
+		}
+	}
+}
+
+func TestQuoteSeries_carriesOverWeekend(t *testing.T) {
+	qs := newQuoteSeries([]storage.CurrencyRate{
+		{Date: day("2024-03-08"), CurrencyCode: "USD", Nominal: 1, Value: dec("91")},
+		{Date: day("2024-03-06"), CurrencyCode: "USD", Nominal: 1, Value: dec("90")},
+	})
+
+	if q, ok := qs.on(day("2024-03-10")); !ok || q.Value.String() != "91" {
+		t.Errorf("Sunday should carry Friday's rate, got %v ok=%v", q.Value, ok)
+	}
+	if _, ok := qs.on(day("2024-03-01")); ok {
+		t.Error("no rate before the first row")
+	}
+	if _, ok := qs.on(day("2024-04-01")); ok {
+		t.Error("rates older than the lookback window must not be carried")
+	}
+}
+
+func TestApplyCBRQuote_crossRateWithNominal(t *testing.T) {
+	qs := newQuoteSeries([]storage.CurrencyRate{
+		{Date: day("2024-03-01"), CurrencyCode: "JPY", Nominal: 100, Value: dec("60"), Previous: dec("50")},
+	})
+	rates := []storage.CurrencyRate{
+		{Date: day("2024-03-01"), CurrencyCode: "USD", Nominal: 1, Value: dec("90"), Previous: dec("80")},
+		{Date: day("2024-03-01"), CurrencyCode: "JPY", Nominal: 100, Value: dec("60"), Previous: dec("50")},
+	}
+
+	applyCBRQuote(rates, "JPY", qs)
+
+	if rates[0].QuoteValue.String() != "150" || rates[0].QuotePrevious.String() != "160" {
+		t.Errorf("USD in JPY: expected 150/160, got %s/%s", rates[0].QuoteValue, rates[0].QuotePrevious)
+	}
+	if rates[1].QuoteValue.String() != "100" {
+		t.Errorf("JPY in JPY: expected nominal 100, got %s", rates[1].QuoteValue)
+	}
+	if rates[0].Quote != "JPY" {
+		t.Errorf("expected Quote=JPY, got %q", rates[0].Quote)
+	}
+}
+
+func TestApplyCBRQuote_prefersStoredQuote(t *testing.T) {
+	qs := newQuoteSeries([]storage.CurrencyRate{
+		{Date: day("2024-03-01"), CurrencyCode: "USD", Nominal: 1, Value: dec("90")},
+	})
+	rates := []storage.CurrencyRate{
+		{Date: day("2024-03-01"), CurrencyCode: "EUR", Nominal: 1, Value: dec("99"), Quotes: map[string]money.Decimal{"USD": dec("1.08")}},
+	}
+
+	applyCBRQuote(rates, "USD", qs)
+
+	if rates[0].QuoteValue.String() != "1.08" {
+		t.Errorf("expected stored quote 1.08, got %s", rates[0].QuoteValue)
+	}
+}
+
+func TestApplyCryptoQuote(t *testing.T) {
+	qs := newQuoteSeries([]storage.CurrencyRate{
+		{Date: day("2024-03-01"), CurrencyCode: "CNY", Nominal: 10, Value: dec("125")},
+	})
+	rates := []storage.CryptoRate{
+		{Timestamp: day("2024-03-01").Add(15 * time.Hour), Symbol: "BTCUSDT", PriceRUB: dec("90000")},
+		{Timestamp: day("2024-03-01"), Symbol: "BTCUSDT", PriceRUB: dec("90000"), Quotes: map[string]money.Decimal{"CNY": dec("7000")}},
+	}
+
+	applyCryptoQuote(rates, "CNY", qs)
+
+	if rates[0].QuotePrice.String() != "7200" {
+		t.Errorf("expected 7200 CNY via cross rate, got %s", rates[0].QuotePrice)
+	}
+	if rates[1].QuotePrice.String() != "7000" {
+		t.Errorf("expected stored 7000 CNY, got %s", rates[1].QuotePrice)
+	}
+}
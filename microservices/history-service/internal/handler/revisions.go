@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/storage"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/apiv1"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/calendar"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+)
+
+// GET /history/cbr/revisions?code=USD&date=2024-01-13
+//
+// Every value stored for the rate, oldest first, with where and when it was
+// fetched and the publication it was carried over from, if any.
+func (h *Handler) GetCBRRevisions(w http.ResponseWriter, r *http.Request) {
+	code, date, err := parseRevisionParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	revs, err := h.cbrRevisions(r.Context(), code, date)
+	if err != nil {
+		writeFailure(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, revs)
+}
+
+// GET /v1/rates/cbr/revisions?code=USD&date=2024-01-13
+func (h *Handler) V1CBRRevisions(w http.ResponseWriter, r *http.Request) {
+	code, date, err := parseRevisionParams(r)
+	if err != nil {
+		writeV1Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	revs, err := h.cbrRevisions(r.Context(), code, date)
+	if err != nil {
+		writeV1Failure(w, err)
+		return
+	}
+	writeV1(w, v1RateRevisions(revs))
+}
+
+// parseRevisionParams reads the required ?code= and ?date= parameters.
+func parseRevisionParams(r *http.Request) (string, time.Time, error) {
+	code := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("code")))
+	if code == "" {
+		return "", time.Time{}, errors.New("code is required")
+	}
+	date, err := calendar.ParseDate(r.URL.Query().Get("date"))
+	if err != nil {
+		return "", time.Time{}, errors.New("invalid date format, use YYYY-MM-DD")
+	}
+	return code, date, nil
+}
+
+// cbrRevisions loads the revisions of the rate of code on date, oldest
+// first, and reports not found when none is stored.
+func (h *Handler) cbrRevisions(ctx context.Context, code string, date time.Time) ([]storage.Revision, error) {
+	revs, err := h.pg.GetCurrencyRateRevisions(ctx, code, date)
+	if err != nil {
+		logger.ErrorContext(ctx, "revisions query failed", "currency", code, "error", err)
+		return nil, errDatabase
+	}
+	if len(revs) == 0 {
+		return nil, notFound("no rate of " + code + " stored for " + date.Format("2006-01-02"))
+	}
+	return revs, nil
+}
+
+func v1RateRevisions(revs []storage.Revision) []apiv1.RateRevision {
+	out := make([]apiv1.RateRevision, 0, len(revs))
+	for _, r := range revs {
+		rev := apiv1.RateRevision{
+			Date:       r.Date.Format("2006-01-02"),
+			Code:       r.CurrencyCode,
+			Name:       r.CurrencyName,
+			Nominal:    r.Nominal,
+			Value:      r.Value,
+			Previous:   r.Previous,
+			UnitValue:  r.UnitValue,
+			Source:     r.Source,
+			FetchedAt:  r.FetchedAt,
+			RecordedAt: r.RecordedAt,
+		}
+		if !rev.UnitValue.IsPositive() {
+			rev.UnitValue = money.PerUnit(r.Value, r.Nominal)
+		}
+		if r.CarriedFrom != nil {
+			rev.CarriedFrom = r.CarriedFrom.Format("2006-01-02")
+		}
+		out = append(out, rev)
+	}
+	return out
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/storage"
+)
+
+func TestParseAsOfValue(t *testing.T) {
+	if got, err := parseAsOfValue(""); err != nil || !got.IsZero() {
+		t.Errorf("empty: got %v, %v", got, err)
+	}
+	got, err := parseAsOfValue("2024-01-16T12:30:00+03:00")
+	if err != nil || !got.Equal(time.Date(2024, 1, 16, 9, 30, 0, 0, time.UTC)) {
+		t.Errorf("RFC 3339: got %v, %v", got, err)
+	}
+	// A date is the end of that day
+	got, err = parseAsOfValue("2024-01-16")
+	if err != nil || !got.Equal(time.Date(2024, 1, 16, 23, 59, 59, 999999000, time.UTC)) {
+		t.Errorf("date: got %v, %v", got, err)
+	}
+	if _, err := parseAsOfValue("16.01.2024"); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestAsOfAndRevisions_validationErrors(t *testing.T) {
+	h := &Handler{}
+	for _, tc := range []struct {
+		fn   http.HandlerFunc
+		path string
+	}{
+		{h.GetCBRHistory, "/history/cbr?date=2024-01-15&as_of=yesterday"},
+		{h.GetCBRHistoryRange, "/history/cbr/range?code=USD&from=2024-01-01&to=2024-01-31&as_of=2024-13-01"},
+		{h.V1CBRRates, "/v1/rates/cbr?as_of=now"},
+		{h.GetCBRRevisions, "/history/cbr/revisions?date=2024-01-15"},
+		{h.GetCBRRevisions, "/history/cbr/revisions?code=USD&date=15.01.2024"},
+		{h.V1CBRRevisions, "/v1/rates/cbr/revisions?date=2024-01-15"},
+		{h.V1CBRRevisions, "/v1/rates/cbr/revisions?code=USD&date=15.01.2024"},
+	} {
+		if rr := get(t, tc.fn, tc.path); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d %s", tc.path, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestV1RateRevisions_json(t *testing.T) {
+	day := time.Date(2024, 1, 13, 0, 0, 0, 0, time.UTC)
+	friday := time.Date(2024, 1, 12, 0, 0, 0, 0, time.UTC)
+	recorded := time.Date(2024, 1, 12, 15, 0, 0, 0, time.UTC)
+	revs := v1RateRevisions([]storage.Revision{{
+		ID: 7, Date: day, CurrencyCode: "KZT", CurrencyName: "Tenge", Nominal: 100,
+		Value: dec("19.5"), Previous: dec("19.4"), CarriedFrom: &friday, RecordedAt: recorded,
+	}})
+	b, _ := json.Marshal(revs)
+	want := `[{"date":"2024-01-13","code":"KZT","name":"Tenge","nominal":100,"value":"19.5","previous":"19.4",` +
+		`"unit_value":"0.195","carried_from":"2024-01-12","recorded_at":"2024-01-12T15:00:00Z"}]`
+	if string(b) != want {
+		t.Errorf("expected %s, got %s", want, b)
+	}
+}
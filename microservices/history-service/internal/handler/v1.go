@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/storage"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/apiv1"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+)
+
+// The /v1 handlers serve the shared/apiv1 contract on top of the same loaders
+// as the /history routes: snake_case DTOs wrapped in {"data": ...}, errors as
+// {"error": {"code", "message"}}, crypto symbols as base assets (BTC) with
+// prices in RUB, and rows ordered oldest first.
+
+func writeV1(w http.ResponseWriter, v any) {
+	writeJSON(w, http.StatusOK, apiv1.Response[any]{Data: v})
+}
+
+func writeV1Error(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, apiv1.NewError(status, msg))
+}
+
+func writeV1Failure(w http.ResponseWriter, err error) {
+	status, msg := errorStatus(err)
+	writeV1Error(w, status, msg)
+}
+
+// V1NotFound answers unknown /v1 routes in the v1 error format.
+func V1NotFound(w http.ResponseWriter, r *http.Request) {
+	writeV1Error(w, http.StatusNotFound, "no such endpoint: "+r.URL.Path)
+}
+
+// V1MethodNotAllowed answers /v1 routes called with an unsupported method.
+func V1MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeV1Error(w, http.StatusMethodNotAllowed, "method "+r.Method+" not allowed")
+}
+
+// GET /v1/rates/cbr?date=2024-01-15[&as_of=2024-01-16T12:00:00Z]
+func (h *Handler) V1CBRRates(w http.ResponseWriter, r *http.Request) {
+	date, err := parseDate(r)
+	if err != nil {
+		writeV1Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	asOf, err := parseAsOf(r)
+	if err != nil {
+		writeV1Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	rates, err := h.cbrRatesOn(r.Context(), date, asOf)
+	if err != nil {
+		writeV1Failure(w, err)
+		return
+	}
+	writeV1(w, v1CurrencyRates(rates))
+}
+
+// GET /v1/rates/cbr/range?code=USD&from=2024-01-01&to=2024-01-31[&as_of=2024-02-01]
+func (h *Handler) V1CBRRange(w http.ResponseWriter, r *http.Request) {
+	code := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("code")))
+	if code == "" {
+		writeV1Error(w, http.StatusBadRequest, "code is required")
+		return
+	}
+	from, to, err := parseRange(r)
+	if err != nil {
+		writeV1Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	asOf, err := parseAsOf(r)
+	if err != nil {
+		writeV1Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	rates, err := h.cbrRange(r.Context(), code, from, to, asOf)
+	if err != nil {
+		writeV1Failure(w, err)
+		return
+	}
+	writeV1(w, v1CurrencyRates(rates))
+}
+
+// GET /v1/rates/crypto/symbols
+func (h *Handler) V1CryptoSymbols(w http.ResponseWriter, r *http.Request) {
+	symbols, err := h.ch.GetAvailableCryptoSymbols()
+	if err != nil {
+		writeV1Failure(w, errDatabase)
+		return
+	}
+	out := make([]string, 0, len(symbols))
+	for _, s := range symbols {
+		out = append(out, baseSymbol(s))
+	}
+	writeV1(w, out)
+}
+
+// GET /v1/rates/crypto/range?symbol=BTC&from=2024-01-01&to=2024-01-31
+func (h *Handler) V1CryptoRange(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("symbol")))
+	if symbol == "" {
+		writeV1Error(w, http.StatusBadRequest, "symbol is required")
+		return
+	}
+	from, to, err := parseRange(r)
+	if err != nil {
+		writeV1Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	symbol = cryptoSymbol(symbol)
+	rates, err := h.cryptoRange(r.Context(), symbol, from, to)
+	if err != nil {
+		writeV1Failure(w, err)
+		return
+	}
+	writeV1(w, v1CryptoRates(symbol, rates))
+}
+
+// GET /v1/rates/crypto/indicators?symbol=BTC&indicators=rsi14,macd[&interval=][&from=&to=][&when=]
+func (h *Handler) V1CryptoIndicators(w http.ResponseWriter, r *http.Request) {
+	p, err := parseIndicatorParams(r, time.Now())
+	if err != nil {
+		writeV1Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	res, err := h.indicators(r.Context(), p)
+	if err != nil {
+		writeV1Failure(w, err)
+		return
+	}
+	res.Symbol = baseSymbol(res.Symbol)
+	writeV1(w, res)
+}
+
+// GET /v1/convert?from=EUR&to=CNY[&amount=250][&date=2025-03-10]
+func (h *Handler) V1Convert(w http.ResponseWriter, r *http.Request) {
+	p, err := parseConvertParams(r)
+	if err != nil {
+		writeV1Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	res, err := h.convert(r.Context(), p)
+	if err != nil {
+		writeV1Failure(w, err)
+		return
+	}
+	writeV1(w, apiv1.Conversion(res))
+}
+
+// GET /v1/analytics?code=USD&from=2024-01-01&to=2024-03-31[&source=crypto]
+func (h *Handler) V1Analytics(w http.ResponseWriter, r *http.Request) {
+	p, err := parseAnalyticsParams(r)
+	if err != nil {
+		writeV1Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	res, err := h.analyze(r.Context(), p)
+	if err != nil {
+		writeV1Failure(w, err)
+		return
+	}
+	if res.Source == "crypto" {
+		res.Code = baseSymbol(res.Code)
+	}
+	writeV1(w, res)
+}
+
+// GET /v1/analytics/correlation?codes=USD,EUR,BTC&from=2024-01-01&to=2024-03-31
+func (h *Handler) V1Correlation(w http.ResponseWriter, r *http.Request) {
+	codes, from, to, err := parseCorrelationParams(r)
+	if err != nil {
+		writeV1Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	res, err := h.correlate(r.Context(), codes, from, to)
+	if err != nil {
+		writeV1Failure(w, err)
+		return
+	}
+	writeV1(w, res)
+}
+
+// v1CurrencyRates converts stored rows, ordered by date and code.
+func v1CurrencyRates(rates []storage.CurrencyRate) []apiv1.CurrencyRate {
+	out := make([]apiv1.CurrencyRate, 0, len(rates))
+	for _, r := range rates {
+		rate := apiv1.CurrencyRate{
+			Date:            r.Date.Format("2006-01-02"),
+			Code:            r.CurrencyCode,
+			Name:            r.CurrencyName,
+			Nominal:         r.Nominal,
+			Value:           r.Value,
+			Previous:        r.Previous,
+			UnitValue:       r.PerUnit(),
+			PreviousNominal: r.PreviousNominal,
+		}
+		if r.CarriedFrom != nil {
+			rate.CarriedFrom = r.CarriedFrom.Format("2006-01-02")
+		}
+		out = append(out, rate)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Date != out[j].Date {
+			return out[i].Date < out[j].Date
+		}
+		return out[i].Code < out[j].Code
+	})
+	return out
+}
+
+// v1CryptoRates converts stored rows of symbol to RUB candles, ordered by
+// time. The conversion is that of rubCandles, in decimals: prices are
+// scaled by PriceRUB/Close and rounded to money.PriceScale places.
+func v1CryptoRates(symbol string, rates []storage.CryptoRate) []apiv1.CryptoRate {
+	out := make([]apiv1.CryptoRate, 0, len(rates))
+	for _, r := range rates {
+		if !r.PriceRUB.IsPositive() || !r.Close.IsPositive() {
+			continue
+		}
+		rub := func(price money.Decimal) money.Decimal {
+			return price.Mul(r.PriceRUB).Div(r.Close, money.PriceScale)
+		}
+		out = append(out, apiv1.CryptoRate{
+			Time:   r.Timestamp.UTC(),
+			Symbol: baseSymbol(symbol),
+			Open:   rub(r.Open),
+			High:   rub(r.High),
+			Low:    rub(r.Low),
+			Close:  r.PriceRUB,
+			Volume: r.Volume,
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Time.Before(out[j].Time) })
+	return out
+}
+
+// baseSymbol maps the stored BTCUSDT pair to BTC.
+func baseSymbol(symbol string) string {
+	return strings.TrimSuffix(symbol, "USDT")
+}
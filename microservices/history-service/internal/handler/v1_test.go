@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/storage"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/apiv1"
+)
+
+func TestV1CurrencyRates_snakeCaseAndOrder(t *testing.T) {
+	jan9 := time.Date(2024, 1, 9, 0, 0, 0, 0, time.UTC)
+	// Storage returns newest first.
+	rates := v1CurrencyRates([]storage.CurrencyRate{
+		{Date: jan9.AddDate(0, 0, 1), CurrencyCode: "USD", CurrencyName: "Доллар США", Nominal: 1, Value: dec("89.9"), CarriedFrom: &jan9},
+		{Date: jan9, CurrencyCode: "USD", CurrencyName: "Доллар США", Nominal: 1, Value: dec("89.6883"), Previous: dec("90.3041")},
+	})
+	if len(rates) != 2 || rates[0].Date != "2024-01-09" || rates[1].Date != "2024-01-10" {
+		t.Fatalf("expected ascending dates, got %+v", rates)
+	}
+
+	b, _ := json.Marshal(rates[0])
+	want := `{"date":"2024-01-09","code":"USD","name":"Доллар США","nominal":1,"value":"89.6883","previous":"90.3041","unit_value":"89.6883"}`
+	if string(b) != want {
+		t.Errorf("expected %s, got %s", want, b)
+	}
+	if rates[1].CarriedFrom != "2024-01-09" {
+		t.Errorf("expected carried_from 2024-01-09, got %q", rates[1].CarriedFrom)
+	}
+}
+
+func TestV1CurrencyRates_unitValueAcrossNominalChange(t *testing.T) {
+	jan9 := time.Date(2024, 1, 9, 0, 0, 0, 0, time.UTC)
+	rates := v1CurrencyRates([]storage.CurrencyRate{
+		{Date: jan9.AddDate(0, 0, 1), CurrencyCode: "KZT", Nominal: 1000, Value: dec("195.1"), PreviousNominal: 100},
+		{Date: jan9, CurrencyCode: "KZT", Nominal: 100, Value: dec("19.52")},
+	})
+	if rates[0].UnitValue.String() != "0.1952" || rates[1].UnitValue.String() != "0.1951" {
+		t.Errorf("expected a continuous per-unit series, got %s and %s", rates[0].UnitValue, rates[1].UnitValue)
+	}
+	if rates[0].PreviousNominal != 0 || rates[1].PreviousNominal != 100 {
+		t.Errorf("expected previous_nominal on the date of the change only, got %+v", rates)
+	}
+}
+
+func TestV1CryptoRates_rubAndBaseSymbol(t *testing.T) {
+	t0 := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	rates := v1CryptoRates("BTCUSDT", []storage.CryptoRate{
+		{Timestamp: t0.Add(time.Hour), Symbol: "BTCUSDT", Open: dec("100"), High: dec("110"), Low: dec("90"), Close: dec("100"), PriceRUB: dec("9000")},
+		{Timestamp: t0, Symbol: "BTCUSDT", Close: dec("95")},
+	})
+	if len(rates) != 1 {
+		t.Fatalf("rows without PriceRUB must be skipped, got %+v", rates)
+	}
+	r := rates[0]
+	if r.Symbol != "BTC" || r.Open.String() != "9000" || r.High.String() != "9900" || r.Low.String() != "8100" || r.Close.String() != "9000" {
+		t.Errorf("unexpected RUB candle %+v", r)
+	}
+}
+
+func TestV1_validationErrors(t *testing.T) {
+	h := &Handler{}
+	cases := []struct {
+		fn   http.HandlerFunc
+		path string
+	}{
+		{h.V1CBRRates, "/v1/rates/cbr?date=15.01.2024"},
+		{h.V1CBRRange, "/v1/rates/cbr/range?from=2024-01-01&to=2024-01-31"},
+		{h.V1CBRRange, "/v1/rates/cbr/range?code=USD&from=2024-02-01&to=2024-01-31"},
+		{h.V1CryptoRange, "/v1/rates/crypto/range?from=2024-01-01&to=2024-01-31"},
+		{h.V1CryptoIndicators, "/v1/rates/crypto/indicators?symbol=BTC&indicators=vwap"},
+		{h.V1Convert, "/v1/convert?from=USD"},
+		{h.V1Analytics, "/v1/analytics?code=USD&source=moex&from=2024-01-01&to=2024-01-31"},
+		{h.V1Correlation, "/v1/analytics/correlation?codes=USD&from=2024-01-01&to=2024-01-31"},
+	}
+	for _, c := range cases {
+		rr := get(t, c.fn, c.path)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", c.path, rr.Code)
+			continue
+		}
+		var body apiv1.ErrorResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil || body.Error.Code != apiv1.CodeBadRequest || body.Error.Message == "" {
+			t.Errorf("%s: expected v1 error body, got %s", c.path, rr.Body.String())
+		}
+	}
+}
+
+func TestV1NotFound(t *testing.T) {
+	rr := httptest.NewRecorder()
+	V1NotFound(rr, httptest.NewRequest(http.MethodGet, "/v1/nope", nil))
+	want := `{"error":{"code":"not_found","message":"no such endpoint: /v1/nope"}}` + "\n"
+	if rr.Code != http.StatusNotFound || rr.Body.String() != want {
+		t.Errorf("expected 404 %s, got %d %s", want, rr.Code, rr.Body.String())
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	if status, msg := errorStatus(notFound("no data")); status != http.StatusNotFound || msg != "no data" {
+		t.Errorf("unexpected %d %q", status, msg)
+	}
+	if status, msg := errorStatus(errDatabase); status != http.StatusInternalServerError || msg != "database error" {
+		t.Errorf("unexpected %d %q", status, msg)
+	}
+}
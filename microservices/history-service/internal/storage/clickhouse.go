@@ -7,6 +7,9 @@ import (
 
 	"github.com/ClickHouse/clickhouse-go/v2"
 	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/metrics"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+	"github.com/shopspring/decimal"
 )
 
 type ClickHouseConfig struct {
@@ -53,41 +56,149 @@ func NewClickHouseDB(cfg ClickHouseConfig) (*ClickHouseDB, error) {
 
 func (c *ClickHouseDB) Close() error { return c.conn.Close() }
 
+// Ping checks the connection for /readyz.
+func (c *ClickHouseDB) Ping(ctx context.Context) error { return c.conn.Ping(ctx) }
+
 func (c *ClickHouseDB) InitSchema() error {
-	return c.conn.Exec(context.Background(), `
+	ctx := context.Background()
+	if err := c.conn.Exec(ctx, `
 		CREATE TABLE IF NOT EXISTS crypto_rates (
 			timestamp  DateTime,
 			symbol     String,
-			open       Float64,
-			high       Float64,
-			low        Float64,
-			close      Float64,
-			volume     Float64,
-			price_rub  Float64,
+			open       Decimal128(8),
+			high       Decimal128(8),
+			low        Decimal128(8),
+			close      Decimal128(8),
+			volume     Decimal128(8),
+			price_rub  Decimal128(8),
 			created_at DateTime DEFAULT now()
 		) ENGINE = ReplacingMergeTree(created_at)
 		ORDER BY (symbol, timestamp)
+	`); err != nil {
+		return err
+	}
+	if err := c.conn.Exec(ctx, `ALTER TABLE crypto_rates ADD COLUMN IF NOT EXISTS quotes Map(String, Decimal128(8))`); err != nil {
+		return err
+	}
+	return c.migrateDecimals(ctx)
+}
+
+// decimalColumns are the price columns of crypto_rates with their types
+// before and after the switch from Float64 to Decimal128 (money.PriceScale
+// places), the String type each is converted through, and the expression
+// that rewrites the String values as fixed-point text.
+var decimalColumns = []struct {
+	name, float, via, decimal, fixed string
+}{
+	{"open", "Float64", "String", "Decimal(38, 8)", fixedText("open")},
+	{"high", "Float64", "String", "Decimal(38, 8)", fixedText("high")},
+	{"low", "Float64", "String", "Decimal(38, 8)", fixedText("low")},
+	{"close", "Float64", "String", "Decimal(38, 8)", fixedText("close")},
+	{"volume", "Float64", "String", "Decimal(38, 8)", fixedText("volume")},
+	{"price_rub", "Float64", "String", "Decimal(38, 8)", fixedText("price_rub")},
+	{"quotes", "Map(String, Float64)", "Map(String, String)", "Map(String, Decimal(38, 8))",
+		"mapApply((k, v) -> (k, " + fixedText("v") + "), quotes)"},
+}
+
+// fixedText renders the float written as text in expr with exactly
+// money.PriceScale places, e.g. "1e-7" as "0.00000010".
+func fixedText(expr string) string {
+	return fmt.Sprintf("toDecimalString(toFloat64(%s), %d)", expr, money.PriceScale)
+}
+
+// migrateDecimals converts the price columns of a table created with Float64
+// columns to Decimal128. Each column goes through String first: a float is
+// written as its shortest decimal text (0.3, not 0.29999999), which then
+// parses exactly, where a direct cast would truncate the binary value. The
+// text of very small or large floats is in exponent notation (1e-7), which
+// a Decimal does not parse, so it is rewritten as fixed-point text before
+// the final cast. Columns already converted are left alone; a column left
+// as String by an interrupted migration is taken up again.
+func (c *ClickHouseDB) migrateDecimals(ctx context.Context) error {
+	rows, err := c.conn.Query(ctx, `
+		SELECT name, type FROM system.columns
+		WHERE database = currentDatabase() AND table = 'crypto_rates'
 	`)
+	if err != nil {
+		return err
+	}
+	types := make(map[string]string)
+	for rows.Next() {
+		var name, typ string
+		if err := rows.Scan(&name, &typ); err != nil {
+			rows.Close()
+			return err
+		}
+		types[name] = typ
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	ctx = clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{"mutations_sync": 2}))
+	for _, col := range decimalColumns {
+		switch types[col.name] {
+		case col.float:
+			if err := c.conn.Exec(ctx, fmt.Sprintf("ALTER TABLE crypto_rates MODIFY COLUMN %s %s", col.name, col.via)); err != nil {
+				return fmt.Errorf("convert %s to %s: %w", col.name, col.via, err)
+			}
+		case col.via:
+		default:
+			continue
+		}
+		if err := c.conn.Exec(ctx, fmt.Sprintf("ALTER TABLE crypto_rates UPDATE %s = %s WHERE 1", col.name, col.fixed)); err != nil {
+			return fmt.Errorf("rewrite %s as fixed-point text: %w", col.name, err)
+		}
+		if err := c.conn.Exec(ctx, fmt.Sprintf("ALTER TABLE crypto_rates MODIFY COLUMN %s %s", col.name, col.decimal)); err != nil {
+			return fmt.Errorf("convert %s to %s: %w", col.name, col.decimal, err)
+		}
+	}
+	return nil
 }
 
 func (c *ClickHouseDB) SaveCryptoRates(rates []CryptoRate) error {
+	defer metrics.ObserveQuery(metrics.DBClickHouse, "save_crypto_rates", time.Now())
 	ctx := context.Background()
 	batch, err := c.conn.PrepareBatch(ctx,
-		"INSERT INTO crypto_rates (timestamp, symbol, open, high, low, close, volume, price_rub)")
+		"INSERT INTO crypto_rates (timestamp, symbol, open, high, low, close, volume, price_rub, quotes)")
 	if err != nil {
 		return fmt.Errorf("prepare batch: %w", err)
 	}
 	for _, r := range rates {
-		if err := batch.Append(r.Timestamp, r.Symbol, r.Open, r.High, r.Low, r.Close, r.Volume, r.PriceRUB); err != nil {
+		if err := batch.Append(r.Timestamp, r.Symbol, r.Open.Decimal(), r.High.Decimal(), r.Low.Decimal(),
+			r.Close.Decimal(), r.Volume.Decimal(), r.PriceRUB.Decimal(), toDriverQuotes(r.Quotes)); err != nil {
 			return err
 		}
 	}
-	return batch.Send()
+	if err := batch.Send(); err != nil {
+		return err
+	}
+	for _, r := range rates {
+		metrics.RateStored(metrics.SourceBinance, r.Timestamp)
+	}
+	return nil
+}
+
+// LatestCryptoRateTime returns the newest stored candle time, or the zero
+// time when there are no rates.
+func (c *ClickHouseDB) LatestCryptoRateTime() (time.Time, error) {
+	defer metrics.ObserveQuery(metrics.DBClickHouse, "latest_crypto_rate_time", time.Now())
+	var t time.Time
+	if err := c.conn.QueryRow(context.Background(), `SELECT max(timestamp) FROM crypto_rates`).Scan(&t); err != nil {
+		return time.Time{}, err
+	}
+	// max() of an empty table is the Unix epoch
+	if t.Unix() <= 0 {
+		return time.Time{}, nil
+	}
+	return t, nil
 }
 
 func (c *ClickHouseDB) GetCryptoRatesBySymbol(symbol string, limit int) ([]CryptoRate, error) {
+	defer metrics.ObserveQuery(metrics.DBClickHouse, "get_crypto_rates_by_symbol", time.Now())
 	rows, err := c.conn.Query(context.Background(), `
-		SELECT timestamp, symbol, open, high, low, close, volume, price_rub, created_at
+		SELECT timestamp, symbol, open, high, low, close, volume, price_rub, created_at, quotes
 		FROM crypto_rates
 		WHERE symbol = ?
 		ORDER BY timestamp DESC
@@ -101,10 +212,11 @@ func (c *ClickHouseDB) GetCryptoRatesBySymbol(symbol string, limit int) ([]Crypt
 }
 
 func (c *ClickHouseDB) GetCryptoRatesByDateRange(symbol string, start, end time.Time) ([]CryptoRate, error) {
+	defer metrics.ObserveQuery(metrics.DBClickHouse, "get_crypto_rates_by_date_range", time.Now())
 	// start/end are UTC midnights for YYYY-MM-DD from the API; include the full "to" calendar day.
 	endExclusive := end.AddDate(0, 0, 1)
 	rows, err := c.conn.Query(context.Background(), `
-		SELECT timestamp, symbol, open, high, low, close, volume, price_rub, created_at
+		SELECT timestamp, symbol, open, high, low, close, volume, price_rub, created_at, quotes
 		FROM crypto_rates
 		WHERE symbol = ? AND timestamp >= ? AND timestamp < ?
 		ORDER BY timestamp ASC
@@ -116,7 +228,35 @@ func (c *ClickHouseDB) GetCryptoRatesByDateRange(symbol string, start, end time.
 	return scanClickHouseCryptoRates(rows)
 }
 
+// StoredCryptoSlots returns the open times of the step-sized candles,
+// aligned to the Unix epoch, that hold at least one row of symbol with
+// start <= timestamp < end, oldest first.
+func (c *ClickHouseDB) StoredCryptoSlots(ctx context.Context, symbol string, step time.Duration, start, end time.Time) ([]time.Time, error) {
+	defer metrics.ObserveQuery(metrics.DBClickHouse, "stored_crypto_slots", time.Now())
+	sec := int64(step / time.Second)
+	rows, err := c.conn.Query(ctx, `
+		SELECT DISTINCT toInt64(intDiv(toUnixTimestamp(timestamp), ?) * ?) AS slot
+		FROM crypto_rates
+		WHERE symbol = ? AND timestamp >= ? AND timestamp < ?
+		ORDER BY slot
+	`, sec, sec, symbol, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var slots []time.Time
+	for rows.Next() {
+		var slot int64
+		if err := rows.Scan(&slot); err != nil {
+			return nil, err
+		}
+		slots = append(slots, time.Unix(slot, 0).UTC())
+	}
+	return slots, rows.Err()
+}
+
 func (c *ClickHouseDB) GetAvailableCryptoSymbols() ([]string, error) {
+	defer metrics.ObserveQuery(metrics.DBClickHouse, "get_available_crypto_symbols", time.Now())
 	rows, err := c.conn.Query(context.Background(),
 		`SELECT DISTINCT symbol FROM crypto_rates ORDER BY symbol`)
 	if err != nil {
@@ -134,14 +274,74 @@ func (c *ClickHouseDB) GetAvailableCryptoSymbols() ([]string, error) {
 	return symbols, rows.Err()
 }
 
+// StreamCryptoRates calls fn for every row of symbol (all symbols when empty)
+// with start <= timestamp < end, oldest first. Rows are read block by block
+// from the server cursor, so arbitrarily long ranges use constant memory.
+func (c *ClickHouseDB) StreamCryptoRates(ctx context.Context, symbol string, start, end time.Time, fn func(CryptoRate) error) error {
+	defer metrics.ObserveQuery(metrics.DBClickHouse, "stream_crypto_rates", time.Now())
+	rows, err := c.conn.Query(ctx, `
+		SELECT timestamp, symbol, open, high, low, close, volume, price_rub, created_at, quotes
+		FROM crypto_rates
+		WHERE (? = '' OR symbol = ?) AND timestamp >= ? AND timestamp < ?
+		ORDER BY timestamp ASC, symbol ASC
+	`, symbol, symbol, start, end)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		r, err := scanClickHouseCryptoRate(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
 func scanClickHouseCryptoRates(rows driver.Rows) ([]CryptoRate, error) {
 	var rates []CryptoRate
 	for rows.Next() {
-		var r CryptoRate
-		if err := rows.Scan(&r.Timestamp, &r.Symbol, &r.Open, &r.High, &r.Low, &r.Close, &r.Volume, &r.PriceRUB, &r.CreatedAt); err != nil {
+		r, err := scanClickHouseCryptoRate(rows)
+		if err != nil {
 			return nil, err
 		}
 		rates = append(rates, r)
 	}
 	return rates, rows.Err()
 }
+
+func scanClickHouseCryptoRate(rows driver.Rows) (CryptoRate, error) {
+	var r CryptoRate
+	var quotes map[string]decimal.Decimal
+	if err := rows.Scan(&r.Timestamp, &r.Symbol, &r.Open, &r.High, &r.Low, &r.Close, &r.Volume, &r.PriceRUB, &r.CreatedAt, &quotes); err != nil {
+		return r, err
+	}
+	r.Quotes = fromDriverQuotes(quotes)
+	return r, nil
+}
+
+// toDriverQuotes converts quotes to the map type the driver writes a
+// Map(String, Decimal128) column from; the column is not nullable, so no
+// quotes is an empty map.
+func toDriverQuotes(quotes map[string]money.Decimal) map[string]decimal.Decimal {
+	out := make(map[string]decimal.Decimal, len(quotes))
+	for code, q := range quotes {
+		out[code] = q.Decimal()
+	}
+	return out
+}
+
+// fromDriverQuotes is the inverse of toDriverQuotes; an empty map is nil.
+func fromDriverQuotes(quotes map[string]decimal.Decimal) map[string]money.Decimal {
+	if len(quotes) == 0 {
+		return nil
+	}
+	out := make(map[string]money.Decimal, len(quotes))
+	for code, q := range quotes {
+		out[code] = money.FromDecimal(q)
+	}
+	return out
+}
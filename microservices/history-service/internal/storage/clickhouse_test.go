@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+)
+
+func TestFixedText(t *testing.T) {
+	if got := fixedText("open"); got != "toDecimalString(toFloat64(open), 8)" {
+		t.Errorf("fixedText(open) = %q", got)
+	}
+	for _, col := range decimalColumns {
+		if !strings.Contains(col.fixed, "toDecimalString(") {
+			t.Errorf("%s: expected a fixed-point rewrite, got %q", col.name, col.fixed)
+		}
+	}
+}
+
+// ─── ClickHouse integration tests (skipped when ClickHouse is unavailable) ────
+
+const testDatabase = "currency_tracker_migrate_test"
+
+// newTestClickHouse returns a ClickHouseDB on a scratch database of the
+// ClickHouse server at localhost:9000. The test is skipped if the server
+// is not available.
+func newTestClickHouse(t *testing.T) *ClickHouseDB {
+	t.Helper()
+	ctx := context.Background()
+	admin, err := clickhouse.Open(&clickhouse.Options{Addr: []string{"localhost:9000"}})
+	if err == nil {
+		err = admin.Ping(ctx)
+	}
+	if err != nil {
+		t.Skipf("ClickHouse not available at localhost:9000: %v", err)
+	}
+	defer admin.Close()
+	if err := admin.Exec(ctx, "CREATE DATABASE IF NOT EXISTS "+testDatabase); err != nil {
+		t.Fatalf("create database: %v", err)
+	}
+	t.Cleanup(func() { _ = admin.Exec(context.Background(), "DROP DATABASE IF EXISTS "+testDatabase) })
+
+	conn, err := clickhouse.Open(&clickhouse.Options{
+		Addr: []string{"localhost:9000"},
+		Auth: clickhouse.Auth{Database: testDatabase},
+	})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &ClickHouseDB{conn: conn}
+}
+
+func TestMigrateDecimals_smallAndLargeFloats(t *testing.T) {
+	c := newTestClickHouse(t)
+	ctx := context.Background()
+
+	// The table as it was created before the switch to Decimal128
+	for _, q := range []string{
+		`DROP TABLE IF EXISTS crypto_rates`,
+		`CREATE TABLE crypto_rates (
+			timestamp  DateTime,
+			symbol     String,
+			open       Float64,
+			high       Float64,
+			low        Float64,
+			close      Float64,
+			volume     Float64,
+			price_rub  Float64,
+			quotes     Map(String, Float64),
+			created_at DateTime DEFAULT now()
+		) ENGINE = ReplacingMergeTree(created_at)
+		ORDER BY (symbol, timestamp)`,
+		// 1e-7 and 1.2e-7 are written as exponents by toString
+		`INSERT INTO crypto_rates (timestamp, symbol, open, high, low, close, volume, price_rub, quotes) VALUES
+			('2024-01-15 00:00:00', 'SHIBUSDT', 0.0000001, 0.00000012, 0.00000009, 0.0000001, 12345678901234567, 0.0000089, map('USD', 0.0000001)),
+			('2024-01-15 00:00:00', 'BTCUSDT', 0.3, 42000.12, 41000.5, 41500.01, 1.5, 3735000.9, map('USD', 41500.01))`,
+	} {
+		if err := c.conn.Exec(ctx, q); err != nil {
+			t.Fatalf("%s: %v", q, err)
+		}
+	}
+
+	if err := c.InitSchema(); err != nil {
+		t.Fatalf("InitSchema: %v", err)
+	}
+
+	want := map[string][]string{
+		"SHIBUSDT": {"0.0000001", "0.00000012", "0.00000009", "0.0000001", "12345678901234568", "0.0000089", "0.0000001"},
+		"BTCUSDT":  {"0.3", "42000.12", "41000.5", "41500.01", "1.5", "3735000.9", "41500.01"},
+	}
+	rows, err := c.conn.Query(ctx, `
+		SELECT symbol, toString(open), toString(high), toString(low), toString(close),
+		       toString(volume), toString(price_rub), toString(quotes['USD'])
+		FROM crypto_rates`)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	defer rows.Close()
+	var n int
+	for rows.Next() {
+		var symbol string
+		got := make([]string, 7)
+		if err := rows.Scan(&symbol, &got[0], &got[1], &got[2], &got[3], &got[4], &got[5], &got[6]); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		n++
+		for i, w := range want[symbol] {
+			if got[i] != w {
+				t.Errorf("%s column %d: got %s, want %s", symbol, i, got[i], w)
+			}
+		}
+	}
+	if n != len(want) {
+		t.Errorf("expected %d rows after the migration, got %d", len(want), n)
+	}
+}
@@ -1,11 +1,15 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"time"
 
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/metrics"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
 	_ "github.com/lib/pq"
 )
 
@@ -45,6 +49,9 @@ func NewPostgresDB(cfg Config) (*PostgresDB, error) {
 
 func (p *PostgresDB) Close() error { return p.db.Close() }
 
+// Ping checks the connection for /readyz.
+func (p *PostgresDB) Ping(ctx context.Context) error { return p.db.PingContext(ctx) }
+
 func (p *PostgresDB) InitSchema() error {
 	_, err := p.db.Exec(`
 		CREATE TABLE IF NOT EXISTS cbr_rates (
@@ -60,11 +67,99 @@ func (p *PostgresDB) InitSchema() error {
 		);
 		CREATE INDEX IF NOT EXISTS idx_cbr_rates_date ON cbr_rates(date);
 		CREATE INDEX IF NOT EXISTS idx_cbr_rates_code ON cbr_rates(currency_code);
+		ALTER TABLE cbr_rates ADD COLUMN IF NOT EXISTS quotes JSONB;
+		ALTER TABLE cbr_rates ADD COLUMN IF NOT EXISTS carried_from DATE;
+
+		CREATE TABLE IF NOT EXISTS cbr_rate_revisions (
+			id BIGSERIAL PRIMARY KEY,
+			date DATE NOT NULL,
+			currency_code VARCHAR(3) NOT NULL,
+			currency_name VARCHAR(100) NOT NULL,
+			nominal INTEGER NOT NULL,
+			value DECIMAL(12,4) NOT NULL,
+			previous DECIMAL(12,4),
+			source TEXT NOT NULL DEFAULT '',
+			carried_from DATE,
+			fetched_at TIMESTAMPTZ,
+			recorded_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS idx_cbr_rate_revisions_key ON cbr_rate_revisions(date, currency_code, recorded_at);
+
+		-- Rows stored before revisions were kept get one revision each, as
+		-- of their last save
+		INSERT INTO cbr_rate_revisions (date, currency_code, currency_name, nominal, value, previous, carried_from, recorded_at)
+		SELECT r.date, r.currency_code, r.currency_name, r.nominal, r.value, r.previous, r.carried_from, COALESCE(r.created_at, NOW())
+		FROM cbr_rates r
+		WHERE NOT EXISTS (
+			SELECT 1 FROM cbr_rate_revisions v WHERE v.date = r.date AND v.currency_code = r.currency_code
+		);
+
+		-- Per-unit values; rows stored before them get Value / Nominal
+		ALTER TABLE cbr_rates ADD COLUMN IF NOT EXISTS unit_value DECIMAL(28,16);
+		ALTER TABLE cbr_rate_revisions ADD COLUMN IF NOT EXISTS unit_value DECIMAL(28,16);
+		UPDATE cbr_rates SET unit_value = ROUND(value / GREATEST(nominal, 1), 16) WHERE unit_value IS NULL;
+		UPDATE cbr_rate_revisions SET unit_value = ROUND(value / GREATEST(nominal, 1), 16) WHERE unit_value IS NULL;
+		CREATE INDEX IF NOT EXISTS idx_cbr_rates_code_date ON cbr_rates(currency_code, date);
+
+		CREATE TABLE IF NOT EXISTS metal_prices (
+			date DATE NOT NULL,
+			metal VARCHAR(3) NOT NULL,
+			name VARCHAR(20) NOT NULL,
+			buy DECIMAL(12,4) NOT NULL,
+			sell DECIMAL(12,4) NOT NULL,
+			source TEXT NOT NULL DEFAULT '',
+			fetched_at TIMESTAMPTZ,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			PRIMARY KEY (date, metal)
+		);
+		CREATE INDEX IF NOT EXISTS idx_metal_prices_metal_date ON metal_prices(metal, date);
+
+		-- A row is the value an indicator took on effective_date; it stays in
+		-- effect until the next row of the indicator
+		CREATE TABLE IF NOT EXISTS indicator_rates (
+			indicator VARCHAR(16) NOT NULL,
+			effective_date DATE NOT NULL,
+			name VARCHAR(40) NOT NULL,
+			value DECIMAL(8,4) NOT NULL,
+			source TEXT NOT NULL DEFAULT '',
+			fetched_at TIMESTAMPTZ,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			PRIMARY KEY (indicator, effective_date)
+		);
 	`)
 	return err
 }
 
+// insertRevision appends a revision unless the latest one of the pair
+// already has the same nominal, value, previous value and carry-over.
+const insertRevision = `
+	INSERT INTO cbr_rate_revisions (date, currency_code, currency_name, nominal, value, previous, source, carried_from, fetched_at, unit_value)
+	SELECT $1::date, $2::varchar, $3::varchar, $4::integer, $5::decimal(12,4), $6::decimal(12,4), $7::text, $8::date, $9::timestamptz, $10::decimal(28,16)
+	WHERE NOT EXISTS (
+		SELECT 1 FROM (
+			SELECT nominal, value, previous, carried_from FROM cbr_rate_revisions
+			WHERE date = $1::date AND currency_code = $2::varchar
+			ORDER BY recorded_at DESC, id DESC LIMIT 1
+		) last
+		WHERE last.nominal = $4::integer AND last.value = $5::decimal(12,4)
+			AND last.previous IS NOT DISTINCT FROM $6::decimal(12,4)
+			AND last.carried_from IS NOT DISTINCT FROM $8::date
+	)
+`
+
+// previousNominal selects the nominal of the currency of row r (of cbr_rates
+// or cbr_rate_revisions) on the latest stored date before r's.
+const previousNominal = `(
+	SELECT p.nominal FROM cbr_rates p
+	WHERE p.currency_code = r.currency_code AND p.date < r.date
+	ORDER BY p.date DESC LIMIT 1
+) AS previous_nominal`
+
+// SaveCurrencyRates upserts rates and records a revision for every rate
+// whose value changed, in one transaction. The current row always reflects
+// the latest save; earlier values stay in cbr_rate_revisions.
 func (p *PostgresDB) SaveCurrencyRates(rates []CurrencyRate) error {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "save_currency_rates", time.Now())
 	tx, err := p.db.Begin()
 	if err != nil {
 		return err
@@ -72,32 +167,67 @@ func (p *PostgresDB) SaveCurrencyRates(rates []CurrencyRate) error {
 	defer tx.Rollback()
 
 	stmt, err := tx.Prepare(`
-		INSERT INTO cbr_rates (date, currency_code, currency_name, nominal, value, previous)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO cbr_rates (date, currency_code, currency_name, nominal, value, previous, quotes, carried_from, unit_value)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		ON CONFLICT (date, currency_code) DO UPDATE SET
 			currency_name = EXCLUDED.currency_name,
 			nominal = EXCLUDED.nominal,
 			value = EXCLUDED.value,
 			previous = EXCLUDED.previous,
+			unit_value = EXCLUDED.unit_value,
+			quotes = COALESCE(EXCLUDED.quotes, cbr_rates.quotes),
+			carried_from = EXCLUDED.carried_from,
 			created_at = NOW()
 	`)
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
+	revise, err := tx.Prepare(insertRevision)
+	if err != nil {
+		return err
+	}
+	defer revise.Close()
 
 	for _, r := range rates {
-		if _, err := stmt.Exec(r.Date, r.CurrencyCode, r.CurrencyName, r.Nominal, r.Value, r.Previous); err != nil {
+		quotes, err := marshalQuotes(r.Quotes)
+		if err != nil {
+			return err
+		}
+		unit := r.PerUnit()
+		if _, err := stmt.Exec(r.Date, r.CurrencyCode, r.CurrencyName, r.Nominal, r.Value, r.Previous, quotes, r.CarriedFrom, unit); err != nil {
+			return err
+		}
+		if _, err := revise.Exec(r.Date, r.CurrencyCode, r.CurrencyName, r.Nominal, r.Value, r.Previous, r.Source, r.CarriedFrom, nullTime(r.FetchedAt), unit); err != nil {
 			return err
 		}
 	}
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for _, r := range rates {
+		metrics.RateStored(metrics.SourceCBR, r.Date)
+	}
+	return nil
+}
+
+// LatestCurrencyRateDate returns the newest stored rate date, or the zero
+// time when there are no rates.
+func (p *PostgresDB) LatestCurrencyRateDate() (time.Time, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "latest_currency_rate_date", time.Now())
+	var d sql.NullTime
+	if err := p.db.QueryRow(`SELECT MAX(date) FROM cbr_rates`).Scan(&d); err != nil {
+		return time.Time{}, err
+	}
+	return d.Time, nil
 }
 
 func (p *PostgresDB) GetCurrencyRatesByDate(date time.Time) ([]CurrencyRate, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "get_currency_rates_by_date", time.Now())
 	rows, err := p.db.Query(`
-		SELECT id, date, currency_code, currency_name, nominal, value, previous, created_at
-		FROM cbr_rates WHERE date = $1 ORDER BY currency_code
+		SELECT id, date, currency_code, currency_name, nominal, value, previous, created_at, quotes, carried_from,
+			unit_value, `+previousNominal+`
+		FROM cbr_rates r WHERE date = $1 ORDER BY currency_code
 	`, date)
 	if err != nil {
 		return nil, err
@@ -108,6 +238,7 @@ func (p *PostgresDB) GetCurrencyRatesByDate(date time.Time) ([]CurrencyRate, err
 
 // HasCBRRateOnDay reports whether there is at least one row for code on the given calendar date.
 func (p *PostgresDB) HasCBRRateOnDay(code string, day time.Time) (bool, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "has_cbr_rate_on_day", time.Now())
 	ds := day.Format("2006-01-02")
 	var ok bool
 	err := p.db.QueryRow(`
@@ -121,10 +252,37 @@ func (p *PostgresDB) HasCBRRateOnDay(code string, day time.Time) (bool, error) {
 	return ok, nil
 }
 
+// StoredCBRDates returns the dates in [start, end] with a rate of code, or
+// with any rate when code is empty, oldest first.
+func (p *PostgresDB) StoredCBRDates(ctx context.Context, code string, start, end time.Time) ([]time.Time, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "stored_cbr_dates", time.Now())
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT DISTINCT date FROM cbr_rates
+		WHERE ($1 = '' OR currency_code = $1) AND date >= $2 AND date <= $3
+		ORDER BY date
+	`, code, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var dates []time.Time
+	for rows.Next() {
+		var d time.Time
+		if err := rows.Scan(&d); err != nil {
+			return nil, err
+		}
+		y, m, dd := d.Date()
+		dates = append(dates, time.Date(y, m, dd, 0, 0, 0, 0, time.UTC))
+	}
+	return dates, rows.Err()
+}
+
 func (p *PostgresDB) GetCurrencyRatesByDateRange(code string, start, end time.Time) ([]CurrencyRate, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "get_currency_rates_by_date_range", time.Now())
 	rows, err := p.db.Query(`
-		SELECT id, date, currency_code, currency_name, nominal, value, previous, created_at
-		FROM cbr_rates WHERE currency_code = $1 AND date >= $2 AND date <= $3 ORDER BY date DESC
+		SELECT id, date, currency_code, currency_name, nominal, value, previous, created_at, quotes, carried_from,
+			unit_value, `+previousNominal+`
+		FROM cbr_rates r WHERE currency_code = $1 AND date >= $2 AND date <= $3 ORDER BY date DESC
 	`, code, start, end)
 	if err != nil {
 		return nil, err
@@ -133,14 +291,366 @@ func (p *PostgresDB) GetCurrencyRatesByDateRange(code string, start, end time.Ti
 	return scanCurrencyRates(rows)
 }
 
+// GetCurrencyRatesAsOf returns the rates of code (all currencies when
+// empty) in [start, end] as they were stored at asOf: the latest revision of
+// each date recorded at or before it, newest date first. Rows have no
+// quotes, which are not kept with revisions; PreviousNominal is that of the
+// current rows.
+func (p *PostgresDB) GetCurrencyRatesAsOf(ctx context.Context, code string, start, end, asOf time.Time) ([]CurrencyRate, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "get_currency_rates_as_of", time.Now())
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT DISTINCT ON (date, currency_code)
+			id, date, currency_code, currency_name, nominal, value, previous, recorded_at, NULL::jsonb, carried_from,
+			unit_value, `+previousNominal+`
+		FROM cbr_rate_revisions r
+		WHERE ($1 = '' OR currency_code = $1) AND date >= $2 AND date <= $3 AND recorded_at <= $4
+		ORDER BY date DESC, currency_code, recorded_at DESC, id DESC
+	`, code, start, end, asOf)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanCurrencyRates(rows)
+}
+
+// GetCurrencyRateRevisions returns every revision of code on date, oldest
+// first.
+func (p *PostgresDB) GetCurrencyRateRevisions(ctx context.Context, code string, date time.Time) ([]Revision, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "get_currency_rate_revisions", time.Now())
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT id, date, currency_code, currency_name, nominal, value, previous, unit_value, source, carried_from, fetched_at, recorded_at
+		FROM cbr_rate_revisions WHERE currency_code = $1 AND date = $2
+		ORDER BY recorded_at, id
+	`, code, date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var revs []Revision
+	for rows.Next() {
+		var r Revision
+		var carried, fetched sql.NullTime
+		// A NULL previous value scans as zero.
+		if err := rows.Scan(&r.ID, &r.Date, &r.CurrencyCode, &r.CurrencyName, &r.Nominal, &r.Value, &r.Previous, &r.UnitValue,
+			&r.Source, &carried, &fetched, &r.RecordedAt); err != nil {
+			return nil, err
+		}
+		r.CarriedFrom, r.FetchedAt = timePtr(carried), timePtr(fetched)
+		revs = append(revs, r)
+	}
+	return revs, rows.Err()
+}
+
+// GetNominalChanges returns the dates in [start, end] on which the CBR
+// changed the nominal of code (of any currency when empty), compared with
+// the latest stored date before, ordered by date and code.
+func (p *PostgresDB) GetNominalChanges(ctx context.Context, code string, start, end time.Time) ([]NominalChange, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "get_nominal_changes", time.Now())
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT date, currency_code, previous_nominal, nominal FROM (
+			SELECT date, currency_code, nominal,
+				LAG(nominal) OVER (PARTITION BY currency_code ORDER BY date) AS previous_nominal
+			FROM cbr_rates WHERE ($1 = '' OR currency_code = $1) AND date <= $3
+		) c
+		WHERE previous_nominal <> nominal AND date >= $2
+		ORDER BY date, currency_code
+	`, code, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var changes []NominalChange
+	for rows.Next() {
+		var c NominalChange
+		if err := rows.Scan(&c.Date, &c.CurrencyCode, &c.PreviousNominal, &c.Nominal); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+// SaveMetalPrices upserts CBR metal prices. The collector asks for the
+// last days on every run, so most saves repeat stored prices.
+func (p *PostgresDB) SaveMetalPrices(prices []MetalPrice) error {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "save_metal_prices", time.Now())
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO metal_prices (date, metal, name, buy, sell, source, fetched_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (date, metal) DO UPDATE SET
+			name = EXCLUDED.name,
+			buy = EXCLUDED.buy,
+			sell = EXCLUDED.sell,
+			source = EXCLUDED.source,
+			fetched_at = EXCLUDED.fetched_at,
+			created_at = NOW()
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, m := range prices {
+		if _, err := stmt.Exec(m.Date, m.Metal, m.Name, m.Buy, m.Sell, m.Source, nullTime(m.FetchedAt)); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for _, m := range prices {
+		metrics.RateStored(metrics.SourceCBRMetals, m.Date)
+	}
+	return nil
+}
+
+// LatestMetalPriceDate returns the newest stored metal price date, or the
+// zero time when there are none.
+func (p *PostgresDB) LatestMetalPriceDate() (time.Time, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "latest_metal_price_date", time.Now())
+	var d sql.NullTime
+	if err := p.db.QueryRow(`SELECT MAX(date) FROM metal_prices`).Scan(&d); err != nil {
+		return time.Time{}, err
+	}
+	return d.Time, nil
+}
+
+// GetMetalPricesOn returns the prices of the latest date on or before date
+// with any price stored, ordered by metal. The CBR sets none on weekends
+// and holidays, so the prices of the last business day are in effect then.
+func (p *PostgresDB) GetMetalPricesOn(ctx context.Context, date time.Time) ([]MetalPrice, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "get_metal_prices_on", time.Now())
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT date, metal, name, buy, sell, created_at FROM metal_prices
+		WHERE date = (SELECT MAX(date) FROM metal_prices WHERE date <= $1)
+		ORDER BY metal
+	`, date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanMetalPrices(rows)
+}
+
+// GetMetalPricesByDateRange returns the prices of metal (all metals when
+// empty) in [start, end], ordered by date then metal.
+func (p *PostgresDB) GetMetalPricesByDateRange(ctx context.Context, metal string, start, end time.Time) ([]MetalPrice, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "get_metal_prices_by_date_range", time.Now())
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT date, metal, name, buy, sell, created_at FROM metal_prices
+		WHERE ($1 = '' OR metal = $1) AND date >= $2 AND date <= $3
+		ORDER BY date, metal
+	`, metal, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanMetalPrices(rows)
+}
+
+func scanMetalPrices(rows *sql.Rows) ([]MetalPrice, error) {
+	var prices []MetalPrice
+	for rows.Next() {
+		var m MetalPrice
+		if err := rows.Scan(&m.Date, &m.Metal, &m.Name, &m.Buy, &m.Sell, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		prices = append(prices, m)
+	}
+	return prices, rows.Err()
+}
+
+// SaveIndicatorRates upserts CBR indicator values and then drops every row
+// repeating the value of the row before it, so that each remaining row is a
+// change: the key rate is published for every business day but only moves
+// on board decision dates.
+func (p *PostgresDB) SaveIndicatorRates(rates []IndicatorRate) error {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "save_indicator_rates", time.Now())
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO indicator_rates (indicator, effective_date, name, value, source, fetched_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (indicator, effective_date) DO UPDATE SET
+			name = EXCLUDED.name,
+			value = EXCLUDED.value,
+			source = EXCLUDED.source,
+			fetched_at = EXCLUDED.fetched_at,
+			created_at = NOW()
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	indicators := make(map[string]bool)
+	for _, r := range rates {
+		if _, err := stmt.Exec(r.Indicator, r.EffectiveDate, r.Name, r.Value, r.Source, nullTime(r.FetchedAt)); err != nil {
+			return err
+		}
+		indicators[r.Indicator] = true
+	}
+	for indicator := range indicators {
+		if _, err := tx.Exec(`
+			DELETE FROM indicator_rates r USING (
+				SELECT effective_date, value,
+					LAG(value) OVER (ORDER BY effective_date) AS previous
+				FROM indicator_rates WHERE indicator = $1
+			) s
+			WHERE r.indicator = $1 AND r.effective_date = s.effective_date AND s.value = s.previous
+		`, indicator); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for _, r := range rates {
+		metrics.RateStored(metrics.SourceCBRIndicators, r.EffectiveDate)
+	}
+	return nil
+}
+
+// GetIndicatorRatesOn returns the value of every indicator in effect on
+// date, the latest on or before it, ordered by indicator.
+func (p *PostgresDB) GetIndicatorRatesOn(ctx context.Context, date time.Time) ([]IndicatorRate, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "get_indicator_rates_on", time.Now())
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT DISTINCT ON (indicator) indicator, effective_date, name, value, created_at
+		FROM indicator_rates WHERE effective_date <= $1
+		ORDER BY indicator, effective_date DESC
+	`, date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanIndicatorRates(rows)
+}
+
+// GetIndicatorRatesByDateRange returns the values of indicator in effect in
+// [start, end], oldest first: the one in effect on start, which may have
+// taken effect before it, and those taking effect after it.
+func (p *PostgresDB) GetIndicatorRatesByDateRange(ctx context.Context, indicator string, start, end time.Time) ([]IndicatorRate, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "get_indicator_rates_by_date_range", time.Now())
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT indicator, effective_date, name, value, created_at FROM indicator_rates
+		WHERE indicator = $1 AND effective_date <= $3 AND effective_date >= COALESCE(
+			(SELECT MAX(effective_date) FROM indicator_rates WHERE indicator = $1 AND effective_date <= $2), $2)
+		ORDER BY effective_date
+	`, indicator, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanIndicatorRates(rows)
+}
+
+func scanIndicatorRates(rows *sql.Rows) ([]IndicatorRate, error) {
+	var rates []IndicatorRate
+	for rows.Next() {
+		var r IndicatorRate
+		if err := rows.Scan(&r.Indicator, &r.EffectiveDate, &r.Name, &r.Value, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		rates = append(rates, r)
+	}
+	return rates, rows.Err()
+}
+
+// StreamCurrencyRates calls fn for every rate of code (all currencies when
+// empty) between start and end inclusive, ordered by date then code. Rows are
+// consumed from the open cursor one at a time instead of being collected.
+func (p *PostgresDB) StreamCurrencyRates(ctx context.Context, code string, start, end time.Time, fn func(CurrencyRate) error) error {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "stream_currency_rates", time.Now())
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT id, date, currency_code, currency_name, nominal, value, previous, created_at, quotes, carried_from,
+			unit_value, `+previousNominal+`
+		FROM cbr_rates r WHERE ($1 = '' OR currency_code = $1) AND date >= $2 AND date <= $3
+		ORDER BY date ASC, currency_code ASC
+	`, code, start, end)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		r, err := scanCurrencyRate(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
 func scanCurrencyRates(rows *sql.Rows) ([]CurrencyRate, error) {
 	var rates []CurrencyRate
 	for rows.Next() {
-		var r CurrencyRate
-		if err := rows.Scan(&r.ID, &r.Date, &r.CurrencyCode, &r.CurrencyName, &r.Nominal, &r.Value, &r.Previous, &r.CreatedAt); err != nil {
+		r, err := scanCurrencyRate(rows)
+		if err != nil {
 			return nil, err
 		}
 		rates = append(rates, r)
 	}
 	return rates, rows.Err()
 }
+
+func scanCurrencyRate(rows *sql.Rows) (CurrencyRate, error) {
+	var r CurrencyRate
+	var quotes []byte
+	var carried sql.NullTime
+	var prevNominal sql.NullInt64
+	if err := rows.Scan(&r.ID, &r.Date, &r.CurrencyCode, &r.CurrencyName, &r.Nominal, &r.Value, &r.Previous, &r.CreatedAt, &quotes, &carried,
+		&r.UnitValue, &prevNominal); err != nil {
+		return r, err
+	}
+	r.CarriedFrom = timePtr(carried)
+	if prevNominal.Valid && int(prevNominal.Int64) != r.Nominal {
+		r.PreviousNominal = int(prevNominal.Int64)
+	}
+	if len(quotes) > 0 {
+		if err := json.Unmarshal(quotes, &r.Quotes); err != nil {
+			return r, fmt.Errorf("decode quotes for %s: %w", r.CurrencyCode, err)
+		}
+	}
+	return r, nil
+}
+
+// marshalQuotes encodes quotes for the JSONB column; rows without quotes
+// (e.g. archive backfills) are stored as NULL.
+func marshalQuotes(quotes map[string]money.Decimal) (any, error) {
+	if len(quotes) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(quotes)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// nullTime stores the zero time as NULL.
+func nullTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
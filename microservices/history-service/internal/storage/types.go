@@ -1,6 +1,10 @@
 package storage
 
-import "time"
+import (
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+)
 
 // CurrencyRate represents a CBR fiat currency rate stored in PostgreSQL.
 type CurrencyRate struct {
@@ -9,20 +13,112 @@ type CurrencyRate struct {
 	CurrencyCode string
 	CurrencyName string
 	Nominal      int
-	Value        float64
-	Previous     float64
-	CreatedAt    time.Time
+	Value        money.Decimal
+	Previous     money.Decimal
+	// UnitValue is the price of one unit: the CBR's VunitRate, or Value /
+	// Nominal. Unlike Value it stays comparable when the nominal changes.
+	UnitValue money.Decimal
+	// PreviousNominal is set on the first date stored with a new nominal: the
+	// nominal of the currency on the date before.
+	PreviousNominal int `json:",omitempty"`
+	CreatedAt       time.Time
+	// CarriedFrom is the publication date of the earlier sheet the rate was
+	// copied from, when the CBR published nothing for Date.
+	CarriedFrom *time.Time `json:",omitempty"`
+	// Source is the URL the rate was read from and FetchedAt the time it was
+	// read. They are kept with the revision history only (see Revision).
+	Source    string    `json:"-"`
+	FetchedAt time.Time `json:"-"`
+	// Quotes is the price of Nominal units in other currencies, as computed
+	// by normalization-service from CBR cross rates.
+	Quotes map[string]money.Decimal `json:",omitempty"`
+	// Quote, QuoteValue and QuotePrevious are filled only when a client asks
+	// for a quote currency other than RUB (?quote=USD).
+	Quote         string         `json:",omitempty"`
+	QuoteValue    *money.Decimal `json:",omitempty"`
+	QuotePrevious *money.Decimal `json:",omitempty"`
+}
+
+// PerUnit returns UnitValue, or Value / Nominal for a rate built without it
+// (an archive sheet without VunitRate, an event from an older normalizer).
+func (r CurrencyRate) PerUnit() money.Decimal {
+	if r.UnitValue.IsPositive() {
+		return r.UnitValue
+	}
+	return money.PerUnit(r.Value, r.Nominal)
+}
+
+// Revision is one stored value of a CBR rate. A revision is recorded
+// whenever a save changes the nominal, value, previous value or carry-over
+// of a (date, code) pair; saving the same value again records nothing.
+type Revision struct {
+	ID           int64         `json:"id"`
+	Date         time.Time     `json:"date"`
+	CurrencyCode string        `json:"currency_code"`
+	CurrencyName string        `json:"currency_name"`
+	Nominal      int           `json:"nominal"`
+	Value        money.Decimal `json:"value"`
+	Previous     money.Decimal `json:"previous"`
+	UnitValue    money.Decimal `json:"unit_value"`
+	Source       string        `json:"source,omitempty"`
+	CarriedFrom  *time.Time    `json:"carried_from,omitempty"`
+	FetchedAt    *time.Time    `json:"fetched_at,omitempty"`
+	RecordedAt   time.Time     `json:"recorded_at"`
+}
+
+// NominalChange is a date on which the CBR started quoting a currency for a
+// different number of units, e.g. KZT moving from 100 to 1000. Value jumps
+// on such a date; UnitValue does not.
+type NominalChange struct {
+	Date            time.Time `json:"date"`
+	CurrencyCode    string    `json:"currency_code"`
+	PreviousNominal int       `json:"previous_nominal"`
+	Nominal         int       `json:"nominal"`
+}
+
+// MetalPrice is a CBR discount price of a precious metal stored in
+// PostgreSQL. Metal is the ISO 4217 code (XAU, XAG, XPT, XPD); Buy and Sell
+// are in RUB per gram.
+type MetalPrice struct {
+	Date      time.Time
+	Metal     string
+	Name      string
+	Buy       money.Decimal
+	Sell      money.Decimal
+	Source    string    `json:"-"`
+	FetchedAt time.Time `json:"-"`
+	CreatedAt time.Time
+}
+
+// IndicatorRate is a value of a CBR indicator (KEY_RATE, RUONIA) stored in
+// PostgreSQL, in percent per annum. It is in effect from EffectiveDate until
+// the next value of the indicator.
+type IndicatorRate struct {
+	Indicator     string
+	EffectiveDate time.Time
+	Name          string
+	Value         money.Decimal
+	Source        string    `json:"-"`
+	FetchedAt     time.Time `json:"-"`
+	CreatedAt     time.Time
 }
 
 // CryptoRate represents a Binance crypto rate stored in ClickHouse.
 type CryptoRate struct {
 	Timestamp time.Time
 	Symbol    string
-	Open      float64
-	High      float64
-	Low       float64
-	Close     float64
-	Volume    float64
-	PriceRUB  float64
+	Open      money.Decimal
+	High      money.Decimal
+	Low       money.Decimal
+	Close     money.Decimal
+	Volume    money.Decimal
+	PriceRUB  money.Decimal
 	CreatedAt time.Time
+	// Quotes is the Close price in other currencies, as computed by
+	// normalization-service from CBR cross rates.
+	Quotes map[string]money.Decimal `json:",omitempty"`
+	// Quote and QuotePrice are filled only when a client asks for a quote
+	// currency other than RUB (?quote=USD).
+	Quote      string         `json:",omitempty"`
+	QuotePrice *money.Decimal `json:",omitempty"`
 }
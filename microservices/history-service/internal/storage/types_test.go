@@ -3,6 +3,8 @@ package storage
 import (
 	"testing"
 	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
 )
 
 // Tests for the shared domain types — CurrencyRate and CryptoRate.
@@ -16,41 +18,52 @@ func TestCurrencyRate_fields(t *testing.T) {
 		CurrencyCode: "USD",
 		CurrencyName: "Доллар США",
 		Nominal:      1,
-		Value:        90.5,
-		Previous:     89.0,
+		Value:        money.MustParse("90.5"),
+		Previous:     money.MustParse("89.0"),
 		CreatedAt:    now,
 	}
 
 	if r.CurrencyCode != "USD" {
 		t.Errorf("expected USD, got %s", r.CurrencyCode)
 	}
-	if r.Value != 90.5 {
-		t.Errorf("expected Value=90.5, got %f", r.Value)
+	if r.Value.String() != "90.5" {
+		t.Errorf("expected Value=90.5, got %s", r.Value)
 	}
 	if r.Nominal != 1 {
 		t.Errorf("expected Nominal=1, got %d", r.Nominal)
 	}
 }
 
+func TestCurrencyRate_PerUnit(t *testing.T) {
+	jpy := CurrencyRate{Nominal: 100, Value: money.MustParse("60.1234")}
+	if got := jpy.PerUnit(); got.String() != "0.601234" {
+		t.Errorf("expected Value / Nominal 0.601234, got %s", got)
+	}
+	jpy.UnitValue = money.MustParse("0.6012341")
+	if got := jpy.PerUnit(); got.String() != "0.6012341" {
+		t.Errorf("expected the stored unit value, got %s", got)
+	}
+}
+
 func TestCryptoRate_fields(t *testing.T) {
 	ts := time.Unix(1700000000, 0)
 	r := CryptoRate{
 		Timestamp: ts,
 		Symbol:    "BTCUSDT",
-		Open:      40000,
-		High:      42000,
-		Low:       39000,
-		Close:     41000,
-		Volume:    1.5,
-		PriceRUB:  41000 * 90,
+		Open:      money.NewFromInt(40000),
+		High:      money.NewFromInt(42000),
+		Low:       money.NewFromInt(39000),
+		Close:     money.NewFromInt(41000),
+		Volume:    money.MustParse("1.5"),
+		PriceRUB:  money.NewFromInt(41000).Mul(money.NewFromInt(90)),
 		CreatedAt: time.Now(),
 	}
 
 	if r.Symbol != "BTCUSDT" {
 		t.Errorf("expected BTCUSDT, got %s", r.Symbol)
 	}
-	if r.PriceRUB != 3690000 {
-		t.Errorf("expected PriceRUB=3690000, got %f", r.PriceRUB)
+	if r.PriceRUB.String() != "3690000" {
+		t.Errorf("expected PriceRUB=3690000, got %s", r.PriceRUB)
 	}
 	if !r.Timestamp.Equal(ts) {
 		t.Errorf("timestamp mismatch")
@@ -59,19 +72,19 @@ func TestCryptoRate_fields(t *testing.T) {
 
 func TestCurrencyRate_changeDirection(t *testing.T) {
 	tests := []struct {
-		value    float64
-		previous float64
+		value    string
+		previous string
 		wantUp   bool
 	}{
-		{91.0, 90.0, true},
-		{89.0, 90.0, false},
-		{90.0, 90.0, false},
+		{"91.0", "90.0", true},
+		{"89.0", "90.0", false},
+		{"90.0", "90.0", false},
 	}
 	for _, tc := range tests {
-		r := CurrencyRate{Value: tc.value, Previous: tc.previous}
-		up := r.Value > r.Previous
+		r := CurrencyRate{Value: money.MustParse(tc.value), Previous: money.MustParse(tc.previous)}
+		up := r.Value.Cmp(r.Previous) > 0
 		if up != tc.wantUp {
-			t.Errorf("value=%f previous=%f: expected up=%v, got %v", tc.value, tc.previous, tc.wantUp, up)
+			t.Errorf("value=%s previous=%s: expected up=%v, got %v", tc.value, tc.previous, tc.wantUp, up)
 		}
 	}
 }
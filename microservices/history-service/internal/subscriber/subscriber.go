@@ -3,16 +3,22 @@ package subscriber
 import (
 	"context"
 	"encoding/json"
-	"log"
 	"strings"
+	"time"
 
 	"github.com/casualdoto/go-currency-tracker/microservices/history-service/internal/storage"
 	"github.com/casualdoto/go-currency-tracker/microservices/shared/events"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/logging"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/metrics"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/tracing"
 	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const groupID = "history-service"
 
+var logger = logging.For("subscriber")
+
 type Subscriber struct {
 	reader *kafka.Reader
 	pg     *storage.PostgresDB
@@ -38,8 +44,12 @@ func (s *Subscriber) Run() error {
 		if err != nil {
 			return err
 		}
-		if err := s.process(msg.Value); err != nil {
-			log.Printf("subscriber: process error: %v", err)
+		metrics.KafkaConsumed(events.TopicNormalizedRates, groupID, s.reader.Stats().Lag)
+		msgCtx, span := tracing.StartConsumer(logging.ExtractKafka(ctx, msg), groupID, msg)
+		err = s.process(msgCtx, msg.Value)
+		tracing.End(span, err)
+		if err != nil {
+			logger.ErrorContext(msgCtx, "process failed", "offset", msg.Offset, "error", err)
 		}
 	}
 }
@@ -49,7 +59,7 @@ type baseEvent struct {
 	Rates  json.RawMessage `json:"rates"`
 }
 
-func (s *Subscriber) process(data []byte) error {
+func (s *Subscriber) process(ctx context.Context, data []byte) error {
 	var evt baseEvent
 	if err := json.Unmarshal(data, &evt); err != nil {
 		return err
@@ -70,12 +80,22 @@ func (s *Subscriber) process(data []byte) error {
 				Nominal:      r.Nominal,
 				Value:        r.ValueRUB,
 				Previous:     r.PreviousRUB,
+				UnitValue:    r.UnitValueRUB,
+				Quotes:       r.Quotes,
+				Source:       r.SourceURL,
+				FetchedAt:    r.CollectedAt,
 			})
 		}
-		if err := s.pg.SaveCurrencyRates(dbRates); err != nil {
+		start := time.Now()
+		_, span := tracing.Start(ctx, "postgres save_currency_rates",
+			attribute.String("db.system", metrics.DBPostgres), attribute.Int("rows", len(dbRates)))
+		err := s.pg.SaveCurrencyRates(dbRates)
+		tracing.End(span, err)
+		if err != nil {
 			return err
 		}
-		log.Printf("subscriber: saved %d CBR rates to PostgreSQL", len(dbRates))
+		logger.InfoContext(ctx, "saved rates", "source", events.SourceCBR, "db", metrics.DBPostgres,
+			"count", len(dbRates), "duration_ms", logging.Millis(time.Since(start)))
 
 	case string(events.SourceBinance):
 		var rates []events.NormalizedCryptoRate
@@ -93,12 +113,74 @@ func (s *Subscriber) process(data []byte) error {
 				Close:     r.Close,
 				Volume:    r.Volume,
 				PriceRUB:  r.PriceRUB,
+				Quotes:    r.Quotes,
+			})
+		}
+		start := time.Now()
+		_, span := tracing.Start(ctx, "clickhouse save_crypto_rates",
+			attribute.String("db.system", metrics.DBClickHouse), attribute.Int("rows", len(dbRates)))
+		err := s.ch.SaveCryptoRates(dbRates)
+		tracing.End(span, err)
+		if err != nil {
+			return err
+		}
+		logger.InfoContext(ctx, "saved rates", "source", events.SourceBinance, "db", metrics.DBClickHouse,
+			"count", len(dbRates), "duration_ms", logging.Millis(time.Since(start)))
+
+	case string(events.SourceCBRMetals):
+		var prices []events.NormalizedMetalPrice
+		if err := json.Unmarshal(evt.Rates, &prices); err != nil {
+			return err
+		}
+		dbPrices := make([]storage.MetalPrice, 0, len(prices))
+		for _, m := range prices {
+			dbPrices = append(dbPrices, storage.MetalPrice{
+				Date:      m.Date,
+				Metal:     m.Metal,
+				Name:      m.Name,
+				Buy:       m.Buy,
+				Sell:      m.Sell,
+				Source:    m.SourceURL,
+				FetchedAt: m.CollectedAt,
 			})
 		}
-		if err := s.ch.SaveCryptoRates(dbRates); err != nil {
+		start := time.Now()
+		_, span := tracing.Start(ctx, "postgres save_metal_prices",
+			attribute.String("db.system", metrics.DBPostgres), attribute.Int("rows", len(dbPrices)))
+		err := s.pg.SaveMetalPrices(dbPrices)
+		tracing.End(span, err)
+		if err != nil {
+			return err
+		}
+		logger.InfoContext(ctx, "saved rates", "source", events.SourceCBRMetals, "db", metrics.DBPostgres,
+			"count", len(dbPrices), "duration_ms", logging.Millis(time.Since(start)))
+
+	case string(events.SourceCBRIndicators):
+		var rates []events.IndicatorRate
+		if err := json.Unmarshal(evt.Rates, &rates); err != nil {
+			return err
+		}
+		dbRates := make([]storage.IndicatorRate, 0, len(rates))
+		for _, r := range rates {
+			dbRates = append(dbRates, storage.IndicatorRate{
+				Indicator:     r.Indicator,
+				EffectiveDate: r.Date,
+				Name:          r.Name,
+				Value:         r.Value,
+				Source:        r.SourceURL,
+				FetchedAt:     r.CollectedAt,
+			})
+		}
+		start := time.Now()
+		_, span := tracing.Start(ctx, "postgres save_indicator_rates",
+			attribute.String("db.system", metrics.DBPostgres), attribute.Int("rows", len(dbRates)))
+		err := s.pg.SaveIndicatorRates(dbRates)
+		tracing.End(span, err)
+		if err != nil {
 			return err
 		}
-		log.Printf("subscriber: saved %d crypto rates to ClickHouse", len(dbRates))
+		logger.InfoContext(ctx, "saved rates", "source", events.SourceCBRIndicators, "db", metrics.DBPostgres,
+			"count", len(dbRates), "duration_ms", logging.Millis(time.Since(start)))
 	}
 	return nil
 }
@@ -1,31 +1,65 @@
 package main
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
 
 	"github.com/casualdoto/go-currency-tracker/microservices/normalization-service/internal/normalizer"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/health"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/logging"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/tracing"
 )
 
 func main() {
 	brokers := getEnv("KAFKA_BROKERS", "localhost:9092")
 	cbrURL := getEnv("CBR_BASE_URL", "https://www.cbr-xml-daily.ru")
+	quotes := normalizer.ParseQuoteCurrencies(getEnv("QUOTE_CURRENCIES", normalizer.DefaultQuoteCurrencies))
+	limits, err := normalizer.ParseLimits(
+		getEnv("MAX_CBR_CHANGE_PCT", normalizer.DefaultMaxCBRChangePct),
+		getEnv("MAX_CRYPTO_CHANGE_PCT", normalizer.DefaultMaxCryptoChangePct))
+	// METRICS_PORT is the name the port had before it served health checks
+	httpPort := getEnv("HTTP_PORT", getEnv("METRICS_PORT", "9082"))
 
-	svc := normalizer.New(brokers, cbrURL)
+	logging.Setup("normalization-service")
+	if err != nil {
+		slog.Error("invalid change limit", "error", err)
+		os.Exit(1)
+	}
+	shutdownTracing, err := tracing.Setup(context.Background(), "normalization-service")
+	if err != nil {
+		slog.Error("tracing setup failed", "error", err)
+		os.Exit(1)
+	}
+	defer shutdownTracing(context.Background())
+
+	svc := normalizer.New(brokers, cbrURL, quotes, limits)
+
+	// Health checks and Prometheus metrics; the service only needs Kafka.
+	// The CBR fallback for USD/RUB is not checked: without it the normalizer
+	// keeps using the last rate it saw.
+	checker := health.New().Add("kafka", health.Kafka(brokers))
+	go func() {
+		slog.Info("http listening", "addr", ":"+httpPort)
+		if err := health.ListenAndServe(":"+httpPort, checker); err != nil {
+			slog.Error("http server failed", "error", err)
+		}
+	}()
 
 	go func() {
-		log.Println("Normalization Service: starting")
+		slog.Info("starting", "quotes", quotes, "limits", limits)
 		if err := svc.Run(); err != nil {
-			log.Fatalf("normalizer error: %v", err)
+			slog.Error("normalizer stopped", "error", err)
+			os.Exit(1)
 		}
 	}()
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	log.Println("Normalization Service: shutting down")
+	slog.Info("shutting down")
 }
 
 func getEnv(key, def string) string {
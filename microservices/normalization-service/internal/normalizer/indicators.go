@@ -0,0 +1,55 @@
+package normalizer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/events"
+)
+
+// cbrIndicators maps the indicator codes of the collector to their names.
+var cbrIndicators = map[string]string{
+	events.IndicatorKeyRate: "Key rate",
+	events.IndicatorRUONIA:  "RUONIA",
+}
+
+func (n *Normalizer) normalizeIndicators(ctx context.Context, raw json.RawMessage) error {
+	normalized, rejected, err := buildNormalizedIndicators(raw)
+	if err != nil {
+		return err
+	}
+	if len(normalized) > 0 {
+		err = n.publish(ctx, n.writer, events.IndicatorRatesEvent{Source: events.SourceCBRIndicators, Rates: normalized})
+	}
+	return errors.Join(err, n.quarantineRates(ctx, events.SourceCBRIndicators, rejected))
+}
+
+// buildNormalizedIndicators parses raw CBR indicator values and returns the
+// normalized structs of the valid ones, dated by the Moscow day they took
+// effect, and the rejected rest. Extracted for unit-testability.
+func buildNormalizedIndicators(raw json.RawMessage) ([]events.IndicatorRate, []rejection, error) {
+	var rates []events.RawIndicatorRate
+	if err := json.Unmarshal(raw, &rates); err != nil {
+		return nil, nil, err
+	}
+
+	var rejected []rejection
+	normalized := make([]events.IndicatorRate, 0, len(rates))
+	for _, r := range rates {
+		name, date, rej := checkIndicator(r)
+		if rej != nil {
+			rejected = append(rejected, *rej)
+			continue
+		}
+		normalized = append(normalized, events.IndicatorRate{
+			Date:        date,
+			Indicator:   r.Indicator,
+			Name:        name,
+			Value:       r.Value,
+			SourceURL:   r.SourceURL,
+			CollectedAt: r.CollectedAt,
+		})
+	}
+	return normalized, rejected, nil
+}
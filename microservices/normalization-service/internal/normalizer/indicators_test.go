@@ -0,0 +1,73 @@
+package normalizer
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/events"
+)
+
+func TestNormalizeIndicators_basic(t *testing.T) {
+	collected := time.Date(2026, 4, 14, 9, 0, 0, 0, time.UTC)
+	raw, _ := json.Marshal([]events.RawIndicatorRate{
+		{Date: "2026-04-14T00:00:00+03:00", Indicator: events.IndicatorKeyRate, Value: dec("15.50"), CollectedAt: collected, SourceURL: "https://www.cbr.ru/DailyInfoWebServ/DailyInfo.asmx/KeyRateXML"},
+		{Date: "2026-04-13T00:00:00+03:00", Indicator: events.IndicatorRUONIA, Value: dec("15.84"), CollectedAt: collected},
+	})
+
+	rates, rejected, err := buildNormalizedIndicators(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rejected) != 0 || len(rates) != 2 {
+		t.Fatalf("expected 2 values and no rejections, got %d and %d", len(rates), len(rejected))
+	}
+	key := rates[0]
+	if key.Indicator != events.IndicatorKeyRate || key.Name != "Key rate" {
+		t.Errorf("expected the key rate, got %s %s", key.Indicator, key.Name)
+	}
+	// Midnight in Moscow is the Moscow day, not the UTC day before
+	if want := time.Date(2026, 4, 14, 0, 0, 0, 0, time.UTC); !key.Date.Equal(want) {
+		t.Errorf("expected date %s, got %s", want, key.Date)
+	}
+	if key.Value.String() != "15.5" || key.SourceURL == "" || !key.CollectedAt.Equal(collected) {
+		t.Errorf("expected value, source URL and collection time to pass through, got %+v", key)
+	}
+	if rates[1].Name != "RUONIA" {
+		t.Errorf("expected RUONIA, got %s", rates[1].Name)
+	}
+}
+
+func TestCheckIndicator(t *testing.T) {
+	valid := events.RawIndicatorRate{Date: "2026-04-14T00:00:00+03:00", Indicator: events.IndicatorKeyRate, Value: dec("15.5")}
+
+	tests := []struct {
+		name   string
+		modify func(r *events.RawIndicatorRate)
+		rule   string
+	}{
+		{"valid", func(r *events.RawIndicatorRate) {}, ""},
+		{"unknown indicator", func(r *events.RawIndicatorRate) { r.Indicator = "MIACR" }, RuleUnknownCode},
+		{"zero value", func(r *events.RawIndicatorRate) { r.Value = dec("0") }, RuleNonPositiveValue},
+		{"bad date", func(r *events.RawIndicatorRate) { r.Date = "14.04.2026" }, RuleInvalidDate},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := valid
+			tc.modify(&r)
+			name, date, rej := checkIndicator(r)
+			if tc.rule == "" {
+				if rej != nil {
+					t.Fatalf("unexpected rejection: %s: %s", rej.rule, rej.reason)
+				}
+				if name != "Key rate" || date.IsZero() {
+					t.Errorf("expected the key rate with a date, got %q %s", name, date)
+				}
+				return
+			}
+			if rej == nil || rej.rule != tc.rule {
+				t.Fatalf("expected rule %s, got %+v", tc.rule, rej)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package normalizer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/events"
+)
+
+// cbrMetal is a precious metal the CBR sets a discount price for.
+type cbrMetal struct {
+	code string // ISO 4217
+	name string
+}
+
+// cbrMetals maps the metal codes of xml_metall.asp to the metals.
+var cbrMetals = map[int]cbrMetal{
+	1: {"XAU", "Gold"},
+	2: {"XAG", "Silver"},
+	3: {"XPT", "Platinum"},
+	4: {"XPD", "Palladium"},
+}
+
+func (n *Normalizer) normalizeMetals(ctx context.Context, raw json.RawMessage) error {
+	normalized, rejected, err := buildNormalizedMetals(raw)
+	if err != nil {
+		return err
+	}
+	if len(normalized) > 0 {
+		err = n.publish(ctx, n.writer, events.NormalizedMetalPricesEvent{Source: events.SourceCBRMetals, Rates: normalized})
+	}
+	return errors.Join(err, n.quarantineRates(ctx, events.SourceCBRMetals, rejected))
+}
+
+// buildNormalizedMetals parses raw CBR metal prices and returns the
+// normalized structs of the valid ones, keyed by ISO code, and the rejected
+// rest. Extracted for unit-testability.
+func buildNormalizedMetals(raw json.RawMessage) ([]events.NormalizedMetalPrice, []rejection, error) {
+	var prices []events.RawMetalPrice
+	if err := json.Unmarshal(raw, &prices); err != nil {
+		return nil, nil, err
+	}
+
+	var rejected []rejection
+	normalized := make([]events.NormalizedMetalPrice, 0, len(prices))
+	for _, p := range prices {
+		metal, date, rej := checkMetal(p)
+		if rej != nil {
+			rejected = append(rejected, *rej)
+			continue
+		}
+		normalized = append(normalized, events.NormalizedMetalPrice{
+			Date:        date,
+			Metal:       metal.code,
+			Name:        metal.name,
+			Buy:         p.Buy,
+			Sell:        p.Sell,
+			SourceURL:   p.SourceURL,
+			CollectedAt: p.CollectedAt,
+		})
+	}
+	return normalized, rejected, nil
+}
@@ -0,0 +1,95 @@
+package normalizer
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/events"
+)
+
+func TestNormalizeMetals_basic(t *testing.T) {
+	collected := time.Date(2026, 4, 15, 9, 0, 0, 0, time.UTC)
+	raw, _ := json.Marshal([]events.RawMetalPrice{
+		{Date: "15.04.2026", Code: 1, Buy: dec("8540.1"), Sell: dec("8540.1"), CollectedAt: collected, SourceURL: "https://www.cbr.ru/scripts/xml_metall.asp"},
+		{Date: "15.04.2026", Code: 4, Buy: dec("2801.05"), Sell: dec("2801.05"), CollectedAt: collected},
+	})
+
+	prices, rejected, err := buildNormalizedMetals(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rejected) != 0 || len(prices) != 2 {
+		t.Fatalf("expected 2 prices and no rejections, got %d and %d", len(prices), len(rejected))
+	}
+	gold := prices[0]
+	if gold.Metal != "XAU" || gold.Name != "Gold" {
+		t.Errorf("expected XAU Gold, got %s %s", gold.Metal, gold.Name)
+	}
+	if want := time.Date(2026, 4, 15, 0, 0, 0, 0, time.UTC); !gold.Date.Equal(want) {
+		t.Errorf("expected date %s, got %s", want, gold.Date)
+	}
+	if gold.Buy.String() != "8540.1" || gold.Sell.String() != "8540.1" {
+		t.Errorf("expected 8540.1, got %s and %s", gold.Buy, gold.Sell)
+	}
+	if gold.SourceURL == "" || !gold.CollectedAt.Equal(collected) {
+		t.Errorf("expected source URL and collection time to pass through, got %+v", gold)
+	}
+	if prices[1].Metal != "XPD" {
+		t.Errorf("expected XPD, got %s", prices[1].Metal)
+	}
+}
+
+func TestCheckMetal(t *testing.T) {
+	valid := events.RawMetalPrice{Date: "15.04.2026", Code: 2, Buy: dec("96.84"), Sell: dec("96.84")}
+
+	tests := []struct {
+		name   string
+		modify func(r *events.RawMetalPrice)
+		rule   string
+	}{
+		{"valid", func(r *events.RawMetalPrice) {}, ""},
+		{"unknown code", func(r *events.RawMetalPrice) { r.Code = 5 }, RuleUnknownCode},
+		{"zero buy", func(r *events.RawMetalPrice) { r.Buy = dec("0") }, RuleNonPositiveValue},
+		{"negative sell", func(r *events.RawMetalPrice) { r.Sell = dec("-1") }, RuleNonPositiveValue},
+		{"bad date", func(r *events.RawMetalPrice) { r.Date = "2026-04-15" }, RuleInvalidDate},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := valid
+			tc.modify(&r)
+			metal, date, rej := checkMetal(r)
+			if tc.rule == "" {
+				if rej != nil {
+					t.Fatalf("unexpected rejection: %s: %s", rej.rule, rej.reason)
+				}
+				if metal.code != "XAG" || date.IsZero() {
+					t.Errorf("expected XAG with a date, got %+v %s", metal, date)
+				}
+				return
+			}
+			if rej == nil || rej.rule != tc.rule {
+				t.Fatalf("expected rule %s, got %+v", tc.rule, rej)
+			}
+		})
+	}
+}
+
+func TestNormalizeMetals_rejectedQuarantined(t *testing.T) {
+	raw, _ := json.Marshal([]events.RawMetalPrice{
+		{Date: "15.04.2026", Code: 1, Buy: dec("8540.1"), Sell: dec("8540.1")},
+		{Date: "15.04.2026", Code: 9, Buy: dec("1"), Sell: dec("1")},
+	})
+	prices, rejected, err := buildNormalizedMetals(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prices) != 1 || len(rejected) != 1 || rejected[0].rule != RuleUnknownCode {
+		t.Fatalf("expected one price and one unknown_code rejection, got %d and %+v", len(prices), rejected)
+	}
+	q := rejected[0].quarantined(events.SourceCBRMetals, time.Now())
+	var back events.RawMetalPrice
+	if err := json.Unmarshal(q.Rate, &back); err != nil || back.Code != 9 {
+		t.Errorf("expected the raw price in the quarantined record, got %s", q.Rate)
+	}
+}
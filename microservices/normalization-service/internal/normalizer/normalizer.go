@@ -3,30 +3,56 @@ package normalizer
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/calendar"
 	"github.com/casualdoto/go-currency-tracker/microservices/shared/events"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/logging"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/metrics"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/tracing"
 	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
 	groupID = "normalization-service"
 )
 
-// Normalizer reads from raw-rates, normalizes, and publishes to normalized-rates.
+var logger = logging.For("normalizer")
+
+// Normalizer reads from raw-rates, validates and normalizes, and publishes
+// to normalized-rates. Rates that fail validation go to quarantined-rates.
 type Normalizer struct {
-	reader      *kafka.Reader
-	writer      *kafka.Writer
-	cbrURL      string
-	httpClient  *http.Client
-	lastUSDRUB  float64 // last successfully fetched USD/RUB rate; used as fallback
+	reader     *kafka.Reader
+	writer     *kafka.Writer
+	quarantine *kafka.Writer
+	cbrURL     string
+	httpClient *http.Client
+	quotes     []string      // quote currencies added to every normalized rate
+	limits     Limits        // day-over-day change limits of the validation
+	lastUSDRUB money.Decimal // last successfully fetched USD/RUB rate; used as fallback
+	lastRates  rubPerUnit    // last successfully fetched CBR sheet; used as fallback
+	nominals   map[string]seenNominal
+}
+
+// seenNominal is the nominal of a currency on the newest CBR sheet accepted
+// and the one on the sheet before it (zero if not seen since the start).
+type seenNominal struct {
+	date    time.Time
+	nominal int
+	before  int
 }
 
-func New(brokers, cbrURL string) *Normalizer {
+// New creates a Normalizer. quotes lists the currencies (besides RUB) that
+// normalized prices are additionally expressed in; see ParseQuoteCurrencies.
+// limits bounds the day-over-day changes accepted; see ParseLimits.
+func New(brokers, cbrURL string, quotes []string, limits Limits) *Normalizer {
 	brokerList := strings.Split(brokers, ",")
 	r := kafka.NewReader(kafka.ReaderConfig{
 		Brokers:  brokerList,
@@ -35,18 +61,23 @@ func New(brokers, cbrURL string) *Normalizer {
 		MinBytes: 1,
 		MaxBytes: 10e6,
 	})
-	w := &kafka.Writer{
-		Addr:         kafka.TCP(brokerList...),
-		Topic:        events.TopicNormalizedRates,
-		Balancer:     &kafka.LeastBytes{},
-		BatchTimeout: 10 * time.Millisecond,
-		RequiredAcks: kafka.RequireOne,
+	writer := func(topic string) *kafka.Writer {
+		return &kafka.Writer{
+			Addr:         kafka.TCP(brokerList...),
+			Topic:        topic,
+			Balancer:     &kafka.LeastBytes{},
+			BatchTimeout: 10 * time.Millisecond,
+			RequiredAcks: kafka.RequireOne,
+		}
 	}
 	return &Normalizer{
 		reader:     r,
-		writer:     w,
+		writer:     writer(events.TopicNormalizedRates),
+		quarantine: writer(events.TopicQuarantinedRates),
 		cbrURL:     cbrURL,
-		httpClient: &http.Client{Timeout: 15 * time.Second},
+		httpClient: &http.Client{Timeout: 15 * time.Second, Transport: metrics.Transport(metrics.SourceCBR, tracing.Transport(nil))},
+		quotes:     quotes,
+		limits:     limits,
 	}
 }
 
@@ -57,8 +88,16 @@ func (n *Normalizer) Run() error {
 		if err != nil {
 			return fmt.Errorf("read message: %w", err)
 		}
-		if err := n.process(ctx, msg.Value); err != nil {
-			log.Printf("normalizer: process error: %v", err)
+		metrics.KafkaConsumed(events.TopicRawRates, groupID, n.reader.Stats().Lag)
+		// The normalized message continues the trace and request of the collector run
+		msgCtx, span := tracing.StartConsumer(logging.ExtractKafka(ctx, msg), groupID, msg)
+		start := time.Now()
+		err = n.process(msgCtx, msg.Value)
+		tracing.End(span, err)
+		if err != nil {
+			logger.ErrorContext(msgCtx, "process failed", "offset", msg.Offset, "error", err)
+		} else {
+			logger.DebugContext(msgCtx, "processed", "offset", msg.Offset, "duration_ms", logging.Millis(time.Since(start)))
 		}
 	}
 }
@@ -79,80 +118,201 @@ func (n *Normalizer) process(ctx context.Context, data []byte) error {
 		return n.normalizeCBR(ctx, evt.Rates)
 	case string(events.SourceBinance):
 		return n.normalizeCrypto(ctx, evt.Rates)
+	case string(events.SourceCBRMetals):
+		return n.normalizeMetals(ctx, evt.Rates)
+	case string(events.SourceCBRIndicators):
+		return n.normalizeIndicators(ctx, evt.Rates)
 	default:
-		log.Printf("normalizer: unknown source %q", evt.Source)
+		logger.WarnContext(ctx, "unknown source", "source", evt.Source)
 	}
 	return nil
 }
 
 func (n *Normalizer) normalizeCBR(ctx context.Context, raw json.RawMessage) error {
-	normalized, err := n.buildNormalizedCBR(raw)
+	normalized, rejected, err := n.buildNormalizedCBR(raw)
 	if err != nil {
 		return err
 	}
-	return n.publish(ctx, events.NormalizedCBRRatesEvent{Source: events.SourceCBR, Rates: normalized})
+	if len(normalized) > 0 {
+		err = n.publish(ctx, n.writer, events.NormalizedCBRRatesEvent{Source: events.SourceCBR, Rates: normalized})
+	}
+	if changes := nominalChanges(normalized); len(changes) > 0 && err == nil {
+		err = n.publish(ctx, n.writer, events.NominalChangesEvent{Source: events.SourceCBRNominal, Rates: changes})
+	}
+	return errors.Join(err, n.quarantineRates(ctx, events.SourceCBR, rejected))
+}
+
+// nominalChanges returns the changes of nominal among normalized rates.
+func nominalChanges(rates []events.NormalizedCBRRate) []events.NominalChange {
+	var changes []events.NominalChange
+	for _, r := range rates {
+		if r.PreviousNominal > 0 {
+			changes = append(changes, events.NominalChange{
+				Date:            r.Date,
+				CurrencyCode:    r.CurrencyCode,
+				CurrencyName:    r.CurrencyName,
+				PreviousNominal: r.PreviousNominal,
+				Nominal:         r.Nominal,
+				UnitValueRUB:    r.UnitValueRUB,
+			})
+		}
+	}
+	return changes
 }
 
-// buildNormalizedCBR parses raw CBR rates and returns normalized structs.
-// Extracted for unit-testability.
-func (n *Normalizer) buildNormalizedCBR(raw json.RawMessage) ([]events.NormalizedCBRRate, error) {
+// buildNormalizedCBR parses raw CBR rates and returns the normalized structs
+// of the valid ones and the rejected rest. A rate on the first sheet seen
+// with a new nominal carries PreviousNominal. Extracted for unit-testability.
+func (n *Normalizer) buildNormalizedCBR(raw json.RawMessage) ([]events.NormalizedCBRRate, []rejection, error) {
 	var rates []events.RawCBRRate
 	if err := json.Unmarshal(raw, &rates); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	normalized := make([]events.NormalizedCBRRate, 0, len(rates))
+	var rejected []rejection
+	valid := make([]events.RawCBRRate, 0, len(rates))
+	dates := make([]time.Time, 0, len(rates))
+	changedFrom := make([]int, 0, len(rates))
 	for _, r := range rates {
-		date, err := time.Parse("2006/01/02 15:04:05", r.Date)
-		if err != nil {
-			date, err = time.Parse("2006-01-02T15:04:05-07:00", r.Date)
-			if err != nil {
-				date = time.Now().Truncate(24 * time.Hour)
+		prevNominal, newer := n.previousNominal(r)
+		date, rej := n.limits.checkCBR(r, prevNominal)
+		if rej != nil {
+			rejected = append(rejected, *rej)
+			continue
+		}
+		from := 0
+		if newer {
+			if n.nominals == nil {
+				n.nominals = make(map[string]seenNominal)
+			}
+			n.nominals[r.CharCode] = seenNominal{date: date, nominal: r.Nominal, before: prevNominal}
+			if prevNominal > 0 && prevNominal != r.Nominal {
+				from = prevNominal
 			}
 		}
+		valid = append(valid, r)
+		dates = append(dates, date)
+		changedFrom = append(changedFrom, from)
+	}
+
+	// The batch is a full CBR sheet, so cross rates come from the valid
+	// rates of the batch itself.
+	sheet := make(rubPerUnit, len(valid))
+	for _, r := range valid {
+		sheet.add(r.CharCode, r.Value, r.Nominal)
+	}
+
+	normalized := make([]events.NormalizedCBRRate, 0, len(valid))
+	for i, r := range valid {
 		normalized = append(normalized, events.NormalizedCBRRate{
-			Date:         date,
-			CurrencyCode: r.CharCode,
-			CurrencyName: r.Name,
-			Nominal:      r.Nominal,
-			ValueRUB:     r.Value,
-			PreviousRUB:  r.Previous,
+			Date:            dates[i],
+			CurrencyCode:    r.CharCode,
+			CurrencyName:    r.Name,
+			Nominal:         r.Nominal,
+			ValueRUB:        r.Value,
+			PreviousRUB:     r.Previous,
+			UnitValueRUB:    unitValue(r),
+			PreviousNominal: changedFrom[i],
+			Quotes:          sheet.quotesFor(r.CharCode, r.Nominal, r.Value, n.quotes),
+			SourceURL:       r.SourceURL,
+			CollectedAt:     r.CollectedAt,
 		})
 	}
-	return normalized, nil
+	return normalized, rejected, nil
+}
+
+// previousNominal returns the nominal of the currency of r on the sheet
+// before r's, or zero when it is not known: since the start, nothing earlier
+// was seen, or r is from an older sheet than the newest one. newer reports
+// whether r is from a sheet newer than any seen.
+func (n *Normalizer) previousNominal(r events.RawCBRRate) (nominal int, newer bool) {
+	date, err := calendar.ParseSheetDate(r.Date)
+	if err != nil {
+		return 0, false
+	}
+	seen, ok := n.nominals[r.CharCode]
+	switch {
+	case !ok:
+		return 0, true
+	case seen.date.Before(date):
+		return seen.nominal, true
+	case seen.date.Equal(date):
+		// The same sheet again, e.g. from the next collector run
+		return seen.before, false
+	}
+	return 0, false
+}
+
+// unitValue is the price of one unit of r: the CBR's VunitRate when the
+// sheet has it, otherwise Value divided by Nominal.
+func unitValue(r events.RawCBRRate) money.Decimal {
+	if r.VunitRate != nil && r.VunitRate.IsPositive() {
+		return *r.VunitRate
+	}
+	return money.PerUnit(r.Value, r.Nominal)
 }
 
 func (n *Normalizer) normalizeCrypto(ctx context.Context, raw json.RawMessage) error {
-	normalized, err := n.buildNormalizedCrypto(raw)
+	normalized, rejected, err := n.buildNormalizedCrypto(ctx, raw)
 	if err != nil {
 		return err
 	}
-	return n.publish(ctx, events.NormalizedCryptoRatesEvent{Source: events.SourceBinance, Rates: normalized})
+	if len(normalized) > 0 {
+		err = n.publish(ctx, n.writer, events.NormalizedCryptoRatesEvent{Source: events.SourceBinance, Rates: normalized})
+	}
+	return errors.Join(err, n.quarantineRates(ctx, events.SourceBinance, rejected))
 }
 
-// buildNormalizedCrypto fetches USD/RUB, calculates PriceRUB and returns
-// normalized structs. Extracted for unit-testability.
-func (n *Normalizer) buildNormalizedCrypto(raw json.RawMessage) ([]events.NormalizedCryptoRate, error) {
-	var rates []events.RawCryptoRate
-	if err := json.Unmarshal(raw, &rates); err != nil {
-		return nil, err
+// buildNormalizedCrypto fetches the CBR sheet, calculates PriceRUB and the
+// configured quotes and returns the normalized structs of the valid rates
+// and the rejected rest. The USD/RUB rate used is recorded on the span in
+// ctx. Extracted for unit-testability.
+func (n *Normalizer) buildNormalizedCrypto(ctx context.Context, raw json.RawMessage) ([]events.NormalizedCryptoRate, []rejection, error) {
+	var all []events.RawCryptoRate
+	if err := json.Unmarshal(raw, &all); err != nil {
+		return nil, nil, err
+	}
+	var rejected []rejection
+	rates := make([]events.RawCryptoRate, 0, len(all))
+	for _, r := range all {
+		if rej := n.limits.checkCrypto(r); rej != nil {
+			rejected = append(rejected, *rej)
+			continue
+		}
+		rates = append(rates, r)
+	}
+	if len(rates) == 0 {
+		return nil, rejected, nil
 	}
 
-	usdRUB, err := n.getUSDRUBRate()
+	sheet, err := n.getCBRRates(ctx)
+	usdRUB, ok := sheet["USD"]
+	if err == nil && !ok {
+		err = fmt.Errorf("USD not found in CBR response")
+	}
 	if err != nil {
-		if n.lastUSDRUB != 0 {
-			log.Printf("normalizer: failed to get USD/RUB rate: %v, using last known rate %.4f", err, n.lastUSDRUB)
-			usdRUB = n.lastUSDRUB
-		} else {
-			log.Printf("normalizer: failed to get USD/RUB rate: %v, no cached rate available, using 1.0", err)
-			usdRUB = 1.0
+		if n.lastUSDRUB.IsZero() {
+			// Without any USD/RUB rate there is no RUB price to publish; the
+			// USD close must not be stored as one
+			logger.WarnContext(ctx, "USD/RUB fetch failed, no cached rate, quarantining batch", "currency", "USD", "error", err)
+			for _, r := range rates {
+				rejected = append(rejected, rejection{rule: RuleMissingFXRate, reason: fmt.Sprintf("no USD/RUB rate: %v", err), rate: r})
+			}
+			return nil, rejected, nil
 		}
+		logger.WarnContext(ctx, "USD/RUB fetch failed, using last known rate", "currency", "USD", "rate", n.lastUSDRUB, "error", err)
+		sheet, usdRUB = n.lastRates, n.lastUSDRUB
 	} else {
 		n.lastUSDRUB = usdRUB
+		n.lastRates = sheet
 	}
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.Float64("fx.usd_rub", usdRUB.Float64()),
+		attribute.Bool("fx.fallback", err != nil))
 
 	normalized := make([]events.NormalizedCryptoRate, 0, len(rates))
 	for _, r := range rates {
+		priceRUB := r.Close.Mul(usdRUB).Round(money.PriceScale)
 		normalized = append(normalized, events.NormalizedCryptoRate{
 			Symbol:    r.Symbol,
 			Timestamp: r.Timestamp,
@@ -161,39 +321,68 @@ func (n *Normalizer) buildNormalizedCrypto(raw json.RawMessage) ([]events.Normal
 			Low:       r.Low,
 			Close:     r.Close,
 			Volume:    r.Volume,
-			PriceRUB:  r.Close * usdRUB,
+			PriceRUB:  priceRUB,
+			Quotes:    sheet.convert(priceRUB, n.quotes),
 		})
 	}
-	return normalized, nil
+	return normalized, rejected, nil
 }
 
 type cbrResp struct {
 	Valute map[string]struct {
-		Value float64 `json:"Value"`
+		Nominal int           `json:"Nominal"`
+		Value   money.Decimal `json:"Value"`
 	} `json:"Valute"`
 }
 
-func (n *Normalizer) getUSDRUBRate() (float64, error) {
-	resp, err := n.httpClient.Get(n.cbrURL + "/daily_json.js")
+// getCBRRates fetches the current CBR sheet as RUB-per-unit rates.
+func (n *Normalizer) getCBRRates(ctx context.Context) (rubPerUnit, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, n.cbrURL+"/daily_json.js", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := n.httpClient.Do(req)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 	defer resp.Body.Close()
 	var data cbrResp
 	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return 0, err
+		return nil, err
+	}
+	sheet := make(rubPerUnit, len(data.Valute))
+	for code, v := range data.Valute {
+		sheet.add(code, v.Value, v.Nominal)
+	}
+	return sheet, nil
+}
+
+// quarantineRates publishes the rejected rates of a batch to the quarantine
+// topic and counts them by rule.
+func (n *Normalizer) quarantineRates(ctx context.Context, source events.SourceType, rejected []rejection) error {
+	if len(rejected) == 0 {
+		return nil
 	}
-	usd, ok := data.Valute["USD"]
-	if !ok {
-		return 0, fmt.Errorf("USD not found in CBR response")
+	now := time.Now().UTC()
+	evt := events.QuarantinedRatesEvent{Source: source, Rates: make([]events.QuarantinedRate, 0, len(rejected))}
+	for _, r := range rejected {
+		metrics.RateRejected(string(source), r.rule)
+		logger.WarnContext(ctx, "rate quarantined", "source", source, "rule", r.rule, "reason", r.reason)
+		evt.Rates = append(evt.Rates, r.quarantined(source, now))
 	}
-	return usd.Value, nil
+	return n.publish(ctx, n.quarantine, evt)
 }
 
-func (n *Normalizer) publish(ctx context.Context, v any) error {
+func (n *Normalizer) publish(ctx context.Context, w *kafka.Writer, v any) error {
 	data, err := json.Marshal(v)
 	if err != nil {
 		return err
 	}
-	return n.writer.WriteMessages(ctx, kafka.Message{Value: data})
+	msg := kafka.Message{Value: data}
+	logging.InjectKafka(ctx, &msg)
+	ctx, span := tracing.StartProducer(ctx, w.Topic, &msg)
+	err = w.WriteMessages(ctx, msg)
+	tracing.End(span, err)
+	metrics.KafkaProduced(w.Topic, err)
+	return err
 }
@@ -1,6 +1,7 @@
 package normalizer
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -8,6 +9,7 @@ import (
 	"time"
 
 	"github.com/casualdoto/go-currency-tracker/microservices/shared/events"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
 )
 
 // ─── helpers ──────────────────────────────────────────────────────────────────
@@ -19,6 +21,10 @@ func newTestNormalizer(cbrURL string) *Normalizer {
 	}
 }
 
+func dec(s string) money.Decimal { return money.MustParse(s) }
+
+func decp(s string) *money.Decimal { d := dec(s); return &d }
+
 // stubCBRServer returns an httptest.Server that responds with a CBR JSON
 // containing a single USD entry with the given value.
 func stubCBRServer(t *testing.T, usdValue float64) *httptest.Server {
@@ -40,25 +46,26 @@ func TestNormalizeCBR_basic(t *testing.T) {
 
 	rates := []events.RawCBRRate{
 		{
-			Date:     "2024-01-15T00:00:00+03:00",
-			CharCode: "USD",
-			Nominal:  1,
-			Name:     "Доллар США",
-			Value:    90.5,
-			Previous: 89.0,
+			Date:      "2024-01-15T00:00:00+03:00",
+			CharCode:  "USD",
+			Nominal:   1,
+			Name:      "Доллар США",
+			Value:     dec("90.5"),
+			Previous:  dec("89.0"),
+			SourceURL: "https://www.cbr-xml-daily.ru/daily_json.js",
 		},
 		{
 			Date:     "2024-01-15T00:00:00+03:00",
 			CharCode: "EUR",
 			Nominal:  1,
 			Name:     "Евро",
-			Value:    98.2,
-			Previous: 97.0,
+			Value:    dec("98.2"),
+			Previous: dec("97.0"),
 		},
 	}
 
 	raw, _ := json.Marshal(rates)
-	result, err := n.buildNormalizedCBR(raw)
+	result, _, err := n.buildNormalizedCBR(raw)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -71,11 +78,14 @@ func TestNormalizeCBR_basic(t *testing.T) {
 	if usd.CurrencyCode != "USD" {
 		t.Errorf("expected USD, got %s", usd.CurrencyCode)
 	}
-	if usd.ValueRUB != 90.5 {
-		t.Errorf("expected ValueRUB=90.5, got %f", usd.ValueRUB)
+	if !usd.ValueRUB.Equal(dec("90.5")) {
+		t.Errorf("expected ValueRUB=90.5, got %s", usd.ValueRUB)
+	}
+	if !usd.PreviousRUB.Equal(dec("89.0")) {
+		t.Errorf("expected PreviousRUB=89.0, got %s", usd.PreviousRUB)
 	}
-	if usd.PreviousRUB != 89.0 {
-		t.Errorf("expected PreviousRUB=89.0, got %f", usd.PreviousRUB)
+	if usd.SourceURL != "https://www.cbr-xml-daily.ru/daily_json.js" {
+		t.Errorf("SourceURL should be passed on, got %q", usd.SourceURL)
 	}
 }
 
@@ -83,28 +93,34 @@ func TestNormalizeCBR_dateFormats(t *testing.T) {
 	n := newTestNormalizer("")
 
 	tests := []struct {
-		name     string
-		dateStr  string
-		wantZero bool
+		name         string
+		dateStr      string
+		wantRejected bool
 	}{
 		{"RFC3339", "2024-03-01T00:00:00+03:00", false},
 		{"slash format", "2024/03/01 00:00:00", false},
-		{"invalid falls back to today", "not-a-date", true},
+		{"invalid is quarantined", "not-a-date", true},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			rates := []events.RawCBRRate{{Date: tc.dateStr, CharCode: "USD", Nominal: 1, Name: "USD", Value: 90}}
+			rates := []events.RawCBRRate{{Date: tc.dateStr, CharCode: "USD", Nominal: 1, Name: "USD", Value: dec("90")}}
 			raw, _ := json.Marshal(rates)
-			result, err := n.buildNormalizedCBR(raw)
+			result, rejected, err := n.buildNormalizedCBR(raw)
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
+			if tc.wantRejected {
+				if len(result) != 0 || len(rejected) != 1 || rejected[0].rule != RuleInvalidDate {
+					t.Fatalf("expected the rate to be rejected as %s, got %v / %v", RuleInvalidDate, result, rejected)
+				}
+				return
+			}
 			if len(result) != 1 {
 				t.Fatalf("expected 1 result")
 			}
-			if result[0].Date.IsZero() {
-				t.Error("date should not be zero")
+			if !result[0].Date.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, result[0].Date.Location())) {
+				t.Errorf("date = %v, want 2024-03-01", result[0].Date)
 			}
 		})
 	}
@@ -119,12 +135,12 @@ func TestNormalizeCrypto_priceRUB(t *testing.T) {
 	n := newTestNormalizer(srv.URL)
 
 	rates := []events.RawCryptoRate{
-		{Symbol: "BTCUSDT", Timestamp: time.Now(), Open: 40000, High: 42000, Low: 39000, Close: 41000, Volume: 1.5},
-		{Symbol: "ETHUSDT", Timestamp: time.Now(), Open: 2000, High: 2100, Low: 1950, Close: 2050, Volume: 10},
+		{Symbol: "BTCUSDT", Timestamp: time.Now(), Open: dec("40000"), High: dec("42000"), Low: dec("39000"), Close: dec("41000"), Volume: dec("1.5")},
+		{Symbol: "ETHUSDT", Timestamp: time.Now(), Open: dec("2000"), High: dec("2100"), Low: dec("1950"), Close: dec("2050"), Volume: dec("10")},
 	}
 	raw, _ := json.Marshal(rates)
 
-	result, err := n.buildNormalizedCrypto(raw)
+	result, _, err := n.buildNormalizedCrypto(context.Background(), raw)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -133,50 +149,55 @@ func TestNormalizeCrypto_priceRUB(t *testing.T) {
 	}
 
 	btc := result[0]
-	expectedRUB := 41000.0 * 90.0
-	if btc.PriceRUB != expectedRUB {
-		t.Errorf("BTC PriceRUB: expected %f, got %f", expectedRUB, btc.PriceRUB)
+	expectedRUB := dec("3690000")
+	if !btc.PriceRUB.Equal(expectedRUB) {
+		t.Errorf("BTC PriceRUB: expected %s, got %s", expectedRUB, btc.PriceRUB)
 	}
 	if btc.Symbol != "BTCUSDT" {
 		t.Errorf("expected BTCUSDT, got %s", btc.Symbol)
 	}
 }
 
-func TestNormalizeCrypto_cbrUnavailable_noCache_fallbackTo1(t *testing.T) {
-	// No cached rate and CBR is unreachable — should fall back to 1.0.
+func TestNormalizeCrypto_cbrUnavailable_noCache_quarantined(t *testing.T) {
+	// No cached rate and CBR is unreachable — the USD close must not be
+	// published as the RUB price, so the batch is quarantined.
 	n := newTestNormalizer("http://127.0.0.1:1")
 
 	rates := []events.RawCryptoRate{
-		{Symbol: "BTCUSDT", Timestamp: time.Now(), Close: 50000},
+		{Symbol: "BTCUSDT", Timestamp: time.Now(), Open: dec("50000"), High: dec("50000"), Low: dec("50000"), Close: dec("50000")},
+		{Symbol: "ETHUSDT", Timestamp: time.Now(), Open: dec("2000"), High: dec("2000"), Low: dec("2000"), Close: dec("2000")},
 	}
 	raw, _ := json.Marshal(rates)
 
-	result, err := n.buildNormalizedCrypto(raw)
+	result, rejected, err := n.buildNormalizedCrypto(context.Background(), raw)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if result[0].PriceRUB != 50000.0 {
-		t.Errorf("expected fallback PriceRUB=50000, got %f", result[0].PriceRUB)
+	if len(result) != 0 {
+		t.Errorf("expected nothing normalized, got %+v", result)
+	}
+	if len(rejected) != 2 || rejected[0].rule != RuleMissingFXRate || rejected[1].rule != RuleMissingFXRate {
+		t.Errorf("expected both rates rejected with %s, got %+v", RuleMissingFXRate, rejected)
 	}
 }
 
 func TestNormalizeCrypto_cbrUnavailable_usesLastKnownRate(t *testing.T) {
 	// CBR is unreachable but a cached rate exists — should use the cached value.
 	n := newTestNormalizer("http://127.0.0.1:1")
-	n.lastUSDRUB = 92.5
+	n.lastUSDRUB = dec("92.5")
 
 	rates := []events.RawCryptoRate{
-		{Symbol: "BTCUSDT", Timestamp: time.Now(), Close: 50000},
+		{Symbol: "BTCUSDT", Timestamp: time.Now(), Open: dec("50000"), High: dec("50000"), Low: dec("50000"), Close: dec("50000")},
 	}
 	raw, _ := json.Marshal(rates)
 
-	result, err := n.buildNormalizedCrypto(raw)
+	result, _, err := n.buildNormalizedCrypto(context.Background(), raw)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	expected := 50000.0 * 92.5
-	if result[0].PriceRUB != expected {
-		t.Errorf("expected PriceRUB=%f (cached rate), got %f", expected, result[0].PriceRUB)
+	expected := dec("4625000")
+	if !result[0].PriceRUB.Equal(expected) {
+		t.Errorf("expected PriceRUB=%s (cached rate), got %s", expected, result[0].PriceRUB)
 	}
 }
 
@@ -188,43 +209,171 @@ func TestNormalizeCrypto_successUpdatesCache(t *testing.T) {
 	n := newTestNormalizer(srv.URL)
 
 	rates := []events.RawCryptoRate{
-		{Symbol: "BTCUSDT", Timestamp: time.Now(), Close: 1000},
+		{Symbol: "BTCUSDT", Timestamp: time.Now(), Open: dec("1000"), High: dec("1000"), Low: dec("1000"), Close: dec("1000")},
 	}
 	raw, _ := json.Marshal(rates)
 
-	if _, err := n.buildNormalizedCrypto(raw); err != nil {
+	if _, _, err := n.buildNormalizedCrypto(context.Background(), raw); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if n.lastUSDRUB != 95.0 {
-		t.Errorf("expected lastUSDRUB=95.0, got %f", n.lastUSDRUB)
+	if !n.lastUSDRUB.Equal(dec("95")) {
+		t.Errorf("expected lastUSDRUB=95.0, got %s", n.lastUSDRUB)
 	}
 }
 
-// ─── getUSDRUBRate ────────────────────────────────────────────────────────────
+// ─── getCBRRates ──────────────────────────────────────────────────────────────
 
-func TestGetUSDRUBRate(t *testing.T) {
+func TestGetCBRRates(t *testing.T) {
 	srv := stubCBRServer(t, 87.5)
 	defer srv.Close()
 
 	n := newTestNormalizer(srv.URL)
-	rate, err := n.getUSDRUBRate()
+	sheet, err := n.getCBRRates(context.Background())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if rate != 87.5 {
-		t.Errorf("expected 87.5, got %f", rate)
+	if !sheet["USD"].Equal(dec("87.5")) {
+		t.Errorf("expected 87.5, got %s", sheet["USD"])
 	}
 }
 
-func TestGetUSDRUBRate_serverError(t *testing.T) {
+func TestGetCBRRates_serverError(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
 	}))
 	defer srv.Close()
 
 	n := newTestNormalizer(srv.URL)
-	_, err := n.getUSDRUBRate()
+	_, err := n.getCBRRates(context.Background())
 	if err == nil {
 		t.Error("expected error for invalid JSON response")
 	}
 }
+
+// ─── quotes ───────────────────────────────────────────────────────────────────
+
+func TestParseQuoteCurrencies(t *testing.T) {
+	got := ParseQuoteCurrencies(" rub, USD,,eur,USD ")
+	want := []string{"RUB", "USD", "EUR"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("index %d: expected %s, got %s", i, want[i], got[i])
+		}
+	}
+}
+
+func TestNormalizeCBR_quotesRespectNominal(t *testing.T) {
+	n := newTestNormalizer("")
+	n.quotes = []string{"RUB", "USD", "JPY"}
+
+	rates := []events.RawCBRRate{
+		{Date: "2024-01-15T00:00:00+03:00", CharCode: "USD", Nominal: 1, Value: dec("90")},
+		{Date: "2024-01-15T00:00:00+03:00", CharCode: "JPY", Nominal: 100, Value: dec("60")},
+	}
+	raw, _ := json.Marshal(rates)
+	result, _, err := n.buildNormalizedCBR(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	usd, jpy := result[0], result[1]
+	// 1 JPY = 0.6 RUB, so 1 USD = 150 JPY.
+	if got := usd.Quotes["JPY"]; got.String() != "150" {
+		t.Errorf("USD in JPY: expected 150, got %s", got)
+	}
+	if got := usd.Quotes["USD"]; got.String() != "1" {
+		t.Errorf("USD in USD: expected 1, got %s", got)
+	}
+	// 100 JPY = 60 RUB = 0.6667 USD, rounded to money.PriceScale places.
+	if got := jpy.Quotes["USD"]; got.String() != "0.66666667" {
+		t.Errorf("100 JPY in USD: expected 0.66666667, got %s", got)
+	}
+	if got := jpy.Quotes["JPY"]; got.String() != "100" {
+		t.Errorf("JPY in JPY: expected nominal 100, got %s", got)
+	}
+	if got := jpy.Quotes["RUB"]; got.String() != "60" {
+		t.Errorf("JPY in RUB: expected 60, got %s", got)
+	}
+}
+
+func TestNormalizeCBR_unitValue(t *testing.T) {
+	n := newTestNormalizer("")
+
+	rates := []events.RawCBRRate{
+		{Date: "2024-01-15T00:00:00+03:00", CharCode: "JPY", Nominal: 100, Value: dec("60.1234")},
+		{Date: "2024-01-15T00:00:00+03:00", CharCode: "VND", Nominal: 10000, Value: dec("35.1234"), VunitRate: decp("0.00351234")},
+	}
+	raw, _ := json.Marshal(rates)
+	result, _, err := n.buildNormalizedCBR(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Without VunitRate the nominal is divided out
+	if got := result[0].UnitValueRUB; got.String() != "0.601234" {
+		t.Errorf("JPY unit value: expected 0.601234, got %s", got)
+	}
+	if got := result[1].UnitValueRUB; got.String() != "0.00351234" {
+		t.Errorf("VND unit value: expected VunitRate 0.00351234, got %s", got)
+	}
+}
+
+func TestNormalizeCBR_missingQuoteOmitted(t *testing.T) {
+	n := newTestNormalizer("")
+	n.quotes = []string{"RUB", "CNY"}
+
+	rates := []events.RawCBRRate{{Date: "2024-01-15T00:00:00+03:00", CharCode: "USD", Nominal: 1, Value: dec("90")}}
+	raw, _ := json.Marshal(rates)
+	result, _, _ := n.buildNormalizedCBR(raw)
+
+	if _, ok := result[0].Quotes["CNY"]; ok {
+		t.Error("CNY is not in the sheet and should be omitted")
+	}
+	if got := result[0].Quotes["RUB"]; got.String() != "90" {
+		t.Errorf("expected RUB quote 90, got %s", got)
+	}
+}
+
+func TestNormalizeCrypto_quotes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"Valute": map[string]any{
+				"USD": map[string]any{"Nominal": 1, "Value": 90.0},
+				"EUR": map[string]any{"Nominal": 1, "Value": 100.0},
+				"CNY": map[string]any{"Nominal": 10, "Value": 125.0},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	n := newTestNormalizer(srv.URL)
+	n.quotes = []string{"RUB", "USD", "EUR", "CNY"}
+
+	raw, _ := json.Marshal([]events.RawCryptoRate{{Symbol: "BTCUSDT", Timestamp: time.Now(), Open: dec("1000"), High: dec("1000"), Low: dec("1000"), Close: dec("1000")}})
+	result, _, err := n.buildNormalizedCrypto(context.Background(), raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	q := result[0].Quotes
+	want := map[string]string{"RUB": "90000", "USD": "1000", "EUR": "900", "CNY": "7200"}
+	for code, v := range want {
+		if q[code].String() != v {
+			t.Errorf("%s: expected %s, got %s", code, v, q[code])
+		}
+	}
+}
+
+func TestNormalizeCrypto_noSheet_noQuotes(t *testing.T) {
+	n := newTestNormalizer("http://127.0.0.1:1")
+	n.quotes = []string{"RUB", "USD"}
+	n.lastUSDRUB = dec("90")
+
+	raw, _ := json.Marshal([]events.RawCryptoRate{{Symbol: "BTCUSDT", Timestamp: time.Now(), Open: dec("1000"), High: dec("1000"), Low: dec("1000"), Close: dec("1000")}})
+	result, _, _ := n.buildNormalizedCrypto(context.Background(), raw)
+	if result[0].Quotes != nil {
+		t.Errorf("expected no quotes without a CBR sheet, got %v", result[0].Quotes)
+	}
+}
@@ -0,0 +1,70 @@
+package normalizer
+
+import (
+	"strings"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/events"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+)
+
+// DefaultQuoteCurrencies is used when QUOTE_CURRENCIES is not set.
+const DefaultQuoteCurrencies = "RUB,USD,EUR,CNY"
+
+// ParseQuoteCurrencies parses a comma-separated list of ISO codes such as
+// "RUB,USD,EUR". Codes are upper-cased, blanks and duplicates are dropped.
+func ParseQuoteCurrencies(s string) []string {
+	var quotes []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ",") {
+		code := strings.ToUpper(strings.TrimSpace(part))
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+		quotes = append(quotes, code)
+	}
+	return quotes
+}
+
+// rubPerUnit maps a currency code to the RUB price of a single unit.
+// CBR quotes some currencies per 10 or 100 units (JPY, KZT, ...), so the
+// nominal is divided out before any cross rate is computed.
+type rubPerUnit map[string]money.Decimal
+
+func (s rubPerUnit) add(code string, value money.Decimal, nominal int) {
+	if !value.IsPositive() {
+		return
+	}
+	s[code] = money.PerUnit(value, nominal)
+}
+
+// convert expresses a RUB amount in every requested quote currency, to
+// money.PriceScale places. Quotes missing from the sheet are omitted; nil
+// is returned when the sheet is empty so consumers can tell "no cross
+// rates" from "zero".
+func (s rubPerUnit) convert(amountRUB money.Decimal, quotes []string) map[string]money.Decimal {
+	if len(s) == 0 || len(quotes) == 0 {
+		return nil
+	}
+	out := make(map[string]money.Decimal, len(quotes))
+	for _, q := range quotes {
+		if q == events.QuoteRUB {
+			out[q] = amountRUB.Round(money.PriceScale)
+			continue
+		}
+		if perUnit, ok := s[q]; ok {
+			out[q] = amountRUB.Div(perUnit, money.PriceScale)
+		}
+	}
+	return out
+}
+
+// quotesFor returns the price of nominal units of code in every quote
+// currency. A currency quoted in itself is simply its nominal.
+func (s rubPerUnit) quotesFor(code string, nominal int, valueRUB money.Decimal, quotes []string) map[string]money.Decimal {
+	out := s.convert(valueRUB, quotes)
+	if _, ok := out[code]; ok && nominal > 0 {
+		out[code] = money.NewFromInt(int64(nominal))
+	}
+	return out
+}
@@ -0,0 +1,215 @@
+package normalizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/calendar"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/events"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+)
+
+// Validation rules. A rejected rate is quarantined with its rule, which also
+// labels the rates_rejected_total metric.
+const (
+	RuleNonPositiveValue   = "non_positive_value"
+	RuleNonPositiveNominal = "non_positive_nominal"
+	RuleInvalidDate        = "invalid_date"
+	RuleUnknownCode        = "unknown_code"
+	RuleJump               = "jump"
+	RuleMissingFXRate      = "missing_fx_rate"
+)
+
+// Default day-over-day change limits in percent, used when
+// MAX_CBR_CHANGE_PCT and MAX_CRYPTO_CHANGE_PCT are not set.
+const (
+	DefaultMaxCBRChangePct    = "25"
+	DefaultMaxCryptoChangePct = "50"
+)
+
+// Limits are the largest day-over-day changes accepted, as fractions
+// (0.25 = 25%). Zero disables the check.
+type Limits struct {
+	CBRChange    float64
+	CryptoChange float64
+}
+
+// ParseLimits parses the CBR and crypto limits given in percent.
+func ParseLimits(cbrPct, cryptoPct string) (Limits, error) {
+	cbr, err := parsePct(cbrPct)
+	if err != nil {
+		return Limits{}, fmt.Errorf("CBR change limit: %w", err)
+	}
+	crypto, err := parsePct(cryptoPct)
+	if err != nil {
+		return Limits{}, fmt.Errorf("crypto change limit: %w", err)
+	}
+	return Limits{CBRChange: cbr, CryptoChange: crypto}, nil
+}
+
+func parsePct(s string) (float64, error) {
+	pct, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || pct < 0 || math.IsInf(pct, 0) || math.IsNaN(pct) {
+		return 0, fmt.Errorf("%q is not a non-negative percentage", s)
+	}
+	return pct / 100, nil
+}
+
+// rejection is a rate that failed validation, with the raw record as the
+// collector sent it.
+type rejection struct {
+	rule   string
+	reason string
+	rate   any
+}
+
+func (r rejection) quarantined(source events.SourceType, at time.Time) events.QuarantinedRate {
+	raw, _ := json.Marshal(r.rate)
+	return events.QuarantinedRate{Source: source, Rule: r.rule, Reason: r.reason, Rate: raw, QuarantinedAt: at}
+}
+
+// checkCBR validates a CBR rate and returns the Moscow day it is dated. The
+// day-over-day change is measured against Previous, the rate of the
+// preceding sheet. Previous is quoted for that sheet's nominal, so when
+// prevNominal, the nominal of the preceding sheet, differs from Nominal the
+// change is measured per unit; zero means it is not known.
+func (l Limits) checkCBR(r events.RawCBRRate, prevNominal int) (time.Time, *rejection) {
+	reject := func(rule, format string, args ...any) (time.Time, *rejection) {
+		return time.Time{}, &rejection{rule: rule, reason: fmt.Sprintf(format, args...), rate: r}
+	}
+	if !isoCurrencies[r.CharCode] {
+		return reject(RuleUnknownCode, "%q is not an ISO 4217 currency code", r.CharCode)
+	}
+	if r.Nominal <= 0 {
+		return reject(RuleNonPositiveNominal, "nominal %d", r.Nominal)
+	}
+	if !r.Value.IsPositive() {
+		return reject(RuleNonPositiveValue, "value %s", r.Value)
+	}
+	date, err := calendar.ParseSheetDate(r.Date)
+	if err != nil {
+		return reject(RuleInvalidDate, "date %q", r.Date)
+	}
+	prev, cur := r.Previous, r.Value
+	if prevNominal > 0 && prevNominal != r.Nominal {
+		prev, cur = money.PerUnit(r.Previous, prevNominal), unitValue(r)
+	}
+	if change := relChange(prev, cur); l.CBRChange > 0 && prev.IsPositive() && change > l.CBRChange {
+		return reject(RuleJump, "changed %.1f%% from %s to %s, limit %.1f%%", change*100, prev, cur, l.CBRChange*100)
+	}
+	return date, nil
+}
+
+// metalDateLayout is the form of the Date of a CBR metal price.
+const metalDateLayout = "02.01.2006"
+
+// checkMetal validates a CBR metal price and returns the metal it is of and
+// the day it is dated. The CBR publishes no previous price with it, so
+// there is no day-over-day check.
+func checkMetal(r events.RawMetalPrice) (cbrMetal, time.Time, *rejection) {
+	reject := func(rule, format string, args ...any) (cbrMetal, time.Time, *rejection) {
+		return cbrMetal{}, time.Time{}, &rejection{rule: rule, reason: fmt.Sprintf(format, args...), rate: r}
+	}
+	metal, ok := cbrMetals[r.Code]
+	if !ok {
+		return reject(RuleUnknownCode, "%d is not a CBR metal code", r.Code)
+	}
+	if !r.Buy.IsPositive() {
+		return reject(RuleNonPositiveValue, "buy %s", r.Buy)
+	}
+	if !r.Sell.IsPositive() {
+		return reject(RuleNonPositiveValue, "sell %s", r.Sell)
+	}
+	date, err := time.Parse(metalDateLayout, r.Date)
+	if err != nil {
+		return reject(RuleInvalidDate, "date %q", r.Date)
+	}
+	return metal, calendar.Date(date), nil
+}
+
+// checkIndicator validates a CBR indicator value and returns the name of
+// the indicator and the Moscow day the value took effect. A rate moves in
+// steps of its own, so there is no day-over-day check either.
+func checkIndicator(r events.RawIndicatorRate) (string, time.Time, *rejection) {
+	reject := func(rule, format string, args ...any) (string, time.Time, *rejection) {
+		return "", time.Time{}, &rejection{rule: rule, reason: fmt.Sprintf(format, args...), rate: r}
+	}
+	name, ok := cbrIndicators[r.Indicator]
+	if !ok {
+		return reject(RuleUnknownCode, "%q is not a CBR indicator", r.Indicator)
+	}
+	if !r.Value.IsPositive() {
+		return reject(RuleNonPositiveValue, "value %s", r.Value)
+	}
+	date, err := calendar.ParseSheetDate(r.Date)
+	if err != nil {
+		return reject(RuleInvalidDate, "date %q", r.Date)
+	}
+	return name, date, nil
+}
+
+// cryptoSymbol matches the USDT pairs the normalizer converts to RUB via
+// USD/RUB.
+var cryptoSymbol = regexp.MustCompile(`^[A-Z0-9]{2,}USDT$`)
+
+// checkCrypto validates a 24-hour Binance ticker. Its Open is the price 24
+// hours before Close, so the day-over-day change is Close against Open.
+func (l Limits) checkCrypto(r events.RawCryptoRate) *rejection {
+	reject := func(rule, format string, args ...any) *rejection {
+		return &rejection{rule: rule, reason: fmt.Sprintf(format, args...), rate: r}
+	}
+	if !cryptoSymbol.MatchString(r.Symbol) {
+		return reject(RuleUnknownCode, "%q is not a USDT pair", r.Symbol)
+	}
+	for _, p := range []struct {
+		name  string
+		value money.Decimal
+	}{{"open", r.Open}, {"high", r.High}, {"low", r.Low}, {"close", r.Close}} {
+		if !p.value.IsPositive() {
+			return reject(RuleNonPositiveValue, "%s %s", p.name, p.value)
+		}
+	}
+	if r.Volume.IsNegative() {
+		return reject(RuleNonPositiveValue, "volume %s", r.Volume)
+	}
+	if r.Timestamp.IsZero() {
+		return reject(RuleInvalidDate, "no timestamp")
+	}
+	if change := relChange(r.Open, r.Close); l.CryptoChange > 0 && change > l.CryptoChange {
+		return reject(RuleJump, "changed %.1f%% from %s to %s in 24h, limit %.1f%%", change*100, r.Open, r.Close, l.CryptoChange*100)
+	}
+	return nil
+}
+
+// relChange is the absolute change from prev to cur relative to prev.
+func relChange(prev, cur money.Decimal) float64 {
+	if !prev.IsPositive() {
+		return 0
+	}
+	return cur.Sub(prev).Abs().Div(prev, money.PriceScale).Float64()
+}
+
+// isoCurrencies holds the active ISO 4217 codes, including the funds,
+// precious metals and the SDR (XDR) the CBR publishes.
+var isoCurrencies = func() map[string]bool {
+	codes := strings.Fields(`
+		AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BHD BIF BMD BND BOB BOV
+		BRL BSD BTN BWP BYN BZD CAD CDF CHE CHF CHW CLF CLP CNY COP COU CRC CUC CUP CVE
+		CZK DJF DKK DOP DZD EGP ERN ETB EUR FJD FKP GBP GEL GHS GIP GMD GNF GTQ GYD HKD
+		HNL HTG HUF IDR ILS INR IQD IRR ISK JMD JOD JPY KES KGS KHR KMF KPW KRW KWD KYD
+		KZT LAK LBP LKR LRD LSL LYD MAD MDL MGA MKD MMK MNT MOP MRU MUR MVR MWK MXN MXV
+		MYR MZN NAD NGN NIO NOK NPR NZD OMR PAB PEN PGK PHP PKR PLN PYG QAR RON RSD RUB
+		RWF SAR SBD SCR SDG SEK SGD SHP SLE SLL SOS SRD SSP STN SVC SYP SZL THB TJS TMT
+		TND TOP TRY TTD TWD TZS UAH UGX USD USN UYI UYU UYW UZS VED VES VND VUV WST XAF
+		XAG XAU XBA XBB XBC XBD XCD XCG XDR XOF XPD XPF XPT XSU XUA YER ZAR ZMW ZWG ZWL`)
+	m := make(map[string]bool, len(codes))
+	for _, c := range codes {
+		m[c] = true
+	}
+	return m
+}()
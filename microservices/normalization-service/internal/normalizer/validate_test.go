@@ -0,0 +1,222 @@
+package normalizer
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/events"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+)
+
+func TestParseLimits(t *testing.T) {
+	l, err := ParseLimits("25", " 12.5 ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if l.CBRChange != 0.25 || l.CryptoChange != 0.125 {
+		t.Errorf("got %+v", l)
+	}
+	if l, err := ParseLimits("0", "0"); err != nil || l != (Limits{}) {
+		t.Errorf("zero limits: got %+v, %v", l, err)
+	}
+	for _, bad := range [][2]string{{"", "50"}, {"25", "-1"}, {"abc", "50"}, {"25", "Inf"}} {
+		if _, err := ParseLimits(bad[0], bad[1]); err == nil {
+			t.Errorf("ParseLimits(%q, %q): expected error", bad[0], bad[1])
+		}
+	}
+}
+
+func TestCheckCBR(t *testing.T) {
+	l := Limits{CBRChange: 0.25}
+	valid := events.RawCBRRate{Date: "2024-01-15T00:00:00+03:00", CharCode: "USD", Nominal: 1, Value: dec("90"), Previous: dec("89")}
+
+	tests := []struct {
+		name   string
+		modify func(r *events.RawCBRRate)
+		rule   string
+	}{
+		{"valid", func(r *events.RawCBRRate) {}, ""},
+		{"unknown code", func(r *events.RawCBRRate) { r.CharCode = "XYZ" }, RuleUnknownCode},
+		{"zero nominal", func(r *events.RawCBRRate) { r.Nominal = 0 }, RuleNonPositiveNominal},
+		{"zero value", func(r *events.RawCBRRate) { r.Value = dec("0") }, RuleNonPositiveValue},
+		{"negative value", func(r *events.RawCBRRate) { r.Value = dec("-1") }, RuleNonPositiveValue},
+		{"bad date", func(r *events.RawCBRRate) { r.Date = "15.01.2024" }, RuleInvalidDate},
+		{"jump", func(r *events.RawCBRRate) { r.Value = dec("120") }, RuleJump},
+		{"drop", func(r *events.RawCBRRate) { r.Value = dec("60") }, RuleJump},
+		{"no previous", func(r *events.RawCBRRate) { r.Previous, r.Value = money.Zero, dec("120") }, ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := valid
+			tc.modify(&r)
+			date, rej := l.checkCBR(r, 0)
+			if tc.rule == "" {
+				if rej != nil {
+					t.Fatalf("unexpected rejection: %s: %s", rej.rule, rej.reason)
+				}
+				if date.IsZero() {
+					t.Error("date should not be zero")
+				}
+				return
+			}
+			if rej == nil || rej.rule != tc.rule {
+				t.Fatalf("expected rule %s, got %+v", tc.rule, rej)
+			}
+		})
+	}
+
+	r := valid
+	r.Value = dec("120")
+	if _, rej := (Limits{}).checkCBR(r, 0); rej != nil {
+		t.Errorf("zero limit should disable the jump check, got %s", rej.rule)
+	}
+}
+
+func TestCheckCBR_nominalChange(t *testing.T) {
+	l := Limits{CBRChange: 0.25}
+	// KZT moved from 100 to 1000 units: Previous is the price of 100 tenge
+	kzt := events.RawCBRRate{Date: "2024-07-02T00:00:00+03:00", CharCode: "KZT", Nominal: 1000, Value: dec("195.3"), Previous: dec("19.4")}
+	if _, rej := l.checkCBR(kzt, 100); rej != nil {
+		t.Errorf("a nominal change must pass, got %s: %s", rej.rule, rej.reason)
+	}
+	kzt.VunitRate = decp("0.1953")
+	if _, rej := l.checkCBR(kzt, 100); rej != nil {
+		t.Errorf("a nominal change with VunitRate must pass, got %s: %s", rej.rule, rej.reason)
+	}
+	// Without the previous nominal, or with the same one, the raw values count
+	if _, rej := l.checkCBR(kzt, 0); rej == nil || rej.rule != RuleJump {
+		t.Errorf("expected %s without the previous nominal, got %+v", RuleJump, rej)
+	}
+	if _, rej := l.checkCBR(kzt, 1000); rej == nil || rej.rule != RuleJump {
+		t.Errorf("expected %s for a tenfold value at the same nominal, got %+v", RuleJump, rej)
+	}
+	// A real jump across a change of nominal is still caught
+	kzt.VunitRate = nil
+	kzt.Value = dec("300")
+	if _, rej := l.checkCBR(kzt, 100); rej == nil || rej.rule != RuleJump {
+		t.Errorf("expected %s per unit, got %+v", RuleJump, rej)
+	}
+}
+
+func TestNormalizeCBR_nominalChange(t *testing.T) {
+	n := newTestNormalizer("")
+	n.limits = Limits{CBRChange: 0.25}
+	build := func(rates ...events.RawCBRRate) []events.NormalizedCBRRate {
+		t.Helper()
+		raw, _ := json.Marshal(rates)
+		result, rejected, err := n.buildNormalizedCBR(raw)
+		if err != nil || len(rejected) != 0 {
+			t.Fatalf("unexpected error %v or rejections %+v", err, rejected)
+		}
+		return result
+	}
+
+	build(events.RawCBRRate{Date: "2024-07-01T00:00:00+03:00", CharCode: "KZT", Nominal: 100, Value: dec("19.4"), Previous: dec("19.3")})
+	changed := events.RawCBRRate{Date: "2024-07-02T00:00:00+03:00", CharCode: "KZT", Name: "Tenge", Nominal: 1000, Value: dec("195.3"), Previous: dec("19.4")}
+	result := build(changed)
+	if len(result) != 1 || result[0].PreviousNominal != 100 {
+		t.Fatalf("expected the change from 100 to be marked, got %+v", result)
+	}
+	changes := nominalChanges(result)
+	if len(changes) != 1 || changes[0].CurrencyCode != "KZT" || changes[0].PreviousNominal != 100 ||
+		changes[0].Nominal != 1000 || !changes[0].UnitValueRUB.Equal(dec("0.1953")) {
+		t.Errorf("unexpected nominal changes %+v", changes)
+	}
+
+	// The next collector run sends the same sheet: accepted, not reported again
+	if result := build(changed); len(result) != 1 || result[0].PreviousNominal != 0 {
+		t.Errorf("expected the repeated sheet without a change, got %+v", result)
+	}
+	// The next sheet keeps the new nominal
+	next := build(events.RawCBRRate{Date: "2024-07-03T00:00:00+03:00", CharCode: "KZT", Nominal: 1000, Value: dec("196"), Previous: dec("195.3")})
+	if len(nominalChanges(next)) != 0 {
+		t.Errorf("expected no change on the next sheet, got %+v", next)
+	}
+}
+
+func TestCheckCrypto(t *testing.T) {
+	l := Limits{CryptoChange: 0.5}
+	valid := events.RawCryptoRate{Symbol: "BTCUSDT", Timestamp: time.Now(), Open: dec("40000"), High: dec("42000"), Low: dec("39000"), Close: dec("41000"), Volume: dec("1.5")}
+
+	tests := []struct {
+		name   string
+		modify func(r *events.RawCryptoRate)
+		rule   string
+	}{
+		{"valid", func(r *events.RawCryptoRate) {}, ""},
+		{"zero volume", func(r *events.RawCryptoRate) { r.Volume = dec("0") }, ""},
+		{"not a USDT pair", func(r *events.RawCryptoRate) { r.Symbol = "BTCEUR" }, RuleUnknownCode},
+		{"lower case", func(r *events.RawCryptoRate) { r.Symbol = "btcusdt" }, RuleUnknownCode},
+		{"zero close", func(r *events.RawCryptoRate) { r.Close = dec("0") }, RuleNonPositiveValue},
+		{"zero low", func(r *events.RawCryptoRate) { r.Low = dec("0") }, RuleNonPositiveValue},
+		{"negative volume", func(r *events.RawCryptoRate) { r.Volume = dec("-1") }, RuleNonPositiveValue},
+		{"no timestamp", func(r *events.RawCryptoRate) { r.Timestamp = time.Time{} }, RuleInvalidDate},
+		{"jump", func(r *events.RawCryptoRate) { r.Close = dec("70000") }, RuleJump},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := valid
+			tc.modify(&r)
+			rej := l.checkCrypto(r)
+			if tc.rule == "" {
+				if rej != nil {
+					t.Fatalf("unexpected rejection: %s: %s", rej.rule, rej.reason)
+				}
+				return
+			}
+			if rej == nil || rej.rule != tc.rule {
+				t.Fatalf("expected rule %s, got %+v", tc.rule, rej)
+			}
+		})
+	}
+}
+
+func TestNormalizeCBR_rejectedLeaveTheSheet(t *testing.T) {
+	n := newTestNormalizer("")
+	n.quotes = []string{"USD"}
+	n.limits = Limits{CBRChange: 0.25}
+
+	rates := []events.RawCBRRate{
+		{Date: "2024-01-15T00:00:00+03:00", CharCode: "USD", Nominal: 1, Value: dec("900"), Previous: dec("90")},
+		{Date: "2024-01-15T00:00:00+03:00", CharCode: "EUR", Nominal: 1, Value: dec("99"), Previous: dec("98")},
+	}
+	raw, _ := json.Marshal(rates)
+	result, rejected, err := n.buildNormalizedCBR(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result) != 1 || result[0].CurrencyCode != "EUR" {
+		t.Fatalf("expected only EUR, got %+v", result)
+	}
+	if _, ok := result[0].Quotes["USD"]; ok {
+		t.Error("the rejected USD rate must not be used for cross rates")
+	}
+	if len(rejected) != 1 || rejected[0].rule != RuleJump {
+		t.Fatalf("expected one %s rejection, got %+v", RuleJump, rejected)
+	}
+
+	q := rejected[0].quarantined(events.SourceCBR, time.Now())
+	var back events.RawCBRRate
+	if err := json.Unmarshal(q.Rate, &back); err != nil || !back.Value.Equal(dec("900")) {
+		t.Errorf("quarantined rate should carry the raw record, got %s (%v)", q.Rate, err)
+	}
+	if q.Rule != RuleJump || q.Source != events.SourceCBR || q.Reason == "" {
+		t.Errorf("got %+v", q)
+	}
+}
+
+func TestNormalizeCrypto_allRejected_skipsSheet(t *testing.T) {
+	// An unreachable CBR would fall back to 1; no sheet is fetched at all
+	n := newTestNormalizer("http://127.0.0.1:1")
+
+	raw, _ := json.Marshal([]events.RawCryptoRate{{Symbol: "BTCUSDT", Timestamp: time.Now()}})
+	result, rejected, err := n.buildNormalizedCrypto(context.Background(), raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result) != 0 || len(rejected) != 1 || rejected[0].rule != RuleNonPositiveValue {
+		t.Fatalf("got %+v / %+v", result, rejected)
+	}
+}
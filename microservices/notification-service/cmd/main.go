@@ -1,7 +1,9 @@
 package main
 
 import (
-	"log"
+	"context"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -11,6 +13,12 @@ import (
 	"github.com/casualdoto/go-currency-tracker/microservices/notification-service/internal/handler"
 	"github.com/casualdoto/go-currency-tracker/microservices/notification-service/internal/store"
 	"github.com/casualdoto/go-currency-tracker/microservices/notification-service/internal/subscriber"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/health"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/logging"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/metrics"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/pkg/client"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/rpcv1"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/tracing"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
@@ -18,19 +26,29 @@ import (
 func main() {
 	cfg := config.Load()
 
+	logging.Setup("notification-service")
+	shutdownTracing, err := tracing.Setup(context.Background(), "notification-service")
+	if err != nil {
+		slog.Error("tracing setup failed", "error", err)
+		os.Exit(1)
+	}
+	defer shutdownTracing(context.Background())
+
 	redisStore := store.NewRedis(cfg.RedisAddr)
 
-	sub := subscriber.New(cfg.KafkaBrokers, redisStore, cfg.TelegramBotToken)
+	sub := subscriber.New(cfg.KafkaBrokers, redisStore, cfg.TelegramBotToken, client.New(cfg.HistoryServiceURL))
 	go func() {
-		log.Println("Notification Service: starting Kafka subscriber")
+		slog.Info("kafka subscriber starting")
 		if err := sub.Run(); err != nil {
-			log.Printf("subscriber error: %v", err)
+			slog.Error("subscriber stopped", "error", err)
 		}
 	}()
 
 	h := handler.New(redisStore)
 	r := chi.NewRouter()
-	r.Use(middleware.Logger)
+	r.Use(tracing.Middleware)
+	r.Use(logging.Middleware)
+	r.Use(metrics.Middleware)
 	r.Use(middleware.Recoverer)
 
 	// Subscription management
@@ -40,20 +58,54 @@ func main() {
 	r.Post("/subscriptions/crypto", h.SubscribeCrypto)
 	r.Delete("/subscriptions/crypto", h.UnsubscribeCrypto)
 	r.Get("/subscriptions/crypto", h.ListCryptoSubscriptions)
+	r.Post("/subscriptions/metals", h.SubscribeMetals)
+	r.Delete("/subscriptions/metals", h.UnsubscribeMetals)
+	r.Get("/subscriptions/metals", h.ListMetalsSubscriptions)
+	r.Post("/subscriptions/indicators", h.SubscribeIndicators)
+	r.Delete("/subscriptions/indicators", h.UnsubscribeIndicators)
+	r.Get("/subscriptions/indicators", h.ListIndicatorsSubscriptions)
+	r.Post("/subscriptions/alerts", h.SubscribeAlerts)
+	r.Delete("/subscriptions/alerts", h.UnsubscribeAlerts)
+	r.Get("/subscriptions/alerts", h.ListAlertsSubscriptions)
 
+	// Health: subscriptions need Redis; without Kafka only the notifications stop
+	checker := health.New().
+		Add("redis", redisStore.Ping).
+		AddOptional("kafka", health.Kafka(cfg.KafkaBrokers))
 	r.Get("/ping", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("pong")) })
+	r.Get("/healthz", health.Live)
+	r.Get("/readyz", checker.Ready)
+	r.Method(http.MethodGet, "/metrics", metrics.Handler())
 
 	addr := ":" + cfg.ServerPort
-	log.Printf("Notification Service listening on %s", addr)
+	slog.Info("http listening", "addr", addr)
 	srv := &http.Server{Addr: addr, Handler: r}
 	go func() {
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("server error: %v", err)
+			slog.Error("http server failed", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	// Internal gRPC API (rpcv1.SubscriptionService) for the gateway and the bot
+	lis, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+	if err != nil {
+		slog.Error("failed to listen for gRPC", "error", err)
+		os.Exit(1)
+	}
+	grpcSrv := rpcv1.NewServer()
+	rpcv1.RegisterSubscriptionServiceServer(grpcSrv, handler.NewGRPCServer(redisStore))
+	slog.Info("grpc listening", "addr", ":"+cfg.GRPCPort)
+	go func() {
+		if err := grpcSrv.Serve(lis); err != nil {
+			slog.Error("grpc server failed", "error", err)
+			os.Exit(1)
 		}
 	}()
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	log.Println("Notification Service: shutting down")
+	slog.Info("shutting down")
+	grpcSrv.GracefulStop()
 }
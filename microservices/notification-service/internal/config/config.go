@@ -7,14 +7,21 @@ type Config struct {
 	KafkaBrokers     string
 	TelegramBotToken string
 	ServerPort       string
+	// GRPCPort serves rpcv1.SubscriptionService to the gateway and the bot.
+	GRPCPort string
+	// HistoryServiceURL serves the indicators alert subscriptions are
+	// checked against.
+	HistoryServiceURL string
 }
 
 func Load() *Config {
 	return &Config{
-		RedisAddr:        getEnv("REDIS_ADDR", "localhost:6379"),
-		KafkaBrokers:     getEnv("KAFKA_BROKERS", "localhost:9092"),
-		TelegramBotToken: getEnv("TELEGRAM_BOT_TOKEN", ""),
-		ServerPort:       getEnv("SERVER_PORT", "8085"),
+		RedisAddr:         getEnv("REDIS_ADDR", "localhost:6379"),
+		KafkaBrokers:      getEnv("KAFKA_BROKERS", "localhost:9092"),
+		TelegramBotToken:  getEnv("TELEGRAM_BOT_TOKEN", ""),
+		ServerPort:        getEnv("SERVER_PORT", "8085"),
+		GRPCPort:          getEnv("GRPC_PORT", "9085"),
+		HistoryServiceURL: getEnv("HISTORY_SERVICE_URL", "http://localhost:8084"),
 	}
 }
 
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/rpcv1"
+)
+
+// GRPCServer serves rpcv1.SubscriptionService from the same store as the
+// HTTP handlers.
+type GRPCServer struct {
+	rpcv1.UnimplementedSubscriptionServiceServer
+	store SubscriptionStore
+}
+
+func NewGRPCServer(s SubscriptionStore) *GRPCServer {
+	return &GRPCServer{store: s}
+}
+
+// storeFuncs picks the store methods of a subscription kind.
+func (s *GRPCServer) storeFuncs(kind rpcv1.SubscriptionKind) (
+	subscribe, unsubscribe func(context.Context, int64, string) error,
+	list func(context.Context, int64) ([]string, error),
+	err error,
+) {
+	switch kind {
+	case rpcv1.SubscriptionKind_SUBSCRIPTION_KIND_CBR:
+		return s.store.SubscribeCBR, s.store.UnsubscribeCBR, s.store.GetCBRSubscriptions, nil
+	case rpcv1.SubscriptionKind_SUBSCRIPTION_KIND_CRYPTO:
+		return s.store.SubscribeCrypto, s.store.UnsubscribeCrypto, s.store.GetCryptoSubscriptions, nil
+	case rpcv1.SubscriptionKind_SUBSCRIPTION_KIND_METALS:
+		return s.store.SubscribeMetals, s.store.UnsubscribeMetals, s.store.GetMetalsSubscriptions, nil
+	case rpcv1.SubscriptionKind_SUBSCRIPTION_KIND_INDICATORS:
+		return s.store.SubscribeIndicators, s.store.UnsubscribeIndicators, s.store.GetIndicatorsSubscriptions, nil
+	case rpcv1.SubscriptionKind_SUBSCRIPTION_KIND_ALERTS:
+		return s.store.SubscribeAlerts, s.store.UnsubscribeAlerts, s.store.GetAlertsSubscriptions, nil
+	}
+	return nil, nil, nil, rpcv1.Error(http.StatusBadRequest, "unknown subscription kind")
+}
+
+func validRequest(telegramID int64, value string) error {
+	if telegramID <= 0 {
+		return rpcv1.Error(http.StatusBadRequest, "invalid telegram_id")
+	}
+	if value == "" {
+		return rpcv1.Error(http.StatusBadRequest, "value is required")
+	}
+	return nil
+}
+
+// storedValue returns value in the form it is stored in. Only alerts have a
+// syntax of their own.
+func storedValue(kind rpcv1.SubscriptionKind, value string) (string, error) {
+	if kind != rpcv1.SubscriptionKind_SUBSCRIPTION_KIND_ALERTS {
+		return value, nil
+	}
+	alert, err := alertValue(value)
+	if err != nil {
+		return "", rpcv1.Error(http.StatusBadRequest, err.Error())
+	}
+	return alert, nil
+}
+
+func (s *GRPCServer) Subscribe(ctx context.Context, req *rpcv1.SubscriptionRequest) (*rpcv1.SubscribeResponse, error) {
+	subscribe, _, _, err := s.storeFuncs(req.GetKind())
+	if err != nil {
+		return nil, err
+	}
+	if err := validRequest(req.GetTelegramId(), req.GetValue()); err != nil {
+		return nil, err
+	}
+	value, err := storedValue(req.GetKind(), req.GetValue())
+	if err != nil {
+		return nil, err
+	}
+	if err := subscribe(ctx, req.GetTelegramId(), value); err != nil {
+		return nil, rpcv1.Error(http.StatusInternalServerError, err.Error())
+	}
+	return &rpcv1.SubscribeResponse{}, nil
+}
+
+func (s *GRPCServer) Unsubscribe(ctx context.Context, req *rpcv1.SubscriptionRequest) (*rpcv1.UnsubscribeResponse, error) {
+	_, unsubscribe, _, err := s.storeFuncs(req.GetKind())
+	if err != nil {
+		return nil, err
+	}
+	if err := validRequest(req.GetTelegramId(), req.GetValue()); err != nil {
+		return nil, err
+	}
+	value, err := storedValue(req.GetKind(), req.GetValue())
+	if err != nil {
+		return nil, err
+	}
+	if err := unsubscribe(ctx, req.GetTelegramId(), value); err != nil {
+		return nil, rpcv1.Error(http.StatusInternalServerError, err.Error())
+	}
+	return &rpcv1.UnsubscribeResponse{}, nil
+}
+
+func (s *GRPCServer) ListSubscriptions(ctx context.Context, req *rpcv1.ListSubscriptionsRequest) (*rpcv1.Subscriptions, error) {
+	_, _, list, err := s.storeFuncs(req.GetKind())
+	if err != nil {
+		return nil, err
+	}
+	if req.GetTelegramId() <= 0 {
+		return nil, rpcv1.Error(http.StatusBadRequest, "invalid telegram_id")
+	}
+	values, err := list(ctx, req.GetTelegramId())
+	if err != nil {
+		return nil, rpcv1.Error(http.StatusInternalServerError, err.Error())
+	}
+	return &rpcv1.Subscriptions{Values: values}, nil
+}
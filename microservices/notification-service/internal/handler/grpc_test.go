@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/rpcv1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// recordingStore records the subscriptions it is given.
+type recordingStore struct {
+	stubStore
+	cbr, crypto, metals, indicators, alerts []string
+}
+
+func (s *recordingStore) SubscribeCBR(_ context.Context, _ int64, v string) error {
+	s.cbr = append(s.cbr, v)
+	return s.subscribeCBRErr
+}
+
+func (s *recordingStore) SubscribeCrypto(_ context.Context, _ int64, v string) error {
+	s.crypto = append(s.crypto, v)
+	return s.subscribeCryptoErr
+}
+
+func (s *recordingStore) SubscribeMetals(_ context.Context, _ int64, v string) error {
+	s.metals = append(s.metals, v)
+	return s.subscribeMetalsErr
+}
+
+func (s *recordingStore) SubscribeIndicators(_ context.Context, _ int64, v string) error {
+	s.indicators = append(s.indicators, v)
+	return nil
+}
+
+func (s *recordingStore) SubscribeAlerts(_ context.Context, _ int64, v string) error {
+	s.alerts = append(s.alerts, v)
+	return nil
+}
+
+// ─── GRPCServer ───────────────────────────────────────────────────────────────
+
+func TestGRPCServer_subscribeByKind(t *testing.T) {
+	store := &recordingStore{}
+	s := NewGRPCServer(store)
+	ctx := context.Background()
+
+	for kind, value := range map[rpcv1.SubscriptionKind]string{
+		rpcv1.SubscriptionKind_SUBSCRIPTION_KIND_CBR:        "USD",
+		rpcv1.SubscriptionKind_SUBSCRIPTION_KIND_CRYPTO:     "BTC",
+		rpcv1.SubscriptionKind_SUBSCRIPTION_KIND_METALS:     "XAU",
+		rpcv1.SubscriptionKind_SUBSCRIPTION_KIND_INDICATORS: "KEY_RATE",
+		rpcv1.SubscriptionKind_SUBSCRIPTION_KIND_ALERTS:     "eth 4h sma20>sma50",
+	} {
+		if _, err := s.Subscribe(ctx, &rpcv1.SubscriptionRequest{Kind: kind, TelegramId: 123, Value: value}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(store.cbr) != 1 || store.cbr[0] != "USD" || len(store.crypto) != 1 || store.crypto[0] != "BTC" {
+		t.Errorf("unexpected subscriptions cbr=%v crypto=%v", store.cbr, store.crypto)
+	}
+	if len(store.metals) != 1 || store.metals[0] != "XAU" {
+		t.Errorf("unexpected metals subscriptions %v", store.metals)
+	}
+	if len(store.indicators) != 1 || store.indicators[0] != "KEY_RATE" {
+		t.Errorf("unexpected indicators subscriptions %v", store.indicators)
+	}
+	if len(store.alerts) != 1 || store.alerts[0] != "ETH 4h sma20>sma50" {
+		t.Errorf("expected the alert in its stored form, got %v", store.alerts)
+	}
+}
+
+func TestGRPCServer_listSubscriptions(t *testing.T) {
+	s := NewGRPCServer(&stubStore{getCryptoSubs: []string{"BTC", "ETH"}})
+	resp, err := s.ListSubscriptions(context.Background(), &rpcv1.ListSubscriptionsRequest{
+		Kind: rpcv1.SubscriptionKind_SUBSCRIPTION_KIND_CRYPTO, TelegramId: 123,
+	})
+	if err != nil || len(resp.GetValues()) != 2 {
+		t.Errorf("unexpected response %v, %v", resp, err)
+	}
+}
+
+func TestGRPCServer_invalidRequests(t *testing.T) {
+	s := NewGRPCServer(&stubStore{})
+	ctx := context.Background()
+	cbr := rpcv1.SubscriptionKind_SUBSCRIPTION_KIND_CBR
+
+	for msg, req := range map[string]*rpcv1.SubscriptionRequest{
+		"unknown subscription kind":                  {TelegramId: 123, Value: "USD"},
+		"invalid telegram_id":                        {Kind: cbr, Value: "USD"},
+		"value is required":                          {Kind: cbr, TelegramId: 123},
+		`invalid alert "BTC" (e.g. BTC 1h rsi14>70)`: {Kind: rpcv1.SubscriptionKind_SUBSCRIPTION_KIND_ALERTS, TelegramId: 123, Value: "BTC"},
+	} {
+		_, err := s.Unsubscribe(ctx, req)
+		if st, _ := status.FromError(err); st.Code() != codes.InvalidArgument || st.Message() != msg {
+			t.Errorf("expected InvalidArgument %q, got %v", msg, err)
+		}
+	}
+}
+
+func TestGRPCServer_storeError(t *testing.T) {
+	s := NewGRPCServer(&stubStore{getCBRSubsErr: errors.New("redis down")})
+	_, err := s.ListSubscriptions(context.Background(), &rpcv1.ListSubscriptionsRequest{
+		Kind: rpcv1.SubscriptionKind_SUBSCRIPTION_KIND_CBR, TelegramId: 123,
+	})
+	if status.Code(err) != codes.Internal {
+		t.Errorf("expected Internal, got %v", err)
+	}
+}
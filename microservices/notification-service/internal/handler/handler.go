@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/indicators"
 )
 
 // SubscriptionStore is the interface the Handler depends on for managing subscriptions.
@@ -16,6 +18,15 @@ type SubscriptionStore interface {
 	SubscribeCrypto(ctx context.Context, telegramID int64, symbol string) error
 	UnsubscribeCrypto(ctx context.Context, telegramID int64, symbol string) error
 	GetCryptoSubscriptions(ctx context.Context, telegramID int64) ([]string, error)
+	SubscribeMetals(ctx context.Context, telegramID int64, metal string) error
+	UnsubscribeMetals(ctx context.Context, telegramID int64, metal string) error
+	GetMetalsSubscriptions(ctx context.Context, telegramID int64) ([]string, error)
+	SubscribeIndicators(ctx context.Context, telegramID int64, indicator string) error
+	UnsubscribeIndicators(ctx context.Context, telegramID int64, indicator string) error
+	GetIndicatorsSubscriptions(ctx context.Context, telegramID int64) ([]string, error)
+	SubscribeAlerts(ctx context.Context, telegramID int64, alert string) error
+	UnsubscribeAlerts(ctx context.Context, telegramID int64, alert string) error
+	GetAlertsSubscriptions(ctx context.Context, telegramID int64) ([]string, error)
 }
 
 type Handler struct {
@@ -34,7 +45,7 @@ func writeJSON(w http.ResponseWriter, status int, v any) {
 
 type subRequest struct {
 	TelegramID int64  `json:"telegram_id"`
-	Value      string `json:"value"` // currency code or symbol
+	Value      string `json:"value"` // currency code, symbol, metal, indicator code or alert
 }
 
 func (h *Handler) SubscribeCBR(w http.ResponseWriter, r *http.Request) {
@@ -118,3 +129,146 @@ func (h *Handler) ListCryptoSubscriptions(w http.ResponseWriter, r *http.Request
 	}
 	writeJSON(w, http.StatusOK, subs)
 }
+
+func (h *Handler) SubscribeMetals(w http.ResponseWriter, r *http.Request) {
+	var req subRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid body"})
+		return
+	}
+	if err := h.store.SubscribeMetals(context.Background(), req.TelegramID, req.Value); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) UnsubscribeMetals(w http.ResponseWriter, r *http.Request) {
+	var req subRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid body"})
+		return
+	}
+	if err := h.store.UnsubscribeMetals(context.Background(), req.TelegramID, req.Value); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) ListMetalsSubscriptions(w http.ResponseWriter, r *http.Request) {
+	tidStr := r.URL.Query().Get("telegram_id")
+	tid, err := strconv.ParseInt(tidStr, 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid telegram_id"})
+		return
+	}
+	subs, err := h.store.GetMetalsSubscriptions(context.Background(), tid)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, subs)
+}
+
+func (h *Handler) SubscribeIndicators(w http.ResponseWriter, r *http.Request) {
+	var req subRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid body"})
+		return
+	}
+	if err := h.store.SubscribeIndicators(context.Background(), req.TelegramID, req.Value); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) UnsubscribeIndicators(w http.ResponseWriter, r *http.Request) {
+	var req subRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid body"})
+		return
+	}
+	if err := h.store.UnsubscribeIndicators(context.Background(), req.TelegramID, req.Value); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) ListIndicatorsSubscriptions(w http.ResponseWriter, r *http.Request) {
+	tidStr := r.URL.Query().Get("telegram_id")
+	tid, err := strconv.ParseInt(tidStr, 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid telegram_id"})
+		return
+	}
+	subs, err := h.store.GetIndicatorsSubscriptions(context.Background(), tid)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, subs)
+}
+
+// alertValue validates an alert and returns it in the form it is stored and
+// matched in, e.g. "BTC 1h rsi14>70" for "btc rsi14 > 70".
+func alertValue(value string) (string, error) {
+	a, err := indicators.ParseAlert(value)
+	if err != nil {
+		return "", err
+	}
+	return a.String(), nil
+}
+
+func (h *Handler) SubscribeAlerts(w http.ResponseWriter, r *http.Request) {
+	var req subRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid body"})
+		return
+	}
+	alert, err := alertValue(req.Value)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if err := h.store.SubscribeAlerts(context.Background(), req.TelegramID, alert); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) UnsubscribeAlerts(w http.ResponseWriter, r *http.Request) {
+	var req subRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid body"})
+		return
+	}
+	alert, err := alertValue(req.Value)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if err := h.store.UnsubscribeAlerts(context.Background(), req.TelegramID, alert); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) ListAlertsSubscriptions(w http.ResponseWriter, r *http.Request) {
+	tidStr := r.URL.Query().Get("telegram_id")
+	tid, err := strconv.ParseInt(tidStr, 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid telegram_id"})
+		return
+	}
+	subs, err := h.store.GetAlertsSubscriptions(context.Background(), tid)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, subs)
+}
@@ -21,6 +21,10 @@ type stubStore struct {
 	unsubCryptoErr     error
 	getCryptoSubs      []string
 	getCryptoSubsErr   error
+	subscribeMetalsErr error
+	getMetalsSubs      []string
+	getIndicatorsSubs  []string
+	getAlertsSubs      []string
 }
 
 func (s *stubStore) SubscribeCBR(_ context.Context, _ int64, _ string) error {
@@ -41,6 +45,33 @@ func (s *stubStore) UnsubscribeCrypto(_ context.Context, _ int64, _ string) erro
 func (s *stubStore) GetCryptoSubscriptions(_ context.Context, _ int64) ([]string, error) {
 	return s.getCryptoSubs, s.getCryptoSubsErr
 }
+func (s *stubStore) SubscribeMetals(_ context.Context, _ int64, _ string) error {
+	return s.subscribeMetalsErr
+}
+func (s *stubStore) UnsubscribeMetals(_ context.Context, _ int64, _ string) error {
+	return nil
+}
+func (s *stubStore) GetMetalsSubscriptions(_ context.Context, _ int64) ([]string, error) {
+	return s.getMetalsSubs, nil
+}
+func (s *stubStore) SubscribeIndicators(_ context.Context, _ int64, _ string) error {
+	return nil
+}
+func (s *stubStore) UnsubscribeIndicators(_ context.Context, _ int64, _ string) error {
+	return nil
+}
+func (s *stubStore) GetIndicatorsSubscriptions(_ context.Context, _ int64) ([]string, error) {
+	return s.getIndicatorsSubs, nil
+}
+func (s *stubStore) SubscribeAlerts(_ context.Context, _ int64, _ string) error {
+	return nil
+}
+func (s *stubStore) UnsubscribeAlerts(_ context.Context, _ int64, _ string) error {
+	return nil
+}
+func (s *stubStore) GetAlertsSubscriptions(_ context.Context, _ int64) ([]string, error) {
+	return s.getAlertsSubs, nil
+}
 
 // ─── helpers ──────────────────────────────────────────────────────────────────
 
@@ -259,3 +290,75 @@ func TestListCryptoSubscriptions_storeError(t *testing.T) {
 		t.Errorf("expected 500, got %d", rr.Code)
 	}
 }
+
+// ─── Metals ───────────────────────────────────────────────────────────────────
+
+func TestSubscribeMetals(t *testing.T) {
+	h := New(&stubStore{})
+	if rr := post(t, h.SubscribeMetals, "/subscriptions/metals", `{"telegram_id":123,"value":"XAU"}`); rr.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", rr.Code)
+	}
+	if rr := post(t, h.SubscribeMetals, "/subscriptions/metals", `not json`); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	h = New(&stubStore{subscribeMetalsErr: errors.New("redis down")})
+	if rr := post(t, h.SubscribeMetals, "/subscriptions/metals", `{"telegram_id":123,"value":"XAU"}`); rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", rr.Code)
+	}
+}
+
+func TestListMetalsSubscriptions(t *testing.T) {
+	h := New(&stubStore{getMetalsSubs: []string{"XAU", "XPT"}})
+	rr := get(t, h.ListMetalsSubscriptions, "/subscriptions/metals?telegram_id=123")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	var subs []string
+	json.NewDecoder(rr.Body).Decode(&subs)
+	if len(subs) != 2 || subs[0] != "XAU" {
+		t.Errorf("expected [XAU XPT], got %v", subs)
+	}
+	if rr := get(t, h.ListMetalsSubscriptions, "/subscriptions/metals"); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without telegram_id, got %d", rr.Code)
+	}
+}
+
+// ─── Indicators ───────────────────────────────────────────────────────────────
+
+func TestIndicatorsSubscriptions(t *testing.T) {
+	h := New(&stubStore{getIndicatorsSubs: []string{"KEY_RATE"}})
+	if rr := post(t, h.SubscribeIndicators, "/subscriptions/indicators", `{"telegram_id":123,"value":"KEY_RATE"}`); rr.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", rr.Code)
+	}
+	rr := get(t, h.ListIndicatorsSubscriptions, "/subscriptions/indicators?telegram_id=123")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	var subs []string
+	json.NewDecoder(rr.Body).Decode(&subs)
+	if len(subs) != 1 || subs[0] != "KEY_RATE" {
+		t.Errorf("expected [KEY_RATE], got %v", subs)
+	}
+}
+
+// ─── Alerts ───────────────────────────────────────────────────────────────────
+
+func TestAlertsSubscriptions(t *testing.T) {
+	h := New(&stubStore{getAlertsSubs: []string{"BTC 1h rsi14>70"}})
+	if rr := post(t, h.SubscribeAlerts, "/subscriptions/alerts", `{"telegram_id":123,"value":"BTC rsi14>70"}`); rr.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", rr.Code)
+	}
+	rr := post(t, h.SubscribeAlerts, "/subscriptions/alerts", `{"telegram_id":123,"value":"BTC rsi>70"}`)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown indicator, got %d", rr.Code)
+	}
+	rr = get(t, h.ListAlertsSubscriptions, "/subscriptions/alerts?telegram_id=123")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	var subs []string
+	json.NewDecoder(rr.Body).Decode(&subs)
+	if len(subs) != 1 || subs[0] != "BTC 1h rsi14>70" {
+		t.Errorf("expected [BTC 1h rsi14>70], got %v", subs)
+	}
+}
@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/redis/go-redis/v9"
 )
@@ -12,6 +13,12 @@ import (
 //
 //	user:{telegram_id}:cbr_subscriptions  -> Set of currency codes
 //	user:{telegram_id}:crypto_subscriptions -> Set of symbols
+//	user:{telegram_id}:metals_subscriptions -> Set of metal codes (XAU)
+//	metals:notified:{metal}                 -> Date of the last price sent
+//	user:{telegram_id}:indicators_subscriptions -> Set of indicator codes (KEY_RATE)
+//	indicators:last:{indicator}             -> "{effective date} {value}" last seen
+//	user:{telegram_id}:alerts_subscriptions -> Set of alerts (BTC 1h rsi14>70)
+//	alerts:met:{alert}                      -> Set of telegram IDs told the alert holds
 type RedisStore struct {
 	client *redis.Client
 }
@@ -21,6 +28,11 @@ func NewRedis(addr string) *RedisStore {
 	return &RedisStore{client: c}
 }
 
+// Ping checks the connection for /readyz.
+func (r *RedisStore) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
 func cbrKey(telegramID int64) string {
 	return fmt.Sprintf("user:%d:cbr_subscriptions", telegramID)
 }
@@ -29,6 +41,18 @@ func cryptoKey(telegramID int64) string {
 	return fmt.Sprintf("user:%d:crypto_subscriptions", telegramID)
 }
 
+func metalsKey(telegramID int64) string {
+	return fmt.Sprintf("user:%d:metals_subscriptions", telegramID)
+}
+
+func indicatorsKey(telegramID int64) string {
+	return fmt.Sprintf("user:%d:indicators_subscriptions", telegramID)
+}
+
+func alertsKey(telegramID int64) string {
+	return fmt.Sprintf("user:%d:alerts_subscriptions", telegramID)
+}
+
 func (r *RedisStore) SubscribeCBR(ctx context.Context, telegramID int64, currency string) error {
 	return r.client.SAdd(ctx, cbrKey(telegramID), currency).Err()
 }
@@ -53,53 +77,92 @@ func (r *RedisStore) GetCryptoSubscriptions(ctx context.Context, telegramID int6
 	return r.client.SMembers(ctx, cryptoKey(telegramID)).Result()
 }
 
+func (r *RedisStore) SubscribeMetals(ctx context.Context, telegramID int64, metal string) error {
+	return r.client.SAdd(ctx, metalsKey(telegramID), metal).Err()
+}
+
+func (r *RedisStore) UnsubscribeMetals(ctx context.Context, telegramID int64, metal string) error {
+	return r.client.SRem(ctx, metalsKey(telegramID), metal).Err()
+}
+
+func (r *RedisStore) GetMetalsSubscriptions(ctx context.Context, telegramID int64) ([]string, error) {
+	return r.client.SMembers(ctx, metalsKey(telegramID)).Result()
+}
+
+func (r *RedisStore) SubscribeIndicators(ctx context.Context, telegramID int64, indicator string) error {
+	return r.client.SAdd(ctx, indicatorsKey(telegramID), indicator).Err()
+}
+
+func (r *RedisStore) UnsubscribeIndicators(ctx context.Context, telegramID int64, indicator string) error {
+	return r.client.SRem(ctx, indicatorsKey(telegramID), indicator).Err()
+}
+
+func (r *RedisStore) GetIndicatorsSubscriptions(ctx context.Context, telegramID int64) ([]string, error) {
+	return r.client.SMembers(ctx, indicatorsKey(telegramID)).Result()
+}
+
+func (r *RedisStore) SubscribeAlerts(ctx context.Context, telegramID int64, alert string) error {
+	return r.client.SAdd(ctx, alertsKey(telegramID), alert).Err()
+}
+
+// UnsubscribeAlerts also forgets that the user was told the alert holds,
+// so subscribing again alerts again.
+func (r *RedisStore) UnsubscribeAlerts(ctx context.Context, telegramID int64, alert string) error {
+	if err := r.client.SRem(ctx, alertsKey(telegramID), alert).Err(); err != nil {
+		return err
+	}
+	return r.client.SRem(ctx, alertMetKey(alert), telegramID).Err()
+}
+
+func (r *RedisStore) GetAlertsSubscriptions(ctx context.Context, telegramID int64) ([]string, error) {
+	return r.client.SMembers(ctx, alertsKey(telegramID)).Result()
+}
+
 // GetAllCBRSubscribers returns map[currency_code][]telegramID
 func (r *RedisStore) GetAllCBRSubscribers(ctx context.Context) (map[string][]int64, error) {
-	// Scan all user:*:cbr_subscriptions keys
-	result := make(map[string][]int64)
-	var cursor uint64
-	for {
-		keys, nextCursor, err := r.client.Scan(ctx, cursor, "user:*:cbr_subscriptions", 100).Result()
-		if err != nil {
-			return nil, err
-		}
-		for _, key := range keys {
-			var telegramID int64
-			fmt.Sscanf(key, "user:%d:cbr_subscriptions", &telegramID)
-			currencies, err := r.client.SMembers(ctx, key).Result()
-			if err != nil {
-				continue
-			}
-			for _, c := range currencies {
-				result[c] = append(result[c], telegramID)
-			}
-		}
-		cursor = nextCursor
-		if cursor == 0 {
-			break
-		}
-	}
-	return result, nil
+	return r.allSubscribers(ctx, "cbr")
 }
 
 // GetAllCryptoSubscribers returns map[symbol][]telegramID
 func (r *RedisStore) GetAllCryptoSubscribers(ctx context.Context) (map[string][]int64, error) {
+	return r.allSubscribers(ctx, "crypto")
+}
+
+// GetAllMetalsSubscribers returns map[metal][]telegramID
+func (r *RedisStore) GetAllMetalsSubscribers(ctx context.Context) (map[string][]int64, error) {
+	return r.allSubscribers(ctx, "metals")
+}
+
+// GetAllIndicatorsSubscribers returns map[indicator][]telegramID
+func (r *RedisStore) GetAllIndicatorsSubscribers(ctx context.Context) (map[string][]int64, error) {
+	return r.allSubscribers(ctx, "indicators")
+}
+
+// GetAllAlertsSubscribers returns map[alert][]telegramID
+func (r *RedisStore) GetAllAlertsSubscribers(ctx context.Context) (map[string][]int64, error) {
+	return r.allSubscribers(ctx, "alerts")
+}
+
+// allSubscribers scans every user:*:{kind}_subscriptions set and returns
+// map[value][]telegramID.
+func (r *RedisStore) allSubscribers(ctx context.Context, kind string) (map[string][]int64, error) {
+	pattern := "user:%d:" + kind + "_subscriptions"
 	result := make(map[string][]int64)
 	var cursor uint64
 	for {
-		keys, nextCursor, err := r.client.Scan(ctx, cursor, "user:*:crypto_subscriptions", 100).Result()
+		keys, nextCursor, err := r.client.Scan(ctx, cursor, "user:*:"+kind+"_subscriptions", 100).Result()
 		if err != nil {
 			return nil, err
 		}
 		for _, key := range keys {
 			var telegramID int64
-			fmt.Sscanf(key, "user:%d:crypto_subscriptions", &telegramID)
-			symbols, err := r.client.SMembers(ctx, key).Result()
+			fmt.Sscanf(key, pattern, &telegramID)
+			values, err := r.client.SMembers(ctx, key).Result()
 			if err != nil {
 				continue
 			}
-			for _, s := range symbols {
-				result[s] = append(result[s], telegramID)
+			for _, v := range values {
+				result[v] = append(result[v], telegramID)
 			}
 		}
 		cursor = nextCursor
@@ -109,3 +172,60 @@ func (r *RedisStore) GetAllCryptoSubscribers(ctx context.Context) (map[string][]
 	}
 	return result, nil
 }
+
+func metalNotifiedKey(metal string) string {
+	return "metals:notified:" + metal
+}
+
+// MarkMetalNotified records date (YYYY-MM-DD) as the latest price of metal
+// sent to subscribers and reports whether it is newer than the one before.
+// The collector republishes the last days on every run, so a price is only
+// announced the first time it arrives.
+func (r *RedisStore) MarkMetalNotified(ctx context.Context, metal, date string) (bool, error) {
+	last, err := r.client.Get(ctx, metalNotifiedKey(metal)).Result()
+	if err != nil && err != redis.Nil {
+		return false, err
+	}
+	if last >= date {
+		return false, nil
+	}
+	return true, r.client.Set(ctx, metalNotifiedKey(metal), date, 0).Err()
+}
+
+func indicatorLastKey(indicator string) string {
+	return "indicators:last:" + indicator
+}
+
+// MarkIndicatorValue records value as the latest of indicator, in effect
+// from date (YYYY-MM-DD), and reports whether it changed and what it was
+// before. The first value seen is recorded without a change, as is one
+// dated before the recorded value.
+func (r *RedisStore) MarkIndicatorValue(ctx context.Context, indicator, date, value string) (previous string, changed bool, err error) {
+	last, err := r.client.Get(ctx, indicatorLastKey(indicator)).Result()
+	if err != nil && err != redis.Nil {
+		return "", false, err
+	}
+	lastDate, lastValue, _ := strings.Cut(last, " ")
+	if last != "" && (lastValue == value || lastDate > date) {
+		return "", false, nil
+	}
+	if err := r.client.Set(ctx, indicatorLastKey(indicator), date+" "+value, 0).Err(); err != nil {
+		return "", false, err
+	}
+	return lastValue, last != "", nil
+}
+
+func alertMetKey(alert string) string {
+	return "alerts:met:" + alert
+}
+
+// MarkAlert records whether alert holds for telegramID and reports whether
+// it has just started to, so a subscriber is told once each time the
+// condition comes to hold rather than on every check.
+func (r *RedisStore) MarkAlert(ctx context.Context, alert string, telegramID int64, met bool) (bool, error) {
+	if !met {
+		return false, r.client.SRem(ctx, alertMetKey(alert), telegramID).Err()
+	}
+	added, err := r.client.SAdd(ctx, alertMetKey(alert), telegramID).Result()
+	return added == 1, err
+}
@@ -32,6 +32,22 @@ func TestCBRKey_zeroID(t *testing.T) {
 	}
 }
 
+func TestIndicatorsKey_format(t *testing.T) {
+	got := indicatorsKey(42)
+	want := "user:42:indicators_subscriptions"
+	if got != want {
+		t.Errorf("indicatorsKey(42) = %q, want %q", got, want)
+	}
+}
+
+func TestAlertsKey_format(t *testing.T) {
+	got := alertsKey(42)
+	want := "user:42:alerts_subscriptions"
+	if got != want {
+		t.Errorf("alertsKey(42) = %q, want %q", got, want)
+	}
+}
+
 func TestCryptoKey_largeID(t *testing.T) {
 	got := cryptoKey(9999999999)
 	want := "user:9999999999:crypto_subscriptions"
@@ -40,6 +56,14 @@ func TestCryptoKey_largeID(t *testing.T) {
 	}
 }
 
+func TestMetalsKey_format(t *testing.T) {
+	got := metalsKey(42)
+	want := "user:42:metals_subscriptions"
+	if got != want {
+		t.Errorf("metalsKey(42) = %q, want %q", got, want)
+	}
+}
+
 // ─── Redis integration tests (skipped when Redis is unavailable) ──────────────
 
 // newTestStore returns a RedisStore connected to localhost:6379.
@@ -173,3 +197,118 @@ func TestRedisStore_EmptySubscriptions_returnsNil(t *testing.T) {
 		t.Errorf("expected empty, got %v", subs)
 	}
 }
+
+func TestRedisStore_MetalsSubscribers(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	defer s.client.Del(ctx, metalsKey(3001), metalsKey(3002))
+
+	_ = s.SubscribeMetals(ctx, 3001, "XAU")
+	_ = s.SubscribeMetals(ctx, 3002, "XAU")
+	_ = s.SubscribeMetals(ctx, 3002, "XAG")
+	if err := s.UnsubscribeMetals(ctx, 3002, "XAG"); err != nil {
+		t.Fatalf("UnsubscribeMetals: %v", err)
+	}
+
+	all, err := s.GetAllMetalsSubscribers(ctx)
+	if err != nil {
+		t.Fatalf("GetAllMetalsSubscribers: %v", err)
+	}
+	gold := all["XAU"]
+	sort.Slice(gold, func(i, j int) bool { return gold[i] < gold[j] })
+	if len(gold) < 2 || gold[0] != 3001 || gold[1] != 3002 {
+		t.Errorf("expected 3001 and 3002 on XAU, got %v", gold)
+	}
+	for _, tid := range all["XAG"] {
+		if tid == 3002 {
+			t.Error("3002 should have been unsubscribed from XAG")
+		}
+	}
+}
+
+func TestRedisStore_MarkMetalNotified(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	key := metalNotifiedKey("XTEST")
+	defer s.client.Del(ctx, key)
+
+	for _, tc := range []struct {
+		date string
+		want bool
+	}{
+		{"2026-04-14", true},
+		{"2026-04-15", true},
+		{"2026-04-15", false}, // the same price republished
+		{"2026-04-14", false}, // an older price in the window
+	} {
+		got, err := s.MarkMetalNotified(ctx, "XTEST", tc.date)
+		if err != nil {
+			t.Fatalf("MarkMetalNotified(%s): %v", tc.date, err)
+		}
+		if got != tc.want {
+			t.Errorf("MarkMetalNotified(%s) = %v, want %v", tc.date, got, tc.want)
+		}
+	}
+}
+
+func TestRedisStore_MarkIndicatorValue(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	key := indicatorLastKey("XTEST")
+	defer s.client.Del(ctx, key)
+
+	for _, tc := range []struct {
+		date, value string
+		previous    string
+		wantChanged bool
+	}{
+		{"2026-03-20", "16", "", false},    // the first value seen
+		{"2026-03-23", "16", "", false},    // the same rate on the next day
+		{"2026-03-23", "15.5", "16", true}, // a decision
+		{"2026-03-20", "16", "", false},    // an older batch
+		{"2026-03-24", "15.5", "", false},  // the new rate republished
+	} {
+		previous, changed, err := s.MarkIndicatorValue(ctx, "XTEST", tc.date, tc.value)
+		if err != nil {
+			t.Fatalf("MarkIndicatorValue(%s, %s): %v", tc.date, tc.value, err)
+		}
+		if changed != tc.wantChanged || previous != tc.previous {
+			t.Errorf("MarkIndicatorValue(%s, %s) = %q, %v, want %q, %v", tc.date, tc.value, previous, changed, tc.previous, tc.wantChanged)
+		}
+	}
+}
+
+func TestRedisStore_MarkAlert(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	const alert = "XTEST 1h rsi14>70"
+	defer s.client.Del(ctx, alertMetKey(alert), alertsKey(42))
+
+	for i, tc := range []struct {
+		met, want bool
+	}{
+		{true, true},   // starts to hold
+		{true, false},  // still holds
+		{false, false}, // stops holding
+		{true, true},   // holds again
+	} {
+		got, err := s.MarkAlert(ctx, alert, 42, tc.met)
+		if err != nil {
+			t.Fatalf("MarkAlert #%d: %v", i, err)
+		}
+		if got != tc.want {
+			t.Errorf("MarkAlert #%d(met=%v) = %v, want %v", i, tc.met, got, tc.want)
+		}
+	}
+
+	// Subscribing again after unsubscribing alerts again
+	if err := s.SubscribeAlerts(ctx, 42, alert); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UnsubscribeAlerts(ctx, 42, alert); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.MarkAlert(ctx, alert, 42, true); !got {
+		t.Error("expected the alert to be told again after resubscribing")
+	}
+}
@@ -3,27 +3,46 @@ package subscriber
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/casualdoto/go-currency-tracker/microservices/notification-service/internal/store"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/apiv1"
 	"github.com/casualdoto/go-currency-tracker/microservices/shared/events"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/indicators"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/logging"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/metrics"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/tracing"
 	"github.com/segmentio/kafka-go"
 )
 
 const groupID = "notification-service"
 
+var logger = logging.For("subscriber")
+
+// IndicatorSource computes the indicators of a cryptocurrency; *client.Client
+// reads them from history-service.
+type IndicatorSource interface {
+	CryptoIndicators(ctx context.Context, symbol, interval, specs string) (apiv1.IndicatorsResult, error)
+}
+
 type Subscriber struct {
 	reader     *kafka.Reader
 	store      *store.RedisStore
+	indicators IndicatorSource
 	botToken   string
 	httpClient *http.Client
 }
 
-func New(brokers string, s *store.RedisStore, botToken string) *Subscriber {
+func New(brokers string, s *store.RedisStore, botToken string, ind IndicatorSource) *Subscriber {
 	brokerList := strings.Split(brokers, ",")
 	r := kafka.NewReader(kafka.ReaderConfig{
 		Brokers:  brokerList,
@@ -35,6 +54,7 @@ func New(brokers string, s *store.RedisStore, botToken string) *Subscriber {
 	return &Subscriber{
 		reader:     r,
 		store:      s,
+		indicators: ind,
 		botToken:   botToken,
 		httpClient: &http.Client{Timeout: 10 * time.Second},
 	}
@@ -47,8 +67,12 @@ func (s *Subscriber) Run() error {
 		if err != nil {
 			return err
 		}
-		if err := s.process(ctx, msg.Value); err != nil {
-			log.Printf("notification subscriber: process error: %v", err)
+		metrics.KafkaConsumed(events.TopicNormalizedRates, groupID, s.reader.Stats().Lag)
+		msgCtx, span := tracing.StartConsumer(logging.ExtractKafka(ctx, msg), groupID, msg)
+		err = s.process(msgCtx, msg.Value)
+		tracing.End(span, err)
+		if err != nil {
+			logger.ErrorContext(msgCtx, "process failed", "offset", msg.Offset, "error", err)
 		}
 	}
 }
@@ -64,15 +88,27 @@ func (s *Subscriber) process(ctx context.Context, data []byte) error {
 		return err
 	}
 
-	if evt.Source != string(events.SourceBinance) {
-		return nil // only notify on crypto changes for now
-	}
-
-	var rates []events.NormalizedCryptoRate
-	if err := json.Unmarshal(evt.Rates, &rates); err != nil {
-		return err
+	switch evt.Source {
+	case string(events.SourceBinance):
+		var rates []events.NormalizedCryptoRate
+		if err := json.Unmarshal(evt.Rates, &rates); err != nil {
+			return err
+		}
+		if err := s.notifyCrypto(ctx, rates); err != nil {
+			return err
+		}
+		return s.checkAlerts(ctx, rates)
+	case string(events.SourceCBRMetals):
+		return s.notifyMetals(ctx, evt.Rates)
+	case string(events.SourceCBRIndicators):
+		return s.notifyKeyRate(ctx, evt.Rates)
+	case string(events.SourceCBRNominal):
+		return s.notifyNominalChanges(ctx, evt.Rates)
 	}
+	return nil // CBR rates are not announced
+}
 
+func (s *Subscriber) notifyCrypto(ctx context.Context, rates []events.NormalizedCryptoRate) error {
 	subscribers, err := s.store.GetAllCryptoSubscribers(ctx)
 	if err != nil {
 		return err
@@ -85,24 +121,284 @@ func (s *Subscriber) process(ctx context.Context, data []byte) error {
 		if !ok {
 			continue
 		}
-		msg := fmt.Sprintf("💰 %s update: %.2f RUB", symbol, rate.PriceRUB)
+		msg := fmt.Sprintf("💰 %s update: %s RUB", symbol, rate.PriceRUB.StringFixed(2))
 		for _, tid := range tids {
-			s.sendTelegram(tid, msg)
+			s.sendTelegram(ctx, tid, msg)
 		}
 	}
 	return nil
 }
 
-func (s *Subscriber) sendTelegram(chatID int64, text string) {
+// checkAlerts evaluates the alert subscriptions on the symbols of a Binance
+// batch. The indicators are read once per symbol and interval, and a
+// subscriber is told when an alert starts to hold, not while it keeps holding.
+func (s *Subscriber) checkAlerts(ctx context.Context, rates []events.NormalizedCryptoRate) error {
+	subscribers, err := s.store.GetAllAlertsSubscribers(ctx)
+	if err != nil || len(subscribers) == 0 {
+		return err
+	}
+
+	symbols := make([]string, 0, len(rates))
+	for _, rate := range rates {
+		symbols = append(symbols, strings.TrimSuffix(rate.Symbol, "USDT"))
+	}
+	for _, g := range alertGroups(subscribers, symbols) {
+		res, err := s.indicators.CryptoIndicators(ctx, g.symbol, g.interval, strings.Join(g.specs, ","))
+		if err != nil {
+			// The other groups may still be computed; this one is checked on the next batch
+			logger.WarnContext(ctx, "alert indicators failed", "symbol", g.symbol, "interval", g.interval, "error", err)
+			continue
+		}
+		for _, a := range g.alerts {
+			met := a.Condition.Met(res.Latest)
+			for _, tid := range subscribers[a.String()] {
+				fresh, err := s.store.MarkAlert(ctx, a.String(), tid, met)
+				if err != nil {
+					return err
+				}
+				if fresh {
+					s.sendTelegram(ctx, tid, alertMessage(a, res.Latest))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// alertGroup is the alerts on one symbol and interval, which are checked
+// against a single indicators request.
+type alertGroup struct {
+	symbol   string
+	interval string
+	specs    []string
+	alerts   []indicators.Alert
+}
+
+// alertGroups groups the subscribed alerts on symbols by symbol and interval,
+// with the indicators each group needs. Subscriptions are stored in the form
+// ParseAlert gives them; any that no longer parse are skipped.
+func alertGroups(subscribers map[string][]int64, symbols []string) []alertGroup {
+	var groups []alertGroup
+	index := make(map[string]int)
+	for value := range subscribers {
+		a, err := indicators.ParseAlert(value)
+		if err != nil {
+			logger.Warn("skipping invalid alert subscription", "alert", value, "error", err)
+			continue
+		}
+		if !slices.Contains(symbols, a.Symbol) {
+			continue
+		}
+		specs, _ := a.Condition.Specs()
+		key := a.Symbol + " " + a.Interval
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, alertGroup{symbol: a.Symbol, interval: a.Interval})
+		}
+		g := &groups[i]
+		for _, spec := range specs {
+			if !slices.Contains(g.specs, spec.Name()) {
+				g.specs = append(g.specs, spec.Name())
+			}
+		}
+		g.alerts = append(g.alerts, a)
+	}
+	for i := range groups {
+		sort.Strings(groups[i].specs)
+		sort.Slice(groups[i].alerts, func(j, k int) bool { return groups[i].alerts[j].String() < groups[i].alerts[k].String() })
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].symbol != groups[j].symbol {
+			return groups[i].symbol < groups[j].symbol
+		}
+		return groups[i].interval < groups[j].interval
+	})
+	return groups
+}
+
+// alertMessage reports an alert that has started to hold with the values it
+// compared.
+func alertMessage(a indicators.Alert, latest map[string]float64) string {
+	values := []string{a.Condition.Indicator + " is " + strconv.FormatFloat(latest[a.Condition.Indicator], 'f', 2, 64)}
+	if a.Condition.Other != "" {
+		values = append(values, a.Condition.Other+" is "+strconv.FormatFloat(latest[a.Condition.Other], 'f', 2, 64))
+	}
+	return fmt.Sprintf("🔔 %s: %s", a, strings.Join(values, ", "))
+}
+
+// notifyMetals announces the newest price of every metal in the batch, once:
+// the batch repeats the prices of the last days on every collector run.
+func (s *Subscriber) notifyMetals(ctx context.Context, raw json.RawMessage) error {
+	var prices []events.NormalizedMetalPrice
+	if err := json.Unmarshal(raw, &prices); err != nil {
+		return err
+	}
+
+	subscribers, err := s.store.GetAllMetalsSubscribers(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range latestMetalPrices(prices) {
+		tids, ok := subscribers[p.Metal]
+		if !ok {
+			continue
+		}
+		fresh, err := s.store.MarkMetalNotified(ctx, p.Metal, p.Date.Format(time.DateOnly))
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			continue
+		}
+		msg := metalMessage(p)
+		for _, tid := range tids {
+			s.sendTelegram(ctx, tid, msg)
+		}
+	}
+	return nil
+}
+
+// notifyNominalChanges tells the subscribers of a currency that the CBR now
+// quotes it for a different number of units, so its rate jumps. The
+// normalizer reports each change once.
+func (s *Subscriber) notifyNominalChanges(ctx context.Context, raw json.RawMessage) error {
+	var changes []events.NominalChange
+	if err := json.Unmarshal(raw, &changes); err != nil {
+		return err
+	}
+
+	subscribers, err := s.store.GetAllCBRSubscribers(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range changes {
+		logger.InfoContext(ctx, "nominal changed", "currency", c.CurrencyCode, "from", c.PreviousNominal, "to", c.Nominal, "date", c.Date.Format(time.DateOnly))
+		msg := nominalMessage(c)
+		for _, tid := range subscribers[c.CurrencyCode] {
+			s.sendTelegram(ctx, tid, msg)
+		}
+	}
+	return nil
+}
+
+func nominalMessage(c events.NominalChange) string {
+	return fmt.Sprintf("📏 From %s the CBR quotes %s per %d units instead of %d; one unit costs %s RUB",
+		c.Date.Format(time.DateOnly), c.CurrencyCode, c.Nominal, c.PreviousNominal, c.UnitValueRUB.String())
+}
+
+// latestMetalPrices returns the newest price of each metal in prices.
+func latestMetalPrices(prices []events.NormalizedMetalPrice) []events.NormalizedMetalPrice {
+	latest := make(map[string]int)
+	var out []events.NormalizedMetalPrice
+	for _, p := range prices {
+		i, ok := latest[p.Metal]
+		if !ok {
+			latest[p.Metal] = len(out)
+			out = append(out, p)
+		} else if p.Date.After(out[i].Date) {
+			out[i] = p
+		}
+	}
+	return out
+}
+
+func metalMessage(p events.NormalizedMetalPrice) string {
+	return fmt.Sprintf("🪙 %s (%s) on %s: %s RUB per gram", p.Name, p.Metal, p.Date.Format(time.DateOnly), p.Buy.StringFixed(2))
+}
+
+// notifyKeyRate announces a change of the CBR key rate. The batch holds the
+// key rate of every business day of the collector window, so the latest
+// value is compared with the one last seen; RUONIA moves daily and is not
+// announced.
+func (s *Subscriber) notifyKeyRate(ctx context.Context, raw json.RawMessage) error {
+	var rates []events.IndicatorRate
+	if err := json.Unmarshal(raw, &rates); err != nil {
+		return err
+	}
+	latest, ok := latestIndicatorValue(rates, events.IndicatorKeyRate)
+	if !ok {
+		return nil
+	}
+
+	// Recorded whether anyone is subscribed, so a later subscriber is not
+	// told of an old change
+	previous, changed, err := s.store.MarkIndicatorValue(ctx, latest.Indicator, latest.Date.Format(time.DateOnly), latest.Value.String())
+	if err != nil || !changed {
+		return err
+	}
+	logger.InfoContext(ctx, "key rate changed", "from", previous, "to", latest.Value.String(), "effective", latest.Date.Format(time.DateOnly))
+
+	subscribers, err := s.store.GetAllIndicatorsSubscribers(ctx)
+	if err != nil {
+		return err
+	}
+	msg := keyRateMessage(previous, latest)
+	for _, tid := range subscribers[events.IndicatorKeyRate] {
+		s.sendTelegram(ctx, tid, msg)
+	}
+	return nil
+}
+
+// latestIndicatorValue returns the newest value of indicator in rates, dated
+// by the first day of the run of equal values it ends, which is the day it
+// took effect when the batch reaches back to the change.
+func latestIndicatorValue(rates []events.IndicatorRate, indicator string) (events.IndicatorRate, bool) {
+	var own []events.IndicatorRate
+	for _, r := range rates {
+		if r.Indicator == indicator {
+			own = append(own, r)
+		}
+	}
+	if len(own) == 0 {
+		return events.IndicatorRate{}, false
+	}
+	sort.Slice(own, func(i, j int) bool { return own[i].Date.Before(own[j].Date) })
+	latest := own[len(own)-1]
+	for i := len(own) - 2; i >= 0 && own[i].Value.Equal(latest.Value); i-- {
+		latest.Date = own[i].Date
+	}
+	return latest, true
+}
+
+func keyRateMessage(previous string, r events.IndicatorRate) string {
+	prev, err := money.Parse(previous)
+	if err != nil {
+		return fmt.Sprintf("🏦 CBR key rate is now %s%% from %s", r.Value.StringFixed(2), r.Date.Format(time.DateOnly))
+	}
+	return fmt.Sprintf("🏦 CBR key rate changed from %s%% to %s%% from %s", prev.StringFixed(2), r.Value.StringFixed(2), r.Date.Format(time.DateOnly))
+}
+
+// sendTelegram sends text to chatID. The call is traced by hand rather than
+// through tracing.Transport, whose spans would record the bot token in the URL;
+// for the same reason the URL is dropped from transport errors.
+func (s *Subscriber) sendTelegram(ctx context.Context, chatID int64, text string) {
 	if s.botToken == "" {
 		return
 	}
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", s.botToken)
+	_, span := tracing.Start(ctx, "telegram sendMessage")
+	endpoint := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", s.botToken)
 	body := fmt.Sprintf(`{"chat_id":%d,"text":%q}`, chatID, text)
-	resp, err := s.httpClient.Post(url, "application/json", strings.NewReader(body))
+	start := time.Now()
+	resp, err := s.httpClient.Post(endpoint, "application/json", strings.NewReader(body))
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		err = uerr.Err
+	}
+	if err == nil {
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			err = fmt.Errorf("telegram status %d", resp.StatusCode)
+		}
+	}
+	tracing.End(span, err)
+	metrics.TelegramSent(err)
 	if err != nil {
-		log.Printf("sendTelegram: %v", err)
+		logger.WarnContext(ctx, "telegram send failed", "chat_id", chatID, "error", err)
 		return
 	}
-	resp.Body.Close()
+	logger.DebugContext(ctx, "telegram sent", "chat_id", chatID, "duration_ms", logging.Millis(time.Since(start)))
 }
@@ -0,0 +1,113 @@
+package subscriber
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/events"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/indicators"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+)
+
+func TestLatestMetalPrices(t *testing.T) {
+	day := time.Date(2026, 4, 14, 0, 0, 0, 0, time.UTC)
+	prices := []events.NormalizedMetalPrice{
+		{Date: day, Metal: "XAU", Buy: money.MustParse("8512.37")},
+		{Date: day, Metal: "XAG", Buy: money.MustParse("96.84")},
+		{Date: day.AddDate(0, 0, 1), Metal: "XAU", Buy: money.MustParse("8540.1")},
+	}
+	latest := latestMetalPrices(prices)
+	if len(latest) != 2 {
+		t.Fatalf("expected one price per metal, got %+v", latest)
+	}
+	if latest[0].Metal != "XAU" || latest[0].Buy.String() != "8540.1" {
+		t.Errorf("expected the 15 April gold price, got %+v", latest[0])
+	}
+}
+
+func TestMetalMessage(t *testing.T) {
+	p := events.NormalizedMetalPrice{Date: time.Date(2026, 4, 15, 0, 0, 0, 0, time.UTC), Metal: "XAU", Name: "Gold", Buy: money.MustParse("8540.1")}
+	want := "🪙 Gold (XAU) on 2026-04-15: 8540.10 RUB per gram"
+	if got := metalMessage(p); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestLatestIndicatorValue(t *testing.T) {
+	day := time.Date(2026, 3, 19, 0, 0, 0, 0, time.UTC)
+	rates := []events.IndicatorRate{
+		{Date: day.AddDate(0, 0, 5), Indicator: events.IndicatorKeyRate, Value: money.MustParse("15.5")},
+		{Date: day, Indicator: events.IndicatorKeyRate, Value: money.MustParse("16")},
+		{Date: day.AddDate(0, 0, 4), Indicator: events.IndicatorKeyRate, Value: money.MustParse("15.50")},
+		{Date: day.AddDate(0, 0, 5), Indicator: events.IndicatorRUONIA, Value: money.MustParse("15.84")},
+	}
+	latest, ok := latestIndicatorValue(rates, events.IndicatorKeyRate)
+	if !ok {
+		t.Fatal("expected a key rate")
+	}
+	// Dated by the decision, not by the last day republished
+	if want := day.AddDate(0, 0, 4); !latest.Date.Equal(want) || latest.Value.String() != "15.5" {
+		t.Errorf("expected 15.5 from %s, got %s from %s", want, latest.Value, latest.Date)
+	}
+	if _, ok := latestIndicatorValue(rates[3:], events.IndicatorKeyRate); ok {
+		t.Error("expected no key rate in a RUONIA-only batch")
+	}
+}
+
+func TestKeyRateMessage(t *testing.T) {
+	r := events.IndicatorRate{Date: time.Date(2026, 3, 23, 0, 0, 0, 0, time.UTC), Indicator: events.IndicatorKeyRate, Value: money.MustParse("15.5")}
+	want := "🏦 CBR key rate changed from 16.00% to 15.50% from 2026-03-23"
+	if got := keyRateMessage("16", r); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestNominalMessage(t *testing.T) {
+	c := events.NominalChange{Date: time.Date(2024, 7, 2, 0, 0, 0, 0, time.UTC), CurrencyCode: "KZT", PreviousNominal: 100, Nominal: 1000, UnitValueRUB: money.MustParse("0.1953")}
+	want := "📏 From 2024-07-02 the CBR quotes KZT per 1000 units instead of 100; one unit costs 0.1953 RUB"
+	if got := nominalMessage(c); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestAlertGroups(t *testing.T) {
+	subscribers := map[string][]int64{
+		"BTC 1h rsi14>70":    {1},
+		"BTC 1h sma20>sma50": {2},
+		"BTC 4h rsi14<30":    {1},
+		"ETH 1h macd_hist>0": {3},
+		"SOL 1h rsi14>70":    {4},
+		"not an alert":       {5},
+	}
+	groups := alertGroups(subscribers, []string{"BTC", "ETH"})
+	if len(groups) != 3 {
+		t.Fatalf("expected BTC 1h, BTC 4h and ETH 1h, got %+v", groups)
+	}
+	g := groups[0]
+	if g.symbol != "BTC" || g.interval != "1h" || strings.Join(g.specs, ",") != "rsi14,sma20,sma50" || len(g.alerts) != 2 {
+		t.Errorf("unexpected BTC 1h group %+v", g)
+	}
+	if g := groups[1]; g.symbol != "BTC" || g.interval != "4h" || strings.Join(g.specs, ",") != "rsi14" {
+		t.Errorf("unexpected BTC 4h group %+v", g)
+	}
+	if g := groups[2]; g.symbol != "ETH" || strings.Join(g.specs, ",") != "macd" || g.alerts[0].String() != "ETH 1h macd_hist>0" {
+		t.Errorf("unexpected ETH group %+v", g)
+	}
+}
+
+func TestAlertMessage(t *testing.T) {
+	latest := map[string]float64{"rsi14": 72.346, "sma20": 6012345.5, "sma50": 5998000}
+	for alert, want := range map[string]string{
+		"BTC rsi14>70":       "🔔 BTC 1h rsi14>70: rsi14 is 72.35",
+		"BTC 4h sma20>sma50": "🔔 BTC 4h sma20>sma50: sma20 is 6012345.50, sma50 is 5998000.00",
+	} {
+		a, err := indicators.ParseAlert(alert)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := alertMessage(a, latest); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+// Package analytics computes descriptive statistics and correlations over
+// rate series. It is pure math with no storage dependencies so that every
+// service can reuse it.
+package analytics
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Point is a single observation of a series.
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Summary holds statistics over a series ordered by time.
+type Summary struct {
+	Count int       `json:"count"`
+	From  time.Time `json:"from"`
+	To    time.Time `json:"to"`
+	First float64   `json:"first"`
+	Last  float64   `json:"last"`
+	Mean  float64   `json:"mean"`
+	Min   float64   `json:"min"`
+	Max   float64   `json:"max"`
+	// StdDev is the population standard deviation of the values.
+	StdDev float64 `json:"stddev"`
+	// CoefficientOfVariation is StdDev/Mean in percent (shown as volatility in the web UI).
+	CoefficientOfVariation float64 `json:"coefficient_of_variation_pct"`
+	// Volatility is the sample standard deviation of daily log returns, in percent.
+	Volatility float64 `json:"volatility_pct"`
+	// MaxDrawdown is the largest peak-to-trough decline, in percent (>= 0).
+	MaxDrawdown float64 `json:"max_drawdown_pct"`
+	// ChangePct is (Last-First)/First in percent.
+	ChangePct float64 `json:"change_pct"`
+}
+
+// Summarize returns statistics over points. Points are sorted by time and
+// collapsed to one close per UTC day before log returns are computed, so
+// intraday crypto candles and daily CBR rates give comparable volatility.
+func Summarize(points []Point) Summary {
+	pts := sorted(points)
+	if len(pts) == 0 {
+		return Summary{}
+	}
+
+	s := Summary{
+		Count: len(pts),
+		From:  pts[0].Time,
+		To:    pts[len(pts)-1].Time,
+		First: pts[0].Value,
+		Last:  pts[len(pts)-1].Value,
+		Min:   pts[0].Value,
+		Max:   pts[0].Value,
+	}
+
+	var sum float64
+	for _, p := range pts {
+		sum += p.Value
+		s.Min = math.Min(s.Min, p.Value)
+		s.Max = math.Max(s.Max, p.Value)
+	}
+	s.Mean = sum / float64(len(pts))
+
+	var sq float64
+	for _, p := range pts {
+		sq += (p.Value - s.Mean) * (p.Value - s.Mean)
+	}
+	s.StdDev = math.Sqrt(sq / float64(len(pts)))
+	if s.Mean != 0 {
+		s.CoefficientOfVariation = s.StdDev / s.Mean * 100
+	}
+	if s.First != 0 {
+		s.ChangePct = (s.Last - s.First) / s.First * 100
+	}
+	s.MaxDrawdown = maxDrawdown(pts) * 100
+	s.Volatility = sampleStdDev(LogReturns(DailyCloses(pts))) * 100
+	return s
+}
+
+// DailyCloses keeps the last observation of every UTC calendar day.
+func DailyCloses(points []Point) []Point {
+	pts := sorted(points)
+	var out []Point
+	for _, p := range pts {
+		if n := len(out); n > 0 && sameUTCDay(out[n-1].Time, p.Time) {
+			out[n-1] = p
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// LogReturns returns ln(v[i]/v[i-1]) for consecutive positive values.
+func LogReturns(points []Point) []float64 {
+	var out []float64
+	for i := 1; i < len(points); i++ {
+		prev, cur := points[i-1].Value, points[i].Value
+		if prev > 0 && cur > 0 {
+			out = append(out, math.Log(cur/prev))
+		}
+	}
+	return out
+}
+
+// CorrelationMatrix is a symmetric matrix of Pearson coefficients; Matrix[i][j]
+// is the correlation between Codes[i] and Codes[j].
+type CorrelationMatrix struct {
+	Codes  []string    `json:"codes"`
+	Matrix [][]float64 `json:"matrix"`
+	// Observations is the number of common daily returns the matrix is based on.
+	Observations int `json:"observations"`
+}
+
+// Correlate computes the Pearson correlation of daily log returns for every
+// pair of series. Series are aligned on the UTC days present in all of them;
+// correlating returns rather than levels avoids spurious trend correlation.
+// Pairs where either side has no variance report 0.
+func Correlate(codes []string, series map[string][]Point) CorrelationMatrix {
+	daily := make(map[string]map[string]float64, len(codes))
+	common := map[string]bool(nil)
+	for _, code := range codes {
+		byDay := make(map[string]float64)
+		for _, p := range DailyCloses(series[code]) {
+			byDay[p.Time.UTC().Format("2006-01-02")] = p.Value
+		}
+		daily[code] = byDay
+		if common == nil {
+			common = make(map[string]bool, len(byDay))
+			for d := range byDay {
+				common[d] = true
+			}
+			continue
+		}
+		for d := range common {
+			if _, ok := byDay[d]; !ok {
+				delete(common, d)
+			}
+		}
+	}
+
+	days := make([]string, 0, len(common))
+	for d := range common {
+		days = append(days, d)
+	}
+	sort.Strings(days)
+
+	returns := make([][]float64, len(codes))
+	for i, code := range codes {
+		pts := make([]Point, len(days))
+		for j, d := range days {
+			pts[j] = Point{Value: daily[code][d]}
+		}
+		returns[i] = LogReturns(pts)
+	}
+
+	m := CorrelationMatrix{Codes: codes, Matrix: make([][]float64, len(codes))}
+	if len(codes) > 0 {
+		m.Observations = len(returns[0])
+	}
+	for i := range codes {
+		m.Matrix[i] = make([]float64, len(codes))
+		for j := range codes {
+			if i == j {
+				m.Matrix[i][j] = 1
+				continue
+			}
+			m.Matrix[i][j] = Pearson(returns[i], returns[j])
+		}
+	}
+	return m
+}
+
+// Pearson returns the Pearson correlation coefficient of x and y, using the
+// common prefix when lengths differ. It returns 0 when either side is constant.
+func Pearson(x, y []float64) float64 {
+	n := len(x)
+	if len(y) < n {
+		n = len(y)
+	}
+	if n < 2 {
+		return 0
+	}
+	var mx, my float64
+	for i := 0; i < n; i++ {
+		mx += x[i]
+		my += y[i]
+	}
+	mx /= float64(n)
+	my /= float64(n)
+
+	var cov, vx, vy float64
+	for i := 0; i < n; i++ {
+		dx, dy := x[i]-mx, y[i]-my
+		cov += dx * dy
+		vx += dx * dx
+		vy += dy * dy
+	}
+	if vx == 0 || vy == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(vx*vy)
+}
+
+func maxDrawdown(pts []Point) float64 {
+	var peak, worst float64
+	for _, p := range pts {
+		if p.Value > peak {
+			peak = p.Value
+		}
+		if peak > 0 {
+			if dd := (peak - p.Value) / peak; dd > worst {
+				worst = dd
+			}
+		}
+	}
+	return worst
+}
+
+func sampleStdDev(xs []float64) float64 {
+	if len(xs) < 2 {
+		return 0
+	}
+	var mean float64
+	for _, x := range xs {
+		mean += x
+	}
+	mean /= float64(len(xs))
+	var sq float64
+	for _, x := range xs {
+		sq += (x - mean) * (x - mean)
+	}
+	return math.Sqrt(sq / float64(len(xs)-1))
+}
+
+func sorted(points []Point) []Point {
+	pts := append([]Point(nil), points...)
+	sort.SliceStable(pts, func(i, j int) bool { return pts[i].Time.Before(pts[j].Time) })
+	return pts
+}
+
+func sameUTCDay(a, b time.Time) bool {
+	ay, am, ad := a.UTC().Date()
+	by, bm, bd := b.UTC().Date()
+	return ay == by && am == bm && ad == bd
+}
@@ -0,0 +1,126 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func series(start time.Time, step time.Duration, values ...float64) []Point {
+	pts := make([]Point, len(values))
+	for i, v := range values {
+		pts[i] = Point{Time: start.Add(time.Duration(i) * step), Value: v}
+	}
+	return pts
+}
+
+var day0 = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func TestSummarize_basic(t *testing.T) {
+	s := Summarize(series(day0, 24*time.Hour, 100, 110, 99, 105))
+
+	if s.Count != 4 || s.First != 100 || s.Last != 105 {
+		t.Fatalf("unexpected summary: %+v", s)
+	}
+	if s.Mean != 103.5 || s.Min != 99 || s.Max != 110 {
+		t.Errorf("mean/min/max: got %f/%f/%f", s.Mean, s.Min, s.Max)
+	}
+	if math.Abs(s.ChangePct-5) > 1e-9 {
+		t.Errorf("expected change 5%%, got %f", s.ChangePct)
+	}
+	// Peak 110 → trough 99 is a 10% drawdown.
+	if math.Abs(s.MaxDrawdown-10) > 1e-9 {
+		t.Errorf("expected max drawdown 10%%, got %f", s.MaxDrawdown)
+	}
+	wantStd := math.Sqrt((12.25 + 42.25 + 20.25 + 2.25) / 4)
+	if math.Abs(s.StdDev-wantStd) > 1e-9 {
+		t.Errorf("expected stddev %f, got %f", wantStd, s.StdDev)
+	}
+	if s.Volatility <= 0 {
+		t.Errorf("expected positive volatility, got %f", s.Volatility)
+	}
+}
+
+func TestSummarize_unsortedInput(t *testing.T) {
+	pts := series(day0, 24*time.Hour, 100, 120)
+	pts[0], pts[1] = pts[1], pts[0]
+	if s := Summarize(pts); s.First != 100 || s.Last != 120 {
+		t.Errorf("expected points sorted by time, got first=%f last=%f", s.First, s.Last)
+	}
+}
+
+func TestSummarize_empty(t *testing.T) {
+	if s := Summarize(nil); s.Count != 0 {
+		t.Errorf("expected empty summary, got %+v", s)
+	}
+}
+
+func TestSummarize_constantSeries(t *testing.T) {
+	s := Summarize(series(day0, 24*time.Hour, 90, 90, 90))
+	if s.StdDev != 0 || s.Volatility != 0 || s.MaxDrawdown != 0 || s.ChangePct != 0 {
+		t.Errorf("constant series should have zero dispersion: %+v", s)
+	}
+}
+
+func TestDailyCloses_keepsLastOfDay(t *testing.T) {
+	pts := series(day0, 8*time.Hour, 1, 2, 3, 4, 5, 6)
+	daily := DailyCloses(pts)
+	if len(daily) != 2 || daily[0].Value != 3 || daily[1].Value != 6 {
+		t.Errorf("unexpected daily closes: %+v", daily)
+	}
+}
+
+func TestVolatility_usesDailyReturns(t *testing.T) {
+	// Intraday noise within a day must not affect daily-return volatility.
+	intraday := []Point{
+		{Time: day0.Add(1 * time.Hour), Value: 50},
+		{Time: day0.Add(23 * time.Hour), Value: 100},
+		{Time: day0.Add(25 * time.Hour), Value: 500},
+		{Time: day0.Add(47 * time.Hour), Value: 110},
+		{Time: day0.Add(71 * time.Hour), Value: 121},
+	}
+	daily := series(day0.Add(23*time.Hour), 24*time.Hour, 100, 110, 121)
+	if a, b := Summarize(intraday).Volatility, Summarize(daily).Volatility; math.Abs(a-b) > 1e-9 {
+		t.Errorf("expected equal volatility, got %f vs %f", a, b)
+	}
+}
+
+func TestPearson(t *testing.T) {
+	x := []float64{1, 2, 3, 4}
+	if got := Pearson(x, []float64{2, 4, 6, 8}); math.Abs(got-1) > 1e-9 {
+		t.Errorf("expected 1, got %f", got)
+	}
+	if got := Pearson(x, []float64{8, 6, 4, 2}); math.Abs(got+1) > 1e-9 {
+		t.Errorf("expected -1, got %f", got)
+	}
+	if got := Pearson(x, []float64{5, 5, 5, 5}); got != 0 {
+		t.Errorf("expected 0 for constant series, got %f", got)
+	}
+}
+
+func TestCorrelate_alignsOnCommonDays(t *testing.T) {
+	usd := series(day0, 24*time.Hour, 90, 91, 92, 91, 93)
+	eur := series(day0, 24*time.Hour, 99, 100.1, 101.2, 100.1, 102.3)
+	// BTC has an extra day that others lack; it must be dropped.
+	btc := append(series(day0, 24*time.Hour, 100, 101.1, 102.2, 101.1, 103.3),
+		Point{Time: day0.AddDate(0, 0, 10), Value: 1})
+
+	m := Correlate([]string{"USD", "EUR", "BTC"}, map[string][]Point{"USD": usd, "EUR": eur, "BTC": btc})
+
+	if m.Observations != 4 {
+		t.Errorf("expected 4 common returns, got %d", m.Observations)
+	}
+	for i := range m.Codes {
+		if m.Matrix[i][i] != 1 {
+			t.Errorf("diagonal must be 1, got %f", m.Matrix[i][i])
+		}
+		for j := range m.Codes {
+			if m.Matrix[i][j] != m.Matrix[j][i] {
+				t.Errorf("matrix must be symmetric at %d,%d", i, j)
+			}
+		}
+	}
+	if m.Matrix[0][1] < 0.99 {
+		t.Errorf("USD/EUR move together, expected ~1, got %f", m.Matrix[0][1])
+	}
+}
@@ -0,0 +1,43 @@
+package apiv1
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+)
+
+func TestCodeForStatus(t *testing.T) {
+	cases := map[int]string{
+		http.StatusBadRequest:          CodeBadRequest,
+		http.StatusMethodNotAllowed:    CodeBadRequest,
+		http.StatusNotFound:            CodeNotFound,
+		http.StatusInternalServerError: CodeInternal,
+		http.StatusBadGateway:          CodeUnavailable,
+		http.StatusServiceUnavailable:  CodeUnavailable,
+	}
+	for status, want := range cases {
+		if got := CodeForStatus(status); got != want {
+			t.Errorf("%d: expected %s, got %s", status, want, got)
+		}
+	}
+}
+
+func TestNewError_json(t *testing.T) {
+	b, _ := json.Marshal(NewError(http.StatusNotFound, "no data for XYZ in range"))
+	want := `{"error":{"code":"not_found","message":"no data for XYZ in range"}}`
+	if string(b) != want {
+		t.Errorf("expected %s, got %s", want, b)
+	}
+}
+
+func TestResponse_json(t *testing.T) {
+	rates := []CryptoRate{{Time: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Symbol: "BTC", Close: money.NewFromInt(5600000)}}
+	b, _ := json.Marshal(Response[[]CryptoRate]{Data: rates})
+	want := `{"data":[{"time":"2024-03-01T00:00:00Z","symbol":"BTC","open":"0","high":"0","low":"0","close":"5600000","volume":"0"}]}`
+	if string(b) != want {
+		t.Errorf("expected %s, got %s", want, b)
+	}
+}
@@ -0,0 +1,97 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDay(t *testing.T) {
+	tests := []struct {
+		name string
+		at   time.Time
+		want string
+	}{
+		{"UTC midnight is 03:00 in Moscow", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), "2024-01-15"},
+		{"last instant of a Moscow day", time.Date(2024, 1, 15, 20, 59, 59, 999999999, time.UTC), "2024-01-15"},
+		{"Moscow midnight", time.Date(2024, 1, 15, 21, 0, 0, 0, time.UTC), "2024-01-16"},
+		{"between 00:00 and 03:00 MSK", time.Date(2024, 1, 15, 23, 30, 0, 0, time.UTC), "2024-01-16"},
+		{"just before publication", time.Date(2024, 1, 16, 11, 29, 0, 0, Moscow), "2024-01-16"},
+		{"just after publication", time.Date(2024, 1, 16, 11, 31, 0, 0, Moscow), "2024-01-16"},
+		{"west of UTC", time.Date(2024, 1, 15, 19, 0, 0, 0, time.FixedZone("EST", -5*3600)), "2024-01-16"},
+		{"east of Moscow", time.Date(2024, 1, 16, 2, 0, 0, 0, time.FixedZone("+07", 7*3600)), "2024-01-15"},
+		{"Moscow summer time before 2011", time.Date(2010, 7, 1, 20, 30, 0, 0, time.UTC), "2010-07-02"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Day(tc.at)
+			if got.Format(time.DateOnly) != tc.want || got.Location() != time.UTC || !got.Equal(Date(got)) {
+				t.Errorf("Day(%s) = %s, want %s at midnight UTC", tc.at, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDate_keepsCalendarDate(t *testing.T) {
+	// A date value in a zone east of Moscow is not moved to the previous day
+	d := time.Date(2024, 1, 16, 0, 0, 0, 0, time.FixedZone("+07", 7*3600))
+	if got := Date(d).Format(time.DateOnly); got != "2024-01-16" {
+		t.Errorf("Date = %s, want 2024-01-16", got)
+	}
+	parsed, err := ParseDate("2024-01-16")
+	if err != nil || !Date(parsed).Equal(parsed) || !Day(parsed).Equal(parsed) {
+		t.Errorf("ParseDate = %s, %v, want midnight UTC kept by Date and Day", parsed, err)
+	}
+}
+
+func TestParseSheetDate(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"2024-01-16T11:30:00+03:00", "2024-01-16"},
+		{"2024-01-16T00:00:00+03:00", "2024-01-16"},
+		{"2024-01-15T21:00:00Z", "2024-01-16"},
+		{"2024/01/16 11:30:00", "2024-01-16"},
+		{"2024/01/16 00:30:00", "2024-01-16"},
+	}
+	for _, tc := range tests {
+		got, err := ParseSheetDate(tc.in)
+		if err != nil || got.Format(time.DateOnly) != tc.want || got.Location() != time.UTC {
+			t.Errorf("ParseSheetDate(%q) = %s, %v, want %s", tc.in, got, err, tc.want)
+		}
+	}
+	for _, in := range []string{"tomorrow", "16.01.2024", ""} {
+		if _, err := ParseSheetDate(in); err != ErrSheetDate {
+			t.Errorf("ParseSheetDate(%q): expected ErrSheetDate, got %v", in, err)
+		}
+	}
+}
+
+func TestBusinessDays(t *testing.T) {
+	// Friday to Tuesday
+	days := BusinessDays(time.Date(2024, 1, 12, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC))
+	var got []string
+	for _, d := range days {
+		got = append(got, d.Format(time.DateOnly))
+	}
+	if len(got) != 3 || got[0] != "2024-01-12" || got[1] != "2024-01-15" || got[2] != "2024-01-16" {
+		t.Errorf("unexpected business days %v", got)
+	}
+	if IsBusinessDay(time.Date(2024, 1, 13, 0, 0, 0, 0, time.UTC)) {
+		t.Error("Saturday is not a business day")
+	}
+}
+
+func TestSheetDays(t *testing.T) {
+	// Friday to Tuesday: Saturday's sheet covers Sunday and Monday
+	days := SheetDays(time.Date(2024, 1, 12, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC))
+	var got []string
+	for _, d := range days {
+		got = append(got, d.Format(time.DateOnly))
+	}
+	if len(got) != 3 || got[0] != "2024-01-12" || got[1] != "2024-01-13" || got[2] != "2024-01-16" {
+		t.Errorf("unexpected sheet days %v", got)
+	}
+	if IsSheetDay(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Error("no sheet is dated Monday")
+	}
+}
@@ -1,7 +1,15 @@
 // Package events defines the Kafka message types shared across microservices.
+//
+// Rates and prices are money.Decimal values written as JSON strings; numbers
+// in messages from before the switch still decode.
 package events
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+)
 
 // TopicRawRates is the Kafka topic for raw (unprocessed) currency rates
 // published by Data Collector Service.
@@ -11,24 +19,54 @@ const TopicRawRates = "raw-rates"
 // published by Normalization Service and consumed by History and Notification services.
 const TopicNormalizedRates = "normalized-rates"
 
+// TopicQuarantinedRates is the Kafka topic for rates the Normalization
+// Service rejected. Nothing consumes it; it keeps bad data out of storage
+// and notifications while leaving it available for inspection.
+const TopicQuarantinedRates = "quarantined-rates"
+
 // SourceType identifies the origin of a rate event.
 type SourceType string
 
 const (
 	SourceCBR     SourceType = "cbr"
 	SourceBinance SourceType = "binance"
+	// SourceCBRMetals is the CBR's daily discount prices of precious metals.
+	SourceCBRMetals SourceType = "cbr_metals"
+	// SourceCBRIndicators is the CBR's key rate and RUONIA.
+	SourceCBRIndicators SourceType = "cbr_indicators"
+	// SourceCBRNominal is a change of the number of units the CBR quotes a
+	// currency for, derived by the Normalization Service from CBR sheets.
+	SourceCBRNominal SourceType = "cbr_nominal"
+)
+
+// Money-market indicators the CBR publishes, as IndicatorRate codes. Their
+// values are percent per annum.
+const (
+	// IndicatorKeyRate is the CBR key rate. It changes on board decision
+	// dates and stays in effect until the next change.
+	IndicatorKeyRate = "KEY_RATE"
+	// IndicatorRUONIA is the overnight ruble interbank rate, fixed every
+	// business day.
+	IndicatorRUONIA = "RUONIA"
 )
 
+// QuoteRUB is the base currency of every CBR rate; all other quotes are
+// derived from it via cross rates.
+const QuoteRUB = "RUB"
+
 // RawCBRRate is a raw currency rate event from CBR API.
 type RawCBRRate struct {
-	Date        string  `json:"date"`
-	CharCode    string  `json:"char_code"`
-	NumCode     string  `json:"num_code"`
-	Nominal     int     `json:"nominal"`
-	Name        string  `json:"name"`
-	Value       float64 `json:"value"`
-	Previous    float64 `json:"previous"`
-	CollectedAt time.Time `json:"collected_at"`
+	Date        string         `json:"date"`
+	CharCode    string         `json:"char_code"`
+	NumCode     string         `json:"num_code"`
+	Nominal     int            `json:"nominal"`
+	Name        string         `json:"name"`
+	Value       money.Decimal  `json:"value"`
+	Previous    money.Decimal  `json:"previous"`
+	VunitRate   *money.Decimal `json:"vunit_rate,omitempty"` // price of one unit, when the sheet has it
+	CollectedAt time.Time      `json:"collected_at"`
+	// SourceURL is the daily_json.js the rate was read from.
+	SourceURL string `json:"source_url,omitempty"`
 }
 
 // RawCBRRatesEvent wraps a batch of CBR rates for Kafka.
@@ -39,14 +77,14 @@ type RawCBRRatesEvent struct {
 
 // RawCryptoRate is a raw OHLCV record from Binance.
 type RawCryptoRate struct {
-	Symbol      string    `json:"symbol"`
-	Timestamp   time.Time `json:"timestamp"`
-	Open        float64   `json:"open"`
-	High        float64   `json:"high"`
-	Low         float64   `json:"low"`
-	Close       float64   `json:"close"`
-	Volume      float64   `json:"volume"`
-	CollectedAt time.Time `json:"collected_at"`
+	Symbol      string        `json:"symbol"`
+	Timestamp   time.Time     `json:"timestamp"`
+	Open        money.Decimal `json:"open"`
+	High        money.Decimal `json:"high"`
+	Low         money.Decimal `json:"low"`
+	Close       money.Decimal `json:"close"`
+	Volume      money.Decimal `json:"volume"`
+	CollectedAt time.Time     `json:"collected_at"`
 }
 
 // RawCryptoRatesEvent wraps a batch of Binance OHLCV records for Kafka.
@@ -55,14 +93,66 @@ type RawCryptoRatesEvent struct {
 	Rates  []RawCryptoRate `json:"rates"`
 }
 
+// RawMetalPrice is a discount price of a precious metal from the CBR's
+// xml_metall.asp, as published: Date is DD.MM.YYYY and Code the CBR's metal
+// code (1 gold, 2 silver, 3 platinum, 4 palladium). Prices are in RUB per
+// gram.
+type RawMetalPrice struct {
+	Date        string        `json:"date"`
+	Code        int           `json:"code"`
+	Buy         money.Decimal `json:"buy"`
+	Sell        money.Decimal `json:"sell"`
+	CollectedAt time.Time     `json:"collected_at"`
+	// SourceURL is the xml_metall.asp query the price was read from.
+	SourceURL string `json:"source_url,omitempty"`
+}
+
+// RawMetalPricesEvent wraps a batch of CBR metal prices for Kafka.
+type RawMetalPricesEvent struct {
+	Source SourceType      `json:"source"`
+	Rates  []RawMetalPrice `json:"rates"`
+}
+
+// RawIndicatorRate is a value of a CBR indicator from the DailyInfo web
+// service, as published: Date is the XML date time the value took effect
+// (2024-01-09T00:00:00+03:00).
+type RawIndicatorRate struct {
+	Date        string        `json:"date"`
+	Indicator   string        `json:"indicator"`
+	Value       money.Decimal `json:"value"`
+	CollectedAt time.Time     `json:"collected_at"`
+	// SourceURL is the DailyInfo query the value was read from.
+	SourceURL string `json:"source_url,omitempty"`
+}
+
+// RawIndicatorRatesEvent wraps a batch of CBR indicator values for Kafka.
+type RawIndicatorRatesEvent struct {
+	Source SourceType         `json:"source"`
+	Rates  []RawIndicatorRate `json:"rates"`
+}
+
 // NormalizedCBRRate is a CBR rate normalized to a unified schema.
 type NormalizedCBRRate struct {
-	Date         time.Time `json:"date"`
-	CurrencyCode string    `json:"currency_code"`
-	CurrencyName string    `json:"currency_name"`
-	Nominal      int       `json:"nominal"`
-	ValueRUB     float64   `json:"value_rub"`
-	PreviousRUB  float64   `json:"previous_rub"`
+	Date         time.Time     `json:"date"`
+	CurrencyCode string        `json:"currency_code"`
+	CurrencyName string        `json:"currency_name"`
+	Nominal      int           `json:"nominal"`
+	ValueRUB     money.Decimal `json:"value_rub"`
+	PreviousRUB  money.Decimal `json:"previous_rub"`
+	// UnitValueRUB is the price of one unit: the CBR's VunitRate, or
+	// ValueRUB / Nominal when the sheet has none. Unlike ValueRUB it does not
+	// jump when the CBR changes the nominal of a currency.
+	UnitValueRUB money.Decimal `json:"unit_value_rub"`
+	// PreviousNominal is set on the first sheet with a new Nominal to the
+	// nominal of the sheet before; see NominalChange.
+	PreviousNominal int `json:"previous_nominal,omitempty"`
+	// Quotes holds the price of Nominal units in each configured quote
+	// currency (e.g. "USD", "EUR"), derived from CBR cross rates.
+	Quotes map[string]money.Decimal `json:"quotes,omitempty"`
+	// SourceURL and CollectedAt are passed on from the raw rate, so the
+	// stored revision records where and when the value was fetched.
+	SourceURL   string    `json:"source_url,omitempty"`
+	CollectedAt time.Time `json:"collected_at"`
 }
 
 // NormalizedCBRRatesEvent wraps a batch of normalized CBR rates for Kafka.
@@ -71,16 +161,39 @@ type NormalizedCBRRatesEvent struct {
 	Rates  []NormalizedCBRRate `json:"rates"`
 }
 
+// NominalChange is a date on which the CBR started quoting a currency for
+// Nominal units instead of PreviousNominal. ValueRUB jumps on that date;
+// UnitValueRUB, the price of one unit, does not.
+type NominalChange struct {
+	Date            time.Time     `json:"date"`
+	CurrencyCode    string        `json:"currency_code"`
+	CurrencyName    string        `json:"currency_name"`
+	PreviousNominal int           `json:"previous_nominal"`
+	Nominal         int           `json:"nominal"`
+	UnitValueRUB    money.Decimal `json:"unit_value_rub"`
+}
+
+// NominalChangesEvent wraps the nominal changes found in one CBR sheet for
+// Kafka. It follows the NormalizedCBRRatesEvent of the sheet on
+// TopicNormalizedRates.
+type NominalChangesEvent struct {
+	Source SourceType      `json:"source"`
+	Rates  []NominalChange `json:"rates"`
+}
+
 // NormalizedCryptoRate is a crypto rate normalized and converted to RUB.
 type NormalizedCryptoRate struct {
-	Symbol    string    `json:"symbol"`
-	Timestamp time.Time `json:"timestamp"`
-	Open      float64   `json:"open"`
-	High      float64   `json:"high"`
-	Low       float64   `json:"low"`
-	Close     float64   `json:"close"`
-	Volume    float64   `json:"volume"`
-	PriceRUB  float64   `json:"price_rub"` // Close price in RUB
+	Symbol    string        `json:"symbol"`
+	Timestamp time.Time     `json:"timestamp"`
+	Open      money.Decimal `json:"open"`
+	High      money.Decimal `json:"high"`
+	Low       money.Decimal `json:"low"`
+	Close     money.Decimal `json:"close"`
+	Volume    money.Decimal `json:"volume"`
+	PriceRUB  money.Decimal `json:"price_rub"` // Close price in RUB
+	// Quotes holds the Close price in each configured quote currency,
+	// derived from PriceRUB and CBR cross rates.
+	Quotes map[string]money.Decimal `json:"quotes,omitempty"`
 }
 
 // NormalizedCryptoRatesEvent wraps a batch of normalized crypto rates for Kafka.
@@ -88,3 +201,63 @@ type NormalizedCryptoRatesEvent struct {
 	Source SourceType             `json:"source"`
 	Rates  []NormalizedCryptoRate `json:"rates"`
 }
+
+// NormalizedMetalPrice is a CBR metal price keyed by the ISO 4217 code of
+// the metal (XAU, XAG, XPT, XPD). Buy and Sell are in RUB per gram.
+type NormalizedMetalPrice struct {
+	Date        time.Time     `json:"date"`
+	Metal       string        `json:"metal"`
+	Name        string        `json:"name"`
+	Buy         money.Decimal `json:"buy"`
+	Sell        money.Decimal `json:"sell"`
+	SourceURL   string        `json:"source_url,omitempty"`
+	CollectedAt time.Time     `json:"collected_at"`
+}
+
+// NormalizedMetalPricesEvent wraps a batch of normalized metal prices for Kafka.
+type NormalizedMetalPricesEvent struct {
+	Source SourceType             `json:"source"`
+	Rates  []NormalizedMetalPrice `json:"rates"`
+}
+
+// IndicatorRate is a normalized value of a CBR indicator (IndicatorKeyRate,
+// IndicatorRUONIA) in percent per annum. It takes effect on Date and stays
+// in effect until the next value of the indicator.
+type IndicatorRate struct {
+	Date        time.Time     `json:"date"`
+	Indicator   string        `json:"indicator"`
+	Name        string        `json:"name"`
+	Value       money.Decimal `json:"value"`
+	SourceURL   string        `json:"source_url,omitempty"`
+	CollectedAt time.Time     `json:"collected_at"`
+}
+
+// IndicatorRatesEvent wraps a batch of normalized indicator values for Kafka.
+type IndicatorRatesEvent struct {
+	Source SourceType      `json:"source"`
+	Rates  []IndicatorRate `json:"rates"`
+}
+
+// QuarantinedRate is a raw rate that failed validation in the Normalization
+// Service, or a record the Data Collector could not parse, with the rule it
+// broke.
+type QuarantinedRate struct {
+	Source SourceType `json:"source"`
+	Rule   string     `json:"rule"`
+	Reason string     `json:"reason"`
+	// Rate is the RawCBRRate, RawCryptoRate, RawMetalPrice or
+	// RawIndicatorRate as the collector sent it, or the upstream record
+	// for RuleUnparseable.
+	Rate          json.RawMessage `json:"rate"`
+	QuarantinedAt time.Time       `json:"quarantined_at"`
+}
+
+// RuleUnparseable is the rule of a record the Data Collector could not parse
+// into a raw rate. Its Rate is the record as the upstream API returned it.
+const RuleUnparseable = "unparseable"
+
+// QuarantinedRatesEvent wraps the rejected rates of one raw batch for Kafka.
+type QuarantinedRatesEvent struct {
+	Source SourceType        `json:"source"`
+	Rates  []QuarantinedRate `json:"rates"`
+}
@@ -0,0 +1,279 @@
+// Package health serves the liveness and readiness endpoints of the
+// services. /healthz answers as long as the process serves HTTP; /readyz
+// checks every dependency (PostgreSQL, ClickHouse, Redis, Kafka, other
+// services) with a timeout and reports each one:
+//
+//	{"status": "degraded", "components": {
+//	  "postgres": {"status": "ok", "duration_ms": 0.8},
+//	  "kafka":    {"status": "down", "optional": true, "error": "...", "duration_ms": 2000}}}
+//
+// A required component that is down makes /readyz answer 503 with status
+// "unavailable". An optional one only turns the status into "degraded":
+// the service still answers, e.g. history-service serves stored rates while
+// Kafka is down.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/logging"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/metrics"
+	"github.com/segmentio/kafka-go"
+)
+
+// Status values of a report and of its components.
+const (
+	StatusOK          = "ok"
+	StatusDegraded    = "degraded"
+	StatusUnavailable = "unavailable"
+	StatusDown        = "down"
+)
+
+// DefaultTimeout bounds each check of a Checker.
+const DefaultTimeout = 2 * time.Second
+
+// Check reports whether a dependency is usable. It should give up when ctx
+// is done; a check that does not is abandoned at the timeout anyway.
+type Check func(ctx context.Context) error
+
+// Component is the result of one check. Components of another service's
+// report are nested, so the gateway's /status shows the dependencies of
+// every upstream.
+type Component struct {
+	Status     string               `json:"status"`
+	Optional   bool                 `json:"optional,omitempty"`
+	Error      string               `json:"error,omitempty"`
+	DurationMs float64              `json:"duration_ms"`
+	Components map[string]Component `json:"components,omitempty"`
+}
+
+// Report is the body of /healthz, /readyz and the gateway's /status.
+type Report struct {
+	Status     string               `json:"status"`
+	Components map[string]Component `json:"components,omitempty"`
+}
+
+// Checker runs the readiness checks of a service.
+type Checker struct {
+	timeout    time.Duration
+	components []component
+}
+
+type component struct {
+	name     string
+	optional bool
+	// run returns the nested report of a remote service, or nil
+	run func(ctx context.Context) (*Report, error)
+}
+
+// New returns a Checker without checks, which is always ready.
+func New() *Checker {
+	return &Checker{timeout: DefaultTimeout}
+}
+
+// WithTimeout sets how long each check may take.
+func (c *Checker) WithTimeout(d time.Duration) *Checker {
+	c.timeout = d
+	return c
+}
+
+// Add registers a dependency the service cannot work without.
+func (c *Checker) Add(name string, check Check) *Checker {
+	return c.add(name, false, check)
+}
+
+// AddOptional registers a dependency the service can partly work without.
+func (c *Checker) AddOptional(name string, check Check) *Checker {
+	return c.add(name, true, check)
+}
+
+func (c *Checker) add(name string, optional bool, check Check) *Checker {
+	c.components = append(c.components, component{name: name, optional: optional,
+		run: func(ctx context.Context) (*Report, error) { return nil, check(ctx) }})
+	return c
+}
+
+// AddService registers another service by the URL of its /readyz. Its
+// report is nested in the component, which takes the service's status.
+func (c *Checker) AddService(name string, hc *http.Client, readyURL string, optional bool) *Checker {
+	c.components = append(c.components, component{name: name, optional: optional,
+		run: func(ctx context.Context) (*Report, error) { return fetch(ctx, hc, readyURL) }})
+	return c
+}
+
+// Check runs every check concurrently and combines the results.
+func (c *Checker) Check(ctx context.Context) Report {
+	results := make([]Component, len(c.components))
+	var wg sync.WaitGroup
+	for i, comp := range c.components {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.run(ctx, comp)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Components: make(map[string]Component, len(results))}
+	for i, res := range results {
+		report.Components[c.components[i].name] = res
+		switch {
+		case res.Status == StatusOK:
+		case !res.Optional && res.Status != StatusDegraded:
+			report.Status = StatusUnavailable
+		case report.Status == StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, comp component) Component {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	type result struct {
+		report *Report
+		err    error
+	}
+	done := make(chan result, 1)
+	start := time.Now()
+	go func() {
+		r, err := comp.run(ctx)
+		done <- result{r, err}
+	}()
+
+	var res result
+	select {
+	case res = <-done:
+	case <-ctx.Done():
+		res.err = fmt.Errorf("no answer within %s", c.timeout)
+	}
+
+	out := Component{Status: StatusOK, Optional: comp.optional, DurationMs: logging.Millis(time.Since(start))}
+	if res.report != nil {
+		out.Status = res.report.Status
+		out.Components = res.report.Components
+	}
+	if res.err != nil {
+		out.Status = StatusDown
+		out.Error = res.err.Error()
+	}
+	return out
+}
+
+// Ready serves /readyz: the report of every check, with 503 when a required
+// dependency is down.
+func (c *Checker) Ready(w http.ResponseWriter, r *http.Request) {
+	report := c.Check(r.Context())
+	code := http.StatusOK
+	if report.Status == StatusUnavailable {
+		code = http.StatusServiceUnavailable
+	}
+	write(w, code, report)
+}
+
+// Live serves /healthz. It checks no dependency, so an outage of one does
+// not get the service restarted.
+func Live(w http.ResponseWriter, r *http.Request) {
+	write(w, http.StatusOK, Report{Status: StatusOK})
+}
+
+func write(w http.ResponseWriter, code int, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(report)
+}
+
+// ListenAndServe serves /healthz, /readyz and /metrics on addr, for
+// services that have no HTTP server of their own.
+func ListenAndServe(addr string, c *Checker) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", Live)
+	mux.HandleFunc("/readyz", c.Ready)
+	mux.Handle("/metrics", metrics.Handler())
+	return http.ListenAndServe(addr, mux)
+}
+
+// Kafka checks that one of the comma-separated brokers accepts connections
+// and answers a metadata request.
+func Kafka(brokers string) Check {
+	return func(ctx context.Context) error {
+		var errs []error
+		for _, b := range strings.Split(brokers, ",") {
+			conn, err := (&kafka.Dialer{}).DialContext(ctx, "tcp", strings.TrimSpace(b))
+			if err == nil {
+				if deadline, ok := ctx.Deadline(); ok {
+					conn.SetDeadline(deadline)
+				}
+				_, err = conn.Brokers()
+				conn.Close()
+				if err == nil {
+					return nil
+				}
+			}
+			errs = append(errs, err)
+		}
+		return errors.Join(errs...)
+	}
+}
+
+// HTTP checks that url answers with a 2xx status, e.g. another service's
+// /healthz.
+func HTTP(hc *http.Client, url string) Check {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := hc.Do(req)
+		if err != nil {
+			return unwrap(err)
+		}
+		defer resp.Body.Close()
+		io.Copy(io.Discard, resp.Body)
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("%s answered %s", url, resp.Status)
+		}
+		return nil
+	}
+}
+
+// fetch returns the report of the /readyz at url. A 503 still carries a
+// report; any other answer that is not one is an error.
+func fetch(ctx context.Context, hc *http.Client, url string) (*Report, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, unwrap(err)
+	}
+	defer resp.Body.Close()
+	var report Report
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil || report.Status == "" {
+		return nil, fmt.Errorf("%s answered %s without a health report", url, resp.Status)
+	}
+	return &report, nil
+}
+
+// unwrap drops the method and URL net/http adds to transport errors, which
+// the component name already identifies.
+func unwrap(err error) error {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return opErr
+	}
+	return err
+}
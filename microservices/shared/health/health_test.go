@@ -0,0 +1,102 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var (
+	up   Check = func(context.Context) error { return nil }
+	down Check = func(context.Context) error { return errors.New("connection refused") }
+)
+
+func serveReady(t *testing.T, c *Checker) (int, Report) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	c.Ready(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var report Report
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("invalid report %q: %v", w.Body, err)
+	}
+	return w.Code, report
+}
+
+func TestReady_requiredAndOptional(t *testing.T) {
+	code, report := serveReady(t, New().Add("postgres", up).AddOptional("kafka", down))
+	if code != http.StatusOK || report.Status != StatusDegraded {
+		t.Errorf("expected 200 degraded when an optional check fails, got %d %s", code, report.Status)
+	}
+	if c := report.Components["kafka"]; c.Status != StatusDown || c.Error != "connection refused" || !c.Optional {
+		t.Errorf("unexpected kafka component %+v", c)
+	}
+
+	code, report = serveReady(t, New().Add("postgres", down).AddOptional("kafka", up))
+	if code != http.StatusServiceUnavailable || report.Status != StatusUnavailable {
+		t.Errorf("expected 503 unavailable when a required check fails, got %d %s", code, report.Status)
+	}
+
+	code, report = serveReady(t, New().Add("postgres", up))
+	if code != http.StatusOK || report.Status != StatusOK {
+		t.Errorf("expected 200 ok, got %d %s", code, report.Status)
+	}
+}
+
+func TestCheck_abandonsSlowChecks(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	stuck := func(context.Context) error { <-block; return nil }
+
+	start := time.Now()
+	report := New().WithTimeout(20*time.Millisecond).Add("clickhouse", stuck).Check(context.Background())
+
+	if time.Since(start) > time.Second {
+		t.Fatal("expected the check to be abandoned at the timeout")
+	}
+	if c := report.Components["clickhouse"]; c.Status != StatusDown || c.Error == "" {
+		t.Errorf("expected a timed out component, got %+v", c)
+	}
+}
+
+func TestAddService_nestsTheReport(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(New().Add("postgres", up).AddOptional("kafka", down).Ready))
+	defer upstream.Close()
+	gone := httptest.NewServer(http.NotFoundHandler())
+	gone.Close()
+
+	report := New().
+		AddService("history-service", http.DefaultClient, upstream.URL+"/readyz", true).
+		AddService("notification-service", http.DefaultClient, gone.URL+"/readyz", true).
+		Check(context.Background())
+
+	if report.Status != StatusDegraded {
+		t.Errorf("expected degraded, got %s", report.Status)
+	}
+	history := report.Components["history-service"]
+	if history.Status != StatusDegraded || history.Components["kafka"].Status != StatusDown {
+		t.Errorf("expected the upstream's own report, got %+v", history)
+	}
+	if n := report.Components["notification-service"]; n.Status != StatusDown || n.Error == "" {
+		t.Errorf("expected an unreachable service to be down, got %+v", n)
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	if err := HTTP(http.DefaultClient, srv.URL+"/healthz")(context.Background()); err != nil {
+		t.Errorf("expected healthy, got %v", err)
+	}
+	if err := HTTP(http.DefaultClient, srv.URL+"/missing")(context.Background()); err == nil {
+		t.Error("expected an error for a 404")
+	}
+}
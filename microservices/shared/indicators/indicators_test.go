@@ -0,0 +1,283 @@
+package indicators
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func closes(values ...float64) []Candle {
+	cs := make([]Candle, len(values))
+	for i, v := range values {
+		cs[i] = Candle{Time: t0.Add(time.Duration(i) * time.Hour), Open: v, High: v, Low: v, Close: v}
+	}
+	return cs
+}
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+// ─── parsing ─────────────────────────────────────────────────────────────────
+
+func TestParseSpecs(t *testing.T) {
+	specs, err := ParseSpecs("RSI14, ema20,macd,bb,sma5,rsi14")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"rsi14", "ema20", "macd", "bb20", "sma5"}
+	if len(specs) != len(want) {
+		t.Fatalf("expected %v, got %+v", want, specs)
+	}
+	for i, s := range specs {
+		if s.Name() != want[i] {
+			t.Errorf("spec %d: expected %s, got %s", i, want[i], s.Name())
+		}
+	}
+	if specs[0].Warmup() != 15 || specs[2].Warmup() != 35 {
+		t.Errorf("unexpected warmups: rsi14=%d macd=%d", specs[0].Warmup(), specs[2].Warmup())
+	}
+
+	for _, bad := range []string{"", "vwap", "rsi1", "ema9999", "macd12", "smaX"} {
+		if _, err := ParseSpecs(bad); err == nil {
+			t.Errorf("%q: expected error", bad)
+		}
+	}
+}
+
+func TestParseInterval(t *testing.T) {
+	if d, err := ParseInterval("4h"); err != nil || d != 4*time.Hour {
+		t.Errorf("4h: got %v, %v", d, err)
+	}
+	if _, err := ParseInterval("2h"); err == nil {
+		t.Error("2h: expected error")
+	}
+}
+
+// ─── resampling ──────────────────────────────────────────────────────────────
+
+func TestResample(t *testing.T) {
+	in := []Candle{
+		{Time: t0.Add(90 * time.Minute), Open: 3, High: 4, Low: 2, Close: 3.5, Volume: 1},
+		{Time: t0, Open: 1, High: 2, Low: 0.5, Close: 1.5, Volume: 2},
+		{Time: t0.Add(30 * time.Minute), Open: 1.5, High: 5, Low: 1, Close: 2, Volume: 3},
+	}
+	out := Resample(in, time.Hour)
+	if len(out) != 2 {
+		t.Fatalf("expected 2 bars, got %+v", out)
+	}
+	first := Candle{Time: t0, Open: 1, High: 5, Low: 0.5, Close: 2, Volume: 3}
+	if out[0] != first {
+		t.Errorf("expected %+v, got %+v", first, out[0])
+	}
+	if !out[1].Time.Equal(t0.Add(time.Hour)) || out[1].Close != 3.5 {
+		t.Errorf("unexpected second bar %+v", out[1])
+	}
+}
+
+// ─── indicators ──────────────────────────────────────────────────────────────
+
+func TestSMAAndEMA(t *testing.T) {
+	sma := SMA([]float64{1, 2, 3, 4, 5}, 3)
+	if !math.IsNaN(sma[1]) || sma[2] != 2 || sma[4] != 4 {
+		t.Errorf("unexpected SMA %v", sma)
+	}
+
+	// alpha = 0.5; seed = mean(1,2,3) = 2; then 0.5*4+0.5*2 = 3; 0.5*5+0.5*3 = 4
+	ema := EMA([]float64{1, 2, 3, 4, 5}, 3)
+	if !math.IsNaN(ema[1]) || ema[2] != 2 || ema[3] != 3 || ema[4] != 4 {
+		t.Errorf("unexpected EMA %v", ema)
+	}
+}
+
+func TestRSI(t *testing.T) {
+	up := make([]float64, 20)
+	for i := range up {
+		up[i] = float64(i)
+	}
+	if r := RSI(up, 14); !math.IsNaN(r[13]) || r[14] != 100 || r[19] != 100 {
+		t.Errorf("monotonic rise should give RSI 100, got %v", r)
+	}
+	if r := RSI(make([]float64, 20), 14); r[19] != 50 {
+		t.Errorf("flat series should give RSI 50, got %f", r[19])
+	}
+
+	// Alternating +1/-1 moves: equal average gain and loss.
+	alt := []float64{10, 11, 10, 11, 10}
+	if r := RSI(alt, 2); !near(r[2], 50) {
+		t.Errorf("expected 50, got %f", r[2])
+	}
+}
+
+func TestMACD(t *testing.T) {
+	values := make([]float64, 60)
+	for i := range values {
+		values[i] = 100 + float64(i)
+	}
+	line, signal, hist := MACD(values, 12, 26, 9)
+	if !math.IsNaN(line[24]) || math.IsNaN(line[25]) {
+		t.Errorf("MACD line should start at index 25")
+	}
+	if !math.IsNaN(signal[32]) || math.IsNaN(signal[33]) {
+		t.Errorf("signal should start at index 33")
+	}
+	if line[59] <= 0 || !near(hist[59], line[59]-signal[59]) {
+		t.Errorf("rising series: line=%f signal=%f hist=%f", line[59], signal[59], hist[59])
+	}
+}
+
+func TestBollinger(t *testing.T) {
+	upper, middle, lower := Bollinger([]float64{1, 3, 1, 3}, 2, 2)
+	// Window (1,3): mean 2, population stddev 1.
+	if middle[1] != 2 || upper[1] != 4 || lower[1] != 0 {
+		t.Errorf("unexpected bands %f/%f/%f", upper[1], middle[1], lower[1])
+	}
+}
+
+func TestComputeTrimAndLatest(t *testing.T) {
+	cs := closes(1, 2, 3, 4, 5, 6)
+	specs, _ := ParseSpecs("sma3,bb3")
+	series := Compute(cs, specs)
+
+	if len(series["sma3"]) != 4 || !series["sma3"][0].Time.Equal(cs[2].Time) {
+		t.Errorf("sma3 should start at the third candle: %+v", series["sma3"])
+	}
+	for _, name := range []string{"bb3_upper", "bb3_middle", "bb3_lower"} {
+		if len(series[name]) != 4 {
+			t.Errorf("%s: expected 4 values, got %d", name, len(series[name]))
+		}
+	}
+
+	Trim(series, cs[4].Time)
+	if len(series["sma3"]) != 2 {
+		t.Errorf("expected 2 values after trim, got %d", len(series["sma3"]))
+	}
+	if latest := Latest(series); latest["sma3"] != 5 {
+		t.Errorf("expected latest sma3 5, got %f", latest["sma3"])
+	}
+}
+
+// fixture is testdata/fixture.json, which the monolith copy of this package
+// is tested against too.
+type fixture struct {
+	Interval   string             `json:"interval"`
+	Indicators string             `json:"indicators"`
+	Bars       int                `json:"bars"`
+	FirstBar   Candle             `json:"first_bar"`
+	LastBar    Candle             `json:"last_bar"`
+	Latest     map[string]float64 `json:"latest"`
+	Candles    []Candle           `json:"candles"`
+}
+
+func TestSharedFixture(t *testing.T) {
+	data, err := os.ReadFile("testdata/fixture.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		t.Fatal(err)
+	}
+	step, err := ParseInterval(f.Interval)
+	if err != nil {
+		t.Fatal(err)
+	}
+	specs, err := ParseSpecs(f.Indicators)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bars := Resample(f.Candles, step)
+	if len(bars) != f.Bars || bars[0] != f.FirstBar || bars[len(bars)-1] != f.LastBar {
+		t.Fatalf("resampled to %d bars %+v .. %+v, want %d bars %+v .. %+v",
+			len(bars), bars[0], bars[len(bars)-1], f.Bars, f.FirstBar, f.LastBar)
+	}
+	latest := Latest(Compute(bars, specs))
+	if len(latest) != len(f.Latest) {
+		t.Errorf("got %d latest values, want %d", len(latest), len(f.Latest))
+	}
+	for name, want := range f.Latest {
+		if got, ok := latest[name]; !ok || !near(got, want) {
+			t.Errorf("%s: got %v, want %v", name, got, want)
+		}
+	}
+}
+
+// ─── conditions ──────────────────────────────────────────────────────────────
+
+func TestCondition(t *testing.T) {
+	c, err := ParseCondition("RSI14 > 70")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Indicator != "rsi14" || c.Op != ">" || c.Threshold != 70 || c.String() != "rsi14>70" {
+		t.Errorf("unexpected condition %+v", c)
+	}
+	if !c.Met(map[string]float64{"rsi14": 75}) || c.Met(map[string]float64{"rsi14": 70}) {
+		t.Error("rsi14>70 evaluated incorrectly")
+	}
+	if c.Met(map[string]float64{}) {
+		t.Error("missing indicator must not match")
+	}
+
+	le, _ := ParseCondition("macd_hist<=0")
+	if le.Op != "<=" || !le.Met(map[string]float64{"macd_hist": 0}) {
+		t.Errorf("unexpected <= condition %+v", le)
+	}
+
+	cross, err := ParseCondition("sma20>sma50")
+	if err != nil || cross.Other != "sma50" || cross.String() != "sma20>sma50" {
+		t.Fatalf("unexpected cross condition %+v (%v)", cross, err)
+	}
+	if !cross.Met(map[string]float64{"sma20": 101, "sma50": 100}) || cross.Met(map[string]float64{"sma20": 99, "sma50": 100}) {
+		t.Error("sma20>sma50 evaluated incorrectly")
+	}
+	if cross.Met(map[string]float64{"sma20": 101}) {
+		t.Error("missing right-hand indicator must not match")
+	}
+
+	for _, bad := range []string{"rsi14", ">70", "rsi14>abc", "rsi14>rsi"} {
+		if _, err := ParseCondition(bad); err == nil {
+			t.Errorf("%q: expected error", bad)
+		}
+	}
+}
+
+func TestSeriesSpec(t *testing.T) {
+	for name, want := range map[string]string{"rsi14": "rsi14", "sma50": "sma50", "macd_hist": "macd", "bb20_upper": "bb20"} {
+		spec, ok := SeriesSpec(name)
+		if !ok || spec.Name() != want {
+			t.Errorf("%s: got %+v, %v, want %s", name, spec, ok, want)
+		}
+	}
+	for _, name := range []string{"close", "rsi", "macd_x", "bb20", "sma20_upper"} {
+		if _, ok := SeriesSpec(name); ok {
+			t.Errorf("%s: expected no spec", name)
+		}
+	}
+}
+
+func TestParseAlert(t *testing.T) {
+	a, err := ParseAlert("btcusdt RSI14>70")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a.String() != "BTC 1h rsi14>70" {
+		t.Errorf("got %q, want the symbol without USDT and the default interval", a)
+	}
+	a, err = ParseAlert("ETH 4h sma20>sma50")
+	if err != nil || a.Interval != "4h" || a.Condition.Other != "sma50" {
+		t.Errorf("unexpected alert %+v (%v)", a, err)
+	}
+	specs, _ := a.Condition.Specs()
+	if len(specs) != 2 || specs[0].Name() != "sma20" || specs[1].Name() != "sma50" {
+		t.Errorf("unexpected specs %+v", specs)
+	}
+	for _, bad := range []string{"BTC", "BTC 2h rsi14>70", "BTC foo>70", "BTC 1h rsi14>70 extra", "BTC close>100"} {
+		if _, err := ParseAlert(bad); err == nil {
+			t.Errorf("%q: expected error", bad)
+		}
+	}
+}
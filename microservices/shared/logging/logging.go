@@ -0,0 +1,343 @@
+// Package logging sets up structured JSON logging with log/slog for the
+// services and carries a request ID through HTTP, gRPC and Kafka, so every
+// line logged for one user request or one collector run can be found by a
+// single request_id.
+//
+// Packages log through a logger of their own:
+//
+//	var logger = logging.For("normalizer")
+//
+//	logger.InfoContext(ctx, "published rates", "source", "cbr", "count", n)
+//
+// Lines logged with a context carry its request_id, trace_id and, inside a
+// chi handler, the route pattern. The level is read by Setup:
+//
+//	LOG_LEVEL    debug, info, warn or error for every package (default info)
+//	LOG_LEVELS   per-package overrides, e.g. storage=debug,subscriber=warn
+//
+// The monolith has its own copy of this package without Kafka and gRPC
+// (monolith/internal/logging); keep the field names in sync.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// HeaderRequestID carries the request ID on HTTP requests and responses and
+// on Kafka messages.
+const HeaderRequestID = "X-Request-ID"
+
+// metadataRequestID carries the request ID on gRPC calls.
+const metadataRequestID = "x-request-id"
+
+// output is the handler every logger writes to; Setup replaces it.
+var output atomic.Pointer[slog.Handler]
+
+var levels = struct {
+	sync.RWMutex
+	def  slog.Level
+	pkgs map[string]slog.Level
+}{def: slog.LevelInfo}
+
+func init() {
+	setOutput(os.Stderr, nil)
+}
+
+func setOutput(w io.Writer, attrs []slog.Attr) {
+	// Levels are checked per package before a record reaches the output
+	var h slog.Handler = slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug})
+	if len(attrs) > 0 {
+		h = h.WithAttrs(attrs)
+	}
+	output.Store(&h)
+}
+
+// Setup makes every logger write JSON lines tagged with service to stdout,
+// reads the levels from the environment and routes the standard library's
+// log package and slog's default logger through the same output.
+func Setup(service string) {
+	setOutput(os.Stdout, []slog.Attr{slog.String("service", service)})
+	err := SetLevels(os.Getenv("LOG_LEVEL"), os.Getenv("LOG_LEVELS"))
+	slog.SetDefault(For(""))
+	if err != nil {
+		slog.Warn("invalid log level, using info", "error", err)
+	}
+}
+
+// SetLevels sets the level of every package to def (info when empty) and
+// then applies the comma-separated package=level overrides.
+func SetLevels(def, overrides string) error {
+	d := slog.LevelInfo
+	pkgs := map[string]slog.Level{}
+	var errs []string
+	if def != "" {
+		if err := d.UnmarshalText([]byte(def)); err != nil {
+			errs = append(errs, err.Error())
+			d = slog.LevelInfo
+		}
+	}
+	for _, kv := range strings.Split(overrides, ",") {
+		pkg, lvl, ok := strings.Cut(strings.TrimSpace(kv), "=")
+		if !ok {
+			if kv != "" {
+				errs = append(errs, fmt.Sprintf("%q is not package=level", kv))
+			}
+			continue
+		}
+		var l slog.Level
+		if err := l.UnmarshalText([]byte(lvl)); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		pkgs[pkg] = l
+	}
+
+	levels.Lock()
+	levels.def, levels.pkgs = d, pkgs
+	levels.Unlock()
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func levelFor(pkg string) slog.Level {
+	levels.RLock()
+	defer levels.RUnlock()
+	if l, ok := levels.pkgs[pkg]; ok {
+		return l
+	}
+	return levels.def
+}
+
+// For returns the logger of pkg. It may be created before Setup runs; the
+// output and levels are looked up when a line is logged.
+func For(pkg string) *slog.Logger {
+	h := &handler{pkg: pkg}
+	if pkg != "" {
+		return slog.New(h).With("package", pkg)
+	}
+	return slog.New(h)
+}
+
+// handler checks the level of its package, adds the correlation fields from
+// the context and hands the record to the current output.
+type handler struct {
+	pkg string
+	ops []func(slog.Handler) slog.Handler // WithAttrs and WithGroup calls, replayed on the output
+}
+
+func (h *handler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= levelFor(h.pkg)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+	}
+	if rctx := chi.RouteContext(ctx); rctx != nil && rctx.RoutePattern() != "" {
+		r.AddAttrs(slog.String("route", rctx.RoutePattern()))
+	}
+	out := *output.Load()
+	for _, op := range h.ops {
+		out = op(out)
+	}
+	return out.Handle(ctx, r)
+}
+
+func (h *handler) with(op func(slog.Handler) slog.Handler) *handler {
+	ops := make([]func(slog.Handler) slog.Handler, len(h.ops), len(h.ops)+1)
+	copy(ops, h.ops)
+	return &handler{pkg: h.pkg, ops: append(ops, op)}
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(out slog.Handler) slog.Handler { return out.WithAttrs(attrs) })
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return h.with(func(out slog.Handler) slog.Handler { return out.WithGroup(name) })
+}
+
+type requestIDKey struct{}
+
+// NewRequestID returns a random request ID.
+func NewRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// WithRequestID returns a copy of ctx carrying id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, if any.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// validRequestID reports whether an ID received from a caller is safe to
+// log and pass on.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+var httpLogger = For("http")
+
+// probes are the paths polled by Prometheus and health checks.
+var probes = map[string]bool{"/metrics": true, "/healthz": true, "/readyz": true}
+
+// Middleware replaces chi's request logger. It takes the request ID from the
+// X-Request-ID header, or generates one when the caller sent none, echoes
+// it in the response and logs every request with its status and duration.
+// The ID is also set on the incoming request, so proxied calls pass it on.
+// Prometheus scrapes and health probes are logged at debug level.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(HeaderRequestID)
+		if !validRequestID(id) {
+			id = NewRequestID()
+			r.Header.Set(HeaderRequestID, id)
+		}
+		w.Header().Set(HeaderRequestID, id)
+		r = r.WithContext(WithRequestID(r.Context(), id))
+
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case probes[r.URL.Path]:
+			level = slog.LevelDebug
+		}
+		httpLogger.LogAttrs(r.Context(), level, "request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", status),
+			slog.Int("bytes", ww.BytesWritten()),
+			slog.Float64("duration_ms", Millis(time.Since(start))))
+	})
+}
+
+// Millis converts d to fractional milliseconds for duration_ms fields.
+func Millis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// Transport returns a RoundTripper that sets the X-Request-ID header from
+// the request's context on calls made through base (http.DefaultTransport
+// when nil).
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base}
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if id := RequestID(req.Context()); id != "" && req.Header.Get(HeaderRequestID) == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set(HeaderRequestID, id)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// InjectKafka writes the request ID of ctx into the headers of msg.
+func InjectKafka(ctx context.Context, msg *kafka.Message) {
+	if id := RequestID(ctx); id != "" {
+		msg.Headers = append(msg.Headers, kafka.Header{Key: HeaderRequestID, Value: []byte(id)})
+	}
+}
+
+// ExtractKafka returns ctx carrying the request ID of msg, or a new one when
+// the producer sent none.
+func ExtractKafka(ctx context.Context, msg kafka.Message) context.Context {
+	for _, h := range msg.Headers {
+		if h.Key == HeaderRequestID && validRequestID(string(h.Value)) {
+			return WithRequestID(ctx, string(h.Value))
+		}
+	}
+	return WithRequestID(ctx, NewRequestID())
+}
+
+// UnaryClientInterceptor passes the request ID of the caller's context on
+// in the call metadata.
+func UnaryClientInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if id := RequestID(ctx); id != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, metadataRequestID, id)
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+var grpcLogger = For("grpc")
+
+// UnaryServerInterceptor takes the request ID from the call metadata, or
+// generates one, and logs every call with its status code and duration.
+func UnaryServerInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	id := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(metadataRequestID); len(v) > 0 {
+			id = v[0]
+		}
+	}
+	if !validRequestID(id) {
+		id = NewRequestID()
+	}
+	ctx = WithRequestID(ctx, id)
+
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	level := slog.LevelInfo
+	if err != nil {
+		level = slog.LevelWarn
+	}
+	grpcLogger.LogAttrs(ctx, level, "grpc request",
+		slog.String("method", info.FullMethod),
+		slog.String("code", status.Code(err).String()),
+		slog.Float64("duration_ms", Millis(time.Since(start))))
+	return resp, err
+}
@@ -0,0 +1,145 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/segmentio/kafka-go"
+)
+
+// capture makes every logger write to the returned buffer at the given
+// levels until the test ends.
+func capture(t *testing.T, def, overrides string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prev := output.Load()
+	setOutput(&buf, nil)
+	if err := SetLevels(def, overrides); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		output.Store(prev)
+		SetLevels("", "")
+	})
+	return &buf
+}
+
+// lines decodes the JSON lines in buf.
+func lines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for _, l := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if l == "" {
+			continue
+		}
+		var m map[string]any
+		if err := json.Unmarshal([]byte(l), &m); err != nil {
+			t.Fatalf("invalid log line %q: %v", l, err)
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+func TestFor_levelPerPackage(t *testing.T) {
+	buf := capture(t, "warn", "storage=debug")
+
+	For("storage").Debug("query", "rows", 3)
+	For("collector").Info("collected")
+	For("collector").Warn("slow", "symbol", "BTCUSDT")
+
+	got := lines(t, buf)
+	if len(got) != 2 {
+		t.Fatalf("expected the storage debug and collector warn lines, got %s", buf)
+	}
+	if got[0]["package"] != "storage" || got[0]["msg"] != "query" || got[1]["symbol"] != "BTCUSDT" {
+		t.Errorf("unexpected lines %v", got)
+	}
+}
+
+func TestSetLevels_rejectsInvalidLevels(t *testing.T) {
+	capture(t, "", "")
+	if err := SetLevels("loud", "storage=debug,collector"); err == nil {
+		t.Fatal("expected an error")
+	}
+	if levelFor("storage").String() != "DEBUG" || levelFor("collector").String() != "INFO" {
+		t.Errorf("expected the valid override to apply and the default to stay info")
+	}
+}
+
+func TestMiddleware_tagsLinesWithRequestAndRoute(t *testing.T) {
+	buf := capture(t, "", "")
+
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Get("/rates/{code}", func(w http.ResponseWriter, r *http.Request) {
+		For("api").InfoContext(r.Context(), "rate", "currency", chi.URLParam(r, "code"))
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/rates/USD", nil)
+	req.Header.Set(HeaderRequestID, "abc-123")
+	r.ServeHTTP(w, req)
+
+	if w.Header().Get(HeaderRequestID) != "abc-123" {
+		t.Errorf("expected the caller's ID to be echoed, got %q", w.Header().Get(HeaderRequestID))
+	}
+	got := lines(t, buf)
+	if len(got) != 2 {
+		t.Fatalf("expected the handler and request lines, got %s", buf)
+	}
+	for _, l := range got {
+		if l["request_id"] != "abc-123" || l["route"] != "/rates/{code}" {
+			t.Errorf("expected request_id and route on %v", l)
+		}
+	}
+	if got[1]["status"] != float64(200) || got[1]["duration_ms"] == nil {
+		t.Errorf("expected status and duration on the request line, got %v", got[1])
+	}
+
+	// A missing or unusable ID is replaced
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/rates/EUR", nil)
+	req.Header.Set(HeaderRequestID, "bad id\n")
+	r.ServeHTTP(w, req)
+	if id := w.Header().Get(HeaderRequestID); len(id) != 16 {
+		t.Errorf("expected a generated ID, got %q", id)
+	}
+}
+
+func TestTransport_sendsRequestID(t *testing.T) {
+	var header string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get(HeaderRequestID)
+	}))
+	defer srv.Close()
+
+	req, _ := http.NewRequestWithContext(WithRequestID(context.Background(), "abc-123"), http.MethodGet, srv.URL, nil)
+	resp, err := (&http.Client{Transport: Transport(nil)}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if header != "abc-123" {
+		t.Errorf("expected abc-123, got %q", header)
+	}
+}
+
+func TestKafka_carriesRequestID(t *testing.T) {
+	msg := kafka.Message{Value: []byte("{}")}
+	InjectKafka(WithRequestID(context.Background(), "abc-123"), &msg)
+
+	if got := RequestID(ExtractKafka(context.Background(), msg)); got != "abc-123" {
+		t.Errorf("expected abc-123, got %q", got)
+	}
+	if got := RequestID(ExtractKafka(context.Background(), kafka.Message{})); got == "" {
+		t.Error("expected an ID for a message without one")
+	}
+}
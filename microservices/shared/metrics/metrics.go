@@ -0,0 +1,257 @@
+// Package metrics defines the Prometheus metrics exported by every service
+// at /metrics. The monolith exports the same names from its own copy of
+// this package (monolith/internal/metrics), so dashboards and alerts work
+// against either implementation; keep the two in sync.
+//
+// All metrics share the currency_tracker namespace:
+//
+//	http_request_duration_seconds{route,method,status}  served HTTP requests
+//	upstream_request_duration_seconds{upstream,status}  CBR and Binance calls
+//	upstream_errors_total{upstream}                     failed upstream calls
+//	kafka_messages_produced_total{topic,result}         published messages
+//	kafka_messages_consumed_total{topic,group}          consumed messages
+//	kafka_consumer_lag{topic,group}                     messages behind the head
+//	db_query_duration_seconds{db,operation}             PostgreSQL and ClickHouse
+//	backfills_total{source,result}                      on-demand history fetches
+//	rates_rejected_total{source,rule}                   rates quarantined by the normalizer
+//	telegram_messages_sent_total{result}                Telegram sendMessage calls
+//	latest_rate_age_seconds{source}                     age of the newest stored rate
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "currency_tracker"
+
+// Label values shared by both implementations. The sources label upstream
+// calls, backfills and stored rates alike.
+const (
+	SourceCBR           = "cbr"
+	SourceBinance       = "binance"
+	SourceCBRMetals     = "cbr_metals"
+	SourceCBRIndicators = "cbr_indicators"
+
+	DBPostgres   = "postgres"
+	DBClickHouse = "clickhouse"
+
+	resultOK    = "ok"
+	resultError = "error"
+)
+
+var (
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Duration of served HTTP requests by route pattern, method and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	upstreamDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upstream_request_duration_seconds",
+		Help:      "Duration of calls to the CBR and Binance APIs by HTTP status (\"error\" when no response arrived).",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"upstream", "status"})
+
+	upstreamErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upstream_errors_total",
+		Help:      "Calls to the CBR and Binance APIs that failed, were rate limited or answered 5xx.",
+	}, []string{"upstream"})
+
+	kafkaProduced = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kafka_messages_produced_total",
+		Help:      "Messages published to Kafka by topic and result.",
+	}, []string{"topic", "result"})
+
+	kafkaConsumed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kafka_messages_consumed_total",
+		Help:      "Messages read from Kafka by topic and consumer group.",
+	}, []string{"topic", "group"})
+
+	kafkaLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "kafka_consumer_lag",
+		Help:      "Messages between the last one read and the head of the partition.",
+	}, []string{"topic", "group"})
+
+	dbDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Duration of database operations by database and operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"db", "operation"})
+
+	backfills = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "backfills_total",
+		Help:      "History fetched from the upstream APIs on demand, by source and result.",
+	}, []string{"source", "result"})
+
+	ratesRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rates_rejected_total",
+		Help:      "Rates that failed validation and were quarantined, by source and rule.",
+	}, []string{"source", "rule"})
+
+	telegramSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "telegram_messages_sent_total",
+		Help:      "Messages sent to Telegram users by result.",
+	}, []string{"result"})
+
+	rateAge = &latestRates{
+		latest: map[string]time.Time{},
+		desc: prometheus.NewDesc(namespace+"_latest_rate_age_seconds",
+			"Seconds since the newest stored rate of each source.", []string{"source"}, nil),
+	}
+)
+
+func init() {
+	prometheus.MustRegister(rateAge)
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Middleware records the duration and status of requests served by a chi
+// router. Routes are labelled by their pattern, so /v1/rates/cbr?date=...
+// and every proxied /history/... path stay one series each; requests that
+// match no route are labelled "unmatched".
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		httpDuration.WithLabelValues(route, r.Method, strconv.Itoa(status)).Observe(time.Since(start).Seconds())
+	})
+}
+
+// Transport returns a RoundTripper that records the calls made through base
+// (http.DefaultTransport when nil) as calls to upstream.
+func Transport(upstream string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{upstream: upstream, base: base}
+}
+
+type transport struct {
+	upstream string
+	base     http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	upstreamDuration.WithLabelValues(t.upstream, status).Observe(time.Since(start).Seconds())
+	// A 404 is an answer: the CBR archive has no sheet for weekends
+	if err != nil || resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		upstreamErrors.WithLabelValues(t.upstream).Inc()
+	}
+	return resp, err
+}
+
+// KafkaProduced counts a message published to topic.
+func KafkaProduced(topic string, err error) {
+	kafkaProduced.WithLabelValues(topic, result(err)).Inc()
+}
+
+// KafkaConsumed counts a message read from topic by group and records how
+// far the consumer is behind (kafka.ReaderStats.Lag).
+func KafkaConsumed(topic, group string, lag int64) {
+	kafkaConsumed.WithLabelValues(topic, group).Inc()
+	kafkaLag.WithLabelValues(topic, group).Set(float64(lag))
+}
+
+// ObserveQuery records a database operation that began at start. Deferred
+// with the start time as an argument it times the whole function:
+//
+//	defer metrics.ObserveQuery(metrics.DBPostgres, "save_currency_rates", time.Now())
+func ObserveQuery(db, operation string, start time.Time) {
+	dbDuration.WithLabelValues(db, operation).Observe(time.Since(start).Seconds())
+}
+
+// Backfill counts an on-demand fetch of history for source.
+func Backfill(source string, err error) {
+	backfills.WithLabelValues(source, result(err)).Inc()
+}
+
+// RateRejected counts a rate of source that broke the validation rule.
+func RateRejected(source, rule string) {
+	ratesRejected.WithLabelValues(source, rule).Inc()
+}
+
+// TelegramSent counts a message sent to a Telegram user.
+func TelegramSent(err error) {
+	telegramSent.WithLabelValues(result(err)).Inc()
+}
+
+// RateStored records that a rate of source dated t was stored. The age
+// reported for source is measured from the newest t seen so far.
+func RateStored(source string, t time.Time) {
+	rateAge.observe(source, t)
+}
+
+func result(err error) string {
+	if err != nil {
+		return resultError
+	}
+	return resultOK
+}
+
+// latestRates is a collector computing the age of the newest rate of every
+// source at scrape time.
+type latestRates struct {
+	mu     sync.Mutex
+	latest map[string]time.Time
+	desc   *prometheus.Desc
+}
+
+func (c *latestRates) observe(source string, t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t.After(c.latest[source]) {
+		c.latest[source] = t
+	}
+}
+
+func (c *latestRates) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *latestRates) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for source, t := range c.latest {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, now.Sub(t).Seconds(), source)
+	}
+}
@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestMiddleware_labelsByRoutePattern(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Get("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	for _, path := range []string{"/items/1", "/items/2", "/nowhere"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if n := testutil.CollectAndCount(httpDuration); n != 2 {
+		t.Errorf("expected one series for the pattern and one for unmatched paths, got %d", n)
+	}
+	if n := sampleCount(t, "/items/{id}", http.MethodGet, "418"); n != 2 {
+		t.Errorf("expected 2 requests for the pattern, got %d", n)
+	}
+	if n := sampleCount(t, "unmatched", http.MethodGet, "404"); n != 1 {
+		t.Errorf("expected 1 unmatched request, got %d", n)
+	}
+}
+
+func sampleCount(t *testing.T, labels ...string) uint64 {
+	t.Helper()
+	var m dto.Metric
+	if err := httpDuration.WithLabelValues(labels...).(prometheus.Histogram).Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestTransport_countsErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/broken":
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+	c := &http.Client{Transport: Transport("test", nil)}
+	errs := upstreamErrors.WithLabelValues("test")
+
+	for _, path := range []string{"/", "/missing", "/broken"} {
+		resp, err := c.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if n := testutil.ToFloat64(errs); n != 1 {
+		t.Errorf("only the 502 is an error, counted %v", n)
+	}
+
+	if _, err := c.Get("http://127.0.0.1:1"); err == nil {
+		t.Fatal("expected a connection error")
+	}
+	if n := testutil.ToFloat64(errs); n != 2 {
+		t.Errorf("expected the failed connection to be counted, got %v", n)
+	}
+}
+
+func TestResultLabels(t *testing.T) {
+	KafkaProduced("test-topic", nil)
+	KafkaProduced("test-topic", errors.New("broker down"))
+	Backfill("test", nil)
+	TelegramSent(errors.New("blocked by user"))
+
+	if testutil.ToFloat64(kafkaProduced.WithLabelValues("test-topic", "ok")) != 1 ||
+		testutil.ToFloat64(kafkaProduced.WithLabelValues("test-topic", "error")) != 1 {
+		t.Error("expected one ok and one error message")
+	}
+	if testutil.ToFloat64(backfills.WithLabelValues("test", "ok")) != 1 {
+		t.Error("expected one successful backfill")
+	}
+	if testutil.ToFloat64(telegramSent.WithLabelValues("error")) < 1 {
+		t.Error("expected a failed Telegram message")
+	}
+
+	KafkaConsumed("test-topic", "test-group", 42)
+	if lag := testutil.ToFloat64(kafkaLag.WithLabelValues("test-topic", "test-group")); lag != 42 {
+		t.Errorf("expected lag 42, got %v", lag)
+	}
+}
+
+func TestRateStored_keepsNewest(t *testing.T) {
+	now := time.Now()
+	RateStored("test", now.Add(-time.Hour))
+	RateStored("test", now.Add(-3*time.Hour)) // an older backfill does not reset the age
+
+	age := testutil.ToFloat64(rateAge)
+	if age < 3600 || age > 3700 {
+		t.Errorf("expected an age of about an hour, got %v", age)
+	}
+}
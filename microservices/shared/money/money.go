@@ -0,0 +1,213 @@
+// Package money represents rates, prices and amounts as exact decimals, so
+// that values published by the CBR and Binance as decimal text are stored,
+// converted and served without binary floating-point rounding.
+//
+// A Decimal encodes to JSON as a string ("87.0341") and decodes from either a
+// string or a number, so Kafka messages and responses written before the
+// switch still decode. Numbers returns a copy of a response whose decimals
+// encode as JSON numbers, for clients that cannot take strings; Middleware
+// and ForResponse apply it to requests with ?decimals=number.
+//
+// Rounding rules (half away from zero):
+//
+//   - CBR rates keep the RateScale places they are published with.
+//   - Crypto prices and volumes, cross rates and quotes keep PriceScale places.
+//   - Per-unit CBR rates (the price of one unit of a currency quoted per 10
+//     or 100 units) keep UnitScale places.
+//   - Amounts are rounded to the minor unit of their currency (Scale).
+package money
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+
+	"github.com/shopspring/decimal"
+)
+
+// RateScale is the number of decimal places of CBR rates.
+const RateScale = 4
+
+// PriceScale is the number of decimal places of crypto prices and volumes,
+// as Binance publishes them, and of derived cross rates and quotes.
+const PriceScale = 8
+
+// UnitScale is the number of decimal places of per-unit CBR rates. CBR
+// nominals are powers of ten, so dividing a RateScale value by one stays
+// exact.
+const UnitScale = 2 * PriceScale
+
+// Decimal is an exact decimal number. The zero value is 0.
+type Decimal struct {
+	d decimal.Decimal
+	// number makes MarshalJSON write a JSON number, see Numbers.
+	number bool
+}
+
+// Zero is the decimal 0.
+var Zero = Decimal{}
+
+// New returns value * 10^exp.
+func New(value int64, exp int32) Decimal {
+	return Decimal{d: decimal.New(value, exp)}
+}
+
+// NewFromInt returns the decimal value of i.
+func NewFromInt(i int64) Decimal {
+	return Decimal{d: decimal.NewFromInt(i)}
+}
+
+// NewFromFloat returns the shortest decimal that rounds to f, so 87.0341
+// becomes exactly 87.0341. It is meant for values that only exist as floats
+// (old messages, user input parsed elsewhere); NaN and infinities become 0.
+func NewFromFloat(f float64) Decimal {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Zero
+	}
+	return Decimal{d: decimal.NewFromFloat(f)}
+}
+
+// Parse parses a decimal in plain or exponent notation ("87.0341", "1e-8").
+func Parse(s string) (Decimal, error) {
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return Zero, fmt.Errorf("invalid decimal %q", s)
+	}
+	return Decimal{d: d}, nil
+}
+
+// MustParse is Parse for constants; it panics on invalid input.
+func MustParse(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// Add returns d + x.
+func (d Decimal) Add(x Decimal) Decimal { return Decimal{d: d.d.Add(x.d)} }
+
+// Sub returns d - x.
+func (d Decimal) Sub(x Decimal) Decimal { return Decimal{d: d.d.Sub(x.d)} }
+
+// Mul returns d * x exactly.
+func (d Decimal) Mul(x Decimal) Decimal { return Decimal{d: d.d.Mul(x.d)} }
+
+// Div returns d / x rounded to places decimal places. Division by zero
+// returns 0, like the float code it replaces guarded against.
+func (d Decimal) Div(x Decimal, places int32) Decimal {
+	if x.IsZero() {
+		return Zero
+	}
+	return Decimal{d: d.d.DivRound(x.d, places)}
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal { return Decimal{d: d.d.Neg()} }
+
+// Abs returns |d|.
+func (d Decimal) Abs() Decimal { return Decimal{d: d.d.Abs()} }
+
+// Round rounds d to places decimal places, half away from zero.
+func (d Decimal) Round(places int32) Decimal { return Decimal{d: d.d.Round(places)} }
+
+// Cmp returns -1, 0 or +1 as d is less than, equal to or greater than x.
+func (d Decimal) Cmp(x Decimal) int { return d.d.Cmp(x.d) }
+
+// Equal reports whether d and x are the same number, whatever their scale.
+func (d Decimal) Equal(x Decimal) bool { return d.d.Equal(x.d) }
+
+// IsZero reports whether d is 0.
+func (d Decimal) IsZero() bool { return d.d.IsZero() }
+
+// IsPositive reports whether d > 0.
+func (d Decimal) IsPositive() bool { return d.d.IsPositive() }
+
+// IsNegative reports whether d < 0.
+func (d Decimal) IsNegative() bool { return d.d.IsNegative() }
+
+// Exponent returns the exponent of d: -4 for 87.0341.
+func (d Decimal) Exponent() int32 { return d.d.Exponent() }
+
+// Float64 returns the nearest float64, for statistics and indicators that
+// are computed in floating point anyway.
+func (d Decimal) Float64() float64 {
+	f, _ := d.d.Float64()
+	return f
+}
+
+// String returns d in plain notation without trailing zeros.
+func (d Decimal) String() string { return d.d.String() }
+
+// StringFixed returns d rounded to places decimal places with trailing zeros.
+func (d Decimal) StringFixed(places int32) string { return d.d.StringFixed(places) }
+
+// Decimal returns the shopspring/decimal value of d, for drivers that take
+// one directly.
+func (d Decimal) Decimal() decimal.Decimal { return d.d }
+
+// FromDecimal wraps a shopspring/decimal value.
+func FromDecimal(d decimal.Decimal) Decimal { return Decimal{d: d} }
+
+// MarshalJSON writes d as a JSON string, or as a number after Numbers.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	if d.number {
+		return []byte(d.d.String()), nil
+	}
+	return []byte(`"` + d.d.String() + `"`), nil
+}
+
+// UnmarshalJSON reads a JSON string or number; null leaves d unchanged.
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if s == "null" {
+		return nil
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+	}
+	v, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// Scan reads a numeric database value. PostgreSQL NUMERIC arrives as text,
+// ClickHouse Decimal as a shopspring/decimal value; NULL is 0.
+func (d *Decimal) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*d = Zero
+	case decimal.Decimal:
+		*d = Decimal{d: v}
+	case []byte:
+		return d.scanString(string(v))
+	case string:
+		return d.scanString(v)
+	case float64:
+		*d = NewFromFloat(v)
+	case int64:
+		*d = NewFromInt(v)
+	default:
+		return fmt.Errorf("cannot scan %T into money.Decimal", src)
+	}
+	return nil
+}
+
+func (d *Decimal) scanString(s string) error {
+	v, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// Value writes d as decimal text, which both PostgreSQL and ClickHouse
+// parse exactly.
+func (d Decimal) Value() (driver.Value, error) {
+	return d.d.String(), nil
+}
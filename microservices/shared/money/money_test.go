@@ -0,0 +1,138 @@
+package money
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestJSON(t *testing.T) {
+	d := MustParse("87.0341")
+	b, err := json.Marshal(d)
+	if err != nil || string(b) != `"87.0341"` {
+		t.Fatalf("Marshal = %s, %v, want \"87.0341\"", b, err)
+	}
+	for _, in := range []string{`"87.0341"`, `87.0341`, `8.70341e1`} {
+		var got Decimal
+		if err := json.Unmarshal([]byte(in), &got); err != nil || !got.Equal(d) {
+			t.Errorf("Unmarshal(%s) = %s, %v, want 87.0341", in, got, err)
+		}
+	}
+	var bad Decimal
+	if err := json.Unmarshal([]byte(`"abc"`), &bad); err == nil {
+		t.Error("expected an error for a non-numeric string")
+	}
+}
+
+func TestArithmeticIsExact(t *testing.T) {
+	// 0.1 + 0.2 is 0.30000000000000004 in float64
+	if got := MustParse("0.1").Add(MustParse("0.2")); got.String() != "0.3" {
+		t.Errorf("0.1 + 0.2 = %s", got)
+	}
+	// A Binance close times the CBR USD rate
+	if got := MustParse("64123.45").Mul(MustParse("92.5843")); got.String() != "5936824.731835" {
+		t.Errorf("product = %s", got)
+	}
+	if got := MustParse("1").Div(MustParse("3"), PriceScale); got.String() != "0.33333333" {
+		t.Errorf("1 / 3 = %s", got)
+	}
+	if got := MustParse("1").Div(Zero, PriceScale); !got.IsZero() {
+		t.Errorf("1 / 0 = %s, want 0", got)
+	}
+}
+
+func TestRoundAmount(t *testing.T) {
+	tests := []struct {
+		in, code string
+		crypto   bool
+		want     string
+	}{
+		{"1234.565", "RUB", false, "1234.57"},
+		{"-1234.565", "RUB", false, "-1234.57"},
+		{"1234.5", "JPY", false, "1235"},
+		{"1.23456", "KWD", false, "1.235"},
+		{"0.123456789", "BTC", true, "0.12345679"},
+	}
+	for _, tc := range tests {
+		if got := RoundAmount(MustParse(tc.in), tc.code, tc.crypto); got.String() != tc.want {
+			t.Errorf("RoundAmount(%s, %s) = %s, want %s", tc.in, tc.code, got, tc.want)
+		}
+	}
+}
+
+func TestPerUnit(t *testing.T) {
+	tests := []struct {
+		value   string
+		nominal int
+		want    string
+	}{
+		{"92.5843", 1, "92.5843"},
+		{"60.1234", 100, "0.601234"},
+		{"35.1234", 10000, "0.00351234"},
+		{"92.5843", 0, "92.5843"},
+	}
+	for _, tc := range tests {
+		if got := PerUnit(MustParse(tc.value), tc.nominal); got.String() != tc.want {
+			t.Errorf("PerUnit(%s, %d) = %s, want %s", tc.value, tc.nominal, got, tc.want)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	for _, src := range []any{"89.6883", []byte("89.6883"), decimal.RequireFromString("89.6883"), 89.6883} {
+		var d Decimal
+		if err := d.Scan(src); err != nil || d.String() != "89.6883" {
+			t.Errorf("Scan(%T) = %s, %v", src, d, err)
+		}
+	}
+	var d Decimal
+	if err := d.Scan(nil); err != nil || !d.IsZero() {
+		t.Errorf("Scan(nil) = %s, %v", d, err)
+	}
+	if v, _ := MustParse("89.6883").Value(); v != "89.6883" {
+		t.Errorf("Value = %v", v)
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	type rate struct {
+		Code   string             `json:"code"`
+		Value  Decimal            `json:"value"`
+		Quotes map[string]Decimal `json:"quotes"`
+		Next   *Decimal           `json:"next,omitempty"`
+	}
+	next := MustParse("91.5")
+	v := struct {
+		Data []rate `json:"data"`
+	}{Data: []rate{{Code: "USD", Value: MustParse("90.1"), Quotes: map[string]Decimal{"EUR": MustParse("0.92")}, Next: &next}}}
+
+	b, err := json.Marshal(Numbers(v))
+	want := `{"data":[{"code":"USD","value":90.1,"quotes":{"EUR":0.92},"next":91.5}]}`
+	if err != nil || string(b) != want {
+		t.Errorf("Numbers = %s, %v, want %s", b, err, want)
+	}
+	// The original still encodes strings.
+	if b, _ := json.Marshal(v); string(b) != `{"data":[{"code":"USD","value":"90.1","quotes":{"EUR":"0.92"},"next":"91.5"}]}` {
+		t.Errorf("original changed: %s", b)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(ForResponse(w, map[string]Decimal{"value": MustParse("90.1")}))
+	}))
+	for target, want := range map[string]string{
+		"/rates":                 `{"value":"90.1"}`,
+		"/rates?decimals=number": `{"value":90.1}`,
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if got := strings.TrimSpace(rec.Body.String()); got != want {
+			t.Errorf("%s: expected %s, got %s", target, want, got)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package money
+
+import "reflect"
+
+var decimalType = reflect.TypeOf(Decimal{})
+
+// Numbers returns a deep copy of v in which every Decimal reachable through
+// exported fields, slices, arrays, maps, pointers and interfaces encodes as
+// a JSON number instead of a string. Responses pass through it when a client
+// opts in to the format used before decimals (?decimals=number).
+func Numbers(v any) any {
+	if v == nil {
+		return nil
+	}
+	return numbers(reflect.ValueOf(v)).Interface()
+}
+
+func numbers(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type().Elem())
+		out.Elem().Set(numbers(v.Elem()))
+		return out
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.Set(numbers(v.Elem()))
+		return out
+	case reflect.Struct:
+		if v.Type() == decimalType {
+			d := v.Interface().(Decimal)
+			d.number = true
+			return reflect.ValueOf(d)
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				out.Field(i).Set(numbers(v.Field(i)))
+			}
+		}
+		return out
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(numbers(v.Index(i)))
+		}
+		return out
+	case reflect.Array:
+		out := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(numbers(v.Index(i)))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), numbers(iter.Value()))
+		}
+		return out
+	}
+	return v
+}
+
+// WantNumbers reports whether a client asked for decimals as JSON numbers
+// with the decimals query parameter.
+func WantNumbers(query interface{ Get(string) string }) bool {
+	return query.Get("decimals") == "number"
+}
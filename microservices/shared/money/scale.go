@@ -0,0 +1,45 @@
+package money
+
+// minorUnits lists the ISO 4217 minor units of currencies quoted by the CBR
+// that differ from the usual 2 decimal places.
+var minorUnits = map[string]int32{
+	"JPY": 0,
+	"KRW": 0,
+	"VND": 0,
+	"CLP": 0,
+	"ISK": 0,
+	"BHD": 3,
+	"KWD": 3,
+	"OMR": 3,
+	"JOD": 3,
+	"TND": 3,
+	"IQD": 3,
+	"LYD": 3,
+}
+
+// Scale returns the number of decimal places an amount in code is rounded to:
+// the ISO 4217 minor unit of a fiat currency (2 unless listed otherwise), or
+// PriceScale when crypto is set.
+func Scale(code string, crypto bool) int32 {
+	if crypto {
+		return PriceScale
+	}
+	if places, ok := minorUnits[code]; ok {
+		return places
+	}
+	return 2
+}
+
+// RoundAmount rounds an amount in code to Scale(code, crypto) places.
+func RoundAmount(d Decimal, code string, crypto bool) Decimal {
+	return d.Round(Scale(code, crypto))
+}
+
+// PerUnit returns the price of one unit of a rate quoted for nominal units,
+// to UnitScale places. A nominal below 1 counts as 1.
+func PerUnit(value Decimal, nominal int) Decimal {
+	if nominal <= 0 {
+		nominal = 1
+	}
+	return value.Div(NewFromInt(int64(nominal)), UnitScale)
+}
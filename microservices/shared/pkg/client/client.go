@@ -0,0 +1,215 @@
+// Package client is a typed Go client for the rates API served by the
+// gateway (and, for the /v1 routes, by the monolith). Responses decode into
+// the shared/apiv1 DTOs, so a change to the API shape breaks callers at
+// compile time. Every call takes a context, is retried on network errors and
+// 502/503/504 responses, and fails with an *APIError when the server answers
+// with an error status.
+//
+// Services inside the deployment can use the internal gRPC API instead (see
+// WithGRPC): rate and subscription calls then go straight to
+// history-service and notification-service with a deadline per call, and
+// gRPC failures become the same *APIError values.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/logging"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/rpcv1"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/tracing"
+	"google.golang.org/grpc"
+)
+
+// Defaults used by New.
+const (
+	DefaultTimeout    = 15 * time.Second
+	DefaultRetries    = 2
+	DefaultRetryDelay = 200 * time.Millisecond
+)
+
+// Client calls the rates API. It is safe for concurrent use.
+type Client struct {
+	baseURL          string
+	notificationsURL string
+	httpClient       *http.Client
+	retries          int
+	retryDelay       time.Duration
+	// history and subscriptions replace the HTTP routes when set
+	history       rpcv1.HistoryServiceClient
+	subscriptions rpcv1.SubscriptionServiceClient
+	callTimeout   time.Duration
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for requests. The default client
+// passes the caller's trace context and request ID on; a custom one needs
+// tracing.Transport and logging.Transport for that.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithRetries sets how many times a failed call is repeated and the delay
+// before the first retry; the delay doubles on every further retry.
+func WithRetries(retries int, delay time.Duration) Option {
+	return func(c *Client) { c.retries, c.retryDelay = retries, delay }
+}
+
+// WithNotificationsURL sets the base URL of the subscription endpoints. It
+// defaults to the gateway's /notifications prefix; pass the
+// notification-service address to talk to it directly.
+func WithNotificationsURL(u string) Option {
+	return func(c *Client) { c.notificationsURL = strings.TrimRight(u, "/") }
+}
+
+// WithGRPC sends rate calls to history-service and subscription calls to
+// notification-service over gRPC. Either connection may be nil to keep
+// using HTTP for its calls; the legacy quote-aware routes always use HTTP.
+func WithGRPC(history, subscriptions grpc.ClientConnInterface) Option {
+	return func(c *Client) {
+		if history != nil {
+			c.history = rpcv1.NewHistoryServiceClient(history)
+		}
+		if subscriptions != nil {
+			c.subscriptions = rpcv1.NewSubscriptionServiceClient(subscriptions)
+		}
+	}
+}
+
+// WithCallTimeout sets the deadline of every gRPC call attempt; it defaults
+// to DefaultTimeout. The HTTP client's own timeout applies to HTTP calls.
+func WithCallTimeout(d time.Duration) Option {
+	return func(c *Client) { c.callTimeout = d }
+}
+
+// New returns a client for the API at baseURL, e.g. http://localhost:8080.
+func New(baseURL string, opts ...Option) *Client {
+	baseURL = strings.TrimRight(baseURL, "/")
+	c := &Client{
+		baseURL:          baseURL,
+		notificationsURL: baseURL + "/notifications",
+		httpClient:       &http.Client{Timeout: DefaultTimeout, Transport: tracing.Transport(logging.Transport(nil))},
+		retries:          DefaultRetries,
+		retryDelay:       DefaultRetryDelay,
+		callTimeout:      DefaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// getV1 calls a /v1 route and unwraps the {"data": ...} envelope.
+func getV1[T any](ctx context.Context, c *Client, path string, query url.Values) (T, error) {
+	var resp struct {
+		Data T `json:"data"`
+	}
+	err := c.do(ctx, http.MethodGet, c.baseURL+path, query, nil, &resp)
+	return resp.Data, err
+}
+
+// do sends a request, retrying as described in the package comment, and
+// decodes a successful JSON response into out unless out is nil. All calls
+// the client makes are idempotent, so every method is retried.
+func (c *Client) do(ctx context.Context, method, endpoint string, query url.Values, body, out any) error {
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("client: encode request: %w", err)
+		}
+	}
+
+	return c.retry(ctx, func() error {
+		return c.send(ctx, method, endpoint, payload, out)
+	})
+}
+
+// invoke makes a gRPC call with the per-call deadline, retrying it like an
+// HTTP request, and converts a failure into an *APIError.
+func invoke[Req, Resp any](ctx context.Context, c *Client, call func(context.Context, Req, ...grpc.CallOption) (Resp, error), req Req) (Resp, error) {
+	var resp Resp
+	err := c.retry(ctx, func() error {
+		callCtx, cancel := context.WithTimeout(ctx, c.callTimeout)
+		defer cancel()
+		var err error
+		if resp, err = call(callCtx, req); err != nil {
+			return newGRPCError(err)
+		}
+		return nil
+	})
+	return resp, err
+}
+
+// retry runs attempt until it succeeds, fails for good or the retries are
+// used up, doubling the delay between attempts.
+func (c *Client) retry(ctx context.Context, attempt func() error) error {
+	delay := c.retryDelay
+	for n := 0; ; n++ {
+		err := attempt()
+		if err == nil || n >= c.retries || !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, endpoint string, payload []byte, out any) error {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return fmt.Errorf("client: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return newAPIError(resp)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("client: decode %s %s: %w", method, req.URL.Path, err)
+	}
+	return nil
+}
+
+// retryable reports whether a failed attempt may succeed when repeated.
+func retryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+	// Context errors are final; anything else is a transport failure
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
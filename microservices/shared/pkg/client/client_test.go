@@ -0,0 +1,339 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/apiv1"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+)
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func newTestClient(t testing.TB, h http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return New(srv.URL, append([]Option{WithRetries(2, time.Millisecond)}, opts...)...)
+}
+
+func TestCBRRange_decodesV1(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/rates/cbr/range" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		q := r.URL.Query()
+		if q.Get("code") != "USD" || q.Get("from") != "2024-01-01" || q.Get("to") != "2024-01-07" {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+		writeJSON(w, http.StatusOK, apiv1.Response[[]apiv1.CurrencyRate]{Data: []apiv1.CurrencyRate{
+			{Date: "2024-01-02", Code: "USD", Nominal: 1, Value: money.MustParse("90.5"), Previous: money.MustParse("89.9")},
+		}})
+	})
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rates, err := c.CBRRange(context.Background(), "USD", from, from.AddDate(0, 0, 6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rates) != 1 || rates[0].Code != "USD" || !rates[0].Value.Equal(money.MustParse("90.5")) {
+		t.Errorf("unexpected rates %+v", rates)
+	}
+}
+
+func TestCBRRatesAsOf_query(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.RawQuery; got != "as_of=2024-01-16T09%3A30%3A00Z&date=2024-01-13" {
+			t.Errorf("unexpected query %s", got)
+		}
+		writeJSON(w, http.StatusOK, apiv1.Response[[]apiv1.CurrencyRate]{Data: []apiv1.CurrencyRate{
+			{Date: "2024-01-13", Code: "USD", Nominal: 1, Value: money.MustParse("89.7"), CarriedFrom: "2024-01-12"},
+		}})
+	})
+
+	date := time.Date(2024, 1, 13, 0, 0, 0, 0, time.UTC)
+	asOf := time.Date(2024, 1, 16, 12, 30, 0, 0, time.FixedZone("MSK", 3*3600))
+	rates, err := c.CBRRatesAsOf(context.Background(), date, asOf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rates) != 1 || rates[0].CarriedFrom != "2024-01-12" {
+		t.Errorf("unexpected rates %+v", rates)
+	}
+}
+
+func TestConvert_query(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.RawQuery; got != "amount=250.5&date=2025-03-10&from=EUR&to=CNY" {
+			t.Errorf("unexpected query %s", got)
+		}
+		writeJSON(w, http.StatusOK, apiv1.Response[apiv1.Conversion]{Data: apiv1.Conversion{From: "EUR", To: "CNY", Result: money.NewFromInt(1950)}})
+	})
+
+	res, err := c.Convert(context.Background(), ConvertRequest{
+		From: "EUR", To: "CNY", Amount: money.MustParse("250.5"), Date: time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Result.Equal(money.NewFromInt(1950)) {
+		t.Errorf("expected 1950, got %v", res.Result)
+	}
+}
+
+func TestMetalRange_query(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/rates/metals/range" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if got := r.URL.RawQuery; got != "from=2026-04-01&metal=XAU&to=2026-04-15" {
+			t.Errorf("unexpected query %s", got)
+		}
+		writeJSON(w, http.StatusOK, apiv1.Response[[]apiv1.MetalPrice]{Data: []apiv1.MetalPrice{
+			{Date: "2026-04-15", Metal: "XAU", Name: "Gold", Buy: money.MustParse("8540.1"), Sell: money.MustParse("8540.1")},
+		}})
+	})
+
+	from := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	prices, err := c.MetalRange(context.Background(), "XAU", from, from.AddDate(0, 0, 14))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prices) != 1 || prices[0].Metal != "XAU" || !prices[0].Buy.Equal(money.MustParse("8540.1")) {
+		t.Errorf("unexpected prices %+v", prices)
+	}
+}
+
+func TestIndicatorRange_query(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/rates/indicators/range" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if got := r.URL.RawQuery; got != "from=2026-04-01&indicator=KEY_RATE&to=2026-04-15" {
+			t.Errorf("unexpected query %s", got)
+		}
+		writeJSON(w, http.StatusOK, apiv1.Response[[]apiv1.IndicatorRate]{Data: []apiv1.IndicatorRate{
+			{EffectiveDate: "2026-03-23", Indicator: "KEY_RATE", Name: "Key rate", Value: money.MustParse("15.5")},
+		}})
+	})
+
+	from := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	rates, err := c.IndicatorRange(context.Background(), "KEY_RATE", from, from.AddDate(0, 0, 14))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rates) != 1 || rates[0].EffectiveDate != "2026-03-23" || !rates[0].Value.Equal(money.MustParse("15.5")) {
+		t.Errorf("unexpected rates %+v", rates)
+	}
+}
+
+func TestCryptoIndicators_query(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/rates/crypto/indicators" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if got := r.URL.RawQuery; got != "indicators=sma20%2Csma50&interval=4h&symbol=ETH" {
+			t.Errorf("unexpected query %s", got)
+		}
+		writeJSON(w, http.StatusOK, apiv1.Response[apiv1.IndicatorsResult]{Data: apiv1.IndicatorsResult{
+			Symbol: "ETHUSDT", Interval: "4h", Latest: map[string]float64{"sma20": 301, "sma50": 300, "close": 305},
+		}})
+	})
+
+	res, err := c.CryptoIndicators(context.Background(), "ETH", "4h", "sma20,sma50")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Interval != "4h" || res.Latest["sma20"] != 301 {
+		t.Errorf("unexpected result %+v", res)
+	}
+}
+
+func TestAPIError_formats(t *testing.T) {
+	cases := []struct {
+		name    string
+		status  int
+		body    string
+		code    string
+		message string
+		details int
+	}{
+		{"v1", http.StatusNotFound, `{"error":{"code":"not_found","message":"no data"}}`, apiv1.CodeNotFound, "no data", 0},
+		{"gateway validation", http.StatusBadRequest,
+			`{"error":{"code":"bad_request","message":"a; b","details":["a","b"]}}`, apiv1.CodeBadRequest, "a; b", 2},
+		{"legacy", http.StatusBadRequest, `{"error":"invalid date"}`, apiv1.CodeBadRequest, "invalid date", 0},
+		{"plain text", http.StatusInternalServerError, "boom\n", apiv1.CodeInternal, "boom", 0},
+		{"empty", http.StatusNotFound, "", apiv1.CodeNotFound, "Not Found", 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				io.WriteString(w, tc.body)
+			})
+			_, err := c.CryptoSymbols(context.Background())
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected *APIError, got %v", err)
+			}
+			if apiErr.StatusCode != tc.status || apiErr.Code != tc.code || apiErr.Message != tc.message || len(apiErr.Details) != tc.details {
+				t.Errorf("unexpected error %+v", apiErr)
+			}
+		})
+	}
+}
+
+func TestIsNotFound(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusNotFound, apiv1.NewError(http.StatusNotFound, "no data"))
+	})
+	_, err := c.CBRRates(context.Background(), time.Time{})
+	if !IsNotFound(err) || IsBadRequest(err) {
+		t.Errorf("expected a not_found error, got %v", err)
+	}
+}
+
+func TestDo_retriesUnavailable(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			writeJSON(w, http.StatusServiceUnavailable, apiv1.NewError(http.StatusServiceUnavailable, "warming up"))
+			return
+		}
+		writeJSON(w, http.StatusOK, apiv1.Response[[]string]{Data: []string{"BTC"}})
+	})
+
+	symbols, err := c.CryptoSymbols(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(symbols) != 1 || calls.Load() != 3 {
+		t.Errorf("expected success on the third call, got %v after %d calls", symbols, calls.Load())
+	}
+}
+
+func TestDo_givesUpAfterRetries(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "upstream unavailable", http.StatusBadGateway)
+	})
+
+	_, err := c.CryptoSymbols(context.Background())
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !apiErr.Temporary() {
+		t.Fatalf("expected a temporary *APIError, got %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 calls, got %d", calls.Load())
+	}
+}
+
+func TestDo_doesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		writeJSON(w, http.StatusBadRequest, apiv1.NewError(http.StatusBadRequest, "code is required"))
+	})
+
+	_, err := c.CBRRange(context.Background(), "", time.Now(), time.Now())
+	if !IsBadRequest(err) {
+		t.Errorf("expected a bad_request error, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected 1 call, got %d", calls.Load())
+	}
+}
+
+func TestDo_stopsWhenContextIsDone(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, WithRetries(5, time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.CryptoSymbols(ctx); err == nil {
+		t.Fatal("expected an error")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected 1 call, got %d", calls.Load())
+	}
+}
+
+func TestSubscriptions_roundTrip(t *testing.T) {
+	subs := map[string]bool{}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/subscriptions/crypto" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		switch r.Method {
+		case http.MethodGet:
+			out := []string{}
+			for v := range subs {
+				out = append(out, v)
+			}
+			writeJSON(w, http.StatusOK, out)
+			return
+		case http.MethodPost, http.MethodDelete:
+			var req subscriptionRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.TelegramID != 7 {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid body"})
+				return
+			}
+			if r.Method == http.MethodPost {
+				subs[req.Value] = true
+			} else {
+				delete(subs, req.Value)
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	// Talk to the notification-service directly rather than via the gateway
+	c = New(c.baseURL, WithNotificationsURL(c.baseURL))
+	ctx := context.Background()
+
+	if err := c.Subscribe(ctx, CryptoSubscriptions, 7, "BTC"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.Subscriptions(ctx, CryptoSubscriptions, 7)
+	if err != nil || len(got) != 1 || got[0] != "BTC" {
+		t.Fatalf("expected [BTC], got %v (%v)", got, err)
+	}
+	if err := c.Unsubscribe(ctx, CryptoSubscriptions, 7, "BTC"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := c.Subscriptions(ctx, CryptoSubscriptions, 7); len(got) != 0 {
+		t.Errorf("expected no subscriptions, got %v", got)
+	}
+}
+
+func TestQuotedCBRRates_decodesLegacyRows(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rates/cbr" || r.URL.Query().Get("quote") != "EUR" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		io.WriteString(w, `[{"ID":1,"Date":"2024-01-15T00:00:00Z","CurrencyCode":"USD","CurrencyName":"US Dollar",`+
+			`"Nominal":1,"Value":90,"Previous":89,"Quote":"EUR","QuoteValue":0.9,"QuotePrevious":0.91}]`)
+	})
+
+	rates, err := c.QuotedCBRRates(context.Background(), time.Time{}, "EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rates) != 1 || !rates[0].QuoteValue.Equal(money.MustParse("0.9")) || rates[0].Date.Format(dateLayout) != "2024-01-15" {
+		t.Errorf("unexpected rates %+v", rates)
+	}
+}
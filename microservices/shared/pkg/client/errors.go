@@ -0,0 +1,95 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/apiv1"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/rpcv1"
+)
+
+// maxErrorBody bounds how much of an error response is read.
+const maxErrorBody = 64 << 10
+
+// APIError is returned when the server answers with a 4xx or 5xx status,
+// or a gRPC call fails with the equivalent status code. It understands the /v1 error format ({"error": {"code", "message"}}, plus
+// the gateway's validation "details") and the legacy {"error": "message"}.
+type APIError struct {
+	StatusCode int
+	// Code is one of the apiv1 error codes; for legacy routes it is derived
+	// from StatusCode.
+	Code    string
+	Message string
+	// Details lists individual validation failures, when the gateway
+	// rejected the request.
+	Details []string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("api error %d (%s): %s", e.StatusCode, e.Code, e.Message)
+}
+
+// Temporary reports whether the request may succeed when repeated.
+func (e *APIError) Temporary() bool {
+	switch e.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// IsNotFound reports whether err is an *APIError with the not_found code.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == apiv1.CodeNotFound
+}
+
+// IsBadRequest reports whether err is an *APIError with the bad_request code.
+func IsBadRequest(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == apiv1.CodeBadRequest
+}
+
+func newAPIError(resp *http.Response) *APIError {
+	e := &APIError{StatusCode: resp.StatusCode, Code: apiv1.CodeForStatus(resp.StatusCode)}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+	var envelope struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(body, &envelope) == nil && len(envelope.Error) > 0 {
+		var v1 struct {
+			apiv1.Error
+			Details []string `json:"details"`
+		}
+		var legacy string
+		switch {
+		case json.Unmarshal(envelope.Error, &v1) == nil && v1.Message != "":
+			if v1.Code != "" {
+				e.Code = v1.Code
+			}
+			e.Message, e.Details = v1.Message, v1.Details
+		case json.Unmarshal(envelope.Error, &legacy) == nil:
+			e.Message = legacy
+		}
+	}
+	if e.Message == "" {
+		// Plain-text errors such as http.Error from a proxy
+		e.Message = strings.TrimSpace(string(body))
+	}
+	if e.Message == "" {
+		e.Message = http.StatusText(resp.StatusCode)
+	}
+	return e
+}
+
+// newGRPCError describes a failed gRPC call by the HTTP status the /v1 route
+// would have answered with.
+func newGRPCError(err error) *APIError {
+	status := rpcv1.HTTPStatus(err)
+	return &APIError{StatusCode: status, Code: apiv1.CodeForStatus(status), Message: rpcv1.Message(err)}
+}
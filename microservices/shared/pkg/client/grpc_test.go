@@ -0,0 +1,183 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/apiv1"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/rpcv1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeHistory answers GetCBRRange with two rates of the requested code, the
+// second carried over from the first, after failing the first fail calls with the err of the test.
+type fakeHistory struct {
+	rpcv1.UnimplementedHistoryServiceServer
+	calls atomic.Int32
+	fail  int32
+	err   error
+	block bool
+}
+
+func (f *fakeHistory) GetCBRRange(ctx context.Context, req *rpcv1.GetCBRRangeRequest) (*rpcv1.CurrencyRates, error) {
+	if f.calls.Add(1) <= f.fail {
+		return nil, f.err
+	}
+	if f.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return rpcv1.NewCurrencyRates([]apiv1.CurrencyRate{
+		{Date: req.From, Code: req.Code, Nominal: 1, Value: money.MustParse("90.5")},
+		{Date: req.To, Code: req.Code, Nominal: 1, Value: money.NewFromInt(91), CarriedFrom: req.From},
+	}), nil
+}
+
+type fakeSubscriptions struct {
+	rpcv1.UnimplementedSubscriptionServiceServer
+	values map[rpcv1.SubscriptionKind][]string
+}
+
+func (f *fakeSubscriptions) Subscribe(_ context.Context, req *rpcv1.SubscriptionRequest) (*rpcv1.SubscribeResponse, error) {
+	if req.Kind == rpcv1.SubscriptionKind_SUBSCRIPTION_KIND_UNSPECIFIED {
+		return nil, rpcv1.Error(http.StatusBadRequest, "unknown subscription kind")
+	}
+	f.values[req.Kind] = append(f.values[req.Kind], req.Value)
+	return &rpcv1.SubscribeResponse{}, nil
+}
+
+func (f *fakeSubscriptions) ListSubscriptions(_ context.Context, req *rpcv1.ListSubscriptionsRequest) (*rpcv1.Subscriptions, error) {
+	return &rpcv1.Subscriptions{Values: f.values[req.Kind]}, nil
+}
+
+// newGRPCConn serves the fakes over an in-memory listener.
+func newGRPCConn(t testing.TB, history rpcv1.HistoryServiceServer, subs rpcv1.SubscriptionServiceServer) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	if history != nil {
+		rpcv1.RegisterHistoryServiceServer(srv, history)
+	}
+	if subs != nil {
+		rpcv1.RegisterSubscriptionServiceServer(srv, subs)
+	}
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestGRPC_CBRRange(t *testing.T) {
+	conn := newGRPCConn(t, &fakeHistory{}, nil)
+	// The HTTP base URL is unreachable: rate calls must not use it
+	c := New("http://127.0.0.1:1", WithGRPC(conn, nil))
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rates, err := c.CBRRange(context.Background(), "USD", from, from.AddDate(0, 0, 6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rates) != 2 || rates[0].Date != "2024-01-01" || rates[1].Date != "2024-01-07" || !rates[1].Value.Equal(money.NewFromInt(91)) ||
+		rates[0].CarriedFrom != "" || rates[1].CarriedFrom != "2024-01-01" {
+		t.Errorf("unexpected rates %+v", rates)
+	}
+}
+
+func TestGRPC_errorsAreAPIErrors(t *testing.T) {
+	f := &fakeHistory{fail: 1, err: rpcv1.Error(http.StatusNotFound, "no rates for XXX")}
+	c := New("", WithGRPC(newGRPCConn(t, f, nil), nil), WithRetries(2, time.Millisecond))
+
+	_, err := c.CBRRange(context.Background(), "XXX", time.Now(), time.Now())
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Message != "no rates for XXX" || !IsNotFound(err) {
+		t.Fatalf("expected a not_found APIError, got %#v", err)
+	}
+	if n := f.calls.Load(); n != 1 {
+		t.Errorf("not found must not be retried, got %d calls", n)
+	}
+}
+
+func TestGRPC_retriesUnavailable(t *testing.T) {
+	f := &fakeHistory{fail: 2, err: rpcv1.Error(http.StatusServiceUnavailable, "database is starting")}
+	c := New("", WithGRPC(newGRPCConn(t, f, nil), nil), WithRetries(2, time.Millisecond))
+
+	if _, err := c.CBRRange(context.Background(), "USD", time.Now(), time.Now()); err != nil {
+		t.Fatalf("expected success after retries, got %v", err)
+	}
+	if n := f.calls.Load(); n != 3 {
+		t.Errorf("expected 3 calls, got %d", n)
+	}
+}
+
+func TestGRPC_callDeadline(t *testing.T) {
+	f := &fakeHistory{block: true}
+	c := New("", WithGRPC(newGRPCConn(t, f, nil), nil), WithRetries(0, 0), WithCallTimeout(20*time.Millisecond))
+
+	start := time.Now()
+	_, err := c.CBRRange(context.Background(), "USD", time.Now(), time.Now())
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusGatewayTimeout || apiErr.Code != apiv1.CodeUnavailable {
+		t.Fatalf("expected a 504 APIError, got %#v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("the deadline was not applied, the call took %s", elapsed)
+	}
+}
+
+func TestGRPC_subscriptions(t *testing.T) {
+	subs := &fakeSubscriptions{values: map[rpcv1.SubscriptionKind][]string{}}
+	c := New("", WithGRPC(nil, newGRPCConn(t, nil, subs)), WithRetries(0, 0))
+	ctx := context.Background()
+
+	if err := c.Subscribe(ctx, CryptoSubscriptions, 42, "BTC"); err != nil {
+		t.Fatal(err)
+	}
+	values, err := c.Subscriptions(ctx, CryptoSubscriptions, 42)
+	if err != nil || len(values) != 1 || values[0] != "BTC" {
+		t.Errorf("unexpected subscriptions %v, %v", values, err)
+	}
+	if err := c.Subscribe(ctx, "stocks", 42, "AAPL"); !IsBadRequest(err) {
+		t.Errorf("expected bad_request for an unknown kind, got %v", err)
+	}
+}
+
+// BenchmarkCBRRange compares the overhead of the two transports for the
+// same call; both servers answer from memory over loopback.
+func BenchmarkCBRRange(b *testing.B) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 6)
+	ctx := context.Background()
+
+	rest := newTestClient(b, func(w http.ResponseWriter, r *http.Request) {
+		rates, _ := (&fakeHistory{}).GetCBRRange(r.Context(), &rpcv1.GetCBRRangeRequest{
+			Code: r.URL.Query().Get("code"), From: r.URL.Query().Get("from"), To: r.URL.Query().Get("to"),
+		})
+		writeJSON(w, http.StatusOK, apiv1.Response[[]apiv1.CurrencyRate]{Data: rates.DTO()})
+	})
+	grpcClient := New("", WithGRPC(newGRPCConn(b, &fakeHistory{}, nil), nil))
+
+	for name, c := range map[string]*Client{"REST": rest, "gRPC": grpcClient} {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := c.CBRRange(ctx, "USD", from, to); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
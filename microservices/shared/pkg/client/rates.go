@@ -0,0 +1,244 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/apiv1"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/rpcv1"
+)
+
+const dateLayout = "2006-01-02"
+
+// CBRRates returns all CBR rates published for date, ordered by currency
+// code. A zero date means today.
+func (c *Client) CBRRates(ctx context.Context, date time.Time) ([]apiv1.CurrencyRate, error) {
+	return c.CBRRatesAsOf(ctx, date, time.Time{})
+}
+
+// CBRRatesAsOf is CBRRates as the rates were stored at asOf, before any
+// later revision. A zero asOf means the current rates.
+func (c *Client) CBRRatesAsOf(ctx context.Context, date, asOf time.Time) ([]apiv1.CurrencyRate, error) {
+	if c.history != nil {
+		resp, err := invoke(ctx, c, c.history.GetCBRRates, &rpcv1.GetCBRRatesRequest{Date: formatDate(date), AsOf: formatTime(asOf)})
+		if err != nil {
+			return nil, err
+		}
+		return resp.DTO(), nil
+	}
+	q := url.Values{}
+	if !date.IsZero() {
+		q.Set("date", date.Format(dateLayout))
+	}
+	setAsOf(q, asOf)
+	return getV1[[]apiv1.CurrencyRate](ctx, c, "/v1/rates/cbr", q)
+}
+
+// CBRRange returns the CBR rates of code between from and to inclusive,
+// oldest first. The server accepts at most 365 days.
+func (c *Client) CBRRange(ctx context.Context, code string, from, to time.Time) ([]apiv1.CurrencyRate, error) {
+	return c.CBRRangeAsOf(ctx, code, from, to, time.Time{})
+}
+
+// CBRRangeAsOf is CBRRange as the rates were stored at asOf. A zero asOf
+// means the current rates.
+func (c *Client) CBRRangeAsOf(ctx context.Context, code string, from, to, asOf time.Time) ([]apiv1.CurrencyRate, error) {
+	if c.history != nil {
+		resp, err := invoke(ctx, c, c.history.GetCBRRange, &rpcv1.GetCBRRangeRequest{
+			Code: code, From: formatDate(from), To: formatDate(to), AsOf: formatTime(asOf),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return resp.DTO(), nil
+	}
+	q := rangeQuery(from, to)
+	q.Set("code", code)
+	setAsOf(q, asOf)
+	return getV1[[]apiv1.CurrencyRate](ctx, c, "/v1/rates/cbr/range", q)
+}
+
+// CryptoSymbols returns the available cryptocurrencies as base assets (BTC).
+func (c *Client) CryptoSymbols(ctx context.Context) ([]string, error) {
+	if c.history != nil {
+		resp, err := invoke(ctx, c, c.history.ListCryptoSymbols, &rpcv1.ListCryptoSymbolsRequest{})
+		if err != nil {
+			return nil, err
+		}
+		return append([]string{}, resp.GetSymbols()...), nil
+	}
+	return getV1[[]string](ctx, c, "/v1/rates/crypto/symbols", nil)
+}
+
+// CryptoRange returns the RUB candles of symbol (e.g. BTC) between from and
+// to inclusive, oldest first.
+func (c *Client) CryptoRange(ctx context.Context, symbol string, from, to time.Time) ([]apiv1.CryptoRate, error) {
+	if c.history != nil {
+		resp, err := invoke(ctx, c, c.history.GetCryptoRange, &rpcv1.GetCryptoRangeRequest{
+			Symbol: symbol, From: formatDate(from), To: formatDate(to),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return resp.DTO(), nil
+	}
+	q := rangeQuery(from, to)
+	q.Set("symbol", symbol)
+	return getV1[[]apiv1.CryptoRate](ctx, c, "/v1/rates/crypto/range", q)
+}
+
+// MetalPrices returns the CBR precious metals prices in effect on date: those
+// of the latest date on or before it, ordered by metal. A zero date means
+// today. Metals are served over HTTP only, also when history-service is
+// reached over gRPC.
+func (c *Client) MetalPrices(ctx context.Context, date time.Time) ([]apiv1.MetalPrice, error) {
+	q := url.Values{}
+	if !date.IsZero() {
+		q.Set("date", date.Format(dateLayout))
+	}
+	return getV1[[]apiv1.MetalPrice](ctx, c, "/v1/rates/metals", q)
+}
+
+// MetalRange returns the CBR prices of metal (e.g. XAU; every metal when
+// empty) between from and to inclusive, oldest first.
+func (c *Client) MetalRange(ctx context.Context, metal string, from, to time.Time) ([]apiv1.MetalPrice, error) {
+	q := rangeQuery(from, to)
+	if metal != "" {
+		q.Set("metal", metal)
+	}
+	return getV1[[]apiv1.MetalPrice](ctx, c, "/v1/rates/metals/range", q)
+}
+
+// IndicatorRates returns the CBR indicators (key rate, RUONIA) in effect on
+// date, ordered by indicator. A zero date means today. Like metals, they are
+// served over HTTP only.
+func (c *Client) IndicatorRates(ctx context.Context, date time.Time) ([]apiv1.IndicatorRate, error) {
+	q := url.Values{}
+	if !date.IsZero() {
+		q.Set("date", date.Format(dateLayout))
+	}
+	return getV1[[]apiv1.IndicatorRate](ctx, c, "/v1/rates/indicators", q)
+}
+
+// IndicatorRange returns the values of indicator (e.g. KEY_RATE) in effect
+// between from and to inclusive, oldest first. The first value is the one
+// in effect on from and may have taken effect before it.
+func (c *Client) IndicatorRange(ctx context.Context, indicator string, from, to time.Time) ([]apiv1.IndicatorRate, error) {
+	q := rangeQuery(from, to)
+	q.Set("indicator", indicator)
+	return getV1[[]apiv1.IndicatorRate](ctx, c, "/v1/rates/indicators/range", q)
+}
+
+// CryptoIndicators returns the technical indicators (e.g. "rsi14,sma20")
+// of symbol (e.g. BTC) over the last bars of interval (e.g. 1h), with their
+// latest values. Like metals, they are served over HTTP only.
+func (c *Client) CryptoIndicators(ctx context.Context, symbol, interval, specs string) (apiv1.IndicatorsResult, error) {
+	q := url.Values{}
+	q.Set("symbol", symbol)
+	q.Set("interval", interval)
+	q.Set("indicators", specs)
+	return getV1[apiv1.IndicatorsResult](ctx, c, "/v1/rates/crypto/indicators", q)
+}
+
+// ConvertRequest describes a conversion. A zero Amount means 1 and a zero
+// Date means today.
+type ConvertRequest struct {
+	From   string
+	To     string
+	Amount money.Decimal
+	Date   time.Time
+}
+
+// Convert converts an amount between currencies and cryptocurrencies.
+func (c *Client) Convert(ctx context.Context, req ConvertRequest) (apiv1.Conversion, error) {
+	if c.history != nil {
+		resp, err := invoke(ctx, c, c.history.Convert,
+			rpcv1.NewConvertRequest(req.From, req.To, req.Amount, formatDate(req.Date)))
+		if err != nil {
+			return apiv1.Conversion{}, err
+		}
+		return resp.DTO(), nil
+	}
+	q := url.Values{}
+	q.Set("from", req.From)
+	q.Set("to", req.To)
+	if !req.Amount.IsZero() {
+		q.Set("amount", req.Amount.String())
+	}
+	if !req.Date.IsZero() {
+		q.Set("date", req.Date.Format(dateLayout))
+	}
+	return getV1[apiv1.Conversion](ctx, c, "/v1/convert", q)
+}
+
+// QuotedRate is a CBR rate as served by the legacy quote-aware routes.
+// Value and Previous are in RUB; when a quote other than RUB was requested,
+// QuoteValue and QuotePrevious hold the same prices in Quote and are zero if
+// no cross rate was available.
+type QuotedRate struct {
+	Date          time.Time     `json:"Date"`
+	CurrencyCode  string        `json:"CurrencyCode"`
+	CurrencyName  string        `json:"CurrencyName"`
+	Nominal       int           `json:"Nominal"`
+	Value         money.Decimal `json:"Value"`
+	Previous      money.Decimal `json:"Previous"`
+	Quote         string        `json:"Quote"`
+	QuoteValue    money.Decimal `json:"QuoteValue"`
+	QuotePrevious money.Decimal `json:"QuotePrevious"`
+}
+
+// QuotedCBRRates returns the CBR rates of date priced in quote as well. It
+// uses the deprecated /rates/cbr route because /v1 has no quote parameter.
+// A zero date means today.
+func (c *Client) QuotedCBRRates(ctx context.Context, date time.Time, quote string) ([]QuotedRate, error) {
+	q := url.Values{}
+	q.Set("quote", quote)
+	if !date.IsZero() {
+		q.Set("date", date.Format(dateLayout))
+	}
+	var rates []QuotedRate
+	err := c.do(ctx, http.MethodGet, c.baseURL+"/rates/cbr", q, nil, &rates)
+	return rates, err
+}
+
+// QuotedCBRRange is CBRRange with prices in quote as well, served by the
+// deprecated /rates/cbr/range route.
+func (c *Client) QuotedCBRRange(ctx context.Context, code string, from, to time.Time, quote string) ([]QuotedRate, error) {
+	q := rangeQuery(from, to)
+	q.Set("code", code)
+	q.Set("quote", quote)
+	var rates []QuotedRate
+	err := c.do(ctx, http.MethodGet, c.baseURL+"/rates/cbr/range", q, nil, &rates)
+	return rates, err
+}
+
+// formatDate formats a date for a request; the zero date is empty.
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(dateLayout)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func setAsOf(q url.Values, asOf time.Time) {
+	if !asOf.IsZero() {
+		q.Set("as_of", formatTime(asOf))
+	}
+}
+
+func rangeQuery(from, to time.Time) url.Values {
+	q := url.Values{}
+	q.Set("from", from.Format(dateLayout))
+	q.Set("to", to.Format(dateLayout))
+	return q
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/rpcv1"
+)
+
+// SubscriptionKind selects the kind of rate a subscription follows.
+type SubscriptionKind string
+
+const (
+	// CBRSubscriptions follow CBR rates; values are currency codes (USD).
+	CBRSubscriptions SubscriptionKind = "cbr"
+	// CryptoSubscriptions follow cryptocurrencies; values are symbols (BTC).
+	CryptoSubscriptions SubscriptionKind = "crypto"
+	// MetalSubscriptions follow CBR precious metals prices; values are ISO
+	// codes (XAU).
+	MetalSubscriptions SubscriptionKind = "metals"
+	// IndicatorSubscriptions follow changes of CBR indicators; the only
+	// value is KEY_RATE.
+	IndicatorSubscriptions SubscriptionKind = "indicators"
+	// AlertSubscriptions follow conditions on the technical indicators of a
+	// cryptocurrency; values are alerts such as "BTC 1h rsi14>70" (see
+	// indicators.ParseAlert).
+	AlertSubscriptions SubscriptionKind = "alerts"
+)
+
+// subscriptionRequest is the body of subscribe and unsubscribe calls.
+type subscriptionRequest struct {
+	TelegramID int64  `json:"telegram_id"`
+	Value      string `json:"value"`
+}
+
+// rpcKind returns the gRPC enum value of kind.
+func rpcKind(kind SubscriptionKind) rpcv1.SubscriptionKind {
+	switch kind {
+	case CBRSubscriptions:
+		return rpcv1.SubscriptionKind_SUBSCRIPTION_KIND_CBR
+	case CryptoSubscriptions:
+		return rpcv1.SubscriptionKind_SUBSCRIPTION_KIND_CRYPTO
+	case MetalSubscriptions:
+		return rpcv1.SubscriptionKind_SUBSCRIPTION_KIND_METALS
+	case IndicatorSubscriptions:
+		return rpcv1.SubscriptionKind_SUBSCRIPTION_KIND_INDICATORS
+	case AlertSubscriptions:
+		return rpcv1.SubscriptionKind_SUBSCRIPTION_KIND_ALERTS
+	}
+	return rpcv1.SubscriptionKind_SUBSCRIPTION_KIND_UNSPECIFIED
+}
+
+func (c *Client) subscriptionsURL(kind SubscriptionKind) string {
+	return c.notificationsURL + "/subscriptions/" + string(kind)
+}
+
+// Subscribe subscribes a Telegram user to updates of value. Subscribing
+// twice is harmless.
+func (c *Client) Subscribe(ctx context.Context, kind SubscriptionKind, telegramID int64, value string) error {
+	if c.subscriptions != nil {
+		_, err := invoke(ctx, c, c.subscriptions.Subscribe, &rpcv1.SubscriptionRequest{
+			Kind: rpcKind(kind), TelegramId: telegramID, Value: value,
+		})
+		return err
+	}
+	return c.do(ctx, http.MethodPost, c.subscriptionsURL(kind), nil, subscriptionRequest{telegramID, value}, nil)
+}
+
+// Unsubscribe removes a subscription; removing a missing one is harmless.
+func (c *Client) Unsubscribe(ctx context.Context, kind SubscriptionKind, telegramID int64, value string) error {
+	if c.subscriptions != nil {
+		_, err := invoke(ctx, c, c.subscriptions.Unsubscribe, &rpcv1.SubscriptionRequest{
+			Kind: rpcKind(kind), TelegramId: telegramID, Value: value,
+		})
+		return err
+	}
+	return c.do(ctx, http.MethodDelete, c.subscriptionsURL(kind), nil, subscriptionRequest{telegramID, value}, nil)
+}
+
+// Subscriptions lists the values a Telegram user is subscribed to.
+func (c *Client) Subscriptions(ctx context.Context, kind SubscriptionKind, telegramID int64) ([]string, error) {
+	if c.subscriptions != nil {
+		resp, err := invoke(ctx, c, c.subscriptions.ListSubscriptions, &rpcv1.ListSubscriptionsRequest{
+			Kind: rpcKind(kind), TelegramId: telegramID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return append([]string{}, resp.GetValues()...), nil
+	}
+	q := url.Values{}
+	q.Set("telegram_id", strconv.FormatInt(telegramID, 10))
+	var values []string
+	err := c.do(ctx, http.MethodGet, c.subscriptionsURL(kind), q, nil, &values)
+	return values, err
+}
@@ -0,0 +1,239 @@
+// Package rpcv1 is the internal gRPC contract between the gateway, the
+// Telegram bot and the history and notification services. The messages
+// mirror the shared/apiv1 DTOs, and this file converts between the two, so
+// the gateway can answer /v1 HTTP requests from gRPC responses unchanged.
+//
+// Failures are reported as gRPC statuses whose code matches the /v1 error
+// code: bad_request is InvalidArgument, not_found is NotFound, unavailable
+// is Unavailable and anything else is Internal.
+//
+// The *.pb.go files are generated from the .proto files next to them with
+// protoc-gen-go and protoc-gen-go-grpc (paths=source_relative, run from
+// shared/); regenerate them after editing a .proto file.
+package rpcv1
+
+import (
+	"net/http"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/apiv1"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/logging"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Dial returns a connection to a service at addr (host:port). The services
+// talk over the private compose network, so the connection is not
+// encrypted. Connecting is lazy: an unreachable service fails the calls,
+// not Dial. Calls pass the caller's trace context and request ID on to the
+// service.
+func Dial(addr string) (*grpc.ClientConn, error) {
+	return grpc.NewClient(addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+		grpc.WithUnaryInterceptor(logging.UnaryClientInterceptor))
+}
+
+// NewServer returns a gRPC server that continues the callers' traces and
+// logs every call under the caller's request ID.
+func NewServer() *grpc.Server {
+	return grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.UnaryInterceptor(logging.UnaryServerInterceptor))
+}
+
+// Error returns the status error for a failure the /v1 HTTP routes would
+// answer with the HTTP status.
+func Error(httpStatus int, msg string) error {
+	var code codes.Code
+	switch apiv1.CodeForStatus(httpStatus) {
+	case apiv1.CodeBadRequest:
+		code = codes.InvalidArgument
+	case apiv1.CodeNotFound:
+		code = codes.NotFound
+	case apiv1.CodeUnavailable:
+		code = codes.Unavailable
+	default:
+		code = codes.Internal
+	}
+	return status.Error(code, msg)
+}
+
+// HTTPStatus returns the HTTP status matching the gRPC status of err.
+// Errors without a status, such as a failed connection, are 502.
+func HTTPStatus(err error) int {
+	st, ok := status.FromError(err)
+	if !ok {
+		return http.StatusBadGateway
+	}
+	switch st.Code() {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument, codes.OutOfRange, codes.FailedPrecondition:
+		return http.StatusBadRequest
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
+// Message returns the message of the gRPC status of err.
+func Message(err error) string {
+	if st, ok := status.FromError(err); ok {
+		return st.Message()
+	}
+	return err.Error()
+}
+
+// decimal returns the value of a *_decimal field, or of the double next to
+// it when a caller built before the decimals left the string empty.
+func decimal(s string, f float64) money.Decimal {
+	if s == "" {
+		return money.NewFromFloat(f)
+	}
+	d, err := money.Parse(s)
+	if err != nil {
+		return money.NewFromFloat(f)
+	}
+	return d
+}
+
+// NewConvertRequest returns a request to convert amount of from into to on
+// date.
+func NewConvertRequest(from, to string, amount money.Decimal, date string) *ConvertRequest {
+	return &ConvertRequest{
+		From:          from,
+		To:            to,
+		Amount:        amount.Float64(),
+		AmountDecimal: amount.String(),
+		Date:          date,
+	}
+}
+
+// AmountValue returns the amount to convert; zero means 1.
+func (x *ConvertRequest) AmountValue() money.Decimal {
+	return decimal(x.GetAmountDecimal(), x.GetAmount())
+}
+
+// NewCurrencyRates converts /v1 CBR rates.
+func NewCurrencyRates(rates []apiv1.CurrencyRate) *CurrencyRates {
+	out := &CurrencyRates{Rates: make([]*CurrencyRate, 0, len(rates))}
+	for _, r := range rates {
+		out.Rates = append(out.Rates, &CurrencyRate{
+			Date:             r.Date,
+			Code:             r.Code,
+			Name:             r.Name,
+			Nominal:          int32(r.Nominal),
+			Value:            r.Value.Float64(),
+			Previous:         r.Previous.Float64(),
+			CarriedFrom:      r.CarriedFrom,
+			ValueDecimal:     r.Value.String(),
+			PreviousDecimal:  r.Previous.String(),
+			UnitValueDecimal: r.UnitValue.String(),
+			PreviousNominal:  int32(r.PreviousNominal),
+		})
+	}
+	return out
+}
+
+// DTO converts the rates to their /v1 form.
+func (x *CurrencyRates) DTO() []apiv1.CurrencyRate {
+	out := make([]apiv1.CurrencyRate, 0, len(x.GetRates()))
+	for _, r := range x.GetRates() {
+		rate := apiv1.CurrencyRate{
+			Date:            r.GetDate(),
+			Code:            r.GetCode(),
+			Name:            r.GetName(),
+			Nominal:         int(r.GetNominal()),
+			Value:           decimal(r.GetValueDecimal(), r.GetValue()),
+			Previous:        decimal(r.GetPreviousDecimal(), r.GetPrevious()),
+			UnitValue:       decimal(r.GetUnitValueDecimal(), 0),
+			PreviousNominal: int(r.GetPreviousNominal()),
+			CarriedFrom:     r.GetCarriedFrom(),
+		}
+		if !rate.UnitValue.IsPositive() {
+			// A server built before unit_value
+			rate.UnitValue = money.PerUnit(rate.Value, rate.Nominal)
+		}
+		out = append(out, rate)
+	}
+	return out
+}
+
+// NewCryptoRates converts /v1 crypto candles.
+func NewCryptoRates(rates []apiv1.CryptoRate) *CryptoRates {
+	out := &CryptoRates{Rates: make([]*CryptoRate, 0, len(rates))}
+	for _, r := range rates {
+		out.Rates = append(out.Rates, &CryptoRate{
+			Time:          timestamppb.New(r.Time),
+			Symbol:        r.Symbol,
+			Open:          r.Open.Float64(),
+			High:          r.High.Float64(),
+			Low:           r.Low.Float64(),
+			Close:         r.Close.Float64(),
+			Volume:        r.Volume.Float64(),
+			OpenDecimal:   r.Open.String(),
+			HighDecimal:   r.High.String(),
+			LowDecimal:    r.Low.String(),
+			CloseDecimal:  r.Close.String(),
+			VolumeDecimal: r.Volume.String(),
+		})
+	}
+	return out
+}
+
+// DTO converts the candles to their /v1 form, with times in UTC.
+func (x *CryptoRates) DTO() []apiv1.CryptoRate {
+	out := make([]apiv1.CryptoRate, 0, len(x.GetRates()))
+	for _, r := range x.GetRates() {
+		out = append(out, apiv1.CryptoRate{
+			Time:   r.GetTime().AsTime(),
+			Symbol: r.GetSymbol(),
+			Open:   decimal(r.GetOpenDecimal(), r.GetOpen()),
+			High:   decimal(r.GetHighDecimal(), r.GetHigh()),
+			Low:    decimal(r.GetLowDecimal(), r.GetLow()),
+			Close:  decimal(r.GetCloseDecimal(), r.GetClose()),
+			Volume: decimal(r.GetVolumeDecimal(), r.GetVolume()),
+		})
+	}
+	return out
+}
+
+// NewConversion converts a /v1 conversion.
+func NewConversion(c apiv1.Conversion) *Conversion {
+	return &Conversion{
+		From:          c.From,
+		To:            c.To,
+		Amount:        c.Amount.Float64(),
+		Result:        c.Result.Float64(),
+		Rate:          c.Rate.Float64(),
+		Date:          c.Date,
+		FromRateDate:  c.FromRateDate,
+		ToRateDate:    c.ToRateDate,
+		AmountDecimal: c.Amount.String(),
+		ResultDecimal: c.Result.String(),
+		RateDecimal:   c.Rate.String(),
+	}
+}
+
+// DTO converts the conversion to its /v1 form.
+func (x *Conversion) DTO() apiv1.Conversion {
+	return apiv1.Conversion{
+		From:         x.GetFrom(),
+		To:           x.GetTo(),
+		Amount:       decimal(x.GetAmountDecimal(), x.GetAmount()),
+		Result:       decimal(x.GetResultDecimal(), x.GetResult()),
+		Rate:         decimal(x.GetRateDecimal(), x.GetRate()),
+		Date:         x.GetDate(),
+		FromRateDate: x.GetFromRateDate(),
+		ToRateDate:   x.GetToRateDate(),
+	}
+}
@@ -0,0 +1,73 @@
+package rpcv1
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/apiv1"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestError_roundTripsHTTPStatus(t *testing.T) {
+	for in, want := range map[int]codes.Code{
+		http.StatusBadRequest:          codes.InvalidArgument,
+		http.StatusNotFound:            codes.NotFound,
+		http.StatusServiceUnavailable:  codes.Unavailable,
+		http.StatusInternalServerError: codes.Internal,
+	} {
+		err := Error(in, "boom")
+		if got := status.Code(err); got != want {
+			t.Errorf("%d: expected %s, got %s", in, want, got)
+		}
+		if got := HTTPStatus(err); got != in {
+			t.Errorf("%s: expected HTTP %d, got %d", want, in, got)
+		}
+		if Message(err) != "boom" {
+			t.Errorf("expected the message to survive, got %q", Message(err))
+		}
+	}
+	if got := HTTPStatus(status.Error(codes.DeadlineExceeded, "")); got != http.StatusGatewayTimeout {
+		t.Errorf("expected 504 for an expired deadline, got %d", got)
+	}
+	if got := HTTPStatus(errors.New("dial failed")); got != http.StatusBadGateway {
+		t.Errorf("expected 502 for an error without status, got %d", got)
+	}
+}
+
+func TestDTO_roundTrips(t *testing.T) {
+	rates := []apiv1.CurrencyRate{{Date: "2024-01-09", Code: "JPY", Name: "Иена", Nominal: 100, Value: money.MustParse("61.5"), Previous: money.MustParse("61.2"), UnitValue: money.MustParse("0.615"), PreviousNominal: 10}}
+	if got := NewCurrencyRates(rates).DTO(); !reflect.DeepEqual(got, rates) {
+		t.Errorf("expected %+v, got %+v", rates, got)
+	}
+	before := &CurrencyRates{Rates: []*CurrencyRate{{Code: "JPY", Nominal: 100, ValueDecimal: "61.5"}}}
+	if got := before.DTO(); got[0].UnitValue.String() != "0.615" {
+		t.Errorf("expected the unit value of an older sender to be derived, got %s", got[0].UnitValue)
+	}
+
+	candles := []apiv1.CryptoRate{{Time: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), Symbol: "BTC", Open: money.NewFromInt(1), High: money.NewFromInt(2), Low: money.MustParse("0.5"), Close: money.MustParse("1.5"), Volume: money.MustParse("10.25")}}
+	if got := NewCryptoRates(candles).DTO(); !reflect.DeepEqual(got, candles) {
+		t.Errorf("expected %+v, got %+v", candles, got)
+	}
+
+	conv := apiv1.Conversion{From: "EUR", To: "CNY", Amount: money.NewFromInt(250), Result: money.NewFromInt(1950), Rate: money.MustParse("7.8"), Date: "2025-03-10", FromRateDate: "2025-03-08", ToRateDate: "2025-03-08"}
+	if got := NewConversion(conv).DTO(); !reflect.DeepEqual(got, conv) {
+		t.Errorf("expected %+v, got %+v", conv, got)
+	}
+
+	old := &Conversion{From: "EUR", To: "CNY", Amount: 250, Result: 1950.75, Rate: 7.803}
+	if got := old.DTO(); got.Result.String() != "1950.75" || got.Rate.String() != "7.803" {
+		t.Errorf("expected the doubles of an older sender to be read, got %+v", got)
+	}
+	if got := NewConvertRequest("EUR", "CNY", money.MustParse("0.1"), "").AmountValue(); got.String() != "0.1" {
+		t.Errorf("expected amount 0.1, got %s", got)
+	}
+
+	if got := (*CurrencyRates)(nil).DTO(); got == nil || len(got) != 0 {
+		t.Errorf("expected an empty slice for a nil message, got %#v", got)
+	}
+}
@@ -0,0 +1,205 @@
+// Package tracing sets up OpenTelemetry tracing for the services and carries
+// trace context across HTTP, gRPC and Kafka, so one trace follows a rate
+// from the data-collector run that fetched it through normalization, the
+// PostgreSQL or ClickHouse write and the gateway's live stream.
+//
+// Setup picks the exporter from the standard environment variables:
+//
+//	OTEL_TRACES_EXPORTER          otlp, stdout (or console) or none
+//	OTEL_EXPORTER_OTLP_ENDPOINT   OTLP/gRPC collector, e.g. http://jaeger:4317
+//	OTEL_SERVICE_NAME             overrides the service name passed to Setup
+//
+// Without OTEL_TRACES_EXPORTER, traces are exported over OTLP when an
+// endpoint is configured and not recorded otherwise. Trace context is
+// propagated either way, so a service without an exporter does not break
+// the traces passing through it.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentation = "github.com/casualdoto/go-currency-tracker/microservices/shared/tracing"
+
+func init() {
+	// Propagate W3C trace context even before Setup and without an exporter
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+}
+
+// Setup installs the global tracer provider of service and returns a
+// function that flushes the remaining spans; call it before exiting.
+func Setup(ctx context.Context, service string) (shutdown func(context.Context) error, err error) {
+	exp, err := newExporter(ctx, os.Stdout)
+	if err != nil || exp == nil {
+		return func(context.Context) error { return nil }, err
+	}
+	tp, err := NewProvider(ctx, service, exp)
+	if err != nil {
+		return nil, err
+	}
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// NewProvider returns a tracer provider of service that batches spans to
+// exp. Tests pass a stdouttrace exporter writing to a buffer.
+func NewProvider(ctx context.Context, service string, exp sdktrace.SpanExporter) (*sdktrace.TracerProvider, error) {
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(service)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK())
+	if err != nil {
+		return nil, fmt.Errorf("tracing resource: %w", err)
+	}
+	return sdktrace.NewTracerProvider(sdktrace.WithBatcher(exp), sdktrace.WithResource(res)), nil
+}
+
+// newExporter returns the exporter configured in the environment, or nil
+// when traces are not exported.
+func newExporter(ctx context.Context, stdout io.Writer) (sdktrace.SpanExporter, error) {
+	kind := os.Getenv("OTEL_TRACES_EXPORTER")
+	if kind == "" && (os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != "") {
+		kind = "otlp"
+	}
+	switch kind {
+	case "", "none":
+		return nil, nil
+	case "otlp":
+		// The endpoint, headers and TLS settings come from the OTEL_EXPORTER_OTLP_* variables
+		return otlptracegrpc.New(ctx)
+	case "stdout", "console":
+		return stdouttrace.New(stdouttrace.WithWriter(stdout))
+	default:
+		return nil, fmt.Errorf("unknown OTEL_TRACES_EXPORTER %q", kind)
+	}
+}
+
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentation)
+}
+
+// Start starts a span named name as a child of the span in ctx, or as the
+// root of a new trace.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err, if any, on span and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Middleware starts a server span for every request served by a chi router,
+// continuing the trace of the caller, and names it after the route pattern
+// once the request has been routed. Prometheus scrapes and health probes
+// are not traced.
+func Middleware(next http.Handler) http.Handler {
+	named := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			span := trace.SpanFromContext(r.Context())
+			span.SetName(r.Method + " " + rctx.RoutePattern())
+			span.SetAttributes(semconv.HTTPRoute(rctx.RoutePattern()))
+		}
+	})
+	return otelhttp.NewHandler(named, "http.request",
+		otelhttp.WithFilter(func(r *http.Request) bool { return !probes[r.URL.Path] }))
+}
+
+// probes are the paths polled by Prometheus and health checks.
+var probes = map[string]bool{"/metrics": true, "/healthz": true, "/readyz": true}
+
+// Transport returns a RoundTripper that records the calls made through base
+// (http.DefaultTransport when nil) as client spans and passes the trace
+// context on in the request headers. Requests must carry the caller's
+// context (http.NewRequestWithContext) to join its trace.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return otelhttp.NewTransport(base)
+}
+
+// headerCarrier adapts Kafka message headers to the propagation API.
+type headerCarrier struct {
+	headers *[]kafka.Header
+}
+
+func (c headerCarrier) Get(key string) string {
+	for _, h := range *c.headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c headerCarrier) Set(key, value string) {
+	for i, h := range *c.headers {
+		if h.Key == key {
+			(*c.headers)[i].Value = []byte(value)
+			return
+		}
+	}
+	*c.headers = append(*c.headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, len(*c.headers))
+	for i, h := range *c.headers {
+		keys[i] = h.Key
+	}
+	return keys
+}
+
+// StartProducer starts a producer span for publishing msg to topic and
+// writes its trace context into the message headers. End the span once the
+// message is written.
+func StartProducer(ctx context.Context, topic string, msg *kafka.Message) (context.Context, trace.Span) {
+	ctx, span := tracer().Start(ctx, topic+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingDestinationName(topic),
+			semconv.MessagingOperationTypePublish))
+	otel.GetTextMapPropagator().Inject(ctx, headerCarrier{&msg.Headers})
+	return ctx, span
+}
+
+// StartConsumer starts a consumer span for processing msg, read by group,
+// as a child of the span that published it. End the span once the message
+// is processed.
+func StartConsumer(ctx context.Context, group string, msg kafka.Message) (context.Context, trace.Span) {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, headerCarrier{&msg.Headers})
+	return tracer().Start(ctx, msg.Topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingDestinationName(msg.Topic),
+			semconv.MessagingKafkaConsumerGroup(group),
+			semconv.MessagingKafkaMessageOffset(int(msg.Offset)),
+			semconv.MessagingDestinationPartitionID(fmt.Sprint(msg.Partition))))
+}
@@ -0,0 +1,112 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// useStdout installs a tracer provider exporting to the returned buffer;
+// the spans are in it once the provider is shut down.
+func useStdout(t *testing.T) (*bytes.Buffer, *sdktrace.TracerProvider) {
+	t.Helper()
+	var buf bytes.Buffer
+	exp, err := stdouttrace.New(stdouttrace.WithWriter(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tp, err := NewProvider(context.Background(), "test", exp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return &buf, tp
+}
+
+func TestKafka_propagatesThroughHeaders(t *testing.T) {
+	buf, tp := useStdout(t)
+
+	msg := kafka.Message{Value: []byte("{}")}
+	_, producer := StartProducer(context.Background(), "raw-rates", &msg)
+	producer.End()
+	if len(msg.Headers) == 0 || msg.Headers[0].Key != "traceparent" {
+		t.Fatalf("expected a traceparent header, got %v", msg.Headers)
+	}
+
+	msg.Topic = "raw-rates" // set by the reader
+	_, consumer := StartConsumer(context.Background(), "test-group", msg)
+	consumer.End()
+
+	got := consumer.(sdktrace.ReadOnlySpan)
+	if got.Parent().SpanID() != producer.SpanContext().SpanID() || got.SpanContext().TraceID() != producer.SpanContext().TraceID() {
+		t.Errorf("expected the consumer span to continue the producer's trace")
+	}
+
+	tp.Shutdown(context.Background())
+	if !strings.Contains(buf.String(), `"Name":"raw-rates publish"`) || !strings.Contains(buf.String(), `"Name":"raw-rates process"`) {
+		t.Errorf("expected both spans to be exported, got %s", buf)
+	}
+}
+
+func TestMiddleware_continuesTraceNamedByRoute(t *testing.T) {
+	useStdout(t)
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	var span trace.Span
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Get("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		span = trace.SpanFromContext(r.Context())
+	})
+	r.Get("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		span = trace.SpanFromContext(r.Context())
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	got := span.(sdktrace.ReadOnlySpan)
+	if got.Name() != "GET /items/{id}" || got.SpanContext().TraceID().String() != traceID {
+		t.Errorf("expected GET /items/{id} in trace %s, got %q in %s", traceID, got.Name(), got.SpanContext().TraceID())
+	}
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if span.SpanContext().IsValid() {
+		t.Error("expected scrapes not to be traced")
+	}
+}
+
+func TestTransport_sendsTraceContext(t *testing.T) {
+	useStdout(t)
+	var header string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("traceparent")
+	}))
+	defer srv.Close()
+
+	ctx, span := Start(context.Background(), "caller")
+	defer span.End()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	resp, err := (&http.Client{Transport: Transport(nil)}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if !strings.Contains(header, span.SpanContext().TraceID().String()) {
+		t.Errorf("expected the caller's trace in traceparent, got %q", header)
+	}
+}
@@ -1,11 +1,16 @@
 package main
 
 import (
-	"log"
+	"context"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/health"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/logging"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/tracing"
 	"github.com/casualdoto/go-currency-tracker/microservices/telegram-bot/internal/bot"
 	"github.com/casualdoto/go-currency-tracker/microservices/telegram-bot/internal/config"
 )
@@ -13,19 +18,41 @@ import (
 func main() {
 	cfg := config.Load()
 
+	logging.Setup("telegram-bot")
+	shutdownTracing, err := tracing.Setup(context.Background(), "telegram-bot")
+	if err != nil {
+		slog.Error("tracing setup failed", "error", err)
+		os.Exit(1)
+	}
+	defer shutdownTracing(context.Background())
+
 	b, err := bot.New(cfg)
 	if err != nil {
-		log.Fatalf("failed to create bot: %v", err)
+		slog.Error("failed to create bot", "error", err)
+		os.Exit(1)
 	}
 
-	log.Println("Telegram Bot Service: starting")
+	// Health checks and Prometheus metrics; the bot has no other HTTP server.
+	// Rates go through the gateway; only the subscription commands need
+	// notification-service.
+	checker := health.New().
+		Add("api-gateway", health.HTTP(http.DefaultClient, cfg.APIGatewayURL+"/healthz")).
+		AddOptional("notification-service", health.HTTP(http.DefaultClient, cfg.NotificationSvcURL+"/healthz"))
+	go func() {
+		slog.Info("http listening", "addr", ":"+cfg.HTTPPort)
+		if err := health.ListenAndServe(":"+cfg.HTTPPort, checker); err != nil {
+			slog.Error("http server failed", "error", err)
+		}
+	}()
+
+	slog.Info("starting")
 	b.Start()
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("Telegram Bot Service: shutting down")
+	slog.Info("shutting down")
 	b.Stop()
 }
 
@@ -1,24 +1,44 @@
 package bot
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
-	"log"
-	"net/http"
 	"strings"
 	"time"
 
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/calendar"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/events"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/indicators"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/logging"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/metrics"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/pkg/client"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/rpcv1"
 	"github.com/casualdoto/go-currency-tracker/microservices/telegram-bot/internal/config"
 	"github.com/tucnak/telebot"
+	"google.golang.org/grpc"
 )
 
+// requestTimeout bounds the upstream calls made for one command, retries included.
+const requestTimeout = 30 * time.Second
+
+var logger = logging.For("bot")
+
+// commandContext returns the context of one command: its own request ID,
+// which the upstream calls carry, bounded by requestTimeout.
+func commandContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(logging.WithRequestID(context.Background(), logging.NewRequestID()), requestTimeout)
+}
+
 // Bot wraps the Telegram bot and calls upstream services.
 type Bot struct {
-	bot        *telebot.Bot
-	cfg        *config.Config
-	httpClient *http.Client
+	bot *telebot.Bot
+	cfg *config.Config
+	// api calls the gateway, and notification-service directly for
+	// subscriptions; with gRPC addresses configured it calls history-service
+	// and notification-service over gRPC instead.
+	api *client.Client
 }
 
 func New(cfg *config.Config) (*Bot, error) {
@@ -29,30 +49,57 @@ func New(cfg *config.Config) (*Bot, error) {
 	if err != nil {
 		return nil, err
 	}
+	history, err := dialOptional(cfg.HistoryGRPCAddr)
+	if err != nil {
+		return nil, err
+	}
+	subscriptions, err := dialOptional(cfg.NotificationGRPCAddr)
+	if err != nil {
+		return nil, err
+	}
 	return &Bot{
-		bot:        b,
-		cfg:        cfg,
-		httpClient: &http.Client{Timeout: 15 * time.Second},
+		bot: b,
+		cfg: cfg,
+		api: client.New(cfg.APIGatewayURL,
+			client.WithNotificationsURL(cfg.NotificationSvcURL),
+			client.WithGRPC(history, subscriptions)),
 	}, nil
 }
 
+// dialOptional connects to addr, or returns nil when addr is empty.
+func dialOptional(addr string) (grpc.ClientConnInterface, error) {
+	if addr == "" {
+		return nil, nil
+	}
+	return rpcv1.Dial(addr)
+}
+
 func (b *Bot) Start() {
 	b.bot.Handle("/start", b.handleStart)
 	b.bot.Handle("/rates", b.handleRates)
 	b.bot.Handle("/subscribe", b.handleSubscribe)
 	b.bot.Handle("/unsubscribe", b.handleUnsubscribe)
 	b.bot.Handle("/history", b.handleHistory)
+	b.bot.Handle("/convert", b.handleConvert)
 	b.bot.Handle("/crypto_subscribe", b.handleCryptoSubscribe)
 	b.bot.Handle("/crypto_unsubscribe", b.handleCryptoUnsubscribe)
+	b.bot.Handle("/metals", b.handleMetals)
+	b.bot.Handle("/metals_subscribe", b.handleMetalsSubscribe)
+	b.bot.Handle("/metals_unsubscribe", b.handleMetalsUnsubscribe)
+	b.bot.Handle("/keyrate", b.handleKeyRate)
+	b.bot.Handle("/keyrate_subscribe", b.handleKeyRateSubscribe)
+	b.bot.Handle("/keyrate_unsubscribe", b.handleKeyRateUnsubscribe)
+	b.bot.Handle("/alert_subscribe", b.handleAlertSubscribe)
+	b.bot.Handle("/alert_unsubscribe", b.handleAlertUnsubscribe)
 
 	// If a webhook was set (e.g. from another deploy), getUpdates receives nothing.
 	if _, err := b.bot.Raw("deleteWebhook", map[string]interface{}{}); err != nil {
-		log.Printf("telegram: deleteWebhook: %v", err)
+		logger.Warn("deleteWebhook failed", "error", err)
 	} else {
-		log.Printf("telegram: webhook cleared, long polling enabled")
+		logger.Info("webhook cleared, long polling enabled")
 	}
 	if b.bot.Me != nil {
-		log.Printf("telegram: bot @%s ready", b.bot.Me.Username)
+		logger.Info("bot ready", "username", b.bot.Me.Username)
 	}
 
 	go b.bot.Start()
@@ -62,200 +109,385 @@ func (b *Bot) Stop() {
 	b.bot.Stop()
 }
 
+// send replies to a user and counts the outcome.
+func (b *Bot) send(to telebot.Recipient, what interface{}) {
+	_, err := b.bot.Send(to, what)
+	metrics.TelegramSent(err)
+	if err != nil {
+		logger.Warn("telegram send failed", "chat_id", to.Recipient(), "error", err)
+	}
+}
+
 func (b *Bot) handleStart(m *telebot.Message) {
 	msg := "Welcome to Currency Tracker Bot!\n\n" +
 		"Commands:\n" +
-		"/rates - Get current CBR rates\n" +
+		"/rates [QUOTE] - Get current CBR rates (e.g. /rates USD, default RUB)\n" +
 		"/subscribe [CURRENCY] - Subscribe to daily updates (e.g. /subscribe USD)\n" +
 		"/unsubscribe [CURRENCY] - Unsubscribe\n" +
-		"/history [CURRENCY] - Get 7-day history (e.g. /history USD)\n" +
+		"/history [CURRENCY] [QUOTE] - Get 7-day history (e.g. /history USD EUR)\n" +
+		"/convert [AMOUNT] [FROM] [TO] [DATE] - Convert (e.g. /convert 250 EUR CNY)\n" +
 		"/crypto_subscribe [SYMBOL] - Subscribe to crypto (e.g. /crypto_subscribe BTC)\n" +
-		"/crypto_unsubscribe [SYMBOL] - Unsubscribe from crypto"
-	b.bot.Send(m.Sender, msg)
+		"/crypto_unsubscribe [SYMBOL] - Unsubscribe from crypto\n" +
+		"/metals - Get CBR precious metals prices\n" +
+		"/metals_subscribe [METAL] - Subscribe to a metal (e.g. /metals_subscribe XAU or gold)\n" +
+		"/metals_unsubscribe [METAL] - Unsubscribe from a metal\n" +
+		"/keyrate - Get the CBR key rate and RUONIA\n" +
+		"/keyrate_subscribe - Get notified when the key rate changes\n" +
+		"/keyrate_unsubscribe - Stop key rate notifications\n" +
+		"/alert_subscribe [SYMBOL] [INTERVAL] [CONDITION] - Get notified when an indicator condition starts to hold (e.g. /alert_subscribe BTC 1h rsi14>70 or ETH 4h sma20>sma50)\n" +
+		"/alert_unsubscribe [SYMBOL] [INTERVAL] [CONDITION] - Stop an indicator alert"
+	b.send(m.Sender, msg)
 }
 
 func (b *Bot) handleRates(m *telebot.Message) {
-	url := fmt.Sprintf("%s/rates/cbr", b.cfg.APIGatewayURL)
-	resp, err := b.httpClient.Get(url)
+	args := strings.Fields(m.Text)
+	quote := quoteArg(args, 1)
+	ctx, cancel := commandContext()
+	defer cancel()
+	rates, err := b.api.QuotedCBRRates(ctx, time.Time{}, quote)
 	if err != nil {
-		b.bot.Send(m.Sender, "Failed to fetch rates. Please try again later.")
+		logger.WarnContext(ctx, "command failed", "command", "/rates", "currency", quote, "error", err)
+		b.send(m.Sender, "Failed to fetch rates. Please try again later.")
 		return
 	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
-	var rates []struct {
-		CurrencyCode string  `json:"CurrencyCode"`
-		CurrencyName string  `json:"CurrencyName"`
-		Value        float64 `json:"Value"`
-		Previous     float64 `json:"Previous"`
-	}
-	if err := json.Unmarshal(body, &rates); err != nil || len(rates) == 0 {
-		b.bot.Send(m.Sender, "No rate data available right now.")
+	if len(rates) == 0 {
+		b.send(m.Sender, "No rate data available right now.")
 		return
 	}
 
-	msg := "📊 Current CBR Rates:\n\n"
+	msg := fmt.Sprintf("📊 Current CBR Rates (%s):\n\n", quote)
 	for _, r := range rates {
-		change := ((r.Value - r.Previous) / r.Previous) * 100
+		if quote != quoteRUB {
+			if r.QuoteValue.IsZero() || r.CurrencyCode == quote {
+				continue
+			}
+			r.Value, r.Previous = r.QuoteValue, r.QuotePrevious
+		}
+		change := r.Value.Sub(r.Previous).Div(r.Previous, 6).Float64() * 100
 		emoji := "🔄"
 		if change > 0 {
 			emoji = "📈"
 		} else if change < 0 {
 			emoji = "📉"
 		}
-		msg += fmt.Sprintf("%s %s (%s): %.4f RUB (%+.2f%%)\n", emoji, r.CurrencyName, r.CurrencyCode, r.Value, change)
+		msg += fmt.Sprintf("%s %s (%s): %s %s (%+.2f%%)\n", emoji, r.CurrencyName, r.CurrencyCode, r.Value.StringFixed(4), quote, change)
 	}
-	b.bot.Send(m.Sender, msg)
+	b.send(m.Sender, msg)
 }
 
 func (b *Bot) handleSubscribe(m *telebot.Message) {
 	args := strings.Fields(m.Text)
 	if len(args) < 2 {
-		b.bot.Send(m.Sender, "Usage: /subscribe USD")
+		b.send(m.Sender, "Usage: /subscribe USD")
 		return
 	}
 	currency := strings.ToUpper(args[1])
 	if err := b.subscribeCBR(m.Sender.ID, currency); err != nil {
-		b.bot.Send(m.Sender, fmt.Sprintf("Failed to subscribe: %v", err))
+		b.send(m.Sender, fmt.Sprintf("Failed to subscribe: %v", err))
 		return
 	}
-	b.bot.Send(m.Sender, fmt.Sprintf("Subscribed to %s updates!", currency))
+	b.send(m.Sender, fmt.Sprintf("Subscribed to %s updates!", currency))
 }
 
 func (b *Bot) handleUnsubscribe(m *telebot.Message) {
 	args := strings.Fields(m.Text)
 	if len(args) < 2 {
-		b.bot.Send(m.Sender, "Usage: /unsubscribe USD")
+		b.send(m.Sender, "Usage: /unsubscribe USD")
 		return
 	}
 	currency := strings.ToUpper(args[1])
 	if err := b.unsubscribeCBR(m.Sender.ID, currency); err != nil {
-		b.bot.Send(m.Sender, fmt.Sprintf("Failed to unsubscribe: %v", err))
+		b.send(m.Sender, fmt.Sprintf("Failed to unsubscribe: %v", err))
 		return
 	}
-	b.bot.Send(m.Sender, fmt.Sprintf("Unsubscribed from %s.", currency))
+	b.send(m.Sender, fmt.Sprintf("Unsubscribed from %s.", currency))
 }
 
 func (b *Bot) handleCryptoSubscribe(m *telebot.Message) {
 	args := strings.Fields(m.Text)
 	if len(args) < 2 {
-		b.bot.Send(m.Sender, "Usage: /crypto_subscribe BTC")
+		b.send(m.Sender, "Usage: /crypto_subscribe BTC")
 		return
 	}
 	symbol := strings.ToUpper(args[1])
 	if err := b.subscribeCrypto(m.Sender.ID, symbol); err != nil {
-		b.bot.Send(m.Sender, fmt.Sprintf("Failed to subscribe: %v", err))
+		b.send(m.Sender, fmt.Sprintf("Failed to subscribe: %v", err))
 		return
 	}
-	b.bot.Send(m.Sender, fmt.Sprintf("Subscribed to %s crypto updates!", symbol))
+	b.send(m.Sender, fmt.Sprintf("Subscribed to %s crypto updates!", symbol))
 }
 
 func (b *Bot) handleCryptoUnsubscribe(m *telebot.Message) {
 	args := strings.Fields(m.Text)
 	if len(args) < 2 {
-		b.bot.Send(m.Sender, "Usage: /crypto_unsubscribe BTC")
+		b.send(m.Sender, "Usage: /crypto_unsubscribe BTC")
 		return
 	}
 	symbol := strings.ToUpper(args[1])
 	if err := b.unsubscribeCrypto(m.Sender.ID, symbol); err != nil {
-		b.bot.Send(m.Sender, fmt.Sprintf("Failed to unsubscribe: %v", err))
+		b.send(m.Sender, fmt.Sprintf("Failed to unsubscribe: %v", err))
 		return
 	}
-	b.bot.Send(m.Sender, fmt.Sprintf("Unsubscribed from %s.", symbol))
+	b.send(m.Sender, fmt.Sprintf("Unsubscribed from %s.", symbol))
+}
+
+func (b *Bot) handleMetals(m *telebot.Message) {
+	ctx, cancel := commandContext()
+	defer cancel()
+	prices, err := b.api.MetalPrices(ctx, time.Time{})
+	if err != nil {
+		logger.WarnContext(ctx, "command failed", "command", "/metals", "error", err)
+		b.send(m.Sender, "Failed to fetch metal prices. Please try again later.")
+		return
+	}
+	if len(prices) == 0 {
+		b.send(m.Sender, "No metal prices available right now.")
+		return
+	}
+
+	msg := fmt.Sprintf("🪙 CBR precious metals prices (%s), RUB per gram:\n\n", prices[0].Date)
+	for _, p := range prices {
+		msg += fmt.Sprintf("%s (%s): %s\n", p.Name, p.Metal, p.Buy.StringFixed(2))
+	}
+	b.send(m.Sender, msg)
+}
+
+func (b *Bot) handleMetalsSubscribe(m *telebot.Message) {
+	args := strings.Fields(m.Text)
+	metal, ok := metalArg(args)
+	if !ok {
+		b.send(m.Sender, "Usage: /metals_subscribe XAU (XAU, XAG, XPT, XPD or gold, silver, platinum, palladium)")
+		return
+	}
+	if err := b.updateSubscription(b.api.Subscribe, client.MetalSubscriptions, m.Sender.ID, metal); err != nil {
+		b.send(m.Sender, fmt.Sprintf("Failed to subscribe: %v", err))
+		return
+	}
+	b.send(m.Sender, fmt.Sprintf("Subscribed to %s price updates!", metal))
+}
+
+func (b *Bot) handleMetalsUnsubscribe(m *telebot.Message) {
+	args := strings.Fields(m.Text)
+	metal, ok := metalArg(args)
+	if !ok {
+		b.send(m.Sender, "Usage: /metals_unsubscribe XAU")
+		return
+	}
+	if err := b.updateSubscription(b.api.Unsubscribe, client.MetalSubscriptions, m.Sender.ID, metal); err != nil {
+		b.send(m.Sender, fmt.Sprintf("Failed to unsubscribe: %v", err))
+		return
+	}
+	b.send(m.Sender, fmt.Sprintf("Unsubscribed from %s.", metal))
+}
+
+func (b *Bot) handleKeyRate(m *telebot.Message) {
+	ctx, cancel := commandContext()
+	defer cancel()
+	rates, err := b.api.IndicatorRates(ctx, time.Time{})
+	if err != nil {
+		logger.WarnContext(ctx, "command failed", "command", "/keyrate", "error", err)
+		b.send(m.Sender, "Failed to fetch the key rate. Please try again later.")
+		return
+	}
+	if len(rates) == 0 {
+		b.send(m.Sender, "No key rate available right now.")
+		return
+	}
+
+	msg := "🏦 CBR indicators:\n\n"
+	for _, r := range rates {
+		msg += fmt.Sprintf("%s: %s%% since %s\n", r.Name, r.Value.StringFixed(2), r.EffectiveDate)
+	}
+	b.send(m.Sender, msg)
+}
+
+func (b *Bot) handleKeyRateSubscribe(m *telebot.Message) {
+	if err := b.updateSubscription(b.api.Subscribe, client.IndicatorSubscriptions, m.Sender.ID, events.IndicatorKeyRate); err != nil {
+		b.send(m.Sender, fmt.Sprintf("Failed to subscribe: %v", err))
+		return
+	}
+	b.send(m.Sender, "Subscribed to key rate changes!")
+}
+
+func (b *Bot) handleKeyRateUnsubscribe(m *telebot.Message) {
+	if err := b.updateSubscription(b.api.Unsubscribe, client.IndicatorSubscriptions, m.Sender.ID, events.IndicatorKeyRate); err != nil {
+		b.send(m.Sender, fmt.Sprintf("Failed to unsubscribe: %v", err))
+		return
+	}
+	b.send(m.Sender, "Unsubscribed from key rate changes.")
+}
+
+func (b *Bot) handleAlertSubscribe(m *telebot.Message) {
+	alert, err := alertArg(m.Text)
+	if err != nil {
+		b.send(m.Sender, fmt.Sprintf("%v\nUsage: /alert_subscribe BTC 1h rsi14>70", err))
+		return
+	}
+	if err := b.updateSubscription(b.api.Subscribe, client.AlertSubscriptions, m.Sender.ID, alert.String()); err != nil {
+		b.send(m.Sender, fmt.Sprintf("Failed to subscribe: %v", err))
+		return
+	}
+	b.send(m.Sender, fmt.Sprintf("Subscribed to the alert %s!", alert))
+}
+
+func (b *Bot) handleAlertUnsubscribe(m *telebot.Message) {
+	alert, err := alertArg(m.Text)
+	if err != nil {
+		b.send(m.Sender, fmt.Sprintf("%v\nUsage: /alert_unsubscribe BTC 1h rsi14>70", err))
+		return
+	}
+	if err := b.updateSubscription(b.api.Unsubscribe, client.AlertSubscriptions, m.Sender.ID, alert.String()); err != nil {
+		b.send(m.Sender, fmt.Sprintf("Failed to unsubscribe: %v", err))
+		return
+	}
+	b.send(m.Sender, fmt.Sprintf("Unsubscribed from the alert %s.", alert))
+}
+
+// alertArg parses the alert that follows the command in text.
+func alertArg(text string) (indicators.Alert, error) {
+	args := strings.Fields(text)
+	if len(args) < 2 {
+		return indicators.Alert{}, fmt.Errorf("no alert given")
+	}
+	return indicators.ParseAlert(strings.Join(args[1:], " "))
+}
+
+// metalCodes maps the metals the CBR prices, by ISO code or English name,
+// to their ISO codes.
+var metalCodes = map[string]string{
+	"XAU": "XAU", "GOLD": "XAU",
+	"XAG": "XAG", "SILVER": "XAG",
+	"XPT": "XPT", "PLATINUM": "XPT",
+	"XPD": "XPD", "PALLADIUM": "XPD",
+}
+
+// metalArg returns the ISO code of the metal named by args[1].
+func metalArg(args []string) (string, bool) {
+	if len(args) < 2 {
+		return "", false
+	}
+	code, ok := metalCodes[strings.ToUpper(args[1])]
+	return code, ok
 }
 
 func (b *Bot) handleHistory(m *telebot.Message) {
 	args := strings.Fields(m.Text)
 	if len(args) < 2 {
-		b.bot.Send(m.Sender, "Usage: /history USD")
+		b.send(m.Sender, "Usage: /history USD")
 		return
 	}
 	currency := strings.ToUpper(args[1])
-	to := time.Now()
+	quote := quoteArg(args, 2)
+	to := calendar.Today()
 	from := to.AddDate(0, 0, -7)
-	url := fmt.Sprintf("%s/rates/cbr/range?code=%s&from=%s&to=%s",
-		b.cfg.APIGatewayURL, currency,
-		from.Format("2006-01-02"), to.Format("2006-01-02"))
+	ctx, cancel := commandContext()
+	defer cancel()
+	rates, err := b.api.QuotedCBRRange(ctx, currency, from, to, quote)
+	if err != nil {
+		logger.WarnContext(ctx, "command failed", "command", "/history", "currency", currency, "error", err)
+		b.send(m.Sender, "Failed to fetch history.")
+		return
+	}
+	if len(rates) == 0 {
+		b.send(m.Sender, "No history data available.")
+		return
+	}
 
-	resp, err := b.httpClient.Get(url)
-	if err != nil || resp.StatusCode != 200 {
-		b.bot.Send(m.Sender, "Failed to fetch history.")
+	msg := fmt.Sprintf("📈 %s history (7 days):\n\n", currency)
+	var lines int
+	for _, r := range rates {
+		value := r.Value
+		if quote != quoteRUB {
+			// Days without a cross rate into the quote have no value
+			if r.QuoteValue.IsZero() {
+				continue
+			}
+			value = r.QuoteValue
+		}
+		msg += fmt.Sprintf("%s: %s %s\n", r.Date.Format("2006-01-02"), value.StringFixed(4), quote)
+		lines++
+	}
+	if lines == 0 {
+		b.send(m.Sender, fmt.Sprintf("No %s history data available.", quote))
 		return
 	}
-	defer resp.Body.Close()
+	b.send(m.Sender, msg)
+}
 
-	var rates []struct {
-		Date  string  `json:"Date"`
-		Value float64 `json:"Value"`
+func (b *Bot) handleConvert(m *telebot.Message) {
+	args := strings.Fields(m.Text)
+	if len(args) < 4 {
+		b.send(m.Sender, "Usage: /convert 250 EUR CNY [YYYY-MM-DD]")
+		return
 	}
-	body, _ := io.ReadAll(resp.Body)
-	if err := json.Unmarshal(body, &rates); err != nil || len(rates) == 0 {
-		b.bot.Send(m.Sender, "No history data available.")
+	amount, err := money.Parse(strings.Replace(args[1], ",", ".", 1))
+	if err != nil || !amount.IsPositive() {
+		b.send(m.Sender, "Cannot convert: amount must be a positive number")
 		return
 	}
+	req := client.ConvertRequest{From: strings.ToUpper(args[2]), To: strings.ToUpper(args[3]), Amount: amount}
+	if len(args) > 4 {
+		if req.Date, err = time.Parse("2006-01-02", args[4]); err != nil {
+			b.send(m.Sender, "Cannot convert: date must be in YYYY-MM-DD format")
+			return
+		}
+	}
 
-	msg := fmt.Sprintf("📈 %s history (7 days):\n\n", currency)
-	for _, r := range rates {
-		msg += fmt.Sprintf("%s: %.4f RUB\n", r.Date, r.Value)
+	ctx, cancel := commandContext()
+	defer cancel()
+	result, err := b.api.Convert(ctx, req)
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) && !apiErr.Temporary() {
+		b.send(m.Sender, fmt.Sprintf("Cannot convert: %s", apiErr.Message))
+		return
+	}
+	if err != nil {
+		logger.WarnContext(ctx, "command failed", "command", "/convert", "currency", req.From, "error", err)
+		b.send(m.Sender, "Failed to convert. Please try again later.")
+		return
 	}
-	b.bot.Send(m.Sender, msg)
+
+	msg := fmt.Sprintf("💱 %s %s = %s %s\n", result.Amount, result.From, result.Result, result.To)
+	msg += fmt.Sprintf("Rate: 1 %s = %s %s (%s)", result.From, result.Rate, result.To, result.Date)
+	b.send(m.Sender, msg)
+}
+
+const quoteRUB = "RUB"
+
+// quoteArg returns the optional quote currency at args[i], defaulting to RUB.
+func quoteArg(args []string, i int) string {
+	if len(args) > i {
+		return strings.ToUpper(args[i])
+	}
+	return quoteRUB
 }
 
 func (b *Bot) subscribeCBR(telegramID int, currency string) error {
-	return b.postToNotification("/subscriptions/cbr", map[string]any{
-		"telegram_id": int64(telegramID),
-		"value":       currency,
-	})
+	return b.updateSubscription(b.api.Subscribe, client.CBRSubscriptions, telegramID, currency)
 }
 
 func (b *Bot) unsubscribeCBR(telegramID int, currency string) error {
-	return b.deleteFromNotification("/subscriptions/cbr", map[string]any{
-		"telegram_id": int64(telegramID),
-		"value":       currency,
-	})
+	return b.updateSubscription(b.api.Unsubscribe, client.CBRSubscriptions, telegramID, currency)
 }
 
 func (b *Bot) subscribeCrypto(telegramID int, symbol string) error {
-	return b.postToNotification("/subscriptions/crypto", map[string]any{
-		"telegram_id": int64(telegramID),
-		"value":       symbol,
-	})
+	return b.updateSubscription(b.api.Subscribe, client.CryptoSubscriptions, telegramID, symbol)
 }
 
 func (b *Bot) unsubscribeCrypto(telegramID int, symbol string) error {
-	return b.deleteFromNotification("/subscriptions/crypto", map[string]any{
-		"telegram_id": int64(telegramID),
-		"value":       symbol,
-	})
+	return b.updateSubscription(b.api.Unsubscribe, client.CryptoSubscriptions, telegramID, symbol)
 }
 
-func (b *Bot) postToNotification(path string, body any) error {
-	data, _ := json.Marshal(body)
-	url := b.cfg.NotificationSvcURL + path
-	resp, err := b.httpClient.Post(url, "application/json", bytes.NewReader(data))
+// updateSubscription calls Subscribe or Unsubscribe of the API client.
+func (b *Bot) updateSubscription(
+	call func(context.Context, client.SubscriptionKind, int64, string) error,
+	kind client.SubscriptionKind, telegramID int, value string,
+) error {
+	ctx, cancel := commandContext()
+	defer cancel()
+	err := call(ctx, kind, int64(telegramID), value)
 	if err != nil {
-		return err
-	}
-	resp.Body.Close()
-	if resp.StatusCode >= 300 {
-		return fmt.Errorf("notification service returned %d", resp.StatusCode)
-	}
-	return nil
-}
-
-func (b *Bot) deleteFromNotification(path string, body any) error {
-	data, _ := json.Marshal(body)
-	url := b.cfg.NotificationSvcURL + path
-	req, _ := http.NewRequest(http.MethodDelete, url, bytes.NewReader(data))
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := b.httpClient.Do(req)
-	if err != nil {
-		return err
-	}
-	resp.Body.Close()
-	if resp.StatusCode >= 300 {
-		return fmt.Errorf("notification service returned %d", resp.StatusCode)
+		logger.WarnContext(ctx, "subscription update failed", "kind", kind, "value", value, "error", err)
 	}
-	return nil
+	return err
 }
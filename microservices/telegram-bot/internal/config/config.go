@@ -7,6 +7,13 @@ type Config struct {
 	APIGatewayURL      string
 	NotificationSvcURL string
 	RedisAddr          string
+	// HistoryGRPCAddr and NotificationGRPCAddr (host:port) send conversions
+	// and subscription changes over the internal gRPC API; empty uses HTTP.
+	HistoryGRPCAddr      string
+	NotificationGRPCAddr string
+	// HTTPPort serves /healthz, /readyz and the Prometheus metrics at
+	// /metrics. METRICS_PORT is its name from before the health checks.
+	HTTPPort string
 }
 
 func Load() *Config {
@@ -14,6 +21,11 @@ func Load() *Config {
 		TelegramBotToken:   getEnv("TELEGRAM_BOT_TOKEN", ""),
 		APIGatewayURL:      getEnv("API_GATEWAY_URL", "http://localhost:8080"),
 		NotificationSvcURL: getEnv("NOTIFICATION_SERVICE_URL", "http://localhost:8085"),
+
+		HistoryGRPCAddr:      os.Getenv("HISTORY_GRPC_ADDR"),
+		NotificationGRPCAddr: os.Getenv("NOTIFICATION_GRPC_ADDR"),
+
+		HTTPPort: getEnv("HTTP_PORT", getEnv("METRICS_PORT", "9083")),
 	}
 }
 
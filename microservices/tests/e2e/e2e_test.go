@@ -3,17 +3,22 @@
 package e2e
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/apiv1"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/money"
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/pkg/client"
 )
 
 // ─── in-memory subscription store (Redis replacement) ─────────────────────────
@@ -97,6 +102,15 @@ func startHistoryService(t *testing.T) *httptest.Server {
 	}
 	symbols := []string{"BTCUSDT", "ETHUSDT", "BNBUSDT"}
 
+	v1CBRRates := []apiv1.CurrencyRate{
+		{Date: "2024-01-15", Code: "EUR", Name: "Euro", Nominal: 1, Value: money.MustParse("98.2"), Previous: money.MustParse("97.5")},
+		{Date: "2024-01-15", Code: "USD", Name: "US Dollar", Nominal: 1, Value: money.MustParse("90.5"), Previous: money.MustParse("89.0")},
+	}
+	v1CryptoRates := []apiv1.CryptoRate{
+		{Time: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), Symbol: "BTC", Close: money.MustParse("3690000")},
+		{Time: time.Date(2024, 1, 15, 1, 0, 0, 0, time.UTC), Symbol: "BTC", Close: money.MustParse("3780000")},
+	}
+
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/history/cbr", func(w http.ResponseWriter, r *http.Request) {
@@ -122,6 +136,22 @@ func startHistoryService(t *testing.T) *httptest.Server {
 		writeJSON(w, http.StatusOK, symbols)
 	})
 
+	mux.HandleFunc("/v1/rates/cbr", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, apiv1.Response[[]apiv1.CurrencyRate]{Data: v1CBRRates})
+	})
+
+	mux.HandleFunc("/v1/rates/crypto/range", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("symbol") != "BTC" {
+			writeJSON(w, http.StatusNotFound, apiv1.NewError(http.StatusNotFound, "no crypto data in range"))
+			return
+		}
+		writeJSON(w, http.StatusOK, apiv1.Response[[]apiv1.CryptoRate]{Data: v1CryptoRates})
+	})
+
+	mux.HandleFunc("/v1/rates/crypto/symbols", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, apiv1.Response[[]string]{Data: []string{"BNB", "BTC", "ETH"}})
+	})
+
 	mux.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("pong"))
 	})
@@ -220,7 +250,7 @@ func startNotificationService(t *testing.T, store *memStore) *httptest.Server {
 }
 
 // startAPIGateway returns an httptest.Server that acts as a minimal gateway.
-// It routes /history/* and /rates/* to historySrv, /notifications/* to notifSrv.
+// It routes /history/*, /v1/* and /rates/* to historySrv, /notifications/* to notifSrv.
 func startAPIGateway(t *testing.T, historySrv, notifSrv *httptest.Server) *httptest.Server {
 	t.Helper()
 
@@ -262,6 +292,11 @@ func startAPIGateway(t *testing.T, historySrv, notifSrv *httptest.Server) *httpt
 		forward(w, r, historySrv.URL+"/history"+path)
 	})
 
+	// versioned API
+	mux.HandleFunc("/v1/", func(w http.ResponseWriter, r *http.Request) {
+		forward(w, r, historySrv.URL+r.URL.RequestURI())
+	})
+
 	// rates shortcuts
 	mux.HandleFunc("/rates/cbr", func(w http.ResponseWriter, r *http.Request) {
 		forward(w, r, historySrv.URL+"/history/cbr?"+r.URL.RawQuery)
@@ -291,6 +326,12 @@ func writeJSON(w http.ResponseWriter, status int, v any) {
 	json.NewEncoder(w).Encode(v)
 }
 
+// newClient returns an API client for the gateway that does not retry, so
+// failures surface immediately.
+func newClient(gw *httptest.Server) *client.Client {
+	return client.New(gw.URL, client.WithRetries(0, 0))
+}
+
 // ─── E2E tests ────────────────────────────────────────────────────────────────
 
 // TestE2E_HealthChecks verifies that /ping returns 200 "pong" through the gateway.
@@ -315,78 +356,67 @@ func TestE2E_HealthChecks(t *testing.T) {
 	}
 }
 
-// TestE2E_CBRHistory_fullFlow tests the complete path:
-// HTTP client → API Gateway → history-service stub → JSON rates returned.
-func TestE2E_CBRHistory_fullFlow(t *testing.T) {
+// TestE2E_CBRRates_fullFlow tests the complete path:
+// API client → API Gateway → history-service stub → typed rates returned.
+func TestE2E_CBRRates_fullFlow(t *testing.T) {
 	store := newMemStore()
 	historySrv := startHistoryService(t)
 	notifSrv := startNotificationService(t, store)
 	gw := startAPIGateway(t, historySrv, notifSrv)
 
-	resp, err := http.Get(gw.URL + "/history/cbr?date=2024-01-15")
+	rates, err := newClient(gw).CBRRates(context.Background(), time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
 	if err != nil {
 		t.Fatal(err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		t.Fatalf("expected 200, got %d", resp.StatusCode)
-	}
-
-	var rates []map[string]any
-	if err := json.NewDecoder(resp.Body).Decode(&rates); err != nil {
-		t.Fatal(err)
-	}
 	if len(rates) != 2 {
-		t.Errorf("expected 2 rates, got %d", len(rates))
+		t.Fatalf("expected 2 rates, got %d", len(rates))
+	}
+	if rates[1].Code != "USD" || rates[1].Value.String() != "90.5" {
+		t.Errorf("unexpected rate %+v", rates[1])
 	}
 }
 
-// TestE2E_CryptoHistory_fullFlow tests the crypto rates endpoint.
+// TestE2E_CryptoHistory_fullFlow tests the crypto range endpoint.
 func TestE2E_CryptoHistory_fullFlow(t *testing.T) {
 	store := newMemStore()
 	historySrv := startHistoryService(t)
 	notifSrv := startNotificationService(t, store)
 	gw := startAPIGateway(t, historySrv, notifSrv)
 
-	resp, err := http.Get(gw.URL + "/rates/crypto/history?symbol=BTCUSDT")
+	day := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	rates, err := newClient(gw).CryptoRange(context.Background(), "BTC", day, day)
 	if err != nil {
 		t.Fatal(err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		t.Fatalf("expected 200, got %d", resp.StatusCode)
-	}
-
-	var rates []map[string]any
-	if err := json.NewDecoder(resp.Body).Decode(&rates); err != nil {
-		t.Fatal(err)
-	}
 	if len(rates) == 0 {
 		t.Error("expected at least one rate")
 	}
 }
 
-// TestE2E_CryptoSymbols_fullFlow tests the symbols list endpoint.
-func TestE2E_CryptoSymbols_fullFlow(t *testing.T) {
+// TestE2E_CryptoHistory_notFound verifies that an upstream v1 error reaches
+// the client as a typed error.
+func TestE2E_CryptoHistory_notFound(t *testing.T) {
 	store := newMemStore()
 	historySrv := startHistoryService(t)
 	notifSrv := startNotificationService(t, store)
 	gw := startAPIGateway(t, historySrv, notifSrv)
 
-	resp, err := http.Get(gw.URL + "/rates/crypto/symbols")
-	if err != nil {
-		t.Fatal(err)
+	day := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	_, err := newClient(gw).CryptoRange(context.Background(), "DOGE", day, day)
+	if !client.IsNotFound(err) {
+		t.Errorf("expected a not_found error, got %v", err)
 	}
-	defer resp.Body.Close()
+}
 
-	if resp.StatusCode != http.StatusOK {
-		t.Fatalf("expected 200, got %d", resp.StatusCode)
-	}
+// TestE2E_CryptoSymbols_fullFlow tests the symbols list endpoint.
+func TestE2E_CryptoSymbols_fullFlow(t *testing.T) {
+	store := newMemStore()
+	historySrv := startHistoryService(t)
+	notifSrv := startNotificationService(t, store)
+	gw := startAPIGateway(t, historySrv, notifSrv)
 
-	var symbols []string
-	if err := json.NewDecoder(resp.Body).Decode(&symbols); err != nil {
+	symbols, err := newClient(gw).CryptoSymbols(context.Background())
+	if err != nil {
 		t.Fatal(err)
 	}
 	if len(symbols) == 0 {
@@ -403,54 +433,33 @@ func TestE2E_SubscriptionWorkflow_CBR(t *testing.T) {
 	gw := startAPIGateway(t, historySrv, notifSrv)
 
 	const userID = int64(100)
+	c := newClient(gw)
+	ctx := context.Background()
 
 	// 1. Subscribe to USD
-	body, _ := json.Marshal(map[string]any{"telegram_id": userID, "value": "USD"})
-	resp, err := http.Post(gw.URL+"/notifications/subscriptions/cbr",
-		"application/json", bytes.NewReader(body))
-	if err != nil {
-		t.Fatal(err)
-	}
-	resp.Body.Close()
-	if resp.StatusCode != http.StatusNoContent {
-		t.Fatalf("subscribe: expected 204, got %d", resp.StatusCode)
+	if err := c.Subscribe(ctx, client.CBRSubscriptions, userID, "USD"); err != nil {
+		t.Fatalf("subscribe: %v", err)
 	}
 
 	// 2. List — should contain USD
-	resp, err = http.Get(fmt.Sprintf("%s/notifications/subscriptions/cbr?telegram_id=%d", gw.URL, userID))
+	subs, err := c.Subscriptions(ctx, client.CBRSubscriptions, userID)
 	if err != nil {
 		t.Fatal(err)
 	}
-	var subs []string
-	json.NewDecoder(resp.Body).Decode(&subs)
-	resp.Body.Close()
 	if len(subs) != 1 || subs[0] != "USD" {
 		t.Errorf("expected [USD], got %v", subs)
 	}
 
 	// 3. Unsubscribe
-	delBody, _ := json.Marshal(map[string]any{"telegram_id": userID, "value": "USD"})
-	req, _ := http.NewRequest(http.MethodDelete,
-		gw.URL+"/notifications/subscriptions/cbr",
-		bytes.NewReader(delBody))
-	req.Header.Set("Content-Type", "application/json")
-	resp, err = http.DefaultClient.Do(req)
-	if err != nil {
-		t.Fatal(err)
-	}
-	resp.Body.Close()
-	if resp.StatusCode != http.StatusNoContent {
-		t.Fatalf("unsubscribe: expected 204, got %d", resp.StatusCode)
+	if err := c.Unsubscribe(ctx, client.CBRSubscriptions, userID, "USD"); err != nil {
+		t.Fatalf("unsubscribe: %v", err)
 	}
 
 	// 4. List — should be empty
-	resp, err = http.Get(fmt.Sprintf("%s/notifications/subscriptions/cbr?telegram_id=%d", gw.URL, userID))
+	subsAfter, err := c.Subscriptions(ctx, client.CBRSubscriptions, userID)
 	if err != nil {
 		t.Fatal(err)
 	}
-	var subsAfter []string
-	json.NewDecoder(resp.Body).Decode(&subsAfter)
-	resp.Body.Close()
 	if len(subsAfter) != 0 {
 		t.Errorf("expected empty list after unsubscribe, got %v", subsAfter)
 	}
@@ -464,51 +473,26 @@ func TestE2E_SubscriptionWorkflow_Crypto(t *testing.T) {
 	gw := startAPIGateway(t, historySrv, notifSrv)
 
 	const userID = int64(200)
+	c := newClient(gw)
+	ctx := context.Background()
 
 	// 1. Subscribe to BTCUSDT
-	body, _ := json.Marshal(map[string]any{"telegram_id": userID, "value": "BTCUSDT"})
-	resp, err := http.Post(gw.URL+"/notifications/subscriptions/crypto",
-		"application/json", bytes.NewReader(body))
-	if err != nil {
-		t.Fatal(err)
-	}
-	resp.Body.Close()
-	if resp.StatusCode != http.StatusNoContent {
-		t.Fatalf("subscribe crypto: expected 204, got %d", resp.StatusCode)
+	if err := c.Subscribe(ctx, client.CryptoSubscriptions, userID, "BTCUSDT"); err != nil {
+		t.Fatalf("subscribe crypto: %v", err)
 	}
 
 	// 2. List — should contain BTCUSDT
-	resp, err = http.Get(fmt.Sprintf("%s/notifications/subscriptions/crypto?telegram_id=%d", gw.URL, userID))
+	subs, err := c.Subscriptions(ctx, client.CryptoSubscriptions, userID)
 	if err != nil {
 		t.Fatal(err)
 	}
-	var subs []string
-	json.NewDecoder(resp.Body).Decode(&subs)
-	resp.Body.Close()
-
-	found := false
-	for _, s := range subs {
-		if s == "BTCUSDT" {
-			found = true
-		}
-	}
-	if !found {
+	if !slices.Contains(subs, "BTCUSDT") {
 		t.Errorf("expected BTCUSDT in %v", subs)
 	}
 
 	// 3. Unsubscribe
-	delBody, _ := json.Marshal(map[string]any{"telegram_id": userID, "value": "BTCUSDT"})
-	req, _ := http.NewRequest(http.MethodDelete,
-		gw.URL+"/notifications/subscriptions/crypto",
-		bytes.NewReader(delBody))
-	req.Header.Set("Content-Type", "application/json")
-	resp, err = http.DefaultClient.Do(req)
-	if err != nil {
-		t.Fatal(err)
-	}
-	resp.Body.Close()
-	if resp.StatusCode != http.StatusNoContent {
-		t.Fatalf("unsubscribe crypto: expected 204, got %d", resp.StatusCode)
+	if err := c.Unsubscribe(ctx, client.CryptoSubscriptions, userID, "BTCUSDT"); err != nil {
+		t.Fatalf("unsubscribe crypto: %v", err)
 	}
 }
 
@@ -1,9 +1,8 @@
 package load
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
-	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -12,6 +11,8 @@ import (
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/casualdoto/go-currency-tracker/microservices/shared/pkg/client"
 )
 
 // newBenchClient returns an HTTP client with a fresh transport per benchmark,
@@ -474,41 +475,34 @@ func newNotifServer(store *inMemNotifStore) *httptest.Server {
 	return httptest.NewServer(mux)
 }
 
+// newNotifClient returns an API client talking to the notification service
+// benchmark server directly.
+func newNotifClient(srv *httptest.Server) *client.Client {
+	return client.New(srv.URL,
+		client.WithHTTPClient(newBenchClient()),
+		client.WithNotificationsURL(srv.URL),
+		client.WithRetries(0, 0))
+}
+
 // BenchmarkNotification_SubscribeUnsubscribe measures the full subscribe→unsubscribe cycle.
 func BenchmarkNotification_SubscribeUnsubscribe(b *testing.B) {
 	store := newInMemNotifStore()
 	srv := newNotifServer(store)
 	defer srv.Close()
 
-	client := newBenchClient()
-	url := srv.URL + "/subscriptions/cbr"
+	c := newNotifClient(srv)
+	ctx := context.Background()
 	b.ReportAllocs()
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
 		userID := int64(i % 1000)
-
-		// Subscribe
-		subBody, _ := json.Marshal(map[string]any{
-			"telegram_id": userID, "value": "USD",
-		})
-		req, _ := http.NewRequest(http.MethodPost, url, bytes.NewReader(subBody))
-		resp, err := client.Do(req)
-		if err != nil {
+		if err := c.Subscribe(ctx, client.CBRSubscriptions, userID, "USD"); err != nil {
 			b.Fatal(err)
 		}
-		resp.Body.Close()
-
-		// Unsubscribe
-		delBody, _ := json.Marshal(map[string]any{
-			"telegram_id": userID, "value": "USD",
-		})
-		req, _ = http.NewRequest(http.MethodDelete, url, bytes.NewReader(delBody))
-		resp, err = client.Do(req)
-		if err != nil {
+		if err := c.Unsubscribe(ctx, client.CBRSubscriptions, userID, "USD"); err != nil {
 			b.Fatal(err)
 		}
-		resp.Body.Close()
 	}
 }
 
@@ -523,17 +517,14 @@ func BenchmarkNotification_ListSubscriptions(b *testing.B) {
 	srv := newNotifServer(store)
 	defer srv.Close()
 
-	url := fmt.Sprintf("%s/subscriptions/cbr?telegram_id=42", srv.URL)
-	client := newBenchClient()
+	c := newNotifClient(srv)
+	ctx := context.Background()
 	b.ReportAllocs()
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		resp, err := client.Get(url)
-		if err != nil {
+		if _, err := c.Subscriptions(ctx, client.CBRSubscriptions, 42); err != nil {
 			b.Fatal(err)
 		}
-		io.Copy(io.Discard, resp.Body)
-		resp.Body.Close()
 	}
 }
@@ -1,7 +1,7 @@
 package main
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 )
@@ -16,12 +16,22 @@ func getEnv(key, def string) string {
 func main() {
 	port := getEnv("SERVER_PORT", "3000")
 
+	// Same JSON lines as the Go services; the UI has no shared dependencies
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, nil)).With("service", "web-ui"))
+
 	fs := http.FileServer(http.Dir("./static"))
 	http.Handle("/", fs)
+	// Liveness for the compose health check; the UI has no dependencies, so
+	// there is no /readyz
+	http.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"ok"}` + "\n"))
+	})
 
 	addr := ":" + port
-	log.Printf("Web UI Service listening on %s", addr)
+	slog.Info("http listening", "addr", addr)
 	if err := http.ListenAndServe(addr, nil); err != nil {
-		log.Fatalf("server error: %v", err)
+		slog.Error("http server failed", "error", err)
+		os.Exit(1)
 	}
 }
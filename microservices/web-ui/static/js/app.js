@@ -62,6 +62,17 @@
             return Number.isFinite(n) && n >= 1 ? n : 7;
         }
 
+        // Fetches a /v1 endpoint and returns its data; failures are thrown
+        // with the message from the common error format.
+        async function fetchV1(path) {
+            const response = await fetch(`${API_BASE}/v1${path}`);
+            const body = await response.json();
+            if (!response.ok || body.error) {
+                throw new Error((body.error && body.error.message) || `HTTP ${response.status}`);
+            }
+            return body.data;
+        }
+
         function cbrDateISOFromAPI(d) {
             if (d == null) return '';
             if (typeof d === 'string') {
@@ -120,6 +131,8 @@
             downloadExcelBtn.disabled = true;
             if (currentDataSource === 'cbr') {
                 loadCurrencies();
+            } else if (currentDataSource === 'metals') {
+                loadMetals();
             } else {
                 loadCryptoSymbols();
             }
@@ -204,11 +217,13 @@
                     showNotification('Custom period cannot exceed 365 days.');
                     return;
                 }
-                currentCurrencyCode = currentDataSource === 'cbr' ? currencyVal : (currencySelect.selectedOptions[0]?.dataset.base || stripUsdt(currencyVal));
+                currentCurrencyCode = currentDataSource === 'crypto' ? (currencySelect.selectedOptions[0]?.dataset.base || stripUsdt(currencyVal)) : currencyVal;
                 currentStartDate = formatDate(startDate);
                 currentEndDate = formatDate(endDate);
                 if (currentDataSource === 'cbr') {
                     loadCurrencyHistoryCustom(currencyVal, startDate, endDate);
+                } else if (currentDataSource === 'metals') {
+                    loadMetalHistory(currencyVal, startDate, endDate);
                 } else {
                     loadCryptoHistoryCustom(currencyVal, startDate, endDate);
                 }
@@ -222,10 +237,12 @@
             startDay.setDate(startDay.getDate() - n);
             currentStartDate = formatDate(startDay);
             currentEndDate = formatDate(endDay);
-            currentCurrencyCode = currentDataSource === 'cbr' ? currencyVal : (currencySelect.selectedOptions[0]?.dataset.base || stripUsdt(currencyVal));
+            currentCurrencyCode = currentDataSource === 'crypto' ? (currencySelect.selectedOptions[0]?.dataset.base || stripUsdt(currencyVal)) : currencyVal;
 
             if (currentDataSource === 'cbr') {
                 loadCurrencyHistory(currencyVal, period);
+            } else if (currentDataSource === 'metals') {
+                loadMetalHistory(currencyVal, startDay, endDay);
             } else {
                 loadCryptoHistory(currencyVal, period);
             }
@@ -237,9 +254,12 @@
                 for (let i = 0; i <= 7; i++) {
                     const d = new Date(Date.now() - i * 86400000);
                     const ds = formatDate(d);
-                    const response = await fetch(`${API_BASE}/rates/cbr?date=${ds}`);
-                    const data = await response.json();
-                    if (data && data.error) continue;
+                    let data;
+                    try {
+                        data = await fetchV1(`/rates/cbr?date=${ds}`);
+                    } catch (e) {
+                        continue;
+                    }
                     if (Array.isArray(data) && data.length > 0) {
                         rates = data;
                         break;
@@ -258,16 +278,16 @@
                     return;
                 }
 
-                rates.sort((a, b) => (a.CurrencyName || '').localeCompare(b.CurrencyName || ''));
+                rates.sort((a, b) => (a.name || '').localeCompare(b.name || ''));
 
                 rates.forEach((r) => {
-                    const code = r.CurrencyCode;
+                    const code = r.code;
                     const option = document.createElement('option');
                     option.value = code;
-                    window.currencyData[code] = { name: r.CurrencyName, nominal: r.Nominal };
-                    const label = `${r.Nominal} ${getCurrencyNameForm(r.CurrencyName, r.Nominal)} (${code})`;
+                    window.currencyData[code] = { name: r.name, nominal: r.nominal };
+                    const label = `${r.nominal} ${getCurrencyNameForm(r.name, r.nominal)} (${code})`;
                     option.textContent = label;
-                    option.dataset.nominal = String(r.Nominal);
+                    option.dataset.nominal = String(r.nominal);
                     currencySelect.appendChild(option);
                 });
 
@@ -317,10 +337,10 @@
 
         async function loadCryptoSymbols() {
             try {
-                const res = await fetch(`${API_BASE}/rates/crypto/symbols`);
-                const fromApi = await res.json();
+                // v1 lists base assets (BTC); options keep the Binance pair as value
+                const fromApi = await fetchV1('/rates/crypto/symbols');
                 const apiSet = new Set(
-                    Array.isArray(fromApi) ? fromApi.map((s) => String(s).toUpperCase()) : []
+                    Array.isArray(fromApi) ? fromApi.map((s) => toBinanceSymbol(s)) : []
                 );
 
                 currencySelect.innerHTML = '';
@@ -330,7 +350,7 @@
 
                 if (list.length === 0 && Array.isArray(fromApi) && fromApi.length > 0) {
                     fromApi.forEach((sym) => {
-                        const s = String(sym).toUpperCase();
+                        const s = toBinanceSymbol(sym);
                         const base = stripUsdt(s);
                         const option = document.createElement('option');
                         option.value = s;
@@ -373,6 +393,30 @@
             }
         }
 
+        // The CBR sets prices for these four metals, in rubles per gram.
+        const cbrMetals = [
+            { code: 'XAU', name: 'Gold' },
+            { code: 'XAG', name: 'Silver' },
+            { code: 'XPT', name: 'Platinum' },
+            { code: 'XPD', name: 'Palladium' },
+        ];
+
+        async function loadMetals() {
+            currencySelect.innerHTML = '';
+            cbrMetals.forEach((metal) => {
+                const option = document.createElement('option');
+                option.value = metal.code;
+                option.textContent = `${metal.name} (${metal.code})`;
+                currencySelect.appendChild(option);
+            });
+            currencySelect.value = 'XAU';
+            updateDownloadButtonState();
+            const endDay = startOfLocalDay(new Date());
+            const startDay = new Date(endDay);
+            startDay.setDate(startDay.getDate() - parsePeriodDays(periodSelect.value));
+            await loadMetalHistory(currencySelect.value, startDay, endDay);
+        }
+
         function toBinanceSymbol(base) {
             const u = String(base).toUpperCase();
             if (u.endsWith('USDT')) return u;
@@ -511,10 +555,9 @@
                 currentStartDate = startDateStr;
                 currentEndDate = endDateStr;
 
-                const response = await fetch(
-                    `${API_BASE}/rates/cbr/range?code=${encodeURIComponent(currencyCode)}&from=${startDateStr}&to=${endDateStr}`
+                const history = await fetchV1(
+                    `/rates/cbr/range?code=${encodeURIComponent(currencyCode)}&from=${startDateStr}&to=${endDateStr}`
                 );
-                const history = await response.json();
 
                 if (isStaleHistoryRequest(reqId)) return;
 
@@ -531,22 +574,22 @@
                 document.getElementById('loading-status').textContent = 'Processing data...';
 
                 history.sort((a, b) =>
-                    cbrDateISOFromAPI(a.Date).localeCompare(cbrDateISOFromAPI(b.Date))
+                    cbrDateISOFromAPI(a.date).localeCompare(cbrDateISOFromAPI(b.date))
                 );
-                const nominalChanges = checkNominalChanges(history);
-                const dates = history.map((item) => cbrDateISOFromAPI(item.Date));
-                const values = history.map((item) => item.Value / (item.Nominal || 1));
+                const nominalChanges = flaggedNominalChanges(history);
+                const dates = history.map((item) => cbrDateISOFromAPI(item.date));
+                const values = history.map((item) => Number(item.unit_value));
 
                 const mostRecentItem = history[history.length - 1];
                 const currencyInfo = {
                     code: currencyCode,
-                    nominal: mostRecentItem.Nominal,
-                    name: mostRecentItem.CurrencyName,
+                    nominal: mostRecentItem.nominal,
+                    name: mostRecentItem.name,
                     nominalChanged: nominalChanges.changed,
                     nominalChangeDates: nominalChanges.dates,
                 };
 
-                calculateMetrics(values, currencyInfo.nominal);
+                loadMetrics('cbr', currencyCode, startDateStr, endDateStr, currencyInfo.nominal);
 
                 document.getElementById('loading-progress').style.width = '100%';
                 document.getElementById('loading-status').textContent = 'Completed!';
@@ -598,10 +641,9 @@
                 document.getElementById('loading-status').textContent =
                     'Retrieving currency data from database...';
 
-                const response = await fetch(
-                    `${API_BASE}/rates/cbr/range?code=${encodeURIComponent(currencyCode)}&from=${startDateStr}&to=${endDateStr}`
+                const history = await fetchV1(
+                    `/rates/cbr/range?code=${encodeURIComponent(currencyCode)}&from=${startDateStr}&to=${endDateStr}`
                 );
-                const history = await response.json();
 
                 if (isStaleHistoryRequest(reqId)) return;
 
@@ -618,22 +660,22 @@
                 document.getElementById('loading-status').textContent = 'Processing data...';
 
                 history.sort((a, b) =>
-                    cbrDateISOFromAPI(a.Date).localeCompare(cbrDateISOFromAPI(b.Date))
+                    cbrDateISOFromAPI(a.date).localeCompare(cbrDateISOFromAPI(b.date))
                 );
-                const nominalChanges = checkNominalChanges(history);
-                const dates = history.map((item) => cbrDateISOFromAPI(item.Date));
-                const values = history.map((item) => item.Value / (item.Nominal || 1));
+                const nominalChanges = flaggedNominalChanges(history);
+                const dates = history.map((item) => cbrDateISOFromAPI(item.date));
+                const values = history.map((item) => Number(item.unit_value));
 
                 const mostRecentItem = history[history.length - 1];
                 const currencyInfo = {
                     code: currencyCode,
-                    nominal: mostRecentItem.Nominal,
-                    name: mostRecentItem.CurrencyName,
+                    nominal: mostRecentItem.nominal,
+                    name: mostRecentItem.name,
                     nominalChanged: nominalChanges.changed,
                     nominalChangeDates: nominalChanges.dates,
                 };
 
-                calculateMetrics(values, currencyInfo.nominal);
+                loadMetrics('cbr', currencyCode, startDateStr, endDateStr, currencyInfo.nominal);
 
                 document.getElementById('loading-progress').style.width = '100%';
                 document.getElementById('loading-status').textContent = 'Completed!';
@@ -663,12 +705,6 @@
             }
         }
 
-        function pickCryptoPrice(item) {
-            if (item.PriceRUB != null && item.PriceRUB !== 0) return item.PriceRUB;
-            if (item.price_rub != null && item.price_rub !== 0) return item.price_rub;
-            return item.Close;
-        }
-
         async function loadCryptoHistory(apiSymbol, days) {
             const reqId = beginHistoryLoad();
             try {
@@ -694,10 +730,9 @@
                 currentStartDate = startDateStr;
                 currentEndDate = endDateStr;
 
-                const response = await fetch(
-                    `${API_BASE}/rates/crypto/history/range?symbol=${encodeURIComponent(apiSymbol)}&from=${startDateStr}&to=${endDateStr}`
+                const history = await fetchV1(
+                    `/rates/crypto/range?symbol=${encodeURIComponent(stripUsdt(apiSymbol))}&from=${startDateStr}&to=${endDateStr}`
                 );
-                const history = await response.json();
 
                 if (isStaleHistoryRequest(reqId)) return;
 
@@ -713,13 +748,9 @@
                 document.getElementById('loading-progress').style.width = '50%';
                 document.getElementById('loading-status').textContent = 'Processing data...';
 
-                history.sort((a, b) => new Date(a.Timestamp) - new Date(b.Timestamp));
-                const dates = history.map((item) => {
-                    const t = item.Timestamp;
-                    if (typeof t === 'string') return t.slice(0, 10);
-                    return formatDate(new Date(t));
-                });
-                const values = history.map((item) => pickCryptoPrice(item));
+                history.sort((a, b) => new Date(a.time) - new Date(b.time));
+                const dates = history.map((item) => String(item.time).slice(0, 10));
+                const values = history.map((item) => item.close);
 
                 const base = stripUsdt(apiSymbol);
                 const cryptoInfo = {
@@ -729,7 +760,7 @@
                     data: history,
                 };
 
-                calculateCryptoMetricsFromValues(values);
+                loadMetrics('crypto', base, startDateStr, endDateStr);
 
                 document.getElementById('loading-progress').style.width = '100%';
                 document.getElementById('loading-status').textContent = 'Completed!';
@@ -774,10 +805,9 @@
                 document.getElementById('loading-progress').style.width = '10%';
                 document.getElementById('loading-status').textContent = 'Retrieving crypto data...';
 
-                const response = await fetch(
-                    `${API_BASE}/rates/crypto/history/range?symbol=${encodeURIComponent(apiSymbol)}&from=${startDateStr}&to=${endDateStr}`
+                const history = await fetchV1(
+                    `/rates/crypto/range?symbol=${encodeURIComponent(stripUsdt(apiSymbol))}&from=${startDateStr}&to=${endDateStr}`
                 );
-                const history = await response.json();
 
                 if (isStaleHistoryRequest(reqId)) return;
 
@@ -793,13 +823,9 @@
                 document.getElementById('loading-progress').style.width = '50%';
                 document.getElementById('loading-status').textContent = 'Processing data...';
 
-                history.sort((a, b) => new Date(a.Timestamp) - new Date(b.Timestamp));
-                const dates = history.map((item) => {
-                    const t = item.Timestamp;
-                    if (typeof t === 'string') return t.slice(0, 10);
-                    return formatDate(new Date(t));
-                });
-                const values = history.map((item) => pickCryptoPrice(item));
+                history.sort((a, b) => new Date(a.time) - new Date(b.time));
+                const dates = history.map((item) => String(item.time).slice(0, 10));
+                const values = history.map((item) => item.close);
 
                 const base = stripUsdt(apiSymbol);
                 const cryptoInfo = {
@@ -809,7 +835,7 @@
                     data: history,
                 };
 
-                calculateCryptoMetricsFromValues(values);
+                loadMetrics('crypto', base, startDateStr, endDateStr);
 
                 document.getElementById('loading-progress').style.width = '100%';
                 document.getElementById('loading-status').textContent = 'Completed!';
@@ -833,25 +859,91 @@
             }
         }
 
-        function checkNominalChanges(history) {
-            if (!history || history.length <= 1) {
-                return { changed: false, dates: [] };
-            }
-            let prevNominal = history[0].Nominal;
-            const changes = [];
-            for (let i = 1; i < history.length; i++) {
-                const currentNominal = history[i].Nominal;
-                if (currentNominal !== prevNominal) {
-                    const d = history[i].Date;
-                    const dateStr = typeof d === 'string' ? d.slice(0, 10) : formatDate(new Date(d));
-                    changes.push({
-                        date: dateStr,
-                        oldNominal: prevNominal,
-                        newNominal: currentNominal,
-                    });
-                    prevNominal = currentNominal;
+        async function loadMetalHistory(metal, startDate, endDate) {
+            const reqId = beginHistoryLoad();
+            try {
+                downloadExcelBtn.disabled = true;
+                resetMetrics();
+                if (currencyChart) {
+                    currencyChart.destroy();
+                    currencyChart = null;
+                }
+
+                const startDateStr = formatDate(startDate);
+                const endDateStr = formatDate(endDate);
+                currentCurrencyCode = metal;
+                currentStartDate = startDateStr;
+                currentEndDate = endDateStr;
+
+                loadingIndicator.classList.remove('d-none');
+                document.getElementById('currency-chart').classList.add('d-none');
+                document.getElementById('loading-progress').style.width = '10%';
+                document.getElementById('loading-status').textContent = 'Retrieving metal prices...';
+
+                const history = await fetchV1(
+                    `/rates/metals/range?metal=${encodeURIComponent(metal)}&from=${startDateStr}&to=${endDateStr}`
+                );
+
+                if (isStaleHistoryRequest(reqId)) return;
+
+                if (!Array.isArray(history) || history.length === 0) {
+                    loadingIndicator.classList.add('d-none');
+                    document.getElementById('currency-chart').classList.remove('d-none');
+                    downloadExcelBtn.disabled = true;
+                    showNotification(`No prices available for ${metal} for the selected period.`, 'warning');
+                    resetMetrics();
+                    return;
                 }
+
+                document.getElementById('loading-progress').style.width = '50%';
+                document.getElementById('loading-status').textContent = 'Processing data...';
+
+                history.sort((a, b) => new Date(a.date) - new Date(b.date));
+                const dates = history.map((item) => cbrDateISOFromAPI(item.date));
+                const values = history.map((item) => Number(item.buy));
+
+                const metalInfo = {
+                    code: metal,
+                    name: history[0].name || metal,
+                    type: 'metal',
+                    data: history,
+                };
+
+                // The analytics endpoint covers currencies and crypto only.
+                showSummaryMetrics(values);
+
+                document.getElementById('loading-progress').style.width = '100%';
+                document.getElementById('loading-status').textContent = 'Completed!';
+
+                setTimeout(() => {
+                    if (isStaleHistoryRequest(reqId)) return;
+                    loadingIndicator.classList.add('d-none');
+                    document.getElementById('currency-chart').classList.remove('d-none');
+                    renderChart(dates, values, metalInfo);
+                    window.__lastExport = { kind: 'metals', rows: history };
+                    downloadExcelBtn.disabled = false;
+                }, 500);
+            } catch (e) {
+                if (isStaleHistoryRequest(reqId)) return;
+                loadingIndicator.classList.add('d-none');
+                document.getElementById('currency-chart').classList.remove('d-none');
+                downloadExcelBtn.disabled = true;
+                console.error('Error loading metal prices:', e);
+                showNotification('Historical data service is temporarily unavailable. Please try again later.');
+                resetMetrics();
             }
+        }
+
+        // The API marks the first date quoted for a new nominal with
+        // previous_nominal; unit_value is already per unit across it.
+        function flaggedNominalChanges(history) {
+            const changes = history
+                .filter((item) => item.previous_nominal)
+                .map((item) => ({
+                    date: cbrDateISOFromAPI(item.date),
+                    oldNominal: item.previous_nominal,
+                    newNominal: item.nominal,
+                }));
             return { changed: changes.length > 0, dates: changes };
         }
 
@@ -862,50 +954,46 @@
             return `${year}-${month}-${day}`;
         }
 
-        function calculateMetrics(values, nominal) {
-            if (values.length === 0) {
+        // Metrics come from the history analytics endpoint; nominal scales
+        // per-unit CBR values back to the quoted amount.
+        async function loadMetrics(source, code, startDateStr, endDateStr, nominal = 1) {
+            try {
+                const data = await fetchV1(
+                    `/analytics?source=${source}&code=${encodeURIComponent(code)}&from=${startDateStr}&to=${endDateStr}`
+                );
+                if (!data.summary || data.summary.count === 0) {
+                    resetMetrics();
+                    return;
+                }
+                const s = data.summary;
+                const digits = source === 'crypto' ? 2 : 4;
+                metricAvg.textContent = (s.mean * nominal).toFixed(digits) + ' ₽';
+                metricStd.textContent = (s.stddev * nominal).toFixed(digits) + ' ₽';
+                metricMin.textContent = (s.min * nominal).toFixed(digits) + ' ₽';
+                metricMax.textContent = (s.max * nominal).toFixed(digits) + ' ₽';
+                metricVolatility.textContent = s.coefficient_of_variation_pct.toFixed(2) + '%';
+            } catch (e) {
+                console.error('Error loading metrics:', e);
                 resetMetrics();
-                return;
             }
-            const avg = values.reduce((sum, val) => sum + val, 0) / values.length;
-            const min = Math.min(...values);
-            const max = Math.max(...values);
-            const squaredDiffs = values.map((val) => Math.pow(val - avg, 2));
-            const variance = squaredDiffs.reduce((sum, val) => sum + val, 0) / values.length;
-            const std = Math.sqrt(variance);
-            const volatility = (std / avg) * 100;
-
-            const displayAvg = avg * nominal;
-            const displayStd = std * nominal;
-            const displayMin = min * nominal;
-            const displayMax = max * nominal;
-
-            metricAvg.textContent = displayAvg.toFixed(4) + ' ₽';
-            metricStd.textContent = displayStd.toFixed(4) + ' ₽';
-            metricMin.textContent = displayMin.toFixed(4) + ' ₽';
-            metricMax.textContent = displayMax.toFixed(4) + ' ₽';
-            metricVolatility.textContent = volatility.toFixed(2) + '%';
         }
 
-        function calculateCryptoMetricsFromValues(closingPrices) {
-            if (closingPrices.length === 0) {
+        // showSummaryMetrics fills the metrics panel from values already on
+        // the chart, with the population standard deviation the analytics
+        // endpoint uses.
+        function showSummaryMetrics(values) {
+            if (!values.length) {
                 resetMetrics();
                 return;
             }
-            const avg = closingPrices.reduce((sum, val) => sum + val, 0) / closingPrices.length;
-            const min = Math.min(...closingPrices);
-            const max = Math.max(...closingPrices);
-            const squaredDiffs = closingPrices.map((val) => Math.pow(val - avg, 2));
-            const variance = squaredDiffs.reduce((sum, val) => sum + val, 0) / closingPrices.length;
-            const std = Math.sqrt(variance);
-            const volatility = (std / avg) * 100;
-
-            const formatPrice = (price) => price.toFixed(2);
-            metricAvg.textContent = `${formatPrice(avg)} ₽`;
-            metricStd.textContent = `${formatPrice(std)} ₽`;
-            metricMin.textContent = `${formatPrice(min)} ₽`;
-            metricMax.textContent = `${formatPrice(max)} ₽`;
-            metricVolatility.textContent = `${volatility.toFixed(2)}%`;
+            const mean = values.reduce((sum, v) => sum + v, 0) / values.length;
+            const variance = values.reduce((sum, v) => sum + (v - mean) * (v - mean), 0) / values.length;
+            const stddev = Math.sqrt(variance);
+            metricAvg.textContent = mean.toFixed(2) + ' ₽';
+            metricStd.textContent = stddev.toFixed(2) + ' ₽';
+            metricMin.textContent = Math.min(...values).toFixed(2) + ' ₽';
+            metricMax.textContent = Math.max(...values).toFixed(2) + ' ₽';
+            metricVolatility.textContent = (mean ? (stddev / mean) * 100 : 0).toFixed(2) + '%';
         }
 
         function resetMetrics() {
@@ -922,6 +1010,10 @@
                 currencyLabel.textContent = 'Select Currency:';
                 currencySelect.innerHTML =
                     '<option value="" selected disabled>Loading currencies...</option>';
+            } else if (currentDataSource === 'metals') {
+                currencyLabel.textContent = 'Select Metal:';
+                currencySelect.innerHTML =
+                    '<option value="" selected disabled>Loading metals...</option>';
             } else {
                 currencyLabel.textContent = 'Select Cryptocurrency:';
                 currencySelect.innerHTML =
@@ -935,6 +1027,7 @@
             }
 
             const isCrypto = currencyInfo.type === 'crypto';
+            const isMetal = currencyInfo.type === 'metal';
             let chartLabel;
             let displayValues;
             let yAxisLabel;
@@ -944,7 +1037,14 @@
             let chartValues;
             let originalDates;
 
-            if (isCrypto) {
+            if (isMetal) {
+                chartLabel = `${currencyInfo.name} (${currencyInfo.code}), RUB per gram`;
+                displayValues = values;
+                yAxisLabel = 'Price per gram (RUB)';
+                tooltipCallback = function (context) {
+                    return `Price: ${context.raw.toFixed(2)} ₽ per gram`;
+                };
+            } else if (isCrypto) {
                 chartLabel = `${currencyInfo.code} Price (RUB)`;
                 displayValues = values;
                 yAxisLabel = 'Price (RUB)';
@@ -1080,38 +1180,23 @@
             let rows;
             let filename;
             if (exp.kind === 'cbr') {
-                rows = [['Date', 'Code', 'Name', 'Nominal', 'Value (RUB)', 'Previous']];
+                rows = [['Date', 'Code', 'Name', 'Nominal', 'Value (RUB)', 'Previous', 'Unit value (RUB)']];
                 exp.rows.forEach((r) => {
-                    const d = r.Date;
-                    const ds = typeof d === 'string' ? d.slice(0, 10) : formatDate(new Date(d));
-                    rows.push([
-                        ds,
-                        r.CurrencyCode,
-                        r.CurrencyName,
-                        r.Nominal,
-                        r.Value,
-                        r.Previous,
-                    ]);
+                    rows.push([r.date, r.code, r.name, r.nominal, r.value, r.previous, r.unit_value]);
                 });
                 filename = `cbr_${currentCurrencyCode || 'export'}_${currentStartDate}_${currentEndDate}.csv`;
+            } else if (exp.kind === 'metals') {
+                rows = [['Date', 'Metal', 'Name', 'Buy (RUB per gram)', 'Sell (RUB per gram)']];
+                exp.rows.forEach((r) => {
+                    rows.push([r.date, r.metal, r.name, r.buy, r.sell]);
+                });
+                filename = `metals_${currentCurrencyCode || 'export'}_${currentStartDate}_${currentEndDate}.csv`;
             } else {
-                rows = [['Timestamp', 'Symbol', 'Open', 'High', 'Low', 'Close', 'Volume', 'Price (RUB)']];
+                rows = [['Time', 'Symbol', 'Open (RUB)', 'High (RUB)', 'Low (RUB)', 'Close (RUB)', 'Volume']];
                 exp.rows.forEach((r) => {
-                    const ts = r.Timestamp;
-                    const tsIso =
-                        typeof ts === 'string' ? ts : ts instanceof Date ? ts.toISOString() : String(ts);
-                    rows.push([
-                        tsIso,
-                        r.Symbol,
-                        r.Open,
-                        r.High,
-                        r.Low,
-                        r.Close,
-                        r.Volume,
-                        pickCryptoPrice(r),
-                    ]);
+                    rows.push([r.time, r.symbol, r.open, r.high, r.low, r.close, r.volume]);
                 });
-                filename = `crypto_${stripUsdt(exp.rows[0].Symbol || 'export')}_${currentStartDate}_${currentEndDate}.csv`;
+                filename = `crypto_${exp.rows[0].symbol || 'export'}_${currentStartDate}_${currentEndDate}.csv`;
             }
 
             const csv = rows
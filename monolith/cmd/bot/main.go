@@ -1,7 +1,7 @@
 package main
 
 import (
-	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"strconv"
@@ -9,21 +9,26 @@ import (
 
 	"github.com/casualdoto/go-currency-tracker/internal/alert"
 	"github.com/casualdoto/go-currency-tracker/internal/currency/binance"
+	"github.com/casualdoto/go-currency-tracker/internal/logging"
+	"github.com/casualdoto/go-currency-tracker/internal/metrics"
 	"github.com/casualdoto/go-currency-tracker/internal/scheduler"
 	"github.com/casualdoto/go-currency-tracker/internal/storage"
 	"github.com/joho/godotenv"
 )
 
 func main() {
+	logging.Setup("bot")
+
 	// Load environment variables from .env file
 	if err := godotenv.Load(); err != nil {
-		log.Println("Warning: .env file not found. Using environment variables.")
+		slog.Info(".env file not found, using environment variables")
 	}
 
 	// Get Telegram bot token from environment variables
 	token := os.Getenv("TELEGRAM_BOT_TOKEN")
 	if token == "" {
-		log.Fatal("TELEGRAM_BOT_TOKEN is not set in environment variables")
+		slog.Error("TELEGRAM_BOT_TOKEN is not set in environment variables")
+		os.Exit(1)
 	}
 
 	// Setup database connection
@@ -38,43 +43,55 @@ func main() {
 
 	db, err := storage.NewPostgresDB(dbConfig)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		slog.Error("failed to connect to database", "error", err)
+		os.Exit(1)
 	}
 	defer db.Close()
 
 	// Initialize database schema
 	if err := db.InitSchema(); err != nil {
-		log.Fatalf("Failed to initialize database schema: %v", err)
+		slog.Error("failed to initialize database schema", "error", err)
+		os.Exit(1)
 	}
 
 	// Create a new Telegram bot
 	bot, err := alert.NewTelegramBot(token, db)
 	if err != nil {
-		log.Fatalf("Failed to create Telegram bot: %v", err)
+		slog.Error("failed to create Telegram bot", "error", err)
+		os.Exit(1)
 	}
 
 	// Start the bot
 	bot.Start()
-	log.Println("Telegram bot started")
+	slog.Info("Telegram bot started")
+
+	// Prometheus metrics; the bot has no other HTTP server
+	metricsPort := getEnv("METRICS_PORT", "9083")
+	go func() {
+		slog.Info("metrics listening", "addr", ":"+metricsPort)
+		if err := metrics.ListenAndServe(":" + metricsPort); err != nil {
+			slog.Error("metrics server failed", "error", err)
+		}
+	}()
 
 	// Test crypto rates functionality
-	log.Println("Testing crypto rates...")
+	slog.Debug("testing crypto rates")
 	testClient := binance.NewClient()
 
 	// Test BTC
 	btcRate, err := testClient.GetCurrentCryptoToRubRate("BTC")
 	if err != nil {
-		log.Printf("Error getting BTC rate: %v", err)
+		slog.Warn("crypto rate test failed", "symbol", "BTC", "error", err)
 	} else {
-		log.Printf("BTC rate test: %.2f RUB", btcRate.Close)
+		slog.Debug("crypto rate test", "symbol", "BTC", "rub", btcRate.Close)
 	}
 
 	// Test DOGE
 	dogeRate, err := testClient.GetCurrentCryptoToRubRate("DOGE")
 	if err != nil {
-		log.Printf("Error getting DOGE rate: %v", err)
+		slog.Warn("crypto rate test failed", "symbol", "DOGE", "error", err)
 	} else {
-		log.Printf("DOGE rate test: %.2f RUB", dogeRate.Close)
+		slog.Debug("crypto rate test", "symbol", "DOGE", "rub", dogeRate.Close)
 	}
 
 	// Create a scheduler for daily updates at 2:00 UTC
@@ -82,11 +99,14 @@ func main() {
 	sched.StartDailyUpdates(2)
 	// for test
 	//sched.RunNow()
-	log.Println("Daily updates scheduler started")
+	slog.Info("daily updates scheduler started")
 
 	// Start crypto updates every 15 minutes
 	sched.StartCryptoUpdates()
-	log.Println("Crypto updates scheduler started (15 minutes interval)")
+	slog.Info("crypto updates scheduler started", "interval", "15m")
+
+	// Announce the next day's CBR rates once they are published
+	sched.StartNextDayAnnouncements()
 
 	// Wait for interrupt signal to gracefully shutdown
 	quit := make(chan os.Signal, 1)
@@ -94,10 +114,10 @@ func main() {
 	<-quit
 
 	// Stop the bot and scheduler
-	log.Println("Shutting down...")
+	slog.Info("shutting down")
 	sched.Stop()
 	bot.Stop()
-	log.Println("Bot stopped")
+	slog.Info("bot stopped")
 }
 
 // getEnv gets an environment variable or returns a default value
@@ -117,7 +137,7 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	value, err := strconv.Atoi(valueStr)
 	if err != nil {
-		log.Printf("Warning: Could not parse %s as integer, using default value %d", key, defaultValue)
+		slog.Warn("could not parse environment variable as integer, using default", "key", key, "default", defaultValue)
 		return defaultValue
 	}
 	return value
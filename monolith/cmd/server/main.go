@@ -1,65 +1,111 @@
 package main
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/casualdoto/go-currency-tracker/internal/api"
+	"github.com/casualdoto/go-currency-tracker/internal/logging"
+	"github.com/casualdoto/go-currency-tracker/internal/metrics"
 	"github.com/casualdoto/go-currency-tracker/internal/scheduler"
 	"github.com/casualdoto/go-currency-tracker/internal/storage"
+	"github.com/casualdoto/go-currency-tracker/internal/stream"
 )
 
 func main() {
+	logging.Setup("server")
+
 	// Define working directory for correct access to static files
 	workDir, err := os.Getwd()
 	if err != nil {
-		log.Fatalf("Failed to get working directory: %v", err)
+		slog.Error("failed to get working directory", "error", err)
+		os.Exit(1)
 	}
-	log.Printf("Working directory: %s", workDir)
+	slog.Info("working directory", "path", workDir)
 
 	// Check for api directory
 	apiDir := filepath.Join(workDir, "api")
 	if _, err := os.Stat(apiDir); os.IsNotExist(err) {
-		log.Printf("Warning: API docs directory not found at %s", apiDir)
+		slog.Warn("API docs directory not found", "path", apiDir)
 	} else {
-		log.Printf("API docs directory found at %s", apiDir)
+		slog.Debug("API docs directory found", "path", apiDir)
 
 		// Check for documentation file
 		apiFile := filepath.Join(apiDir, "openapi.json")
 		if _, err := os.Stat(apiFile); os.IsNotExist(err) {
-			log.Printf("Warning: API docs file not found at %s", apiFile)
+			slog.Warn("API docs file not found", "path", apiFile)
 		} else {
-			log.Printf("API docs file found at %s", apiFile)
+			slog.Debug("API docs file found", "path", apiFile)
 		}
 	}
 
 	// Initialize database connection
 	db, err := initDatabase()
 	if err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+		slog.Error("failed to initialize database", "error", err)
+		os.Exit(1)
 	}
 	defer db.Close()
 
-	// Initialize scheduler for daily currency rate updates at 23:59 UTC
-	currencyScheduler := scheduler.NewCurrencyRateScheduler(db, 23, 59)
+	// Report the age of the newest stored rates from startup on
+	if date, err := db.LatestCurrencyRateDate(); err == nil {
+		metrics.RateStored(metrics.SourceCBR, date)
+	}
+	if timestamp, err := db.LatestCryptoRateTime(); err == nil {
+		metrics.RateStored(metrics.SourceBinance, timestamp)
+	}
+	if date, err := db.LatestMetalPriceDate(); err == nil {
+		metrics.RateStored(metrics.SourceCBRMetals, date)
+	}
+
+	// Live rate stream served at /v1/stream, fed by the schedulers below
+	hub := stream.NewHub(stream.DefaultHistorySize)
+
+	// Initialize scheduler for daily currency rate updates at 02:59 Moscow
+	// time, when today's sheet is the current one
+	currencyScheduler := scheduler.NewCurrencyRateScheduler(db, 2, 59)
+	currencyScheduler.SetStream(hub)
 	currencyScheduler.Start()
 	defer currencyScheduler.Stop()
 
 	// Run the initial currency rates update
-	log.Println("Running initial currency rates update...")
+	slog.Info("running initial currency rates update")
 	if err := currencyScheduler.RunImmediately(); err != nil {
-		log.Printf("Warning: Failed to run initial currency rates update: %v", err)
+		slog.Warn("initial currency rates update failed", "error", err)
 	} else {
-		log.Println("Initial currency rates update completed successfully")
+		slog.Info("initial currency rates update completed")
+	}
+	if err := currencyScheduler.UpdateMetalPrices(); err != nil {
+		slog.Warn("initial metal prices update failed", "error", err)
+	}
+	if err := currencyScheduler.UpdateIndicatorRates(); err != nil {
+		slog.Warn("initial indicator rates update failed", "error", err)
+	}
+
+	// Poll current crypto prices for the stream
+	interval, err := time.ParseDuration(getEnv("STREAM_CRYPTO_INTERVAL", "5m"))
+	if err != nil || interval <= 0 {
+		slog.Warn("invalid STREAM_CRYPTO_INTERVAL, using 5m")
+		interval = 5 * time.Minute
+	}
+	var symbols []string
+	for _, s := range strings.Split(getEnv("STREAM_CRYPTO_SYMBOLS", "BTC,ETH,BNB,SOL,XRP"), ",") {
+		if s = strings.ToUpper(strings.TrimSpace(s)); s != "" {
+			symbols = append(symbols, s)
+		}
 	}
+	cryptoScheduler := scheduler.NewCryptoStreamScheduler(hub, symbols, interval)
+	cryptoScheduler.Start()
+	defer cryptoScheduler.Stop()
 
 	// Setup routes with database access
-	router := api.SetupRoutesWithDB(db)
+	router := api.SetupRoutesWithDB(db, hub)
 
 	// Start HTTP server
 	server := &http.Server{
@@ -69,9 +115,10 @@ func main() {
 
 	// Graceful shutdown
 	go func() {
-		log.Println("Go Currency Monitor API started on :8081")
+		slog.Info("Go Currency Monitor API started", "addr", server.Addr)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v", err)
+			slog.Error("failed to start server", "error", err)
+			os.Exit(1)
 		}
 	}()
 
@@ -80,7 +127,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("Shutting down server...")
+	slog.Info("shutting down server")
 }
 
 // initDatabase initializes the database connection and schema
@@ -109,9 +156,9 @@ func initDatabase() (*storage.PostgresDB, error) {
 			break
 		}
 
-		log.Printf("Failed to connect to database (attempt %d/%d): %v", i+1, maxRetries, err)
+		slog.Warn("failed to connect to database", "attempt", i+1, "max_attempts", maxRetries, "error", err)
 		if i < maxRetries-1 {
-			log.Printf("Retrying in %v...", retryDelay)
+			slog.Info("retrying database connection", "delay", retryDelay.String())
 			time.Sleep(retryDelay)
 			// Increase delay for next attempt
 			retryDelay *= 2
@@ -127,7 +174,7 @@ func initDatabase() (*storage.PostgresDB, error) {
 		return nil, err
 	}
 
-	log.Println("Database connection established and schema initialized")
+	slog.Info("database connection established and schema initialized")
 	return db, nil
 }
 
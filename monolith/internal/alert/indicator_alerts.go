@@ -0,0 +1,203 @@
+package alert
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/internal/currency/binance"
+	"github.com/casualdoto/go-currency-tracker/internal/indicators"
+	"github.com/casualdoto/go-currency-tracker/internal/money"
+	"github.com/casualdoto/go-currency-tracker/internal/storage"
+	"github.com/tucnak/telebot"
+)
+
+// alertBars is the number of bars loaded for an alert beyond the warmup of
+// its indicators
+const alertBars = 10
+
+// loadAlertCandles loads the latest RUB candles of symbol at interval, enough
+// of them to seed specs: the stored rates, or Binance klines when too few are
+// stored
+func loadAlertCandles(db *storage.PostgresDB, symbol, interval string, specs []indicators.Spec) ([]indicators.Candle, error) {
+	step, err := indicators.ParseInterval(interval)
+	if err != nil {
+		return nil, err
+	}
+	warmup := indicators.MaxWarmup(specs)
+	to := time.Now().UTC()
+	from := to.Add(-time.Duration(warmup+alertBars) * step)
+
+	rates, err := db.GetCryptoRatesByDateRange(symbol+"/RUB", from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cryptocurrency rates: %w", err)
+	}
+	var candles []indicators.Candle
+	for _, rate := range rates {
+		if rate.Close.IsPositive() {
+			candles = append(candles, rubCandle(rate.Timestamp, rate.Open, rate.High, rate.Low, rate.Close, rate.Volume))
+		}
+	}
+	if candles = indicators.Resample(candles, step); len(candles) > warmup {
+		return candles, nil
+	}
+
+	klines, err := binance.NewClient().GetHistoricalCryptoToRubRates(symbol, binance.KlineInterval(interval), from, to)
+	if err != nil {
+		return nil, err
+	}
+	candles = candles[:0]
+	for _, rate := range klines {
+		if rate.Close.IsPositive() {
+			candles = append(candles, rubCandle(rate.Timestamp, rate.Open, rate.High, rate.Low, rate.Close, rate.Volume))
+		}
+	}
+	return indicators.Resample(candles, step), nil
+}
+
+// rubCandle builds a candle from RUB prices
+func rubCandle(t time.Time, o, h, l, c, v money.Decimal) indicators.Candle {
+	return indicators.Candle{Time: t, Open: o.Float64(), High: h.Float64(), Low: l.Float64(), Close: c.Float64(), Volume: v.Float64()}
+}
+
+// alertLatest computes the latest values of specs over candles, plus "close"
+func alertLatest(candles []indicators.Candle, specs []indicators.Spec) map[string]float64 {
+	latest := indicators.Latest(indicators.Compute(candles, specs))
+	if len(candles) > 0 {
+		latest["close"] = candles[len(candles)-1].Close
+	}
+	return latest
+}
+
+// alertNotice is the message for a user whose alert has started to hold
+type alertNotice struct {
+	userID int
+	text   string
+}
+
+// evaluateAlerts checks the alerts subscribed in subs against the latest
+// values of their symbol and interval, computed once for all alerts on them.
+// met holds the users each alert held for at the previous check and is
+// updated; a notice is returned for every user an alert has started to hold
+// for. Alerts whose values cannot be computed keep their state
+func evaluateAlerts(subs map[int][]string, met map[string]map[int]bool,
+	latest func(symbol, interval string, specs []indicators.Spec) (map[string]float64, bool)) []alertNotice {
+	type group struct {
+		symbol, interval string
+		specs            []indicators.Spec
+	}
+	groups := make(map[string]*group)
+	alerts := make(map[string]indicators.Alert)
+	users := make(map[string][]int)
+	for userID, values := range subs {
+		for _, value := range values {
+			a, err := indicators.ParseAlert(value)
+			if err != nil {
+				logger.Warn("skipping invalid alert subscription", "chat_id", userID, "alert", value, "error", err)
+				continue
+			}
+			key := a.Symbol + " " + a.Interval
+			g, ok := groups[key]
+			if !ok {
+				g = &group{symbol: a.Symbol, interval: a.Interval}
+				groups[key] = g
+			}
+			specs, _ := a.Condition.Specs()
+			for _, spec := range specs {
+				if !containsSpec(g.specs, spec) {
+					g.specs = append(g.specs, spec)
+				}
+			}
+			alerts[a.String()] = a
+			users[a.String()] = append(users[a.String()], userID)
+		}
+	}
+
+	values := make(map[string]map[string]float64)
+	for key, g := range groups {
+		if v, ok := latest(g.symbol, g.interval, g.specs); ok {
+			values[key] = v
+		}
+	}
+
+	names := make([]string, 0, len(alerts))
+	for name := range alerts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var notices []alertNotice
+	for _, name := range names {
+		a := alerts[name]
+		v, ok := values[a.Symbol+" "+a.Interval]
+		if !ok {
+			continue
+		}
+		holds := a.Condition.Met(v)
+		if met[name] == nil {
+			met[name] = make(map[int]bool)
+		}
+		sort.Ints(users[name])
+		for _, userID := range users[name] {
+			if holds && !met[name][userID] {
+				notices = append(notices, alertNotice{userID: userID, text: alertMessage(a, v)})
+			}
+			met[name][userID] = holds
+		}
+	}
+	return notices
+}
+
+func containsSpec(specs []indicators.Spec, spec indicators.Spec) bool {
+	for _, s := range specs {
+		if s.Name() == spec.Name() {
+			return true
+		}
+	}
+	return false
+}
+
+// alertMessage reports an alert that has started to hold with the values it
+// compared
+func alertMessage(a indicators.Alert, latest map[string]float64) string {
+	values := []string{a.Condition.Indicator + " is " + strconv.FormatFloat(latest[a.Condition.Indicator], 'f', 2, 64)}
+	if a.Condition.Other != "" {
+		values = append(values, a.Condition.Other+" is "+strconv.FormatFloat(latest[a.Condition.Other], 'f', 2, 64))
+	}
+	return fmt.Sprintf("🔔 %s: %s", a, strings.Join(values, ", "))
+}
+
+// CheckIndicatorAlerts tells alert subscribers whose indicator conditions
+// have started to hold since the previous check. The state is kept in
+// memory, so after a restart alerts that hold are reported once more
+func (t *TelegramBot) CheckIndicatorAlerts() {
+	// Refresh alert subscriptions from database
+	alertSubs, err := t.db.GetAllTelegramAlertSubscriptions()
+	if err != nil {
+		logger.Error("refreshing alert subscriptions failed", "error", err)
+		// Continue with in-memory cache if available
+	} else {
+		t.mu.Lock()
+		t.alertSubs = alertSubs
+		t.mu.Unlock()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	notices := evaluateAlerts(t.alertSubs, t.alertsMet, func(symbol, interval string, specs []indicators.Spec) (map[string]float64, bool) {
+		candles, err := loadAlertCandles(t.db, symbol, interval, specs)
+		if err != nil || len(candles) == 0 {
+			logger.Warn("alert candles unavailable", "symbol", symbol, "interval", interval, "error", err)
+			return nil, false
+		}
+		return alertLatest(candles, specs), true
+	})
+	for _, n := range notices {
+		if err := t.send(&telebot.User{ID: n.userID}, n.text); err != nil {
+			logger.Warn("telegram send failed", "chat_id", n.userID, "error", err)
+		}
+	}
+}
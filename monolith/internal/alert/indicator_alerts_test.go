@@ -0,0 +1,89 @@
+package alert
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/internal/indicators"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateAlerts_notifiesWhenConditionStartsToHold(t *testing.T) {
+	subs := map[int][]string{
+		1: {"BTC 1h rsi14>70", "ETH 4h sma20>sma50"},
+		2: {"BTC 1h rsi14>70"},
+	}
+	met := make(map[string]map[int]bool)
+	values := map[string]map[string]float64{
+		"BTC 1h": {"rsi14": 65},
+		"ETH 4h": {"sma20": 190000, "sma50": 195000},
+	}
+	var computed []string
+	latest := func(symbol, interval string, specs []indicators.Spec) (map[string]float64, bool) {
+		var names []string
+		for _, spec := range specs {
+			names = append(names, spec.Name())
+		}
+		computed = append(computed, symbol+" "+interval+" "+strings.Join(names, ","))
+		v, ok := values[symbol+" "+interval]
+		return v, ok
+	}
+
+	assert.Empty(t, evaluateAlerts(subs, met, latest))
+	assert.ElementsMatch(t, []string{"BTC 1h rsi14", "ETH 4h sma20,sma50"}, computed, "one computation per symbol and interval")
+
+	// RSI crosses 70 and the fast average crosses the slow one
+	values["BTC 1h"]["rsi14"] = 72.5
+	values["ETH 4h"]["sma20"] = 196000
+	notices := evaluateAlerts(subs, met, latest)
+	assert.Equal(t, []alertNotice{
+		{userID: 1, text: "🔔 BTC 1h rsi14>70: rsi14 is 72.50"},
+		{userID: 2, text: "🔔 BTC 1h rsi14>70: rsi14 is 72.50"},
+		{userID: 1, text: "🔔 ETH 4h sma20>sma50: sma20 is 196000.00, sma50 is 195000.00"},
+	}, notices)
+
+	// Still holding: no repeat
+	values["BTC 1h"]["rsi14"] = 75
+	assert.Empty(t, evaluateAlerts(subs, met, latest))
+
+	// Values unavailable: the state is kept
+	delete(values, "BTC 1h")
+	assert.Empty(t, evaluateAlerts(subs, met, latest))
+	values["BTC 1h"] = map[string]float64{"rsi14": 74}
+	assert.Empty(t, evaluateAlerts(subs, met, latest))
+
+	// Drops below and crosses again
+	values["BTC 1h"]["rsi14"] = 60
+	assert.Empty(t, evaluateAlerts(subs, met, latest))
+	values["BTC 1h"]["rsi14"] = 71
+	assert.Len(t, evaluateAlerts(subs, met, latest), 2)
+}
+
+func TestEvaluateAlerts_skipsInvalidSubscriptions(t *testing.T) {
+	subs := map[int][]string{1: {"not an alert"}}
+	called := false
+	notices := evaluateAlerts(subs, make(map[string]map[int]bool), func(string, string, []indicators.Spec) (map[string]float64, bool) {
+		called = true
+		return nil, false
+	})
+	assert.Empty(t, notices)
+	assert.False(t, called)
+}
+
+func TestAlertLatest(t *testing.T) {
+	start := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	var candles []indicators.Candle
+	for i := 0; i < 30; i++ {
+		price := 6000000 + float64(i)*1000
+		candles = append(candles, indicators.Candle{Time: start.Add(time.Duration(i) * time.Hour), Open: price, High: price, Low: price, Close: price})
+	}
+	specs, err := indicators.ParseSpecs("rsi14,sma5")
+	require.NoError(t, err)
+
+	latest := alertLatest(candles, specs)
+	assert.Equal(t, 100.0, latest["rsi14"], "only gains")
+	assert.Equal(t, 6027000.0, latest["sma5"])
+	assert.Equal(t, 6029000.0, latest["close"])
+}
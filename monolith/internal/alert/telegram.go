@@ -2,17 +2,24 @@ package alert
 
 import (
 	"fmt"
-	"log"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/casualdoto/go-currency-tracker/internal/calendar"
+	"github.com/casualdoto/go-currency-tracker/internal/convert"
 	"github.com/casualdoto/go-currency-tracker/internal/currency/binance"
 	currency "github.com/casualdoto/go-currency-tracker/internal/currency/cbr"
+	"github.com/casualdoto/go-currency-tracker/internal/indicators"
+	"github.com/casualdoto/go-currency-tracker/internal/logging"
+	"github.com/casualdoto/go-currency-tracker/internal/metrics"
+	"github.com/casualdoto/go-currency-tracker/internal/money"
 	"github.com/casualdoto/go-currency-tracker/internal/storage"
 	"github.com/tucnak/telebot"
 )
 
+var logger = logging.For("alert")
+
 // Subscription represents a user's currency subscription
 type Subscription struct {
 	UserID    int
@@ -23,11 +30,20 @@ type Subscription struct {
 // TelegramBot represents a Telegram bot instance
 type TelegramBot struct {
 	bot              *telebot.Bot
-	subscriptions    map[int][]string   // UserID -> []Currency (in-memory cache)
-	cryptoSubs       map[int][]string   // UserID -> []CryptoSymbol (in-memory cache)
-	lastCryptoPrices map[string]float64 // Symbol -> Last price for change calculation
+	subscriptions    map[int][]string         // UserID -> []Currency (in-memory cache)
+	cryptoSubs       map[int][]string         // UserID -> []CryptoSymbol (in-memory cache)
+	metalSubs        map[int][]string         // UserID -> []Metal (in-memory cache)
+	indicatorSubs    map[int][]string         // UserID -> []Indicator (in-memory cache)
+	alertSubs        map[int][]string         // UserID -> []Alert (in-memory cache)
+	alertsMet        map[string]map[int]bool  // Alert -> UserID -> held at the last check
+	lastCryptoPrices map[string]money.Decimal // Symbol -> Last price for change calculation
 	mu               sync.RWMutex
 	db               *storage.PostgresDB
+	// announcedThrough is the effective date of the latest CBR sheet seen by
+	// AnnounceNextDayRates
+	announcedThrough time.Time
+	// keyRate is the latest key rate seen by AnnounceKeyRateChange
+	keyRate currency.IndicatorRate
 }
 
 // NewTelegramBot creates a new Telegram bot instance
@@ -55,20 +71,52 @@ func NewTelegramBot(token string, db *storage.PostgresDB) (*TelegramBot, error)
 	// Load crypto subscriptions from database
 	cryptoSubs, err := db.GetAllTelegramCryptoSubscriptions()
 	if err != nil {
-		log.Printf("Failed to load crypto subscriptions from database: %v", err)
+		logger.Error("loading crypto subscriptions failed", "error", err)
 		cryptoSubs = make(map[int][]string)
 	}
 
+	// Load precious metal subscriptions from database
+	metalSubs, err := db.GetAllTelegramMetalSubscriptions()
+	if err != nil {
+		logger.Error("loading metal subscriptions failed", "error", err)
+		metalSubs = make(map[int][]string)
+	}
+
+	// Load CBR indicator subscriptions from database
+	indicatorSubs, err := db.GetAllTelegramIndicatorSubscriptions()
+	if err != nil {
+		logger.Error("loading indicator subscriptions failed", "error", err)
+		indicatorSubs = make(map[int][]string)
+	}
+
+	// Load indicator alert subscriptions from database
+	alertSubs, err := db.GetAllTelegramAlertSubscriptions()
+	if err != nil {
+		logger.Error("loading alert subscriptions failed", "error", err)
+		alertSubs = make(map[int][]string)
+	}
+
 	return &TelegramBot{
 		bot:              bot,
 		subscriptions:    subscriptions,
 		cryptoSubs:       cryptoSubs,
-		lastCryptoPrices: make(map[string]float64),
+		metalSubs:        metalSubs,
+		indicatorSubs:    indicatorSubs,
+		alertSubs:        alertSubs,
+		alertsMet:        make(map[string]map[int]bool),
+		lastCryptoPrices: make(map[string]money.Decimal),
 		mu:               sync.RWMutex{},
 		db:               db,
 	}, nil
 }
 
+// send sends a message to a user and counts the outcome
+func (t *TelegramBot) send(to telebot.Recipient, what interface{}) error {
+	_, err := t.bot.Send(to, what)
+	metrics.TelegramSent(err)
+	return err
+}
+
 // Start starts the bot
 func (t *TelegramBot) Start() {
 	// Handle /start command
@@ -79,15 +127,27 @@ func (t *TelegramBot) Start() {
 			"/subscribe [currency] - Subscribe to currency updates (e.g., /subscribe USD)\n" +
 			"/unsubscribe [currency] - Unsubscribe from currency updates (e.g., /unsubscribe USD)\n" +
 			"/list - List your subscriptions\n" +
-			"/rate [currency] - Get current rate for a currency (e.g., /rate USD)\n\n" +
+			"/rate [currency] - Get current rate for a currency (e.g., /rate USD)\n" +
+			"/convert [amount] [from] [to] [date] - Convert an amount (e.g., /convert 250 EUR CNY)\n\n" +
 			"Cryptocurrency commands:\n" +
 			"/cryptocurrencies - Get list of available cryptocurrencies\n" +
 			"/crypto_subscribe [symbol] - Subscribe to crypto updates (e.g., /crypto_subscribe BTC)\n" +
 			"/crypto_unsubscribe [symbol] - Unsubscribe from crypto updates (e.g., /crypto_unsubscribe BTC)\n" +
 			"/crypto_list - List your crypto subscriptions\n" +
-			"/crypto_rate [symbol] - Get current rate for a cryptocurrency (e.g., /crypto_rate BTC)"
-
-		t.bot.Send(m.Sender, msg)
+			"/crypto_rate [symbol] - Get current rate for a cryptocurrency (e.g., /crypto_rate BTC)\n\n" +
+			"Precious metals commands:\n" +
+			"/metals - Get CBR precious metals prices\n" +
+			"/metals_subscribe [metal] - Subscribe to a metal (e.g., /metals_subscribe XAU or gold)\n" +
+			"/metals_unsubscribe [metal] - Unsubscribe from a metal (e.g., /metals_unsubscribe XAU)\n\n" +
+			"Key rate commands:\n" +
+			"/keyrate - Get the CBR key rate and RUONIA\n" +
+			"/keyrate_subscribe - Get notified when the CBR key rate changes\n" +
+			"/keyrate_unsubscribe - Stop key rate notifications\n\n" +
+			"Indicator alerts:\n" +
+			"/alert_subscribe [symbol] [interval] [condition] - Get notified when a condition starts to hold (e.g., /alert_subscribe BTC 1h rsi14>70 or /alert_subscribe ETH 4h sma20>sma50)\n" +
+			"/alert_unsubscribe [symbol] [interval] [condition] - Stop an alert (e.g., /alert_unsubscribe BTC 1h rsi14>70)"
+
+		t.send(m.Sender, msg)
 	})
 
 	// Handle /currencies command
@@ -95,7 +155,7 @@ func (t *TelegramBot) Start() {
 		// Get available currencies from CBR
 		rates, err := currency.GetCBRRatesByDate("")
 		if err != nil {
-			t.bot.Send(m.Sender, "Failed to retrieve available currencies. Please try again later.")
+			t.send(m.Sender, "Failed to retrieve available currencies. Please try again later.")
 			return
 		}
 
@@ -127,7 +187,7 @@ func (t *TelegramBot) Start() {
 
 		msg += "\nUse /subscribe [currency] to subscribe to updates"
 
-		t.bot.Send(m.Sender, msg)
+		t.send(m.Sender, msg)
 	})
 
 	// Handle /cryptocurrencies command
@@ -167,14 +227,14 @@ func (t *TelegramBot) Start() {
 
 		msg += "\nUse /crypto_subscribe [symbol] to subscribe to updates"
 
-		t.bot.Send(m.Sender, msg)
+		t.send(m.Sender, msg)
 	})
 
 	// Handle /subscribe command
 	t.bot.Handle("/subscribe", func(m *telebot.Message) {
 		args := strings.Fields(m.Text)
 		if len(args) < 2 {
-			t.bot.Send(m.Sender, "Please specify a currency code. Example: /subscribe USD")
+			t.send(m.Sender, "Please specify a currency code. Example: /subscribe USD")
 			return
 		}
 
@@ -183,7 +243,7 @@ func (t *TelegramBot) Start() {
 		// Verify the currency exists
 		_, err := currency.GetCurrencyRate(currencyCode, "")
 		if err != nil {
-			t.bot.Send(m.Sender, fmt.Sprintf("Currency %s not found or unavailable", currencyCode))
+			t.send(m.Sender, fmt.Sprintf("Currency %s not found or unavailable", currencyCode))
 			return
 		}
 
@@ -198,7 +258,7 @@ func (t *TelegramBot) Start() {
 			// Check if already subscribed
 			for _, c := range currencies {
 				if c == currencyCode {
-					t.bot.Send(m.Sender, fmt.Sprintf("You are already subscribed to %s", currencyCode))
+					t.send(m.Sender, fmt.Sprintf("You are already subscribed to %s", currencyCode))
 					return
 				}
 			}
@@ -208,19 +268,19 @@ func (t *TelegramBot) Start() {
 		// Save to database
 		err = t.db.SaveTelegramSubscription(m.Sender.ID, currencyCode)
 		if err != nil {
-			log.Printf("Error saving subscription to database: %v", err)
-			t.bot.Send(m.Sender, "Failed to save subscription. Please try again later.")
+			logger.Error("saving subscription failed", "chat_id", m.Sender.ID, "currency", currencyCode, "error", err)
+			t.send(m.Sender, "Failed to save subscription. Please try again later.")
 			return
 		}
 
-		t.bot.Send(m.Sender, fmt.Sprintf("You have successfully subscribed to %s", currencyCode))
+		t.send(m.Sender, fmt.Sprintf("You have successfully subscribed to %s", currencyCode))
 	})
 
 	// Handle /unsubscribe command
 	t.bot.Handle("/unsubscribe", func(m *telebot.Message) {
 		args := strings.Fields(m.Text)
 		if len(args) < 2 {
-			t.bot.Send(m.Sender, "Please specify a currency code. Example: /unsubscribe USD")
+			t.send(m.Sender, "Please specify a currency code. Example: /unsubscribe USD")
 			return
 		}
 
@@ -231,7 +291,7 @@ func (t *TelegramBot) Start() {
 
 		currencies, exists := t.subscriptions[m.Sender.ID]
 		if !exists {
-			t.bot.Send(m.Sender, "You don't have any subscriptions")
+			t.send(m.Sender, "You don't have any subscriptions")
 			return
 		}
 
@@ -246,20 +306,20 @@ func (t *TelegramBot) Start() {
 		}
 
 		if !found {
-			t.bot.Send(m.Sender, fmt.Sprintf("You are not subscribed to %s", currencyCode))
+			t.send(m.Sender, fmt.Sprintf("You are not subscribed to %s", currencyCode))
 			return
 		}
 
 		// Delete from database
 		err := t.db.DeleteTelegramSubscription(m.Sender.ID, currencyCode)
 		if err != nil {
-			log.Printf("Error deleting subscription from database: %v", err)
-			t.bot.Send(m.Sender, "Failed to unsubscribe. Please try again later.")
+			logger.Error("deleting subscription failed", "chat_id", m.Sender.ID, "currency", currencyCode, "error", err)
+			t.send(m.Sender, "Failed to unsubscribe. Please try again later.")
 			return
 		}
 
 		t.subscriptions[m.Sender.ID] = newCurrencies
-		t.bot.Send(m.Sender, fmt.Sprintf("You have successfully unsubscribed from %s", currencyCode))
+		t.send(m.Sender, fmt.Sprintf("You have successfully unsubscribed from %s", currencyCode))
 	})
 
 	// Handle /list command
@@ -267,13 +327,13 @@ func (t *TelegramBot) Start() {
 		// Get subscriptions from database to ensure we have the latest data
 		currencies, err := t.db.GetTelegramSubscriptions(m.Sender.ID)
 		if err != nil {
-			log.Printf("Error getting subscriptions from database: %v", err)
-			t.bot.Send(m.Sender, "Failed to retrieve your subscriptions. Please try again later.")
+			logger.Error("loading subscriptions failed", "chat_id", m.Sender.ID, "error", err)
+			t.send(m.Sender, "Failed to retrieve your subscriptions. Please try again later.")
 			return
 		}
 
 		if len(currencies) == 0 {
-			t.bot.Send(m.Sender, "You don't have any subscriptions")
+			t.send(m.Sender, "You don't have any subscriptions")
 			return
 		}
 
@@ -282,7 +342,7 @@ func (t *TelegramBot) Start() {
 			msg += "- " + c + "\n"
 		}
 
-		t.bot.Send(m.Sender, msg)
+		t.send(m.Sender, msg)
 
 		// Update in-memory cache
 		t.mu.Lock()
@@ -294,7 +354,7 @@ func (t *TelegramBot) Start() {
 	t.bot.Handle("/rate", func(m *telebot.Message) {
 		args := strings.Fields(m.Text)
 		if len(args) < 2 {
-			t.bot.Send(m.Sender, "Please specify a currency code. Example: /rate USD")
+			t.send(m.Sender, "Please specify a currency code. Example: /rate USD")
 			return
 		}
 
@@ -303,23 +363,61 @@ func (t *TelegramBot) Start() {
 		// Get current rate
 		rate, err := currency.GetCurrencyRate(currencyCode, "")
 		if err != nil {
-			t.bot.Send(m.Sender, fmt.Sprintf("Error getting rate for %s: %v", currencyCode, err))
+			t.send(m.Sender, fmt.Sprintf("Error getting rate for %s: %v", currencyCode, err))
 			return
 		}
 
 		// Format the message
 		msg := fmt.Sprintf("Currency: %s (%s)\n", rate.Name, rate.CharCode)
-		msg += fmt.Sprintf("Current rate: %.4f RUB (per %d unit)\n", rate.Value, rate.Nominal)
-		msg += fmt.Sprintf("Previous rate: %.4f RUB", rate.Previous)
+		msg += fmt.Sprintf("Current rate: %s RUB (per %d unit)\n", rate.Value.StringFixed(4), rate.Nominal)
+		msg += fmt.Sprintf("Previous rate: %s RUB", rate.Previous.StringFixed(4))
+
+		t.send(m.Sender, msg)
+	})
+
+	// Handle /convert command
+	t.bot.Handle("/convert", func(m *telebot.Message) {
+		args := strings.Fields(m.Text)
+		if len(args) < 4 {
+			t.send(m.Sender, "Please specify amount and currencies. Example: /convert 250 EUR CNY [YYYY-MM-DD]")
+			return
+		}
+
+		amount, err := money.Parse(strings.Replace(args[1], ",", ".", 1))
+		if err != nil || amount.IsNegative() {
+			t.send(m.Sender, "Invalid amount. Example: /convert 250 EUR CNY")
+			return
+		}
+
+		// Optional date, defaults to today in Moscow
+		date := calendar.Today()
+		if len(args) > 4 {
+			date, err = calendar.ParseDate(args[4])
+			if err != nil {
+				t.send(m.Sender, "Invalid date format. Use YYYY-MM-DD")
+				return
+			}
+		}
+
+		result, err := convert.NewConverter(t.db).Convert(amount, args[2], args[3], date)
+		if err != nil {
+			t.send(m.Sender, fmt.Sprintf("Error converting %s to %s: %v", strings.ToUpper(args[2]), strings.ToUpper(args[3]), err))
+			return
+		}
+
+		// Format the message
+		msg := fmt.Sprintf("%s %s = %s %s\n", result.Amount, result.From, result.Result, result.To)
+		msg += fmt.Sprintf("Rate: 1 %s = %s %s\n", result.From, result.Rate, result.To)
+		msg += fmt.Sprintf("Date: %s", result.Date)
 
-		t.bot.Send(m.Sender, msg)
+		t.send(m.Sender, msg)
 	})
 
 	// Handle /crypto_subscribe command
 	t.bot.Handle("/crypto_subscribe", func(m *telebot.Message) {
 		args := strings.Fields(m.Text)
 		if len(args) < 2 {
-			t.bot.Send(m.Sender, "Please specify a cryptocurrency symbol. Example: /crypto_subscribe BTC")
+			t.send(m.Sender, "Please specify a cryptocurrency symbol. Example: /crypto_subscribe BTC")
 			return
 		}
 
@@ -329,7 +427,7 @@ func (t *TelegramBot) Start() {
 		client := binance.NewClient()
 		_, err := client.GetCurrentCryptoToRubRate(symbol)
 		if err != nil {
-			t.bot.Send(m.Sender, fmt.Sprintf("Cryptocurrency %s not found or unavailable: %v", symbol, err))
+			t.send(m.Sender, fmt.Sprintf("Cryptocurrency %s not found or unavailable: %v", symbol, err))
 			return
 		}
 
@@ -344,7 +442,7 @@ func (t *TelegramBot) Start() {
 			// Check if already subscribed
 			for _, s := range symbols {
 				if s == symbol {
-					t.bot.Send(m.Sender, fmt.Sprintf("You are already subscribed to %s", symbol))
+					t.send(m.Sender, fmt.Sprintf("You are already subscribed to %s", symbol))
 					return
 				}
 			}
@@ -354,19 +452,19 @@ func (t *TelegramBot) Start() {
 		// Save to database
 		err = t.db.SaveTelegramCryptoSubscription(m.Sender.ID, symbol)
 		if err != nil {
-			log.Printf("Error saving crypto subscription to database: %v", err)
-			t.bot.Send(m.Sender, "Failed to save subscription. Please try again later.")
+			logger.Error("saving crypto subscription failed", "chat_id", m.Sender.ID, "symbol", symbol, "error", err)
+			t.send(m.Sender, "Failed to save subscription. Please try again later.")
 			return
 		}
 
-		t.bot.Send(m.Sender, fmt.Sprintf("You have successfully subscribed to %s", symbol))
+		t.send(m.Sender, fmt.Sprintf("You have successfully subscribed to %s", symbol))
 	})
 
 	// Handle /crypto_unsubscribe command
 	t.bot.Handle("/crypto_unsubscribe", func(m *telebot.Message) {
 		args := strings.Fields(m.Text)
 		if len(args) < 2 {
-			t.bot.Send(m.Sender, "Please specify a cryptocurrency symbol. Example: /crypto_unsubscribe BTC")
+			t.send(m.Sender, "Please specify a cryptocurrency symbol. Example: /crypto_unsubscribe BTC")
 			return
 		}
 
@@ -377,7 +475,7 @@ func (t *TelegramBot) Start() {
 
 		symbols, exists := t.cryptoSubs[m.Sender.ID]
 		if !exists {
-			t.bot.Send(m.Sender, "You don't have any cryptocurrency subscriptions")
+			t.send(m.Sender, "You don't have any cryptocurrency subscriptions")
 			return
 		}
 
@@ -392,20 +490,20 @@ func (t *TelegramBot) Start() {
 		}
 
 		if !found {
-			t.bot.Send(m.Sender, fmt.Sprintf("You are not subscribed to %s", symbol))
+			t.send(m.Sender, fmt.Sprintf("You are not subscribed to %s", symbol))
 			return
 		}
 
 		// Delete from database
 		err := t.db.DeleteTelegramCryptoSubscription(m.Sender.ID, symbol)
 		if err != nil {
-			log.Printf("Error deleting crypto subscription from database: %v", err)
-			t.bot.Send(m.Sender, "Failed to unsubscribe. Please try again later.")
+			logger.Error("deleting crypto subscription failed", "chat_id", m.Sender.ID, "symbol", symbol, "error", err)
+			t.send(m.Sender, "Failed to unsubscribe. Please try again later.")
 			return
 		}
 
 		t.cryptoSubs[m.Sender.ID] = newSymbols
-		t.bot.Send(m.Sender, fmt.Sprintf("You have successfully unsubscribed from %s", symbol))
+		t.send(m.Sender, fmt.Sprintf("You have successfully unsubscribed from %s", symbol))
 	})
 
 	// Handle /crypto_list command
@@ -413,13 +511,13 @@ func (t *TelegramBot) Start() {
 		// Get crypto subscriptions from database to ensure we have the latest data
 		symbols, err := t.db.GetTelegramCryptoSubscriptions(m.Sender.ID)
 		if err != nil {
-			log.Printf("Error getting crypto subscriptions from database: %v", err)
-			t.bot.Send(m.Sender, "Failed to retrieve your cryptocurrency subscriptions. Please try again later.")
+			logger.Error("loading crypto subscriptions failed", "chat_id", m.Sender.ID, "error", err)
+			t.send(m.Sender, "Failed to retrieve your cryptocurrency subscriptions. Please try again later.")
 			return
 		}
 
 		if len(symbols) == 0 {
-			t.bot.Send(m.Sender, "You don't have any cryptocurrency subscriptions")
+			t.send(m.Sender, "You don't have any cryptocurrency subscriptions")
 			return
 		}
 
@@ -428,7 +526,7 @@ func (t *TelegramBot) Start() {
 			msg += "- " + s + "\n"
 		}
 
-		t.bot.Send(m.Sender, msg)
+		t.send(m.Sender, msg)
 
 		// Update in-memory cache
 		t.mu.Lock()
@@ -440,7 +538,7 @@ func (t *TelegramBot) Start() {
 	t.bot.Handle("/crypto_rate", func(m *telebot.Message) {
 		args := strings.Fields(m.Text)
 		if len(args) < 2 {
-			t.bot.Send(m.Sender, "Please specify a cryptocurrency symbol. Example: /crypto_rate BTC")
+			t.send(m.Sender, "Please specify a cryptocurrency symbol. Example: /crypto_rate BTC")
 			return
 		}
 
@@ -450,7 +548,7 @@ func (t *TelegramBot) Start() {
 		client := binance.NewClient()
 		rate, err := client.GetCurrentCryptoToRubRate(symbol)
 		if err != nil {
-			t.bot.Send(m.Sender, fmt.Sprintf("Error getting rate for %s: %v", symbol, err))
+			t.send(m.Sender, fmt.Sprintf("Error getting rate for %s: %v", symbol, err))
 			return
 		}
 
@@ -475,23 +573,346 @@ func (t *TelegramBot) Start() {
 
 		// Format the message
 		msg := fmt.Sprintf("Cryptocurrency: %s (%s)\n", cryptoName, symbol)
-		msg += fmt.Sprintf("Current rate: %.2f RUB\n", rate.Close)
-		msg += fmt.Sprintf("24h High: %.2f RUB\n", rate.High)
-		msg += fmt.Sprintf("24h Low: %.2f RUB", rate.Low)
+		msg += fmt.Sprintf("Current rate: %s RUB\n", rate.Close.StringFixed(2))
+		msg += fmt.Sprintf("24h High: %s RUB\n", rate.High.StringFixed(2))
+		msg += fmt.Sprintf("24h Low: %s RUB", rate.Low.StringFixed(2))
+
+		t.send(m.Sender, msg)
+	})
+
+	// Handle /metals command
+	t.bot.Handle("/metals", func(m *telebot.Message) {
+		prices, err := currency.GetLatestMetalPrices()
+		if err != nil {
+			logger.Warn("metal prices fetch failed", "error", err)
+			t.send(m.Sender, "Failed to fetch metal prices. Please try again later.")
+			return
+		}
+		if len(prices) == 0 {
+			t.send(m.Sender, "No metal prices available right now.")
+			return
+		}
+
+		msg := fmt.Sprintf("🪙 CBR precious metals prices (%s), RUB per gram:\n\n", prices[0].Date.Format("02.01.2006"))
+		for _, p := range prices {
+			msg += fmt.Sprintf("%s (%s): %s\n", p.Name, p.Metal, p.Buy.StringFixed(2))
+		}
+		t.send(m.Sender, msg)
+	})
+
+	// Handle /metals_subscribe command
+	t.bot.Handle("/metals_subscribe", func(m *telebot.Message) {
+		args := strings.Fields(m.Text)
+		metal, ok := metalArg(args)
+		if !ok {
+			t.send(m.Sender, "Please specify a metal: XAU, XAG, XPT, XPD or gold, silver, platinum, palladium. Example: /metals_subscribe XAU")
+			return
+		}
+
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		for _, s := range t.metalSubs[m.Sender.ID] {
+			if s == metal {
+				t.send(m.Sender, fmt.Sprintf("You are already subscribed to %s", metal))
+				return
+			}
+		}
+
+		if err := t.db.SaveTelegramMetalSubscription(m.Sender.ID, metal); err != nil {
+			logger.Error("saving metal subscription failed", "chat_id", m.Sender.ID, "metal", metal, "error", err)
+			t.send(m.Sender, "Failed to save subscription. Please try again later.")
+			return
+		}
+
+		t.metalSubs[m.Sender.ID] = append(t.metalSubs[m.Sender.ID], metal)
+		t.send(m.Sender, fmt.Sprintf("You have successfully subscribed to %s", metal))
+	})
+
+	// Handle /metals_unsubscribe command
+	t.bot.Handle("/metals_unsubscribe", func(m *telebot.Message) {
+		args := strings.Fields(m.Text)
+		metal, ok := metalArg(args)
+		if !ok {
+			t.send(m.Sender, "Please specify a metal. Example: /metals_unsubscribe XAU")
+			return
+		}
+
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		found := false
+		newMetals := []string{}
+		for _, s := range t.metalSubs[m.Sender.ID] {
+			if s != metal {
+				newMetals = append(newMetals, s)
+			} else {
+				found = true
+			}
+		}
+
+		if !found {
+			t.send(m.Sender, fmt.Sprintf("You are not subscribed to %s", metal))
+			return
+		}
+
+		if err := t.db.DeleteTelegramMetalSubscription(m.Sender.ID, metal); err != nil {
+			logger.Error("deleting metal subscription failed", "chat_id", m.Sender.ID, "metal", metal, "error", err)
+			t.send(m.Sender, "Failed to unsubscribe. Please try again later.")
+			return
+		}
+
+		t.metalSubs[m.Sender.ID] = newMetals
+		t.send(m.Sender, fmt.Sprintf("You have successfully unsubscribed from %s", metal))
+	})
 
-		t.bot.Send(m.Sender, msg)
+	// Handle /keyrate command
+	t.bot.Handle("/keyrate", func(m *telebot.Message) {
+		today := calendar.Today()
+		msg := ""
+		for _, code := range []string{currency.IndicatorKeyRate, currency.IndicatorRUONIA} {
+			rates, err := currency.GetIndicatorRates(code, today.AddDate(0, 0, 1-currency.IndicatorsLookbackDays), today)
+			if err != nil {
+				logger.Warn("indicator rates fetch failed", "indicator", code, "error", err)
+				continue
+			}
+			if latest, ok := latestIndicatorRate(rates); ok {
+				msg += fmt.Sprintf("🏦 %s: %s%% (%s)\n", latest.Name, latest.Value.StringFixed(2), rates[len(rates)-1].Date.Format("02.01.2006"))
+			}
+		}
+		if msg == "" {
+			t.send(m.Sender, "Failed to fetch the key rate. Please try again later.")
+			return
+		}
+		t.send(m.Sender, msg)
+	})
+
+	// Handle /keyrate_subscribe command
+	t.bot.Handle("/keyrate_subscribe", func(m *telebot.Message) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		for _, s := range t.indicatorSubs[m.Sender.ID] {
+			if s == currency.IndicatorKeyRate {
+				t.send(m.Sender, "You are already subscribed to key rate changes")
+				return
+			}
+		}
+
+		if err := t.db.SaveTelegramIndicatorSubscription(m.Sender.ID, currency.IndicatorKeyRate); err != nil {
+			logger.Error("saving indicator subscription failed", "chat_id", m.Sender.ID, "indicator", currency.IndicatorKeyRate, "error", err)
+			t.send(m.Sender, "Failed to save subscription. Please try again later.")
+			return
+		}
+
+		t.indicatorSubs[m.Sender.ID] = append(t.indicatorSubs[m.Sender.ID], currency.IndicatorKeyRate)
+		t.send(m.Sender, "You will be notified when the CBR key rate changes")
+	})
+
+	// Handle /keyrate_unsubscribe command
+	t.bot.Handle("/keyrate_unsubscribe", func(m *telebot.Message) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		found := false
+		newIndicators := []string{}
+		for _, s := range t.indicatorSubs[m.Sender.ID] {
+			if s != currency.IndicatorKeyRate {
+				newIndicators = append(newIndicators, s)
+			} else {
+				found = true
+			}
+		}
+
+		if !found {
+			t.send(m.Sender, "You are not subscribed to key rate changes")
+			return
+		}
+
+		if err := t.db.DeleteTelegramIndicatorSubscription(m.Sender.ID, currency.IndicatorKeyRate); err != nil {
+			logger.Error("deleting indicator subscription failed", "chat_id", m.Sender.ID, "indicator", currency.IndicatorKeyRate, "error", err)
+			t.send(m.Sender, "Failed to unsubscribe. Please try again later.")
+			return
+		}
+
+		t.indicatorSubs[m.Sender.ID] = newIndicators
+		t.send(m.Sender, "You have successfully unsubscribed from key rate changes")
+	})
+
+	// Handle /alert_subscribe command
+	t.bot.Handle("/alert_subscribe", func(m *telebot.Message) {
+		args := strings.Fields(m.Text)
+		a, err := indicators.ParseAlert(strings.Join(args[1:], " "))
+		if err != nil {
+			t.send(m.Sender, fmt.Sprintf("%v\nUsage: /alert_subscribe BTC 1h rsi14>70", err))
+			return
+		}
+		alert := a.String()
+
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		for _, s := range t.alertSubs[m.Sender.ID] {
+			if s == alert {
+				t.send(m.Sender, fmt.Sprintf("You are already subscribed to %s", alert))
+				return
+			}
+		}
+
+		if err := t.db.SaveTelegramAlertSubscription(m.Sender.ID, alert); err != nil {
+			logger.Error("saving alert subscription failed", "chat_id", m.Sender.ID, "alert", alert, "error", err)
+			t.send(m.Sender, "Failed to save subscription. Please try again later.")
+			return
+		}
+
+		t.alertSubs[m.Sender.ID] = append(t.alertSubs[m.Sender.ID], alert)
+		t.send(m.Sender, fmt.Sprintf("You will be notified when %s starts to hold", alert))
+	})
+
+	// Handle /alert_unsubscribe command
+	t.bot.Handle("/alert_unsubscribe", func(m *telebot.Message) {
+		args := strings.Fields(m.Text)
+		a, err := indicators.ParseAlert(strings.Join(args[1:], " "))
+		if err != nil {
+			t.send(m.Sender, fmt.Sprintf("%v\nUsage: /alert_unsubscribe BTC 1h rsi14>70", err))
+			return
+		}
+		alert := a.String()
+
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		found := false
+		newAlerts := []string{}
+		for _, s := range t.alertSubs[m.Sender.ID] {
+			if s != alert {
+				newAlerts = append(newAlerts, s)
+			} else {
+				found = true
+			}
+		}
+
+		if !found {
+			t.send(m.Sender, fmt.Sprintf("You are not subscribed to %s", alert))
+			return
+		}
+
+		if err := t.db.DeleteTelegramAlertSubscription(m.Sender.ID, alert); err != nil {
+			logger.Error("deleting alert subscription failed", "chat_id", m.Sender.ID, "alert", alert, "error", err)
+			t.send(m.Sender, "Failed to unsubscribe. Please try again later.")
+			return
+		}
+
+		t.alertSubs[m.Sender.ID] = newAlerts
+		delete(t.alertsMet[alert], m.Sender.ID)
+		t.send(m.Sender, fmt.Sprintf("You have successfully unsubscribed from %s", alert))
 	})
 
 	// Start the bot
 	go t.bot.Start()
 }
 
+// latestIndicatorRate returns the newest of rates, ordered by date, dated by
+// the first day of the run of equal values it ends: the day it took effect,
+// as far as rates reach back
+func latestIndicatorRate(rates []currency.IndicatorRate) (currency.IndicatorRate, bool) {
+	if len(rates) == 0 {
+		return currency.IndicatorRate{}, false
+	}
+	latest := rates[len(rates)-1]
+	for i := len(rates) - 2; i >= 0 && rates[i].Value.Equal(latest.Value); i-- {
+		latest.Date = rates[i].Date
+	}
+	return latest, true
+}
+
+// keyRateMessage formats the notification of a key rate change from
+// previous
+func keyRateMessage(previous money.Decimal, rate currency.IndicatorRate) string {
+	return fmt.Sprintf("🏦 CBR key rate changed from %s%% to %s%% from %s",
+		previous.StringFixed(2), rate.Value.StringFixed(2), rate.Date.Format("02.01.2006"))
+}
+
+// AnnounceKeyRateChange sends key rate subscribers the new key rate when it
+// differs from the one seen by the previous call. The first call only
+// records the key rate, so that a restart does not repeat an announcement
+func (t *TelegramBot) AnnounceKeyRateChange() {
+	today := calendar.Today()
+	rates, err := currency.GetIndicatorRates(currency.IndicatorKeyRate, today.AddDate(0, 0, 1-currency.IndicatorsLookbackDays), today)
+	if err != nil {
+		logger.Warn("key rate fetch failed", "error", err)
+		return
+	}
+	latest, ok := latestIndicatorRate(rates)
+	if !ok {
+		return
+	}
+	previous := t.keyRate
+	t.keyRate = latest
+	if previous.Value.IsZero() || latest.Value.Equal(previous.Value) {
+		return
+	}
+
+	indicatorSubs, err := t.db.GetAllTelegramIndicatorSubscriptions()
+	if err != nil {
+		logger.Error("refreshing indicator subscriptions failed", "error", err)
+	} else {
+		t.mu.Lock()
+		t.indicatorSubs = indicatorSubs
+		t.mu.Unlock()
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	logger.Info("announcing key rate change", "from", previous.Value.String(), "to", latest.Value.String(), "effective_on", latest.Date.Format(time.DateOnly))
+	msg := keyRateMessage(previous.Value, latest)
+	for userID, indicators := range t.indicatorSubs {
+		for _, code := range indicators {
+			if code != currency.IndicatorKeyRate {
+				continue
+			}
+			user := &telebot.User{ID: userID}
+			if err := t.send(user, msg); err != nil {
+				logger.Warn("telegram send failed", "chat_id", userID, "error", err)
+			}
+		}
+	}
+}
+
+// metalArg returns the ISO code of the metal named by args[1], by code or
+// English name
+func metalArg(args []string) (string, bool) {
+	if len(args) < 2 {
+		return "", false
+	}
+	metal, ok := currency.MetalByCode(args[1])
+	return metal.Code, ok
+}
+
+// metalsMessage formats the daily update of the subscribed metals, or
+// returns an empty string when prices has none of them
+func metalsMessage(prices []currency.MetalPrice, metals []string) string {
+	var lines string
+	for _, p := range prices {
+		for _, metal := range metals {
+			if p.Metal == metal {
+				lines += fmt.Sprintf("🪙 %s (%s): %s RUB per gram\n", p.Name, p.Metal, p.Buy.StringFixed(2))
+			}
+		}
+	}
+	if lines == "" {
+		return ""
+	}
+	return fmt.Sprintf("🪙 CBR Precious Metals Prices on %s 🪙\n\n", prices[0].Date.Format("02.01.2006")) + lines
+}
+
 // SendDailyUpdates sends daily updates to all subscribers
 func (t *TelegramBot) SendDailyUpdates() {
 	// Refresh subscriptions from database
 	subscriptions, err := t.db.GetAllTelegramSubscriptions()
 	if err != nil {
-		log.Printf("Error refreshing subscriptions from database: %v", err)
+		logger.Error("refreshing subscriptions failed", "error", err)
 	} else {
 		t.mu.Lock()
 		t.subscriptions = subscriptions
@@ -501,13 +922,23 @@ func (t *TelegramBot) SendDailyUpdates() {
 	// Refresh crypto subscriptions from database
 	cryptoSubs, err := t.db.GetAllTelegramCryptoSubscriptions()
 	if err != nil {
-		log.Printf("Error refreshing crypto subscriptions from database: %v", err)
+		logger.Error("refreshing crypto subscriptions failed", "error", err)
 	} else {
 		t.mu.Lock()
 		t.cryptoSubs = cryptoSubs
 		t.mu.Unlock()
 	}
 
+	// Refresh metal subscriptions from database
+	metalSubs, err := t.db.GetAllTelegramMetalSubscriptions()
+	if err != nil {
+		logger.Error("refreshing metal subscriptions failed", "error", err)
+	} else {
+		t.mu.Lock()
+		t.metalSubs = metalSubs
+		t.mu.Unlock()
+	}
+
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 
@@ -524,29 +955,18 @@ func (t *TelegramBot) SendDailyUpdates() {
 			// Get current rate
 			rate, err := currency.GetCurrencyRate(code, "")
 			if err != nil {
-				log.Printf("Error getting rate for %s: %v", code, err)
+				logger.Warn("rate fetch failed", "currency", code, "error", err)
 				continue
 			}
 
-			// Calculate change percentage
-			changePercent := ((rate.Value - rate.Previous) / rate.Previous) * 100
-			changeEmoji := "🔄"
-			if changePercent > 0 {
-				changeEmoji = "📈"
-			} else if changePercent < 0 {
-				changeEmoji = "📉"
-			}
-
-			// Add to message
-			msg += fmt.Sprintf("%s %s (%s): %.4f RUB (%.2f%%)\n",
-				changeEmoji, rate.Name, rate.CharCode, rate.Value, changePercent)
+			msg += rateChangeLine(*rate)
 		}
 
 		// Send message
 		user := &telebot.User{ID: userID}
-		_, err := t.bot.Send(user, msg)
+		err := t.send(user, msg)
 		if err != nil {
-			log.Printf("Error sending message to user %d: %v", userID, err)
+			logger.Warn("telegram send failed", "chat_id", userID, "error", err)
 		}
 	}
 
@@ -565,11 +985,11 @@ func (t *TelegramBot) SendDailyUpdates() {
 			// Get current rate
 			rate, err := client.GetCurrentCryptoToRubRate(symbol)
 			if err != nil {
-				log.Printf("Error getting crypto rate for %s: %v", symbol, err)
+				logger.Warn("crypto rate fetch failed", "symbol", symbol, "error", err)
 				continue
 			}
 
-			log.Printf("SendDailyUpdates: Got rate for %s: Close=%.2f RUB", symbol, rate.Close)
+			logger.Debug("crypto rate for daily update", "symbol", symbol, "price_rub", rate.Close)
 
 			// Get crypto name
 			cryptoNames := map[string]string{
@@ -591,17 +1011,109 @@ func (t *TelegramBot) SendDailyUpdates() {
 			}
 
 			// Add to message
-			msg += fmt.Sprintf("💲 %s (%s): %.2f RUB\n",
-				cryptoName, symbol, rate.Close)
+			msg += fmt.Sprintf("💲 %s (%s): %s RUB\n",
+				cryptoName, symbol, rate.Close.StringFixed(2))
 		}
 
 		// Send message
 		user := &telebot.User{ID: userID}
-		_, err := t.bot.Send(user, msg)
+		err := t.send(user, msg)
 		if err != nil {
-			log.Printf("Error sending crypto message to user %d: %v", userID, err)
+			logger.Warn("telegram send failed", "chat_id", userID, "error", err)
+		}
+	}
+
+	// Send precious metals updates
+	if len(t.metalSubs) == 0 {
+		return
+	}
+	prices, err := currency.GetLatestMetalPrices()
+	if err != nil {
+		logger.Warn("metal prices fetch failed", "error", err)
+		return
+	}
+	for userID, metals := range t.metalSubs {
+		msg := metalsMessage(prices, metals)
+		if msg == "" {
+			continue
+		}
+
+		user := &telebot.User{ID: userID}
+		if err := t.send(user, msg); err != nil {
+			logger.Warn("telegram send failed", "chat_id", userID, "error", err)
+		}
+	}
+}
+
+// rateChangeLine formats a CBR rate with its change against the previous one
+func rateChangeLine(rate currency.Valute) string {
+	// Calculate change percentage
+	changePercent := rate.Value.Sub(rate.Previous).Div(rate.Previous, 6).Float64() * 100
+	changeEmoji := "🔄"
+	if changePercent > 0 {
+		changeEmoji = "📈"
+	} else if changePercent < 0 {
+		changeEmoji = "📉"
+	}
+
+	return fmt.Sprintf("%s %s (%s): %s RUB (%.2f%%)\n",
+		changeEmoji, rate.Name, rate.CharCode, rate.Value.StringFixed(4), changePercent)
+}
+
+// AnnounceNextDayRates sends currency subscribers the rates the CBR has
+// published for the next day, once per effective date, and returns the
+// effective date of the latest sheet. The first call only records that
+// date, so that a restart does not repeat an announcement
+func (t *TelegramBot) AnnounceNextDayRates() time.Time {
+	rates, err := currency.GetLatestCBRRates()
+	if err != nil {
+		logger.Warn("next-day rates fetch failed", "error", err)
+		return t.announcedThrough
+	}
+	effective := rates.EffectiveOn()
+	first := t.announcedThrough.IsZero()
+	if !effective.After(t.announcedThrough) {
+		return t.announcedThrough
+	}
+	t.announcedThrough = effective
+	if first || !effective.After(calendar.Today()) {
+		return effective
+	}
+
+	subscriptions, err := t.db.GetAllTelegramSubscriptions()
+	if err != nil {
+		logger.Error("refreshing subscriptions failed", "error", err)
+	} else {
+		t.mu.Lock()
+		t.subscriptions = subscriptions
+		t.mu.Unlock()
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	logger.Info("announcing next-day rates", "effective_on", effective.Format(time.DateOnly), "subscribers", len(t.subscriptions))
+	for userID, currencies := range t.subscriptions {
+		msg := fmt.Sprintf("🆕 CBR rates from %s 🆕\n\n", effective.Format("02.01.2006"))
+		found := false
+		for _, code := range currencies {
+			rate, ok := rates.Valute[code]
+			if !ok {
+				continue
+			}
+			msg += rateChangeLine(rate)
+			found = true
+		}
+		if !found {
+			continue
+		}
+
+		user := &telebot.User{ID: userID}
+		if err := t.send(user, msg); err != nil {
+			logger.Warn("telegram send failed", "chat_id", userID, "error", err)
 		}
 	}
+	return effective
 }
 
 // SendCryptoUpdates sends 15-minute crypto updates to all subscribers
@@ -609,7 +1121,7 @@ func (t *TelegramBot) SendCryptoUpdates() {
 	// Refresh crypto subscriptions from database
 	cryptoSubs, err := t.db.GetAllTelegramCryptoSubscriptions()
 	if err != nil {
-		log.Printf("Error refreshing crypto subscriptions from database: %v", err)
+		logger.Error("refreshing crypto subscriptions failed", "error", err)
 		// Continue with in-memory cache if available
 	} else {
 		t.mu.Lock()
@@ -639,7 +1151,7 @@ func (t *TelegramBot) SendCryptoUpdates() {
 	for symbol := range allSymbols {
 		rate, err := client.GetCurrentCryptoToRubRate(symbol)
 		if err != nil {
-			log.Printf("Error getting crypto rate for %s: %v", symbol, err)
+			logger.Warn("crypto rate fetch failed", "symbol", symbol, "error", err)
 			continue
 		}
 		currentPrices[symbol] = rate
@@ -694,7 +1206,7 @@ func (t *TelegramBot) SendCryptoUpdates() {
 
 			if hadPrevious {
 				// Calculate change percentage
-				changePercent := ((currentPrice - lastPrice) / lastPrice) * 100
+				changePercent := currentPrice.Sub(lastPrice).Div(lastPrice, 6).Float64() * 100
 				changeEmoji := "🔄"
 
 				// Only show notification if change is significant (>= 2%)
@@ -716,12 +1228,12 @@ func (t *TelegramBot) SendCryptoUpdates() {
 				}
 
 				// Add to message
-				msg += fmt.Sprintf("%s %s (%s): %.2f ₽ (%+.2f%%)\n",
-					changeEmoji, cryptoName, symbol, currentPrice, changePercent)
+				msg += fmt.Sprintf("%s %s (%s): %s ₽ (%+.2f%%)\n",
+					changeEmoji, cryptoName, symbol, currentPrice.StringFixed(2), changePercent)
 			} else {
 				// First time seeing this crypto
-				msg += fmt.Sprintf("💲 %s (%s): %.2f ₽ (new)\n",
-					cryptoName, symbol, currentPrice)
+				msg += fmt.Sprintf("💲 %s (%s): %s ₽ (new)\n",
+					cryptoName, symbol, currentPrice.StringFixed(2))
 				hasSignificantChange = true
 			}
 
@@ -732,9 +1244,9 @@ func (t *TelegramBot) SendCryptoUpdates() {
 		// Only send message if there are significant changes (>= 2%) or it's first time
 		if hasSignificantChange {
 			user := &telebot.User{ID: userID}
-			_, err := t.bot.Send(user, msg)
+			err := t.send(user, msg)
 			if err != nil {
-				log.Printf("Error sending crypto update to user %d: %v", userID, err)
+				logger.Warn("telegram send failed", "chat_id", userID, "error", err)
 			}
 		}
 	}
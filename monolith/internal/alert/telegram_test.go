@@ -5,6 +5,8 @@ import (
 	"testing"
 	"time"
 
+	currency "github.com/casualdoto/go-currency-tracker/internal/currency/cbr"
+	"github.com/casualdoto/go-currency-tracker/internal/money"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -471,3 +473,43 @@ func BenchmarkMockTelegramBot_ReadSubscriptions(b *testing.B) {
 		bot.mu.RUnlock()
 	}
 }
+
+// Testing metal arguments and the daily metals message
+func TestMetalsMessage(t *testing.T) {
+	for _, args := range [][]string{{"/metals_subscribe", "XAU"}, {"/metals_subscribe", "gold"}} {
+		metal, ok := metalArg(args)
+		assert.True(t, ok)
+		assert.Equal(t, "XAU", metal)
+	}
+	_, ok := metalArg([]string{"/metals_subscribe"})
+	assert.False(t, ok)
+	_, ok = metalArg([]string{"/metals_subscribe", "BTC"})
+	assert.False(t, ok)
+
+	day := time.Date(2026, 4, 15, 0, 0, 0, 0, time.UTC)
+	prices := []currency.MetalPrice{
+		{Date: day, Metal: "XAG", Name: "Silver", Buy: money.MustParse("96.84")},
+		{Date: day, Metal: "XAU", Name: "Gold", Buy: money.MustParse("8540.1")},
+	}
+	assert.Equal(t, "🪙 CBR Precious Metals Prices on 15.04.2026 🪙\n\n🪙 Gold (XAU): 8540.10 RUB per gram\n",
+		metalsMessage(prices, []string{"XAU"}))
+	assert.Empty(t, metalsMessage(prices, []string{"XPD"}))
+}
+
+// Testing the key rate change message and the date a rate took effect
+func TestKeyRateMessage(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 4, d, 0, 0, 0, 0, time.UTC) }
+	rates := []currency.IndicatorRate{
+		{Date: day(10), Indicator: currency.IndicatorKeyRate, Value: money.MustParse("16")},
+		{Date: day(13), Indicator: currency.IndicatorKeyRate, Value: money.MustParse("15.5")},
+		{Date: day(14), Indicator: currency.IndicatorKeyRate, Value: money.MustParse("15.5")},
+	}
+	latest, ok := latestIndicatorRate(rates)
+	assert.True(t, ok)
+	assert.Equal(t, day(13), latest.Date)
+	assert.Equal(t, "🏦 CBR key rate changed from 16.00% to 15.50% from 13.04.2026",
+		keyRateMessage(money.MustParse("16"), latest))
+
+	_, ok = latestIndicatorRate(nil)
+	assert.False(t, ok)
+}
@@ -0,0 +1,149 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/internal/money"
+	"github.com/casualdoto/go-currency-tracker/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var day0 = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// Builds a series with evenly spaced points starting at start
+func series(start time.Time, step time.Duration, values ...float64) []Point {
+	pts := make([]Point, len(values))
+	for i, v := range values {
+		pts[i] = Point{Time: start.Add(time.Duration(i) * step), Value: v}
+	}
+	return pts
+}
+
+// TestSummarize checks mean, min/max, stddev, change and drawdown
+func TestSummarize(t *testing.T) {
+	s := Summarize(series(day0, 24*time.Hour, 100, 110, 99, 105))
+
+	assert.Equal(t, 4, s.Count)
+	assert.Equal(t, 100.0, s.First)
+	assert.Equal(t, 105.0, s.Last)
+	assert.Equal(t, 103.5, s.Mean)
+	assert.Equal(t, 99.0, s.Min)
+	assert.Equal(t, 110.0, s.Max)
+	assert.InDelta(t, 5, s.ChangePct, 1e-9)
+	// Peak 110 to trough 99 is a 10% drawdown
+	assert.InDelta(t, 10, s.MaxDrawdown, 1e-9)
+	assert.InDelta(t, math.Sqrt((12.25+42.25+20.25+2.25)/4), s.StdDev, 1e-9)
+	assert.InDelta(t, s.StdDev/s.Mean*100, s.CoefficientOfVariation, 1e-9)
+	assert.Greater(t, s.Volatility, 0.0)
+}
+
+// TestSummarize_emptyAndConstant checks degenerate series
+func TestSummarize_emptyAndConstant(t *testing.T) {
+	assert.Equal(t, 0, Summarize(nil).Count)
+
+	s := Summarize(series(day0, 24*time.Hour, 90, 90, 90))
+	assert.Zero(t, s.StdDev)
+	assert.Zero(t, s.Volatility)
+	assert.Zero(t, s.MaxDrawdown)
+	assert.Zero(t, s.ChangePct)
+}
+
+// TestSummarize_volatilityUsesDailyCloses checks that intraday candles are
+// collapsed to one close per day before returns are computed
+func TestSummarize_volatilityUsesDailyCloses(t *testing.T) {
+	intraday := []Point{
+		{Time: day0.Add(1 * time.Hour), Value: 50},
+		{Time: day0.Add(23 * time.Hour), Value: 100},
+		{Time: day0.Add(25 * time.Hour), Value: 500},
+		{Time: day0.Add(47 * time.Hour), Value: 110},
+		{Time: day0.Add(71 * time.Hour), Value: 121},
+	}
+	daily := series(day0, 24*time.Hour, 100, 110, 121)
+
+	assert.InDelta(t, Summarize(daily).Volatility, Summarize(intraday).Volatility, 1e-9)
+}
+
+// TestCorrelate checks the matrix for perfectly correlated and inverse series
+func TestCorrelate(t *testing.T) {
+	a := series(day0, 24*time.Hour, 100, 110, 99, 105, 120)
+	b := series(day0, 24*time.Hour, 50, 55, 49.5, 52.5, 60)
+	c := make([]Point, len(a))
+	for i, p := range a {
+		c[i] = Point{Time: p.Time, Value: 10000 / p.Value}
+	}
+
+	m := Correlate([]string{"A", "B", "C"}, map[string][]Point{"A": a, "B": b, "C": c})
+
+	assert.Equal(t, []string{"A", "B", "C"}, m.Codes)
+	assert.Equal(t, 4, m.Observations)
+	assert.Equal(t, 1.0, m.Matrix[0][0])
+	assert.InDelta(t, 1, m.Matrix[0][1], 1e-9)
+	assert.InDelta(t, -1, m.Matrix[0][2], 1e-9)
+	assert.Equal(t, m.Matrix[1][2], m.Matrix[2][1])
+}
+
+// TestCorrelate_alignsCommonDays checks that only days present in every series are used
+func TestCorrelate_alignsCommonDays(t *testing.T) {
+	a := series(day0, 24*time.Hour, 100, 110, 99, 105)
+	b := series(day0.AddDate(0, 0, 1), 24*time.Hour, 55, 49.5, 52.5, 60)
+
+	m := Correlate([]string{"A", "B"}, map[string][]Point{"A": a, "B": b})
+
+	assert.Equal(t, 2, m.Observations)
+	assert.InDelta(t, 1, m.Matrix[0][1], 1e-9)
+}
+
+// Stub store returning fixed rows
+type stubStore struct {
+	fiat   []storage.CurrencyRate
+	crypto []storage.CryptoRate
+}
+
+func (s *stubStore) GetCurrencyRatesByDateRange(code string, start, end time.Time) ([]storage.CurrencyRate, error) {
+	var out []storage.CurrencyRate
+	for _, r := range s.fiat {
+		if r.CurrencyCode == code {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func (s *stubStore) GetCryptoRatesByDateRange(symbol string, start, end time.Time) ([]storage.CryptoRate, error) {
+	var out []storage.CryptoRate
+	for _, r := range s.crypto {
+		if r.Symbol == symbol {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+// TestLoadSeries checks fiat per-unit values, crypto fallback and missing data
+func TestLoadSeries(t *testing.T) {
+	store := &stubStore{
+		fiat: []storage.CurrencyRate{
+			{CurrencyCode: "JPY", Date: day0, Nominal: 100, Value: money.MustParse("60")},
+		},
+		crypto: []storage.CryptoRate{
+			{Symbol: "BTC/RUB", Timestamp: day0, Close: money.MustParse("6000000")},
+			{Symbol: "BTC/RUB", Timestamp: day0.Add(time.Hour)},
+		},
+	}
+
+	points, source, err := LoadSeries(store, "", "jpy", day0, day0)
+	require.NoError(t, err)
+	assert.Equal(t, SourceCBR, source)
+	assert.Equal(t, []Point{{Time: day0, Value: 0.6}}, points)
+
+	points, source, err = LoadSeries(store, "", "BTC", day0, day0)
+	require.NoError(t, err)
+	assert.Equal(t, SourceCrypto, source)
+	assert.Equal(t, []Point{{Time: day0, Value: 6000000}}, points)
+
+	_, _, err = LoadSeries(store, SourceCBR, "BTC", day0, day0)
+	assert.ErrorIs(t, err, ErrNoData)
+}
@@ -0,0 +1,68 @@
+package analytics
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/internal/storage"
+)
+
+// Series sources
+const (
+	SourceCBR    = "cbr"
+	SourceCrypto = "crypto"
+)
+
+// ErrNoData is returned when a code has no stored rates in the range
+var ErrNoData = errors.New("no data")
+
+// Store is the subset of storage.PostgresDB used to load series
+type Store interface {
+	GetCurrencyRatesByDateRange(code string, startDate, endDate time.Time) ([]storage.CurrencyRate, error)
+	GetCryptoRatesByDateRange(symbol string, startTime, endTime time.Time) ([]storage.CryptoRate, error)
+}
+
+// LoadSeries loads the RUB series of code between start and end (inclusive
+// days). CBR values are per single unit (UnitValue), crypto values are the
+// stored RUB close of code+"/RUB". With an empty source, CBR is tried first
+// and crypto second; the source actually used is returned.
+func LoadSeries(store Store, source, code string, start, end time.Time) ([]Point, string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+
+	if source == "" || source == SourceCBR {
+		rates, err := store.GetCurrencyRatesByDateRange(code, start, end)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(rates) > 0 {
+			points := make([]Point, 0, len(rates))
+			for _, r := range rates {
+				points = append(points, Point{Time: r.Date, Value: r.PerUnit().Float64()})
+			}
+			return points, SourceCBR, nil
+		}
+	}
+
+	if source == "" || source == SourceCrypto {
+		symbol := strings.TrimSuffix(code, "/RUB") + "/RUB"
+		endOfDay := end.AddDate(0, 0, 1).Add(-time.Second)
+		rates, err := store.GetCryptoRatesByDateRange(symbol, start, endOfDay)
+		if err != nil {
+			return nil, "", err
+		}
+		points := make([]Point, 0, len(rates))
+		for _, r := range rates {
+			if r.Close.IsPositive() {
+				points = append(points, Point{Time: r.Timestamp, Value: r.Close.Float64()})
+			}
+		}
+		if len(points) > 0 {
+			return points, SourceCrypto, nil
+		}
+	}
+
+	return nil, "", fmt.Errorf("%w for %s between %s and %s", ErrNoData, code,
+		start.Format("2006-01-02"), end.Format("2006-01-02"))
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/casualdoto/go-currency-tracker/internal/coverage"
+	"github.com/casualdoto/go-currency-tracker/internal/storage"
+)
+
+// CoverageHandler reports the business days missing from currency_rates or
+// the candles missing from crypto_rates within a date range.
+// Requires query parameters source (cbr or crypto), from and to (YYYY-MM-DD);
+// code is required for crypto (e.g. BTCUSDT) and optional for cbr, where it
+// defaults to any currency. interval (1m, 5m, 15m, 30m, 1h, 4h or 1d, the
+// default) sets the crypto candle size
+func CoverageHandler(w http.ResponseWriter, r *http.Request) {
+	source := r.URL.Query().Get("source")
+	code := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("code")))
+	interval := r.URL.Query().Get("interval")
+	if interval == "" {
+		interval = "1d"
+	}
+
+	from, to, errMsg := parseDateRangeParams(r, "from", "to")
+	if errMsg != "" {
+		writeErrorResponse(w, http.StatusBadRequest, errMsg)
+		return
+	}
+
+	switch source {
+	case coverage.SourceCBR:
+		if interval != "1d" {
+			writeErrorResponse(w, http.StatusBadRequest, "CBR rates are daily, interval must be 1d")
+			return
+		}
+	case coverage.SourceCrypto:
+		if code == "" {
+			writeErrorResponse(w, http.StatusBadRequest, "Symbol not specified (parameter code)")
+			return
+		}
+		if _, ok := coverage.Intervals[interval]; !ok {
+			writeErrorResponse(w, http.StatusBadRequest, "Invalid interval, must be one of 1m, 5m, 15m, 30m, 1h, 4h, 1d")
+			return
+		}
+	default:
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid source parameter, must be cbr or crypto")
+		return
+	}
+
+	db, ok := r.Context().Value("db").(*storage.PostgresDB)
+	if !ok || db == nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Database connection not available")
+		return
+	}
+
+	var report coverage.Report
+	var err error
+	if source == coverage.SourceCBR {
+		report, err = coverage.CBR(r.Context(), db, code, from, to)
+	} else {
+		report, err = coverage.Crypto(r.Context(), db, code, interval, from, to)
+	}
+	if errors.Is(err, coverage.ErrTooLong) {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		logger.ErrorContext(r.Context(), "coverage query failed", "source", source, "code", code, "error", err)
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to query stored history")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encodeJSON(w, APIResponse{Success: true, Data: report})
+}
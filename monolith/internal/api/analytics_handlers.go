@@ -0,0 +1,211 @@
+// Package api provides HTTP request handlers and API route setup.
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/internal/analytics"
+	"github.com/casualdoto/go-currency-tracker/internal/apiv1"
+	"github.com/casualdoto/go-currency-tracker/internal/calendar"
+	"github.com/casualdoto/go-currency-tracker/internal/storage"
+)
+
+// Maximum number of codes accepted by the correlation endpoint
+const maxCorrelationCodes = 20
+
+// AnalyticsResult is the response data of the analytics endpoint
+type AnalyticsResult = apiv1.AnalyticsResult
+
+// AnalyticsHandler returns summary statistics for a currency or cryptocurrency
+// over a date range: mean, min/max, standard deviation, daily log-return
+// volatility, maximum drawdown and percentage change.
+// Requires query parameters code, start_date and end_date (YYYY-MM-DD).
+// Supports optional query parameter source (cbr or crypto); without it CBR
+// rates are tried first and crypto rates second.
+func AnalyticsHandler(w http.ResponseWriter, r *http.Request) {
+	code := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("code")))
+	if code == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "Currency code not specified (parameter code)")
+		return
+	}
+
+	source := r.URL.Query().Get("source")
+	if source != "" && source != analytics.SourceCBR && source != analytics.SourceCrypto {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid source parameter, must be cbr or crypto")
+		return
+	}
+
+	startDate, endDate, errMsg := parseAnalyticsRange(r)
+	if errMsg != "" {
+		writeErrorResponse(w, http.StatusBadRequest, errMsg)
+		return
+	}
+
+	db, ok := r.Context().Value("db").(*storage.PostgresDB)
+	if !ok || db == nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Database connection not available")
+		return
+	}
+
+	points, usedSource, err := analytics.LoadSeries(db, source, code, startDate, endDate)
+	if err != nil {
+		writeAnalyticsLoadError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encodeJSON(w, APIResponse{
+		Success: true,
+		Data: AnalyticsResult{
+			Code:    code,
+			Source:  usedSource,
+			Summary: analytics.Summarize(points),
+		},
+	})
+}
+
+// CorrelationHandler returns the Pearson correlation matrix of daily log
+// returns for a set of currencies and cryptocurrencies over a date range.
+// Requires query parameters codes (comma-separated, e.g. USD,EUR,BTC),
+// start_date and end_date (YYYY-MM-DD).
+func CorrelationHandler(w http.ResponseWriter, r *http.Request) {
+	codes, errMsg := parseCorrelationCodes(r)
+	if errMsg != "" {
+		writeErrorResponse(w, http.StatusBadRequest, errMsg)
+		return
+	}
+
+	startDate, endDate, errMsg := parseAnalyticsRange(r)
+	if errMsg != "" {
+		writeErrorResponse(w, http.StatusBadRequest, errMsg)
+		return
+	}
+
+	db, ok := r.Context().Value("db").(*storage.PostgresDB)
+	if !ok || db == nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Database connection not available")
+		return
+	}
+
+	matrix, err := correlate(db, codes, startDate, endDate)
+	if err != nil {
+		writeAnalyticsLoadError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encodeJSON(w, APIResponse{
+		Success: true,
+		Data:    matrix,
+	})
+}
+
+// parseCorrelationCodes reads the comma-separated codes parameter, upper-casing
+// and de-duplicating it. Returns an error message for the client on failure.
+func parseCorrelationCodes(r *http.Request) ([]string, string) {
+	var codes []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(r.URL.Query().Get("codes"), ",") {
+		code := strings.ToUpper(strings.TrimSpace(part))
+		if code != "" && !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+	}
+	if len(codes) < 2 {
+		return nil, "Parameter codes must list at least two currencies or symbols"
+	}
+	if len(codes) > maxCorrelationCodes {
+		return nil, fmt.Sprintf("Too many codes, at most %d are allowed", maxCorrelationCodes)
+	}
+	return codes, ""
+}
+
+// correlate loads every series (CBR first, crypto second) and correlates them
+func correlate(store analytics.Store, codes []string, startDate, endDate time.Time) (analytics.CorrelationMatrix, error) {
+	series := make(map[string][]analytics.Point, len(codes))
+	for _, code := range codes {
+		points, _, err := analytics.LoadSeries(store, "", code, startDate, endDate)
+		if err != nil {
+			return analytics.CorrelationMatrix{}, err
+		}
+		series[code] = points
+	}
+	return analytics.Correlate(codes, series), nil
+}
+
+// parseAnalyticsRange validates start_date and end_date with the same rules as
+// the history range endpoints. Returns an error message for the client on failure.
+func parseAnalyticsRange(r *http.Request) (time.Time, time.Time, string) {
+	return parseLimitedRange(r, "start_date", "end_date")
+}
+
+// parseLimitedRange validates a date range given by the named parameters and
+// limits it to 365 days. Returns an error message for the client on failure.
+func parseLimitedRange(r *http.Request, startParam, endParam string) (time.Time, time.Time, string) {
+	startDate, endDate, errMsg := parseDateRangeParams(r, startParam, endParam)
+	if errMsg != "" {
+		return time.Time{}, time.Time{}, errMsg
+	}
+	if endDate.Sub(startDate) > 365*24*time.Hour {
+		return time.Time{}, time.Time{}, "Date range cannot exceed 365 days"
+	}
+	return startDate, endDate, ""
+}
+
+// parseDateRange validates start_date and end_date without limiting the range
+// length. Returns an error message for the client on failure.
+func parseDateRange(r *http.Request) (time.Time, time.Time, string) {
+	return parseDateRangeParams(r, "start_date", "end_date")
+}
+
+// parseDateRangeParams validates a date range given by the named parameters
+// in YYYY-MM-DD format. Returns an error message for the client on failure.
+func parseDateRangeParams(r *http.Request, startParam, endParam string) (time.Time, time.Time, string) {
+	startDateStr := r.URL.Query().Get(startParam)
+	endDateStr := r.URL.Query().Get(endParam)
+	if startDateStr == "" || endDateStr == "" {
+		return time.Time{}, time.Time{}, fmt.Sprintf("Both %s and %s parameters are required (format: YYYY-MM-DD)", startParam, endParam)
+	}
+
+	startDate, err := calendar.ParseDate(startDateStr)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Sprintf("Invalid %s format. Use YYYY-MM-DD", startParam)
+	}
+	endDate, err := calendar.ParseDate(endDateStr)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Sprintf("Invalid %s format. Use YYYY-MM-DD", endParam)
+	}
+
+	if startDate.After(endDate) {
+		return time.Time{}, time.Time{}, fmt.Sprintf("%s must be before or equal to %s", startParam, endParam)
+	}
+	return startDate, endDate, ""
+}
+
+// writeAnalyticsLoadError maps series loading errors to 404 or 500
+func writeAnalyticsLoadError(w http.ResponseWriter, err error) {
+	writeErrorResponse(w, analyticsErrorStatus(err), err.Error())
+}
+
+// analyticsErrorStatus returns 404 for ranges without data and 500 otherwise
+func analyticsErrorStatus(err error) int {
+	if errors.Is(err, analytics.ErrNoData) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// writeErrorResponse writes an unsuccessful APIResponse with the given status
+func writeErrorResponse(w http.ResponseWriter, statusCode int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	encodeJSON(w, APIResponse{
+		Success: false,
+		Error:   message,
+	})
+}
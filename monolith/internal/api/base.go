@@ -7,9 +7,14 @@ import (
 	"time"
 
 	currency "github.com/casualdoto/go-currency-tracker/internal/currency/cbr"
+	"github.com/casualdoto/go-currency-tracker/internal/logging"
+	"github.com/casualdoto/go-currency-tracker/internal/metrics"
+	"github.com/casualdoto/go-currency-tracker/internal/money"
 	"github.com/casualdoto/go-currency-tracker/internal/storage"
 )
 
+var logger = logging.For("api")
+
 // CORSMiddleware adds CORS headers to support cross-domain requests.
 // Allows requests from any origin and supports various HTTP methods.
 func CORSMiddleware(next http.Handler) http.Handler {
@@ -17,7 +22,8 @@ func CORSMiddleware(next http.Handler) http.Handler {
 		// Set CORS headers
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS, PUT, DELETE")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID")
 
 		// Handle preflight requests
 		if r.Method == "OPTIONS" {
@@ -30,6 +36,24 @@ func CORSMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// DeprecatedMiddleware marks a legacy route as deprecated in favour of its
+// /v1 successor with the Deprecation and Link response headers.
+func DeprecatedMiddleware(successor string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", "true")
+			w.Header().Set("Link", "<"+successor+">; rel=\"successor-version\"")
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// encodeJSON writes v as the JSON response body, with decimals as JSON
+// numbers when the request asked for them (?decimals=number)
+func encodeJSON(w http.ResponseWriter, v any) error {
+	return json.NewEncoder(w).Encode(money.ForResponse(w, v))
+}
+
 // PingHandler handles requests to check service availability.
 // Returns a simple "pong" response to confirm API is working.
 func PingHandler(w http.ResponseWriter, r *http.Request) {
@@ -39,7 +63,7 @@ func PingHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	encodeJSON(w, response)
 }
 
 // InfoHandler returns information about the service.
@@ -58,26 +82,37 @@ func InfoHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	encodeJSON(w, response)
 }
 
 // Helper function to convert database rates to the same format as CBR API
 func formatDBRatesToValuteMap(rates []storage.CurrencyRate) map[string]currency.Valute {
 	valute := make(map[string]currency.Valute)
 	for _, rate := range rates {
-		valute[rate.CurrencyCode] = currency.Valute{
-			ID:       rate.CurrencyCode,
-			NumCode:  "",
-			CharCode: rate.CurrencyCode,
-			Nominal:  rate.Nominal,
-			Name:     rate.CurrencyName,
-			Value:    rate.Value,
-			Previous: rate.Previous,
-		}
+		valute[rate.CurrencyCode] = valuteFromDB(rate)
 	}
 	return valute
 }
 
+// valuteFromDB converts a database rate to the CBR API format, with the
+// date of the sheet a carried-over rate comes from
+func valuteFromDB(rate storage.CurrencyRate) currency.Valute {
+	v := currency.Valute{
+		ID:        rate.CurrencyCode,
+		NumCode:   "",
+		CharCode:  rate.CurrencyCode,
+		Nominal:   rate.Nominal,
+		Name:      rate.CurrencyName,
+		Value:     rate.Value,
+		Previous:  rate.Previous,
+		VunitRate: rate.PerUnit(),
+	}
+	if rate.CarriedFrom != nil {
+		v.CarriedFrom = rate.CarriedFrom.Format("2006-01-02")
+	}
+	return v
+}
+
 // Helper function to convert storage.CryptoRate to HistoricalCryptoRate
 func convertCryptoRateToHistorical(rate storage.CryptoRate) HistoricalCryptoRate {
 	return HistoricalCryptoRate{
@@ -90,3 +125,95 @@ func convertCryptoRateToHistorical(rate storage.CryptoRate) HistoricalCryptoRate
 		Volume:    rate.Volume,
 	}
 }
+
+// currencyRatesFromSheet converts a CBR sheet to rows stored under date,
+// recording when the sheet was published, where and when it was fetched and,
+// for a sheet effective before date, the sheet the rates were carried over from
+func currencyRatesFromSheet(rates *currency.DailyRates, date time.Time) []storage.CurrencyRate {
+	carriedFrom := rates.CarriedFrom(date)
+	publishedAt := rates.PublishedAt()
+	dbRates := make([]storage.CurrencyRate, 0, len(rates.Valute))
+	for code, valute := range rates.Valute {
+		dbRates = append(dbRates, storage.CurrencyRate{
+			Date:         date,
+			CurrencyCode: code,
+			CurrencyName: valute.Name,
+			Nominal:      valute.Nominal,
+			Value:        valute.Value,
+			Previous:     valute.Previous,
+			UnitValue:    valute.PerUnit(),
+			CarriedFrom:  carriedFrom,
+			PublishedAt:  &publishedAt,
+			Source:       rates.SourceURL,
+			FetchedAt:    rates.FetchedAt,
+		})
+	}
+	return dbRates
+}
+
+// fetchCurrencyRates fetches the CBR sheet in effect on date as rows stored
+// under date. Rows read from the sheet of an earlier day carry its date in
+// CarriedFrom
+func fetchCurrencyRates(date time.Time) ([]storage.CurrencyRate, error) {
+	rates, err := currency.GetCBRRatesByDate(date.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	return currencyRatesFromSheet(rates, date), nil
+}
+
+// fetchIndicatorRates fetches the values an indicator took from
+// IndicatorsLookbackDays before from to to as rows, so that the value in
+// effect on from is among them
+func fetchIndicatorRates(indicator string, from, to time.Time) ([]storage.IndicatorRate, error) {
+	rates, err := currency.GetIndicatorRates(indicator, from.AddDate(0, 0, -currency.IndicatorsLookbackDays), to)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]storage.IndicatorRate, 0, len(rates))
+	for _, rate := range rates {
+		rows = append(rows, storage.IndicatorRate{
+			Indicator:     rate.Indicator,
+			EffectiveDate: rate.Date,
+			Name:          rate.Name,
+			Value:         rate.Value,
+			Source:        rate.SourceURL,
+			FetchedAt:     rate.FetchedAt,
+		})
+	}
+	return rows, nil
+}
+
+// findCurrencyRate returns the rate of code among rates
+func findCurrencyRate(rates []storage.CurrencyRate, code string) (storage.CurrencyRate, bool) {
+	for _, rate := range rates {
+		if rate.CurrencyCode == code {
+			return rate, true
+		}
+	}
+	return storage.CurrencyRate{}, false
+}
+
+// saveBackfilledCurrencyRates stores CBR rates fetched because the database
+// had none and counts the backfill
+func saveBackfilledCurrencyRates(db *storage.PostgresDB, rates []storage.CurrencyRate) error {
+	err := db.SaveCurrencyRates(rates)
+	metrics.Backfill(metrics.SourceCBR, err)
+	return err
+}
+
+// saveBackfilledCryptoRates stores Binance candles fetched because the
+// database had none and counts the backfill
+func saveBackfilledCryptoRates(db *storage.PostgresDB, rates []storage.CryptoRate) error {
+	err := db.SaveCryptoRates(rates)
+	metrics.Backfill(metrics.SourceBinance, err)
+	return err
+}
+
+// saveBackfilledIndicatorRates stores CBR indicator values fetched because
+// the database had none and counts the backfill
+func saveBackfilledIndicatorRates(db *storage.PostgresDB, rates []storage.IndicatorRate) error {
+	err := db.SaveIndicatorRates(rates)
+	metrics.Backfill(metrics.SourceCBRIndicators, err)
+	return err
+}
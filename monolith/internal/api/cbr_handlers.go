@@ -2,13 +2,17 @@
 package api
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/casualdoto/go-currency-tracker/internal/apiv1"
+	"github.com/casualdoto/go-currency-tracker/internal/calendar"
 	currency "github.com/casualdoto/go-currency-tracker/internal/currency/cbr"
 	"github.com/casualdoto/go-currency-tracker/internal/storage"
 	"github.com/xuri/excelize/v2"
@@ -26,14 +30,14 @@ func CBRRatesHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Parse date or use current date
 	if dateStr == "" {
-		date = time.Now().Truncate(24 * time.Hour)
+		date = calendar.Today()
 	} else {
 		// Parse date from string
-		date, err = time.Parse("2006-01-02", dateStr)
+		date, err = calendar.ParseDate(dateStr)
 		if err != nil {
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(APIResponse{
+			encodeJSON(w, APIResponse{
 				Success: false,
 				Error:   "Invalid date format. Use YYYY-MM-DD",
 			})
@@ -41,74 +45,113 @@ func CBRRatesHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Check if we have a database connection in the context
-	db, ok := r.Context().Value("db").(*storage.PostgresDB)
-	if ok && db != nil {
-		// Try to get rates from database first
-		rates, err := db.GetCurrencyRatesByDate(date)
-		if err == nil && len(rates) > 0 {
-			// Convert database rates to response format
-			response := APIResponse{
-				Success: true,
-				Data:    formatDBRatesToValuteMap(rates),
-			}
-
-			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(response)
-			return
-		}
-	}
-
-	// If we don't have data in DB or there was an error, get from CBR API
-	cbrDateStr := ""
-	if dateStr != "" {
-		cbrDateStr = dateStr
-	}
-
-	// Get currency rates from CBR
-	rates, err := currency.GetCBRRatesByDate(cbrDateStr)
+	// Database is optional: without it rates come straight from the CBR API
+	db, _ := r.Context().Value("db").(*storage.PostgresDB)
+	rates, err := cbrRatesByDate(r.Context(), db, date, dateStr)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   err.Error(),
 		})
 		return
 	}
 
-	// If we have a database connection, save the rates
-	if ok && db != nil {
-		// Convert API rates to database format
-		var dbRates []storage.CurrencyRate
-		for code, valute := range rates.Valute {
-			dbRates = append(dbRates, storage.CurrencyRate{
-				Date:         date,
-				CurrencyCode: code,
-				CurrencyName: valute.Name,
-				Nominal:      valute.Nominal,
-				Value:        valute.Value,
-				Previous:     valute.Previous,
-			})
+	// Form successful response
+	response := APIResponse{
+		Success: true,
+		Data:    formatDBRatesToValuteMap(rates),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encodeJSON(w, response)
+}
+
+// cbrRatesByDate returns all CBR rates for date, from the database if possible.
+// dateStr is the requested date as passed by the client, empty for the latest rates.
+// Rates fetched from the CBR API are saved in background when db is not nil.
+func cbrRatesByDate(ctx context.Context, db *storage.PostgresDB, date time.Time, dateStr string) ([]storage.CurrencyRate, error) {
+	if db != nil {
+		// Try to get rates from database first
+		rates, err := db.GetCurrencyRatesByDate(date)
+		if err == nil && len(rates) > 0 {
+			return rates, nil
 		}
+	}
+
+	// Get currency rates from CBR
+	rates, err := currency.GetCBRRatesByDate(dateStr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert API rates to database format
+	dbRates := currencyRatesFromSheet(rates, date)
 
+	// If we have a database connection, save the rates
+	if db != nil {
 		// Save to database in background to not block the response
 		go func(dbRates []storage.CurrencyRate) {
-			if err := db.SaveCurrencyRates(dbRates); err != nil {
+			if err := saveBackfilledCurrencyRates(db, dbRates); err != nil {
 				// Just log the error, don't affect the response
-				fmt.Printf("Failed to save currency rates to database: %v\n", err)
+				logger.ErrorContext(ctx, "saving fetched rates failed", "source", "cbr", "count", len(dbRates), "error", err)
 			}
 		}(dbRates)
 	}
 
-	// Form successful response
-	response := APIResponse{
-		Success: true,
-		Data:    rates.Valute,
+	return dbRates, nil
+}
+
+// NextCBRRatesHandler returns the rates the CBR has already published for a
+// later day than today, with the day they take effect and the time they were
+// published. Answers 404 until the next day's sheet appears in the afternoon
+func NextCBRRatesHandler(w http.ResponseWriter, r *http.Request) {
+	today := calendar.Today()
+
+	// Database is optional: without it the latest sheet is read from the CBR API
+	db, _ := r.Context().Value("db").(*storage.PostgresDB)
+	if db != nil {
+		if date, err := db.LatestCurrencyRateDate(); err == nil && date.After(today) {
+			rates, err := db.GetCurrencyRatesByDate(date)
+			if err == nil && len(rates) > 0 {
+				next := NextCBRRates{Date: date.Format("2006-01-02"), Rates: formatDBRatesToValuteMap(rates)}
+				if rates[0].PublishedAt != nil {
+					next.PublishedAt = rates[0].PublishedAt.Format(time.RFC3339)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				encodeJSON(w, APIResponse{Success: true, Data: next})
+				return
+			}
+		}
+	}
+
+	sheet, err := currency.GetLatestCBRRates()
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	effective := sheet.EffectiveOn()
+	if !effective.After(today) {
+		writeErrorResponse(w, http.StatusNotFound, fmt.Sprintf("Rates for the day after %s are not published yet", today.Format("2006-01-02")))
+		return
+	}
+
+	if db != nil {
+		// Save in background like fetched rates of any other day
+		go func(dbRates []storage.CurrencyRate) {
+			if err := saveBackfilledCurrencyRates(db, dbRates); err != nil {
+				logger.ErrorContext(r.Context(), "saving fetched rates failed", "source", "cbr", "count", len(dbRates), "error", err)
+			}
+		}(currencyRatesFromSheet(sheet, effective))
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	encodeJSON(w, APIResponse{Success: true, Data: NextCBRRates{
+		Date:        effective.Format("2006-01-02"),
+		PublishedAt: sheet.PublishedAt().Format(time.RFC3339),
+		Rates:       sheet.Valute,
+	}})
 }
 
 // CBRCurrencyHandler handles requests for getting a specific CBR currency rate.
@@ -121,7 +164,7 @@ func CBRCurrencyHandler(w http.ResponseWriter, r *http.Request) {
 	if currencyCode == "" {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Currency code not specified (parameter code)",
 		})
@@ -136,14 +179,14 @@ func CBRCurrencyHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Parse date or use current date
 	if dateStr == "" {
-		date = time.Now().Truncate(24 * time.Hour)
+		date = calendar.Today()
 	} else {
 		// Parse date from string
-		date, err = time.Parse("2006-01-02", dateStr)
+		date, err = calendar.ParseDate(dateStr)
 		if err != nil {
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(APIResponse{
+			encodeJSON(w, APIResponse{
 				Success: false,
 				Error:   "Invalid date format. Use YYYY-MM-DD",
 			})
@@ -158,15 +201,7 @@ func CBRCurrencyHandler(w http.ResponseWriter, r *http.Request) {
 		rate, err := db.GetCurrencyRate(currencyCode, date)
 		if err == nil {
 			// Convert database rate to response format
-			valuteRate := currency.Valute{
-				ID:       rate.CurrencyCode,
-				NumCode:  "",
-				CharCode: rate.CurrencyCode,
-				Nominal:  rate.Nominal,
-				Name:     rate.CurrencyName,
-				Value:    rate.Value,
-				Previous: rate.Previous,
-			}
+			valuteRate := valuteFromDB(*rate)
 
 			response := APIResponse{
 				Success: true,
@@ -174,7 +209,7 @@ func CBRCurrencyHandler(w http.ResponseWriter, r *http.Request) {
 			}
 
 			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(response)
+			encodeJSON(w, response)
 			return
 		}
 	}
@@ -195,7 +230,7 @@ func CBRCurrencyHandler(w http.ResponseWriter, r *http.Request) {
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(statusCode)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   err.Error(),
 		})
@@ -208,23 +243,13 @@ func CBRCurrencyHandler(w http.ResponseWriter, r *http.Request) {
 		rates, err := currency.GetCBRRatesByDate(cbrDateStr)
 		if err == nil {
 			// Convert API rates to database format
-			var dbRates []storage.CurrencyRate
-			for code, valute := range rates.Valute {
-				dbRates = append(dbRates, storage.CurrencyRate{
-					Date:         date,
-					CurrencyCode: code,
-					CurrencyName: valute.Name,
-					Nominal:      valute.Nominal,
-					Value:        valute.Value,
-					Previous:     valute.Previous,
-				})
-			}
+			dbRates := currencyRatesFromSheet(rates, date)
 
 			// Save to database in background to not block the response
 			go func(dbRates []storage.CurrencyRate) {
-				if err := db.SaveCurrencyRates(dbRates); err != nil {
+				if err := saveBackfilledCurrencyRates(db, dbRates); err != nil {
 					// Just log the error, don't affect the response
-					fmt.Printf("Failed to save currency rates to database: %v\n", err)
+					logger.ErrorContext(r.Context(), "saving fetched rates failed", "source", "cbr", "count", len(dbRates), "error", err)
 				}
 			}(dbRates)
 		} else {
@@ -236,12 +261,13 @@ func CBRCurrencyHandler(w http.ResponseWriter, r *http.Request) {
 				Nominal:      rate.Nominal,
 				Value:        rate.Value,
 				Previous:     rate.Previous,
+				UnitValue:    rate.PerUnit(),
 			}
 
 			// Save to database in background
 			go func(dbRate storage.CurrencyRate) {
-				if err := db.SaveCurrencyRates([]storage.CurrencyRate{dbRate}); err != nil {
-					fmt.Printf("Failed to save currency rate to database: %v\n", err)
+				if err := saveBackfilledCurrencyRates(db, []storage.CurrencyRate{dbRate}); err != nil {
+					logger.ErrorContext(r.Context(), "saving fetched rates failed", "source", "cbr", "currency", dbRate.CurrencyCode, "error", err)
 				}
 			}(dbRate)
 		}
@@ -254,7 +280,7 @@ func CBRCurrencyHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	encodeJSON(w, response)
 }
 
 // GetCurrencyHistoryHandler handles requests for getting historical currency rates.
@@ -266,7 +292,7 @@ func GetCurrencyHistoryHandler(w http.ResponseWriter, r *http.Request) {
 	if currencyCode == "" {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Currency code not specified (parameter code)",
 		})
@@ -278,7 +304,7 @@ func GetCurrencyHistoryHandler(w http.ResponseWriter, r *http.Request) {
 	if daysStr == "" {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Days parameter not specified (parameter days)",
 		})
@@ -290,7 +316,7 @@ func GetCurrencyHistoryHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil || days <= 0 {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Invalid days parameter, must be a positive integer",
 		})
@@ -303,7 +329,7 @@ func GetCurrencyHistoryHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get current date
-	today := time.Now().Truncate(24 * time.Hour)
+	today := calendar.Today()
 
 	// Array to store historical rates
 	history := []map[string]interface{}{}
@@ -315,85 +341,64 @@ func GetCurrencyHistoryHandler(w http.ResponseWriter, r *http.Request) {
 	for i := 0; i < days; i++ {
 		date := today.AddDate(0, 0, -i)
 
-		var rate *currency.Valute
-		var err error
+		var rate *storage.CurrencyRate
 
 		// Try to get from DB first if available
 		if ok && db != nil {
 			dbRate, dbErr := db.GetCurrencyRate(currencyCode, date)
 			if dbErr == nil {
-				// Convert DB rate to Valute format
-				rate = &currency.Valute{
-					ID:       dbRate.CurrencyCode,
-					NumCode:  "",
-					CharCode: dbRate.CurrencyCode,
-					Nominal:  dbRate.Nominal,
-					Name:     dbRate.CurrencyName,
-					Value:    dbRate.Value,
-					Previous: dbRate.Previous,
-				}
+				rate = dbRate
 			}
 		}
 
 		// If not found in DB, get from CBR API
 		if rate == nil {
-			dateStr := date.Format("2006-01-02")
-			rate, err = currency.GetCurrencyRate(currencyCode, dateStr)
+			rates, err := fetchCurrencyRates(date)
 			if err != nil {
 				// Skip this date if there's an error
 				continue
 			}
+			fetched, found := findCurrencyRate(rates, currencyCode)
+			if !found {
+				continue
+			}
+			rate = &fetched
 
 			// Save all rates for this day to DB if we have a connection
 			if ok && db != nil {
-				go func(dateStr string, date time.Time, currentRate *currency.Valute) {
-					// Get all rates for this date
-					rates, err := currency.GetCBRRatesByDate(dateStr)
-					if err == nil {
-						// Convert API rates to database format
-						var dbRates []storage.CurrencyRate
-						for code, valute := range rates.Valute {
-							dbRates = append(dbRates, storage.CurrencyRate{
-								Date:         date,
-								CurrencyCode: code,
-								CurrencyName: valute.Name,
-								Nominal:      valute.Nominal,
-								Value:        valute.Value,
-								Previous:     valute.Previous,
-							})
-						}
-
-						// Save to database
-						if err := db.SaveCurrencyRates(dbRates); err != nil {
-							fmt.Printf("Failed to save currency rates to database: %v\n", err)
-						}
-					} else {
-						// If we couldn't get all rates, at least save this one
-						dbRate := storage.CurrencyRate{
-							Date:         date,
-							CurrencyCode: currentRate.CharCode,
-							CurrencyName: currentRate.Name,
-							Nominal:      currentRate.Nominal,
-							Value:        currentRate.Value,
-							Previous:     currentRate.Previous,
-						}
-						if err := db.SaveCurrencyRates([]storage.CurrencyRate{dbRate}); err != nil {
-							fmt.Printf("Failed to save currency rate to database: %v\n", err)
-						}
+				go func(dbRates []storage.CurrencyRate) {
+					if err := saveBackfilledCurrencyRates(db, dbRates); err != nil {
+						logger.ErrorContext(r.Context(), "saving fetched rates failed", "source", "cbr", "count", len(dbRates), "error", err)
 					}
-				}(dateStr, date, rate)
+				}(rates)
 			}
 		}
 
 		// Add rate to history
-		history = append(history, map[string]interface{}{
-			"date":     date.Format("2006-01-02"),
-			"code":     rate.CharCode,
-			"name":     rate.Name,
-			"nominal":  rate.Nominal,
-			"value":    rate.Value,
-			"previous": rate.Previous,
-		})
+		item := map[string]interface{}{
+			"date":       date.Format("2006-01-02"),
+			"code":       rate.CurrencyCode,
+			"name":       rate.CurrencyName,
+			"nominal":    rate.Nominal,
+			"value":      rate.Value,
+			"previous":   rate.Previous,
+			"unit_value": rate.PerUnit(),
+		}
+		if rate.PreviousNominal != 0 {
+			item["previous_nominal"] = rate.PreviousNominal
+		}
+		if rate.CarriedFrom != nil {
+			item["carried_from"] = rate.CarriedFrom.Format("2006-01-02")
+		}
+		history = append(history, item)
+	}
+
+	// Rates fetched from the CBR API have no stored predecessor: compare their
+	// nominal with the older rate that follows in the list
+	for i := 0; i+1 < len(history); i++ {
+		if _, flagged := history[i]["previous_nominal"]; !flagged && history[i]["nominal"] != history[i+1]["nominal"] {
+			history[i]["previous_nominal"] = history[i+1]["nominal"]
+		}
 	}
 
 	// Form successful response
@@ -403,19 +408,20 @@ func GetCurrencyHistoryHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	encodeJSON(w, response)
 }
 
 // GetCurrencyHistoryByDateRangeHandler handles requests for getting historical currency rates by date range.
 // Requires query parameter code (currency code, e.g. USD).
 // Requires query parameters start_date and end_date in YYYY-MM-DD format.
+// Optional as_of (RFC 3339 time or YYYY-MM-DD) reads the stored rates as they were at that time
 func GetCurrencyHistoryByDateRangeHandler(w http.ResponseWriter, r *http.Request) {
 	// Get currency code from request
 	currencyCode := r.URL.Query().Get("code")
 	if currencyCode == "" {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Currency code not specified (parameter code)",
 		})
@@ -429,7 +435,7 @@ func GetCurrencyHistoryByDateRangeHandler(w http.ResponseWriter, r *http.Request
 	if startDateStr == "" || endDateStr == "" {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Both start_date and end_date parameters are required (format: YYYY-MM-DD)",
 		})
@@ -437,22 +443,22 @@ func GetCurrencyHistoryByDateRangeHandler(w http.ResponseWriter, r *http.Request
 	}
 
 	// Parse dates
-	startDate, err := time.Parse("2006-01-02", startDateStr)
+	startDate, err := calendar.ParseDate(startDateStr)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Invalid start_date format. Use YYYY-MM-DD",
 		})
 		return
 	}
 
-	endDate, err := time.Parse("2006-01-02", endDateStr)
+	endDate, err := calendar.ParseDate(endDateStr)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Invalid end_date format. Use YYYY-MM-DD",
 		})
@@ -463,7 +469,7 @@ func GetCurrencyHistoryByDateRangeHandler(w http.ResponseWriter, r *http.Request
 	if startDate.After(endDate) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "start_date must be before or equal to end_date",
 		})
@@ -474,42 +480,65 @@ func GetCurrencyHistoryByDateRangeHandler(w http.ResponseWriter, r *http.Request
 	if endDate.Sub(startDate) > 365*24*time.Hour {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Date range cannot exceed 365 days",
 		})
 		return
 	}
 
+	asOf, errMsg := parseAsOf(r)
+	if errMsg != "" {
+		writeErrorResponse(w, http.StatusBadRequest, errMsg)
+		return
+	}
+
 	// Check if we have a database connection in the context
 	db, ok := r.Context().Value("db").(*storage.PostgresDB)
 	if !ok || db == nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Database connection not available",
 		})
 		return
 	}
 
+	history := currencyHistory(r.Context(), db, currencyCode, startDate, endDate)
+	if !asOf.IsZero() {
+		var err error
+		history, err = currencyHistoryAsOf(r.Context(), db, currencyCode, startDate, endDate, asOf)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "as-of query failed", "currency", currencyCode, "error", err)
+			writeErrorResponse(w, http.StatusInternalServerError, "Failed to query stored rates")
+			return
+		}
+	}
+
+	// Form successful response
+	response := APIResponse{
+		Success: true,
+		Data:    history,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encodeJSON(w, response)
+}
+
+// currencyHistory returns the rates of currencyCode from startDate to endDate ordered by date.
+// Dates missing in the database are fetched from the CBR API and saved in background.
+func currencyHistory(ctx context.Context, db *storage.PostgresDB, currencyCode string, startDate, endDate time.Time) []apiv1.CurrencyRate {
 	// Get rates from database for the date range
 	dbRates, err := db.GetCurrencyRatesByDateRange(currencyCode, startDate, endDate)
 
 	// Array to store historical rates
-	history := []map[string]interface{}{}
+	history := []apiv1.CurrencyRate{}
 
 	// If we have data from DB, use it
 	if err == nil && len(dbRates) > 0 {
 		for _, dbRate := range dbRates {
-			history = append(history, map[string]interface{}{
-				"date":     dbRate.Date.Format("2006-01-02"),
-				"code":     dbRate.CurrencyCode,
-				"name":     dbRate.CurrencyName,
-				"nominal":  dbRate.Nominal,
-				"value":    dbRate.Value,
-				"previous": dbRate.Previous,
-			})
+			history = append(history, storedCurrencyRate(dbRate))
 		}
 	}
 
@@ -517,19 +546,15 @@ func GetCurrencyHistoryByDateRangeHandler(w http.ResponseWriter, r *http.Request
 	// Check which dates are missing
 	existingDates := make(map[string]bool)
 	for _, item := range history {
-		if dateStr, ok := item["date"].(string); ok {
-			existingDates[dateStr] = true
-		}
+		existingDates[item.Date] = true
 	}
 
 	// Use a channel to collect fetched rates
-	type fetchedRate struct {
-		dateStr string
-		date    time.Time
-		rate    *currency.Valute
+	type fetchedRates struct {
+		rate  storage.CurrencyRate
+		rates []storage.CurrencyRate
 	}
-	rateChan := make(chan fetchedRate, 10)
-	errorChan := make(chan string, 10)
+	rateChan := make(chan fetchedRates, 10)
 
 	// Fetch missing dates in parallel using goroutines
 	var wg sync.WaitGroup
@@ -538,76 +563,37 @@ func GetCurrencyHistoryByDateRangeHandler(w http.ResponseWriter, r *http.Request
 		dateStr := currentDate.Format("2006-01-02")
 		if !existingDates[dateStr] {
 			wg.Add(1)
-			go func(dateStr string, date time.Time) {
+			go func(date time.Time) {
 				defer wg.Done()
-				rate, err := currency.GetCurrencyRate(currencyCode, dateStr)
-				if err == nil {
-					rateChan <- fetchedRate{dateStr: dateStr, date: date, rate: rate}
-				} else {
-					// Skip weekends and holidays silently
-					errorChan <- dateStr
+				rates, err := fetchCurrencyRates(date)
+				if err != nil {
+					return
+				}
+				if rate, found := findCurrencyRate(rates, currencyCode); found {
+					rateChan <- fetchedRates{rate: rate, rates: rates}
 				}
-			}(dateStr, currentDate)
+			}(currentDate)
 		}
 		currentDate = currentDate.AddDate(0, 0, 1)
 	}
 
-	// Close channels when all goroutines are done
+	// Close channel when all goroutines are done
 	go func() {
 		wg.Wait()
 		close(rateChan)
-		close(errorChan)
 	}()
 
 	// Collect fetched rates
 	for fetched := range rateChan {
-		// Add to history
-		history = append(history, map[string]interface{}{
-			"date":     fetched.dateStr,
-			"code":     fetched.rate.CharCode,
-			"name":     fetched.rate.Name,
-			"nominal":  fetched.rate.Nominal,
-			"value":    fetched.rate.Value,
-			"previous": fetched.rate.Previous,
-		})
-
-		// Save to database in background
-		go func(dateStr string, date time.Time, currentRate *currency.Valute) {
-			// Get all rates for this date
-			rates, err := currency.GetCBRRatesByDate(dateStr)
-			if err == nil {
-				// Convert API rates to database format
-				var dbRates []storage.CurrencyRate
-				for code, valute := range rates.Valute {
-					dbRates = append(dbRates, storage.CurrencyRate{
-						Date:         date,
-						CurrencyCode: code,
-						CurrencyName: valute.Name,
-						Nominal:      valute.Nominal,
-						Value:        valute.Value,
-						Previous:     valute.Previous,
-					})
-				}
+		// Add to history; rates of a day without a publication carry the date they came from
+		history = append(history, storedCurrencyRate(fetched.rate))
 
-				// Save to database
-				if err := db.SaveCurrencyRates(dbRates); err != nil {
-					fmt.Printf("Failed to save currency rates to database: %v\n", err)
-				}
-			} else {
-				// If we couldn't get all rates, at least save this one
-				dbRate := storage.CurrencyRate{
-					Date:         date,
-					CurrencyCode: currentRate.CharCode,
-					CurrencyName: currentRate.Name,
-					Nominal:      currentRate.Nominal,
-					Value:        currentRate.Value,
-					Previous:     currentRate.Previous,
-				}
-				if err := db.SaveCurrencyRates([]storage.CurrencyRate{dbRate}); err != nil {
-					fmt.Printf("Failed to save currency rate to database: %v\n", err)
-				}
+		// Save all rates for this day to database in background
+		go func(dbRates []storage.CurrencyRate) {
+			if err := saveBackfilledCurrencyRates(db, dbRates); err != nil {
+				logger.ErrorContext(ctx, "saving fetched rates failed", "source", "cbr", "count", len(dbRates), "error", err)
 			}
-		}(fetched.dateStr, fetched.date, fetched.rate)
+		}(fetched.rates)
 	}
 
 	// Sort history by date
@@ -615,23 +601,28 @@ func GetCurrencyHistoryByDateRangeHandler(w http.ResponseWriter, r *http.Request
 		// Simple bubble sort by date (ascending)
 		for i := 0; i < len(history)-1; i++ {
 			for j := i + 1; j < len(history); j++ {
-				dateI, _ := time.Parse("2006-01-02", history[i]["date"].(string))
-				dateJ, _ := time.Parse("2006-01-02", history[j]["date"].(string))
+				dateI, _ := time.Parse("2006-01-02", history[i].Date)
+				dateJ, _ := time.Parse("2006-01-02", history[j].Date)
 				if dateI.After(dateJ) {
 					history[i], history[j] = history[j], history[i]
 				}
 			}
 		}
 	}
+	markNominalChanges(history)
 
-	// Form successful response
-	response := APIResponse{
-		Success: true,
-		Data:    history,
-	}
+	return history
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+// markNominalChanges sets PreviousNominal on the rates of a series sorted by
+// date whose nominal differs from the rate before, so that rates fetched from
+// the CBR API are flagged like stored ones
+func markNominalChanges(history []apiv1.CurrencyRate) {
+	for i := 1; i < len(history); i++ {
+		if history[i].PreviousNominal == 0 && history[i].Nominal != history[i-1].Nominal {
+			history[i].PreviousNominal = history[i-1].Nominal
+		}
+	}
 }
 
 // ExportCurrencyHistoryToExcelHandler handles requests for exporting historical currency rates to Excel.
@@ -643,7 +634,7 @@ func ExportCurrencyHistoryToExcelHandler(w http.ResponseWriter, r *http.Request)
 	if currencyCode == "" {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Currency code not specified (parameter code)",
 		})
@@ -657,7 +648,7 @@ func ExportCurrencyHistoryToExcelHandler(w http.ResponseWriter, r *http.Request)
 	if startDateStr == "" || endDateStr == "" {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Both start_date and end_date parameters are required (format: YYYY-MM-DD)",
 		})
@@ -665,22 +656,22 @@ func ExportCurrencyHistoryToExcelHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Parse dates
-	startDate, err := time.Parse("2006-01-02", startDateStr)
+	startDate, err := calendar.ParseDate(startDateStr)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Invalid start_date format. Use YYYY-MM-DD",
 		})
 		return
 	}
 
-	endDate, err := time.Parse("2006-01-02", endDateStr)
+	endDate, err := calendar.ParseDate(endDateStr)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Invalid end_date format. Use YYYY-MM-DD",
 		})
@@ -691,7 +682,7 @@ func ExportCurrencyHistoryToExcelHandler(w http.ResponseWriter, r *http.Request)
 	if startDate.After(endDate) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "start_date must be before or equal to end_date",
 		})
@@ -702,7 +693,7 @@ func ExportCurrencyHistoryToExcelHandler(w http.ResponseWriter, r *http.Request)
 	if endDate.Sub(startDate) > 365*24*time.Hour {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Date range cannot exceed 365 days",
 		})
@@ -714,7 +705,7 @@ func ExportCurrencyHistoryToExcelHandler(w http.ResponseWriter, r *http.Request)
 	if !ok || db == nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Database connection not available",
 		})
@@ -749,67 +740,30 @@ func ExportCurrencyHistoryToExcelHandler(w http.ResponseWriter, r *http.Request)
 		}
 
 		// If not in DB, try to get from API
-		apiRate, err := currency.GetCurrencyRate(currencyCode, dateStr)
+		rates, err := fetchCurrencyRates(d)
 		if err != nil {
-			// Skip this date if there's an error (might be weekend/holiday)
+			// Skip this date if there's an error
 			continue
 		}
-
-		// Convert API rate to DB format and add to history
-		dbRate := storage.CurrencyRate{
-			Date:         d,
-			CurrencyCode: apiRate.CharCode,
-			CurrencyName: apiRate.Name,
-			Nominal:      apiRate.Nominal,
-			Value:        apiRate.Value,
-			Previous:     apiRate.Previous,
+		rate, found := findCurrencyRate(rates, currencyCode)
+		if !found {
+			continue
 		}
-		history = append(history, dbRate)
-
-		// Save to database in background
-		go func(dateStr string, date time.Time, currentRate *currency.Valute) {
-			// Get all rates for this date
-			rates, err := currency.GetCBRRatesByDate(dateStr)
-			if err == nil {
-				// Convert API rates to database format
-				var dbRates []storage.CurrencyRate
-				for code, valute := range rates.Valute {
-					dbRates = append(dbRates, storage.CurrencyRate{
-						Date:         date,
-						CurrencyCode: code,
-						CurrencyName: valute.Name,
-						Nominal:      valute.Nominal,
-						Value:        valute.Value,
-						Previous:     valute.Previous,
-					})
-				}
+		history = append(history, rate)
 
-				// Save to database
-				if err := db.SaveCurrencyRates(dbRates); err != nil {
-					fmt.Printf("Failed to save currency rates to database: %v\n", err)
-				}
-			} else {
-				// If we couldn't get all rates, at least save this one
-				dbRate := storage.CurrencyRate{
-					Date:         date,
-					CurrencyCode: currentRate.CharCode,
-					CurrencyName: currentRate.Name,
-					Nominal:      currentRate.Nominal,
-					Value:        currentRate.Value,
-					Previous:     currentRate.Previous,
-				}
-				if err := db.SaveCurrencyRates([]storage.CurrencyRate{dbRate}); err != nil {
-					fmt.Printf("Failed to save currency rate to database: %v\n", err)
-				}
+		// Save all rates for this day to database in background
+		go func(dbRates []storage.CurrencyRate) {
+			if err := saveBackfilledCurrencyRates(db, dbRates); err != nil {
+				logger.ErrorContext(r.Context(), "saving fetched rates failed", "source", "cbr", "count", len(dbRates), "error", err)
 			}
-		}(dateStr, d, apiRate)
+		}(rates)
 	}
 
 	// If no data found, return error
 	if len(history) == 0 {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "No data found for the specified currency and date range",
 		})
@@ -820,7 +774,7 @@ func ExportCurrencyHistoryToExcelHandler(w http.ResponseWriter, r *http.Request)
 	f := excelize.NewFile()
 	defer func() {
 		if err := f.Close(); err != nil {
-			fmt.Println("Error closing Excel file:", err)
+			logger.WarnContext(r.Context(), "closing Excel file failed", "error", err)
 		}
 	}()
 
@@ -830,7 +784,7 @@ func ExportCurrencyHistoryToExcelHandler(w http.ResponseWriter, r *http.Request)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Failed to create Excel sheet",
 		})
@@ -838,7 +792,7 @@ func ExportCurrencyHistoryToExcelHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Set headers
-	headers := []string{"Date", "Currency Code", "Currency Name", "Nominal", "Value", "Previous Value"}
+	headers := []string{"Date", "Currency Code", "Currency Name", "Nominal", "Value", "Previous Value", "Carried From", "Unit Value"}
 	for i, header := range headers {
 		cell := fmt.Sprintf("%c%d", 'A'+i, 1)
 		f.SetCellValue(sheetName, cell, header)
@@ -851,6 +805,8 @@ func ExportCurrencyHistoryToExcelHandler(w http.ResponseWriter, r *http.Request)
 	f.SetColWidth(sheetName, "D", "D", 10)
 	f.SetColWidth(sheetName, "E", "E", 12)
 	f.SetColWidth(sheetName, "F", "F", 15)
+	f.SetColWidth(sheetName, "G", "G", 14)
+	f.SetColWidth(sheetName, "H", "H", 20)
 
 	// Create a style for the header row
 	headerStyle, err := f.NewStyle(&excelize.Style{
@@ -875,7 +831,7 @@ func ExportCurrencyHistoryToExcelHandler(w http.ResponseWriter, r *http.Request)
 		},
 	})
 	if err == nil {
-		f.SetCellStyle(sheetName, "A1", "F1", headerStyle)
+		f.SetCellStyle(sheetName, "A1", "H1", headerStyle)
 	}
 
 	// Add data rows
@@ -887,6 +843,11 @@ func ExportCurrencyHistoryToExcelHandler(w http.ResponseWriter, r *http.Request)
 		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), rate.Nominal)
 		f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), rate.Value)
 		f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), rate.Previous)
+		// Empty for a rate published on its own date
+		if rate.CarriedFrom != nil {
+			f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), rate.CarriedFrom.Format("2006-01-02"))
+		}
+		f.SetCellValue(sheetName, fmt.Sprintf("H%d", row), rate.PerUnit())
 	}
 
 	// Set the active sheet
@@ -900,10 +861,137 @@ func ExportCurrencyHistoryToExcelHandler(w http.ResponseWriter, r *http.Request)
 	if err := f.Write(w); err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Failed to write Excel file",
 		})
 		return
 	}
 }
+
+// parseAsOf parses the optional as_of query parameter, the point in time to
+// read stored CBR rates as they were then: an RFC 3339 time, or a YYYY-MM-DD
+// date meaning the end of that day in UTC. The zero time means the current
+// rates
+func parseAsOf(r *http.Request) (time.Time, string) {
+	s := r.URL.Query().Get("as_of")
+	if s == "" {
+		return time.Time{}, ""
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, ""
+	}
+	if d, err := time.Parse("2006-01-02", s); err == nil {
+		// PostgreSQL keeps microseconds, so this is the last instant of the day
+		return d.AddDate(0, 0, 1).Add(-time.Microsecond), ""
+	}
+	return time.Time{}, "Invalid as_of format. Use an RFC 3339 time or YYYY-MM-DD"
+}
+
+// currencyHistoryAsOf returns the rates of currencyCode, or of every currency
+// when empty, from startDate to endDate as they were stored at asOf, ordered
+// by date then code. Unlike currencyHistory it never fetches missing dates:
+// the CBR API only knows the current values
+func currencyHistoryAsOf(ctx context.Context, db *storage.PostgresDB, currencyCode string, startDate, endDate, asOf time.Time) ([]apiv1.CurrencyRate, error) {
+	dbRates, err := db.GetCurrencyRatesAsOf(ctx, currencyCode, startDate, endDate, asOf)
+	if err != nil {
+		return nil, err
+	}
+	history := make([]apiv1.CurrencyRate, 0, len(dbRates))
+	for _, dbRate := range dbRates {
+		history = append(history, storedCurrencyRate(dbRate))
+	}
+	sort.Slice(history, func(i, j int) bool {
+		if history[i].Date != history[j].Date {
+			return history[i].Date < history[j].Date
+		}
+		return history[i].Code < history[j].Code
+	})
+	return history, nil
+}
+
+// storedCurrencyRate converts a stored rate to its DTO
+func storedCurrencyRate(rate storage.CurrencyRate) apiv1.CurrencyRate {
+	dto := apiv1.CurrencyRate{
+		Date:            rate.Date.Format("2006-01-02"),
+		Code:            rate.CurrencyCode,
+		Name:            rate.CurrencyName,
+		Nominal:         rate.Nominal,
+		Value:           rate.Value,
+		Previous:        rate.Previous,
+		UnitValue:       rate.PerUnit(),
+		PreviousNominal: rate.PreviousNominal,
+	}
+	if rate.CarriedFrom != nil {
+		dto.CarriedFrom = rate.CarriedFrom.Format("2006-01-02")
+	}
+	return dto
+}
+
+// CBRRevisionsHandler returns every value stored for a CBR rate, oldest
+// first, with where and when it was fetched and the publication it was
+// carried over from, if any.
+// Requires query parameters code (e.g. USD) and date (YYYY-MM-DD)
+func CBRRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	code := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("code")))
+	if code == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "Currency code not specified (parameter code)")
+		return
+	}
+	date, err := calendar.ParseDate(r.URL.Query().Get("date"))
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid date format. Use YYYY-MM-DD")
+		return
+	}
+
+	db, ok := r.Context().Value("db").(*storage.PostgresDB)
+	if !ok || db == nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Database connection not available")
+		return
+	}
+
+	revisions, err := db.GetCurrencyRateRevisions(r.Context(), code, date)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "revisions query failed", "currency", code, "error", err)
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to query stored rates")
+		return
+	}
+	if len(revisions) == 0 {
+		writeErrorResponse(w, http.StatusNotFound, fmt.Sprintf("No rate of %s stored for %s", code, date.Format("2006-01-02")))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encodeJSON(w, APIResponse{Success: true, Data: revisions})
+}
+
+// CBRNominalChangesHandler returns the dates on which the CBR changed the
+// nominal of a currency, oldest first. Requires query parameters start_date
+// and end_date (YYYY-MM-DD); code limits the result to one currency
+func CBRNominalChangesHandler(w http.ResponseWriter, r *http.Request) {
+	startDate, endDate, errMsg := parseDateRange(r)
+	if errMsg != "" {
+		writeErrorResponse(w, http.StatusBadRequest, errMsg)
+		return
+	}
+	code := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("code")))
+
+	db, ok := r.Context().Value("db").(*storage.PostgresDB)
+	if !ok || db == nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Database connection not available")
+		return
+	}
+
+	changes, err := db.GetNominalChanges(r.Context(), code, startDate, endDate)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "nominal changes query failed", "currency", code, "error", err)
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to query stored rates")
+		return
+	}
+	if changes == nil {
+		changes = []storage.NominalChange{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encodeJSON(w, APIResponse{Success: true, Data: changes})
+}
@@ -0,0 +1,87 @@
+// Package api provides HTTP request handlers and API route setup.
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/internal/calendar"
+	"github.com/casualdoto/go-currency-tracker/internal/convert"
+	"github.com/casualdoto/go-currency-tracker/internal/money"
+	"github.com/casualdoto/go-currency-tracker/internal/storage"
+)
+
+// ConvertHandler handles requests for converting an amount between currencies.
+// Requires query parameters from, to (currency codes or crypto symbols, e.g. EUR, BTC)
+// and amount. Supports optional query parameter date in YYYY-MM-DD format; when the
+// date has no published rate, the previous business day's rate is carried over.
+func ConvertHandler(w http.ResponseWriter, r *http.Request) {
+	req, errMsg := parseConvertRequest(r)
+	if errMsg != "" {
+		writeErrorResponse(w, http.StatusBadRequest, errMsg)
+		return
+	}
+
+	// Database is optional: without it the converter asks CBR and Binance directly
+	db, _ := r.Context().Value("db").(*storage.PostgresDB)
+
+	result, err := convert.NewConverter(db).Convert(req.amount, req.from, req.to, req.date)
+	if err != nil {
+		writeErrorResponse(w, convertErrorStatus(err), err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encodeJSON(w, APIResponse{
+		Success: true,
+		Data:    result,
+	})
+}
+
+// convertRequest is a validated conversion query
+type convertRequest struct {
+	from, to string
+	amount   money.Decimal
+	date     time.Time
+}
+
+// parseConvertRequest validates the conversion query. Returns an error message
+// for the client on failure.
+func parseConvertRequest(r *http.Request) (convertRequest, string) {
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+	if from == "" || to == "" {
+		return convertRequest{}, "Parameters from and to are required"
+	}
+
+	// Parse amount parameter (defaults to 1 to return the plain cross rate)
+	amount := money.NewFromInt(1)
+	if amountStr := r.URL.Query().Get("amount"); amountStr != "" {
+		var err error
+		amount, err = money.Parse(amountStr)
+		if err != nil || amount.IsNegative() {
+			return convertRequest{}, "Invalid amount parameter, must be a non-negative number"
+		}
+	}
+
+	// Parse date or use the current Moscow day
+	date := calendar.Today()
+	if dateStr := r.URL.Query().Get("date"); dateStr != "" {
+		var err error
+		date, err = calendar.ParseDate(dateStr)
+		if err != nil {
+			return convertRequest{}, "Invalid date format. Use YYYY-MM-DD"
+		}
+	}
+
+	return convertRequest{from: from, to: to, amount: amount, date: date}, ""
+}
+
+// convertErrorStatus maps conversion errors to 404 or 500
+func convertErrorStatus(err error) int {
+	if errors.Is(err, convert.ErrRateNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
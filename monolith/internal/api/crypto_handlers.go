@@ -2,7 +2,7 @@
 package api
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -20,7 +20,7 @@ func GetCryptoHistoryHandler(w http.ResponseWriter, r *http.Request) {
 	if symbol == "" {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Symbol parameter is required",
 		})
@@ -32,7 +32,7 @@ func GetCryptoHistoryHandler(w http.ResponseWriter, r *http.Request) {
 	if daysStr == "" {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Days parameter is required",
 		})
@@ -44,7 +44,7 @@ func GetCryptoHistoryHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil || days <= 0 {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Invalid days parameter, must be a positive integer",
 		})
@@ -61,7 +61,7 @@ func GetCryptoHistoryHandler(w http.ResponseWriter, r *http.Request) {
 	if !ok || db == nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Database connection not available",
 		})
@@ -102,7 +102,7 @@ func GetCryptoHistoryHandler(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(APIResponse{
+			encodeJSON(w, APIResponse{
 				Success: false,
 				Error:   "Failed to get cryptocurrency rates: " + err.Error(),
 			})
@@ -125,10 +125,10 @@ func GetCryptoHistoryHandler(w http.ResponseWriter, r *http.Request) {
 
 		// Save to database
 		if len(dbRates) > 0 {
-			err = db.SaveCryptoRates(dbRates)
+			err = saveBackfilledCryptoRates(db, dbRates)
 			if err != nil {
 				// Log the error but continue
-				fmt.Printf("Failed to save crypto rates to database: %v\n", err)
+				logger.ErrorContext(r.Context(), "saving fetched rates failed", "source", "binance", "count", len(dbRates), "error", err)
 			}
 		}
 
@@ -148,7 +148,7 @@ func GetCryptoHistoryHandler(w http.ResponseWriter, r *http.Request) {
 
 		// Send response
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: true,
 			Data:    result,
 		})
@@ -163,7 +163,7 @@ func GetCryptoHistoryHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Send response
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(APIResponse{
+	encodeJSON(w, APIResponse{
 		Success: true,
 		Data:    result,
 	})
@@ -176,7 +176,7 @@ func GetCryptoHistoryByDateRangeHandler(w http.ResponseWriter, r *http.Request)
 	if symbol == "" {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Symbol parameter is required",
 		})
@@ -188,7 +188,7 @@ func GetCryptoHistoryByDateRangeHandler(w http.ResponseWriter, r *http.Request)
 	if startDateStr == "" {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Start date parameter is required",
 		})
@@ -200,7 +200,7 @@ func GetCryptoHistoryByDateRangeHandler(w http.ResponseWriter, r *http.Request)
 	if endDateStr == "" {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "End date parameter is required",
 		})
@@ -212,7 +212,7 @@ func GetCryptoHistoryByDateRangeHandler(w http.ResponseWriter, r *http.Request)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Invalid start date format. Use YYYY-MM-DD",
 		})
@@ -223,7 +223,7 @@ func GetCryptoHistoryByDateRangeHandler(w http.ResponseWriter, r *http.Request)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Invalid end date format. Use YYYY-MM-DD",
 		})
@@ -237,7 +237,7 @@ func GetCryptoHistoryByDateRangeHandler(w http.ResponseWriter, r *http.Request)
 	if endTime.Before(startTime) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "End date must be after start date",
 		})
@@ -248,7 +248,7 @@ func GetCryptoHistoryByDateRangeHandler(w http.ResponseWriter, r *http.Request)
 	if endTime.Sub(startTime) > 365*24*time.Hour {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Date range cannot exceed 365 days",
 		})
@@ -260,98 +260,25 @@ func GetCryptoHistoryByDateRangeHandler(w http.ResponseWriter, r *http.Request)
 	if !ok || db == nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Database connection not available",
 		})
 		return
 	}
 
-	// Try to get data from database
-	// Use symbol with /RUB suffix as this format is used in Binance API
-	dbSymbol := symbol + "/RUB"
-	rates, err := db.GetCryptoRatesByDateRange(dbSymbol, startTime, endTime)
-
-	// If data is not found in DB or there was an error, request from Binance API
-	if err != nil || len(rates) == 0 {
-		// If no data in database, fetch from Binance API
-		binanceClient := binance.NewClient()
-
-		// Determine appropriate interval based on date range
-		days := int(endTime.Sub(startTime).Hours() / 24)
-		var interval binance.KlineInterval
-		switch {
-		case days <= 1:
-			interval = binance.Interval1m
-		case days <= 7:
-			interval = binance.Interval15m
-		case days <= 30:
-			interval = binance.Interval1h
-		case days <= 90:
-			interval = binance.Interval4h
-		default:
-			interval = binance.Interval1d
-		}
-
-		// Get historical data from Binance
-		cryptoRates, err := binanceClient.GetHistoricalCryptoToRubRates(symbol, interval, startTime, endTime)
-		if err != nil {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(APIResponse{
-				Success: false,
-				Error:   "Failed to get cryptocurrency rates: " + err.Error(),
-			})
-			return
-		}
-
-		// Convert to storage.CryptoRate format for database
-		dbRates := make([]storage.CryptoRate, len(cryptoRates))
-		for i, rate := range cryptoRates {
-			dbRates[i] = storage.CryptoRate{
-				Timestamp: rate.Timestamp,
-				Symbol:    rate.Symbol, // Symbol already contains the /RUB suffix
-				Open:      rate.Open,
-				High:      rate.High,
-				Low:       rate.Low,
-				Close:     rate.Close,
-				Volume:    rate.Volume,
-			}
-		}
-
-		// Save to database
-		if len(dbRates) > 0 {
-			err = db.SaveCryptoRates(dbRates)
-			if err != nil {
-				// Log the error but continue
-				fmt.Printf("Failed to save crypto rates to database: %v\n", err)
-			}
-		}
-
-		// Format response
-		result := make([]HistoricalCryptoRate, len(cryptoRates))
-		for i, rate := range cryptoRates {
-			result[i] = HistoricalCryptoRate{
-				Timestamp: rate.Timestamp.Unix() * 1000, // Convert to milliseconds for JavaScript
-				Date:      rate.Timestamp.Format("2006-01-02 15:04:05"),
-				Open:      rate.Open,
-				High:      rate.High,
-				Low:       rate.Low,
-				Close:     rate.Close,
-				Volume:    rate.Volume,
-			}
-		}
-
-		// Send response
+	rates, err := cryptoHistory(r.Context(), db, symbol, startTime, endTime)
+	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(APIResponse{
-			Success: true,
-			Data:    result,
+		w.WriteHeader(http.StatusInternalServerError)
+		encodeJSON(w, APIResponse{
+			Success: false,
+			Error:   "Failed to get cryptocurrency rates: " + err.Error(),
 		})
 		return
 	}
 
-	// Format database response
+	// Format response
 	result := make([]HistoricalCryptoRate, len(rates))
 	for i, rate := range rates {
 		result[i] = convertCryptoRateToHistorical(rate)
@@ -359,12 +286,71 @@ func GetCryptoHistoryByDateRangeHandler(w http.ResponseWriter, r *http.Request)
 
 	// Send response
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(APIResponse{
+	encodeJSON(w, APIResponse{
 		Success: true,
 		Data:    result,
 	})
 }
 
+// cryptoHistory returns RUB candles of symbol (e.g. BTC) in [startTime, endTime) from the database.
+// If the database has none, they are fetched from Binance and saved.
+func cryptoHistory(ctx context.Context, db *storage.PostgresDB, symbol string, startTime, endTime time.Time) ([]storage.CryptoRate, error) {
+	// Use symbol with /RUB suffix as this format is used in Binance API
+	dbSymbol := symbol + "/RUB"
+	rates, err := db.GetCryptoRatesByDateRange(dbSymbol, startTime, endTime)
+	if err == nil && len(rates) > 0 {
+		return rates, nil
+	}
+
+	// If no data in database, fetch from Binance API
+	binanceClient := binance.NewClient()
+
+	// Determine appropriate interval based on date range
+	days := int(endTime.Sub(startTime).Hours() / 24)
+	var interval binance.KlineInterval
+	switch {
+	case days <= 1:
+		interval = binance.Interval1m
+	case days <= 7:
+		interval = binance.Interval15m
+	case days <= 30:
+		interval = binance.Interval1h
+	case days <= 90:
+		interval = binance.Interval4h
+	default:
+		interval = binance.Interval1d
+	}
+
+	// Get historical data from Binance
+	cryptoRates, err := binanceClient.GetHistoricalCryptoToRubRates(symbol, interval, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert to storage.CryptoRate format for database
+	dbRates := make([]storage.CryptoRate, len(cryptoRates))
+	for i, rate := range cryptoRates {
+		dbRates[i] = storage.CryptoRate{
+			Timestamp: rate.Timestamp,
+			Symbol:    rate.Symbol, // Symbol already contains the /RUB suffix
+			Open:      rate.Open,
+			High:      rate.High,
+			Low:       rate.Low,
+			Close:     rate.Close,
+			Volume:    rate.Volume,
+		}
+	}
+
+	// Save to database
+	if len(dbRates) > 0 {
+		if err := saveBackfilledCryptoRates(db, dbRates); err != nil {
+			// Log the error but continue
+			logger.ErrorContext(ctx, "saving fetched rates failed", "source", "binance", "count", len(dbRates), "error", err)
+		}
+	}
+	return dbRates, nil
+}
+
 // GetAvailableCryptoSymbolsHandler handles requests for getting available cryptocurrency symbols
 func GetAvailableCryptoSymbolsHandler(w http.ResponseWriter, r *http.Request) {
 	// Get database from context
@@ -372,35 +358,33 @@ func GetAvailableCryptoSymbolsHandler(w http.ResponseWriter, r *http.Request) {
 	if !ok || db == nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Database connection not available",
 		})
 		return
 	}
 
-	// Get available symbols from database
-	symbols, err := db.GetAvailableCryptoSymbols()
-	if err != nil || len(symbols) == 0 {
-		// If no symbols in database, return default list
-		defaultSymbols := []string{"BTC", "ETH", "BNB", "SOL", "XRP", "ADA", "DOGE", "MATIC", "DOT", "LTC"}
-
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(APIResponse{
-			Success: true,
-			Data:    defaultSymbols,
-		})
-		return
-	}
-
 	// Send response
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(APIResponse{
+	encodeJSON(w, APIResponse{
 		Success: true,
-		Data:    symbols,
+		Data:    cryptoSymbols(db),
 	})
 }
 
+// defaultCryptoSymbols are listed while the database has no crypto rates
+var defaultCryptoSymbols = []string{"BTC", "ETH", "BNB", "SOL", "XRP", "ADA", "DOGE", "MATIC", "DOT", "LTC"}
+
+// cryptoSymbols returns the stored symbols or, if there are none, the default list
+func cryptoSymbols(db *storage.PostgresDB) []string {
+	symbols, err := db.GetAvailableCryptoSymbols()
+	if err != nil || len(symbols) == 0 {
+		return defaultCryptoSymbols
+	}
+	return symbols
+}
+
 // ExportCryptoHistoryToExcelHandler handles requests for exporting cryptocurrency history to Excel
 func ExportCryptoHistoryToExcelHandler(w http.ResponseWriter, r *http.Request) {
 	// Get symbol parameter from request
@@ -408,7 +392,7 @@ func ExportCryptoHistoryToExcelHandler(w http.ResponseWriter, r *http.Request) {
 	if symbol == "" {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Symbol parameter is required",
 		})
@@ -420,7 +404,7 @@ func ExportCryptoHistoryToExcelHandler(w http.ResponseWriter, r *http.Request) {
 	if startDateStr == "" {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Start date parameter is required",
 		})
@@ -432,7 +416,7 @@ func ExportCryptoHistoryToExcelHandler(w http.ResponseWriter, r *http.Request) {
 	if endDateStr == "" {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "End date parameter is required",
 		})
@@ -444,7 +428,7 @@ func ExportCryptoHistoryToExcelHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Invalid start date format. Use YYYY-MM-DD",
 		})
@@ -455,7 +439,7 @@ func ExportCryptoHistoryToExcelHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Invalid end date format. Use YYYY-MM-DD",
 		})
@@ -469,7 +453,7 @@ func ExportCryptoHistoryToExcelHandler(w http.ResponseWriter, r *http.Request) {
 	if endTime.Before(startTime) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "End date must be after start date",
 		})
@@ -480,7 +464,7 @@ func ExportCryptoHistoryToExcelHandler(w http.ResponseWriter, r *http.Request) {
 	if endTime.Sub(startTime) > 365*24*time.Hour {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Date range cannot exceed 365 days",
 		})
@@ -492,7 +476,7 @@ func ExportCryptoHistoryToExcelHandler(w http.ResponseWriter, r *http.Request) {
 	if !ok || db == nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Database connection not available",
 		})
@@ -530,7 +514,7 @@ func ExportCryptoHistoryToExcelHandler(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(APIResponse{
+			encodeJSON(w, APIResponse{
 				Success: false,
 				Error:   "Failed to get cryptocurrency rates: " + err.Error(),
 			})
@@ -553,10 +537,10 @@ func ExportCryptoHistoryToExcelHandler(w http.ResponseWriter, r *http.Request) {
 
 		// Save to database
 		if len(dbRates) > 0 {
-			err = db.SaveCryptoRates(dbRates)
+			err = saveBackfilledCryptoRates(db, dbRates)
 			if err != nil {
 				// Log the error but continue
-				fmt.Printf("Failed to save crypto rates to database: %v\n", err)
+				logger.ErrorContext(r.Context(), "saving fetched rates failed", "source", "binance", "count", len(dbRates), "error", err)
 			}
 		}
 
@@ -564,7 +548,7 @@ func ExportCryptoHistoryToExcelHandler(w http.ResponseWriter, r *http.Request) {
 		file := excelize.NewFile()
 		defer func() {
 			if err := file.Close(); err != nil {
-				fmt.Println("Error closing Excel file:", err)
+				logger.WarnContext(r.Context(), "closing Excel file failed", "error", err)
 			}
 		}()
 
@@ -574,7 +558,7 @@ func ExportCryptoHistoryToExcelHandler(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(APIResponse{
+			encodeJSON(w, APIResponse{
 				Success: false,
 				Error:   "Failed to create Excel sheet: " + err.Error(),
 			})
@@ -612,7 +596,7 @@ func ExportCryptoHistoryToExcelHandler(w http.ResponseWriter, r *http.Request) {
 		if err := file.Write(w); err != nil {
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(APIResponse{
+			encodeJSON(w, APIResponse{
 				Success: false,
 				Error:   "Failed to write Excel file: " + err.Error(),
 			})
@@ -626,7 +610,7 @@ func ExportCryptoHistoryToExcelHandler(w http.ResponseWriter, r *http.Request) {
 	file := excelize.NewFile()
 	defer func() {
 		if err := file.Close(); err != nil {
-			fmt.Println("Error closing Excel file:", err)
+			logger.WarnContext(r.Context(), "closing Excel file failed", "error", err)
 		}
 	}()
 
@@ -636,7 +620,7 @@ func ExportCryptoHistoryToExcelHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Failed to create Excel sheet: " + err.Error(),
 		})
@@ -674,7 +658,7 @@ func ExportCryptoHistoryToExcelHandler(w http.ResponseWriter, r *http.Request) {
 	if err := file.Write(w); err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(APIResponse{
+		encodeJSON(w, APIResponse{
 			Success: false,
 			Error:   "Failed to write Excel file: " + err.Error(),
 		})
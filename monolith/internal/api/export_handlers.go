@@ -0,0 +1,142 @@
+// Package api provides HTTP request handlers and API route setup.
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/internal/export"
+	"github.com/casualdoto/go-currency-tracker/internal/money"
+	"github.com/casualdoto/go-currency-tracker/internal/storage"
+)
+
+// Columns of the CBR and crypto exports
+var (
+	cbrExportColumns    = []string{"date", "currency_code", "currency_name", "nominal", "value", "previous", "carried_from", "unit_value"}
+	cryptoExportColumns = []string{"timestamp", "symbol", "open", "high", "low", "close", "volume"}
+)
+
+// ExportCurrencyHistoryHandler streams stored CBR rates as CSV or NDJSON.
+// Requires query parameters start_date and end_date (YYYY-MM-DD); unlike the
+// range endpoint the range length is not limited and missing days are not
+// fetched from the CBR. Supports optional query parameters code (all
+// currencies when omitted) and format (csv, ndjson; default csv).
+func ExportCurrencyHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	format, err := export.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	startDate, endDate, errMsg := parseDateRange(r)
+	if errMsg != "" {
+		writeErrorResponse(w, http.StatusBadRequest, errMsg)
+		return
+	}
+	code := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("code")))
+
+	db, ok := r.Context().Value("db").(*storage.PostgresDB)
+	if !ok || db == nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Database connection not available")
+		return
+	}
+
+	ew := startExport(w, format, cbrExportColumns, exportFilename("cbr", code, startDate, endDate, format))
+	err = db.StreamCurrencyRates(r.Context(), code, startDate, endDate, func(rate storage.CurrencyRate) error {
+		// carried_from is empty for a rate published on its own date
+		carriedFrom := ""
+		if rate.CarriedFrom != nil {
+			carriedFrom = rate.CarriedFrom.Format("2006-01-02")
+		}
+		return ew.Write(rate.Date.Format("2006-01-02"), rate.CurrencyCode, rate.CurrencyName, rate.Nominal, rate.Value, rate.Previous, carriedFrom, rate.PerUnit())
+	})
+	finishExport(r.Context(), w, ew, err)
+}
+
+// ExportCryptoHistoryHandler streams stored cryptocurrency rates (OHLC in RUB)
+// as CSV or NDJSON. Requires query parameters start_date and end_date
+// (YYYY-MM-DD); the range length is not limited and nothing is fetched from
+// Binance. Supports optional query parameters symbol (e.g. BTC; all symbols
+// when omitted) and format (csv, ndjson; default csv).
+func ExportCryptoHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	format, err := export.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	startDate, endDate, errMsg := parseDateRange(r)
+	if errMsg != "" {
+		writeErrorResponse(w, http.StatusBadRequest, errMsg)
+		return
+	}
+	symbol := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("symbol")))
+	dbSymbol := ""
+	if symbol != "" {
+		dbSymbol = symbol + "/RUB"
+	}
+
+	db, ok := r.Context().Value("db").(*storage.PostgresDB)
+	if !ok || db == nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Database connection not available")
+		return
+	}
+
+	// end_date is inclusive
+	endTime := endDate.AddDate(0, 0, 1).Add(-time.Second)
+
+	ew := startExport(w, format, cryptoExportColumns, exportFilename("crypto", symbol, startDate, endDate, format))
+	err = db.StreamCryptoRates(r.Context(), dbSymbol, startDate, endTime, func(rate storage.CryptoRate) error {
+		return ew.Write(rate.Timestamp, rate.Symbol, rate.Open, rate.High, rate.Low, rate.Close, rate.Volume)
+	})
+	finishExport(r.Context(), w, ew, err)
+}
+
+// startExport sets download headers and returns a writer that flushes the
+// response every few hundred rows
+func startExport(w http.ResponseWriter, format export.Format, columns []string, filename string) *export.Writer {
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	flush := func() {}
+	if f, ok := w.(http.Flusher); ok {
+		flush = f.Flush
+	}
+	ew := export.NewWriter(w, format, columns, flush)
+	if money.NumbersRequested(w) {
+		ew.NumberDecimals()
+	}
+	return ew
+}
+
+// finishExport flushes the tail of the export. A failure before the first row
+// is still reported as a JSON error; after it the status is sent, so the
+// stream ends with an error record instead (see export.Writer.Abort)
+func finishExport(ctx context.Context, w http.ResponseWriter, ew *export.Writer, err error) {
+	if err != nil {
+		if ew.Rows() == 0 {
+			w.Header().Del("Content-Disposition")
+			writeErrorResponse(w, http.StatusInternalServerError, "Failed to read rates: "+err.Error())
+			return
+		}
+		logger.ErrorContext(ctx, "export aborted", "rows", ew.Rows(), "error", err)
+		if err := ew.Abort("Failed to read rates: " + err.Error()); err != nil {
+			logger.WarnContext(ctx, "export error record not written", "error", err)
+		}
+		return
+	}
+	if err := ew.Flush(); err != nil {
+		logger.WarnContext(ctx, "export flush failed", "rows", ew.Rows(), "error", err)
+	}
+}
+
+// exportFilename builds names like cbr_USD_2015-01-01_2024-12-31.csv
+func exportFilename(kind, code string, startDate, endDate time.Time, format export.Format) string {
+	name := kind
+	if code != "" {
+		name += "_" + code
+	}
+	return fmt.Sprintf("%s_%s_%s.%s", name, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"), format.Ext())
+}
@@ -6,8 +6,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/casualdoto/go-currency-tracker/internal/apiv1"
 	"github.com/casualdoto/go-currency-tracker/internal/currency/cbr"
+	"github.com/casualdoto/go-currency-tracker/internal/export"
+	"github.com/casualdoto/go-currency-tracker/internal/money"
+	"github.com/casualdoto/go-currency-tracker/internal/storage"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -35,8 +40,8 @@ func (p *MockCurrencyProvider) GetCurrencyRate(code, date string) (*currency.Val
 			CharCode: "USD",
 			Nominal:  1,
 			Name:     "US Dollar",
-			Value:    85.0504,
-			Previous: 87.1992,
+			Value:    money.MustParse("85.0504"),
+			Previous: money.MustParse("87.1992"),
 		}, nil
 	}
 
@@ -128,6 +133,26 @@ func TestPingHandler(t *testing.T) {
 	}
 }
 
+// Testing HealthzHandler and ReadyzHandler without a database
+func TestHealthHandlers(t *testing.T) {
+	routes := SetupRoutes()
+	for _, path := range []string{"/healthz", "/readyz"} {
+		rr := httptest.NewRecorder()
+		routes.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if rr.Code != http.StatusOK {
+			t.Errorf("%s: wrong status code: got %v, expected %v", path, rr.Code, http.StatusOK)
+		}
+		var report HealthReport
+		if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+			t.Fatalf("%s: error parsing JSON: %v", path, err)
+		}
+		if report.Status != "ok" || len(report.Components) != 0 {
+			t.Errorf("%s: expected status ok without components, got %+v", path, report)
+		}
+	}
+}
+
 // Testing InfoHandler
 func TestInfoHandler(t *testing.T) {
 	// Create request
@@ -283,9 +308,10 @@ func TestCORSMiddleware(t *testing.T) {
 
 	// Check CORS headers
 	expectedHeaders := map[string]string{
-		"Access-Control-Allow-Origin":  "*",
-		"Access-Control-Allow-Methods": "GET, POST, OPTIONS, PUT, DELETE",
-		"Access-Control-Allow-Headers": "Content-Type, Authorization",
+		"Access-Control-Allow-Origin":   "*",
+		"Access-Control-Allow-Methods":  "GET, POST, OPTIONS, PUT, DELETE",
+		"Access-Control-Allow-Headers":  "Content-Type, Authorization, X-Request-ID",
+		"Access-Control-Expose-Headers": "X-Request-ID",
 	}
 
 	for header, expected := range expectedHeaders {
@@ -314,3 +340,412 @@ func TestCORSMiddleware(t *testing.T) {
 		t.Errorf("Expected empty body for OPTIONS request, got: %s", rrOptions.Body.String())
 	}
 }
+
+// Testing ConvertHandler parameter validation
+func TestConvertHandler_validation(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"missing from and to", "/convert?amount=250"},
+		{"missing to", "/convert?from=EUR&amount=250"},
+		{"invalid amount", "/convert?from=EUR&to=CNY&amount=abc"},
+		{"negative amount", "/convert?from=EUR&to=CNY&amount=-5"},
+		{"invalid date", "/convert?from=EUR&to=CNY&amount=250&date=10.03.2025"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tc.url, nil)
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(ConvertHandler).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("Wrong status code: got %v, expected %v", status, http.StatusBadRequest)
+			}
+
+			var response APIResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Error parsing JSON: %v", err)
+			}
+			if response.Success || response.Error == "" {
+				t.Errorf("Expected error response, got %+v", response)
+			}
+		})
+	}
+}
+
+// Testing AnalyticsHandler and CorrelationHandler parameter validation
+func TestAnalyticsHandlers_validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		url     string
+	}{
+		{"missing code", AnalyticsHandler, "/rates/analytics?start_date=2024-01-01&end_date=2024-03-31"},
+		{"invalid source", AnalyticsHandler, "/rates/analytics?code=USD&source=moex&start_date=2024-01-01&end_date=2024-03-31"},
+		{"missing dates", AnalyticsHandler, "/rates/analytics?code=USD"},
+		{"reversed range", AnalyticsHandler, "/rates/analytics?code=USD&start_date=2024-03-31&end_date=2024-01-01"},
+		{"range too long", AnalyticsHandler, "/rates/analytics?code=USD&start_date=2022-01-01&end_date=2024-01-01"},
+		{"single code", CorrelationHandler, "/rates/analytics/correlation?codes=USD&start_date=2024-01-01&end_date=2024-03-31"},
+		{"invalid date", CorrelationHandler, "/rates/analytics/correlation?codes=USD,EUR&start_date=01.01.2024&end_date=2024-03-31"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tc.url, nil)
+			rr := httptest.NewRecorder()
+			tc.handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("Wrong status code: got %v, expected %v", status, http.StatusBadRequest)
+			}
+
+			var response APIResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Error parsing JSON: %v", err)
+			}
+			if response.Success || response.Error == "" {
+				t.Errorf("Expected error response, got %+v", response)
+			}
+		})
+	}
+}
+
+// Testing CoverageHandler parameter validation
+func TestCoverageHandler_validation(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"missing source", "/admin/coverage?code=USD&from=2024-01-01&to=2024-01-31"},
+		{"invalid source", "/admin/coverage?source=moex&from=2024-01-01&to=2024-01-31"},
+		{"missing dates", "/admin/coverage?source=cbr&code=USD"},
+		{"reversed range", "/admin/coverage?source=cbr&from=2024-03-31&to=2024-01-01"},
+		{"cbr interval", "/admin/coverage?source=cbr&from=2024-01-01&to=2024-01-31&interval=1h"},
+		{"missing symbol", "/admin/coverage?source=crypto&from=2024-01-01&to=2024-01-31"},
+		{"invalid interval", "/admin/coverage?source=crypto&code=BTCUSDT&from=2024-01-01&to=2024-01-31&interval=2h"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tc.url, nil)
+			rr := httptest.NewRecorder()
+			CoverageHandler(rr, req)
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("Wrong status code: got %v, expected %v", status, http.StatusBadRequest)
+			}
+
+			var response APIResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Error parsing JSON: %v", err)
+			}
+			if response.Success || response.Error == "" {
+				t.Errorf("Expected error response, got %+v", response)
+			}
+		})
+	}
+}
+
+// Testing CryptoIndicatorsHandler parameter validation
+func TestCryptoIndicatorsHandler_validation(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"missing symbol", "/rates/crypto/indicators?indicators=rsi14"},
+		{"missing indicators", "/rates/crypto/indicators?symbol=BTC"},
+		{"unknown indicator", "/rates/crypto/indicators?symbol=BTC&indicators=vwap"},
+		{"unsupported interval", "/rates/crypto/indicators?symbol=BTC&indicators=rsi14&interval=2h"},
+		{"invalid condition", "/rates/crypto/indicators?symbol=BTC&indicators=rsi14&when=rsi14"},
+		{"missing end_date", "/rates/crypto/indicators?symbol=BTC&indicators=rsi14&start_date=2024-01-01"},
+		{"too many bars", "/rates/crypto/indicators?symbol=BTC&indicators=rsi14&interval=1m&start_date=2024-01-01&end_date=2024-03-01"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tc.url, nil)
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(CryptoIndicatorsHandler).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("Wrong status code: got %v, expected %v", status, http.StatusBadRequest)
+			}
+
+			var response APIResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Error parsing JSON: %v", err)
+			}
+			if response.Success || response.Error == "" {
+				t.Errorf("Expected error response, got %+v", response)
+			}
+		})
+	}
+}
+
+// Testing export handlers parameter validation
+func TestExportHandlers_validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		url     string
+	}{
+		{"parquet", ExportCurrencyHistoryHandler, "/rates/cbr/history/range/export?start_date=2024-01-01&end_date=2024-03-31&format=parquet"},
+		{"missing dates", ExportCurrencyHistoryHandler, "/rates/cbr/history/range/export?code=USD"},
+		{"reversed range", ExportCurrencyHistoryHandler, "/rates/cbr/history/range/export?start_date=2024-03-31&end_date=2024-01-01"},
+		{"unknown format", ExportCryptoHistoryHandler, "/rates/crypto/history/range/export?symbol=BTC&start_date=2024-01-01&end_date=2024-03-31&format=xlsx"},
+		{"invalid date", ExportCryptoHistoryHandler, "/rates/crypto/history/range/export?symbol=BTC&start_date=2024-13-01&end_date=2024-03-31"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tc.url, nil)
+			rr := httptest.NewRecorder()
+			tc.handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("Wrong status code: got %v, expected %v", status, http.StatusBadRequest)
+			}
+			if disposition := rr.Header().Get("Content-Disposition"); disposition != "" {
+				t.Errorf("Unexpected Content-Disposition on error: %s", disposition)
+			}
+
+			var response APIResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Error parsing JSON: %v", err)
+			}
+			if response.Success || response.Error == "" {
+				t.Errorf("Expected error response, got %+v", response)
+			}
+		})
+	}
+}
+
+// Testing nominal change requests: invalid ranges are rejected before the
+// database is needed
+func TestCBRNominalChangesHandler(t *testing.T) {
+	for url, want := range map[string]int{
+		"/rates/cbr/nominal-changes?code=KZT":                                           http.StatusBadRequest,
+		"/rates/cbr/nominal-changes?start_date=2024-03-31&end_date=2024-01-01":          http.StatusBadRequest,
+		"/rates/cbr/nominal-changes?start_date=2015-01-01&end_date=2024-12-31&code=kzt": http.StatusInternalServerError,
+	} {
+		req, _ := http.NewRequest("GET", url, nil)
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(CBRNominalChangesHandler).ServeHTTP(rr, req)
+
+		if status := rr.Code; status != want {
+			t.Errorf("%s: wrong status code: got %v, expected %v", url, status, want)
+		}
+	}
+}
+
+// Testing that a long export range is accepted (no 365 day limit)
+func TestExportHandlers_longRange(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/rates/cbr/history/range/export?start_date=2015-01-01&end_date=2024-12-31", nil)
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(ExportCurrencyHistoryHandler).ServeHTTP(rr, req)
+
+	// Without a database the request passes validation and fails on the connection
+	if status := rr.Code; status != http.StatusInternalServerError {
+		t.Errorf("Wrong status code: got %v, expected %v", status, http.StatusInternalServerError)
+	}
+}
+
+// Testing export file names
+func TestExportFilename(t *testing.T) {
+	start := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+	if got := exportFilename("cbr", "USD", start, end, export.CSV); got != "cbr_USD_2015-01-01_2024-12-31.csv" {
+		t.Errorf("Unexpected file name: %s", got)
+	}
+	if got := exportFilename("crypto", "", start, end, export.NDJSON); got != "crypto_2015-01-01_2024-12-31.ndjson" {
+		t.Errorf("Unexpected file name: %s", got)
+	}
+}
+
+// Testing /v1 parameter validation and the v1 error format
+func TestV1Handlers_validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		url     string
+	}{
+		{"invalid date", V1CBRRatesHandler, "/v1/rates/cbr?date=15.01.2024"},
+		{"missing code", V1CBRRangeHandler, "/v1/rates/cbr/range?from=2024-01-01&to=2024-01-31"},
+		{"legacy range parameters", V1CBRRangeHandler, "/v1/rates/cbr/range?code=USD&start_date=2024-01-01&end_date=2024-01-31"},
+		{"invalid as_of", V1CBRRatesHandler, "/v1/rates/cbr?date=2024-01-15&as_of=yesterday"},
+		{"invalid range as_of", V1CBRRangeHandler, "/v1/rates/cbr/range?code=USD&from=2024-01-01&to=2024-01-31&as_of=16.01.2024"},
+		{"missing symbol", V1CryptoRangeHandler, "/v1/rates/crypto/range?from=2024-01-01&to=2024-01-31"},
+		{"range too long", V1CryptoRangeHandler, "/v1/rates/crypto/range?symbol=BTC&from=2022-01-01&to=2024-01-01"},
+		{"unknown indicator", V1CryptoIndicatorsHandler, "/v1/rates/crypto/indicators?symbol=BTC&indicators=vwap"},
+		{"invalid amount", V1ConvertHandler, "/v1/convert?from=EUR&to=CNY&amount=abc"},
+		{"invalid source", V1AnalyticsHandler, "/v1/analytics?code=USD&source=moex&from=2024-01-01&to=2024-03-31"},
+		{"single code", V1CorrelationHandler, "/v1/analytics/correlation?codes=USD&from=2024-01-01&to=2024-03-31"},
+		{"invalid metal date", V1MetalPricesHandler, "/v1/rates/metals?date=15.01.2024"},
+		{"unknown metal", V1MetalRangeHandler, "/v1/rates/metals/range?metal=BTC&from=2024-01-01&to=2024-01-31"},
+		{"missing metal range", V1MetalRangeHandler, "/v1/rates/metals/range?metal=XAU"},
+		{"invalid indicator date", V1IndicatorRatesHandler, "/v1/rates/indicators?date=15.01.2024"},
+		{"missing rate indicator", V1IndicatorRangeHandler, "/v1/rates/indicators/range?from=2024-01-01&to=2024-01-31"},
+		{"unknown rate indicator", V1IndicatorRangeHandler, "/v1/rates/indicators/range?indicator=MIACR&from=2024-01-01&to=2024-01-31"},
+		{"missing indicator range", V1IndicatorRangeHandler, "/v1/rates/indicators/range?indicator=KEY_RATE"},
+		{"missing revision code", V1CBRRevisionsHandler, "/v1/rates/cbr/revisions?date=2024-01-13"},
+		{"invalid revision date", V1CBRRevisionsHandler, "/v1/rates/cbr/revisions?code=USD&date=13.01.2024"},
+		{"legacy nominal change range", V1CBRNominalChangesHandler, "/v1/rates/cbr/nominal-changes?start_date=2015-01-01&end_date=2024-12-31"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tc.url, nil)
+			rr := httptest.NewRecorder()
+			tc.handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("Wrong status code: got %v, expected %v", status, http.StatusBadRequest)
+			}
+
+			var response apiv1.ErrorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Error parsing JSON: %v", err)
+			}
+			if response.Error.Code != apiv1.CodeBadRequest || response.Error.Message == "" {
+				t.Errorf("Expected bad_request error, got %+v", response)
+			}
+		})
+	}
+}
+
+// Testing unknown /v1 routes and deprecation headers of legacy routes
+func TestV1Routes(t *testing.T) {
+	router := SetupRoutes()
+
+	req, _ := http.NewRequest("GET", "/v1/rates/unknown", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Wrong status code: got %v, expected %v", rr.Code, http.StatusNotFound)
+	}
+	var response apiv1.ErrorResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil || response.Error.Code != apiv1.CodeNotFound {
+		t.Errorf("Expected not_found error, got %s", rr.Body.String())
+	}
+
+	// Validation fails before any rate is requested
+	req, _ = http.NewRequest("GET", "/convert?from=EUR", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Header().Get("Deprecation") != "true" {
+		t.Errorf("Expected Deprecation header on /convert")
+	}
+	if link := rr.Header().Get("Link"); link != `</v1/convert>; rel="successor-version"` {
+		t.Errorf("Unexpected Link header: %s", link)
+	}
+}
+
+// Testing conversion of rates to v1 DTOs
+func TestV1DTOs(t *testing.T) {
+	friday := time.Date(2024, 1, 12, 0, 0, 0, 0, time.UTC)
+	monday := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	rates := v1CurrencyRates([]storage.CurrencyRate{
+		{Date: monday, CurrencyCode: "USD", CurrencyName: "US Dollar", Nominal: 1, Value: money.MustParse("88.6"), Previous: money.MustParse("89.7")},
+		{Date: monday, CurrencyCode: "AMD", CurrencyName: "Armenian Dram", Nominal: 100, Value: money.MustParse("21.9"), CarriedFrom: &friday},
+	})
+	if len(rates) != 2 || rates[0].Code != "AMD" || rates[1].Code != "USD" || rates[1].Date != "2024-01-15" {
+		t.Errorf("Unexpected currency rates: %+v", rates)
+	}
+	if rates[0].CarriedFrom != "2024-01-12" || rates[1].CarriedFrom != "" {
+		t.Errorf("Unexpected carry-over: %+v", rates)
+	}
+
+	stored := storedCurrencyRate(storage.CurrencyRate{
+		Date: friday.AddDate(0, 0, 1), CurrencyCode: "USD", Nominal: 1, Value: money.MustParse("89.7"), CarriedFrom: &friday,
+	})
+	if stored.Date != "2024-01-13" || stored.CarriedFrom != "2024-01-12" {
+		t.Errorf("Unexpected stored rate: %+v", stored)
+	}
+
+	// KZT moved from 100 to 1000 units: the per-unit value stays continuous
+	history := []apiv1.CurrencyRate{
+		storedCurrencyRate(storage.CurrencyRate{Date: friday, CurrencyCode: "KZT", Nominal: 100, Value: money.MustParse("17.5")}),
+		storedCurrencyRate(storage.CurrencyRate{Date: monday, CurrencyCode: "KZT", Nominal: 1000, Value: money.MustParse("176"), UnitValue: money.MustParse("0.176")}),
+	}
+	markNominalChanges(history)
+	if history[0].UnitValue.String() != "0.175" || history[1].UnitValue.String() != "0.176" {
+		t.Errorf("Unexpected unit values: %+v", history)
+	}
+	if history[0].PreviousNominal != 0 || history[1].PreviousNominal != 100 {
+		t.Errorf("Unexpected nominal change flags: %+v", history)
+	}
+
+	metals := v1MetalPrices([]storage.MetalPrice{
+		{Date: monday, Metal: "XAU", Name: "Gold", Buy: money.MustParse("6512.37"), Sell: money.MustParse("6512.37")},
+	})
+	if len(metals) != 1 || metals[0].Date != "2024-01-15" || metals[0].Metal != "XAU" || metals[0].Buy.String() != "6512.37" {
+		t.Errorf("Unexpected metal prices: %+v", metals)
+	}
+	if !isMetalCode("XPD") || isMetalCode("Gold") {
+		t.Error("Expected metals to be matched by ISO code only")
+	}
+
+	revisions := v1RateRevisions([]storage.CurrencyRateRevision{
+		{Date: friday.AddDate(0, 0, 1), CurrencyCode: "KZT", CurrencyName: "Tenge", Nominal: 100, Value: money.MustParse("17.5"), CarriedFrom: &friday},
+	})
+	if len(revisions) != 1 || revisions[0].Date != "2024-01-13" || revisions[0].CarriedFrom != "2024-01-12" ||
+		revisions[0].UnitValue.String() != "0.175" || !revisions[0].Previous.IsZero() {
+		t.Errorf("Unexpected revisions: %+v", revisions)
+	}
+
+	changes := v1NominalChanges([]storage.NominalChange{
+		{Date: monday, CurrencyCode: "KZT", PreviousNominal: 100, Nominal: 1000},
+	})
+	if len(changes) != 1 || changes[0].Date != "2024-01-15" || changes[0].Code != "KZT" || changes[0].PreviousNominal != 100 {
+		t.Errorf("Unexpected nominal changes: %+v", changes)
+	}
+
+	indicatorRows := []storage.IndicatorRate{
+		{Indicator: "KEY_RATE", EffectiveDate: friday, Name: "Key rate", Value: money.MustParse("16")},
+		{Indicator: "RUONIA", EffectiveDate: monday, Name: "RUONIA", Value: money.MustParse("15.84")},
+	}
+	indicators := v1IndicatorRates(indicatorRows)
+	if len(indicators) != 2 || indicators[0].EffectiveDate != "2024-01-12" || indicators[1].Value.String() != "15.84" {
+		t.Errorf("Unexpected indicator rates: %+v", indicators)
+	}
+	if !hasIndicator(indicatorRows, "RUONIA") || hasIndicator(indicatorRows[:1], "RUONIA") {
+		t.Error("Expected indicators to be found by code")
+	}
+
+	for in, want := range map[string]string{"BTC/RUB": "BTC", "btcusdt": "BTC", "ETH": "ETH", "USDT": "USDT"} {
+		if got := baseSymbol(in); got != want {
+			t.Errorf("baseSymbol(%q) = %q, expected %q", in, got, want)
+		}
+	}
+}
+
+// TestFormatDBRatesToValuteMap checks that the legacy rates keep the
+// carry-over of a stored rate
+func TestFormatDBRatesToValuteMap(t *testing.T) {
+	friday := time.Date(2024, 1, 12, 0, 0, 0, 0, time.UTC)
+	monday := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	valute := formatDBRatesToValuteMap([]storage.CurrencyRate{
+		{Date: monday, CurrencyCode: "USD", CurrencyName: "US Dollar", Nominal: 1, Value: money.MustParse("89.7"), CarriedFrom: &friday},
+		{Date: monday, CurrencyCode: "EUR", CurrencyName: "Euro", Nominal: 1, Value: money.MustParse("98.1")},
+	})
+	if valute["USD"].CarriedFrom != "2024-01-12" || valute["EUR"].CarriedFrom != "" {
+		t.Errorf("Unexpected carry-over: %+v", valute)
+	}
+
+	data, err := json.Marshal(valute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded["USD"]["carried_from"] != "2024-01-12" {
+		t.Errorf("Expected carried_from in the legacy USD rate, got %s", data)
+	}
+	if _, ok := decoded["EUR"]["carried_from"]; ok {
+		t.Errorf("Expected no carried_from for a published rate, got %s", data)
+	}
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/internal/logging"
+	"github.com/casualdoto/go-currency-tracker/internal/storage"
+)
+
+// readyTimeout bounds each dependency check of /readyz
+const readyTimeout = 2 * time.Second
+
+// HealthComponent is the result of one dependency check. The report has
+// the same shape as the /readyz of the microservices
+type HealthComponent struct {
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// HealthReport is the body of /healthz and /readyz
+type HealthReport struct {
+	Status     string                     `json:"status"`
+	Components map[string]HealthComponent `json:"components,omitempty"`
+}
+
+// HealthzHandler answers while the server runs. It checks no dependency, so
+// a database outage does not get the server restarted
+func HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, http.StatusOK, HealthReport{Status: "ok"})
+}
+
+// ReadyzHandler checks the database, when the routes have one, and answers
+// 503 with status "unavailable" when it is down. The CBR and Binance APIs
+// are not checked
+func ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	report := HealthReport{Status: "ok", Components: map[string]HealthComponent{}}
+	if db, ok := r.Context().Value("db").(*storage.PostgresDB); ok {
+		ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+		defer cancel()
+
+		start := time.Now()
+		c := HealthComponent{Status: "ok"}
+		if err := db.Ping(ctx); err != nil {
+			c.Status, c.Error = "down", err.Error()
+			report.Status = "unavailable"
+		}
+		c.DurationMs = logging.Millis(time.Since(start))
+		report.Components["postgres"] = c
+	}
+
+	code := http.StatusOK
+	if report.Status != "ok" {
+		code = http.StatusServiceUnavailable
+	}
+	writeHealth(w, code, report)
+}
+
+func writeHealth(w http.ResponseWriter, code int, report HealthReport) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	encodeJSON(w, report)
+}
@@ -0,0 +1,243 @@
+// Package api provides HTTP request handlers and API route setup.
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/internal/apiv1"
+	"github.com/casualdoto/go-currency-tracker/internal/currency/binance"
+	"github.com/casualdoto/go-currency-tracker/internal/indicators"
+	"github.com/casualdoto/go-currency-tracker/internal/storage"
+)
+
+// Number of bars returned when no date range is given, and the upper bound
+// for any request
+const (
+	defaultIndicatorBars = 100
+	maxIndicatorBars     = 5000
+)
+
+// IndicatorsResult is the response data of the crypto indicators endpoint
+type IndicatorsResult = apiv1.IndicatorsResult
+
+// ConditionResult reports whether a condition from the when parameter holds
+type ConditionResult = apiv1.ConditionResult
+
+// CryptoIndicatorsHandler returns technical indicators (SMA, EMA, RSI, MACD,
+// Bollinger Bands) computed over stored RUB candles of a cryptocurrency.
+// Requires query parameters symbol (e.g. BTC) and indicators (comma-separated,
+// e.g. rsi14,ema20,macd,bb20). Supports optional query parameters interval
+// (1m, 5m, 15m, 30m, 1h, 4h, 1d; default 1h), start_date and end_date in
+// YYYY-MM-DD format (default: the last 100 bars) and when (comma-separated
+// conditions such as rsi14>70, evaluated on the latest values).
+func CryptoIndicatorsHandler(w http.ResponseWriter, r *http.Request) {
+	req, errMsg := parseIndicatorRequest(r, "start_date", "end_date")
+	if errMsg != "" {
+		writeErrorResponse(w, http.StatusBadRequest, errMsg)
+		return
+	}
+
+	db, ok := r.Context().Value("db").(*storage.PostgresDB)
+	if !ok || db == nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Database connection not available")
+		return
+	}
+
+	result, err := loadIndicators(r.Context(), db, req)
+	if err != nil {
+		writeErrorResponse(w, indicatorsErrorStatus(err), err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encodeJSON(w, APIResponse{
+		Success: true,
+		Data:    result,
+	})
+}
+
+// indicatorRequest is a validated indicators query
+type indicatorRequest struct {
+	symbol     string
+	interval   string
+	step       time.Duration
+	specs      []indicators.Spec
+	conditions []indicators.Condition
+	startTime  time.Time
+	endTime    time.Time
+}
+
+// errNoCandles is returned by loadIndicators when the range has no candles
+var errNoCandles = errors.New("No data")
+
+// indicatorsErrorStatus maps loadIndicators errors to 404 or 500
+func indicatorsErrorStatus(err error) int {
+	if errors.Is(err, errNoCandles) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// parseIndicatorRequest validates the indicators query; the optional date range
+// is read from the named parameters. Returns an error message for the client on failure.
+func parseIndicatorRequest(r *http.Request, startParam, endParam string) (indicatorRequest, string) {
+	symbol := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("symbol")))
+	if symbol == "" {
+		return indicatorRequest{}, "Symbol not specified (parameter symbol)"
+	}
+
+	interval := r.URL.Query().Get("interval")
+	if interval == "" {
+		interval = string(binance.Interval1h)
+	}
+	step, err := indicators.ParseInterval(interval)
+	if err != nil {
+		return indicatorRequest{}, err.Error()
+	}
+
+	specs, err := indicators.ParseSpecs(r.URL.Query().Get("indicators"))
+	if err != nil {
+		return indicatorRequest{}, err.Error()
+	}
+
+	var conditions []indicators.Condition
+	if when := r.URL.Query().Get("when"); when != "" {
+		for _, part := range strings.Split(when, ",") {
+			condition, err := indicators.ParseCondition(part)
+			if err != nil {
+				return indicatorRequest{}, err.Error()
+			}
+			conditions = append(conditions, condition)
+		}
+	}
+
+	// Without a date range return the last bars up to now
+	endTime := time.Now().UTC()
+	startTime := endTime.Add(-defaultIndicatorBars * step)
+	if r.URL.Query().Get(startParam) != "" || r.URL.Query().Get(endParam) != "" {
+		var errMsg string
+		startTime, endTime, errMsg = parseLimitedRange(r, startParam, endParam)
+		if errMsg != "" {
+			return indicatorRequest{}, errMsg
+		}
+		// The end date is inclusive
+		endTime = endTime.AddDate(0, 0, 1).Add(-time.Second)
+	}
+	if bars := endTime.Sub(startTime) / step; bars > maxIndicatorBars {
+		return indicatorRequest{}, fmt.Sprintf("Range too long for interval %s: %d bars (max %d)", interval, bars, maxIndicatorBars)
+	}
+
+	return indicatorRequest{
+		symbol:     symbol,
+		interval:   interval,
+		step:       step,
+		specs:      specs,
+		conditions: conditions,
+		startTime:  startTime,
+		endTime:    endTime,
+	}, ""
+}
+
+// loadIndicators loads the candles of the request, from Binance if the database
+// has too few of them, and computes the indicators
+func loadIndicators(ctx context.Context, db *storage.PostgresDB, req indicatorRequest) (IndicatorsResult, error) {
+	symbol, interval, step := req.symbol, req.interval, req.step
+
+	// Load enough bars before the range to seed every indicator
+	warmup := indicators.MaxWarmup(req.specs)
+	loadFrom := req.startTime.Add(-time.Duration(warmup) * step)
+
+	dbSymbol := symbol + "/RUB"
+	rates, err := db.GetCryptoRatesByDateRange(dbSymbol, loadFrom, req.endTime)
+	if err != nil {
+		return IndicatorsResult{}, fmt.Errorf("Failed to get cryptocurrency rates: %w", err)
+	}
+	candles := indicators.Resample(storedCandles(rates), step)
+
+	// Not enough stored bars: request klines from Binance and cache them
+	if len(candles) <= warmup {
+		cryptoRates, err := binance.NewClient().GetHistoricalCryptoToRubRates(symbol, binance.KlineInterval(interval), loadFrom, req.endTime)
+		if err != nil {
+			logger.WarnContext(ctx, "binance klines failed", "symbol", symbol, "interval", interval, "error", err)
+		} else if len(cryptoRates) > 0 {
+			dbRates := make([]storage.CryptoRate, len(cryptoRates))
+			for i, rate := range cryptoRates {
+				dbRates[i] = storage.CryptoRate{
+					Timestamp: rate.Timestamp,
+					Symbol:    rate.Symbol,
+					Open:      rate.Open,
+					High:      rate.High,
+					Low:       rate.Low,
+					Close:     rate.Close,
+					Volume:    rate.Volume,
+				}
+			}
+			if err := saveBackfilledCryptoRates(db, dbRates); err != nil {
+				// Log the error but continue
+				logger.ErrorContext(ctx, "saving fetched rates failed", "source", "binance", "count", len(dbRates), "error", err)
+			}
+			candles = indicators.Resample(storedCandles(dbRates), step)
+		}
+	}
+
+	if len(candles) == 0 {
+		return IndicatorsResult{}, fmt.Errorf("%w for %s in the requested range", errNoCandles, symbol)
+	}
+	return buildIndicatorsResult(symbol, interval, step, req.startTime, candles, req.specs, req.conditions), nil
+}
+
+// buildIndicatorsResult computes indicators over candles and drops the warmup
+// bars before the bar containing startTime
+func buildIndicatorsResult(symbol, interval string, step time.Duration, startTime time.Time,
+	candles []indicators.Candle, specs []indicators.Spec, conditions []indicators.Condition) IndicatorsResult {
+	start := startTime.UTC().Truncate(step)
+	series := indicators.Compute(candles, specs)
+	indicators.Trim(series, start)
+
+	shown := candles
+	for len(shown) > 0 && shown[0].Time.Before(start) {
+		shown = shown[1:]
+	}
+
+	latest := indicators.Latest(series)
+	latest["close"] = candles[len(candles)-1].Close
+
+	result := IndicatorsResult{
+		Symbol:     symbol,
+		Interval:   interval,
+		Candles:    shown,
+		Indicators: series,
+		Latest:     latest,
+	}
+	for _, condition := range conditions {
+		result.Conditions = append(result.Conditions, ConditionResult{
+			Condition: condition.String(),
+			Met:       condition.Met(latest),
+		})
+	}
+	return result
+}
+
+// storedCandles converts stored crypto rates (OHLC already in RUB) to candles
+func storedCandles(rates []storage.CryptoRate) []indicators.Candle {
+	candles := make([]indicators.Candle, 0, len(rates))
+	for _, rate := range rates {
+		if !rate.Close.IsPositive() {
+			continue
+		}
+		candles = append(candles, indicators.Candle{
+			Time:   rate.Timestamp,
+			Open:   rate.Open.Float64(),
+			High:   rate.High.Float64(),
+			Low:    rate.Low.Float64(),
+			Close:  rate.Close.Float64(),
+			Volume: rate.Volume.Float64(),
+		})
+	}
+	return candles
+}
@@ -12,7 +12,11 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 
+	"github.com/casualdoto/go-currency-tracker/internal/logging"
+	"github.com/casualdoto/go-currency-tracker/internal/metrics"
+	"github.com/casualdoto/go-currency-tracker/internal/money"
 	"github.com/casualdoto/go-currency-tracker/internal/storage"
+	"github.com/casualdoto/go-currency-tracker/internal/stream"
 )
 
 // Function to get the project root path
@@ -36,17 +40,33 @@ func SetupRoutes() http.Handler {
 	r := chi.NewRouter()
 
 	// Middleware
-	r.Use(middleware.Logger)
+	r.Use(logging.Middleware)
+	r.Use(metrics.Middleware)
 	r.Use(middleware.Recoverer)
 	r.Use(CORSMiddleware)
+	// Innermost: decimals as JSON numbers for ?decimals=number
+	r.Use(money.Middleware)
 
 	// Basic routes
 	r.Get("/ping", PingHandler)
+	r.Get("/healthz", HealthzHandler)
+	r.Get("/readyz", ReadyzHandler)
 	r.Get("/info", InfoHandler)
-
-	// Routes for currency rates
-	r.Get("/rates/cbr", CBRRatesHandler)             // All rates (with optional date parameter)
-	r.Get("/rates/cbr/currency", CBRCurrencyHandler) // Specific currency rate
+	r.Method(http.MethodGet, "/metrics", metrics.Handler())
+
+	// Routes for currency rates (legacy, see /v1)
+	r.With(DeprecatedMiddleware("/v1/rates/cbr")).Get("/rates/cbr", CBRRatesHandler) // All rates (with optional date parameter)
+	r.Get("/rates/cbr/currency", CBRCurrencyHandler)                                 // Specific currency rate
+	r.Get("/rates/cbr/next", NextCBRRatesHandler)                                    // Next day's rates, once published
+	r.With(DeprecatedMiddleware("/v1/convert")).Get("/convert", ConvertHandler)      // Amount conversion via cross rates
+
+	// Versioned API; endpoints that need the database are only served by SetupRoutesWithDB
+	r.Route("/v1", func(r chi.Router) {
+		r.NotFound(V1NotFoundHandler)
+		r.MethodNotAllowed(V1MethodNotAllowedHandler)
+		r.Get("/rates/cbr", V1CBRRatesHandler)
+		r.Get("/convert", V1ConvertHandler)
+	})
 
 	// Static OpenAPI documentation
 	r.Get("/api/docs", SwaggerUIHandler)
@@ -60,12 +80,14 @@ func SetupRoutes() http.Handler {
 	return r
 }
 
-// SetupRoutesWithDB configures API routes with database access
-func SetupRoutesWithDB(db *storage.PostgresDB) http.Handler {
+// SetupRoutesWithDB configures API routes with database access and the live
+// rate stream fed by hub
+func SetupRoutesWithDB(db *storage.PostgresDB, hub *stream.Hub) http.Handler {
 	r := chi.NewRouter()
 
 	// Middleware
-	r.Use(middleware.Logger)
+	r.Use(logging.Middleware)
+	r.Use(metrics.Middleware)
 	r.Use(middleware.Recoverer)
 	r.Use(CORSMiddleware)
 
@@ -76,23 +98,67 @@ func SetupRoutesWithDB(db *storage.PostgresDB) http.Handler {
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	})
+	// Innermost: decimals as JSON numbers for ?decimals=number
+	r.Use(money.Middleware)
 
 	// Basic endpoints
 	r.Get("/ping", PingHandler)
+	r.Get("/healthz", HealthzHandler)
+	r.Get("/readyz", ReadyzHandler)
 	r.Get("/info", InfoHandler)
+	r.Method(http.MethodGet, "/metrics", metrics.Handler())
+
+	// Versioned API (shared with the microservices gateway)
+	r.Route("/v1", func(r chi.Router) {
+		r.NotFound(V1NotFoundHandler)
+		r.MethodNotAllowed(V1MethodNotAllowedHandler)
+		r.Get("/rates/cbr", V1CBRRatesHandler)
+		r.Get("/rates/cbr/range", V1CBRRangeHandler)
+		r.Get("/rates/cbr/revisions", V1CBRRevisionsHandler)
+		r.Get("/rates/cbr/nominal-changes", V1CBRNominalChangesHandler)
+		r.Get("/rates/crypto/symbols", V1CryptoSymbolsHandler)
+		r.Get("/rates/crypto/range", V1CryptoRangeHandler)
+		r.Get("/rates/crypto/indicators", V1CryptoIndicatorsHandler)
+		r.Get("/rates/metals", V1MetalPricesHandler)
+		r.Get("/rates/metals/range", V1MetalRangeHandler)
+		r.Get("/rates/indicators", V1IndicatorRatesHandler)
+		r.Get("/rates/indicators/range", V1IndicatorRangeHandler)
+		r.Get("/convert", V1ConvertHandler)
+		r.Get("/analytics", V1AnalyticsHandler)
+		r.Get("/analytics/correlation", V1CorrelationHandler)
+		r.Get("/stream", hub.ServeSSE)
+		r.Get("/stream/ws", hub.ServeWS)
+	})
 
+	// Legacy routes with a /v1 successor answer with Deprecation and Link headers
 	// CBR rates endpoints
-	r.Get("/rates/cbr", CBRRatesHandler)
+	r.With(DeprecatedMiddleware("/v1/rates/cbr")).Get("/rates/cbr", CBRRatesHandler)
 	r.Get("/rates/cbr/currency", CBRCurrencyHandler)
+	r.Get("/rates/cbr/next", NextCBRRatesHandler)
 	r.Get("/rates/cbr/history", GetCurrencyHistoryHandler)
-	r.Get("/rates/cbr/history/range", GetCurrencyHistoryByDateRangeHandler)
+	r.With(DeprecatedMiddleware("/v1/rates/cbr/range")).Get("/rates/cbr/history/range", GetCurrencyHistoryByDateRangeHandler)
 	r.Get("/rates/cbr/history/range/excel", ExportCurrencyHistoryToExcelHandler)
+	r.Get("/rates/cbr/history/range/export", ExportCurrencyHistoryHandler)
+	r.With(DeprecatedMiddleware("/v1/rates/cbr/revisions")).Get("/rates/cbr/revisions", CBRRevisionsHandler)
+	r.With(DeprecatedMiddleware("/v1/rates/cbr/nominal-changes")).Get("/rates/cbr/nominal-changes", CBRNominalChangesHandler)
 
 	// Crypto rates endpoints
-	r.Get("/rates/crypto/symbols", GetAvailableCryptoSymbolsHandler)
+	r.With(DeprecatedMiddleware("/v1/rates/crypto/symbols")).Get("/rates/crypto/symbols", GetAvailableCryptoSymbolsHandler)
 	r.Get("/rates/crypto/history", GetCryptoHistoryHandler)
-	r.Get("/rates/crypto/history/range", GetCryptoHistoryByDateRangeHandler)
+	r.With(DeprecatedMiddleware("/v1/rates/crypto/range")).Get("/rates/crypto/history/range", GetCryptoHistoryByDateRangeHandler)
 	r.Get("/rates/crypto/history/range/excel", ExportCryptoHistoryToExcelHandler)
+	r.Get("/rates/crypto/history/range/export", ExportCryptoHistoryHandler)
+	r.With(DeprecatedMiddleware("/v1/rates/crypto/indicators")).Get("/rates/crypto/indicators", CryptoIndicatorsHandler)
+
+	// Conversion endpoint
+	r.With(DeprecatedMiddleware("/v1/convert")).Get("/convert", ConvertHandler)
+
+	// Analytics endpoints
+	r.With(DeprecatedMiddleware("/v1/analytics")).Get("/rates/analytics", AnalyticsHandler)
+	r.With(DeprecatedMiddleware("/v1/analytics/correlation")).Get("/rates/analytics/correlation", CorrelationHandler)
+
+	// Gaps in the stored history
+	r.Get("/admin/coverage", CoverageHandler)
 
 	// API documentation
 	r.Get("/api/docs", SwaggerUIHandler)
@@ -221,7 +287,7 @@ func OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
 	// If file not found in any location
 	if docsPath == "" {
 		errMsg := fmt.Sprintf("API documentation not found. Searched in: %s", strings.Join(searchPaths, ", "))
-		fmt.Println(errMsg)
+		logger.WarnContext(r.Context(), "API documentation not found", "searched", searchPaths)
 		http.Error(w, errMsg, http.StatusNotFound)
 		return
 	}
@@ -1,6 +1,11 @@
 // Package api provides HTTP request handlers and API route setup.
 package api
 
+import (
+	currency "github.com/casualdoto/go-currency-tracker/internal/currency/cbr"
+	"github.com/casualdoto/go-currency-tracker/internal/money"
+)
+
 // APIResponse represents standard API response structure
 type APIResponse struct {
 	Success bool        `json:"success"`
@@ -10,11 +15,19 @@ type APIResponse struct {
 
 // HistoricalCryptoRate represents crypto currency historical data
 type HistoricalCryptoRate struct {
-	Timestamp int64   `json:"timestamp"`
-	Date      string  `json:"date"`
-	Open      float64 `json:"open"`
-	High      float64 `json:"high"`
-	Low       float64 `json:"low"`
-	Close     float64 `json:"close"`
-	Volume    float64 `json:"volume"`
+	Timestamp int64         `json:"timestamp"`
+	Date      string        `json:"date"`
+	Open      money.Decimal `json:"open"`
+	High      money.Decimal `json:"high"`
+	Low       money.Decimal `json:"low"`
+	Close     money.Decimal `json:"close"`
+	Volume    money.Decimal `json:"volume"`
+}
+
+// NextCBRRates represents rates the CBR has published ahead of the day they
+// take effect
+type NextCBRRates struct {
+	Date        string                     `json:"date"`
+	PublishedAt string                     `json:"published_at,omitempty"`
+	Rates       map[string]currency.Valute `json:"rates"`
 }
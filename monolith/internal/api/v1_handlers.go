@@ -0,0 +1,641 @@
+// Package api provides HTTP request handlers and API route setup.
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/internal/analytics"
+	"github.com/casualdoto/go-currency-tracker/internal/apiv1"
+	"github.com/casualdoto/go-currency-tracker/internal/calendar"
+	"github.com/casualdoto/go-currency-tracker/internal/convert"
+	currency "github.com/casualdoto/go-currency-tracker/internal/currency/cbr"
+	"github.com/casualdoto/go-currency-tracker/internal/metrics"
+	"github.com/casualdoto/go-currency-tracker/internal/money"
+	"github.com/casualdoto/go-currency-tracker/internal/storage"
+)
+
+// The /v1 handlers serve the internal/apiv1 contract shared with the
+// microservices gateway on top of the same loaders as the legacy routes:
+// snake_case DTOs wrapped in {"data": ...}, errors as {"error": {"code",
+// "message"}}, date ranges as from/to, crypto symbols as base assets (BTC)
+// with prices in RUB, and rows ordered oldest first.
+
+// writeV1Response writes a successful /v1 response
+func writeV1Response(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	encodeJSON(w, apiv1.Response[interface{}]{Data: data})
+}
+
+// writeV1Error writes a failed /v1 response with the given status
+func writeV1Error(w http.ResponseWriter, statusCode int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	encodeJSON(w, apiv1.NewError(statusCode, message))
+}
+
+// v1Database returns the database from the request context or writes a 500
+func v1Database(w http.ResponseWriter, r *http.Request) (*storage.PostgresDB, bool) {
+	db, ok := r.Context().Value("db").(*storage.PostgresDB)
+	if !ok || db == nil {
+		writeV1Error(w, http.StatusInternalServerError, "Database connection not available")
+		return nil, false
+	}
+	return db, true
+}
+
+// V1NotFoundHandler answers unknown /v1 routes in the v1 error format
+func V1NotFoundHandler(w http.ResponseWriter, r *http.Request) {
+	writeV1Error(w, http.StatusNotFound, "No such endpoint: "+r.URL.Path)
+}
+
+// V1MethodNotAllowedHandler answers /v1 routes called with an unsupported method
+func V1MethodNotAllowedHandler(w http.ResponseWriter, r *http.Request) {
+	writeV1Error(w, http.StatusMethodNotAllowed, "Method "+r.Method+" not allowed")
+}
+
+// V1CBRRatesHandler returns all CBR rates for the optional date parameter
+// (YYYY-MM-DD, default today) ordered by currency code. With as_of the
+// stored rates are returned as they were at that time.
+func V1CBRRatesHandler(w http.ResponseWriter, r *http.Request) {
+	dateStr := r.URL.Query().Get("date")
+	date := calendar.Today()
+	if dateStr != "" {
+		var err error
+		date, err = calendar.ParseDate(dateStr)
+		if err != nil {
+			writeV1Error(w, http.StatusBadRequest, "Invalid date format. Use YYYY-MM-DD")
+			return
+		}
+	}
+	asOf, errMsg := parseAsOf(r)
+	if errMsg != "" {
+		writeV1Error(w, http.StatusBadRequest, errMsg)
+		return
+	}
+
+	if !asOf.IsZero() {
+		// Past values are only kept in the database
+		db, ok := v1Database(w, r)
+		if !ok {
+			return
+		}
+		rates, err := currencyHistoryAsOf(r.Context(), db, "", date, date, asOf)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "as-of query failed", "date", date.Format("2006-01-02"), "error", err)
+			writeV1Error(w, http.StatusInternalServerError, "Failed to query stored rates")
+			return
+		}
+		writeV1Response(w, rates)
+		return
+	}
+
+	// Database is optional: without it rates come straight from the CBR API
+	db, _ := r.Context().Value("db").(*storage.PostgresDB)
+	rates, err := cbrRatesByDate(r.Context(), db, date, dateStr)
+	if err != nil {
+		writeV1Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeV1Response(w, v1CurrencyRates(rates))
+}
+
+// V1CBRRangeHandler returns the rates of one currency between from and to.
+// Requires query parameters code, from and to (YYYY-MM-DD, at most 365 days);
+// as_of reads the stored rates as they were at that time.
+func V1CBRRangeHandler(w http.ResponseWriter, r *http.Request) {
+	code := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("code")))
+	if code == "" {
+		writeV1Error(w, http.StatusBadRequest, "Currency code not specified (parameter code)")
+		return
+	}
+	startDate, endDate, errMsg := parseLimitedRange(r, "from", "to")
+	if errMsg != "" {
+		writeV1Error(w, http.StatusBadRequest, errMsg)
+		return
+	}
+
+	asOf, errMsg := parseAsOf(r)
+	if errMsg != "" {
+		writeV1Error(w, http.StatusBadRequest, errMsg)
+		return
+	}
+
+	db, ok := v1Database(w, r)
+	if !ok {
+		return
+	}
+	if asOf.IsZero() {
+		writeV1Response(w, currencyHistory(r.Context(), db, code, startDate, endDate))
+		return
+	}
+	rates, err := currencyHistoryAsOf(r.Context(), db, code, startDate, endDate, asOf)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "as-of query failed", "currency", code, "error", err)
+		writeV1Error(w, http.StatusInternalServerError, "Failed to query stored rates")
+		return
+	}
+	writeV1Response(w, rates)
+}
+
+// V1CBRRevisionsHandler returns every value stored for the rate of a
+// currency on a date, oldest first. Requires query parameters code and date
+// (YYYY-MM-DD)
+func V1CBRRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	code := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("code")))
+	if code == "" {
+		writeV1Error(w, http.StatusBadRequest, "Currency code not specified (parameter code)")
+		return
+	}
+	date, err := calendar.ParseDate(r.URL.Query().Get("date"))
+	if err != nil {
+		writeV1Error(w, http.StatusBadRequest, "Invalid date format. Use YYYY-MM-DD")
+		return
+	}
+
+	db, ok := v1Database(w, r)
+	if !ok {
+		return
+	}
+
+	revisions, err := db.GetCurrencyRateRevisions(r.Context(), code, date)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "revisions query failed", "currency", code, "error", err)
+		writeV1Error(w, http.StatusInternalServerError, "Failed to query stored rates")
+		return
+	}
+	if len(revisions) == 0 {
+		writeV1Error(w, http.StatusNotFound, fmt.Sprintf("No rate of %s stored for %s", code, date.Format("2006-01-02")))
+		return
+	}
+	writeV1Response(w, v1RateRevisions(revisions))
+}
+
+// V1CBRNominalChangesHandler returns the dates on which the CBR changed the
+// nominal of a currency, oldest first. Requires query parameters from and
+// to (YYYY-MM-DD); code limits the result to one currency
+func V1CBRNominalChangesHandler(w http.ResponseWriter, r *http.Request) {
+	startDate, endDate, errMsg := parseDateRangeParams(r, "from", "to")
+	if errMsg != "" {
+		writeV1Error(w, http.StatusBadRequest, errMsg)
+		return
+	}
+	code := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("code")))
+
+	db, ok := v1Database(w, r)
+	if !ok {
+		return
+	}
+
+	changes, err := db.GetNominalChanges(r.Context(), code, startDate, endDate)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "nominal changes query failed", "currency", code, "error", err)
+		writeV1Error(w, http.StatusInternalServerError, "Failed to query stored rates")
+		return
+	}
+	writeV1Response(w, v1NominalChanges(changes))
+}
+
+// V1CryptoSymbolsHandler returns the available cryptocurrencies as base assets
+func V1CryptoSymbolsHandler(w http.ResponseWriter, r *http.Request) {
+	db, ok := v1Database(w, r)
+	if !ok {
+		return
+	}
+
+	symbols := []string{}
+	seen := make(map[string]bool)
+	for _, symbol := range cryptoSymbols(db) {
+		base := baseSymbol(symbol)
+		if !seen[base] {
+			seen[base] = true
+			symbols = append(symbols, base)
+		}
+	}
+	sort.Strings(symbols)
+	writeV1Response(w, symbols)
+}
+
+// V1CryptoRangeHandler returns RUB candles of a cryptocurrency between from
+// and to. Requires query parameters symbol (e.g. BTC), from and to
+// (YYYY-MM-DD, at most 365 days).
+func V1CryptoRangeHandler(w http.ResponseWriter, r *http.Request) {
+	symbol := baseSymbol(r.URL.Query().Get("symbol"))
+	if symbol == "" {
+		writeV1Error(w, http.StatusBadRequest, "Symbol not specified (parameter symbol)")
+		return
+	}
+	startDate, endDate, errMsg := parseLimitedRange(r, "from", "to")
+	if errMsg != "" {
+		writeV1Error(w, http.StatusBadRequest, errMsg)
+		return
+	}
+
+	db, ok := v1Database(w, r)
+	if !ok {
+		return
+	}
+
+	// to is inclusive
+	rates, err := cryptoHistory(r.Context(), db, symbol, startDate, endDate.AddDate(0, 0, 1))
+	if err != nil {
+		writeV1Error(w, http.StatusInternalServerError, "Failed to get cryptocurrency rates: "+err.Error())
+		return
+	}
+	writeV1Response(w, v1CryptoRates(symbol, rates))
+}
+
+// V1CryptoIndicatorsHandler is CryptoIndicatorsHandler with the date range
+// given as from and to.
+func V1CryptoIndicatorsHandler(w http.ResponseWriter, r *http.Request) {
+	req, errMsg := parseIndicatorRequest(r, "from", "to")
+	if errMsg != "" {
+		writeV1Error(w, http.StatusBadRequest, errMsg)
+		return
+	}
+	req.symbol = baseSymbol(req.symbol)
+
+	db, ok := v1Database(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := loadIndicators(r.Context(), db, req)
+	if err != nil {
+		writeV1Error(w, indicatorsErrorStatus(err), err.Error())
+		return
+	}
+	writeV1Response(w, result)
+}
+
+// V1MetalPricesHandler returns the CBR prices of gold, silver, platinum and
+// palladium in effect on the optional date parameter (YYYY-MM-DD, default
+// today): those of the latest date on or before it with prices
+func V1MetalPricesHandler(w http.ResponseWriter, r *http.Request) {
+	date := calendar.Today()
+	if dateStr := r.URL.Query().Get("date"); dateStr != "" {
+		var err error
+		date, err = calendar.ParseDate(dateStr)
+		if err != nil {
+			writeV1Error(w, http.StatusBadRequest, "Invalid date format. Use YYYY-MM-DD")
+			return
+		}
+	}
+
+	db, ok := v1Database(w, r)
+	if !ok {
+		return
+	}
+
+	prices, err := db.GetMetalPricesOn(date)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "metal prices query failed", "date", date.Format("2006-01-02"), "error", err)
+		writeV1Error(w, http.StatusInternalServerError, "Failed to query stored metal prices")
+		return
+	}
+	writeV1Response(w, v1MetalPrices(prices))
+}
+
+// V1MetalRangeHandler returns metal prices between from and to. Requires
+// query parameters from and to (YYYY-MM-DD, at most 365 days); the optional
+// metal (XAU, XAG, XPT or XPD) limits them to one metal.
+func V1MetalRangeHandler(w http.ResponseWriter, r *http.Request) {
+	metal := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("metal")))
+	if metal != "" && !isMetalCode(metal) {
+		writeV1Error(w, http.StatusBadRequest, "metal must be one of XAU, XAG, XPT, XPD")
+		return
+	}
+	startDate, endDate, errMsg := parseLimitedRange(r, "from", "to")
+	if errMsg != "" {
+		writeV1Error(w, http.StatusBadRequest, errMsg)
+		return
+	}
+
+	db, ok := v1Database(w, r)
+	if !ok {
+		return
+	}
+
+	prices, err := db.GetMetalPricesByDateRange(metal, startDate, endDate)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "metal prices query failed", "metal", metal, "error", err)
+		writeV1Error(w, http.StatusInternalServerError, "Failed to query stored metal prices")
+		return
+	}
+	writeV1Response(w, v1MetalPrices(prices))
+}
+
+// V1IndicatorRatesHandler returns the CBR key rate and RUONIA in effect on
+// the optional date parameter (YYYY-MM-DD, default today). An indicator
+// with nothing stored on or before the date is fetched from the CBR first
+func V1IndicatorRatesHandler(w http.ResponseWriter, r *http.Request) {
+	date := calendar.Today()
+	if dateStr := r.URL.Query().Get("date"); dateStr != "" {
+		var err error
+		date, err = calendar.ParseDate(dateStr)
+		if err != nil {
+			writeV1Error(w, http.StatusBadRequest, "Invalid date format. Use YYYY-MM-DD")
+			return
+		}
+	}
+
+	db, ok := v1Database(w, r)
+	if !ok {
+		return
+	}
+
+	rates, err := db.GetIndicatorRatesOn(date)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "indicator rates query failed", "date", date.Format("2006-01-02"), "error", err)
+		writeV1Error(w, http.StatusInternalServerError, "Failed to query stored indicator rates")
+		return
+	}
+	filled := false
+	for _, code := range indicatorCodes {
+		if !hasIndicator(rates, code) {
+			filled = backfillIndicatorRates(r, db, code, date, date) || filled
+		}
+	}
+	if filled {
+		if rates, err = db.GetIndicatorRatesOn(date); err != nil {
+			writeV1Error(w, http.StatusInternalServerError, "Failed to query stored indicator rates")
+			return
+		}
+	}
+	writeV1Response(w, v1IndicatorRates(rates))
+}
+
+// V1IndicatorRangeHandler returns the values of a CBR indicator in effect
+// between from and to, one per change: the first is the one in effect on
+// from. Requires query parameters indicator (KEY_RATE or RUONIA), from and
+// to (YYYY-MM-DD, at most 365 days). History missing before from is
+// fetched from the CBR first
+func V1IndicatorRangeHandler(w http.ResponseWriter, r *http.Request) {
+	indicator := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("indicator")))
+	if _, ok := currency.Indicators[indicator]; !ok {
+		writeV1Error(w, http.StatusBadRequest, "indicator must be one of "+strings.Join(indicatorCodes, ", "))
+		return
+	}
+	startDate, endDate, errMsg := parseLimitedRange(r, "from", "to")
+	if errMsg != "" {
+		writeV1Error(w, http.StatusBadRequest, errMsg)
+		return
+	}
+
+	db, ok := v1Database(w, r)
+	if !ok {
+		return
+	}
+
+	rates, err := db.GetIndicatorRatesByDateRange(indicator, startDate, endDate)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "indicator rates query failed", "indicator", indicator, "error", err)
+		writeV1Error(w, http.StatusInternalServerError, "Failed to query stored indicator rates")
+		return
+	}
+	if len(rates) == 0 || rates[0].EffectiveDate.After(startDate) {
+		if backfillIndicatorRates(r, db, indicator, startDate, endDate) {
+			if rates, err = db.GetIndicatorRatesByDateRange(indicator, startDate, endDate); err != nil {
+				writeV1Error(w, http.StatusInternalServerError, "Failed to query stored indicator rates")
+				return
+			}
+		}
+	}
+	writeV1Response(w, v1IndicatorRates(rates))
+}
+
+// backfillIndicatorRates stores the values of indicator the CBR published
+// up to to, reaching back far enough for the one in effect on from, and
+// reports whether any were stored
+func backfillIndicatorRates(r *http.Request, db *storage.PostgresDB, indicator string, from, to time.Time) bool {
+	rates, err := fetchIndicatorRates(indicator, from, to)
+	if err != nil {
+		metrics.Backfill(metrics.SourceCBRIndicators, err)
+		logger.WarnContext(r.Context(), "indicator rates fetch failed", "indicator", indicator, "error", err)
+		return false
+	}
+	if len(rates) == 0 {
+		return false
+	}
+	if err := saveBackfilledIndicatorRates(db, rates); err != nil {
+		logger.ErrorContext(r.Context(), "indicator rates save failed", "indicator", indicator, "error", err)
+		return false
+	}
+	return true
+}
+
+// V1ConvertHandler converts an amount between currencies and cryptocurrencies.
+// Takes the same parameters as ConvertHandler.
+func V1ConvertHandler(w http.ResponseWriter, r *http.Request) {
+	req, errMsg := parseConvertRequest(r)
+	if errMsg != "" {
+		writeV1Error(w, http.StatusBadRequest, errMsg)
+		return
+	}
+
+	// Database is optional: without it the converter asks CBR and Binance directly
+	db, _ := r.Context().Value("db").(*storage.PostgresDB)
+	result, err := convert.NewConverter(db).Convert(req.amount, req.from, req.to, req.date)
+	if err != nil {
+		writeV1Error(w, convertErrorStatus(err), err.Error())
+		return
+	}
+	writeV1Response(w, apiv1.Conversion(*result))
+}
+
+// V1AnalyticsHandler is AnalyticsHandler with the date range given as from and to
+func V1AnalyticsHandler(w http.ResponseWriter, r *http.Request) {
+	code := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("code")))
+	if code == "" {
+		writeV1Error(w, http.StatusBadRequest, "Currency code not specified (parameter code)")
+		return
+	}
+	source := r.URL.Query().Get("source")
+	if source != "" && source != analytics.SourceCBR && source != analytics.SourceCrypto {
+		writeV1Error(w, http.StatusBadRequest, "Invalid source parameter, must be cbr or crypto")
+		return
+	}
+	startDate, endDate, errMsg := parseLimitedRange(r, "from", "to")
+	if errMsg != "" {
+		writeV1Error(w, http.StatusBadRequest, errMsg)
+		return
+	}
+
+	db, ok := v1Database(w, r)
+	if !ok {
+		return
+	}
+
+	points, usedSource, err := analytics.LoadSeries(db, source, code, startDate, endDate)
+	if err != nil {
+		writeV1Error(w, analyticsErrorStatus(err), err.Error())
+		return
+	}
+	writeV1Response(w, AnalyticsResult{
+		Code:    code,
+		Source:  usedSource,
+		Summary: analytics.Summarize(points),
+	})
+}
+
+// V1CorrelationHandler is CorrelationHandler with the date range given as from and to
+func V1CorrelationHandler(w http.ResponseWriter, r *http.Request) {
+	codes, errMsg := parseCorrelationCodes(r)
+	if errMsg != "" {
+		writeV1Error(w, http.StatusBadRequest, errMsg)
+		return
+	}
+	startDate, endDate, errMsg := parseLimitedRange(r, "from", "to")
+	if errMsg != "" {
+		writeV1Error(w, http.StatusBadRequest, errMsg)
+		return
+	}
+
+	db, ok := v1Database(w, r)
+	if !ok {
+		return
+	}
+
+	matrix, err := correlate(db, codes, startDate, endDate)
+	if err != nil {
+		writeV1Error(w, analyticsErrorStatus(err), err.Error())
+		return
+	}
+	writeV1Response(w, matrix)
+}
+
+// v1CurrencyRates converts CBR rates of one date to DTOs ordered by code
+func v1CurrencyRates(rates []storage.CurrencyRate) []apiv1.CurrencyRate {
+	result := make([]apiv1.CurrencyRate, 0, len(rates))
+	for _, rate := range rates {
+		result = append(result, storedCurrencyRate(rate))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Code < result[j].Code })
+	return result
+}
+
+// v1CryptoRates converts stored RUB candles of symbol to DTOs ordered by time
+func v1CryptoRates(symbol string, rates []storage.CryptoRate) []apiv1.CryptoRate {
+	result := make([]apiv1.CryptoRate, 0, len(rates))
+	for _, rate := range rates {
+		result = append(result, apiv1.CryptoRate{
+			Time:   rate.Timestamp.UTC(),
+			Symbol: symbol,
+			Open:   rate.Open,
+			High:   rate.High,
+			Low:    rate.Low,
+			Close:  rate.Close,
+			Volume: rate.Volume,
+		})
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Time.Before(result[j].Time) })
+	return result
+}
+
+// v1RateRevisions converts stored revisions of a rate to DTOs
+func v1RateRevisions(revisions []storage.CurrencyRateRevision) []apiv1.RateRevision {
+	result := make([]apiv1.RateRevision, 0, len(revisions))
+	for _, rev := range revisions {
+		dto := apiv1.RateRevision{
+			Date:       rev.Date.Format("2006-01-02"),
+			Code:       rev.CurrencyCode,
+			Name:       rev.CurrencyName,
+			Nominal:    rev.Nominal,
+			Value:      rev.Value,
+			UnitValue:  rev.UnitValue,
+			Source:     rev.Source,
+			FetchedAt:  rev.FetchedAt,
+			RecordedAt: rev.RecordedAt,
+		}
+		if rev.Previous != nil {
+			dto.Previous = *rev.Previous
+		}
+		if !dto.UnitValue.IsPositive() {
+			dto.UnitValue = money.PerUnit(rev.Value, rev.Nominal)
+		}
+		if rev.CarriedFrom != nil {
+			dto.CarriedFrom = rev.CarriedFrom.Format("2006-01-02")
+		}
+		result = append(result, dto)
+	}
+	return result
+}
+
+// v1NominalChanges converts stored nominal changes to DTOs
+func v1NominalChanges(changes []storage.NominalChange) []apiv1.NominalChange {
+	result := make([]apiv1.NominalChange, 0, len(changes))
+	for _, change := range changes {
+		result = append(result, apiv1.NominalChange{
+			Date:            change.Date.Format("2006-01-02"),
+			Code:            change.CurrencyCode,
+			PreviousNominal: change.PreviousNominal,
+			Nominal:         change.Nominal,
+		})
+	}
+	return result
+}
+
+// v1MetalPrices converts stored metal prices to DTOs
+func v1MetalPrices(prices []storage.MetalPrice) []apiv1.MetalPrice {
+	result := make([]apiv1.MetalPrice, 0, len(prices))
+	for _, price := range prices {
+		result = append(result, apiv1.MetalPrice{
+			Date:  price.Date.Format("2006-01-02"),
+			Metal: price.Metal,
+			Name:  price.Name,
+			Buy:   price.Buy,
+			Sell:  price.Sell,
+		})
+	}
+	return result
+}
+
+// indicatorCodes are the CBR indicators served, in the order they are listed
+var indicatorCodes = []string{currency.IndicatorKeyRate, currency.IndicatorRUONIA}
+
+// hasIndicator reports whether rates hold a value of indicator
+func hasIndicator(rates []storage.IndicatorRate, indicator string) bool {
+	for _, rate := range rates {
+		if rate.Indicator == indicator {
+			return true
+		}
+	}
+	return false
+}
+
+// v1IndicatorRates converts stored indicator values to DTOs
+func v1IndicatorRates(rates []storage.IndicatorRate) []apiv1.IndicatorRate {
+	result := make([]apiv1.IndicatorRate, 0, len(rates))
+	for _, rate := range rates {
+		result = append(result, apiv1.IndicatorRate{
+			EffectiveDate: rate.EffectiveDate.Format("2006-01-02"),
+			Indicator:     rate.Indicator,
+			Name:          rate.Name,
+			Value:         rate.Value,
+		})
+	}
+	return result
+}
+
+// isMetalCode reports whether code is the ISO code of a metal the CBR prices
+func isMetalCode(code string) bool {
+	for _, metal := range currency.Metals {
+		if metal.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// baseSymbol maps stored and exchange symbols (BTC/RUB, BTCUSDT) to the base asset (BTC)
+func baseSymbol(symbol string) string {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	symbol = strings.TrimSuffix(symbol, "/RUB")
+	if len(symbol) > len("USDT") {
+		symbol = strings.TrimSuffix(symbol, "USDT")
+	}
+	return symbol
+}
@@ -0,0 +1,185 @@
+// Package apiv1 is the versioned /v1 HTTP contract, identical to the one
+// served by the microservices gateway: explicit DTOs with snake_case fields,
+// a {"data": ...} envelope for successful responses and a common error
+// format. Field names and meanings here must not change; additions are allowed.
+//
+// Rates, prices and amounts are money.Decimal values written as JSON strings
+// ("87.0341"). Clients that need JSON numbers, the format before decimals,
+// ask for it with ?decimals=number (see money.Numbers).
+package apiv1
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/internal/analytics"
+	"github.com/casualdoto/go-currency-tracker/internal/indicators"
+	"github.com/casualdoto/go-currency-tracker/internal/money"
+)
+
+// Response wraps the data of every successful /v1 response
+type Response[T any] struct {
+	Data T `json:"data"`
+}
+
+// ErrorResponse is the body of every failed /v1 response
+type ErrorResponse struct {
+	Error Error `json:"error"`
+}
+
+// Error describes a failure: Code is stable and machine-readable, Message is
+// meant for humans and may change.
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error codes
+const (
+	CodeBadRequest  = "bad_request"
+	CodeNotFound    = "not_found"
+	CodeInternal    = "internal"
+	CodeUnavailable = "unavailable"
+)
+
+// CodeForStatus maps an HTTP status to an error code
+func CodeForStatus(status int) string {
+	switch {
+	case status == http.StatusNotFound:
+		return CodeNotFound
+	case status == http.StatusBadGateway || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout:
+		return CodeUnavailable
+	case status >= 400 && status < 500:
+		return CodeBadRequest
+	}
+	return CodeInternal
+}
+
+// NewError returns the error body for status
+func NewError(status int, message string) ErrorResponse {
+	return ErrorResponse{Error: Error{Code: CodeForStatus(status), Message: message}}
+}
+
+// CurrencyRate is an official CBR rate: Value is the price of Nominal units
+// in RUB on Date (YYYY-MM-DD), Previous the price on the previous CBR date.
+// UnitValue is the price of one unit, comparable across dates when the CBR
+// changes the nominal; PreviousNominal is set on the first date with a new
+// nominal. CarriedFrom is set when the CBR published nothing for Date (a
+// weekend or holiday) and the rate was carried over from that earlier
+// publication.
+type CurrencyRate struct {
+	Date            string        `json:"date"`
+	Code            string        `json:"code"`
+	Name            string        `json:"name"`
+	Nominal         int           `json:"nominal"`
+	Value           money.Decimal `json:"value"`
+	Previous        money.Decimal `json:"previous"`
+	UnitValue       money.Decimal `json:"unit_value"`
+	PreviousNominal int           `json:"previous_nominal,omitempty"`
+	CarriedFrom     string        `json:"carried_from,omitempty"`
+}
+
+// RateRevision is one stored value of the CBR rate of Code on Date
+// (YYYY-MM-DD). Source is the URL it was fetched from and FetchedAt the
+// fetch time; RecordedAt is when the value was saved
+type RateRevision struct {
+	Date        string        `json:"date"`
+	Code        string        `json:"code"`
+	Name        string        `json:"name"`
+	Nominal     int           `json:"nominal"`
+	Value       money.Decimal `json:"value"`
+	Previous    money.Decimal `json:"previous"`
+	UnitValue   money.Decimal `json:"unit_value"`
+	CarriedFrom string        `json:"carried_from,omitempty"`
+	Source      string        `json:"source,omitempty"`
+	FetchedAt   *time.Time    `json:"fetched_at,omitempty"`
+	RecordedAt  time.Time     `json:"recorded_at"`
+}
+
+// NominalChange is a date (YYYY-MM-DD) on which the CBR started quoting Code
+// for Nominal units instead of PreviousNominal. Value jumps on that date;
+// UnitValue does not
+type NominalChange struct {
+	Date            string `json:"date"`
+	Code            string `json:"code"`
+	PreviousNominal int    `json:"previous_nominal"`
+	Nominal         int    `json:"nominal"`
+}
+
+// CryptoRate is a candle of a cryptocurrency priced in RUB. Symbol is the
+// base asset (BTC), Time the candle open time in UTC.
+type CryptoRate struct {
+	Time   time.Time     `json:"time"`
+	Symbol string        `json:"symbol"`
+	Open   money.Decimal `json:"open"`
+	High   money.Decimal `json:"high"`
+	Low    money.Decimal `json:"low"`
+	Close  money.Decimal `json:"close"`
+	Volume money.Decimal `json:"volume"`
+}
+
+// MetalPrice is the CBR's discount price of a precious metal on Date
+// (YYYY-MM-DD). Metal is the ISO 4217 code (XAU, XAG, XPT, XPD); Buy and
+// Sell are in RUB per gram
+type MetalPrice struct {
+	Date  string        `json:"date"`
+	Metal string        `json:"metal"`
+	Name  string        `json:"name"`
+	Buy   money.Decimal `json:"buy"`
+	Sell  money.Decimal `json:"sell"`
+}
+
+// IndicatorRate is a value of a CBR indicator (KEY_RATE, RUONIA) in
+// percent per annum. It took effect on EffectiveDate (YYYY-MM-DD) and stays
+// in effect until the next value of the indicator, so the key rate has one
+// per board decision
+type IndicatorRate struct {
+	EffectiveDate string        `json:"effective_date"`
+	Indicator     string        `json:"indicator"`
+	Name          string        `json:"name"`
+	Value         money.Decimal `json:"value"`
+}
+
+// Conversion is the result of converting Amount of From into To. Rate is
+// the number of To units per one From unit, to money.PriceScale places, and
+// Result is rounded to the minor unit of To; the rate dates differ from Date
+// when a previous business day's rate was carried over.
+type Conversion struct {
+	From         string        `json:"from"`
+	To           string        `json:"to"`
+	Amount       money.Decimal `json:"amount"`
+	Result       money.Decimal `json:"result"`
+	Rate         money.Decimal `json:"rate"`
+	Date         string        `json:"date"`
+	FromRateDate string        `json:"from_rate_date"`
+	ToRateDate   string        `json:"to_rate_date"`
+}
+
+// AnalyticsResult holds summary statistics of one series. Source is cbr or
+// crypto; Code is a currency code or a crypto base asset.
+type AnalyticsResult struct {
+	Code    string            `json:"code"`
+	Source  string            `json:"source"`
+	Summary analytics.Summary `json:"summary"`
+}
+
+// Correlation is the Pearson matrix of daily log returns
+type Correlation = analytics.CorrelationMatrix
+
+// IndicatorsResult holds technical indicators over RUB candles of Symbol
+type IndicatorsResult struct {
+	Symbol     string                        `json:"symbol"`
+	Interval   string                        `json:"interval"`
+	Candles    []indicators.Candle           `json:"candles"`
+	Indicators map[string][]indicators.Value `json:"indicators"`
+	// Latest holds the last value of every indicator plus "close", so threshold
+	// conditions such as rsi14>70 can be evaluated against it.
+	Latest     map[string]float64 `json:"latest"`
+	Conditions []ConditionResult  `json:"conditions,omitempty"`
+}
+
+// ConditionResult reports whether a condition from ?when= holds on Latest
+type ConditionResult struct {
+	Condition string `json:"condition"`
+	Met       bool   `json:"met"`
+}
@@ -0,0 +1,35 @@
+package apiv1
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/internal/money"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCodeForStatus checks the mapping of HTTP statuses to error codes
+func TestCodeForStatus(t *testing.T) {
+	assert.Equal(t, CodeBadRequest, CodeForStatus(http.StatusBadRequest))
+	assert.Equal(t, CodeBadRequest, CodeForStatus(http.StatusMethodNotAllowed))
+	assert.Equal(t, CodeNotFound, CodeForStatus(http.StatusNotFound))
+	assert.Equal(t, CodeInternal, CodeForStatus(http.StatusInternalServerError))
+	assert.Equal(t, CodeUnavailable, CodeForStatus(http.StatusBadGateway))
+}
+
+// TestNewError_json checks the common error format
+func TestNewError_json(t *testing.T) {
+	b, err := json.Marshal(NewError(http.StatusNotFound, "No data for XYZ"))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"error":{"code":"not_found","message":"No data for XYZ"}}`, string(b))
+}
+
+// TestResponse_json checks the success envelope and crypto DTO field names
+func TestResponse_json(t *testing.T) {
+	rates := []CryptoRate{{Time: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Symbol: "BTC", Close: money.MustParse("5600000")}}
+	b, err := json.Marshal(Response[[]CryptoRate]{Data: rates})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"data":[{"time":"2024-03-01T00:00:00Z","symbol":"BTC","open":"0","high":"0","low":"0","close":"5600000","volume":"0"}]}`, string(b))
+}
@@ -0,0 +1,106 @@
+// Package calendar defines the days CBR rates belong to. The CBR sets a rate
+// for a calendar day in Moscow, so "today" and the day of a timestamp are
+// taken in Europe/Moscow, not in UTC or the host's zone. Days themselves are
+// represented as midnight UTC, the form PostgreSQL DATE columns are scanned
+// into and dates are compared and formatted in.
+//
+// An instant and a date need different conversions: Day takes the Moscow
+// date of an instant (time.Now(), a fetch time), Date keeps the calendar
+// date of a value that already is one (a parsed YYYY-MM-DD, a DATE column).
+package calendar
+
+import (
+	"errors"
+	"time"
+
+	// Embedded so that Moscow resolves in images without a zoneinfo database
+	_ "time/tzdata"
+)
+
+// Moscow is the zone CBR calendar days are counted in
+var Moscow = mustLoad("Europe/Moscow")
+
+func mustLoad(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// Day returns the Moscow calendar day of the instant t
+func Day(t time.Time) time.Time {
+	return Date(t.In(Moscow))
+}
+
+// Today returns the current Moscow calendar day
+func Today() time.Time {
+	return Day(time.Now())
+}
+
+// Date returns the calendar date of t in t's own location as midnight UTC
+func Date(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// ParseDate parses a YYYY-MM-DD date
+func ParseDate(s string) (time.Time, error) {
+	return time.Parse(time.DateOnly, s)
+}
+
+// sheetLayouts are the forms of the Date field of a CBR sheet: RFC 3339 with
+// the Moscow offset in daily_json.js, Moscow wall time in the archive
+var sheetLayouts = []string{time.RFC3339, "2006/01/02 15:04:05"}
+
+// ErrSheetDate is returned by ParseSheetDate for an unrecognised date
+var ErrSheetDate = errors.New("unrecognised CBR sheet date")
+
+// ParseSheetDate returns the day a CBR sheet is dated by its Date field.
+// Times without an offset are Moscow wall time
+func ParseSheetDate(s string) (time.Time, error) {
+	for _, layout := range sheetLayouts {
+		if t, err := time.ParseInLocation(layout, s, Moscow); err == nil {
+			return Day(t), nil
+		}
+	}
+	return time.Time{}, ErrSheetDate
+}
+
+// IsBusinessDay reports whether day is a Moscow working day, Monday to
+// Friday: the days the CBR publishes a sheet on. Public holidays are not
+// known. This is not the calendar of sheet dates, as a sheet is dated the
+// day after it is published; use IsSheetDay for those
+func IsBusinessDay(day time.Time) bool {
+	wd := day.Weekday()
+	return wd != time.Saturday && wd != time.Sunday
+}
+
+// BusinessDays returns the business days in [from, to]
+func BusinessDays(from, to time.Time) []time.Time {
+	return days(from, to, IsBusinessDay)
+}
+
+// IsSheetDay reports whether a CBR sheet can be dated day, a Tuesday to
+// Saturday. Friday's sheet is dated Saturday and stays in effect on Sunday
+// and Monday, which have no sheet of their own. Public holidays are not
+// known
+func IsSheetDay(day time.Time) bool {
+	wd := day.Weekday()
+	return wd != time.Sunday && wd != time.Monday
+}
+
+// SheetDays returns the days in [from, to] a CBR sheet can be dated
+func SheetDays(from, to time.Time) []time.Time {
+	return days(from, to, IsSheetDay)
+}
+
+func days(from, to time.Time, keep func(time.Time) bool) []time.Time {
+	var days []time.Time
+	for d := Date(from); !d.After(Date(to)); d = d.AddDate(0, 0, 1) {
+		if keep(d) {
+			days = append(days, d)
+		}
+	}
+	return days
+}
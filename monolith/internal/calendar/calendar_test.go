@@ -0,0 +1,93 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDay checks the Moscow day of instants around midnight and the CBR
+// publication time
+func TestDay(t *testing.T) {
+	tests := []struct {
+		name string
+		at   time.Time
+		want string
+	}{
+		{"UTC midnight is 03:00 in Moscow", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), "2024-01-15"},
+		{"last instant of a Moscow day", time.Date(2024, 1, 15, 20, 59, 59, 999999999, time.UTC), "2024-01-15"},
+		{"Moscow midnight", time.Date(2024, 1, 15, 21, 0, 0, 0, time.UTC), "2024-01-16"},
+		{"between 00:00 and 03:00 MSK", time.Date(2024, 1, 15, 23, 30, 0, 0, time.UTC), "2024-01-16"},
+		{"just before publication", time.Date(2024, 1, 16, 11, 29, 0, 0, Moscow), "2024-01-16"},
+		{"just after publication", time.Date(2024, 1, 16, 11, 31, 0, 0, Moscow), "2024-01-16"},
+		{"west of UTC", time.Date(2024, 1, 15, 19, 0, 0, 0, time.FixedZone("EST", -5*3600)), "2024-01-16"},
+		{"east of Moscow", time.Date(2024, 1, 16, 2, 0, 0, 0, time.FixedZone("+07", 7*3600)), "2024-01-15"},
+		{"Moscow summer time before 2011", time.Date(2010, 7, 1, 20, 30, 0, 0, time.UTC), "2010-07-02"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Day(tc.at)
+			assert.Equal(t, tc.want, got.Format(time.DateOnly))
+			assert.Equal(t, time.UTC, got.Location())
+			assert.True(t, got.Equal(Date(got)), "expected midnight, got %s", got)
+		})
+	}
+}
+
+// TestDate checks that date values keep their calendar date
+func TestDate(t *testing.T) {
+	// A date value in a zone east of Moscow is not moved to the previous day
+	d := time.Date(2024, 1, 16, 0, 0, 0, 0, time.FixedZone("+07", 7*3600))
+	assert.Equal(t, "2024-01-16", Date(d).Format(time.DateOnly))
+
+	parsed, err := ParseDate("2024-01-16")
+	require.NoError(t, err)
+	assert.True(t, Date(parsed).Equal(parsed))
+	assert.True(t, Day(parsed).Equal(parsed))
+}
+
+// TestParseSheetDate checks both forms of the Date field of a CBR sheet
+func TestParseSheetDate(t *testing.T) {
+	for in, want := range map[string]string{
+		"2024-01-16T11:30:00+03:00": "2024-01-16",
+		"2024-01-16T00:00:00+03:00": "2024-01-16",
+		"2024-01-15T21:00:00Z":      "2024-01-16",
+		"2024/01/16 11:30:00":       "2024-01-16",
+		"2024/01/16 00:30:00":       "2024-01-16",
+	} {
+		got, err := ParseSheetDate(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got.Format(time.DateOnly), in)
+	}
+	for _, in := range []string{"tomorrow", "16.01.2024", ""} {
+		_, err := ParseSheetDate(in)
+		assert.ErrorIs(t, err, ErrSheetDate, in)
+	}
+}
+
+// TestBusinessDays checks that weekends are skipped
+func TestBusinessDays(t *testing.T) {
+	date := func(s string) time.Time {
+		d, _ := ParseDate(s)
+		return d
+	}
+	// Fri 2024-01-12 .. Tue 2024-01-16
+	assert.Equal(t, []time.Time{date("2024-01-12"), date("2024-01-15"), date("2024-01-16")},
+		BusinessDays(date("2024-01-12"), date("2024-01-16")))
+	assert.False(t, IsBusinessDay(date("2024-01-13")))
+}
+
+// TestSheetDays checks that sheets are expected Tuesday to Saturday
+func TestSheetDays(t *testing.T) {
+	date := func(s string) time.Time {
+		d, _ := ParseDate(s)
+		return d
+	}
+	// Fri 2024-01-12 .. Tue 2024-01-16
+	assert.Equal(t, []time.Time{date("2024-01-12"), date("2024-01-13"), date("2024-01-16")},
+		SheetDays(date("2024-01-12"), date("2024-01-16")))
+	assert.False(t, IsSheetDay(date("2024-01-15")))
+	assert.False(t, IsSheetDay(date("2024-01-14")))
+}
@@ -2,18 +2,22 @@ package config
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
 
+	"github.com/casualdoto/go-currency-tracker/internal/logging"
 	"github.com/joho/godotenv"
 )
 
+var logger = logging.For("config")
+
 // Config holds all configuration variables
 type Config struct {
 	CBRBaseURL       string
+	CBRMetalsURL     string
+	CBRIndicatorsURL string
 	TelegramBotToken string
 	TelegramChatID   string
 	DBHost           string
@@ -54,16 +58,16 @@ func loadEnvironment() {
 	// Find project root by looking for go.mod file
 	projectRoot := findProjectRoot()
 	if projectRoot == "" {
-		log.Println("Warning: Could not find project root, using current directory")
+		logger.Warn("project root not found, using the current directory")
 		projectRoot = "."
 	}
 
 	envPath := filepath.Join(projectRoot, ".env")
 	err := godotenv.Load(envPath)
 	if err != nil {
-		log.Printf("Warning: Could not load .env file from %s: %v", envPath, err)
+		logger.Warn(".env file not loaded", "path", envPath, "error", err)
 	} else {
-		log.Printf("Successfully loaded .env file from %s", envPath)
+		logger.Info(".env file loaded", "path", envPath)
 	}
 }
 
@@ -94,6 +98,8 @@ func findProjectRoot() string {
 // loadFromEnv loads configuration from environment variables
 func loadFromEnv() {
 	config.CBRBaseURL = getEnvWithDefault("CBR_BASE_URL", "https://www.cbr-xml-daily.ru")
+	config.CBRMetalsURL = getEnvWithDefault("CBR_METALS_URL", "https://www.cbr.ru")
+	config.CBRIndicatorsURL = getEnvWithDefault("CBR_INDICATORS_URL", "https://www.cbr.ru")
 	config.TelegramBotToken = getEnvWithDefault("TELEGRAM_BOT_TOKEN", "")
 	config.TelegramChatID = getEnvWithDefault("TELEGRAM_CHAT_ID", "")
 	config.DBHost = getEnvWithDefault("DB_HOST", "localhost")
@@ -107,8 +113,10 @@ func loadFromEnv() {
 
 	// Clean up URLs by removing quotes if they exist
 	config.CBRBaseURL = strings.Trim(config.CBRBaseURL, `"`)
+	config.CBRMetalsURL = strings.Trim(config.CBRMetalsURL, `"`)
+	config.CBRIndicatorsURL = strings.Trim(config.CBRIndicatorsURL, `"`)
 
-	log.Printf("Configuration loaded - CBR_BASE_URL: %s", config.CBRBaseURL)
+	logger.Info("configuration loaded", "cbr_base_url", config.CBRBaseURL)
 }
 
 // getEnvWithDefault gets environment variable with default value
@@ -124,6 +132,18 @@ func GetCBRBaseURL() string {
 	return Get().CBRBaseURL
 }
 
+// GetCBRMetalsURL returns the base URL of the CBR site the precious metals
+// prices are read from; cbr-xml-daily.ru does not mirror them
+func GetCBRMetalsURL() string {
+	return Get().CBRMetalsURL
+}
+
+// GetCBRIndicatorsURL returns the base URL of the CBR site the key rate and
+// RUONIA are read from
+func GetCBRIndicatorsURL() string {
+	return Get().CBRIndicatorsURL
+}
+
 // GetTelegramBotToken returns Telegram bot token
 func GetTelegramBotToken() string {
 	return Get().TelegramBotToken
@@ -137,6 +157,23 @@ func SetCBRBaseURLForTesting(url string) {
 	config.CBRBaseURL = url
 }
 
+// SetCBRMetalsURLForTesting sets the CBR metals base URL for testing purposes
+func SetCBRMetalsURLForTesting(url string) {
+	if config == nil {
+		config = &Config{}
+	}
+	config.CBRMetalsURL = url
+}
+
+// SetCBRIndicatorsURLForTesting sets the CBR indicators base URL for testing
+// purposes
+func SetCBRIndicatorsURLForTesting(url string) {
+	if config == nil {
+		config = &Config{}
+	}
+	config.CBRIndicatorsURL = url
+}
+
 // GetDBConnectionString returns database connection string
 func GetDBConnectionString() string {
 	cfg := Get()
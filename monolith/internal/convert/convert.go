@@ -0,0 +1,168 @@
+// Package convert computes cross rates and converts amounts between fiat
+// currencies and cryptocurrencies using stored CBR and crypto RUB rates.
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/internal/calendar"
+	"github.com/casualdoto/go-currency-tracker/internal/currency/binance"
+	currency "github.com/casualdoto/go-currency-tracker/internal/currency/cbr"
+	"github.com/casualdoto/go-currency-tracker/internal/money"
+	"github.com/casualdoto/go-currency-tracker/internal/storage"
+)
+
+// MaxCarryoverDays is how far back a rate is carried over when the requested
+// day has none (weekends and holidays). It matches the 14-day lookback used
+// by the microservices CBR archive backfill.
+const MaxCarryoverDays = 14
+
+// Asset kinds reported in a Quote
+const (
+	KindFiat   = "fiat"
+	KindCrypto = "crypto"
+)
+
+// ErrRateNotFound is returned when no rate exists for a code within the
+// carryover window
+var ErrRateNotFound = errors.New("rate not found")
+
+// Store is the subset of storage.PostgresDB used for lookups
+type Store interface {
+	GetCurrencyRatesByDateRange(code string, startDate, endDate time.Time) ([]storage.CurrencyRate, error)
+	GetCryptoRatesByDateRange(symbol string, startTime, endTime time.Time) ([]storage.CryptoRate, error)
+}
+
+// Quote is the RUB value of a single unit of an asset on a given day
+type Quote struct {
+	Code       string        `json:"code"`
+	Kind       string        `json:"kind"`
+	RUBPerUnit money.Decimal `json:"rub_per_unit"`
+	RateDate   time.Time     `json:"-"`
+}
+
+// Result represents a conversion response. Rate has money.PriceScale places
+// and Result is rounded to the minor unit of To
+type Result struct {
+	From         string        `json:"from"`
+	To           string        `json:"to"`
+	Amount       money.Decimal `json:"amount"`
+	Result       money.Decimal `json:"result"`
+	Rate         money.Decimal `json:"rate"`
+	Date         string        `json:"date"`
+	FromRateDate string        `json:"from_rate_date"`
+	ToRateDate   string        `json:"to_rate_date"`
+}
+
+// Converter resolves quotes from the database first and falls back to the
+// CBR and Binance APIs when nothing is stored
+type Converter struct {
+	store Store
+
+	// Upstream fallbacks, replaceable in tests
+	fetchCBR    func(code, date string) (*currency.Valute, error)
+	fetchCrypto func(symbol string) (*binance.CryptoRate, error)
+	// now is the clock live prices are checked against, replaceable in tests
+	now func() time.Time
+}
+
+// NewConverter creates a converter. db may be nil, in which case only the
+// upstream APIs are used.
+func NewConverter(db *storage.PostgresDB) *Converter {
+	c := &Converter{
+		fetchCBR: currency.GetCurrencyRate,
+		fetchCrypto: func(symbol string) (*binance.CryptoRate, error) {
+			return binance.NewClient().GetCurrentCryptoToRubRate(symbol)
+		},
+		now: time.Now,
+	}
+	if db != nil {
+		c.store = db
+	}
+	return c
+}
+
+// Convert converts amount of from into to as of date. Cross rates go via RUB
+// and respect the CBR nominal (e.g. JPY is quoted per 100 units).
+func (c *Converter) Convert(amount money.Decimal, from, to string, date time.Time) (*Result, error) {
+	from = strings.ToUpper(strings.TrimSpace(from))
+	to = strings.ToUpper(strings.TrimSpace(to))
+	day := calendar.Date(date)
+
+	fromQuote, err := c.QuoteOn(from, day)
+	if err != nil {
+		return nil, err
+	}
+	toQuote, err := c.QuoteOn(to, day)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		From:         from,
+		To:           to,
+		Amount:       amount,
+		Result:       ConvertAmount(amount, fromQuote, toQuote),
+		Rate:         CrossRate(fromQuote, toQuote),
+		Date:         day.Format("2006-01-02"),
+		FromRateDate: fromQuote.RateDate.Format("2006-01-02"),
+		ToRateDate:   toQuote.RateDate.Format("2006-01-02"),
+	}, nil
+}
+
+// CrossRate returns how many units of to one unit of from is worth, to
+// money.PriceScale places
+func CrossRate(from, to Quote) money.Decimal {
+	return from.RUBPerUnit.Div(to.RUBPerUnit, money.PriceScale)
+}
+
+// ConvertAmount returns amount of from in units of to, rounded to the minor
+// unit of to. It divides last, so the result does not inherit the rounding
+// of the cross rate
+func ConvertAmount(amount money.Decimal, from, to Quote) money.Decimal {
+	return amount.Mul(from.RUBPerUnit).Div(to.RUBPerUnit, money.Scale(to.Code, to.Kind == KindCrypto))
+}
+
+// QuoteOn resolves the RUB value of one unit of code on day. Fiat codes are
+// looked up in stored CBR rates, then crypto symbols in stored crypto rates,
+// carrying the latest rate over for up to MaxCarryoverDays.
+func (c *Converter) QuoteOn(code string, day time.Time) (Quote, error) {
+	day = calendar.Date(day)
+	if code == "RUB" {
+		return Quote{Code: code, Kind: KindFiat, RUBPerUnit: money.NewFromInt(1), RateDate: day}, nil
+	}
+
+	if c.store != nil {
+		rates, err := c.store.GetCurrencyRatesByDateRange(code, day.AddDate(0, 0, -MaxCarryoverDays), day)
+		if err == nil && len(rates) > 0 {
+			// Rows are ordered by date descending, the first one is the latest
+			return fiatQuote(code, rates[0].PerUnit(), rates[0].Date), nil
+		}
+
+		// Crypto rows are instants; the Moscow day ends at 21:00 UTC
+		endOfDay := time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, calendar.Moscow).Add(-time.Second)
+		cryptoRates, err := c.store.GetCryptoRatesByDateRange(code+"/RUB", day.AddDate(0, 0, -MaxCarryoverDays), endOfDay)
+		if err == nil && len(cryptoRates) > 0 && cryptoRates[0].Close.IsPositive() {
+			return Quote{Code: code, Kind: KindCrypto, RUBPerUnit: cryptoRates[0].Close, RateDate: calendar.Day(cryptoRates[0].Timestamp)}, nil
+		}
+	}
+
+	// Nothing stored: ask CBR for the day, then Binance for a live price
+	if valute, err := c.fetchCBR(code, day.Format("2006-01-02")); err == nil && valute != nil {
+		return fiatQuote(code, valute.PerUnit(), day), nil
+	}
+	if calendar.Day(c.now()).Equal(day) {
+		if rate, err := c.fetchCrypto(code); err == nil && rate != nil && rate.Close.IsPositive() {
+			return Quote{Code: code, Kind: KindCrypto, RUBPerUnit: rate.Close, RateDate: day}, nil
+		}
+	}
+
+	return Quote{}, fmt.Errorf("%w for %s on or before %s", ErrRateNotFound, code, day.Format("2006-01-02"))
+}
+
+func fiatQuote(code string, perUnit money.Decimal, date time.Time) Quote {
+	return Quote{Code: code, Kind: KindFiat, RUBPerUnit: perUnit, RateDate: calendar.Date(date)}
+}
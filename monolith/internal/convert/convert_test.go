@@ -0,0 +1,193 @@
+package convert
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/internal/calendar"
+	"github.com/casualdoto/go-currency-tracker/internal/currency/binance"
+	currency "github.com/casualdoto/go-currency-tracker/internal/currency/cbr"
+	"github.com/casualdoto/go-currency-tracker/internal/money"
+	"github.com/casualdoto/go-currency-tracker/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Stub store returning rows filtered by the requested window
+type stubStore struct {
+	fiat   []storage.CurrencyRate
+	crypto []storage.CryptoRate
+}
+
+func (s *stubStore) GetCurrencyRatesByDateRange(code string, start, end time.Time) ([]storage.CurrencyRate, error) {
+	var out []storage.CurrencyRate
+	for i := len(s.fiat) - 1; i >= 0; i-- {
+		r := s.fiat[i]
+		if r.CurrencyCode == code && !r.Date.Before(start) && !r.Date.After(end) {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func (s *stubStore) GetCryptoRatesByDateRange(symbol string, start, end time.Time) ([]storage.CryptoRate, error) {
+	var out []storage.CryptoRate
+	for i := len(s.crypto) - 1; i >= 0; i-- {
+		r := s.crypto[i]
+		if r.Symbol == symbol && !r.Timestamp.Before(start) && !r.Timestamp.After(end) {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func newTestConverter(store Store) *Converter {
+	return &Converter{
+		store: store,
+		fetchCBR: func(code, date string) (*currency.Valute, error) {
+			return nil, errors.New("offline")
+		},
+		fetchCrypto: func(symbol string) (*binance.CryptoRate, error) {
+			return nil, errors.New("offline")
+		},
+		now: time.Now,
+	}
+}
+
+func day(s string) time.Time {
+	d, _ := time.Parse("2006-01-02", s)
+	return d
+}
+
+func TestConvert_crossRateViaRUB(t *testing.T) {
+	store := &stubStore{fiat: []storage.CurrencyRate{
+		{Date: day("2025-03-10"), CurrencyCode: "EUR", Nominal: 1, Value: money.MustParse("100")},
+		{Date: day("2025-03-10"), CurrencyCode: "CNY", Nominal: 10, Value: money.MustParse("125")},
+	}}
+	c := newTestConverter(store)
+
+	res, err := c.Convert(money.NewFromInt(250), "eur", "CNY", day("2025-03-10"))
+	require.NoError(t, err)
+
+	// 1 CNY = 12.5 RUB, so 1 EUR = 8 CNY
+	assert.Equal(t, "8", res.Rate.String())
+	assert.Equal(t, "2000", res.Result.String())
+	assert.Equal(t, "EUR", res.From)
+	assert.Equal(t, "2025-03-10", res.FromRateDate)
+}
+
+func TestConvert_nominal(t *testing.T) {
+	store := &stubStore{fiat: []storage.CurrencyRate{
+		{Date: day("2025-03-10"), CurrencyCode: "JPY", Nominal: 100, Value: money.MustParse("60")},
+	}}
+	c := newTestConverter(store)
+
+	res, err := c.Convert(money.NewFromInt(1000), "JPY", "RUB", day("2025-03-10"))
+	require.NoError(t, err)
+	assert.Equal(t, "600", res.Result.String())
+}
+
+func TestConvert_carriesOverWeekend(t *testing.T) {
+	store := &stubStore{fiat: []storage.CurrencyRate{
+		{Date: day("2025-03-07"), CurrencyCode: "USD", Nominal: 1, Value: money.MustParse("88")},
+		{Date: day("2025-03-08"), CurrencyCode: "USD", Nominal: 1, Value: money.MustParse("90")},
+	}}
+	c := newTestConverter(store)
+
+	res, err := c.Convert(money.NewFromInt(1), "USD", "RUB", day("2025-03-09"))
+	require.NoError(t, err)
+	assert.Equal(t, "90", res.Result.String())
+	assert.Equal(t, "2025-03-09", res.Date)
+	assert.Equal(t, "2025-03-08", res.FromRateDate)
+}
+
+func TestConvert_fiatToCrypto(t *testing.T) {
+	store := &stubStore{
+		fiat: []storage.CurrencyRate{
+			{Date: day("2025-03-10"), CurrencyCode: "USD", Nominal: 1, Value: money.MustParse("90")},
+		},
+		crypto: []storage.CryptoRate{
+			{Timestamp: day("2025-03-10").Add(12 * time.Hour), Symbol: "BTC/RUB", Close: money.MustParse("9000000")},
+		},
+	}
+	c := newTestConverter(store)
+
+	res, err := c.Convert(money.NewFromInt(1), "BTC", "USD", day("2025-03-10"))
+	require.NoError(t, err)
+	assert.Equal(t, "100000", res.Result.String())
+}
+
+func TestConvert_livePriceOnMoscowToday(t *testing.T) {
+	// 00:30 in Moscow is still the previous day in UTC
+	now := time.Date(2025, 3, 10, 0, 30, 0, 0, calendar.Moscow)
+	c := newTestConverter(&stubStore{})
+	c.now = func() time.Time { return now }
+	c.fetchCrypto = func(symbol string) (*binance.CryptoRate, error) {
+		return &binance.CryptoRate{Symbol: symbol, Close: money.MustParse("9000000")}, nil
+	}
+
+	res, err := c.Convert(money.NewFromInt(1), "BTC", "RUB", calendar.Day(now))
+	require.NoError(t, err)
+	assert.Equal(t, "2025-03-10", res.Date)
+	assert.Equal(t, "9000000", res.Result.String())
+
+	// The UTC date is yesterday in Moscow, which has no live price
+	_, err = c.Convert(money.NewFromInt(1), "BTC", "RUB", now.UTC())
+	assert.ErrorIs(t, err, ErrRateNotFound)
+}
+
+func TestConvert_cryptoRateDateInMoscow(t *testing.T) {
+	store := &stubStore{crypto: []storage.CryptoRate{
+		// 22:00 UTC on the 9th is 01:00 on the 10th in Moscow
+		{Timestamp: time.Date(2025, 3, 9, 22, 0, 0, 0, time.UTC), Symbol: "BTC/RUB", Close: money.MustParse("9000000")},
+	}}
+	c := newTestConverter(store)
+
+	res, err := c.Convert(money.NewFromInt(1), "BTC", "RUB", day("2025-03-10"))
+	require.NoError(t, err)
+	assert.Equal(t, "2025-03-10", res.FromRateDate)
+
+	_, err = c.Convert(money.NewFromInt(1), "BTC", "RUB", day("2025-03-09"))
+	assert.ErrorIs(t, err, ErrRateNotFound)
+}
+
+func TestConvert_notFound(t *testing.T) {
+	c := newTestConverter(&stubStore{})
+
+	_, err := c.Convert(money.NewFromInt(1), "XXX", "RUB", day("2025-03-10"))
+	assert.ErrorIs(t, err, ErrRateNotFound)
+}
+
+func TestConvert_roundsToMinorUnit(t *testing.T) {
+	store := &stubStore{
+		fiat: []storage.CurrencyRate{
+			{Date: day("2025-03-10"), CurrencyCode: "USD", Nominal: 1, Value: money.MustParse("90.1")},
+			{Date: day("2025-03-10"), CurrencyCode: "JPY", Nominal: 100, Value: money.MustParse("54.3")},
+			{Date: day("2025-03-10"), CurrencyCode: "KWD", Nominal: 1, Value: money.MustParse("265.5")},
+		},
+		crypto: []storage.CryptoRate{
+			{Timestamp: day("2025-03-10").Add(12 * time.Hour), Symbol: "BTC/RUB", Close: money.MustParse("5600000")},
+		},
+	}
+	c := newTestConverter(store)
+
+	tests := []struct {
+		amount, from, to string
+		want             string
+	}{
+		// 0.1 USD is 9.01 RUB exactly, not 9.010000000000002
+		{"0.1", "USD", "RUB", "9.01"},
+		// JPY has no minor unit: 100 USD = 16593.0018... JPY
+		{"100", "USD", "JPY", "16593"},
+		// KWD has three places
+		{"1", "USD", "KWD", "0.339"},
+		// crypto keeps money.PriceScale places
+		{"1000", "USD", "BTC", "0.01608929"},
+	}
+	for _, tc := range tests {
+		res, err := c.Convert(money.MustParse(tc.amount), tc.from, tc.to, day("2025-03-10"))
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, res.Result.String(), "%s %s in %s", tc.amount, tc.from, tc.to)
+	}
+}
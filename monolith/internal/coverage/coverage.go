@@ -0,0 +1,156 @@
+// Package coverage finds the CBR business days and crypto candle intervals
+// missing from the stored history
+package coverage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/internal/calendar"
+	"github.com/casualdoto/go-currency-tracker/internal/storage"
+)
+
+// Sources of a coverage report
+const (
+	SourceCBR    = "cbr"
+	SourceCrypto = "crypto"
+)
+
+// MaxSlots bounds the expected days or candles of one report
+const MaxSlots = 100_000
+
+// ErrTooLong is returned for ranges with more than MaxSlots days or candles
+var ErrTooLong = errors.New("range too long")
+
+// Intervals are the candle sizes a crypto report can be made for
+var Intervals = map[string]time.Duration{
+	"1m":  time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"30m": 30 * time.Minute,
+	"1h":  time.Hour,
+	"4h":  4 * time.Hour,
+	"1d":  24 * time.Hour,
+}
+
+// Gap is a run of consecutive missing days or candles. A CBR gap over a
+// weekend spans Sunday and Monday, as no sheet is dated on them
+type Gap struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Count int    `json:"count"`
+}
+
+// Report is the coverage of one series in [From, To]. It has the same shape
+// as the /admin/coverage report of the microservices history-service
+type Report struct {
+	Source   string  `json:"source"`
+	Code     string  `json:"code,omitempty"`
+	Interval string  `json:"interval"`
+	From     string  `json:"from"`
+	To       string  `json:"to"`
+	Expected int     `json:"expected"`
+	Present  int     `json:"present"`
+	Missing  int     `json:"missing"`
+	Coverage float64 `json:"coverage"`
+	Gaps     []Gap   `json:"gaps"`
+}
+
+// CBR reports the days in [from, to] a CBR sheet is dated, Tuesday to
+// Saturday, without a rate of code, or without any rate when code is empty.
+// Days after today are not expected
+func CBR(ctx context.Context, db *storage.PostgresDB, code string, from, to time.Time) (Report, error) {
+	from, to = calendar.Date(from), calendar.Date(to)
+	if today := calendar.Today(); to.After(today) {
+		to = today
+	}
+	if n := to.Sub(from) / (24 * time.Hour); n >= MaxSlots {
+		return Report{}, fmt.Errorf("%w: %d days", ErrTooLong, n+1)
+	}
+	stored, err := db.StoredCurrencyDates(ctx, code, from, to)
+	if err != nil {
+		return Report{}, err
+	}
+	r := compare(calendar.SheetDays(from, to), stored, time.DateOnly)
+	r.Source, r.Code, r.Interval = SourceCBR, code, "1d"
+	r.From, r.To = from.Format(time.DateOnly), to.Format(time.DateOnly)
+	return r, nil
+}
+
+// Crypto reports the candles of interval in [from, to] (whole UTC days)
+// without a stored row of symbol. A candle counts as present when any row
+// falls into it. Candles not yet opened are not expected
+func Crypto(ctx context.Context, db *storage.PostgresDB, symbol, interval string, from, to time.Time) (Report, error) {
+	step, ok := Intervals[interval]
+	if !ok {
+		return Report{}, fmt.Errorf("unsupported interval %q", interval)
+	}
+	from, to = day(from), day(to)
+	end := to.AddDate(0, 0, 1)
+	if now := time.Now().UTC(); end.After(now) {
+		end = now
+	}
+	if n := end.Sub(from) / step; n > MaxSlots {
+		return Report{}, fmt.Errorf("%w: %d candles of %s", ErrTooLong, n, interval)
+	}
+	stored, err := db.StoredCryptoSlots(ctx, symbol, step, from, end)
+	if err != nil {
+		return Report{}, err
+	}
+	layout := time.RFC3339
+	if interval == "1d" {
+		layout = time.DateOnly
+	}
+	r := compare(Slots(from, end, step), stored, layout)
+	r.Source, r.Code, r.Interval = SourceCrypto, symbol, interval
+	r.From, r.To = from.Format(time.DateOnly), to.Format(time.DateOnly)
+	return r, nil
+}
+
+// Slots returns the open times in [from, end) of candles of step, aligned
+// to the Unix epoch as Binance aligns them
+func Slots(from, end time.Time, step time.Duration) []time.Time {
+	var slots []time.Time
+	for t := from.UTC().Truncate(step); t.Before(end); t = t.Add(step) {
+		slots = append(slots, t)
+	}
+	return slots
+}
+
+// compare finds the expected times not in stored and groups them into gaps
+// of consecutive expected times, formatted with layout
+func compare(expected, stored []time.Time, layout string) Report {
+	have := make(map[int64]bool, len(stored))
+	for _, t := range stored {
+		have[t.Unix()] = true
+	}
+	r := Report{Expected: len(expected), Gaps: []Gap{}}
+	last := -2
+	for i, t := range expected {
+		if have[t.Unix()] {
+			r.Present++
+			continue
+		}
+		r.Missing++
+		if i == last+1 {
+			g := &r.Gaps[len(r.Gaps)-1]
+			g.To = t.Format(layout)
+			g.Count++
+		} else {
+			r.Gaps = append(r.Gaps, Gap{From: t.Format(layout), To: t.Format(layout), Count: 1})
+		}
+		last = i
+	}
+	r.Coverage = 1
+	if r.Expected > 0 {
+		r.Coverage = float64(r.Present) / float64(r.Expected)
+	}
+	return r
+}
+
+func day(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,64 @@
+package coverage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/internal/calendar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse(time.DateOnly, s)
+	return t
+}
+
+// TestSheetDays checks that Saturday sheets are expected and Sundays and
+// Mondays are not
+func TestSheetDays(t *testing.T) {
+	// Fri 2024-01-12 .. Tue 2024-01-16
+	assert.Equal(t, []time.Time{date("2024-01-12"), date("2024-01-13"), date("2024-01-16")},
+		calendar.SheetDays(date("2024-01-12"), date("2024-01-16")))
+	assert.Empty(t, calendar.SheetDays(date("2024-01-14"), date("2024-01-15")))
+}
+
+// TestSlots checks candle alignment and the end bound
+func TestSlots(t *testing.T) {
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	slots := Slots(from, from.Add(time.Hour), 15*time.Minute)
+	require.Len(t, slots, 4)
+	assert.Equal(t, from.Add(45*time.Minute), slots[3])
+	assert.Len(t, Slots(from, from.AddDate(0, 0, 7), 24*time.Hour), 7)
+}
+
+// TestCompare checks that consecutive missing days form one gap, across a
+// weekend too
+func TestCompare(t *testing.T) {
+	expected := calendar.SheetDays(date("2024-01-10"), date("2024-01-18"))
+	stored := []time.Time{date("2024-01-10"), date("2024-01-11"), date("2024-01-12"), date("2024-01-16"), date("2024-01-18")}
+
+	r := compare(expected, stored, time.DateOnly)
+	assert.Equal(t, 7, r.Expected)
+	assert.Equal(t, 5, r.Present)
+	assert.Equal(t, 2, r.Missing)
+	assert.InDelta(t, 5.0/7, r.Coverage, 1e-9)
+	assert.Equal(t, []Gap{
+		{From: "2024-01-13", To: "2024-01-13", Count: 1},
+		{From: "2024-01-17", To: "2024-01-17", Count: 1},
+	}, r.Gaps)
+
+	full := compare(expected, expected, time.DateOnly)
+	assert.Equal(t, 0, full.Missing)
+	assert.Equal(t, 1.0, full.Coverage)
+	assert.NotNil(t, full.Gaps)
+}
+
+// TestTooLong checks that oversized ranges are refused before any query
+func TestTooLong(t *testing.T) {
+	_, err := Crypto(context.Background(), nil, "BTCUSDT", "1m", date("2020-01-01"), date("2024-01-01"))
+	assert.ErrorIs(t, err, ErrTooLong)
+	_, err = CBR(context.Background(), nil, "USD", date("1700-01-01"), date("2024-01-01"))
+	assert.ErrorIs(t, err, ErrTooLong)
+}
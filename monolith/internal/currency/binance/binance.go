@@ -7,13 +7,19 @@ import (
 	"fmt"
 	"net"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/adshao/go-binance/v2"
 	cbr "github.com/casualdoto/go-currency-tracker/internal/currency/cbr"
+	"github.com/casualdoto/go-currency-tracker/internal/logging"
+	"github.com/casualdoto/go-currency-tracker/internal/metrics"
+	"github.com/casualdoto/go-currency-tracker/internal/money"
 )
 
+// Retries, fallbacks and per-call summaries are logged at debug level; the
+// callers log the errors that are returned
+var logger = logging.For("binance")
+
 // KlineInterval represents the interval for kline/candlestick data
 type KlineInterval string
 
@@ -31,13 +37,13 @@ const (
 
 // CryptoRate represents a single cryptocurrency rate data point
 type CryptoRate struct {
-	Symbol    string    `json:"symbol"`
-	Timestamp time.Time `json:"timestamp"`
-	Open      float64   `json:"open"`
-	High      float64   `json:"high"`
-	Low       float64   `json:"low"`
-	Close     float64   `json:"close"`
-	Volume    float64   `json:"volume"`
+	Symbol    string        `json:"symbol"`
+	Timestamp time.Time     `json:"timestamp"`
+	Open      money.Decimal `json:"open"`
+	High      money.Decimal `json:"high"`
+	Low       money.Decimal `json:"low"`
+	Close     money.Decimal `json:"close"`
+	Volume    money.Decimal `json:"volume"`
 }
 
 // Client represents a Binance API client
@@ -50,7 +56,7 @@ func NewClient() *Client {
 	// Create a custom HTTP client with increased timeouts
 	httpClient := &http.Client{
 		Timeout: 30 * time.Second,
-		Transport: &http.Transport{
+		Transport: metrics.Transport(metrics.SourceBinance, &http.Transport{
 			DialContext: (&net.Dialer{
 				Timeout:   10 * time.Second,
 				KeepAlive: 30 * time.Second,
@@ -64,7 +70,7 @@ func NewClient() *Client {
 			TLSClientConfig: &tls.Config{
 				InsecureSkipVerify: false,
 			},
-		},
+		}),
 	}
 
 	// Initialize with empty API keys as we're only using public endpoints
@@ -99,12 +105,11 @@ func (c *Client) GetHistoricalKlines(symbol string, interval KlineInterval, star
 			Do(context.Background())
 		if err != nil {
 			lastErr = err
-			fmt.Printf("Attempt %d failed for %s: %v\n", attempt+1, symbol, err)
 
 			// Wait before retry (exponential backoff)
 			if attempt < maxRetries-1 {
 				waitTime := time.Duration(1<<attempt) * time.Second
-				fmt.Printf("Waiting %v before retry...\n", waitTime)
+				logger.Debug("klines request failed, retrying", "symbol", symbol, "attempt", attempt+1, "wait", waitTime.String(), "error", err)
 				time.Sleep(waitTime)
 				continue
 			}
@@ -118,11 +123,11 @@ func (c *Client) GetHistoricalKlines(symbol string, interval KlineInterval, star
 			timestamp := time.Unix(0, kline.OpenTime*int64(time.Millisecond))
 
 			// Parse price values
-			open, _ := strconv.ParseFloat(kline.Open, 64)
-			high, _ := strconv.ParseFloat(kline.High, 64)
-			low, _ := strconv.ParseFloat(kline.Low, 64)
-			close, _ := strconv.ParseFloat(kline.Close, 64)
-			volume, _ := strconv.ParseFloat(kline.Volume, 64)
+			open, _ := money.Parse(kline.Open)
+			high, _ := money.Parse(kline.High)
+			low, _ := money.Parse(kline.Low)
+			close, _ := money.Parse(kline.Close)
+			volume, _ := money.Parse(kline.Volume)
 
 			rates = append(rates, CryptoRate{
 				Symbol:    symbol,
@@ -161,8 +166,7 @@ func (c *Client) GetCryptoToRubRate(cryptoSymbol string, timestamp time.Time) (*
 	usdtRubRates, err := c.GetHistoricalKlines("USDTRUB", Interval1h, startTime, endTime)
 	if err != nil || len(usdtRubRates) == 0 {
 		// If failed to get USDT/RUB rate from Binance, use USD rate from CBR
-		fmt.Printf("Error getting USDT/RUB rate or no data available: %v\n", err)
-		fmt.Printf("Falling back to CBR USD rate\n")
+		logger.Debug("USDT/RUB unavailable, using the CBR USD rate", "error", err)
 
 		// Get USD rate from CBR for the current date
 		dateStr := timestamp.Format("2006-01-02")
@@ -171,7 +175,7 @@ func (c *Client) GetCryptoToRubRate(cryptoSymbol string, timestamp time.Time) (*
 			return nil, fmt.Errorf("failed to get USD/RUB rate from CBR: %w", err)
 		}
 
-		fmt.Printf("Got USD rate from CBR for %s: %.4f RUB\n", dateStr, usdRate.Value)
+		logger.Debug("CBR USD rate", "currency", "USD", "date", dateStr, "rate", usdRate.Value)
 
 		// Find closest crypto rate to the requested timestamp
 		var closestCryptoRate CryptoRate
@@ -189,10 +193,10 @@ func (c *Client) GetCryptoToRubRate(cryptoSymbol string, timestamp time.Time) (*
 		cryptoRubRate := CryptoRate{
 			Symbol:    cryptoSymbol + "/RUB",
 			Timestamp: timestamp,
-			Open:      closestCryptoRate.Open * usdRate.Value,
-			High:      closestCryptoRate.High * usdRate.Value,
-			Low:       closestCryptoRate.Low * usdRate.Value,
-			Close:     closestCryptoRate.Close * usdRate.Value,
+			Open:      toRUB(closestCryptoRate.Open, usdRate.Value),
+			High:      toRUB(closestCryptoRate.High, usdRate.Value),
+			Low:       toRUB(closestCryptoRate.Low, usdRate.Value),
+			Close:     toRUB(closestCryptoRate.Close, usdRate.Value),
 			Volume:    closestCryptoRate.Volume,
 		}
 
@@ -223,10 +227,10 @@ func (c *Client) GetCryptoToRubRate(cryptoSymbol string, timestamp time.Time) (*
 	cryptoRubRate := CryptoRate{
 		Symbol:    cryptoSymbol + "/RUB",
 		Timestamp: timestamp,
-		Open:      closestCryptoRate.Open * closestUsdtRate.Open,
-		High:      closestCryptoRate.High * closestUsdtRate.High,
-		Low:       closestCryptoRate.Low * closestUsdtRate.Low,
-		Close:     closestCryptoRate.Close * closestUsdtRate.Close,
+		Open:      toRUB(closestCryptoRate.Open, closestUsdtRate.Open),
+		High:      toRUB(closestCryptoRate.High, closestUsdtRate.High),
+		Low:       toRUB(closestCryptoRate.Low, closestUsdtRate.Low),
+		Close:     toRUB(closestCryptoRate.Close, closestUsdtRate.Close),
 		Volume:    closestCryptoRate.Volume,
 	}
 
@@ -235,34 +239,25 @@ func (c *Client) GetCryptoToRubRate(cryptoSymbol string, timestamp time.Time) (*
 
 // GetHistoricalCryptoToRubRates retrieves historical cryptocurrency to RUB rates for a date range
 func (c *Client) GetHistoricalCryptoToRubRates(cryptoSymbol string, interval KlineInterval, startTime, endTime time.Time) ([]CryptoRate, error) {
-	fmt.Printf("GetHistoricalCryptoToRubRates: Getting rates for %s from %s to %s with interval %s\n",
-		cryptoSymbol,
-		startTime.Format("2006-01-02"),
-		endTime.Format("2006-01-02"),
-		string(interval))
+	start := time.Now()
 
 	// Get crypto/USDT rates
 	cryptoUsdtRates, err := c.GetHistoricalKlines(cryptoSymbol+"USDT", interval, startTime, endTime)
 	if err != nil {
-		fmt.Printf("Error getting %s/USDT rates: %v\n", cryptoSymbol, err)
 		return nil, fmt.Errorf("failed to get %s/USDT rates: %w", cryptoSymbol, err)
 	}
 	if len(cryptoUsdtRates) == 0 {
-		fmt.Printf("No %s/USDT rate data available\n", cryptoSymbol)
 		return nil, fmt.Errorf("no %s/USDT rate data available", cryptoSymbol)
 	}
 
-	fmt.Printf("Got %d %s/USDT rates\n", len(cryptoUsdtRates), cryptoSymbol)
-
 	// Get USDT/RUB rates
 	usdtRubRates, err := c.GetHistoricalKlines("USDTRUB", interval, startTime, endTime)
 	if err != nil || len(usdtRubRates) == 0 {
 		// If failed to get USDT/RUB rates from Binance, use USD rates from CBR
-		fmt.Printf("Error getting USDT/RUB rates or no data available: %v\n", err)
-		fmt.Printf("Falling back to CBR USD rates\n")
+		logger.Debug("USDT/RUB unavailable, using CBR USD rates", "symbol", cryptoSymbol, "error", err)
 
 		// Create an empty map to store USD rates by date
-		cbrRates := make(map[string]float64)
+		cbrRates := make(map[string]money.Decimal)
 
 		// Get USD rates for each day in the range
 		currentDate := startTime
@@ -274,9 +269,8 @@ func (c *Client) GetHistoricalCryptoToRubRates(cryptoSymbol string, interval Kli
 			if err == nil && usdRate != nil {
 				// Save rate to the map by date
 				cbrRates[dateStr] = usdRate.Value
-				fmt.Printf("Got USD rate from CBR for %s: %.4f RUB\n", dateStr, usdRate.Value)
 			} else {
-				fmt.Printf("Failed to get USD rate from CBR for %s: %v\n", dateStr, err)
+				logger.Debug("CBR USD rate unavailable", "currency", "USD", "date", dateStr, "error", err)
 			}
 
 			// Move to the next day
@@ -314,10 +308,8 @@ func (c *Client) GetHistoricalCryptoToRubRates(cryptoSymbol string, interval Kli
 				// If found closest date within 7 days, use it
 				if minDiff <= 7 && closestDate != "" {
 					usdRate = cbrRates[closestDate]
-					fmt.Printf("Using closest USD rate from %s for %s\n", closestDate, dateStr)
 				} else {
 					// Otherwise skip this data point
-					fmt.Printf("Skipping rate for %s, no close USD rate found\n", dateStr)
 					continue
 				}
 			}
@@ -326,29 +318,22 @@ func (c *Client) GetHistoricalCryptoToRubRates(cryptoSymbol string, interval Kli
 			cryptoRubRate := CryptoRate{
 				Symbol:    cryptoSymbol + "/RUB",
 				Timestamp: cryptoRate.Timestamp,
-				Open:      cryptoRate.Open * usdRate,
-				High:      cryptoRate.High * usdRate,
-				Low:       cryptoRate.Low * usdRate,
-				Close:     cryptoRate.Close * usdRate,
+				Open:      toRUB(cryptoRate.Open, usdRate),
+				High:      toRUB(cryptoRate.High, usdRate),
+				Low:       toRUB(cryptoRate.Low, usdRate),
+				Close:     toRUB(cryptoRate.Close, usdRate),
 				Volume:    cryptoRate.Volume,
 			}
 
 			result = append(result, cryptoRubRate)
 		}
 
-		fmt.Printf("Generated %d %s/RUB rates using CBR USD rates\n", len(result), cryptoSymbol)
-
-		if len(result) > 0 {
-			fmt.Printf("First result: Symbol=%s, Timestamp=%s\n",
-				result[0].Symbol,
-				result[0].Timestamp.Format("2006-01-02 15:04:05"))
-		}
+		logger.Debug("historical rates", "symbol", cryptoSymbol, "interval", string(interval), "usd_source", "cbr",
+			"count", len(result), "skipped", len(cryptoUsdtRates)-len(result), "duration_ms", logging.Millis(time.Since(start)))
 
 		return result, nil
 	}
 
-	fmt.Printf("Got %d USDT/RUB rates\n", len(usdtRubRates))
-
 	// Map USDT/RUB rates by timestamp for quick lookup
 	usdtRubRatesByTime := make(map[int64]CryptoRate, len(usdtRubRates))
 	for _, rate := range usdtRubRates {
@@ -393,24 +378,19 @@ func (c *Client) GetHistoricalCryptoToRubRates(cryptoSymbol string, interval Kli
 		cryptoRubRate := CryptoRate{
 			Symbol:    cryptoSymbol + "/RUB",
 			Timestamp: cryptoRate.Timestamp,
-			Open:      cryptoRate.Open * usdtRate.Open,
-			High:      cryptoRate.High * usdtRate.High,
-			Low:       cryptoRate.Low * usdtRate.Low,
-			Close:     cryptoRate.Close * usdtRate.Close,
+			Open:      toRUB(cryptoRate.Open, usdtRate.Open),
+			High:      toRUB(cryptoRate.High, usdtRate.High),
+			Low:       toRUB(cryptoRate.Low, usdtRate.Low),
+			Close:     toRUB(cryptoRate.Close, usdtRate.Close),
 			Volume:    cryptoRate.Volume,
 		}
 
 		result = append(result, cryptoRubRate)
 	}
 
-	fmt.Printf("Generated %d %s/RUB rates (exact matches: %d, closest matches: %d, skipped: %d)\n",
-		len(result), cryptoSymbol, matchedCount, closestCount, skippedCount)
-
-	if len(result) > 0 {
-		fmt.Printf("First result: Symbol=%s, Timestamp=%s\n",
-			result[0].Symbol,
-			result[0].Timestamp.Format("2006-01-02 15:04:05"))
-	}
+	logger.Debug("historical rates", "symbol", cryptoSymbol, "interval", string(interval), "usd_source", "binance",
+		"count", len(result), "exact", matchedCount, "closest", closestCount, "skipped", skippedCount,
+		"duration_ms", logging.Millis(time.Since(start)))
 
 	return result, nil
 }
@@ -428,12 +408,11 @@ func (c *Client) GetCurrentPrice(symbol string) (*CryptoRate, error) {
 			Do(context.Background())
 		if err != nil {
 			lastErr = err
-			fmt.Printf("Attempt %d failed for %s ticker: %v\n", attempt+1, symbol, err)
 
 			// Wait before retry (exponential backoff)
 			if attempt < maxRetries-1 {
 				waitTime := time.Duration(1<<attempt) * time.Second
-				fmt.Printf("Waiting %v before retry...\n", waitTime)
+				logger.Debug("ticker request failed, retrying", "symbol", symbol, "attempt", attempt+1, "wait", waitTime.String(), "error", err)
 				time.Sleep(waitTime)
 				continue
 			}
@@ -447,11 +426,11 @@ func (c *Client) GetCurrentPrice(symbol string) (*CryptoRate, error) {
 		// Parse the first ticker result
 		t := ticker[0]
 
-		openPrice, _ := strconv.ParseFloat(t.OpenPrice, 64)
-		highPrice, _ := strconv.ParseFloat(t.HighPrice, 64)
-		lowPrice, _ := strconv.ParseFloat(t.LowPrice, 64)
-		lastPrice, _ := strconv.ParseFloat(t.LastPrice, 64)
-		volume, _ := strconv.ParseFloat(t.Volume, 64)
+		openPrice, _ := money.Parse(t.OpenPrice)
+		highPrice, _ := money.Parse(t.HighPrice)
+		lowPrice, _ := money.Parse(t.LowPrice)
+		lastPrice, _ := money.Parse(t.LastPrice)
+		volume, _ := money.Parse(t.Volume)
 
 		result := &CryptoRate{
 			Symbol:    symbol,
@@ -484,7 +463,7 @@ func (c *Client) GetCurrentCryptoToRubRate(cryptoSymbol string) (*CryptoRate, er
 	}
 
 	// Check if USD rate is valid (not zero)
-	if usdRate.Value == 0 {
+	if usdRate.Value.IsZero() {
 		return nil, fmt.Errorf("USD rate from CBR is zero")
 	}
 
@@ -492,19 +471,25 @@ func (c *Client) GetCurrentCryptoToRubRate(cryptoSymbol string) (*CryptoRate, er
 	cryptoRubRate := CryptoRate{
 		Symbol:    cryptoSymbol + "/RUB",
 		Timestamp: time.Now(),
-		Open:      cryptoUsdtRate.Open * usdRate.Value,
-		High:      cryptoUsdtRate.High * usdRate.Value,
-		Low:       cryptoUsdtRate.Low * usdRate.Value,
-		Close:     cryptoUsdtRate.Close * usdRate.Value,
+		Open:      toRUB(cryptoUsdtRate.Open, usdRate.Value),
+		High:      toRUB(cryptoUsdtRate.High, usdRate.Value),
+		Low:       toRUB(cryptoUsdtRate.Low, usdRate.Value),
+		Close:     toRUB(cryptoUsdtRate.Close, usdRate.Value),
 		Volume:    cryptoUsdtRate.Volume,
 	}
 
-	fmt.Printf("Calculated %s/RUB rate: %.2f RUB (%.2f USDT * %.4f RUB/USD)\n",
-		cryptoSymbol, cryptoRubRate.Close, cryptoUsdtRate.Close, usdRate.Value)
+	logger.Debug("current rate", "symbol", cryptoSymbol, "price_rub", cryptoRubRate.Close,
+		"price_usdt", cryptoUsdtRate.Close, "usd_rub", usdRate.Value)
 
 	return &cryptoRubRate, nil
 }
 
+// toRUB converts a USDT price at rate RUB per USDT, rounded to the
+// DECIMAL(24, 8) columns it is stored in
+func toRUB(price, rate money.Decimal) money.Decimal {
+	return price.Mul(rate).Round(money.PriceScale)
+}
+
 // Helper function to get absolute value of time.Duration
 func absDuration(d time.Duration) time.Duration {
 	if d < 0 {
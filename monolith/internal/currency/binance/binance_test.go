@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/casualdoto/go-currency-tracker/internal/money"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -141,17 +142,17 @@ func TestCryptoRate_Structure(t *testing.T) {
 	rate := CryptoRate{
 		Symbol:    "BTC/RUB",
 		Timestamp: time.Now(),
-		Open:      47000.50,
-		High:      47500.00,
-		Low:       46500.00,
-		Close:     47200.00,
-		Volume:    100.5,
+		Open:      money.MustParse("47000.50"),
+		High:      money.MustParse("47500.00"),
+		Low:       money.MustParse("46500.00"),
+		Close:     money.MustParse("47200.00"),
+		Volume:    money.MustParse("100.5"),
 	}
 
 	assert.Equal(t, "BTC/RUB", rate.Symbol)
-	assert.Greater(t, rate.Open, 0.0)
-	assert.Greater(t, rate.High, rate.Low)
-	assert.Greater(t, rate.Volume, 0.0)
+	assert.True(t, rate.Open.IsPositive())
+	assert.Equal(t, 1, rate.High.Cmp(rate.Low))
+	assert.True(t, rate.Volume.IsPositive())
 	assert.False(t, rate.Timestamp.IsZero())
 }
 
@@ -7,23 +7,84 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/casualdoto/go-currency-tracker/internal/calendar"
 	"github.com/casualdoto/go-currency-tracker/internal/config"
+	"github.com/casualdoto/go-currency-tracker/internal/metrics"
+	"github.com/casualdoto/go-currency-tracker/internal/money"
 )
 
+// maxFallbackDays bounds the walk back from a date without a sheet (a
+// weekend or holiday) to the last day the CBR published one
+const maxFallbackDays = 14
+
 // Structures for parsing API response
 type DailyRates struct {
-	Date   string            `json:"Date"`
-	Valute map[string]Valute `json:"Valute"`
+	// Date is the day the rates take effect and Timestamp the time the sheet
+	// was published, the afternoon before for next-day rates
+	Date      string            `json:"Date"`
+	Timestamp string            `json:"Timestamp"`
+	Valute    map[string]Valute `json:"Valute"`
+
+	// SourceURL is the daily_json.js the rates were read from and FetchedAt
+	// the time they were read
+	SourceURL string    `json:"-"`
+	FetchedAt time.Time `json:"-"`
+}
+
+// EffectiveOn returns the Moscow day the rates of the sheet take effect, or
+// the zero time when its Date cannot be parsed
+func (d *DailyRates) EffectiveOn() time.Time {
+	day, err := calendar.ParseSheetDate(d.Date)
+	if err != nil {
+		return time.Time{}
+	}
+	return day
+}
+
+// PublishedAt returns the time the sheet was published, falling back to the
+// time it was fetched when the sheet has no usable Timestamp
+func (d *DailyRates) PublishedAt() time.Time {
+	if t, err := time.Parse(time.RFC3339, d.Timestamp); err == nil {
+		return t
+	}
+	return d.FetchedAt
+}
+
+// CarriedFrom returns the effective date of the sheet when it is before
+// date, i.e. when the rates stored for date are carried over from an
+// earlier sheet (weekends, holidays, archive fallbacks), and nil otherwise
+func (d *DailyRates) CarriedFrom(date time.Time) *time.Time {
+	effective := d.EffectiveOn()
+	if effective.IsZero() || !effective.Before(calendar.Date(date)) {
+		return nil
+	}
+	return &effective
 }
 
 type Valute struct {
-	ID       string  `json:"ID"`
-	NumCode  string  `json:"NumCode"`
-	CharCode string  `json:"CharCode"`
-	Nominal  int     `json:"Nominal"`
-	Name     string  `json:"Name"`
-	Value    float64 `json:"Value"`
-	Previous float64 `json:"Previous"`
+	ID       string        `json:"ID"`
+	NumCode  string        `json:"NumCode"`
+	CharCode string        `json:"CharCode"`
+	Nominal  int           `json:"Nominal"`
+	Name     string        `json:"Name"`
+	Value    money.Decimal `json:"Value"`
+	Previous money.Decimal `json:"Previous"`
+	// VunitRate is the price of one unit. Older sheets do not publish it;
+	// fetched sheets get Value / Nominal instead
+	VunitRate money.Decimal `json:"VunitRate"`
+	// CarriedFrom is set by the API on a stored rate the CBR published no
+	// sheet for: the date of the earlier sheet it was carried over from.
+	// CBR sheets never have it
+	CarriedFrom string `json:"carried_from,omitempty"`
+}
+
+// PerUnit returns the price of one unit: VunitRate when the sheet has it,
+// Value / Nominal otherwise
+func (v Valute) PerUnit() money.Decimal {
+	if v.VunitRate.IsPositive() {
+		return v.VunitRate
+	}
+	return money.PerUnit(v.Value, v.Nominal)
 }
 
 // Get rates from the CBR site for the current date
@@ -31,50 +92,94 @@ func GetCBRRates() (*DailyRates, error) {
 	return GetCBRRatesByDate("")
 }
 
+// GetLatestCBRRates returns the latest published sheet. After the CBR
+// publishes the next day's rates in the afternoon this is that sheet, so
+// callers must check EffectiveOn before treating it as today's
+func GetLatestCBRRates() (*DailyRates, error) {
+	rates, status, err := fetchCBRRates(fmt.Sprintf("%s/daily_json.js", config.GetCBRBaseURL()))
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch CBR rates, status code: %d", status)
+	}
+	return rates, nil
+}
+
 // Get rates from the CBR site for the specified date
-// If date is an empty string, returns rates for the current date
+// If date is an empty string, returns the rates in effect today, which are
+// read from the archive once the next day's sheet has been published
+// When the archive has no sheet for date, the sheet of the last earlier day
+// it has one for is returned, at most maxFallbackDays back; its EffectiveOn
+// tells the publication day the rates came from
 // Date format: YYYY-MM-DD (for example, "2023-05-15")
 func GetCBRRatesByDate(date string) (*DailyRates, error) {
-	client := &http.Client{Timeout: 10 * time.Second}
-	baseURL := config.GetCBRBaseURL()
-	url := fmt.Sprintf("%s/daily_json.js", baseURL)
-
-	// If date is specified, form URL for archive
-	if date != "" {
-		// Convert date format from YYYY-MM-DD to format for API (YYYY/MM/DD)
-		parsedDate, err := time.Parse("2006-01-02", date)
+	if date == "" {
+		rates, err := GetLatestCBRRates()
 		if err != nil {
-			return nil, fmt.Errorf("invalid date format, expected YYYY-MM-DD: %w", err)
+			return nil, err
 		}
+		if today := calendar.Today(); rates.EffectiveOn().After(today) {
+			return GetCBRRatesByDate(today.Format(time.DateOnly))
+		}
+		return rates, nil
+	}
+
+	// Convert date format from YYYY-MM-DD to format for API (YYYY/MM/DD)
+	parsedDate, err := calendar.ParseDate(date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date format, expected YYYY-MM-DD: %w", err)
+	}
+
+	// For archive data use different URL format
+	// In cbr-xml-daily.ru API archive data is available by URL like:
+	// https://www.cbr-xml-daily.ru/archive/YYYY/MM/DD/daily_json.js
+	for i := 0; i <= maxFallbackDays; i++ {
+		day := parsedDate.AddDate(0, 0, -i)
+		url := fmt.Sprintf("%s/archive/%s/daily_json.js", config.GetCBRBaseURL(), day.Format("2006/01/02"))
 
-		// For archive data use different URL format
-		// In cbr-xml-daily.ru API archive data is available by URL like:
-		// https://www.cbr-xml-daily.ru/archive/YYYY/MM/DD/daily_json.js
-		formattedDate := parsedDate.Format("2006/01/02")
-		url = fmt.Sprintf("%s/archive/%s/daily_json.js", baseURL, formattedDate)
+		rates, status, err := fetchCBRRates(url)
+		if err != nil {
+			return nil, err
+		}
+		switch status {
+		case http.StatusOK:
+			return rates, nil
+		case http.StatusNotFound:
+			// No sheet for this day, try the previous one
+			continue
+		default:
+			return nil, fmt.Errorf("failed to fetch CBR rates, status code: %d", status)
+		}
 	}
+	return nil, fmt.Errorf("no CBR rates published within %d days before %s", maxFallbackDays, date)
+}
 
+// fetchCBRRates reads one daily_json.js sheet. A non-200 status is returned
+// without an error so that callers can decide how to handle a missing sheet
+func fetchCBRRates(url string) (*DailyRates, int, error) {
+	client := &http.Client{Timeout: 10 * time.Second, Transport: metrics.Transport(metrics.SourceCBR, nil)}
 	resp, err := client.Get(url)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch CBR rates: %w", err)
+		return nil, 0, fmt.Errorf("failed to fetch CBR rates: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		// If archive data not found, try to get data for previous working day
-		if date != "" && resp.StatusCode == http.StatusNotFound {
-			// Try to get data for current date
-			return GetCBRRates()
-		}
-		return nil, fmt.Errorf("failed to fetch CBR rates, status code: %d", resp.StatusCode)
+		return nil, resp.StatusCode, nil
 	}
 
 	var rates DailyRates
 	if err := json.NewDecoder(resp.Body).Decode(&rates); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return nil, 0, fmt.Errorf("failed to decode response: %w", err)
+	}
+	rates.SourceURL, rates.FetchedAt = url, time.Now()
+	for code, valute := range rates.Valute {
+		valute.VunitRate = valute.PerUnit()
+		rates.Valute[code] = valute
 	}
 
-	return &rates, nil
+	return &rates, resp.StatusCode, nil
 }
 
 // Get rate of specific currency
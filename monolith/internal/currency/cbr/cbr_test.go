@@ -4,7 +4,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/casualdoto/go-currency-tracker/internal/calendar"
 	"github.com/casualdoto/go-currency-tracker/internal/config"
 )
 
@@ -106,10 +108,10 @@ func TestGetCBRRates(t *testing.T) {
 	}
 
 	// Check rate values
-	if rates.Valute["USD"].Value == 0 {
+	if rates.Valute["USD"].Value.IsZero() {
 		t.Error("USD rate value should not be 0")
 	}
-	if rates.Valute["EUR"].Value == 0 {
+	if rates.Valute["EUR"].Value.IsZero() {
 		t.Error("EUR rate value should not be 0")
 	}
 }
@@ -137,14 +139,40 @@ func TestGetCBRRatesByDate(t *testing.T) {
 	}
 
 	// Check rate values
-	if rates.Valute["USD"].Value != 85.0504 {
+	if rates.Valute["USD"].Value.String() != "85.0504" {
 		t.Errorf("Expected USD rate value 85.0504, got %v", rates.Valute["USD"].Value)
 	}
-	if rates.Valute["EUR"].Value != 93.1373 {
+	if rates.Valute["EUR"].Value.String() != "93.1373" {
 		t.Errorf("Expected EUR rate value 93.1373, got %v", rates.Valute["EUR"].Value)
 	}
 }
 
+// Testing the walk back from a date without a sheet to the last publication
+func TestGetCBRRatesByDateFallback(t *testing.T) {
+	server := setupMockCBRServer()
+	defer server.Close()
+
+	config.SetCBRBaseURLForTesting(server.URL)
+
+	// The archive has no sheets from 2023-06-29 to 2023-07-02
+	rates, err := GetCBRRatesByDate("2023-07-02")
+	if err != nil {
+		t.Fatalf("Error getting currency rates for a date without a sheet: %v", err)
+	}
+	if rates.Valute["USD"].Value.String() != "85.0504" {
+		t.Errorf("Expected the 2023-06-28 USD rate 85.0504, got %v", rates.Valute["USD"].Value)
+	}
+	from := rates.CarriedFrom(time.Date(2023, 7, 2, 0, 0, 0, 0, time.UTC))
+	if from == nil || from.Format("2006-01-02") != "2023-06-28" {
+		t.Errorf("Expected carry-over from 2023-06-28, got %v", from)
+	}
+
+	// The walk back is bounded
+	if _, err := GetCBRRatesByDate("2023-08-01"); err == nil {
+		t.Error("Expected an error when no sheet is published within the fallback window")
+	}
+}
+
 // Testing getting specific currency rate
 func TestGetCurrencyRate(t *testing.T) {
 	// Create mock server
@@ -173,3 +201,100 @@ func TestGetCurrencyRate(t *testing.T) {
 		t.Error("Expected error when requesting non-existent currency")
 	}
 }
+
+// Testing detection of rates carried over from an earlier sheet
+func TestDailyRatesCarriedFrom(t *testing.T) {
+	rates := &DailyRates{Date: "2023-06-29T11:30:00+03:00"}
+
+	if from := rates.CarriedFrom(time.Date(2023, 6, 29, 0, 0, 0, 0, time.UTC)); from != nil {
+		t.Errorf("Expected no carry-over on the publication date, got %v", from)
+	}
+	from := rates.CarriedFrom(time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC))
+	if from == nil || from.Format("2006-01-02") != "2023-06-29" {
+		t.Errorf("Expected carry-over from 2023-06-29, got %v", from)
+	}
+	if from := (&DailyRates{}).CarriedFrom(time.Now()); from != nil {
+		t.Errorf("Expected no carry-over without a publication date, got %v", from)
+	}
+}
+
+// Testing that next-day rates are kept apart from the rates in effect today
+func TestNextDaySheet(t *testing.T) {
+	today := calendar.Today()
+	tomorrow := today.AddDate(0, 0, 1)
+	sheet := func(day time.Time, value string) string {
+		return `{"Date": "` + day.Format("2006-01-02") + `T11:30:00+03:00",` +
+			`"Timestamp": "` + day.AddDate(0, 0, -1).Format("2006-01-02") + `T15:30:00+03:00",` +
+			`"Valute": {"USD": {"CharCode": "USD", "Nominal": 1, "Value": ` + value + `}}}`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/daily_json.js":
+			w.Write([]byte(sheet(tomorrow, "91.5")))
+		case "/archive/" + today.Format("2006/01/02") + "/daily_json.js":
+			w.Write([]byte(sheet(today, "90.1")))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	config.SetCBRBaseURLForTesting(server.URL)
+
+	latest, err := GetLatestCBRRates()
+	if err != nil {
+		t.Fatalf("Error getting latest rates: %v", err)
+	}
+	if !latest.EffectiveOn().Equal(tomorrow) {
+		t.Errorf("Expected the latest sheet to take effect on %s, got %s", tomorrow, latest.EffectiveOn())
+	}
+	wantPublished := time.Date(today.Year(), today.Month(), today.Day(), 15, 30, 0, 0, calendar.Moscow)
+	if !latest.PublishedAt().Equal(wantPublished) {
+		t.Errorf("Expected publication at %s, got %s", wantPublished, latest.PublishedAt())
+	}
+
+	current, err := GetCBRRates()
+	if err != nil {
+		t.Fatalf("Error getting current rates: %v", err)
+	}
+	if !current.EffectiveOn().Equal(today) || current.Valute["USD"].Value.String() != "90.1" {
+		t.Errorf("Expected today's rates, got %s %v", current.Date, current.Valute["USD"].Value)
+	}
+}
+
+// Testing the publication time fallback for sheets without a Timestamp
+func TestDailyRatesPublishedAt(t *testing.T) {
+	fetched := time.Date(2023, 6, 29, 9, 0, 0, 0, time.UTC)
+	rates := &DailyRates{Timestamp: "not a time", FetchedAt: fetched}
+	if !rates.PublishedAt().Equal(fetched) {
+		t.Errorf("Expected fetch time %s, got %s", fetched, rates.PublishedAt())
+	}
+}
+
+// Testing that every fetched rate has a per-unit value, published or derived
+func TestValuteVunitRate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"Date": "2024-06-29T11:30:00+03:00",
+			"Timestamp": "2024-06-28T13:30:00+03:00",
+			"Valute": {
+				"JPY": {"CharCode": "JPY", "Nominal": 100, "Name": "Японских иен", "Value": 53.3049, "Previous": 53.6251},
+				"KZT": {"CharCode": "KZT", "Nominal": 100, "Name": "Казахстанских тенге", "Value": 18.0973, "Previous": 18.2311, "VunitRate": 0.180973}
+			}
+		}`))
+	}))
+	defer server.Close()
+
+	config.SetCBRBaseURLForTesting(server.URL)
+
+	rates, err := GetCBRRates()
+	if err != nil {
+		t.Fatalf("Error getting currency rates: %v", err)
+	}
+	if got := rates.Valute["JPY"].VunitRate.String(); got != "0.533049" {
+		t.Errorf("Expected derived JPY VunitRate 0.533049, got %s", got)
+	}
+	if got := rates.Valute["KZT"].VunitRate.String(); got != "0.180973" {
+		t.Errorf("Expected published KZT VunitRate 0.180973, got %s", got)
+	}
+}
@@ -0,0 +1,174 @@
+package currency
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/internal/calendar"
+	"github.com/casualdoto/go-currency-tracker/internal/config"
+	"github.com/casualdoto/go-currency-tracker/internal/metrics"
+	"github.com/casualdoto/go-currency-tracker/internal/money"
+)
+
+// Codes of the CBR money-market indicators, shared with the microservices
+const (
+	IndicatorKeyRate = "KEY_RATE"
+	IndicatorRUONIA  = "RUONIA"
+)
+
+// IndicatorsLookbackDays is how many days back the scheduler asks for on
+// every run; storage keeps a row only where a value changes
+const IndicatorsLookbackDays = 7
+
+// Indicator is a rate the CBR publishes in percent per annum
+type Indicator struct {
+	Code string
+	Name string
+
+	// method is the DailyInfo web service method serving the indicator,
+	// record the element holding one value and date and value its fields
+	method string
+	record string
+	date   string
+	value  string
+}
+
+// Indicators maps the indicator codes to the DailyInfo feeds serving them
+var Indicators = map[string]Indicator{
+	IndicatorKeyRate: {Code: IndicatorKeyRate, Name: "Key rate", method: "KeyRateXML", record: "KR", date: "DT", value: "Rate"},
+	IndicatorRUONIA:  {Code: IndicatorRUONIA, Name: "RUONIA", method: "RuoniaXML", record: "ro", date: "D0", value: "ruo"},
+}
+
+// IndicatorRate is the value an indicator took effect with on a date. The
+// key rate keeps it until the next decision
+type IndicatorRate struct {
+	Date      time.Time
+	Indicator string
+	Name      string
+	Value     money.Decimal
+
+	// SourceURL is the DailyInfo request the value was read from and
+	// FetchedAt the time it was read
+	SourceURL string
+	FetchedAt time.Time
+}
+
+// indicatorRecord is a record of a DailyInfo answer with its fields by name
+type indicatorRecord struct {
+	Fields []struct {
+		XMLName xml.Name
+		Value   string `xml:",chardata"`
+	} `xml:",any"`
+}
+
+func (r indicatorRecord) field(name string) string {
+	for _, f := range r.Fields {
+		if f.XMLName.Local == name {
+			return strings.TrimSpace(f.Value)
+		}
+	}
+	return ""
+}
+
+// GetIndicatorRates returns the values the indicator with the code took
+// from from to to, inclusive, ordered by date. The CBR answers any range in
+// one request
+func GetIndicatorRates(code string, from, to time.Time) ([]IndicatorRate, error) {
+	indicator, ok := Indicators[code]
+	if !ok {
+		return nil, fmt.Errorf("unknown indicator %q", code)
+	}
+	url := fmt.Sprintf("%s/DailyInfoWebServ/DailyInfo.asmx/%s?fromDate=%s&ToDate=%s",
+		config.GetCBRIndicatorsURL(), indicator.method, from.Format("2006-01-02"), to.Format("2006-01-02"))
+
+	client := &http.Client{Timeout: 30 * time.Second, Transport: metrics.Transport(metrics.SourceCBRIndicators, nil)}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch indicator rates: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch indicator rates, status code: %d", resp.StatusCode)
+	}
+
+	records, err := decodeIndicatorRecords(resp.Body, indicator)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode indicator rates: %w", err)
+	}
+	rates, err := parseIndicatorRecords(records, indicator, url, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode indicator rates: %w", err)
+	}
+	return rates, nil
+}
+
+// decodeIndicatorRecords returns the indicator's record elements of a
+// DailyInfo answer, wherever the SOAP dataset wrapping puts them
+func decodeIndicatorRecords(r io.Reader, indicator Indicator) ([]indicatorRecord, error) {
+	dec := xml.NewDecoder(r)
+	var records []indicatorRecord
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != indicator.record {
+			continue
+		}
+		var rec indicatorRecord
+		if err := dec.DecodeElement(&rec, &start); err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+}
+
+// parseIndicatorRecords converts feed records into values. Dates carry the
+// Moscow offset
+func parseIndicatorRecords(records []indicatorRecord, indicator Indicator, sourceURL string, fetchedAt time.Time) ([]IndicatorRate, error) {
+	rates := make([]IndicatorRate, 0, len(records))
+	for _, rec := range records {
+		date, err := calendar.ParseSheetDate(rec.field(indicator.date))
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid date %q", indicator.Code, rec.field(indicator.date))
+		}
+		value, err := money.Parse(rec.field(indicator.value))
+		if err != nil {
+			return nil, fmt.Errorf("%s on %s: %q: %w", indicator.Code, date.Format("2006-01-02"), rec.field(indicator.value), err)
+		}
+		rates = append(rates, IndicatorRate{
+			Date:      date,
+			Indicator: indicator.Code,
+			Name:      indicator.Name,
+			Value:     value,
+			SourceURL: sourceURL,
+			FetchedAt: fetchedAt,
+		})
+	}
+	sort.SliceStable(rates, func(i, j int) bool {
+		return rates[i].Date.Before(rates[j].Date)
+	})
+	return rates, nil
+}
+
+// IndicatorByCode returns the indicator with the code or name s, in any
+// case
+func IndicatorByCode(s string) (Indicator, bool) {
+	s = strings.TrimSpace(s)
+	for _, ind := range Indicators {
+		if strings.EqualFold(ind.Code, s) || strings.EqualFold(ind.Name, s) {
+			return ind, true
+		}
+	}
+	return Indicator{}, false
+}
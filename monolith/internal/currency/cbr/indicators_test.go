@@ -0,0 +1,135 @@
+package currency
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/internal/config"
+)
+
+// Key rate feed as the DailyInfo web service serves it, newest first
+const mockKeyRateXML = `<?xml version="1.0" encoding="utf-8"?>
+<KeyRate xmlns="">
+  <KR><DT>2026-04-14T00:00:00+03:00</DT><Rate>15.50</Rate></KR>
+  <KR><DT>2026-04-13T00:00:00+03:00</DT><Rate>16.00</Rate></KR>
+</KeyRate>`
+
+// RUONIA feed as the DailyInfo web service serves it
+const mockRuoniaXML = `<?xml version="1.0" encoding="utf-8"?>
+<Ruonia xmlns="">
+  <ro><D0>2026-04-13T00:00:00+03:00</D0><ruo>15.8400</ruo><vol>412.50</vol><DateUpdate>2026-04-14T14:05:00+03:00</DateUpdate></ro>
+</Ruonia>`
+
+// Mock server for the DailyInfo methods in bodies; query receives the
+// request query
+func setupMockIndicatorsServer(bodies map[string]string, status int, query *string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := bodies[strings.TrimPrefix(r.URL.Path, "/DailyInfoWebServ/DailyInfo.asmx/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if query != nil {
+			*query = r.URL.RawQuery
+		}
+		w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+}
+
+// Testing getting the key rate for a date range
+func TestGetIndicatorRatesKeyRate(t *testing.T) {
+	var query string
+	server := setupMockIndicatorsServer(map[string]string{"KeyRateXML": mockKeyRateXML}, http.StatusOK, &query)
+	defer server.Close()
+
+	config.SetCBRIndicatorsURLForTesting(server.URL)
+
+	from := time.Date(2026, 4, 13, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 4, 14, 0, 0, 0, 0, time.UTC)
+	rates, err := GetIndicatorRates(IndicatorKeyRate, from, to)
+	if err != nil {
+		t.Fatalf("Error getting key rate: %v", err)
+	}
+
+	if query != "fromDate=2026-04-13&ToDate=2026-04-14" {
+		t.Errorf("Unexpected query %q", query)
+	}
+	// Values come back ordered by date
+	if len(rates) != 2 {
+		t.Fatalf("Expected 2 values, got %d", len(rates))
+	}
+	if !rates[0].Date.Equal(from) || rates[0].Value.String() != "16" {
+		t.Errorf("Expected 16 on 2026-04-13 first, got %v on %v", rates[0].Value, rates[0].Date)
+	}
+	last := rates[1]
+	if !last.Date.Equal(to) || last.Value.String() != "15.5" || last.Name != "Key rate" {
+		t.Errorf("Expected key rate 15.5 on 2026-04-14, got %+v", last)
+	}
+	if !strings.HasPrefix(last.SourceURL, server.URL) || last.FetchedAt.IsZero() {
+		t.Errorf("Expected source URL and fetch time, got %q and %v", last.SourceURL, last.FetchedAt)
+	}
+}
+
+// Testing that RUONIA is read without the volume
+func TestGetIndicatorRatesRUONIA(t *testing.T) {
+	server := setupMockIndicatorsServer(map[string]string{"RuoniaXML": mockRuoniaXML}, http.StatusOK, nil)
+	defer server.Close()
+
+	config.SetCBRIndicatorsURLForTesting(server.URL)
+
+	day := time.Date(2026, 4, 13, 0, 0, 0, 0, time.UTC)
+	rates, err := GetIndicatorRates(IndicatorRUONIA, day, day)
+	if err != nil {
+		t.Fatalf("Error getting RUONIA: %v", err)
+	}
+	if len(rates) != 1 || rates[0].Indicator != IndicatorRUONIA || rates[0].Value.String() != "15.84" {
+		t.Errorf("Expected RUONIA 15.84, got %+v", rates)
+	}
+}
+
+// Testing unknown indicators and upstream failures
+func TestGetIndicatorRatesErrors(t *testing.T) {
+	day := time.Date(2026, 4, 14, 0, 0, 0, 0, time.UTC)
+
+	if _, err := GetIndicatorRates("MIACR", day, day); err == nil || !strings.Contains(err.Error(), "unknown indicator") {
+		t.Errorf("Expected an unknown indicator error, got %v", err)
+	}
+
+	server := setupMockIndicatorsServer(map[string]string{"KeyRateXML": ""}, http.StatusBadGateway, nil)
+	config.SetCBRIndicatorsURLForTesting(server.URL)
+	if _, err := GetIndicatorRates(IndicatorKeyRate, day, day); err == nil || !strings.Contains(err.Error(), "status code: 502") {
+		t.Errorf("Expected a status error, got %v", err)
+	}
+	server.Close()
+
+	server = setupMockIndicatorsServer(map[string]string{"KeyRateXML": "<KeyRate><KR>"}, http.StatusOK, nil)
+	config.SetCBRIndicatorsURLForTesting(server.URL)
+	if _, err := GetIndicatorRates(IndicatorKeyRate, day, day); err == nil || !strings.Contains(err.Error(), "failed to decode") {
+		t.Errorf("Expected a decode error, got %v", err)
+	}
+	server.Close()
+
+	server = setupMockIndicatorsServer(map[string]string{"KeyRateXML": `<KeyRate><KR><DT>2026-04-14T00:00:00+03:00</DT><Rate>n/a</Rate></KR></KeyRate>`}, http.StatusOK, nil)
+	config.SetCBRIndicatorsURLForTesting(server.URL)
+	if _, err := GetIndicatorRates(IndicatorKeyRate, day, day); err == nil || !strings.Contains(err.Error(), "n/a") {
+		t.Errorf("Expected an error for an unparseable value, got %v", err)
+	}
+	server.Close()
+}
+
+// Testing indicator lookup by code or name
+func TestIndicatorByCode(t *testing.T) {
+	for _, s := range []string{"KEY_RATE", "key_rate", "Key rate", "ruonia"} {
+		if _, ok := IndicatorByCode(s); !ok {
+			t.Errorf("Expected an indicator for %q", s)
+		}
+	}
+	if _, ok := IndicatorByCode("XAU"); ok {
+		t.Error("Expected XAU not to be an indicator")
+	}
+}
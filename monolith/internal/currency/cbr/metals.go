@@ -0,0 +1,171 @@
+package currency
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/internal/calendar"
+	"github.com/casualdoto/go-currency-tracker/internal/config"
+	"github.com/casualdoto/go-currency-tracker/internal/metrics"
+	"github.com/casualdoto/go-currency-tracker/internal/money"
+)
+
+// metalsDateLayout is the form of the date_req1 and date_req2 parameters
+const metalsDateLayout = "02/01/2006"
+
+// MetalsLookbackDays is how far back GetLatestMetalPrices looks for the last
+// day the CBR set prices; none are set on weekends and holidays
+const MetalsLookbackDays = 7
+
+// Metal is a precious metal the CBR sets a price for
+type Metal struct {
+	Code string // ISO 4217 code, e.g. XAU
+	Name string
+}
+
+// Metals maps the codes of the CBR metals feed to the metals they price
+var Metals = map[int]Metal{
+	1: {Code: "XAU", Name: "Gold"},
+	2: {Code: "XAG", Name: "Silver"},
+	3: {Code: "XPT", Name: "Platinum"},
+	4: {Code: "XPD", Name: "Palladium"},
+}
+
+// MetalPrice is the price of one gram of a metal in rubles set by the CBR
+// for a day
+type MetalPrice struct {
+	Date  time.Time
+	Metal string
+	Name  string
+	Buy   money.Decimal
+	Sell  money.Decimal
+
+	// SourceURL is the xml_metall.asp request the price was read from and
+	// FetchedAt the time it was read
+	SourceURL string
+	FetchedAt time.Time
+}
+
+type metalsResponse struct {
+	XMLName xml.Name      `xml:"Metall"`
+	Records []metalRecord `xml:"Record"`
+}
+
+type metalRecord struct {
+	Date string `xml:"Date,attr"`
+	Code int    `xml:"Code,attr"`
+	Buy  string `xml:"Buy"`
+	Sell string `xml:"Sell"`
+}
+
+// GetMetalPrices returns the prices the CBR set from from to to, inclusive,
+// ordered by date and metal. Days without prices are absent, and records
+// for metals not in Metals are skipped
+func GetMetalPrices(from, to time.Time) ([]MetalPrice, error) {
+	url := fmt.Sprintf("%s/scripts/xml_metall.asp?date_req1=%s&date_req2=%s",
+		config.GetCBRMetalsURL(), from.Format(metalsDateLayout), to.Format(metalsDateLayout))
+
+	client := &http.Client{Timeout: 10 * time.Second, Transport: metrics.Transport(metrics.SourceCBRMetals, nil)}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch metal prices: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch metal prices, status code: %d", resp.StatusCode)
+	}
+
+	// The document is declared windows-1251 but holds nothing outside ASCII
+	dec := xml.NewDecoder(resp.Body)
+	dec.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	var data metalsResponse
+	if err := dec.Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode metal prices: %w", err)
+	}
+
+	prices, err := parseMetalRecords(data.Records, url, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode metal prices: %w", err)
+	}
+	return prices, nil
+}
+
+// GetLatestMetalPrices returns the prices of the last day within the
+// lookback window the CBR set them for, or nil if there is none
+func GetLatestMetalPrices() ([]MetalPrice, error) {
+	today := calendar.Today()
+	prices, err := GetMetalPrices(today.AddDate(0, 0, 1-MetalsLookbackDays), today)
+	if err != nil || len(prices) == 0 {
+		return nil, err
+	}
+	latest := prices[len(prices)-1].Date
+	i := len(prices)
+	for i > 0 && prices[i-1].Date.Equal(latest) {
+		i--
+	}
+	return prices[i:], nil
+}
+
+// parseMetalRecords converts feed records into prices. Prices are written
+// with a decimal comma
+func parseMetalRecords(records []metalRecord, sourceURL string, fetchedAt time.Time) ([]MetalPrice, error) {
+	prices := make([]MetalPrice, 0, len(records))
+	for _, rec := range records {
+		metal, ok := Metals[rec.Code]
+		if !ok {
+			continue
+		}
+		date, err := time.Parse("02.01.2006", rec.Date)
+		if err != nil {
+			return nil, fmt.Errorf("metal %d: invalid date %q", rec.Code, rec.Date)
+		}
+		buy, err := parseCommaDecimal(rec.Buy)
+		if err != nil {
+			return nil, fmt.Errorf("metal %d on %s: buy %q: %w", rec.Code, rec.Date, rec.Buy, err)
+		}
+		sell, err := parseCommaDecimal(rec.Sell)
+		if err != nil {
+			return nil, fmt.Errorf("metal %d on %s: sell %q: %w", rec.Code, rec.Date, rec.Sell, err)
+		}
+		prices = append(prices, MetalPrice{
+			Date:      date,
+			Metal:     metal.Code,
+			Name:      metal.Name,
+			Buy:       buy,
+			Sell:      sell,
+			SourceURL: sourceURL,
+			FetchedAt: fetchedAt,
+		})
+	}
+	sort.SliceStable(prices, func(i, j int) bool {
+		if !prices[i].Date.Equal(prices[j].Date) {
+			return prices[i].Date.Before(prices[j].Date)
+		}
+		return prices[i].Metal < prices[j].Metal
+	})
+	return prices, nil
+}
+
+func parseCommaDecimal(s string) (money.Decimal, error) {
+	return money.Parse(strings.ReplaceAll(strings.TrimSpace(s), ",", "."))
+}
+
+// MetalByCode returns the metal with the ISO code or English name s, in any
+// case
+func MetalByCode(s string) (Metal, bool) {
+	s = strings.TrimSpace(s)
+	for _, m := range Metals {
+		if strings.EqualFold(m.Code, s) || strings.EqualFold(m.Name, s) {
+			return m, true
+		}
+	}
+	return Metal{}, false
+}
@@ -0,0 +1,155 @@
+package currency
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/internal/calendar"
+	"github.com/casualdoto/go-currency-tracker/internal/config"
+)
+
+// Metals feed as the CBR serves it, with a record for an unknown code
+const mockMetalsXML = `<?xml version="1.0" encoding="windows-1251"?>
+<Metall FromDate="20260414" ToDate="20260415" name="Precious metals quotations">
+<Record Date="14.04.2026" Code="1"><Buy>8512,37</Buy><Sell>8512,37</Sell></Record>
+<Record Date="14.04.2026" Code="2"><Buy>96,84</Buy><Sell>96,84</Sell></Record>
+<Record Date="15.04.2026" Code="4"><Buy>2801,05</Buy><Sell>2801,05</Sell></Record>
+<Record Date="15.04.2026" Code="1"><Buy>8540,1</Buy><Sell>8540,1</Sell></Record>
+<Record Date="15.04.2026" Code="9"><Buy>1,0</Buy><Sell>1,0</Sell></Record>
+</Metall>`
+
+// Mock server for the CBR metals feed; query receives the request query
+func setupMockMetalsServer(body string, status int, query *string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/scripts/xml_metall.asp" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if query != nil {
+			*query = r.URL.RawQuery
+		}
+		w.Header().Set("Content-Type", "application/xml; charset=windows-1251")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+}
+
+// Testing getting metal prices for a date range
+func TestGetMetalPrices(t *testing.T) {
+	var query string
+	server := setupMockMetalsServer(mockMetalsXML, http.StatusOK, &query)
+	defer server.Close()
+
+	config.SetCBRMetalsURLForTesting(server.URL)
+
+	from := time.Date(2026, 4, 14, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 4, 15, 0, 0, 0, 0, time.UTC)
+	prices, err := GetMetalPrices(from, to)
+	if err != nil {
+		t.Fatalf("Error getting metal prices: %v", err)
+	}
+
+	if query != "date_req1=14/04/2026&date_req2=15/04/2026" {
+		t.Errorf("Unexpected query %q", query)
+	}
+	// The unknown code is skipped and the rest ordered by date and metal
+	if len(prices) != 4 {
+		t.Fatalf("Expected 4 prices, got %d", len(prices))
+	}
+	want := []string{"XAG", "XAU", "XAU", "XPD"}
+	for i, p := range prices {
+		if p.Metal != want[i] {
+			t.Errorf("Expected %s at %d, got %s", want[i], i, p.Metal)
+		}
+	}
+	gold := prices[1]
+	if !gold.Date.Equal(from) || gold.Name != "Gold" {
+		t.Errorf("Expected gold on 2026-04-14, got %s on %v", gold.Name, gold.Date)
+	}
+	if gold.Buy.String() != "8512.37" || gold.Sell.String() != "8512.37" {
+		t.Errorf("Expected 8512.37 as published, got %v and %v", gold.Buy, gold.Sell)
+	}
+	if !strings.HasPrefix(gold.SourceURL, server.URL) || gold.FetchedAt.IsZero() {
+		t.Errorf("Expected source URL and fetch time, got %q and %v", gold.SourceURL, gold.FetchedAt)
+	}
+}
+
+// Testing the latest day of prices within the lookback window
+func TestGetLatestMetalPrices(t *testing.T) {
+	var query string
+	server := setupMockMetalsServer(mockMetalsXML, http.StatusOK, &query)
+	defer server.Close()
+
+	config.SetCBRMetalsURLForTesting(server.URL)
+
+	prices, err := GetLatestMetalPrices()
+	if err != nil {
+		t.Fatalf("Error getting latest metal prices: %v", err)
+	}
+	if len(prices) != 2 || prices[0].Metal != "XAU" || prices[1].Metal != "XPD" {
+		t.Fatalf("Expected gold and palladium of 2026-04-15, got %+v", prices)
+	}
+
+	today := calendar.Today()
+	want := "date_req1=" + today.AddDate(0, 0, -6).Format(metalsDateLayout) + "&date_req2=" + today.Format(metalsDateLayout)
+	if query != want {
+		t.Errorf("Expected query %q, got %q", want, query)
+	}
+}
+
+// Testing a window without prices
+func TestGetLatestMetalPricesEmpty(t *testing.T) {
+	server := setupMockMetalsServer(`<?xml version="1.0" encoding="windows-1251"?><Metall name="Precious metals quotations"></Metall>`, http.StatusOK, nil)
+	defer server.Close()
+
+	config.SetCBRMetalsURLForTesting(server.URL)
+
+	prices, err := GetLatestMetalPrices()
+	if err != nil {
+		t.Fatalf("Expected no error for a window without prices, got %v", err)
+	}
+	if len(prices) != 0 {
+		t.Errorf("Expected no prices, got %+v", prices)
+	}
+}
+
+// Testing upstream failures
+func TestGetMetalPricesErrors(t *testing.T) {
+	day := time.Date(2026, 4, 14, 0, 0, 0, 0, time.UTC)
+
+	server := setupMockMetalsServer("", http.StatusBadGateway, nil)
+	config.SetCBRMetalsURLForTesting(server.URL)
+	if _, err := GetMetalPrices(day, day); err == nil || !strings.Contains(err.Error(), "status code: 502") {
+		t.Errorf("Expected a status error, got %v", err)
+	}
+	server.Close()
+
+	server = setupMockMetalsServer("<html>maintenance</html>", http.StatusOK, nil)
+	config.SetCBRMetalsURLForTesting(server.URL)
+	if _, err := GetMetalPrices(day, day); err == nil || !strings.Contains(err.Error(), "failed to decode") {
+		t.Errorf("Expected a decode error, got %v", err)
+	}
+	server.Close()
+
+	server = setupMockMetalsServer(`<Metall><Record Date="14.04.2026" Code="1"><Buy>n/a</Buy><Sell>1,5</Sell></Record></Metall>`, http.StatusOK, nil)
+	config.SetCBRMetalsURLForTesting(server.URL)
+	if _, err := GetMetalPrices(day, day); err == nil || !strings.Contains(err.Error(), "buy") {
+		t.Errorf("Expected an error for an unparseable price, got %v", err)
+	}
+	server.Close()
+}
+
+// Testing metal lookup by code or name
+func TestMetalByCode(t *testing.T) {
+	for _, s := range []string{"XAU", "xau", "Gold", "GOLD"} {
+		if m, ok := MetalByCode(s); !ok || m.Code != "XAU" {
+			t.Errorf("Expected XAU for %q, got %+v", s, m)
+		}
+	}
+	if _, ok := MetalByCode("BTC"); ok {
+		t.Error("Expected BTC not to be a metal")
+	}
+}
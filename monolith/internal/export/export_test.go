@@ -0,0 +1,93 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseFormat checks accepted format names and the default
+func TestParseFormat(t *testing.T) {
+	for in, want := range map[string]Format{"": CSV, "CSV": CSV, "ndjson": NDJSON, "jsonl": NDJSON} {
+		got, err := ParseFormat(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	for _, bad := range []string{"parquet", "xlsx"} {
+		_, err := ParseFormat(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+// TestWriter_CSV checks header, quoting and buffering of CSV output
+func TestWriter_CSV(t *testing.T) {
+	var buf bytes.Buffer
+	flushes := 0
+	w := NewWriter(&buf, CSV, []string{"date", "code", "nominal", "value"}, func() { flushes++ })
+
+	require.NoError(t, w.Write("2024-01-09", "USD", 1, 89.6883))
+	require.NoError(t, w.Write("2024-01-09", "JPY, Japan", 100, 61.5))
+	assert.Zero(t, buf.Len(), "rows should stay buffered until Flush")
+	require.NoError(t, w.Flush())
+
+	assert.Equal(t, "date,code,nominal,value\n2024-01-09,USD,1,89.6883\n2024-01-09,\"JPY, Japan\",100,61.5\n", buf.String())
+	assert.Equal(t, 1, flushes)
+	assert.Equal(t, 2, w.Rows())
+}
+
+// TestWriter_CSVEmptyHasHeader checks that an empty export still has a header
+func TestWriter_CSVEmptyHasHeader(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, CSV, []string{"a", "b"}, nil)
+	require.NoError(t, w.Flush())
+	assert.Equal(t, "a,b\n", buf.String())
+}
+
+// TestWriter_NDJSON checks key order and UTC timestamps
+func TestWriter_NDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, NDJSON, []string{"timestamp", "symbol", "close"}, nil)
+	ts := time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("MSK", 3*3600))
+	require.NoError(t, w.Write(ts, "BTC/RUB", 5600000.5))
+	require.NoError(t, w.Flush())
+
+	assert.Equal(t, `{"timestamp":"2024-03-01T09:00:00Z","symbol":"BTC/RUB","close":5600000.5}`+"\n", buf.String())
+}
+
+// TestWriter_flushesPeriodically checks automatic flushing of long exports
+func TestWriter_flushesPeriodically(t *testing.T) {
+	var buf bytes.Buffer
+	flushes := 0
+	w := NewWriter(&buf, NDJSON, []string{"n"}, func() { flushes++ })
+	for i := 0; i < flushEvery*2+1; i++ {
+		require.NoError(t, w.Write(i))
+	}
+	assert.Equal(t, 2, flushes)
+	assert.Equal(t, flushEvery*2, strings.Count(buf.String(), "\n"))
+}
+
+// TestWriter_columnMismatch checks that rows must match the column list
+func TestWriter_columnMismatch(t *testing.T) {
+	w := NewWriter(&bytes.Buffer{}, CSV, []string{"a", "b"}, nil)
+	assert.Error(t, w.Write("only one"))
+}
+
+// TestWriter_abortEndsWithErrorRecord checks that a failed export is marked
+// as incomplete in both formats
+func TestWriter_abortEndsWithErrorRecord(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, CSV, []string{"date", "code", "value"}, nil)
+	require.NoError(t, w.Write("2024-01-09", "USD", 89.6883))
+	require.NoError(t, w.Abort("Failed to read rates"))
+	assert.Equal(t, "date,code,value\n2024-01-09,USD,89.6883\nerror,Failed to read rates,\n", buf.String())
+
+	buf.Reset()
+	w = NewWriter(&buf, NDJSON, []string{"n"}, nil)
+	require.NoError(t, w.Write(1))
+	require.NoError(t, w.Abort("Failed to read rates"))
+	assert.Equal(t, "{\"n\":1}\n{\"error\":\"Failed to read rates\"}\n", buf.String())
+}
@@ -0,0 +1,541 @@
+// Package indicators computes technical indicators (SMA, EMA, RSI, MACD,
+// Bollinger Bands) over OHLCV candles and evaluates simple conditions on
+// their latest values, e.g. "rsi14>70" or "sma20>sma50".
+//
+// This is a copy of microservices/shared/indicators, which the monolith
+// module cannot import. Both copies are tested against the fixture in
+// microservices/shared/indicators/testdata; keep them in sync.
+package indicators
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Candle is one OHLCV bar
+type Candle struct {
+	Time   time.Time `json:"time"`
+	Open   float64   `json:"open"`
+	High   float64   `json:"high"`
+	Low    float64   `json:"low"`
+	Close  float64   `json:"close"`
+	Volume float64   `json:"volume"`
+}
+
+// Value is one indicator observation, aligned with the candle at Time
+type Value struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// Intervals accepted by ParseInterval, in Binance notation
+var Intervals = map[string]time.Duration{
+	"1m":  time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"30m": 30 * time.Minute,
+	"1h":  time.Hour,
+	"4h":  4 * time.Hour,
+	"1d":  24 * time.Hour,
+}
+
+// ParseInterval maps an interval such as "1h" to its duration
+func ParseInterval(s string) (time.Duration, error) {
+	d, ok := Intervals[s]
+	if !ok {
+		return 0, fmt.Errorf("unsupported interval %q (use 1m, 5m, 15m, 30m, 1h, 4h or 1d)", s)
+	}
+	return d, nil
+}
+
+// Resample aggregates candles into bars of the given interval aligned to UTC:
+// first open, highest high, lowest low, last close and the last volume. The
+// stored candles are 24h ticker snapshots whose volume already is a rolling
+// 24h total, so volumes are not summed. Input may be unordered; bars are
+// returned oldest first.
+func Resample(candles []Candle, interval time.Duration) []Candle {
+	cs := append([]Candle(nil), candles...)
+	sort.SliceStable(cs, func(i, j int) bool { return cs[i].Time.Before(cs[j].Time) })
+
+	var out []Candle
+	for _, c := range cs {
+		bucket := c.Time.UTC().Truncate(interval)
+		if n := len(out); n > 0 && out[n-1].Time.Equal(bucket) {
+			b := &out[n-1]
+			b.High = math.Max(b.High, c.High)
+			b.Low = math.Min(b.Low, c.Low)
+			b.Close = c.Close
+			b.Volume = c.Volume
+			continue
+		}
+		c.Time = bucket
+		out = append(out, c)
+	}
+	return out
+}
+
+// Indicator kinds
+const (
+	KindSMA       = "sma"
+	KindEMA       = "ema"
+	KindRSI       = "rsi"
+	KindMACD      = "macd"
+	KindBollinger = "bb"
+)
+
+// Defaults used when a spec omits its period; MACD always uses 12/26/9 and
+// Bollinger Bands two standard deviations.
+const (
+	DefaultPeriod    = 20
+	DefaultRSIPeriod = 14
+	macdFast         = 12
+	macdSlow         = 26
+	macdSignal       = 9
+	bollingerK       = 2
+	maxPeriod        = 500
+)
+
+// Spec is a requested indicator, e.g. {rsi 14}
+type Spec struct {
+	Kind   string
+	Period int
+}
+
+// Name is the canonical spec string, e.g. "rsi14" or "macd"
+func (s Spec) Name() string {
+	if s.Kind == KindMACD {
+		return KindMACD
+	}
+	return s.Kind + strconv.Itoa(s.Period)
+}
+
+// Warmup is the number of bars needed before the first value
+func (s Spec) Warmup() int {
+	switch s.Kind {
+	case KindMACD:
+		return macdSlow + macdSignal
+	case KindRSI:
+		return s.Period + 1
+	default:
+		return s.Period
+	}
+}
+
+// ParseSpecs parses a comma-separated list such as "rsi14,ema20,macd,bb20"
+func ParseSpecs(s string) ([]Spec, error) {
+	var specs []Spec
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		spec, err := parseSpec(part)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[spec.Name()] {
+			seen[spec.Name()] = true
+			specs = append(specs, spec)
+		}
+	}
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("no indicators requested")
+	}
+	return specs, nil
+}
+
+func parseSpec(s string) (Spec, error) {
+	for _, kind := range []string{KindSMA, KindEMA, KindRSI, KindMACD, KindBollinger} {
+		if !strings.HasPrefix(s, kind) {
+			continue
+		}
+		rest := strings.TrimPrefix(s, kind)
+		if kind == KindMACD {
+			if rest != "" {
+				return Spec{}, fmt.Errorf("macd takes no period (uses 12/26/9): %q", s)
+			}
+			return Spec{Kind: kind}, nil
+		}
+		period := DefaultPeriod
+		if kind == KindRSI {
+			period = DefaultRSIPeriod
+		}
+		if rest != "" {
+			n, err := strconv.Atoi(rest)
+			if err != nil || n < 2 || n > maxPeriod {
+				return Spec{}, fmt.Errorf("invalid period in %q (2..%d)", s, maxPeriod)
+			}
+			period = n
+		}
+		return Spec{Kind: kind, Period: period}, nil
+	}
+	return Spec{}, fmt.Errorf("unknown indicator %q (use sma, ema, rsi, macd or bb)", s)
+}
+
+// MaxWarmup returns the largest warmup among specs
+func MaxWarmup(specs []Spec) int {
+	var n int
+	for _, s := range specs {
+		if w := s.Warmup(); w > n {
+			n = w
+		}
+	}
+	return n
+}
+
+// Compute evaluates specs over candles (oldest first) and returns one series
+// per output line. MACD yields "macd", "macd_signal" and "macd_hist";
+// Bollinger Bands yield "bbN_upper", "bbN_middle" and "bbN_lower". Bars
+// before an indicator's warmup are omitted.
+func Compute(candles []Candle, specs []Spec) map[string][]Value {
+	closes := make([]float64, len(candles))
+	for i, c := range candles {
+		closes[i] = c.Close
+	}
+
+	out := make(map[string][]Value)
+	put := func(name string, vs []float64) {
+		series := []Value{}
+		for i, v := range vs {
+			if !math.IsNaN(v) {
+				series = append(series, Value{Time: candles[i].Time, Value: v})
+			}
+		}
+		out[name] = series
+	}
+
+	for _, s := range specs {
+		switch s.Kind {
+		case KindSMA:
+			put(s.Name(), SMA(closes, s.Period))
+		case KindEMA:
+			put(s.Name(), EMA(closes, s.Period))
+		case KindRSI:
+			put(s.Name(), RSI(closes, s.Period))
+		case KindMACD:
+			line, signal, hist := MACD(closes, macdFast, macdSlow, macdSignal)
+			put("macd", line)
+			put("macd_signal", signal)
+			put("macd_hist", hist)
+		case KindBollinger:
+			upper, middle, lower := Bollinger(closes, s.Period, bollingerK)
+			put(s.Name()+"_upper", upper)
+			put(s.Name()+"_middle", middle)
+			put(s.Name()+"_lower", lower)
+		}
+	}
+	return out
+}
+
+// Latest returns the last value of every series that has one
+func Latest(series map[string][]Value) map[string]float64 {
+	out := make(map[string]float64, len(series))
+	for name, vs := range series {
+		if len(vs) > 0 {
+			out[name] = vs[len(vs)-1].Value
+		}
+	}
+	return out
+}
+
+// Trim drops values before from, e.g. to remove the warmup bars that were
+// loaded only to seed the indicators.
+func Trim(series map[string][]Value, from time.Time) {
+	for name, vs := range series {
+		i := sort.Search(len(vs), func(i int) bool { return !vs[i].Time.Before(from) })
+		series[name] = vs[i:]
+	}
+}
+
+// SMA is the simple moving average; the first n-1 values are NaN
+func SMA(values []float64, n int) []float64 {
+	out := nanSlice(len(values))
+	var sum float64
+	for i, v := range values {
+		sum += v
+		if i >= n {
+			sum -= values[i-n]
+		}
+		if i >= n-1 {
+			out[i] = sum / float64(n)
+		}
+	}
+	return out
+}
+
+// EMA is the exponential moving average with alpha 2/(n+1), seeded with the
+// SMA of the first n values; the first n-1 values are NaN.
+func EMA(values []float64, n int) []float64 {
+	out := nanSlice(len(values))
+	if len(values) < n {
+		return out
+	}
+	alpha := 2 / float64(n+1)
+	var seed float64
+	for _, v := range values[:n] {
+		seed += v
+	}
+	prev := seed / float64(n)
+	out[n-1] = prev
+	for i := n; i < len(values); i++ {
+		prev = alpha*values[i] + (1-alpha)*prev
+		out[i] = prev
+	}
+	return out
+}
+
+// RSI is Wilder's relative strength index; the first n values are NaN
+func RSI(values []float64, n int) []float64 {
+	out := nanSlice(len(values))
+	if len(values) <= n {
+		return out
+	}
+	var gain, loss float64
+	for i := 1; i <= n; i++ {
+		d := values[i] - values[i-1]
+		if d > 0 {
+			gain += d
+		} else {
+			loss -= d
+		}
+	}
+	gain /= float64(n)
+	loss /= float64(n)
+	out[n] = rsi(gain, loss)
+	for i := n + 1; i < len(values); i++ {
+		d := values[i] - values[i-1]
+		g, l := math.Max(d, 0), math.Max(-d, 0)
+		gain = (gain*float64(n-1) + g) / float64(n)
+		loss = (loss*float64(n-1) + l) / float64(n)
+		out[i] = rsi(gain, loss)
+	}
+	return out
+}
+
+func rsi(gain, loss float64) float64 {
+	if loss == 0 {
+		if gain == 0 {
+			return 50
+		}
+		return 100
+	}
+	return 100 - 100/(1+gain/loss)
+}
+
+// MACD returns the MACD line (EMA fast - EMA slow), its signal EMA and the
+// histogram (line - signal).
+func MACD(values []float64, fast, slow, signal int) (line, sig, hist []float64) {
+	ef, es := EMA(values, fast), EMA(values, slow)
+	line = nanSlice(len(values))
+	for i := range values {
+		line[i] = ef[i] - es[i]
+	}
+
+	sig = nanSlice(len(values))
+	hist = nanSlice(len(values))
+	if len(values) < slow {
+		return line, sig, hist
+	}
+	s := EMA(line[slow-1:], signal)
+	for i, v := range s {
+		j := i + slow - 1
+		sig[j] = v
+		if !math.IsNaN(v) {
+			hist[j] = line[j] - v
+		}
+	}
+	return line, sig, hist
+}
+
+// Bollinger returns the upper and lower bands at k population standard
+// deviations around the n-period SMA (middle).
+func Bollinger(values []float64, n int, k float64) (upper, middle, lower []float64) {
+	middle = SMA(values, n)
+	upper, lower = nanSlice(len(values)), nanSlice(len(values))
+	for i := n - 1; i < len(values); i++ {
+		var sq float64
+		for _, v := range values[i-n+1 : i+1] {
+			sq += (v - middle[i]) * (v - middle[i])
+		}
+		sd := math.Sqrt(sq / float64(n))
+		upper[i] = middle[i] + k*sd
+		lower[i] = middle[i] - k*sd
+	}
+	return upper, middle, lower
+}
+
+func nanSlice(n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = math.NaN()
+	}
+	return out
+}
+
+// Condition is a rule on an indicator's latest value, as checked by the
+// ?when= parameter of the indicators endpoints and by alert subscriptions.
+// It compares with a number, "rsi14>70", "macd_hist<0", "close>=6000000",
+// or with another indicator, "sma20>sma50".
+type Condition struct {
+	Indicator string
+	Op        string
+	Threshold float64
+	// Other is the indicator compared with instead of Threshold
+	Other string
+}
+
+// ParseCondition parses "<indicator><op><number>" or
+// "<indicator><op><indicator>" with op one of >, <, >=, <=.
+func ParseCondition(s string) (Condition, error) {
+	s = strings.ToLower(strings.ReplaceAll(s, " ", ""))
+	for _, op := range []string{">=", "<=", ">", "<"} {
+		i := strings.Index(s, op)
+		if i <= 0 {
+			continue
+		}
+		c := Condition{Indicator: s[:i], Op: op}
+		rhs := s[i+len(op):]
+		threshold, err := strconv.ParseFloat(rhs, 64)
+		if err == nil {
+			c.Threshold = threshold
+			return c, nil
+		}
+		if _, ok := SeriesSpec(rhs); ok || rhs == "close" {
+			c.Other = rhs
+			return c, nil
+		}
+		return Condition{}, fmt.Errorf("invalid threshold in %q", s)
+	}
+	return Condition{}, fmt.Errorf("invalid condition %q (e.g. rsi14>70)", s)
+}
+
+// Met reports whether the condition holds for latest. A missing indicator
+// never matches.
+func (c Condition) Met(latest map[string]float64) bool {
+	v, ok := latest[c.Indicator]
+	if !ok {
+		return false
+	}
+	threshold := c.Threshold
+	if c.Other != "" {
+		if threshold, ok = latest[c.Other]; !ok {
+			return false
+		}
+	}
+	switch c.Op {
+	case ">":
+		return v > threshold
+	case "<":
+		return v < threshold
+	case ">=":
+		return v >= threshold
+	case "<=":
+		return v <= threshold
+	}
+	return false
+}
+
+// String renders the condition back, e.g. "rsi14>70"
+func (c Condition) String() string {
+	if c.Other != "" {
+		return c.Indicator + c.Op + c.Other
+	}
+	return c.Indicator + c.Op + strconv.FormatFloat(c.Threshold, 'f', -1, 64)
+}
+
+// Specs returns the indicators the condition reads; "close" needs none
+func (c Condition) Specs() ([]Spec, error) {
+	var specs []Spec
+	for _, name := range []string{c.Indicator, c.Other} {
+		if name == "" || name == "close" {
+			continue
+		}
+		spec, ok := SeriesSpec(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown indicator %q in %q", name, c.String())
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// SeriesSpec returns the spec whose Compute output includes the series
+// name: rsi14 for "rsi14", macd for "macd_hist", bb20 for "bb20_upper".
+func SeriesSpec(name string) (Spec, bool) {
+	base, _, _ := strings.Cut(name, "_")
+	spec, err := parseSpec(base)
+	if err != nil {
+		return Spec{}, false
+	}
+	var series []string
+	switch spec.Kind {
+	case KindMACD:
+		series = []string{"macd", "macd_signal", "macd_hist"}
+	case KindBollinger:
+		series = []string{spec.Name() + "_upper", spec.Name() + "_middle", spec.Name() + "_lower"}
+	default:
+		series = []string{spec.Name()}
+	}
+	return spec, slices.Contains(series, name)
+}
+
+// DefaultInterval is the interval of an alert that names none
+const DefaultInterval = "1h"
+
+// Alert is a condition on the indicators of a cryptocurrency at an
+// interval, as followed by alert subscriptions: "BTC 1h rsi14>70" holds
+// while the hourly RSI is above 70, "ETH 4h sma20>sma50" while the fast
+// average is above the slow one. Subscribers are told when it starts to
+// hold, so the second one alerts on the cross.
+type Alert struct {
+	Symbol    string
+	Interval  string
+	Condition Condition
+}
+
+// ParseAlert parses "<symbol> [interval] <condition>", e.g. "BTC rsi14>70"
+// or "ETH 4h sma20>sma50". The interval defaults to DefaultInterval and a
+// USDT suffix on the symbol is dropped. The condition must read at least
+// one indicator; plain price alerts are crypto subscriptions.
+func ParseAlert(s string) (Alert, error) {
+	fields := strings.Fields(s)
+	a := Alert{Interval: DefaultInterval}
+	switch len(fields) {
+	case 2:
+	case 3:
+		a.Interval = strings.ToLower(fields[1])
+	default:
+		return Alert{}, fmt.Errorf("invalid alert %q (e.g. BTC 1h rsi14>70)", s)
+	}
+	a.Symbol = strings.TrimSuffix(strings.ToUpper(fields[0]), "USDT")
+	if a.Symbol == "" {
+		return Alert{}, fmt.Errorf("invalid alert %q (e.g. BTC 1h rsi14>70)", s)
+	}
+	if _, err := ParseInterval(a.Interval); err != nil {
+		return Alert{}, err
+	}
+	c, err := ParseCondition(fields[len(fields)-1])
+	if err != nil {
+		return Alert{}, err
+	}
+	specs, err := c.Specs()
+	if err != nil {
+		return Alert{}, err
+	}
+	if len(specs) == 0 {
+		return Alert{}, fmt.Errorf("alert %q names no indicator", s)
+	}
+	a.Condition = c
+	return a, nil
+}
+
+// String renders the alert in full, e.g. "BTC 1h rsi14>70"
+func (a Alert) String() string {
+	return a.Symbol + " " + a.Interval + " " + a.Condition.String()
+}
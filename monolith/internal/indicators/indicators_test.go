@@ -0,0 +1,227 @@
+package indicators
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var t0 = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// Builds hourly candles with the given closes
+func closes(values ...float64) []Candle {
+	cs := make([]Candle, len(values))
+	for i, v := range values {
+		cs[i] = Candle{Time: t0.Add(time.Duration(i) * time.Hour), Open: v, High: v, Low: v, Close: v}
+	}
+	return cs
+}
+
+// TestParseSpecs checks canonical names, defaults, de-duplication and errors
+func TestParseSpecs(t *testing.T) {
+	specs, err := ParseSpecs("RSI14, ema20,macd,bb,sma5,rsi14")
+	require.NoError(t, err)
+
+	var names []string
+	for _, s := range specs {
+		names = append(names, s.Name())
+	}
+	assert.Equal(t, []string{"rsi14", "ema20", "macd", "bb20", "sma5"}, names)
+	assert.Equal(t, 15, specs[0].Warmup())
+	assert.Equal(t, 35, MaxWarmup(specs))
+
+	for _, bad := range []string{"", "vwap", "rsi1", "ema9999", "macd12", "smaX"} {
+		_, err := ParseSpecs(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+// TestParseInterval checks supported and unsupported intervals
+func TestParseInterval(t *testing.T) {
+	d, err := ParseInterval("4h")
+	require.NoError(t, err)
+	assert.Equal(t, 4*time.Hour, d)
+
+	_, err = ParseInterval("2h")
+	assert.Error(t, err)
+}
+
+// TestResample checks OHLCV aggregation into hourly bars
+func TestResample(t *testing.T) {
+	out := Resample([]Candle{
+		{Time: t0.Add(90 * time.Minute), Open: 3, High: 4, Low: 2, Close: 3.5, Volume: 1},
+		{Time: t0, Open: 1, High: 2, Low: 0.5, Close: 1.5, Volume: 2},
+		{Time: t0.Add(30 * time.Minute), Open: 1.5, High: 5, Low: 1, Close: 2, Volume: 3},
+	}, time.Hour)
+
+	require.Len(t, out, 2)
+	assert.Equal(t, Candle{Time: t0, Open: 1, High: 5, Low: 0.5, Close: 2, Volume: 3}, out[0])
+	assert.Equal(t, t0.Add(time.Hour), out[1].Time)
+	assert.Equal(t, 3.5, out[1].Close)
+}
+
+// TestMovingAverages checks SMA and EMA against hand-computed values
+func TestMovingAverages(t *testing.T) {
+	sma := SMA([]float64{1, 2, 3, 4, 5}, 3)
+	assert.True(t, math.IsNaN(sma[1]))
+	assert.Equal(t, []float64{2, 3, 4}, sma[2:])
+
+	// alpha = 0.5, seeded with mean(1,2,3) = 2
+	ema := EMA([]float64{1, 2, 3, 4, 5}, 3)
+	assert.True(t, math.IsNaN(ema[1]))
+	assert.Equal(t, []float64{2, 3, 4}, ema[2:])
+}
+
+// TestRSI checks the bounds for rising, flat and alternating series
+func TestRSI(t *testing.T) {
+	up := make([]float64, 20)
+	for i := range up {
+		up[i] = float64(i)
+	}
+	r := RSI(up, 14)
+	assert.True(t, math.IsNaN(r[13]))
+	assert.Equal(t, 100.0, r[19])
+
+	assert.Equal(t, 50.0, RSI(make([]float64, 20), 14)[19])
+	assert.InDelta(t, 50, RSI([]float64{10, 11, 10, 11, 10}, 2)[2], 1e-9)
+}
+
+// TestMACDAndBollinger checks warmup of MACD and band width of Bollinger Bands
+func TestMACDAndBollinger(t *testing.T) {
+	values := make([]float64, 60)
+	for i := range values {
+		values[i] = 100 + float64(i)
+	}
+	line, signal, hist := MACD(values, 12, 26, 9)
+	assert.True(t, math.IsNaN(line[24]))
+	assert.False(t, math.IsNaN(line[25]))
+	assert.True(t, math.IsNaN(signal[32]))
+	assert.False(t, math.IsNaN(signal[33]))
+	assert.Greater(t, line[59], 0.0)
+	assert.InDelta(t, line[59]-signal[59], hist[59], 1e-9)
+
+	// Window (1,3): mean 2, population stddev 1
+	upper, middle, lower := Bollinger([]float64{1, 3, 1, 3}, 2, 2)
+	assert.Equal(t, 4.0, upper[1])
+	assert.Equal(t, 2.0, middle[1])
+	assert.Equal(t, 0.0, lower[1])
+}
+
+// TestComputeTrimAndLatest checks series naming, warmup omission and trimming
+func TestComputeTrimAndLatest(t *testing.T) {
+	cs := closes(1, 2, 3, 4, 5, 6)
+	specs, err := ParseSpecs("sma3,bb3")
+	require.NoError(t, err)
+
+	series := Compute(cs, specs)
+	assert.Len(t, series["sma3"], 4)
+	assert.Equal(t, cs[2].Time, series["sma3"][0].Time)
+	for _, name := range []string{"bb3_upper", "bb3_middle", "bb3_lower"} {
+		assert.Len(t, series[name], 4, name)
+	}
+
+	Trim(series, cs[4].Time)
+	assert.Len(t, series["sma3"], 2)
+	assert.Equal(t, 5.0, Latest(series)["sma3"])
+}
+
+// sharedFixture is the fixture of the microservices copy of this package;
+// both copies must compute the same values from it
+const sharedFixture = "../../../microservices/shared/indicators/testdata/fixture.json"
+
+// TestSharedFixture checks resampling and the latest indicator values
+// against the fixture shared with the microservices
+func TestSharedFixture(t *testing.T) {
+	data, err := os.ReadFile(sharedFixture)
+	require.NoError(t, err)
+	var f struct {
+		Interval   string             `json:"interval"`
+		Indicators string             `json:"indicators"`
+		Bars       int                `json:"bars"`
+		FirstBar   Candle             `json:"first_bar"`
+		LastBar    Candle             `json:"last_bar"`
+		Latest     map[string]float64 `json:"latest"`
+		Candles    []Candle           `json:"candles"`
+	}
+	require.NoError(t, json.Unmarshal(data, &f))
+	step, err := ParseInterval(f.Interval)
+	require.NoError(t, err)
+	specs, err := ParseSpecs(f.Indicators)
+	require.NoError(t, err)
+
+	bars := Resample(f.Candles, step)
+	require.Len(t, bars, f.Bars)
+	assert.Equal(t, f.FirstBar, bars[0])
+	assert.Equal(t, f.LastBar, bars[len(bars)-1])
+
+	latest := Latest(Compute(bars, specs))
+	require.Len(t, latest, len(f.Latest))
+	for name, want := range f.Latest {
+		assert.InDelta(t, want, latest[name], 1e-9, name)
+	}
+}
+
+// TestCondition checks parsing and evaluation of threshold conditions
+func TestCondition(t *testing.T) {
+	c, err := ParseCondition("RSI14 > 70")
+	require.NoError(t, err)
+	assert.Equal(t, Condition{Indicator: "rsi14", Op: ">", Threshold: 70}, c)
+	assert.Equal(t, "rsi14>70", c.String())
+	assert.True(t, c.Met(map[string]float64{"rsi14": 75}))
+	assert.False(t, c.Met(map[string]float64{"rsi14": 70}))
+	assert.False(t, c.Met(map[string]float64{}))
+
+	le, err := ParseCondition("macd_hist<=0")
+	require.NoError(t, err)
+	assert.True(t, le.Met(map[string]float64{"macd_hist": 0}))
+
+	cross, err := ParseCondition("sma20>sma50")
+	require.NoError(t, err)
+	assert.Equal(t, Condition{Indicator: "sma20", Op: ">", Other: "sma50"}, cross)
+	assert.Equal(t, "sma20>sma50", cross.String())
+	assert.True(t, cross.Met(map[string]float64{"sma20": 101, "sma50": 100}))
+	assert.False(t, cross.Met(map[string]float64{"sma20": 99, "sma50": 100}))
+	assert.False(t, cross.Met(map[string]float64{"sma20": 101}))
+
+	for _, bad := range []string{"rsi14", ">70", "rsi14>abc", "rsi14>rsi"} {
+		_, err := ParseCondition(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+// TestSeriesSpec checks the spec behind every kind of series name
+func TestSeriesSpec(t *testing.T) {
+	for name, want := range map[string]string{"rsi14": "rsi14", "sma50": "sma50", "macd_hist": "macd", "bb20_upper": "bb20"} {
+		spec, ok := SeriesSpec(name)
+		assert.True(t, ok, name)
+		assert.Equal(t, want, spec.Name(), name)
+	}
+	for _, name := range []string{"close", "rsi", "macd_x", "bb20", "sma20_upper"} {
+		_, ok := SeriesSpec(name)
+		assert.False(t, ok, name)
+	}
+}
+
+// TestParseAlert checks parsing of alert subscriptions
+func TestParseAlert(t *testing.T) {
+	a, err := ParseAlert("btcusdt RSI14>70")
+	require.NoError(t, err)
+	assert.Equal(t, "BTC 1h rsi14>70", a.String())
+
+	a, err = ParseAlert("ETH 4h sma20>sma50")
+	require.NoError(t, err)
+	assert.Equal(t, "4h", a.Interval)
+	specs, err := a.Condition.Specs()
+	require.NoError(t, err)
+	assert.Equal(t, []Spec{{Kind: KindSMA, Period: 20}, {Kind: KindSMA, Period: 50}}, specs)
+
+	for _, bad := range []string{"BTC", "BTC 2h rsi14>70", "BTC foo>70", "BTC 1h rsi14>70 extra", "BTC close>100"} {
+		_, err := ParseAlert(bad)
+		assert.Error(t, err, bad)
+	}
+}
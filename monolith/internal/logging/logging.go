@@ -0,0 +1,248 @@
+// Package logging sets up structured JSON logging with log/slog. Every line
+// logged while serving a request carries its request_id and route, so the
+// lines of one request can be found together. The field names are the same
+// as in the microservices (microservices/shared/logging); keep the two in
+// sync. The monolith has no Kafka or gRPC, so request IDs only travel over
+// HTTP.
+//
+// Packages log through a logger of their own:
+//
+//	var logger = logging.For("scheduler")
+//
+//	logger.InfoContext(ctx, "rates saved", "source", "cbr", "count", n)
+//
+// The level is read by Setup:
+//
+//	LOG_LEVEL    debug, info, warn or error for every package (default info)
+//	LOG_LEVELS   per-package overrides, e.g. binance=debug,storage=warn
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// HeaderRequestID carries the request ID on requests and responses
+const HeaderRequestID = "X-Request-ID"
+
+// output is the handler every logger writes to; Setup replaces it
+var output atomic.Pointer[slog.Handler]
+
+var levels = struct {
+	sync.RWMutex
+	def  slog.Level
+	pkgs map[string]slog.Level
+}{def: slog.LevelInfo}
+
+func init() {
+	setOutput(os.Stderr, nil)
+}
+
+func setOutput(w io.Writer, attrs []slog.Attr) {
+	// Levels are checked per package before a record reaches the output
+	var h slog.Handler = slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug})
+	if len(attrs) > 0 {
+		h = h.WithAttrs(attrs)
+	}
+	output.Store(&h)
+}
+
+// Setup makes every logger write JSON lines tagged with service to stdout,
+// reads the levels from the environment and routes the standard library's
+// log package and slog's default logger through the same output
+func Setup(service string) {
+	setOutput(os.Stdout, []slog.Attr{slog.String("service", service)})
+	err := SetLevels(os.Getenv("LOG_LEVEL"), os.Getenv("LOG_LEVELS"))
+	slog.SetDefault(For(""))
+	if err != nil {
+		slog.Warn("invalid log level, using info", "error", err)
+	}
+}
+
+// SetLevels sets the level of every package to def (info when empty) and
+// then applies the comma-separated package=level overrides
+func SetLevels(def, overrides string) error {
+	d := slog.LevelInfo
+	pkgs := map[string]slog.Level{}
+	var errs []string
+	if def != "" {
+		if err := d.UnmarshalText([]byte(def)); err != nil {
+			errs = append(errs, err.Error())
+			d = slog.LevelInfo
+		}
+	}
+	for _, kv := range strings.Split(overrides, ",") {
+		pkg, lvl, ok := strings.Cut(strings.TrimSpace(kv), "=")
+		if !ok {
+			if kv != "" {
+				errs = append(errs, fmt.Sprintf("%q is not package=level", kv))
+			}
+			continue
+		}
+		var l slog.Level
+		if err := l.UnmarshalText([]byte(lvl)); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		pkgs[pkg] = l
+	}
+
+	levels.Lock()
+	levels.def, levels.pkgs = d, pkgs
+	levels.Unlock()
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func levelFor(pkg string) slog.Level {
+	levels.RLock()
+	defer levels.RUnlock()
+	if l, ok := levels.pkgs[pkg]; ok {
+		return l
+	}
+	return levels.def
+}
+
+// For returns the logger of pkg. It may be created before Setup runs; the
+// output and levels are looked up when a line is logged
+func For(pkg string) *slog.Logger {
+	h := &handler{pkg: pkg}
+	if pkg != "" {
+		return slog.New(h).With("package", pkg)
+	}
+	return slog.New(h)
+}
+
+// handler checks the level of its package, adds the request fields from the
+// context and hands the record to the current output
+type handler struct {
+	pkg string
+	ops []func(slog.Handler) slog.Handler // WithAttrs and WithGroup calls, replayed on the output
+}
+
+func (h *handler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= levelFor(h.pkg)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if rctx := chi.RouteContext(ctx); rctx != nil && rctx.RoutePattern() != "" {
+		r.AddAttrs(slog.String("route", rctx.RoutePattern()))
+	}
+	out := *output.Load()
+	for _, op := range h.ops {
+		out = op(out)
+	}
+	return out.Handle(ctx, r)
+}
+
+func (h *handler) with(op func(slog.Handler) slog.Handler) *handler {
+	ops := make([]func(slog.Handler) slog.Handler, len(h.ops), len(h.ops)+1)
+	copy(ops, h.ops)
+	return &handler{pkg: h.pkg, ops: append(ops, op)}
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(out slog.Handler) slog.Handler { return out.WithAttrs(attrs) })
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return h.with(func(out slog.Handler) slog.Handler { return out.WithGroup(name) })
+}
+
+type requestIDKey struct{}
+
+// NewRequestID returns a random request ID
+func NewRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// WithRequestID returns a copy of ctx carrying id
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, if any
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// validRequestID reports whether an ID sent by a client is safe to log
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+var httpLogger = For("http")
+
+// probes are the paths polled by Prometheus and health checks
+var probes = map[string]bool{"/metrics": true, "/healthz": true, "/readyz": true}
+
+// Middleware replaces chi's request logger. It takes the request ID from the
+// X-Request-ID header, or generates one when the client sent none, echoes
+// it in the response and logs every request with its status and duration.
+// Prometheus scrapes and health probes are logged at debug level
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(HeaderRequestID)
+		if !validRequestID(id) {
+			id = NewRequestID()
+		}
+		w.Header().Set(HeaderRequestID, id)
+		r = r.WithContext(WithRequestID(r.Context(), id))
+
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case probes[r.URL.Path]:
+			level = slog.LevelDebug
+		}
+		httpLogger.LogAttrs(r.Context(), level, "request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", status),
+			slog.Int("bytes", ww.BytesWritten()),
+			slog.Float64("duration_ms", Millis(time.Since(start))))
+	})
+}
+
+// Millis converts d to fractional milliseconds for duration_ms fields
+func Millis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
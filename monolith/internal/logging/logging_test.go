@@ -0,0 +1,98 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// capture makes every logger write to the returned buffer at the given
+// levels until the test ends
+func capture(t *testing.T, def, overrides string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prev := output.Load()
+	setOutput(&buf, nil)
+	require.NoError(t, SetLevels(def, overrides))
+	t.Cleanup(func() {
+		output.Store(prev)
+		SetLevels("", "")
+	})
+	return &buf
+}
+
+// lines decodes the JSON lines in buf
+func lines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for _, l := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if l == "" {
+			continue
+		}
+		var m map[string]any
+		require.NoError(t, json.Unmarshal([]byte(l), &m), l)
+		out = append(out, m)
+	}
+	return out
+}
+
+func TestFor(t *testing.T) {
+	buf := capture(t, "warn", "binance=debug")
+
+	For("binance").Debug("retrying", "symbol", "BTCUSDT")
+	For("storage").Info("saved")
+	For("storage").Warn("slow query", "rows", 3)
+
+	got := lines(t, buf)
+	require.Len(t, got, 2)
+	assert.Equal(t, "binance", got[0]["package"])
+	assert.Equal(t, "retrying", got[0]["msg"])
+	assert.Equal(t, float64(3), got[1]["rows"])
+}
+
+func TestSetLevels(t *testing.T) {
+	capture(t, "", "")
+
+	assert.Error(t, SetLevels("loud", "binance=debug,storage"))
+	assert.Equal(t, "DEBUG", levelFor("binance").String())
+	assert.Equal(t, "INFO", levelFor("storage").String())
+}
+
+func TestMiddleware(t *testing.T) {
+	buf := capture(t, "", "")
+
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Get("/rates/{code}", func(w http.ResponseWriter, r *http.Request) {
+		For("api").InfoContext(r.Context(), "rate", "currency", chi.URLParam(r, "code"))
+	})
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/rates/USD", nil)
+	req.Header.Set(HeaderRequestID, "abc-123")
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, "abc-123", rr.Header().Get(HeaderRequestID))
+	got := lines(t, buf)
+	require.Len(t, got, 2)
+	for _, l := range got {
+		assert.Equal(t, "abc-123", l["request_id"])
+		assert.Equal(t, "/rates/{code}", l["route"])
+	}
+	assert.Equal(t, float64(200), got[1]["status"])
+	assert.NotNil(t, got[1]["duration_ms"])
+
+	// A missing or unusable ID is replaced
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/rates/EUR", nil)
+	req.Header.Set(HeaderRequestID, "bad id\n")
+	r.ServeHTTP(rr, req)
+	assert.Len(t, rr.Header().Get(HeaderRequestID), 16)
+}
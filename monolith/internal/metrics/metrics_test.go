@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Get("/rates/{code}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	r.Method(http.MethodGet, "/metrics", Handler())
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/rates/USD", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/rates/EUR", nil))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	// The names must match microservices/shared/metrics
+	assert.Contains(t, rr.Body.String(),
+		`currency_tracker_http_request_duration_seconds_count{method="GET",route="/rates/{code}",status="404"} 2`)
+}
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/limited" {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: Transport("test", nil)}
+
+	for _, path := range []string{"/", "/limited"} {
+		resp, err := client.Get(srv.URL + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(upstreamErrors.WithLabelValues("test")))
+}
+
+func TestCounters(t *testing.T) {
+	Backfill(SourceCBR, nil)
+	Backfill(SourceCBR, errors.New("database is down"))
+	TelegramSent(nil)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(backfills.WithLabelValues(SourceCBR, "ok")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(backfills.WithLabelValues(SourceCBR, "error")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(telegramSent.WithLabelValues("ok")))
+}
+
+func TestRateStored(t *testing.T) {
+	RateStored(SourceBinance, time.Now().Add(-2*time.Hour))
+	RateStored(SourceBinance, time.Time{}) // an empty table while seeding is ignored
+
+	assert.InDelta(t, 7200, testutil.ToFloat64(rateAge), 60)
+}
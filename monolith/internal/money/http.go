@@ -0,0 +1,47 @@
+package money
+
+import "net/http"
+
+// Middleware marks the responses of requests that ask for decimals as JSON
+// numbers (see WantNumbers), so that ForResponse converts what handlers
+// write to them. It must be the innermost middleware, as handlers recognize
+// the marked ResponseWriter by its type
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if WantNumbers(r.URL.Query()) {
+			w = numbersWriter{w}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// NumbersRequested reports whether w is the ResponseWriter of a request that
+// asked for decimals as JSON numbers
+func NumbersRequested(w http.ResponseWriter) bool {
+	_, ok := w.(numbersWriter)
+	return ok
+}
+
+// ForResponse returns v as it is to be encoded on w: Numbers(v) when the
+// request asked for JSON numbers, v itself otherwise
+func ForResponse(w http.ResponseWriter, v any) any {
+	if NumbersRequested(w) {
+		return Numbers(v)
+	}
+	return v
+}
+
+// numbersWriter is the ResponseWriter of a request that asked for numbers
+type numbersWriter struct {
+	http.ResponseWriter
+}
+
+// Flush keeps streamed responses (exports) flushing through the wrapper
+func (w numbersWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w numbersWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
@@ -0,0 +1,122 @@
+package money
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSON(t *testing.T) {
+	d := MustParse("87.0341")
+	b, err := json.Marshal(d)
+	require.NoError(t, err)
+	assert.Equal(t, `"87.0341"`, string(b))
+
+	for _, in := range []string{`"87.0341"`, `87.0341`, `8.70341e1`} {
+		var got Decimal
+		require.NoError(t, json.Unmarshal([]byte(in), &got), in)
+		assert.True(t, got.Equal(d), "%s decoded as %s", in, got)
+	}
+
+	var bad Decimal
+	assert.Error(t, json.Unmarshal([]byte(`"abc"`), &bad))
+}
+
+func TestArithmeticIsExact(t *testing.T) {
+	// 0.1 + 0.2 is 0.30000000000000004 in float64
+	assert.Equal(t, "0.3", MustParse("0.1").Add(MustParse("0.2")).String())
+	// A Binance close times the CBR USD rate
+	assert.Equal(t, "5936824.731835", MustParse("64123.45").Mul(MustParse("92.5843")).String())
+	assert.Equal(t, "0.33333333", MustParse("1").Div(MustParse("3"), PriceScale).String())
+	assert.True(t, MustParse("1").Div(Zero, PriceScale).IsZero())
+}
+
+func TestRoundAmount(t *testing.T) {
+	tests := []struct {
+		in, code string
+		crypto   bool
+		want     string
+	}{
+		{"1234.565", "RUB", false, "1234.57"},
+		{"-1234.565", "RUB", false, "-1234.57"},
+		{"1234.5", "JPY", false, "1235"},
+		{"1.23456", "KWD", false, "1.235"},
+		{"0.123456789", "BTC", true, "0.12345679"},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, RoundAmount(MustParse(tc.in), tc.code, tc.crypto).String(), "%s %s", tc.in, tc.code)
+	}
+}
+
+func TestPerUnit(t *testing.T) {
+	tests := []struct {
+		value   string
+		nominal int
+		want    string
+	}{
+		{"92.5843", 1, "92.5843"},
+		{"60.1234", 100, "0.601234"},
+		{"35.1234", 10000, "0.00351234"},
+		{"92.5843", 0, "92.5843"},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, PerUnit(MustParse(tc.value), tc.nominal).String(), "%s / %d", tc.value, tc.nominal)
+	}
+}
+
+func TestScan(t *testing.T) {
+	for _, src := range []any{"89.6883", []byte("89.6883"), 89.6883} {
+		var d Decimal
+		require.NoError(t, d.Scan(src))
+		assert.Equal(t, "89.6883", d.String(), "%T", src)
+	}
+
+	var d Decimal
+	require.NoError(t, d.Scan(nil))
+	assert.True(t, d.IsZero())
+
+	v, err := MustParse("89.6883").Value()
+	require.NoError(t, err)
+	assert.Equal(t, "89.6883", v)
+}
+
+func TestNumbers(t *testing.T) {
+	type rate struct {
+		Code   string             `json:"code"`
+		Value  Decimal            `json:"value"`
+		Quotes map[string]Decimal `json:"quotes"`
+		Next   *Decimal           `json:"next,omitempty"`
+	}
+	next := MustParse("91.5")
+	v := struct {
+		Data []rate `json:"data"`
+	}{Data: []rate{{Code: "USD", Value: MustParse("90.1"), Quotes: map[string]Decimal{"EUR": MustParse("0.92")}, Next: &next}}}
+
+	b, err := json.Marshal(Numbers(v))
+	require.NoError(t, err)
+	assert.Equal(t, `{"data":[{"code":"USD","value":90.1,"quotes":{"EUR":0.92},"next":91.5}]}`, string(b))
+
+	// The original still encodes strings
+	b, err = json.Marshal(v)
+	require.NoError(t, err)
+	assert.Equal(t, `{"data":[{"code":"USD","value":"90.1","quotes":{"EUR":"0.92"},"next":"91.5"}]}`, string(b))
+}
+
+func TestMiddleware(t *testing.T) {
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(ForResponse(w, map[string]Decimal{"value": MustParse("90.1")}))
+	}))
+	for target, want := range map[string]string{
+		"/rates":                 `{"value":"90.1"}`,
+		"/rates?decimals=number": `{"value":90.1}`,
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		assert.Equal(t, want, strings.TrimSpace(rec.Body.String()), target)
+	}
+}
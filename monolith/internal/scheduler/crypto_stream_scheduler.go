@@ -0,0 +1,116 @@
+package scheduler
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/casualdoto/go-currency-tracker/internal/apiv1"
+	"github.com/casualdoto/go-currency-tracker/internal/currency/binance"
+	"github.com/casualdoto/go-currency-tracker/internal/money"
+	"github.com/casualdoto/go-currency-tracker/internal/stream"
+)
+
+// cryptoQuoter returns the current RUB price of a crypto asset
+type cryptoQuoter interface {
+	GetCurrentCryptoToRubRate(cryptoSymbol string) (*binance.CryptoRate, error)
+}
+
+// CryptoStreamScheduler polls current crypto prices and publishes the ones
+// that changed to the live stream hub
+type CryptoStreamScheduler struct {
+	client   cryptoQuoter
+	hub      *stream.Hub
+	symbols  []string
+	interval time.Duration
+
+	mu        sync.Mutex
+	last      map[string]money.Decimal // last published close per symbol
+	stopChan  chan struct{}
+	isRunning bool
+}
+
+// NewCryptoStreamScheduler creates a scheduler that polls symbols (BTC, ETH, ...)
+// every interval
+func NewCryptoStreamScheduler(hub *stream.Hub, symbols []string, interval time.Duration) *CryptoStreamScheduler {
+	return &CryptoStreamScheduler{
+		client:   binance.NewClient(),
+		hub:      hub,
+		symbols:  symbols,
+		interval: interval,
+		last:     make(map[string]money.Decimal),
+	}
+}
+
+// Start begins polling; the first poll runs immediately
+func (s *CryptoStreamScheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.isRunning {
+		logger.Warn("crypto stream scheduler is already running")
+		return
+	}
+
+	s.isRunning = true
+	s.stopChan = make(chan struct{})
+	logger.Info("crypto stream scheduler started", "symbols", strings.Join(s.symbols, ","), "interval", s.interval.String())
+	go s.run(s.stopChan)
+}
+
+// Stop stops polling
+func (s *CryptoStreamScheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isRunning {
+		logger.Warn("crypto stream scheduler is not running")
+		return
+	}
+
+	close(s.stopChan)
+	s.isRunning = false
+	logger.Info("crypto stream scheduler stopping")
+}
+
+// run is the main loop for the scheduler
+func (s *CryptoStreamScheduler) run(stop <-chan struct{}) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.poll()
+		select {
+		case <-ticker.C:
+		case <-stop:
+			logger.Info("crypto stream scheduler stopped")
+			return
+		}
+	}
+}
+
+// poll fetches every symbol once and publishes changed prices
+func (s *CryptoStreamScheduler) poll() {
+	for _, symbol := range s.symbols {
+		rate, err := s.client.GetCurrentCryptoToRubRate(symbol)
+		if err != nil {
+			logger.Warn("current price fetch failed", "symbol", symbol, "error", err)
+			continue
+		}
+		if last, ok := s.last[symbol]; ok && last.Equal(rate.Close) {
+			continue
+		}
+		s.last[symbol] = rate.Close
+
+		dto := apiv1.CryptoRate{
+			Time:   rate.Timestamp.UTC(),
+			Symbol: symbol,
+			Open:   rate.Open,
+			High:   rate.High,
+			Low:    rate.Low,
+			Close:  rate.Close,
+			Volume: rate.Volume,
+		}
+		if err := s.hub.Publish(stream.TypeCrypto, symbol, dto); err != nil {
+			logger.Warn("stream publish failed", "symbol", symbol, "error", err)
+		}
+	}
+}
@@ -2,14 +2,28 @@
 package scheduler
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"time"
 
+	"github.com/casualdoto/go-currency-tracker/internal/apiv1"
+	"github.com/casualdoto/go-currency-tracker/internal/calendar"
 	currency "github.com/casualdoto/go-currency-tracker/internal/currency/cbr"
+	"github.com/casualdoto/go-currency-tracker/internal/logging"
 	"github.com/casualdoto/go-currency-tracker/internal/storage"
+	"github.com/casualdoto/go-currency-tracker/internal/stream"
 )
 
+var logger = logging.For("scheduler")
+
+// nextDayPollInterval is how often the scheduler looks for the next day's
+// sheet once the CBR may have published it
+const nextDayPollInterval = 30 * time.Minute
+
+// nextDayPollHour is the Moscow hour from which the next business day's
+// sheet is polled for; the CBR publishes it in the afternoon
+const nextDayPollHour = 12
+
 // CurrencyRateScheduler is responsible for scheduling currency rate updates
 type CurrencyRateScheduler struct {
 	db           *storage.PostgresDB
@@ -17,12 +31,17 @@ type CurrencyRateScheduler struct {
 	isRunning    bool
 	dailyJobTime time.Time
 	ticker       *time.Ticker
+	stream       *stream.Hub
+	// storedThrough is the latest effective date stored by the scheduler,
+	// after today once the next day's sheet has been stored
+	storedThrough time.Time
 }
 
 // NewCurrencyRateScheduler creates a new scheduler for currency rate updates
-func NewCurrencyRateScheduler(db *storage.PostgresDB, hourUTC, minuteUTC int) *CurrencyRateScheduler {
-	now := time.Now().UTC()
-	jobTime := time.Date(now.Year(), now.Month(), now.Day(), hourUTC, minuteUTC, 0, 0, time.UTC)
+// that runs daily at hour:minute Moscow time
+func NewCurrencyRateScheduler(db *storage.PostgresDB, hour, minute int) *CurrencyRateScheduler {
+	now := time.Now().In(calendar.Moscow)
+	jobTime := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, calendar.Moscow)
 
 	// If current time has already passed, move to the next day
 	for now.After(jobTime) {
@@ -37,28 +56,33 @@ func NewCurrencyRateScheduler(db *storage.PostgresDB, hourUTC, minuteUTC int) *C
 	}
 }
 
+// SetStream publishes every saved rate to the live stream hub
+func (s *CurrencyRateScheduler) SetStream(hub *stream.Hub) {
+	s.stream = hub
+}
+
 // Start begins the scheduler
 func (s *CurrencyRateScheduler) Start() {
 	if s.isRunning {
-		log.Println("Currency rate scheduler is already running")
+		logger.Warn("currency rate scheduler is already running")
 		return
 	}
 
 	s.isRunning = true
-	log.Printf("Currency rate scheduler started. First update at %s UTC", s.dailyJobTime.Format("2006-01-02 15:04:05"))
+	logger.Info("currency rate scheduler started", "first_run", s.dailyJobTime.Format(time.RFC3339))
 	go s.run()
 }
 
 // Stop stops the scheduler
 func (s *CurrencyRateScheduler) Stop() {
 	if !s.isRunning {
-		log.Println("Currency rate scheduler is not running")
+		logger.Warn("currency rate scheduler is not running")
 		return
 	}
 
 	s.stopChan <- struct{}{}
 	s.isRunning = false
-	log.Println("Currency rate scheduler stopping...")
+	logger.Info("currency rate scheduler stopping")
 }
 
 // run is the main loop for the scheduler
@@ -72,17 +96,24 @@ func (s *CurrencyRateScheduler) run() {
 
 	// delayed start
 	timer := time.NewTimer(delay)
+	poll := time.NewTicker(nextDayPollInterval)
+	defer poll.Stop()
 
-	select {
-	case <-timer.C:
-		log.Println("Executing first scheduled currency rate update")
-		s.executeJob()
-	case <-s.stopChan:
-		if !timer.Stop() {
-			<-timer.C
+first:
+	for {
+		select {
+		case <-timer.C:
+			s.executeJob()
+			break first
+		case now := <-poll.C:
+			s.pollNextDay(now)
+		case <-s.stopChan:
+			if !timer.Stop() {
+				<-timer.C
+			}
+			logger.Info("currency rate scheduler stopped before first run")
+			return
 		}
-		log.Println("Currency rate scheduler stopped before first run")
-		return
 	}
 
 	s.ticker = time.NewTicker(24 * time.Hour)
@@ -90,53 +121,244 @@ func (s *CurrencyRateScheduler) run() {
 	for {
 		select {
 		case <-s.ticker.C:
-			log.Println("Executing daily scheduled currency rate update")
 			s.executeJob()
+		case now := <-poll.C:
+			s.pollNextDay(now)
 		case <-s.stopChan:
 			s.ticker.Stop()
-			log.Println("Currency rate scheduler stopped")
+			logger.Info("currency rate scheduler stopped")
 			return
 		}
 	}
 }
 
-// executeJob calls updateCurrencyRates and logs result
+// executeJob calls updateCurrencyRates and logs result under a request ID
+// of its own, like a served request
 func (s *CurrencyRateScheduler) executeJob() {
+	ctx := logging.WithRequestID(context.Background(), logging.NewRequestID())
+	start := time.Now()
 	if err := s.updateCurrencyRates(); err != nil {
-		log.Printf("Error updating currency rates: %v", err)
+		logger.ErrorContext(ctx, "currency rate update failed", "source", "cbr", "error", err)
+	} else {
+		logger.InfoContext(ctx, "currency rates updated", "source", "cbr", "duration_ms", logging.Millis(time.Since(start)))
+	}
+
+	start = time.Now()
+	if err := s.UpdateMetalPrices(); err != nil {
+		logger.ErrorContext(ctx, "metal price update failed", "source", "cbr_metals", "error", err)
 	} else {
-		log.Printf("Currency rates updated successfully at %s UTC", time.Now().UTC().Format("2006-01-02 15:04:05"))
+		logger.InfoContext(ctx, "metal prices updated", "source", "cbr_metals", "duration_ms", logging.Millis(time.Since(start)))
+	}
+
+	start = time.Now()
+	if err := s.UpdateIndicatorRates(); err != nil {
+		logger.ErrorContext(ctx, "indicator rate update failed", "source", "cbr_indicators", "error", err)
+	} else {
+		logger.InfoContext(ctx, "indicator rates updated", "source", "cbr_indicators", "duration_ms", logging.Millis(time.Since(start)))
 	}
 }
 
-// updateCurrencyRates fetches the latest currency rates and stores them in the database
+// pollNextDay stores the next day's sheet once the CBR has published it.
+// Polls stop for the day after it has been stored
+func (s *CurrencyRateScheduler) pollNextDay(now time.Time) {
+	if !nextDayDue(now, s.storedThrough) {
+		return
+	}
+	ctx := logging.WithRequestID(context.Background(), logging.NewRequestID())
+	if err := s.updateNextDayRates(); err != nil {
+		logger.ErrorContext(ctx, "next-day currency rate update failed", "source", "cbr", "error", err)
+	}
+}
+
+// nextDayDue reports whether the next day's sheet should be polled for at
+// now, given the latest effective date already stored. The CBR publishes on
+// business days only; Friday's sheet covers the weekend
+func nextDayDue(now, storedThrough time.Time) bool {
+	today := calendar.Day(now)
+	if storedThrough.After(today) || !calendar.IsBusinessDay(today) {
+		return false
+	}
+	return now.In(calendar.Moscow).Hour() >= nextDayPollHour
+}
+
+// updateCurrencyRates stores the rates in effect today and, when the CBR has
+// already published them, the next day's rates under their effective date
 func (s *CurrencyRateScheduler) updateCurrencyRates() error {
-	rates, err := currency.GetCBRRates()
+	latest, err := currency.GetLatestCBRRates()
+	if err != nil {
+		return fmt.Errorf("failed to get CBR rates: %w", err)
+	}
+
+	today := calendar.Today()
+	current := latest
+	if latest.EffectiveOn().After(today) {
+		current, err = currency.GetCBRRatesByDate(today.Format(time.DateOnly))
+		if err != nil {
+			return fmt.Errorf("failed to get CBR rates for today: %w", err)
+		}
+	}
+	if err := s.saveSheet(current, today); err != nil {
+		return err
+	}
+	if current != latest {
+		return s.saveNextDaySheet(latest)
+	}
+	return nil
+}
+
+// updateNextDayRates stores the latest sheet if it takes effect after today
+func (s *CurrencyRateScheduler) updateNextDayRates() error {
+	latest, err := currency.GetLatestCBRRates()
 	if err != nil {
 		return fmt.Errorf("failed to get CBR rates: %w", err)
 	}
+	if !latest.EffectiveOn().After(calendar.Today()) {
+		return nil
+	}
+	return s.saveNextDaySheet(latest)
+}
+
+// saveNextDaySheet stores a sheet published ahead of its effective date
+func (s *CurrencyRateScheduler) saveNextDaySheet(rates *currency.DailyRates) error {
+	effective := rates.EffectiveOn()
+	if err := s.saveSheet(rates, effective); err != nil {
+		return err
+	}
+	logger.Info("next-day currency rates stored", "source", "cbr",
+		"effective_on", effective.Format(time.DateOnly), "published_at", rates.PublishedAt().Format(time.RFC3339))
+	return nil
+}
 
+// saveSheet stores the rates of a sheet under date and publishes them
+func (s *CurrencyRateScheduler) saveSheet(rates *currency.DailyRates, date time.Time) error {
 	var dbRates []storage.CurrencyRate
-	currentDate := time.Now().Truncate(24 * time.Hour)
+	carriedFrom := rates.CarriedFrom(date)
+	publishedAt := rates.PublishedAt()
 
 	for code, valute := range rates.Valute {
 		dbRates = append(dbRates, storage.CurrencyRate{
-			Date:         currentDate,
+			Date:         date,
 			CurrencyCode: code,
 			CurrencyName: valute.Name,
 			Nominal:      valute.Nominal,
 			Value:        valute.Value,
 			Previous:     valute.Previous,
+			UnitValue:    valute.PerUnit(),
+			CarriedFrom:  carriedFrom,
+			PublishedAt:  &publishedAt,
+			Source:       rates.SourceURL,
+			FetchedAt:    rates.FetchedAt,
 		})
 	}
 
 	if err := s.db.SaveCurrencyRates(dbRates); err != nil {
 		return fmt.Errorf("failed to save currency rates to database: %w", err)
 	}
+	if date.After(s.storedThrough) {
+		s.storedThrough = date
+	}
 
+	s.publishRates(dbRates)
 	return nil
 }
 
+// publishRates sends saved rates to the stream hub, if one is set
+func (s *CurrencyRateScheduler) publishRates(rates []storage.CurrencyRate) {
+	if s.stream == nil {
+		return
+	}
+	for _, r := range rates {
+		dto := apiv1.CurrencyRate{
+			Date:      r.Date.Format("2006-01-02"),
+			Code:      r.CurrencyCode,
+			Name:      r.CurrencyName,
+			Nominal:   r.Nominal,
+			Value:     r.Value,
+			Previous:  r.Previous,
+			UnitValue: r.PerUnit(),
+		}
+		if r.CarriedFrom != nil {
+			dto.CarriedFrom = r.CarriedFrom.Format("2006-01-02")
+		}
+		if err := s.stream.Publish(stream.TypeCBR, dto.Code, dto); err != nil {
+			logger.Warn("stream publish failed", "currency", dto.Code, "error", err)
+		}
+	}
+}
+
+// UpdateMetalPrices stores the CBR precious metals prices of the last
+// MetalsLookbackDays days, so that prices missed on a failed run still
+// arrive; prices stored before are replaced
+func (s *CurrencyRateScheduler) UpdateMetalPrices() error {
+	today := calendar.Today()
+	prices, err := currency.GetMetalPrices(today.AddDate(0, 0, 1-currency.MetalsLookbackDays), today)
+	if err != nil {
+		return fmt.Errorf("failed to get metal prices: %w", err)
+	}
+	if len(prices) == 0 {
+		return nil
+	}
+	if err := s.db.SaveMetalPrices(metalPriceRows(prices)); err != nil {
+		return fmt.Errorf("failed to save metal prices to database: %w", err)
+	}
+	return nil
+}
+
+// metalPriceRows converts fetched metal prices into database rows
+func metalPriceRows(prices []currency.MetalPrice) []storage.MetalPrice {
+	rows := make([]storage.MetalPrice, 0, len(prices))
+	for _, p := range prices {
+		rows = append(rows, storage.MetalPrice{
+			Date:      p.Date,
+			Metal:     p.Metal,
+			Name:      p.Name,
+			Buy:       p.Buy,
+			Sell:      p.Sell,
+			Source:    p.SourceURL,
+			FetchedAt: p.FetchedAt,
+		})
+	}
+	return rows
+}
+
+// UpdateIndicatorRates stores the CBR key rate and RUONIA of the last
+// IndicatorsLookbackDays days; storage keeps a row only where a value
+// changes, so the repeats collapse into the row already stored
+func (s *CurrencyRateScheduler) UpdateIndicatorRates() error {
+	today := calendar.Today()
+	var rates []currency.IndicatorRate
+	for _, code := range []string{currency.IndicatorKeyRate, currency.IndicatorRUONIA} {
+		got, err := currency.GetIndicatorRates(code, today.AddDate(0, 0, 1-currency.IndicatorsLookbackDays), today)
+		if err != nil {
+			return fmt.Errorf("failed to get %s rates: %w", code, err)
+		}
+		rates = append(rates, got...)
+	}
+	if len(rates) == 0 {
+		return nil
+	}
+	if err := s.db.SaveIndicatorRates(indicatorRateRows(rates)); err != nil {
+		return fmt.Errorf("failed to save indicator rates to database: %w", err)
+	}
+	return nil
+}
+
+// indicatorRateRows converts fetched indicator values into database rows
+func indicatorRateRows(rates []currency.IndicatorRate) []storage.IndicatorRate {
+	rows := make([]storage.IndicatorRate, 0, len(rates))
+	for _, r := range rates {
+		rows = append(rows, storage.IndicatorRate{
+			Indicator:     r.Indicator,
+			EffectiveDate: r.Date,
+			Name:          r.Name,
+			Value:         r.Value,
+			Source:        r.SourceURL,
+			FetchedAt:     r.FetchedAt,
+		})
+	}
+	return rows
+}
+
 // RunImmediately executes the currency rate update job immediately
 func (s *CurrencyRateScheduler) RunImmediately() error {
 	return s.updateCurrencyRates()
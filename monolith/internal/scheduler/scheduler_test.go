@@ -5,6 +5,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/casualdoto/go-currency-tracker/internal/calendar"
+	"github.com/casualdoto/go-currency-tracker/internal/currency/binance"
+	currency "github.com/casualdoto/go-currency-tracker/internal/currency/cbr"
+	"github.com/casualdoto/go-currency-tracker/internal/money"
+	"github.com/casualdoto/go-currency-tracker/internal/storage"
+	"github.com/casualdoto/go-currency-tracker/internal/stream"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -40,8 +46,8 @@ func newTestScheduler(db DatabaseInterface, hour, minute int) *CurrencyRateSched
 	}
 
 	// Set time calculation like in original constructor
-	now := time.Now().UTC()
-	jobTime := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, time.UTC)
+	now := time.Now().In(calendar.Moscow)
+	jobTime := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, calendar.Moscow)
 	for now.After(jobTime) {
 		jobTime = jobTime.Add(24 * time.Hour)
 	}
@@ -133,6 +139,15 @@ func TestNewCurrencyRateScheduler(t *testing.T) {
 		assert.Equal(t, 23, scheduler.dailyJobTime.Hour())
 		assert.Equal(t, 59, scheduler.dailyJobTime.Minute())
 	})
+
+	t.Run("test job time is Moscow wall time", func(t *testing.T) {
+		scheduler := NewCurrencyRateScheduler(nil, 2, 59)
+		jobTime := scheduler.dailyJobTime.In(calendar.Moscow)
+		assert.Equal(t, 2, jobTime.Hour())
+		assert.Equal(t, 59, jobTime.Minute())
+		assert.True(t, scheduler.dailyJobTime.After(time.Now()))
+		assert.True(t, time.Until(scheduler.dailyJobTime) <= 24*time.Hour)
+	})
 }
 
 func TestCurrencyRateScheduler_StartStop(t *testing.T) {
@@ -265,7 +280,7 @@ func TestCurrencyRateScheduler_TimeCalculation(t *testing.T) {
 	mockDB := &MockDatabase{}
 
 	t.Run("test daily job time calculation", func(t *testing.T) {
-		now := time.Now().UTC()
+		now := time.Now().In(calendar.Moscow)
 
 		// Test for future time today
 		futureHour := (now.Hour() + 1) % 24
@@ -290,8 +305,8 @@ func TestCurrencyRateScheduler_TimeCalculation(t *testing.T) {
 	t.Run("test timezone handling", func(t *testing.T) {
 		scheduler := newTestScheduler(mockDB, 23, 59)
 
-		// Should be in UTC
-		assert.Equal(t, time.UTC, scheduler.dailyJobTime.Location())
+		// Should be in Moscow time
+		assert.Equal(t, calendar.Moscow, scheduler.dailyJobTime.Location())
 	})
 }
 
@@ -387,6 +402,32 @@ func TestCurrencyRateScheduler_DataProcessing(t *testing.T) {
 	})
 }
 
+func TestNextDayDue(t *testing.T) {
+	// Tue 2024-01-16 and Sat 2024-01-20, Moscow wall time
+	tuesday := func(hour int) time.Time { return time.Date(2024, 1, 16, hour, 0, 0, 0, calendar.Moscow) }
+	today := calendar.Day(tuesday(12))
+	tomorrow := today.AddDate(0, 0, 1)
+
+	tests := []struct {
+		name          string
+		now           time.Time
+		storedThrough time.Time
+		want          bool
+	}{
+		{"before publication", tuesday(11), today, false},
+		{"after publication", tuesday(13), today, true},
+		{"nothing stored yet", tuesday(13), time.Time{}, true},
+		{"next day already stored", tuesday(16), tomorrow, false},
+		{"weekend", time.Date(2024, 1, 20, 13, 0, 0, 0, calendar.Moscow), today, false},
+		{"afternoon in UTC is evening in Moscow", time.Date(2024, 1, 16, 10, 0, 0, 0, time.UTC), today, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, nextDayDue(tc.now, tc.storedThrough))
+		})
+	}
+}
+
 // Benchmark tests
 func BenchmarkNewCurrencyRateScheduler(b *testing.B) {
 	mockDB := &MockDatabase{}
@@ -432,3 +473,85 @@ func TestSchedulerHelpers(t *testing.T) {
 		assert.Equal(t, 1440.0, minutes)
 	})
 }
+
+// TestCurrencyRateScheduler_publishRates checks the CBR events sent to the stream
+func TestCurrencyRateScheduler_publishRates(t *testing.T) {
+	hub := stream.NewHub(8)
+	s := newTestScheduler(&MockDatabase{}, 23, 59)
+	rates := []storage.CurrencyRate{{
+		Date: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), CurrencyCode: "USD", CurrencyName: "US Dollar",
+		Nominal: 1, Value: money.MustParse("90"), Previous: money.MustParse("89"),
+	}}
+
+	s.publishRates(rates) // no hub set: nothing to do
+	s.SetStream(hub)
+	s.publishRates(rates)
+
+	events, sub := hub.Subscribe(stream.Filter{}, 0, true)
+	sub.Close()
+	assert.Len(t, events, 1)
+	assert.Equal(t, stream.TypeCBR, events[0].Type)
+	assert.JSONEq(t, `{"date":"2024-01-15","code":"USD","name":"US Dollar","nominal":1,"value":"90","previous":"89","unit_value":"90"}`, string(events[0].Data))
+}
+
+// TestMetalPriceRows checks fetched metal prices map onto database rows
+func TestMetalPriceRows(t *testing.T) {
+	day := time.Date(2026, 4, 14, 0, 0, 0, 0, time.UTC)
+	fetched := time.Date(2026, 4, 14, 9, 0, 0, 0, time.UTC)
+	rows := metalPriceRows([]currency.MetalPrice{{
+		Date: day, Metal: "XAU", Name: "Gold", Buy: money.MustParse("8512.37"), Sell: money.MustParse("8512.37"),
+		SourceURL: "https://www.cbr.ru/scripts/xml_metall.asp", FetchedAt: fetched,
+	}})
+
+	assert.Equal(t, []storage.MetalPrice{{
+		Date: day, Metal: "XAU", Name: "Gold", Buy: money.MustParse("8512.37"), Sell: money.MustParse("8512.37"),
+		Source: "https://www.cbr.ru/scripts/xml_metall.asp", FetchedAt: fetched,
+	}}, rows)
+	assert.Empty(t, metalPriceRows(nil))
+}
+
+type fakeQuoter map[string]int64
+
+func (f fakeQuoter) GetCurrentCryptoToRubRate(symbol string) (*binance.CryptoRate, error) {
+	price, ok := f[symbol]
+	if !ok {
+		return nil, fmt.Errorf("unknown symbol %s", symbol)
+	}
+	return &binance.CryptoRate{Symbol: symbol + "/RUB", Timestamp: time.Now(), Close: money.NewFromInt(price)}, nil
+}
+
+// TestCryptoStreamScheduler_poll checks that only changed prices are published
+func TestCryptoStreamScheduler_poll(t *testing.T) {
+	hub := stream.NewHub(8)
+	quotes := fakeQuoter{"BTC": 5600000, "ETH": 300000}
+	s := NewCryptoStreamScheduler(hub, []string{"BTC", "ETH", "DOGE"}, time.Minute)
+	s.client = quotes
+
+	s.poll()
+	quotes["BTC"] = 5700000
+	s.poll()
+
+	events, sub := hub.Subscribe(stream.Filter{}, 0, true)
+	sub.Close()
+	var got []string
+	for _, e := range events {
+		got = append(got, e.Symbol)
+	}
+	assert.Equal(t, []string{"BTC", "ETH", "BTC"}, got)
+	assert.Contains(t, string(events[2].Data), `"close":"5700000"`)
+}
+
+func TestIndicatorRateRows(t *testing.T) {
+	day := time.Date(2026, 4, 14, 0, 0, 0, 0, time.UTC)
+	fetched := time.Date(2026, 4, 14, 9, 0, 0, 0, time.UTC)
+	rows := indicatorRateRows([]currency.IndicatorRate{{
+		Date: day, Indicator: currency.IndicatorKeyRate, Name: "Key rate", Value: money.MustParse("15.5"),
+		SourceURL: "https://www.cbr.ru/DailyInfoWebServ/DailyInfo.asmx/KeyRateXML", FetchedAt: fetched,
+	}})
+
+	assert.Equal(t, []storage.IndicatorRate{{
+		Indicator: "KEY_RATE", EffectiveDate: day, Name: "Key rate", Value: money.MustParse("15.5"),
+		Source: "https://www.cbr.ru/DailyInfoWebServ/DailyInfo.asmx/KeyRateXML", FetchedAt: fetched,
+	}}, rows)
+	assert.Empty(t, indicatorRateRows(nil))
+}
@@ -1,7 +1,6 @@
 package scheduler
 
 import (
-	"log"
 	"time"
 
 	"github.com/casualdoto/go-currency-tracker/internal/alert"
@@ -9,40 +8,47 @@ import (
 
 // TelegramScheduler schedules Telegram bot tasks
 type TelegramScheduler struct {
-	bot             *alert.TelegramBot
-	dailyTicker     *time.Ticker
-	cryptoTicker    *time.Ticker
-	dailyDone       chan bool
-	cryptoDone      chan bool
-	isDailyRunning  bool
-	isCryptoRunning bool
+	bot              *alert.TelegramBot
+	dailyTicker      *time.Ticker
+	cryptoTicker     *time.Ticker
+	nextDayTicker    *time.Ticker
+	dailyDone        chan bool
+	cryptoDone       chan bool
+	nextDayDone      chan bool
+	isDailyRunning   bool
+	isCryptoRunning  bool
+	isNextDayRunning bool
+	// announcedThrough is the effective date of the latest CBR sheet the
+	// bot has seen
+	announcedThrough time.Time
 }
 
 // NewTelegramScheduler creates a new TelegramScheduler
 func NewTelegramScheduler(bot *alert.TelegramBot) *TelegramScheduler {
 	return &TelegramScheduler{
-		bot:        bot,
-		dailyDone:  make(chan bool),
-		cryptoDone: make(chan bool),
+		bot:         bot,
+		dailyDone:   make(chan bool),
+		cryptoDone:  make(chan bool),
+		nextDayDone: make(chan bool),
 	}
 }
 
 // RunNow sends daily update immediately (for testing)
 func (s *TelegramScheduler) RunNow() {
-	log.Println("Running Telegram update immediately for testing")
+	logger.Info("sending daily Telegram update now")
 	s.bot.SendDailyUpdates()
 }
 
 // RunCryptoNow sends crypto update immediately (for testing)
 func (s *TelegramScheduler) RunCryptoNow() {
-	log.Println("Running crypto Telegram update immediately for testing")
+	logger.Info("sending crypto Telegram update now")
 	s.bot.SendCryptoUpdates()
 }
 
 // StartDailyUpdates starts sending daily updates at the specified hour
 func (s *TelegramScheduler) StartDailyUpdates(hour int) {
 	if s.isDailyRunning {
-		log.Println("Daily Telegram scheduler is already running")
+		logger.Warn("daily Telegram scheduler is already running")
 		return
 	}
 
@@ -53,11 +59,15 @@ func (s *TelegramScheduler) StartDailyUpdates(hour int) {
 	}
 
 	initialDelay := nextRun.Sub(now)
-	log.Printf("Scheduling first daily Telegram update in %v", initialDelay)
+	logger.Info("daily Telegram updates scheduled", "first_run", nextRun.Format(time.RFC3339))
+
+	// Record the current key rate without announcing it
+	s.bot.AnnounceKeyRateChange()
 
 	time.AfterFunc(initialDelay, func() {
-		log.Println("Sending initial daily Telegram update")
+		logger.Info("sending daily Telegram update")
 		s.bot.SendDailyUpdates()
+		s.bot.AnnounceKeyRateChange()
 
 		s.dailyTicker = time.NewTicker(24 * time.Hour)
 		s.isDailyRunning = true
@@ -66,13 +76,14 @@ func (s *TelegramScheduler) StartDailyUpdates(hour int) {
 			for {
 				select {
 				case <-s.dailyTicker.C:
-					log.Println("Sending scheduled daily Telegram update")
+					logger.Info("sending daily Telegram update")
 					s.bot.SendDailyUpdates()
+					s.bot.AnnounceKeyRateChange()
 				case <-s.dailyDone:
 					s.dailyTicker.Stop()
 					s.dailyTicker = nil
 					s.isDailyRunning = false
-					log.Println("Daily Telegram scheduler stopped")
+					logger.Info("daily Telegram scheduler stopped")
 					return
 				}
 			}
@@ -80,17 +91,19 @@ func (s *TelegramScheduler) StartDailyUpdates(hour int) {
 	})
 }
 
-// StartCryptoUpdates starts sending crypto updates every 15 minutes
+// StartCryptoUpdates starts sending crypto updates and checking indicator
+// alerts every 15 minutes
 func (s *TelegramScheduler) StartCryptoUpdates() {
 	if s.isCryptoRunning {
-		log.Println("Crypto Telegram scheduler is already running")
+		logger.Warn("crypto Telegram scheduler is already running")
 		return
 	}
 
-	log.Println("Starting crypto updates every 15 minutes")
+	logger.Info("crypto Telegram updates started", "interval", (15 * time.Minute).String())
 
 	// Send initial update
 	s.bot.SendCryptoUpdates()
+	s.bot.CheckIndicatorAlerts()
 
 	s.cryptoTicker = time.NewTicker(15 * time.Minute)
 	s.isCryptoRunning = true
@@ -99,13 +112,48 @@ func (s *TelegramScheduler) StartCryptoUpdates() {
 		for {
 			select {
 			case <-s.cryptoTicker.C:
-				log.Println("Sending scheduled crypto Telegram update")
+				logger.Debug("sending crypto Telegram update")
 				s.bot.SendCryptoUpdates()
+				s.bot.CheckIndicatorAlerts()
 			case <-s.cryptoDone:
 				s.cryptoTicker.Stop()
 				s.cryptoTicker = nil
 				s.isCryptoRunning = false
-				log.Println("Crypto Telegram scheduler stopped")
+				logger.Info("crypto Telegram scheduler stopped")
+				return
+			}
+		}
+	}()
+}
+
+// StartNextDayAnnouncements starts announcing the next day's CBR rates as
+// soon as they are published, polling while nextDayDue
+func (s *TelegramScheduler) StartNextDayAnnouncements() {
+	if s.isNextDayRunning {
+		logger.Warn("next-day Telegram scheduler is already running")
+		return
+	}
+
+	logger.Info("next-day Telegram announcements started", "interval", nextDayPollInterval.String())
+
+	// Record the latest sheet without announcing it
+	s.announcedThrough = s.bot.AnnounceNextDayRates()
+
+	s.nextDayTicker = time.NewTicker(nextDayPollInterval)
+	s.isNextDayRunning = true
+
+	go func() {
+		for {
+			select {
+			case now := <-s.nextDayTicker.C:
+				if nextDayDue(now, s.announcedThrough) {
+					s.announcedThrough = s.bot.AnnounceNextDayRates()
+				}
+			case <-s.nextDayDone:
+				s.nextDayTicker.Stop()
+				s.nextDayTicker = nil
+				s.isNextDayRunning = false
+				logger.Info("next-day Telegram scheduler stopped")
 				return
 			}
 		}
@@ -115,7 +163,7 @@ func (s *TelegramScheduler) StartCryptoUpdates() {
 // StopDaily stops the daily Telegram scheduler
 func (s *TelegramScheduler) StopDaily() {
 	if !s.isDailyRunning {
-		log.Println("Daily Telegram scheduler is not running")
+		logger.Warn("daily Telegram scheduler is not running")
 		return
 	}
 	s.dailyDone <- true
@@ -124,14 +172,24 @@ func (s *TelegramScheduler) StopDaily() {
 // StopCrypto stops the crypto Telegram scheduler
 func (s *TelegramScheduler) StopCrypto() {
 	if !s.isCryptoRunning {
-		log.Println("Crypto Telegram scheduler is not running")
+		logger.Warn("crypto Telegram scheduler is not running")
 		return
 	}
 	s.cryptoDone <- true
 }
 
-// Stop stops both schedulers
+// StopNextDay stops the next-day Telegram scheduler
+func (s *TelegramScheduler) StopNextDay() {
+	if !s.isNextDayRunning {
+		logger.Warn("next-day Telegram scheduler is not running")
+		return
+	}
+	s.nextDayDone <- true
+}
+
+// Stop stops all schedulers
 func (s *TelegramScheduler) Stop() {
 	s.StopDaily()
 	s.StopCrypto()
+	s.StopNextDay()
 }
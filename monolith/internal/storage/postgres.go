@@ -8,8 +8,14 @@ import (
 	"time"
 
 	_ "github.com/lib/pq"
+
+	"github.com/casualdoto/go-currency-tracker/internal/logging"
+	"github.com/casualdoto/go-currency-tracker/internal/metrics"
+	"github.com/casualdoto/go-currency-tracker/internal/money"
 )
 
+var logger = logging.For("storage")
+
 // PostgresConfig contains database connection configuration
 type PostgresConfig struct {
 	Host     string
@@ -53,6 +59,11 @@ func (p *PostgresDB) Close() error {
 	return p.db.Close()
 }
 
+// Ping checks the database connection for /readyz
+func (p *PostgresDB) Ping(ctx context.Context) error {
+	return p.db.PingContext(ctx)
+}
+
 // InitSchema initializes the database schema
 func (p *PostgresDB) InitSchema() error {
 	query := `
@@ -70,6 +81,40 @@ func (p *PostgresDB) InitSchema() error {
 	
 	CREATE INDEX IF NOT EXISTS idx_currency_rates_date ON currency_rates(date);
 	CREATE INDEX IF NOT EXISTS idx_currency_rates_code ON currency_rates(currency_code);
+	ALTER TABLE currency_rates ADD COLUMN IF NOT EXISTS carried_from DATE;
+	ALTER TABLE currency_rates ADD COLUMN IF NOT EXISTS published_at TIMESTAMP WITH TIME ZONE;
+
+	CREATE TABLE IF NOT EXISTS currency_rate_revisions (
+		id BIGSERIAL PRIMARY KEY,
+		date DATE NOT NULL,
+		currency_code VARCHAR(3) NOT NULL,
+		currency_name VARCHAR(100) NOT NULL,
+		nominal INTEGER NOT NULL,
+		value DECIMAL(12, 4) NOT NULL,
+		previous DECIMAL(12, 4),
+		source TEXT NOT NULL DEFAULT '',
+		carried_from DATE,
+		fetched_at TIMESTAMP WITH TIME ZONE,
+		recorded_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_currency_rate_revisions_key ON currency_rate_revisions(date, currency_code, recorded_at);
+
+	-- Rates stored before revisions were kept get one revision each, as of
+	-- their last save
+	INSERT INTO currency_rate_revisions (date, currency_code, currency_name, nominal, value, previous, carried_from, recorded_at)
+	SELECT r.date, r.currency_code, r.currency_name, r.nominal, r.value, r.previous, r.carried_from, COALESCE(r.created_at, NOW())
+	FROM currency_rates r
+	WHERE NOT EXISTS (
+		SELECT 1 FROM currency_rate_revisions v WHERE v.date = r.date AND v.currency_code = r.currency_code
+	);
+
+	-- Per-unit values; rows stored before them get value / nominal
+	ALTER TABLE currency_rates ADD COLUMN IF NOT EXISTS unit_value DECIMAL(28, 16);
+	ALTER TABLE currency_rate_revisions ADD COLUMN IF NOT EXISTS unit_value DECIMAL(28, 16);
+	UPDATE currency_rates SET unit_value = ROUND(value / GREATEST(nominal, 1), 16) WHERE unit_value IS NULL;
+	UPDATE currency_rate_revisions SET unit_value = ROUND(value / GREATEST(nominal, 1), 16) WHERE unit_value IS NULL;
+	CREATE INDEX IF NOT EXISTS idx_currency_rates_code_date ON currency_rates(currency_code, date);
 
 	CREATE TABLE IF NOT EXISTS crypto_rates (
 		id SERIAL PRIMARY KEY,
@@ -86,6 +131,33 @@ func (p *PostgresDB) InitSchema() error {
 	
 	CREATE INDEX IF NOT EXISTS idx_crypto_rates_timestamp ON crypto_rates(timestamp);
 	CREATE INDEX IF NOT EXISTS idx_crypto_rates_symbol ON crypto_rates(symbol);
+
+	CREATE TABLE IF NOT EXISTS metal_prices (
+		date DATE NOT NULL,
+		metal VARCHAR(3) NOT NULL,
+		name VARCHAR(20) NOT NULL,
+		buy DECIMAL(14, 4) NOT NULL,
+		sell DECIMAL(14, 4) NOT NULL,
+		source TEXT NOT NULL DEFAULT '',
+		fetched_at TIMESTAMP WITH TIME ZONE,
+		created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+		PRIMARY KEY (date, metal)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_metal_prices_metal_date ON metal_prices(metal, date);
+
+	-- A row is the value an indicator took on effective_date; it stays in
+	-- effect until the next row of the indicator
+	CREATE TABLE IF NOT EXISTS indicator_rates (
+		indicator VARCHAR(16) NOT NULL,
+		effective_date DATE NOT NULL,
+		name VARCHAR(40) NOT NULL,
+		value DECIMAL(8, 4) NOT NULL,
+		source TEXT NOT NULL DEFAULT '',
+		fetched_at TIMESTAMP WITH TIME ZONE,
+		created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+		PRIMARY KEY (indicator, effective_date)
+	);
 	`
 
 	_, err := p.db.Exec(query)
@@ -103,13 +175,94 @@ type CurrencyRate struct {
 	CurrencyCode string
 	CurrencyName string
 	Nominal      int
-	Value        float64
-	Previous     float64
-	CreatedAt    time.Time
+	Value        money.Decimal
+	Previous     money.Decimal
+	// UnitValue is the price of one unit: the CBR's VunitRate, or Value /
+	// Nominal. Unlike Value it stays comparable when the nominal changes
+	UnitValue money.Decimal
+	// PreviousNominal is set on the first date stored with a new nominal: the
+	// nominal of the currency on the date before
+	PreviousNominal int `json:",omitempty"`
+	CreatedAt       time.Time
+	// CarriedFrom is the publication date of the earlier sheet the rate was
+	// copied from, when the CBR published nothing for Date
+	CarriedFrom *time.Time `json:",omitempty"`
+	// PublishedAt is the time the CBR published the sheet, the afternoon
+	// before Date for rates stored as soon as they appear
+	PublishedAt *time.Time `json:",omitempty"`
+	// Source is the URL the rate was read from and FetchedAt the time it was
+	// read; they are kept with the revisions only
+	Source    string    `json:"-"`
+	FetchedAt time.Time `json:"-"`
+}
+
+// CurrencyRateRevision is one stored value of a currency rate. A revision is
+// recorded whenever a save changes the nominal, value, previous value or
+// carry-over of a rate; saving the same value again records nothing
+type CurrencyRateRevision struct {
+	ID           int64          `json:"id"`
+	Date         time.Time      `json:"date"`
+	CurrencyCode string         `json:"currency_code"`
+	CurrencyName string         `json:"currency_name"`
+	Nominal      int            `json:"nominal"`
+	Value        money.Decimal  `json:"value"`
+	Previous     *money.Decimal `json:"previous"`
+	UnitValue    money.Decimal  `json:"unit_value"`
+	Source       string         `json:"source,omitempty"`
+	CarriedFrom  *time.Time     `json:"carried_from,omitempty"`
+	FetchedAt    *time.Time     `json:"fetched_at,omitempty"`
+	RecordedAt   time.Time      `json:"recorded_at"`
+}
+
+// PerUnit returns UnitValue, or Value / Nominal for a rate built without it
+func (r CurrencyRate) PerUnit() money.Decimal {
+	if r.UnitValue.IsPositive() {
+		return r.UnitValue
+	}
+	return money.PerUnit(r.Value, r.Nominal)
+}
+
+// NominalChange is a date on which the CBR started quoting a currency for a
+// different number of units, e.g. KZT moving from 100 to 1000. Value jumps
+// on such a date; UnitValue does not
+type NominalChange struct {
+	Date            time.Time `json:"date"`
+	CurrencyCode    string    `json:"currency_code"`
+	PreviousNominal int       `json:"previous_nominal"`
+	Nominal         int       `json:"nominal"`
 }
 
-// SaveCurrencyRates saves multiple currency rates to the database
+// insertCurrencyRateRevision appends a revision unless the latest one of the
+// rate already has the same nominal, value, previous value and carry-over
+const insertCurrencyRateRevision = `
+	INSERT INTO currency_rate_revisions (date, currency_code, currency_name, nominal, value, previous, source, carried_from, fetched_at, unit_value)
+	SELECT $1::date, $2::varchar, $3::varchar, $4::integer, $5::decimal(12, 4), $6::decimal(12, 4), $7::text, $8::date, $9::timestamptz, $10::decimal(28, 16)
+	WHERE NOT EXISTS (
+		SELECT 1 FROM (
+			SELECT nominal, value, previous, carried_from FROM currency_rate_revisions
+			WHERE date = $1::date AND currency_code = $2::varchar
+			ORDER BY recorded_at DESC, id DESC LIMIT 1
+		) last
+		WHERE last.nominal = $4::integer AND last.value = $5::decimal(12, 4)
+			AND last.previous IS NOT DISTINCT FROM $6::decimal(12, 4)
+			AND last.carried_from IS NOT DISTINCT FROM $8::date
+	)
+`
+
+// previousNominal selects the nominal of the currency of row r (of
+// currency_rates or currency_rate_revisions) on the latest stored date
+// before r's, or 0 when it is the same or there is none
+const previousNominal = `COALESCE(NULLIF((
+	SELECT p.nominal FROM currency_rates p
+	WHERE p.currency_code = r.currency_code AND p.date < r.date
+	ORDER BY p.date DESC LIMIT 1
+), r.nominal), 0) AS previous_nominal`
+
+// SaveCurrencyRates saves multiple currency rates to the database and
+// records a revision of every rate whose value changed, so that overwritten
+// values stay available to as-of reads
 func (p *PostgresDB) SaveCurrencyRates(rates []CurrencyRate) error {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "save_currency_rates", time.Now())
 	tx, err := p.db.Begin()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
@@ -117,14 +270,17 @@ func (p *PostgresDB) SaveCurrencyRates(rates []CurrencyRate) error {
 	defer tx.Rollback()
 
 	stmt, err := tx.Prepare(`
-		INSERT INTO currency_rates (date, currency_code, currency_name, nominal, value, previous)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO currency_rates (date, currency_code, currency_name, nominal, value, previous, carried_from, published_at, unit_value)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		ON CONFLICT (date, currency_code) 
 		DO UPDATE SET 
 			currency_name = EXCLUDED.currency_name,
 			nominal = EXCLUDED.nominal,
 			value = EXCLUDED.value,
 			previous = EXCLUDED.previous,
+			unit_value = EXCLUDED.unit_value,
+			carried_from = EXCLUDED.carried_from,
+			published_at = COALESCE(EXCLUDED.published_at, currency_rates.published_at),
 			created_at = NOW()
 	`)
 	if err != nil {
@@ -132,7 +288,14 @@ func (p *PostgresDB) SaveCurrencyRates(rates []CurrencyRate) error {
 	}
 	defer stmt.Close()
 
+	revise, err := tx.Prepare(insertCurrencyRateRevision)
+	if err != nil {
+		return fmt.Errorf("failed to prepare revision statement: %w", err)
+	}
+	defer revise.Close()
+
 	for _, rate := range rates {
+		unit := rate.PerUnit()
 		_, err := stmt.Exec(
 			rate.Date,
 			rate.CurrencyCode,
@@ -140,24 +303,63 @@ func (p *PostgresDB) SaveCurrencyRates(rates []CurrencyRate) error {
 			rate.Nominal,
 			rate.Value,
 			rate.Previous,
+			rate.CarriedFrom,
+			rate.PublishedAt,
+			unit,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to insert currency rate: %w", err)
 		}
+
+		var fetchedAt *time.Time
+		if !rate.FetchedAt.IsZero() {
+			fetchedAt = &rate.FetchedAt
+		}
+		_, err = revise.Exec(
+			rate.Date,
+			rate.CurrencyCode,
+			rate.CurrencyName,
+			rate.Nominal,
+			rate.Value,
+			rate.Previous,
+			rate.Source,
+			rate.CarriedFrom,
+			fetchedAt,
+			unit,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert currency rate revision: %w", err)
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
+	for _, rate := range rates {
+		metrics.RateStored(metrics.SourceCBR, rate.Date)
+	}
 
 	return nil
 }
 
+// LatestCurrencyRateDate returns the newest stored rate date, or the zero time
+// when there are no rates
+func (p *PostgresDB) LatestCurrencyRateDate() (time.Time, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "latest_currency_rate_date", time.Now())
+	var date sql.NullTime
+	if err := p.db.QueryRow(`SELECT MAX(date) FROM currency_rates`).Scan(&date); err != nil {
+		return time.Time{}, fmt.Errorf("failed to query latest currency rate date: %w", err)
+	}
+	return date.Time, nil
+}
+
 // GetCurrencyRatesByDate retrieves currency rates for a specific date
 func (p *PostgresDB) GetCurrencyRatesByDate(date time.Time) ([]CurrencyRate, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "get_currency_rates_by_date", time.Now())
 	rows, err := p.db.Query(`
-		SELECT id, date, currency_code, currency_name, nominal, value, previous, created_at
-		FROM currency_rates
+		SELECT id, date, currency_code, currency_name, nominal, value, previous, created_at, carried_from, published_at,
+			unit_value, `+previousNominal+`
+		FROM currency_rates r
 		WHERE date = $1
 		ORDER BY currency_code
 	`, date)
@@ -178,6 +380,10 @@ func (p *PostgresDB) GetCurrencyRatesByDate(date time.Time) ([]CurrencyRate, err
 			&rate.Value,
 			&rate.Previous,
 			&rate.CreatedAt,
+			&rate.CarriedFrom,
+			&rate.PublishedAt,
+			&rate.UnitValue,
+			&rate.PreviousNominal,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan currency rate: %w", err)
 		}
@@ -193,10 +399,12 @@ func (p *PostgresDB) GetCurrencyRatesByDate(date time.Time) ([]CurrencyRate, err
 
 // GetCurrencyRate retrieves a specific currency rate for a date
 func (p *PostgresDB) GetCurrencyRate(code string, date time.Time) (*CurrencyRate, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "get_currency_rate", time.Now())
 	var rate CurrencyRate
 	err := p.db.QueryRow(`
-		SELECT id, date, currency_code, currency_name, nominal, value, previous, created_at
-		FROM currency_rates
+		SELECT id, date, currency_code, currency_name, nominal, value, previous, created_at, carried_from, published_at,
+			unit_value, `+previousNominal+`
+		FROM currency_rates r
 		WHERE currency_code = $1 AND date = $2
 	`, code, date).Scan(
 		&rate.ID,
@@ -207,6 +415,10 @@ func (p *PostgresDB) GetCurrencyRate(code string, date time.Time) (*CurrencyRate
 		&rate.Value,
 		&rate.Previous,
 		&rate.CreatedAt,
+		&rate.CarriedFrom,
+		&rate.PublishedAt,
+		&rate.UnitValue,
+		&rate.PreviousNominal,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -220,6 +432,7 @@ func (p *PostgresDB) GetCurrencyRate(code string, date time.Time) (*CurrencyRate
 
 // GetAvailableDates retrieves a list of dates for which currency rates are available
 func (p *PostgresDB) GetAvailableDates() ([]time.Time, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "get_available_dates", time.Now())
 	rows, err := p.db.Query(`
 		SELECT DISTINCT date
 		FROM currency_rates
@@ -249,9 +462,11 @@ func (p *PostgresDB) GetAvailableDates() ([]time.Time, error) {
 
 // GetCurrencyRatesByDateRange retrieves currency rates for a specific currency within a date range
 func (p *PostgresDB) GetCurrencyRatesByDateRange(code string, startDate, endDate time.Time) ([]CurrencyRate, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "get_currency_rates_by_date_range", time.Now())
 	rows, err := p.db.Query(`
-		SELECT id, date, currency_code, currency_name, nominal, value, previous, created_at
-		FROM currency_rates
+		SELECT id, date, currency_code, currency_name, nominal, value, previous, created_at, carried_from, published_at,
+			unit_value, `+previousNominal+`
+		FROM currency_rates r
 		WHERE currency_code = $1 AND date >= $2 AND date <= $3
 		ORDER BY date DESC
 	`, code, startDate, endDate)
@@ -272,6 +487,10 @@ func (p *PostgresDB) GetCurrencyRatesByDateRange(code string, startDate, endDate
 			&rate.Value,
 			&rate.Previous,
 			&rate.CreatedAt,
+			&rate.CarriedFrom,
+			&rate.PublishedAt,
+			&rate.UnitValue,
+			&rate.PreviousNominal,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan currency rate: %w", err)
 		}
@@ -285,29 +504,175 @@ func (p *PostgresDB) GetCurrencyRatesByDateRange(code string, startDate, endDate
 	return rates, nil
 }
 
+// GetCurrencyRatesAsOf retrieves currency rates of code, or of every currency
+// when code is empty, within a date range as they were stored at asOf: the
+// latest revision of each rate recorded at or before it, newest date first.
+// PreviousNominal is that of the current rates
+func (p *PostgresDB) GetCurrencyRatesAsOf(ctx context.Context, code string, startDate, endDate, asOf time.Time) ([]CurrencyRate, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "get_currency_rates_as_of", time.Now())
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT DISTINCT ON (date, currency_code)
+			date, currency_code, currency_name, nominal, value, COALESCE(previous, 0), recorded_at, carried_from,
+			unit_value, `+previousNominal+`
+		FROM currency_rate_revisions r
+		WHERE ($1 = '' OR currency_code = $1) AND date >= $2 AND date <= $3 AND recorded_at <= $4
+		ORDER BY date DESC, currency_code, recorded_at DESC, id DESC
+	`, code, startDate, endDate, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query currency rate revisions: %w", err)
+	}
+	defer rows.Close()
+
+	var rates []CurrencyRate
+	for rows.Next() {
+		var rate CurrencyRate
+		if err := rows.Scan(
+			&rate.Date,
+			&rate.CurrencyCode,
+			&rate.CurrencyName,
+			&rate.Nominal,
+			&rate.Value,
+			&rate.Previous,
+			&rate.CreatedAt,
+			&rate.CarriedFrom,
+			&rate.UnitValue,
+			&rate.PreviousNominal,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan currency rate revision: %w", err)
+		}
+		rates = append(rates, rate)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over currency rate revisions: %w", err)
+	}
+
+	return rates, nil
+}
+
+// GetCurrencyRateRevisions retrieves every revision of a currency rate on a
+// date, oldest first
+func (p *PostgresDB) GetCurrencyRateRevisions(ctx context.Context, code string, date time.Time) ([]CurrencyRateRevision, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "get_currency_rate_revisions", time.Now())
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT id, date, currency_code, currency_name, nominal, value, previous, unit_value, source, carried_from, fetched_at, recorded_at
+		FROM currency_rate_revisions
+		WHERE currency_code = $1 AND date = $2
+		ORDER BY recorded_at, id
+	`, code, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query currency rate revisions: %w", err)
+	}
+	defer rows.Close()
+
+	var revisions []CurrencyRateRevision
+	for rows.Next() {
+		var rev CurrencyRateRevision
+		if err := rows.Scan(
+			&rev.ID,
+			&rev.Date,
+			&rev.CurrencyCode,
+			&rev.CurrencyName,
+			&rev.Nominal,
+			&rev.Value,
+			&rev.Previous,
+			&rev.UnitValue,
+			&rev.Source,
+			&rev.CarriedFrom,
+			&rev.FetchedAt,
+			&rev.RecordedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan currency rate revision: %w", err)
+		}
+		revisions = append(revisions, rev)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over currency rate revisions: %w", err)
+	}
+
+	return revisions, nil
+}
+
+// GetNominalChanges returns the dates within a date range on which the CBR
+// changed the nominal of code, or of any currency when code is empty,
+// compared with the latest stored date before, ordered by date and code
+func (p *PostgresDB) GetNominalChanges(ctx context.Context, code string, startDate, endDate time.Time) ([]NominalChange, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "get_nominal_changes", time.Now())
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT date, currency_code, previous_nominal, nominal FROM (
+			SELECT date, currency_code, nominal,
+				LAG(nominal) OVER (PARTITION BY currency_code ORDER BY date) AS previous_nominal
+			FROM currency_rates
+			WHERE ($1 = '' OR currency_code = $1) AND date <= $3
+		) c
+		WHERE previous_nominal <> nominal AND date >= $2
+		ORDER BY date, currency_code
+	`, code, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query nominal changes: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []NominalChange
+	for rows.Next() {
+		var c NominalChange
+		if err := rows.Scan(&c.Date, &c.CurrencyCode, &c.PreviousNominal, &c.Nominal); err != nil {
+			return nil, fmt.Errorf("failed to scan nominal change: %w", err)
+		}
+		changes = append(changes, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over nominal changes: %w", err)
+	}
+
+	return changes, nil
+}
+
+// StoredCurrencyDates returns the dates within a date range that have a rate
+// of code, or any rate when code is empty, oldest first
+func (p *PostgresDB) StoredCurrencyDates(ctx context.Context, code string, startDate, endDate time.Time) ([]time.Time, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "stored_currency_dates", time.Now())
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT DISTINCT date FROM currency_rates
+		WHERE ($1 = '' OR currency_code = $1) AND date >= $2 AND date <= $3
+		ORDER BY date
+	`, code, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query currency dates: %w", err)
+	}
+	defer rows.Close()
+
+	var dates []time.Time
+	for rows.Next() {
+		var d time.Time
+		if err := rows.Scan(&d); err != nil {
+			return nil, fmt.Errorf("failed to scan currency date: %w", err)
+		}
+		y, m, dd := d.Date()
+		dates = append(dates, time.Date(y, m, dd, 0, 0, 0, 0, time.UTC))
+	}
+	return dates, rows.Err()
+}
+
 // CryptoRate represents a cryptocurrency rate record in the database
 type CryptoRate struct {
 	ID        int
 	Timestamp time.Time
 	Symbol    string
-	Open      float64
-	High      float64
-	Low       float64
-	Close     float64
-	Volume    float64
+	Open      money.Decimal
+	High      money.Decimal
+	Low       money.Decimal
+	Close     money.Decimal
+	Volume    money.Decimal
 	CreatedAt time.Time
 }
 
 // SaveCryptoRates saves multiple cryptocurrency rates to the database
 func (p *PostgresDB) SaveCryptoRates(rates []CryptoRate) error {
-	fmt.Printf("SaveCryptoRates: Attempting to save %d rates\n", len(rates))
-
-	if len(rates) > 0 {
-		fmt.Printf("First rate: Symbol=%s, Timestamp=%s, Unix=%d\n",
-			rates[0].Symbol,
-			rates[0].Timestamp.Format("2006-01-02 15:04:05"),
-			rates[0].Timestamp.Unix())
-	}
+	defer metrics.ObserveQuery(metrics.DBPostgres, "save_crypto_rates", time.Now())
+	start := time.Now()
 
 	tx, err := p.db.Begin()
 	if err != nil {
@@ -336,11 +701,6 @@ func (p *PostgresDB) SaveCryptoRates(rates []CryptoRate) error {
 		// Convert time.Time to Unix timestamp in seconds
 		unixTimestamp := rate.Timestamp.Unix()
 
-		fmt.Printf("Inserting rate: Symbol=%s, Timestamp=%s, Unix=%d\n",
-			rate.Symbol,
-			rate.Timestamp.Format("2006-01-02 15:04:05"),
-			unixTimestamp)
-
 		_, err := stmt.Exec(
 			unixTimestamp, // Use Unix timestamp instead of time.Time
 			rate.Symbol,
@@ -358,13 +718,31 @@ func (p *PostgresDB) SaveCryptoRates(rates []CryptoRate) error {
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
+	for _, rate := range rates {
+		metrics.RateStored(metrics.SourceBinance, rate.Timestamp)
+	}
 
-	fmt.Printf("SaveCryptoRates: Successfully saved %d rates\n", len(rates))
+	logger.Debug("saved crypto rates", "count", len(rates), "duration_ms", logging.Millis(time.Since(start)))
 	return nil
 }
 
+// LatestCryptoRateTime returns the newest stored candle time, or the zero time
+// when there are no rates
+func (p *PostgresDB) LatestCryptoRateTime() (time.Time, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "latest_crypto_rate_time", time.Now())
+	var timestamp sql.NullInt64
+	if err := p.db.QueryRow(`SELECT MAX(timestamp) FROM crypto_rates`).Scan(&timestamp); err != nil {
+		return time.Time{}, fmt.Errorf("failed to query latest crypto rate time: %w", err)
+	}
+	if !timestamp.Valid {
+		return time.Time{}, nil
+	}
+	return time.Unix(timestamp.Int64, 0).UTC(), nil
+}
+
 // GetCryptoRatesBySymbol retrieves cryptocurrency rates for a specific symbol
 func (p *PostgresDB) GetCryptoRatesBySymbol(symbol string, limit int) ([]CryptoRate, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "get_crypto_rates_by_symbol", time.Now())
 	rows, err := p.db.Query(`
 		SELECT id, timestamp, symbol, open, high, low, close, volume, created_at
 		FROM crypto_rates
@@ -411,6 +789,7 @@ func (p *PostgresDB) GetCryptoRatesBySymbol(symbol string, limit int) ([]CryptoR
 
 // GetCryptoRatesByDateRange retrieves cryptocurrency rates for a specific symbol within a date range
 func (p *PostgresDB) GetCryptoRatesByDateRange(symbol string, startTime, endTime time.Time) ([]CryptoRate, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "get_crypto_rates_by_date_range", time.Now())
 	// Convert time.Time to Unix timestamp in seconds
 	startUnix := startTime.Unix()
 	endUnix := endTime.Unix()
@@ -458,8 +837,131 @@ func (p *PostgresDB) GetCryptoRatesByDateRange(symbol string, startTime, endTime
 	return rates, nil
 }
 
+// StoredCryptoSlots returns the open times of the step-sized candles, aligned
+// to the Unix epoch, that hold at least one rate of symbol with
+// startTime <= timestamp < endTime, oldest first
+func (p *PostgresDB) StoredCryptoSlots(ctx context.Context, symbol string, step time.Duration, startTime, endTime time.Time) ([]time.Time, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "stored_crypto_slots", time.Now())
+	sec := int64(step / time.Second)
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT DISTINCT timestamp / $1 * $1 AS slot
+		FROM crypto_rates
+		WHERE symbol = $2 AND timestamp >= $3 AND timestamp < $4
+		ORDER BY slot
+	`, sec, symbol, startTime.Unix(), endTime.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query crypto slots: %w", err)
+	}
+	defer rows.Close()
+
+	var slots []time.Time
+	for rows.Next() {
+		var slot int64
+		if err := rows.Scan(&slot); err != nil {
+			return nil, fmt.Errorf("failed to scan crypto slot: %w", err)
+		}
+		slots = append(slots, time.Unix(slot, 0).UTC())
+	}
+	return slots, rows.Err()
+}
+
+// StreamCurrencyRates calls fn for every currency rate within a date range in
+// chronological order without loading the whole range into memory. An empty
+// code selects all currencies. Iteration stops at the first error from fn.
+func (p *PostgresDB) StreamCurrencyRates(ctx context.Context, code string, startDate, endDate time.Time, fn func(CurrencyRate) error) error {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "stream_currency_rates", time.Now())
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT id, date, currency_code, currency_name, nominal, value, previous, created_at, carried_from, published_at,
+			unit_value, `+previousNominal+`
+		FROM currency_rates r
+		WHERE ($1 = '' OR currency_code = $1) AND date >= $2 AND date <= $3
+		ORDER BY date ASC, currency_code ASC
+	`, code, startDate, endDate)
+	if err != nil {
+		return fmt.Errorf("failed to query currency rates: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rate CurrencyRate
+		if err := rows.Scan(
+			&rate.ID,
+			&rate.Date,
+			&rate.CurrencyCode,
+			&rate.CurrencyName,
+			&rate.Nominal,
+			&rate.Value,
+			&rate.Previous,
+			&rate.CreatedAt,
+			&rate.CarriedFrom,
+			&rate.PublishedAt,
+			&rate.UnitValue,
+			&rate.PreviousNominal,
+		); err != nil {
+			return fmt.Errorf("failed to scan currency rate: %w", err)
+		}
+		if err := fn(rate); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating over currency rates: %w", err)
+	}
+
+	return nil
+}
+
+// StreamCryptoRates calls fn for every cryptocurrency rate within a time range
+// in chronological order. An empty symbol selects all symbols. Iteration stops
+// at the first error from fn.
+func (p *PostgresDB) StreamCryptoRates(ctx context.Context, symbol string, startTime, endTime time.Time, fn func(CryptoRate) error) error {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "stream_crypto_rates", time.Now())
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT id, timestamp, symbol, open, high, low, close, volume, created_at
+		FROM crypto_rates
+		WHERE ($1 = '' OR symbol = $1) AND timestamp >= $2 AND timestamp <= $3
+		ORDER BY timestamp ASC, symbol ASC
+	`, symbol, startTime.Unix(), endTime.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to query crypto rates: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rate CryptoRate
+		var timestampUnix int64
+
+		if err := rows.Scan(
+			&rate.ID,
+			&timestampUnix,
+			&rate.Symbol,
+			&rate.Open,
+			&rate.High,
+			&rate.Low,
+			&rate.Close,
+			&rate.Volume,
+			&rate.CreatedAt,
+		); err != nil {
+			return fmt.Errorf("failed to scan crypto rate: %w", err)
+		}
+		rate.Timestamp = time.Unix(timestampUnix, 0)
+
+		if err := fn(rate); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating over crypto rates: %w", err)
+	}
+
+	return nil
+}
+
 // GetAvailableCryptoSymbols retrieves a list of available cryptocurrency symbols
 func (p *PostgresDB) GetAvailableCryptoSymbols() ([]string, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "get_available_crypto_symbols", time.Now())
 	rows, err := p.db.Query(`
 		SELECT DISTINCT symbol
 		FROM crypto_rates
@@ -486,6 +988,256 @@ func (p *PostgresDB) GetAvailableCryptoSymbols() ([]string, error) {
 	return symbols, nil
 }
 
+// MetalPrice represents the CBR price of one gram of a precious metal in
+// rubles for a day
+type MetalPrice struct {
+	Date      time.Time
+	Metal     string
+	Name      string
+	Buy       money.Decimal
+	Sell      money.Decimal
+	Source    string
+	FetchedAt time.Time
+	CreatedAt time.Time
+}
+
+// SaveMetalPrices saves metal prices, replacing those stored for the same
+// date and metal
+func (p *PostgresDB) SaveMetalPrices(prices []MetalPrice) error {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "save_metal_prices", time.Now())
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO metal_prices (date, metal, name, buy, sell, source, fetched_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (date, metal)
+		DO UPDATE SET
+			name = EXCLUDED.name,
+			buy = EXCLUDED.buy,
+			sell = EXCLUDED.sell,
+			source = EXCLUDED.source,
+			fetched_at = EXCLUDED.fetched_at,
+			created_at = NOW()
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, price := range prices {
+		var fetchedAt *time.Time
+		if !price.FetchedAt.IsZero() {
+			fetchedAt = &price.FetchedAt
+		}
+		if _, err := stmt.Exec(price.Date, price.Metal, price.Name, price.Buy, price.Sell, price.Source, fetchedAt); err != nil {
+			return fmt.Errorf("failed to insert metal price: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	for _, price := range prices {
+		metrics.RateStored(metrics.SourceCBRMetals, price.Date)
+	}
+
+	return nil
+}
+
+// LatestMetalPriceDate returns the newest stored metal price date, or the
+// zero time when there are no prices
+func (p *PostgresDB) LatestMetalPriceDate() (time.Time, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "latest_metal_price_date", time.Now())
+	var date sql.NullTime
+	if err := p.db.QueryRow(`SELECT MAX(date) FROM metal_prices`).Scan(&date); err != nil {
+		return time.Time{}, fmt.Errorf("failed to query latest metal price date: %w", err)
+	}
+	return date.Time, nil
+}
+
+// GetMetalPricesOn retrieves the prices of the last day on or before date
+// the CBR set them for, since none are set on weekends and holidays
+func (p *PostgresDB) GetMetalPricesOn(date time.Time) ([]MetalPrice, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "get_metal_prices_on", time.Now())
+	rows, err := p.db.Query(`
+		SELECT date, metal, name, buy, sell, created_at
+		FROM metal_prices
+		WHERE date = (SELECT MAX(date) FROM metal_prices WHERE date <= $1)
+		ORDER BY metal
+	`, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query metal prices: %w", err)
+	}
+	return scanMetalPrices(rows)
+}
+
+// GetMetalPricesByDateRange retrieves the prices of a metal, or of every
+// metal when metal is empty, within a date range ordered by date and metal
+func (p *PostgresDB) GetMetalPricesByDateRange(metal string, startDate, endDate time.Time) ([]MetalPrice, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "get_metal_prices_by_date_range", time.Now())
+	rows, err := p.db.Query(`
+		SELECT date, metal, name, buy, sell, created_at
+		FROM metal_prices
+		WHERE ($1 = '' OR metal = $1) AND date >= $2 AND date <= $3
+		ORDER BY date, metal
+	`, metal, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query metal prices: %w", err)
+	}
+	return scanMetalPrices(rows)
+}
+
+func scanMetalPrices(rows *sql.Rows) ([]MetalPrice, error) {
+	defer rows.Close()
+
+	var prices []MetalPrice
+	for rows.Next() {
+		var price MetalPrice
+		if err := rows.Scan(&price.Date, &price.Metal, &price.Name, &price.Buy, &price.Sell, &price.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan metal price: %w", err)
+		}
+		prices = append(prices, price)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over metal prices: %w", err)
+	}
+
+	return prices, nil
+}
+
+// IndicatorRate represents the value a CBR indicator, such as the key rate,
+// took in percent per annum on its effective date
+type IndicatorRate struct {
+	Indicator     string
+	EffectiveDate time.Time
+	Name          string
+	Value         money.Decimal
+	Source        string
+	FetchedAt     time.Time
+	CreatedAt     time.Time
+}
+
+// SaveIndicatorRates saves indicator values, replacing those stored for the
+// same indicator and date, and keeps only the rows where a value changes:
+// the key rate is published for every business day but only moves on board
+// decision dates
+func (p *PostgresDB) SaveIndicatorRates(rates []IndicatorRate) error {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "save_indicator_rates", time.Now())
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO indicator_rates (indicator, effective_date, name, value, source, fetched_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (indicator, effective_date)
+		DO UPDATE SET
+			name = EXCLUDED.name,
+			value = EXCLUDED.value,
+			source = EXCLUDED.source,
+			fetched_at = EXCLUDED.fetched_at,
+			created_at = NOW()
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	indicators := make(map[string]bool)
+	for _, rate := range rates {
+		var fetchedAt *time.Time
+		if !rate.FetchedAt.IsZero() {
+			fetchedAt = &rate.FetchedAt
+		}
+		if _, err := stmt.Exec(rate.Indicator, rate.EffectiveDate, rate.Name, rate.Value, rate.Source, fetchedAt); err != nil {
+			return fmt.Errorf("failed to insert indicator rate: %w", err)
+		}
+		indicators[rate.Indicator] = true
+	}
+
+	for indicator := range indicators {
+		if _, err := tx.Exec(`
+			DELETE FROM indicator_rates r USING (
+				SELECT effective_date, value,
+					LAG(value) OVER (ORDER BY effective_date) AS previous
+				FROM indicator_rates WHERE indicator = $1
+			) s
+			WHERE r.indicator = $1 AND r.effective_date = s.effective_date AND s.value = s.previous
+		`, indicator); err != nil {
+			return fmt.Errorf("failed to collapse indicator rates: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	for _, rate := range rates {
+		metrics.RateStored(metrics.SourceCBRIndicators, rate.EffectiveDate)
+	}
+
+	return nil
+}
+
+// GetIndicatorRatesOn retrieves the value of every indicator in effect on
+// date, the latest on or before it, ordered by indicator
+func (p *PostgresDB) GetIndicatorRatesOn(date time.Time) ([]IndicatorRate, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "get_indicator_rates_on", time.Now())
+	rows, err := p.db.Query(`
+		SELECT DISTINCT ON (indicator) indicator, effective_date, name, value, created_at
+		FROM indicator_rates
+		WHERE effective_date <= $1
+		ORDER BY indicator, effective_date DESC
+	`, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query indicator rates: %w", err)
+	}
+	return scanIndicatorRates(rows)
+}
+
+// GetIndicatorRatesByDateRange retrieves the values of an indicator in
+// effect within a date range, oldest first: the one in effect on the start
+// date, which may have taken effect before it, and those taking effect after
+func (p *PostgresDB) GetIndicatorRatesByDateRange(indicator string, startDate, endDate time.Time) ([]IndicatorRate, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "get_indicator_rates_by_date_range", time.Now())
+	rows, err := p.db.Query(`
+		SELECT indicator, effective_date, name, value, created_at
+		FROM indicator_rates
+		WHERE indicator = $1 AND effective_date <= $3 AND effective_date >= COALESCE(
+			(SELECT MAX(effective_date) FROM indicator_rates WHERE indicator = $1 AND effective_date <= $2), $2)
+		ORDER BY effective_date
+	`, indicator, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query indicator rates: %w", err)
+	}
+	return scanIndicatorRates(rows)
+}
+
+func scanIndicatorRates(rows *sql.Rows) ([]IndicatorRate, error) {
+	defer rows.Close()
+
+	var rates []IndicatorRate
+	for rows.Next() {
+		var rate IndicatorRate
+		if err := rows.Scan(&rate.Indicator, &rate.EffectiveDate, &rate.Name, &rate.Value, &rate.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan indicator rate: %w", err)
+		}
+		rates = append(rates, rate)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over indicator rates: %w", err)
+	}
+
+	return rates, nil
+}
+
 // UpdateSchema initializes the database schema
 func (p *PostgresDB) UpdateSchema() error {
 	query := `
@@ -535,6 +1287,30 @@ func (p *PostgresDB) UpdateSchema() error {
 		created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
 		UNIQUE(user_id, symbol)
 	);
+
+	CREATE TABLE IF NOT EXISTS telegram_metal_subscriptions (
+		id SERIAL PRIMARY KEY,
+		user_id INTEGER NOT NULL,
+		metal VARCHAR(3) NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+		UNIQUE(user_id, metal)
+	);
+
+	CREATE TABLE IF NOT EXISTS telegram_indicator_subscriptions (
+		id SERIAL PRIMARY KEY,
+		user_id INTEGER NOT NULL,
+		indicator VARCHAR(16) NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+		UNIQUE(user_id, indicator)
+	);
+
+	CREATE TABLE IF NOT EXISTS telegram_alert_subscriptions (
+		id SERIAL PRIMARY KEY,
+		user_id INTEGER NOT NULL,
+		alert VARCHAR(64) NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+		UNIQUE(user_id, alert)
+	);
 	`
 
 	_, err := p.db.Exec(query)
@@ -555,6 +1331,7 @@ type TelegramSubscription struct {
 
 // SaveTelegramSubscription saves a telegram subscription to the database
 func (p *PostgresDB) SaveTelegramSubscription(userID int, currency string) error {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "save_telegram_subscription", time.Now())
 	_, err := p.db.Exec(`
 		INSERT INTO telegram_subscriptions (user_id, currency)
 		VALUES ($1, $2)
@@ -568,6 +1345,7 @@ func (p *PostgresDB) SaveTelegramSubscription(userID int, currency string) error
 
 // DeleteTelegramSubscription deletes a telegram subscription from the database
 func (p *PostgresDB) DeleteTelegramSubscription(userID int, currency string) error {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "delete_telegram_subscription", time.Now())
 	result, err := p.db.Exec(`
 		DELETE FROM telegram_subscriptions
 		WHERE user_id = $1 AND currency = $2
@@ -590,6 +1368,7 @@ func (p *PostgresDB) DeleteTelegramSubscription(userID int, currency string) err
 
 // GetTelegramSubscriptions retrieves telegram subscriptions for a specific user
 func (p *PostgresDB) GetTelegramSubscriptions(userID int) ([]string, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "get_telegram_subscriptions", time.Now())
 	rows, err := p.db.Query(`
 		SELECT currency
 		FROM telegram_subscriptions
@@ -619,6 +1398,7 @@ func (p *PostgresDB) GetTelegramSubscriptions(userID int) ([]string, error) {
 
 // GetAllTelegramSubscriptions retrieves all telegram subscriptions
 func (p *PostgresDB) GetAllTelegramSubscriptions() (map[int][]string, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "get_all_telegram_subscriptions", time.Now())
 	rows, err := p.db.Query(`
 		SELECT user_id, currency
 		FROM telegram_subscriptions
@@ -648,6 +1428,7 @@ func (p *PostgresDB) GetAllTelegramSubscriptions() (map[int][]string, error) {
 
 // SaveTelegramCryptoSubscription saves a user's cryptocurrency subscription to the database
 func (p *PostgresDB) SaveTelegramCryptoSubscription(userID int, symbol string) error {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "save_telegram_crypto_subscription", time.Now())
 	_, err := p.db.Exec(`
 		INSERT INTO telegram_crypto_subscriptions (user_id, symbol)
 		VALUES ($1, $2)
@@ -661,6 +1442,7 @@ func (p *PostgresDB) SaveTelegramCryptoSubscription(userID int, symbol string) e
 
 // DeleteTelegramCryptoSubscription deletes a user's cryptocurrency subscription from the database
 func (p *PostgresDB) DeleteTelegramCryptoSubscription(userID int, symbol string) error {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "delete_telegram_crypto_subscription", time.Now())
 	result, err := p.db.Exec(`
 		DELETE FROM telegram_crypto_subscriptions
 		WHERE user_id = $1 AND symbol = $2
@@ -683,6 +1465,7 @@ func (p *PostgresDB) DeleteTelegramCryptoSubscription(userID int, symbol string)
 
 // GetTelegramCryptoSubscriptions retrieves a user's cryptocurrency subscriptions from the database
 func (p *PostgresDB) GetTelegramCryptoSubscriptions(userID int) ([]string, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "get_telegram_crypto_subscriptions", time.Now())
 	rows, err := p.db.Query(`
 		SELECT symbol
 		FROM telegram_crypto_subscriptions
@@ -712,6 +1495,7 @@ func (p *PostgresDB) GetTelegramCryptoSubscriptions(userID int) ([]string, error
 
 // GetAllTelegramCryptoSubscriptions retrieves all cryptocurrency subscriptions from the database
 func (p *PostgresDB) GetAllTelegramCryptoSubscriptions() (map[int][]string, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "get_all_telegram_crypto_subscriptions", time.Now())
 	rows, err := p.db.Query(`
 		SELECT user_id, symbol
 		FROM telegram_crypto_subscriptions
@@ -739,3 +1523,207 @@ func (p *PostgresDB) GetAllTelegramCryptoSubscriptions() (map[int][]string, erro
 
 	return result, nil
 }
+
+// SaveTelegramMetalSubscription saves a user's precious metal subscription to the database
+func (p *PostgresDB) SaveTelegramMetalSubscription(userID int, metal string) error {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "save_telegram_metal_subscription", time.Now())
+	_, err := p.db.Exec(`
+		INSERT INTO telegram_metal_subscriptions (user_id, metal)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, metal) DO NOTHING
+	`, userID, metal)
+	if err != nil {
+		return fmt.Errorf("failed to save telegram metal subscription: %w", err)
+	}
+	return nil
+}
+
+// DeleteTelegramMetalSubscription deletes a user's precious metal subscription from the database
+func (p *PostgresDB) DeleteTelegramMetalSubscription(userID int, metal string) error {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "delete_telegram_metal_subscription", time.Now())
+	result, err := p.db.Exec(`
+		DELETE FROM telegram_metal_subscriptions
+		WHERE user_id = $1 AND metal = $2
+	`, userID, metal)
+	if err != nil {
+		return fmt.Errorf("failed to delete telegram metal subscription: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("subscription not found")
+	}
+
+	return nil
+}
+
+// GetAllTelegramMetalSubscriptions retrieves all precious metal subscriptions from the database
+func (p *PostgresDB) GetAllTelegramMetalSubscriptions() (map[int][]string, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "get_all_telegram_metal_subscriptions", time.Now())
+	rows, err := p.db.Query(`
+		SELECT user_id, metal
+		FROM telegram_metal_subscriptions
+		ORDER BY user_id, metal
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query all telegram metal subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[int][]string)
+	for rows.Next() {
+		var userID int
+		var metal string
+		if err := rows.Scan(&userID, &metal); err != nil {
+			return nil, fmt.Errorf("failed to scan subscription: %w", err)
+		}
+
+		result[userID] = append(result[userID], metal)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over subscriptions: %w", err)
+	}
+
+	return result, nil
+}
+
+// SaveTelegramIndicatorSubscription saves a user's CBR indicator subscription to the database
+func (p *PostgresDB) SaveTelegramIndicatorSubscription(userID int, indicator string) error {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "save_telegram_indicator_subscription", time.Now())
+	_, err := p.db.Exec(`
+		INSERT INTO telegram_indicator_subscriptions (user_id, indicator)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, indicator) DO NOTHING
+	`, userID, indicator)
+	if err != nil {
+		return fmt.Errorf("failed to save telegram indicator subscription: %w", err)
+	}
+	return nil
+}
+
+// DeleteTelegramIndicatorSubscription deletes a user's CBR indicator subscription from the database
+func (p *PostgresDB) DeleteTelegramIndicatorSubscription(userID int, indicator string) error {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "delete_telegram_indicator_subscription", time.Now())
+	result, err := p.db.Exec(`
+		DELETE FROM telegram_indicator_subscriptions
+		WHERE user_id = $1 AND indicator = $2
+	`, userID, indicator)
+	if err != nil {
+		return fmt.Errorf("failed to delete telegram indicator subscription: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("subscription not found")
+	}
+
+	return nil
+}
+
+// GetAllTelegramIndicatorSubscriptions retrieves all CBR indicator subscriptions from the database
+func (p *PostgresDB) GetAllTelegramIndicatorSubscriptions() (map[int][]string, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "get_all_telegram_indicator_subscriptions", time.Now())
+	rows, err := p.db.Query(`
+		SELECT user_id, indicator
+		FROM telegram_indicator_subscriptions
+		ORDER BY user_id, indicator
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query all telegram indicator subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[int][]string)
+	for rows.Next() {
+		var userID int
+		var indicator string
+		if err := rows.Scan(&userID, &indicator); err != nil {
+			return nil, fmt.Errorf("failed to scan subscription: %w", err)
+		}
+
+		result[userID] = append(result[userID], indicator)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over subscriptions: %w", err)
+	}
+
+	return result, nil
+}
+
+// SaveTelegramAlertSubscription saves a user's indicator alert subscription to the database
+func (p *PostgresDB) SaveTelegramAlertSubscription(userID int, alert string) error {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "save_telegram_alert_subscription", time.Now())
+	_, err := p.db.Exec(`
+		INSERT INTO telegram_alert_subscriptions (user_id, alert)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, alert) DO NOTHING
+	`, userID, alert)
+	if err != nil {
+		return fmt.Errorf("failed to save telegram alert subscription: %w", err)
+	}
+	return nil
+}
+
+// DeleteTelegramAlertSubscription deletes a user's indicator alert subscription from the database
+func (p *PostgresDB) DeleteTelegramAlertSubscription(userID int, alert string) error {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "delete_telegram_alert_subscription", time.Now())
+	result, err := p.db.Exec(`
+		DELETE FROM telegram_alert_subscriptions
+		WHERE user_id = $1 AND alert = $2
+	`, userID, alert)
+	if err != nil {
+		return fmt.Errorf("failed to delete telegram alert subscription: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("subscription not found")
+	}
+
+	return nil
+}
+
+// GetAllTelegramAlertSubscriptions retrieves all indicator alert subscriptions from the database
+func (p *PostgresDB) GetAllTelegramAlertSubscriptions() (map[int][]string, error) {
+	defer metrics.ObserveQuery(metrics.DBPostgres, "get_all_telegram_alert_subscriptions", time.Now())
+	rows, err := p.db.Query(`
+		SELECT user_id, alert
+		FROM telegram_alert_subscriptions
+		ORDER BY user_id, alert
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query all telegram alert subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[int][]string)
+	for rows.Next() {
+		var userID int
+		var alert string
+		if err := rows.Scan(&userID, &alert); err != nil {
+			return nil, fmt.Errorf("failed to scan subscription: %w", err)
+		}
+
+		result[userID] = append(result[userID], alert)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over subscriptions: %w", err)
+	}
+
+	return result, nil
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/casualdoto/go-currency-tracker/internal/money"
 	"github.com/stretchr/testify/assert"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
@@ -99,38 +100,47 @@ func TestCurrencyRate_Validation(t *testing.T) {
 		CurrencyCode: "USD",
 		CurrencyName: "US Dollar",
 		Nominal:      1,
-		Value:        85.0504,
-		Previous:     87.1992,
+		Value:        money.MustParse("85.0504"),
+		Previous:     money.MustParse("87.1992"),
 	}
 
 	assert.Equal(t, "USD", rate.CurrencyCode)
 	assert.Equal(t, "US Dollar", rate.CurrencyName)
 	assert.Equal(t, 1, rate.Nominal)
-	assert.Equal(t, 85.0504, rate.Value)
-	assert.Equal(t, 87.1992, rate.Previous)
+	assert.Equal(t, "85.0504", rate.Value.String())
+	assert.Equal(t, "87.1992", rate.Previous.String())
 	assert.False(t, rate.Date.IsZero())
 }
 
+// Test the per-unit value of a rate, stored or derived from the nominal
+func TestCurrencyRate_PerUnit(t *testing.T) {
+	derived := CurrencyRate{CurrencyCode: "JPY", Nominal: 100, Value: money.MustParse("53.3049")}
+	assert.Equal(t, "0.533049", derived.PerUnit().String())
+
+	stored := CurrencyRate{CurrencyCode: "KZT", Nominal: 100, Value: money.MustParse("18.0973"), UnitValue: money.MustParse("0.180973")}
+	assert.Equal(t, "0.180973", stored.PerUnit().String())
+}
+
 func TestCryptoRate_Validation(t *testing.T) {
 	rate := CryptoRate{
 		Timestamp: time.Date(2023, 6, 29, 12, 0, 0, 0, time.UTC),
 		Symbol:    "BTC",
-		Open:      47000.50,
-		High:      47500.00,
-		Low:       46500.00,
-		Close:     47200.00,
-		Volume:    100.5,
+		Open:      money.MustParse("47000.50"),
+		High:      money.MustParse("47500.00"),
+		Low:       money.MustParse("46500.00"),
+		Close:     money.MustParse("47200.00"),
+		Volume:    money.MustParse("100.5"),
 	}
 
 	assert.Equal(t, "BTC", rate.Symbol)
-	assert.Equal(t, 47000.50, rate.Open)
-	assert.Equal(t, 47500.00, rate.High)
-	assert.Equal(t, 46500.00, rate.Low)
-	assert.Equal(t, 47200.00, rate.Close)
-	assert.Equal(t, 100.5, rate.Volume)
+	assert.Equal(t, "47000.5", rate.Open.String())
+	assert.Equal(t, "47500", rate.High.String())
+	assert.Equal(t, "46500", rate.Low.String())
+	assert.Equal(t, "47200", rate.Close.String())
+	assert.Equal(t, "100.5", rate.Volume.String())
 	assert.False(t, rate.Timestamp.IsZero())
-	assert.Greater(t, rate.High, rate.Low)
-	assert.Greater(t, rate.Volume, 0.0)
+	assert.Equal(t, 1, rate.High.Cmp(rate.Low))
+	assert.True(t, rate.Volume.IsPositive())
 }
 
 // Test database connection errors
@@ -176,16 +186,16 @@ func TestPostgresDB_MockOperations(t *testing.T) {
 				CurrencyCode: "USD",
 				CurrencyName: "US Dollar",
 				Nominal:      1,
-				Value:        85.0504,
-				Previous:     87.1992,
+				Value:        money.MustParse("85.0504"),
+				Previous:     money.MustParse("87.1992"),
 			},
 			{
 				Date:         time.Date(2023, 6, 29, 0, 0, 0, 0, time.UTC),
 				CurrencyCode: "EUR",
 				CurrencyName: "Euro",
 				Nominal:      1,
-				Value:        94.7092,
-				Previous:     95.0489,
+				Value:        money.MustParse("94.7092"),
+				Previous:     money.MustParse("95.0489"),
 			},
 		}
 
@@ -200,20 +210,20 @@ func TestPostgresDB_MockOperations(t *testing.T) {
 			{
 				Timestamp: time.Date(2023, 6, 29, 12, 0, 0, 0, time.UTC),
 				Symbol:    "BTC",
-				Open:      47000.50,
-				High:      47500.00,
-				Low:       46500.00,
-				Close:     47200.00,
-				Volume:    100.5,
+				Open:      money.MustParse("47000.50"),
+				High:      money.MustParse("47500.00"),
+				Low:       money.MustParse("46500.00"),
+				Close:     money.MustParse("47200.00"),
+				Volume:    money.MustParse("100.5"),
 			},
 			{
 				Timestamp: time.Date(2023, 6, 29, 13, 0, 0, 0, time.UTC),
 				Symbol:    "ETH",
-				Open:      3200.50,
-				High:      3250.00,
-				Low:       3150.00,
-				Close:     3220.00,
-				Volume:    500.5,
+				Open:      money.MustParse("3200.50"),
+				High:      money.MustParse("3250.00"),
+				Low:       money.MustParse("3150.00"),
+				Close:     money.MustParse("3220.00"),
+				Volume:    money.MustParse("500.5"),
 			},
 		}
 
@@ -270,8 +280,8 @@ func BenchmarkCurrencyRate_Creation(b *testing.B) {
 			CurrencyCode: "USD",
 			CurrencyName: "US Dollar",
 			Nominal:      1,
-			Value:        85.0504,
-			Previous:     87.1992,
+			Value:        money.MustParse("85.0504"),
+			Previous:     money.MustParse("87.1992"),
 		}
 		_ = rate
 	}
@@ -283,11 +293,11 @@ func BenchmarkCryptoRate_Creation(b *testing.B) {
 		rate := CryptoRate{
 			Timestamp: time.Now(),
 			Symbol:    "BTC",
-			Open:      47000.50,
-			High:      47500.00,
-			Low:       46500.00,
-			Close:     47200.00,
-			Volume:    100.5,
+			Open:      money.MustParse("47000.50"),
+			High:      money.MustParse("47500.00"),
+			Low:       money.MustParse("46500.00"),
+			Close:     money.MustParse("47200.00"),
+			Volume:    money.MustParse("100.5"),
 		}
 		_ = rate
 	}
@@ -306,8 +316,8 @@ func TestPostgresDB_ErrorScenarios(t *testing.T) {
 			CurrencyCode: "",
 			CurrencyName: "Empty Currency",
 			Nominal:      1,
-			Value:        85.0504,
-			Previous:     87.1992,
+			Value:        money.MustParse("85.0504"),
+			Previous:     money.MustParse("87.1992"),
 		}
 
 		assert.Empty(t, rate.CurrencyCode)
@@ -318,15 +328,15 @@ func TestPostgresDB_ErrorScenarios(t *testing.T) {
 		rate := CryptoRate{
 			Timestamp: time.Date(2023, 6, 29, 12, 0, 0, 0, time.UTC),
 			Symbol:    "",
-			Open:      47000.50,
-			High:      47500.00,
-			Low:       46500.00,
-			Close:     47200.00,
-			Volume:    100.5,
+			Open:      money.MustParse("47000.50"),
+			High:      money.MustParse("47500.00"),
+			Low:       money.MustParse("46500.00"),
+			Close:     money.MustParse("47200.00"),
+			Volume:    money.MustParse("100.5"),
 		}
 
 		assert.Empty(t, rate.Symbol)
-		assert.Greater(t, rate.Open, 0.0)
+		assert.True(t, rate.Open.IsPositive())
 	})
 }
 
@@ -338,15 +348,15 @@ func TestPostgresDB_DataValidation(t *testing.T) {
 			CurrencyCode: "USD",
 			CurrencyName: "US Dollar",
 			Nominal:      1,
-			Value:        85.0504,
-			Previous:     87.1992,
+			Value:        money.MustParse("85.0504"),
+			Previous:     money.MustParse("87.1992"),
 		}
 
 		// Validate required fields
 		assert.NotEmpty(t, rate.CurrencyCode)
 		assert.NotEmpty(t, rate.CurrencyName)
 		assert.Greater(t, rate.Nominal, 0)
-		assert.Greater(t, rate.Value, 0.0)
+		assert.True(t, rate.Value.IsPositive())
 		assert.False(t, rate.Date.IsZero())
 	})
 
@@ -354,27 +364,27 @@ func TestPostgresDB_DataValidation(t *testing.T) {
 		rate := CryptoRate{
 			Timestamp: time.Date(2023, 6, 29, 12, 0, 0, 0, time.UTC),
 			Symbol:    "BTC",
-			Open:      47000.50,
-			High:      47500.00,
-			Low:       46500.00,
-			Close:     47200.00,
-			Volume:    100.5,
+			Open:      money.MustParse("47000.50"),
+			High:      money.MustParse("47500.00"),
+			Low:       money.MustParse("46500.00"),
+			Close:     money.MustParse("47200.00"),
+			Volume:    money.MustParse("100.5"),
 		}
 
 		// Validate required fields
 		assert.NotEmpty(t, rate.Symbol)
-		assert.Greater(t, rate.Open, 0.0)
-		assert.Greater(t, rate.High, 0.0)
-		assert.Greater(t, rate.Low, 0.0)
-		assert.Greater(t, rate.Close, 0.0)
-		assert.Greater(t, rate.Volume, 0.0)
+		assert.True(t, rate.Open.IsPositive())
+		assert.True(t, rate.High.IsPositive())
+		assert.True(t, rate.Low.IsPositive())
+		assert.True(t, rate.Close.IsPositive())
+		assert.True(t, rate.Volume.IsPositive())
 		assert.False(t, rate.Timestamp.IsZero())
 
 		// Validate logical relationships
-		assert.GreaterOrEqual(t, rate.High, rate.Low)
-		assert.GreaterOrEqual(t, rate.High, rate.Open)
-		assert.GreaterOrEqual(t, rate.High, rate.Close)
-		assert.LessOrEqual(t, rate.Low, rate.Open)
-		assert.LessOrEqual(t, rate.Low, rate.Close)
+		assert.GreaterOrEqual(t, rate.High.Cmp(rate.Low), 0)
+		assert.GreaterOrEqual(t, rate.High.Cmp(rate.Open), 0)
+		assert.GreaterOrEqual(t, rate.High.Cmp(rate.Close), 0)
+		assert.LessOrEqual(t, rate.Low.Cmp(rate.Open), 0)
+		assert.LessOrEqual(t, rate.Low.Cmp(rate.Close), 0)
 	})
 }
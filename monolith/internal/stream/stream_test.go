@@ -0,0 +1,101 @@
+package stream
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHub_resume checks the backlog after a last event ID and the filter
+func TestHub_resume(t *testing.T) {
+	h := NewHub(3)
+	for _, s := range []string{"USD", "EUR", "GBP", "BTC"} {
+		typ := TypeCBR
+		if s == "BTC" {
+			typ = TypeCrypto
+		}
+		require.NoError(t, h.Publish(typ, s, map[string]string{"symbol": s}))
+	}
+
+	all, sub := h.Subscribe(Filter{}, 0, true)
+	sub.Close()
+	require.Len(t, all, 3)
+	assert.Equal(t, "EUR", all[0].Symbol)
+
+	cbr, sub := h.Subscribe(Filter{Types: map[string]bool{TypeCBR: true}}, all[0].ID, true)
+	sub.Close()
+	require.Len(t, cbr, 1)
+	assert.Equal(t, "GBP", cbr[0].Symbol)
+}
+
+// TestHub_dropsSlowSubscriber checks that a full subscriber is disconnected
+func TestHub_dropsSlowSubscriber(t *testing.T) {
+	h := NewHub(8)
+	_, sub := h.Subscribe(Filter{}, 0, false)
+	for i := 0; i <= subscriberBuffer; i++ {
+		require.NoError(t, h.Publish(TypeCBR, "USD", i))
+	}
+	assert.Equal(t, 0, h.Subscribers())
+	sub.Close()
+}
+
+// TestServeSSE checks replayed and live events in the SSE format
+func TestServeSSE(t *testing.T) {
+	h := NewHub(8)
+	require.NoError(t, h.Publish(TypeCBR, "USD", map[string]float64{"value": 90}))
+	srv := httptest.NewServer(http.HandlerFunc(h.ServeSSE))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "?symbols=usd,btc&last_event_id=0")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	require.NoError(t, h.Publish(TypeCrypto, "BTC", map[string]float64{"close": 5600000}))
+	var data []string
+	lines := bufio.NewScanner(resp.Body)
+	for len(data) < 2 && lines.Scan() {
+		if strings.HasPrefix(lines.Text(), "data: ") {
+			data = append(data, lines.Text())
+		}
+	}
+	assert.Equal(t, []string{`data: {"value":90}`, `data: {"close":5600000}`}, data)
+}
+
+// TestServeSSE_invalidType checks the v1 error for unknown event types
+func TestServeSSE_invalidType(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewHub(8).ServeSSE(rec, httptest.NewRequest(http.MethodGet, "/v1/stream?types=fiat", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.JSONEq(t, `{"error":{"code":"bad_request","message":"invalid type \"fiat\", use cbr or crypto"}}`, rec.Body.String())
+}
+
+// TestServeWS checks that filtered events arrive as JSON messages
+func TestServeWS(t *testing.T) {
+	h := NewHub(8)
+	srv := httptest.NewServer(http.HandlerFunc(h.ServeWS))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"?types=crypto", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	// The subscription is registered right after the upgrade
+	require.Eventually(t, func() bool { return h.Subscribers() == 1 }, time.Second, 5*time.Millisecond)
+	require.NoError(t, h.Publish(TypeCBR, "USD", 90))
+	require.NoError(t, h.Publish(TypeCrypto, "ETH", 300000))
+
+	var e Event
+	require.NoError(t, conn.ReadJSON(&e))
+	assert.Equal(t, TypeCrypto, e.Type)
+	assert.Equal(t, "ETH", e.Symbol)
+	assert.JSONEq(t, "300000", string(e.Data))
+}
@@ -73,6 +73,8 @@ document.addEventListener('DOMContentLoaded', function() {
             loadCurrencies();
         } else if (currentDataSource === 'crypto') {
             loadCryptoSymbols();
+        } else if (currentDataSource === 'metals') {
+            loadMetals();
         }
     });
     
@@ -135,8 +137,8 @@ document.addEventListener('DOMContentLoaded', function() {
                 currentEndDate = formatDate(endDate);
             }
             
-            // Enable Excel download button
-            downloadExcelBtn.disabled = false;
+            // Enable Excel download button; there is no export of metal prices
+            downloadExcelBtn.disabled = currentDataSource === 'metals';
         } else {
             downloadExcelBtn.disabled = true;
         }
@@ -184,6 +186,8 @@ document.addEventListener('DOMContentLoaded', function() {
                     loadCurrencyHistoryCustom(currencyCode, startDate, endDate);
                 } else if (currentDataSource === 'crypto') {
                     loadCryptoHistoryCustom(currencyCode, startDate, endDate);
+                } else if (currentDataSource === 'metals') {
+                    loadMetalHistory(currencyCode, startDate, endDate);
                 }
             } else {
                 // Standard period
@@ -204,6 +208,8 @@ document.addEventListener('DOMContentLoaded', function() {
                     loadCurrencyHistory(currencyCode, period);
                 } else if (currentDataSource === 'crypto') {
                     loadCryptoHistory(currencyCode, period);
+                } else if (currentDataSource === 'metals') {
+                    loadMetalHistory(currencyCode, startDate, endDate);
                 }
             }
         }
@@ -518,16 +524,13 @@ document.addEventListener('DOMContentLoaded', function() {
                 // Sort by date (ascending)
                 history.sort((a, b) => new Date(a.date) - new Date(b.date));
                 
-                // Check if nominal changes in the data
-                const nominalChanges = checkNominalChanges(history);
+                // Nominal changes flagged by the server
+                const nominalChanges = flaggedNominalChanges(history);
                 
                 const dates = history.map(item => item.date);
                 
-                // Normalize values based on nominal to ensure consistent comparison
-                const values = history.map(item => {
-                    // Calculate the value per unit of currency
-                    return item.value / item.nominal;
-                });
+                // Per-unit values stay comparable across a change of nominal
+                const values = history.map(item => Number(item.unit_value));
                 
                 // Get currency info for chart display - use the most recent nominal
                 const mostRecentItem = history[history.length - 1];
@@ -539,8 +542,8 @@ document.addEventListener('DOMContentLoaded', function() {
                     nominalChangeDates: nominalChanges.dates
                 };
                 
-                // Calculate metrics based on normalized values
-                calculateMetrics(values, currencyInfo.nominal);
+                // Load metrics for the range from the server
+                loadMetrics('cbr', currencyCode, startDateStr, endDateStr, currencyInfo.nominal);
                 
                 // Update loading progress
                 document.getElementById('loading-progress').style.width = '100%';
@@ -667,16 +670,13 @@ document.addEventListener('DOMContentLoaded', function() {
                 // Sort by date (ascending)
                 history.sort((a, b) => new Date(a.date) - new Date(b.date));
                 
-                // Check if nominal changes in the data
-                const nominalChanges = checkNominalChanges(history);
+                // Nominal changes flagged by the server
+                const nominalChanges = flaggedNominalChanges(history);
                 
                 const dates = history.map(item => item.date);
                 
-                // Normalize values based on nominal to ensure consistent comparison
-                const values = history.map(item => {
-                    // Calculate the value per unit of currency
-                    return item.value / item.nominal;
-                });
+                // Per-unit values stay comparable across a change of nominal
+                const values = history.map(item => Number(item.unit_value));
                 
                 // Get currency info for chart display - use the most recent nominal
                 const mostRecentItem = history[history.length - 1];
@@ -688,8 +688,8 @@ document.addEventListener('DOMContentLoaded', function() {
                     nominalChangeDates: nominalChanges.dates
                 };
                 
-                // Calculate metrics based on normalized values
-                calculateMetrics(values, currencyInfo.nominal);
+                // Load metrics for the range from the server
+                loadMetrics('cbr', currencyCode, startDateStr, endDateStr, currencyInfo.nominal);
                 
                 // Update loading progress
                 document.getElementById('loading-progress').style.width = '100%';
@@ -795,8 +795,8 @@ document.addEventListener('DOMContentLoaded', function() {
                     data: history // Store full OHLC data
                 };
                 
-                // Calculate metrics based on closing prices
-                calculateCryptoMetrics(history);
+                // Load metrics for the range from the server
+                loadMetrics('crypto', symbol, dates[0], dates[dates.length - 1]);
                 
                 // Update loading progress
                 document.getElementById('loading-progress').style.width = '100%';
@@ -891,8 +891,8 @@ document.addEventListener('DOMContentLoaded', function() {
                     data: history // Store full OHLC data
                 };
                 
-                // Calculate metrics based on closing prices
-                calculateCryptoMetrics(history);
+                // Load metrics for the range from the server
+                loadMetrics('crypto', symbol, dates[0], dates[dates.length - 1]);
                 
                 // Update loading progress
                 document.getElementById('loading-progress').style.width = '100%';
@@ -936,26 +936,111 @@ document.addEventListener('DOMContentLoaded', function() {
         }
     }
     
-    // Check if nominal changes in the historical data
-    function checkNominalChanges(history) {
-        if (!history || history.length <= 1) {
-            return { changed: false, dates: [] };
-        }
+    // Load the precious metals the CBR sets prices for, in rubles per gram
+    function loadMetals() {
+        const metals = [
+            { code: 'XAU', name: 'Gold' },
+            { code: 'XAG', name: 'Silver' },
+            { code: 'XPT', name: 'Platinum' },
+            { code: 'XPD', name: 'Palladium' }
+        ];
         
-        let prevNominal = history[0].nominal;
-        const changes = [];
+        // Clear dropdown
+        currencySelect.innerHTML = '';
         
-        for (let i = 1; i < history.length; i++) {
-            const currentNominal = history[i].nominal;
-            if (currentNominal !== prevNominal) {
-                changes.push({
-                    date: history[i].date,
-                    oldNominal: prevNominal,
-                    newNominal: currentNominal
-                });
-                prevNominal = currentNominal;
+        metals.forEach(metal => {
+            const option = document.createElement('option');
+            option.value = metal.code;
+            option.textContent = `${metal.name} (${metal.code})`;
+            currencySelect.appendChild(option);
+        });
+        
+        // Select gold by default and load a week of prices
+        currencySelect.value = 'XAU';
+        updateExcelDownloadButton();
+        
+        const endDate = new Date();
+        const startDate = new Date();
+        startDate.setDate(endDate.getDate() - 7);
+        loadMetalHistory('XAU', startDate, endDate);
+    }
+    
+    // Load metal prices for a date range from the /v1 API
+    async function loadMetalHistory(metal, startDate, endDate) {
+        try {
+            resetMetrics();
+            if (currencyChart) {
+                currencyChart.destroy();
+                currencyChart = null;
             }
+            
+            // Show loading indicator
+            loadingIndicator.classList.remove('d-none');
+            document.getElementById('currency-chart').classList.add('d-none');
+            document.getElementById('loading-progress').style.width = '10%';
+            document.getElementById('loading-status').textContent = 'Retrieving metal prices...';
+            
+            const startDateStr = formatDate(startDate);
+            const endDateStr = formatDate(endDate);
+            
+            const response = await fetch(`/v1/rates/metals/range?metal=${metal}&from=${startDateStr}&to=${endDateStr}`);
+            const data = await response.json();
+            
+            if (response.ok && data.data && data.data.length > 0) {
+                document.getElementById('loading-progress').style.width = '50%';
+                document.getElementById('loading-status').textContent = 'Processing data...';
+                
+                // Rows come ordered by date
+                const history = data.data;
+                const dates = history.map(item => item.date);
+                const values = history.map(item => Number(item.buy));
+                
+                const metalInfo = {
+                    code: metal,
+                    name: history[0].name || metal,
+                    type: 'metal',
+                    data: history
+                };
+                
+                // The analytics endpoint covers currencies and crypto only
+                showSummaryMetrics(values);
+                
+                document.getElementById('loading-progress').style.width = '100%';
+                document.getElementById('loading-status').textContent = 'Completed!';
+                
+                // Small delay to show the 100% progress
+                setTimeout(() => {
+                    loadingIndicator.classList.add('d-none');
+                    document.getElementById('currency-chart').classList.remove('d-none');
+                    renderChart(dates, values, metalInfo);
+                }, 500);
+            } else {
+                loadingIndicator.classList.add('d-none');
+                document.getElementById('currency-chart').classList.remove('d-none');
+                
+                showNotification(`No prices available for ${metal} for the selected period.`, 'warning');
+                resetMetrics();
+            }
+        } catch (error) {
+            loadingIndicator.classList.add('d-none');
+            document.getElementById('currency-chart').classList.remove('d-none');
+            
+            console.error('Error loading metal prices:', error);
+            showNotification('Historical data service is temporarily unavailable. Please try again later.');
+            resetMetrics();
         }
+    }
+    
+    // Nominal changes in the historical data: the server marks the first
+    // date quoted for a new nominal with previous_nominal
+    function flaggedNominalChanges(history) {
+        const changes = history
+            .filter(item => item.previous_nominal)
+            .map(item => ({
+                date: item.date,
+                oldNominal: item.previous_nominal,
+                newNominal: item.nominal
+            }));
         
         return {
             changed: changes.length > 0,
@@ -971,40 +1056,50 @@ document.addEventListener('DOMContentLoaded', function() {
         return `${year}-${month}-${day}`;
     }
     
-    // Calculate metrics based on historical data
-    function calculateMetrics(values, nominal) {
+    // Load metrics for the displayed range from the analytics endpoint
+    // Values are per single unit, nominal scales them back for display
+    async function loadMetrics(source, code, startDateStr, endDateStr, nominal = 1) {
+        try {
+            const response = await fetch(`/rates/analytics?source=${source}&code=${code}&start_date=${startDateStr}&end_date=${endDateStr}`);
+            const data = await response.json();
+            
+            if (!data.success || !data.data || data.data.summary.count === 0) {
+                resetMetrics();
+                return;
+            }
+            
+            const summary = data.data.summary;
+            const digits = source === 'crypto' ? 2 : 4;
+            
+            metricAvg.textContent = (summary.mean * nominal).toFixed(digits) + ' ₽';
+            metricStd.textContent = (summary.stddev * nominal).toFixed(digits) + ' ₽';
+            metricMin.textContent = (summary.min * nominal).toFixed(digits) + ' ₽';
+            metricMax.textContent = (summary.max * nominal).toFixed(digits) + ' ₽';
+            // Volatility card shows the coefficient of variation (stddev / mean)
+            metricVolatility.textContent = summary.coefficient_of_variation_pct.toFixed(2) + '%';
+        } catch (error) {
+            console.error('Error loading metrics:', error);
+            resetMetrics();
+        }
+    }
+    
+    // Fill the metrics from the charted values, with the population standard
+    // deviation the analytics endpoint uses
+    function showSummaryMetrics(values) {
         if (values.length === 0) {
             resetMetrics();
             return;
         }
         
-        // Average value
-        const avg = values.reduce((sum, val) => sum + val, 0) / values.length;
-        
-        // Minimum and maximum values
-        const min = Math.min(...values);
-        const max = Math.max(...values);
-        
-        // Standard deviation
-        const squaredDiffs = values.map(val => Math.pow(val - avg, 2));
-        const variance = squaredDiffs.reduce((sum, val) => sum + val, 0) / values.length;
-        const std = Math.sqrt(variance);
-        
-        // Volatility (standard deviation / average * 100%)
-        const volatility = (std / avg) * 100;
+        const mean = values.reduce((sum, v) => sum + v, 0) / values.length;
+        const variance = values.reduce((sum, v) => sum + (v - mean) * (v - mean), 0) / values.length;
+        const stddev = Math.sqrt(variance);
         
-        // Adjust metrics for display based on nominal
-        const displayAvg = avg * nominal;
-        const displayStd = std * nominal;
-        const displayMin = min * nominal;
-        const displayMax = max * nominal;
-        
-        // Display metrics
-        metricAvg.textContent = displayAvg.toFixed(4) + ' ₽';
-        metricStd.textContent = displayStd.toFixed(4) + ' ₽';
-        metricMin.textContent = displayMin.toFixed(4) + ' ₽';
-        metricMax.textContent = displayMax.toFixed(4) + ' ₽';
-        metricVolatility.textContent = volatility.toFixed(2) + '%';
+        metricAvg.textContent = mean.toFixed(2) + ' ₽';
+        metricStd.textContent = stddev.toFixed(2) + ' ₽';
+        metricMin.textContent = Math.min(...values).toFixed(2) + ' ₽';
+        metricMax.textContent = Math.max(...values).toFixed(2) + ' ₽';
+        metricVolatility.textContent = (mean ? stddev / mean * 100 : 0).toFixed(2) + '%';
     }
     
     // Reset metrics
@@ -1026,45 +1121,12 @@ document.addEventListener('DOMContentLoaded', function() {
         } else if (currentDataSource === 'crypto') {
             currencyLabel.textContent = 'Select Cryptocurrency:';
             currencySelect.innerHTML = '<option value="" selected disabled>Loading cryptocurrencies...</option>';
+        } else if (currentDataSource === 'metals') {
+            currencyLabel.textContent = 'Select Metal:';
+            currencySelect.innerHTML = '<option value="" selected disabled>Loading metals...</option>';
         }
     }
     
-    // Calculate metrics for crypto data
-    function calculateCryptoMetrics(history) {
-        if (history.length === 0) {
-            resetMetrics();
-            return;
-        }
-        
-        // Extract closing prices (now in RUB)
-        const closingPrices = history.map(item => item.close);
-        
-        // Calculate basic metrics
-        const avg = closingPrices.reduce((sum, val) => sum + val, 0) / closingPrices.length;
-        const min = Math.min(...closingPrices);
-        const max = Math.max(...closingPrices);
-        
-        // Standard deviation
-        const squaredDiffs = closingPrices.map(val => Math.pow(val - avg, 2));
-        const variance = squaredDiffs.reduce((sum, val) => sum + val, 0) / closingPrices.length;
-        const std = Math.sqrt(variance);
-        
-        // Volatility (coefficient of variation as percentage)
-        const volatility = (std / avg) * 100;
-        
-        // Format price in RUB
-        const formatPrice = (price) => {
-            return price.toFixed(2);
-        };
-        
-        // Update metrics display for crypto in RUB
-        metricAvg.textContent = `${formatPrice(avg)} ₽`;
-        metricStd.textContent = `${formatPrice(std)} ₽`;
-        metricMin.textContent = `${formatPrice(min)} ₽`;
-        metricMax.textContent = `${formatPrice(max)} ₽`;
-        metricVolatility.textContent = `${volatility.toFixed(2)}%`;
-    }
-    
     // Render currency rate chart
     function renderChart(dates, values, currencyInfo) {
         // If chart already exists, destroy it
@@ -1074,10 +1136,20 @@ document.addEventListener('DOMContentLoaded', function() {
         
         // Check if this is crypto data
         const isCrypto = currencyInfo.type === 'crypto';
+        const isMetal = currencyInfo.type === 'metal';
         
         let chartLabel, displayValues, yAxisLabel, tooltipCallback;
         
-        if (isCrypto) {
+        if (isMetal) {
+            // Metal prices are per gram
+            chartLabel = `${currencyInfo.name} (${currencyInfo.code}), RUB per gram`;
+            displayValues = values;
+            yAxisLabel = 'Price per gram (RUB)';
+            
+            tooltipCallback = function(context) {
+                return `Price: ${context.raw.toFixed(2)} ₽ per gram`;
+            };
+        } else if (isCrypto) {
             // Crypto chart configuration
             chartLabel = `${currencyInfo.code} Price (RUB)`;
             displayValues = values; // Use values as-is for crypto (now in RUB)